@@ -7,12 +7,16 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // reminder time zones must resolve on hosts without zoneinfo
 
 	"github.com/rs/zerolog/log"
 
 	"github.com/example/agents-playground/internal/config"
 	"github.com/example/agents-playground/internal/http/transport"
 	"github.com/example/agents-playground/internal/logging"
+	"github.com/example/agents-playground/internal/scheduler"
+	"github.com/example/agents-playground/internal/storage"
+	"github.com/example/agents-playground/internal/storage/postgres"
 	"github.com/example/agents-playground/internal/storage/sqlite"
 )
 
@@ -20,12 +24,21 @@ func main() {
 	logging.Configure()
 	cfg := config.Load()
 
-	store, err := sqlite.Open(cfg.DatabaseDSN)
+	store, err := openStore(cfg)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to open database")
 	}
 	defer store.Close()
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if reminders, ok := store.(scheduler.Store); ok {
+		go scheduler.New(reminders, scheduler.Options{
+			Interval:   cfg.ReminderPollInterval,
+			WebhookURL: cfg.ReminderWebhookURL,
+		}).Run(ctx)
+	}
+
 	router := transport.NewRouter(cfg, store)
 
 	srv := &http.Server{
@@ -46,6 +59,31 @@ func main() {
 	waitForShutdown(srv)
 }
 
+// openStore selects the storage backend from the scheme of the DSN:
+// postgres:// and postgresql:// use PostgreSQL, anything else SQLite.
+func openStore(cfg config.Config) (storage.Store, error) {
+	revisions := storage.RevisionPolicy{
+		CoalesceWindow: cfg.RevisionCoalesceWindow,
+		MaxRevisions:   cfg.RevisionMaxCount,
+		MaxAge:         cfg.RevisionMaxAge,
+	}
+	if postgres.IsDSN(cfg.DatabaseDSN) {
+		store, err := postgres.Open(cfg.DatabaseDSN)
+		if err != nil {
+			return nil, err
+		}
+		store.SetRevisionPolicy(revisions)
+		return store, nil
+	}
+	store, err := sqlite.Open(cfg.DatabaseDSN)
+	if err != nil {
+		return nil, err
+	}
+	store.SetRevisionPolicy(revisions)
+	store.SetSchemaCacheSize(cfg.SchemaCacheSize)
+	return store, nil
+}
+
 func waitForShutdown(srv *http.Server) {
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
@@ -2,19 +2,39 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"time"
 )
 
 // Config holds runtime configuration values.
 type Config struct {
 	HTTPAddress string
 	DatabaseDSN string
+	AssetDir    string
+
+	// Page revision coalescing and retention; zero count or age keeps all.
+	RevisionCoalesceWindow time.Duration
+	RevisionMaxCount       int
+	RevisionMaxAge         time.Duration
+
+	// Number of database schemas kept in memory; zero disables the cache.
+	SchemaCacheSize int
+
+	// How often due reminders are fired, and where their events are posted;
+	// an empty URL only records inbox notifications.
+	ReminderPollInterval time.Duration
+	ReminderWebhookURL   string
 }
 
 // Load reads configuration from environment variables with defaults.
 func Load() Config {
 	cfg := Config{
-		HTTPAddress: ":8080",
-		DatabaseDSN: "file:data/app.db?_fk=1",
+		HTTPAddress:            ":8080",
+		DatabaseDSN:            "file:data/app.db?_fk=1",
+		AssetDir:               "data/assets",
+		RevisionCoalesceWindow: 5 * time.Minute,
+		SchemaCacheSize:        256,
+		ReminderPollInterval:   30 * time.Second,
 	}
 	if v := os.Getenv("HTTP_ADDRESS"); v != "" {
 		cfg.HTTPAddress = v
@@ -22,5 +42,26 @@ func Load() Config {
 	if v := os.Getenv("DATABASE_DSN"); v != "" {
 		cfg.DatabaseDSN = v
 	}
+	if v := os.Getenv("ASSET_DIR"); v != "" {
+		cfg.AssetDir = v
+	}
+	if d, err := time.ParseDuration(os.Getenv("REVISION_COALESCE_WINDOW")); err == nil && d >= 0 {
+		cfg.RevisionCoalesceWindow = d
+	}
+	if n, err := strconv.Atoi(os.Getenv("REVISION_MAX_COUNT")); err == nil && n >= 0 {
+		cfg.RevisionMaxCount = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("REVISION_MAX_AGE")); err == nil && d >= 0 {
+		cfg.RevisionMaxAge = d
+	}
+	if n, err := strconv.Atoi(os.Getenv("SCHEMA_CACHE_SIZE")); err == nil && n >= 0 {
+		cfg.SchemaCacheSize = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("REMINDER_POLL_INTERVAL")); err == nil && d > 0 {
+		cfg.ReminderPollInterval = d
+	}
+	if v := os.Getenv("REMINDER_WEBHOOK_URL"); v != "" {
+		cfg.ReminderWebhookURL = v
+	}
 	return cfg
 }
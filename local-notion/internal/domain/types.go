@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // Page represents a free-form content page.
 type Page struct {
@@ -16,23 +19,218 @@ type Page struct {
 	LinkedPageIDs     []string  `json:"linked_page_ids"`
 	BacklinkedPageIDs []string  `json:"backlinked_page_ids"`
 	IsArchived        bool      `json:"is_archived"`
+	IsTemplate        bool      `json:"is_template"`
+	Version           int       `json:"version"`
 	CreatedAt         time.Time `json:"created_at"`
 	UpdatedAt         time.Time `json:"updated_at"`
+	// CommentCounts summarises the page's discussion; it is only set when the
+	// page is read on its own.
+	CommentCounts *CommentCounts `json:"comment_counts,omitempty"`
+}
+
+// CommentCounts counts the comment threads on a page and the comments in them.
+type CommentCounts struct {
+	Threads     int `json:"threads"`
+	OpenThreads int `json:"open_threads"`
+	Comments    int `json:"comments"`
+}
+
+// PageLinks lists the pages a page links to and the pages linking to it.
+type PageLinks struct {
+	PageID   string   `json:"page_id"`
+	Outbound []string `json:"linked_page_ids"`
+	Inbound  []string `json:"backlinked_page_ids"`
+}
+
+// Tag is a page tag with the number of pages carrying it.
+type Tag struct {
+	Name      string `json:"name"`
+	PageCount int    `json:"page_count"`
+}
+
+// CommentThread is a discussion on a page. A thread with an Anchor is about a
+// range of the page content; one without is about the page as a whole.
+type CommentThread struct {
+	ID         string         `json:"id"`
+	PageID     string         `json:"page_id"`
+	Anchor     *CommentAnchor `json:"anchor,omitempty"`
+	Detached   bool           `json:"detached"`
+	Resolved   bool           `json:"resolved"`
+	ResolvedBy string         `json:"resolved_by,omitempty"`
+	ResolvedAt *time.Time     `json:"resolved_at,omitempty"`
+	CreatedBy  string         `json:"created_by"`
+	Comments   []Comment      `json:"comments"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+// CommentAnchor is a range of page content in characters (runes), with End
+// exclusive, and the text it covered when last anchored.
+type CommentAnchor struct {
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Quote string `json:"quote"`
+}
+
+// Comment is a message in a comment thread.
+type Comment struct {
+	ID        string     `json:"id"`
+	ThreadID  string     `json:"thread_id"`
+	Author    string     `json:"author"`
+	Body      string     `json:"body"`
+	Mentions  []string   `json:"mentions"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Notification tells a user about activity that concerns them. Source says
+// what kind of activity it was; the IDs point at where it happened.
+type Notification struct {
+	ID         string     `json:"id"`
+	Recipient  string     `json:"recipient"`
+	Source     string     `json:"source"` // mention, reply, assignment, item_update or reminder
+	Actor      string     `json:"actor,omitempty"`
+	PageID     string     `json:"page_id,omitempty"`
+	ThreadID   string     `json:"thread_id,omitempty"`
+	CommentID  string     `json:"comment_id,omitempty"`
+	DatabaseID string     `json:"database_id,omitempty"`
+	ItemID     string     `json:"item_id,omitempty"`
+	Excerpt    string     `json:"excerpt"`
+	ReadAt     *time.Time `json:"read_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// RecurrenceSeries regenerates an item on the dates of an RRULE. Each
+// occurrence is an item copied from the one before it, created when that one
+// is completed or when its date arrives, depending on Generate.
+type RecurrenceSeries struct {
+	ID                 string                 `json:"id"`
+	DatabaseID         string                 `json:"database_id"`
+	PropertyID         string                 `json:"property_id"`
+	Rule               string                 `json:"rule"`
+	Exceptions         []string               `json:"exceptions"` // skipped dates, YYYY-MM-DD in TimeZone
+	Generate           string                 `json:"generate"`   // on_complete or scheduled
+	CompletePropertyID string                 `json:"complete_property_id,omitempty"`
+	TimeZone           string                 `json:"time_zone"`
+	StartsAt           time.Time              `json:"starts_at"`
+	PreviousSeriesID   string                 `json:"previous_series_id,omitempty"`
+	Ended              bool                   `json:"ended"`
+	Next               *time.Time             `json:"next,omitempty"` // date of the occurrence created next
+	Occurrences        []RecurrenceOccurrence `json:"occurrences"`
+	CreatedAt          time.Time              `json:"created_at"`
+	UpdatedAt          time.Time              `json:"updated_at"`
+}
+
+// RecurrenceOccurrence is an item of a series. Index counts occurrences of
+// the rule from the series start, OccursAt is the rule date the item was
+// created for; the item's own date may have been moved since.
+type RecurrenceOccurrence struct {
+	ItemID   string    `json:"item_id"`
+	PageID   string    `json:"page_id"`
+	Title    string    `json:"title"`
+	Index    int       `json:"index"`
+	OccursAt time.Time `json:"occurs_at"`
+}
+
+// Reminder is a scheduled alert for the date an item holds in a date
+// property. FireAt is DueAt moved back by the property's reminder setting.
+type Reminder struct {
+	ID         string     `json:"id"`
+	DatabaseID string     `json:"database_id"`
+	ItemID     string     `json:"item_id"`
+	PageID     string     `json:"page_id"`
+	PropertyID string     `json:"property_id"`
+	Title      string     `json:"title"`
+	DueAt      time.Time  `json:"due_at"`
+	FireAt     time.Time  `json:"fire_at"`
+	TimeZone   string     `json:"time_zone"`
+	FiredAt    *time.Time `json:"fired_at,omitempty"`
+	// DeliveredAt is when the webhook event of a fired reminder was
+	// accepted; DeliveryAttempts counts the posts made so far.
+	DeliveredAt      *time.Time `json:"delivered_at,omitempty"`
+	DeliveryAttempts int        `json:"delivery_attempts"`
+}
+
+// NotificationPreferences lists the notification sources a user has muted.
+type NotificationPreferences struct {
+	Recipient string   `json:"recipient"`
+	Muted     []string `json:"muted"`
+}
+
+// PageRevision is a snapshot of a page's title, content, tags and links taken
+// after a change.
+type PageRevision struct {
+	PageID        string    `json:"page_id"`
+	Revision      int       `json:"revision"`
+	Action        string    `json:"action"` // create, update or restore
+	Title         string    `json:"title"`
+	Content       string    `json:"content"`
+	Tags          []string  `json:"tags"`
+	LinkedPageIDs []string  `json:"linked_page_ids"`
+	Author        string    `json:"author,omitempty"`
+	RestoredFrom  *int      `json:"restored_from,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// PageDiff compares two revisions of a page. Lines is a line-level diff of
+// the content.
+type PageDiff struct {
+	PageID       string      `json:"page_id"`
+	From         int         `json:"from"`
+	To           int         `json:"to"`
+	Title        *TextChange `json:"title,omitempty"`
+	TagsAdded    []string    `json:"tags_added,omitempty"`
+	TagsRemoved  []string    `json:"tags_removed,omitempty"`
+	LinksAdded   []string    `json:"links_added,omitempty"`
+	LinksRemoved []string    `json:"links_removed,omitempty"`
+	Lines        []DiffLine  `json:"lines"`
+}
+
+// TextChange is a changed single-line field.
+type TextChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// DiffLine is one line of a diff. OldLine and NewLine are 1-based and zero
+// when the line does not exist on that side.
+type DiffLine struct {
+	Op      string `json:"op"` // equal, insert or delete
+	Text    string `json:"text"`
+	OldLine int    `json:"old_line,omitempty"`
+	NewLine int    `json:"new_line,omitempty"`
+}
+
+// AuditEntry records one mutation of a stored entity. Before and After are
+// JSON snapshots of the entity and null when it did not exist on that side.
+type AuditEntry struct {
+	ID         int64           `json:"id"`
+	Actor      string          `json:"actor"`
+	RequestID  string          `json:"request_id"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	CreatedAt  time.Time       `json:"created_at"`
 }
 
 // Database represents a structured collection of page-backed items.
 type Database struct {
-	ID          string             `json:"id"`
-	Slug        string             `json:"slug"`
-	Title       string             `json:"title"`
-	Description string             `json:"description"`
-	Icon        *string            `json:"icon"`
-	CoverImage  *string            `json:"cover_image_id"`
-	IsArchived  bool               `json:"is_archived"`
-	CreatedAt   time.Time          `json:"created_at"`
-	UpdatedAt   time.Time          `json:"updated_at"`
-	Properties  []DatabaseProperty `json:"properties"`
-	Views       []DatabaseView     `json:"views"`
+	ID            string                 `json:"id"`
+	Slug          string                 `json:"slug"`
+	Title         string                 `json:"title"`
+	Description   string                 `json:"description"`
+	Icon          *string                `json:"icon"`
+	CoverImage    *string                `json:"cover_image_id"`
+	IsArchived    bool                   `json:"is_archived"`
+	IsTemplate    bool                   `json:"is_template"`
+	Version       int                    `json:"version"`
+	CreatedAt     time.Time              `json:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+	Properties    []DatabaseProperty     `json:"properties"`
+	Views         []DatabaseView         `json:"views"`
+	ItemTemplates []DatabaseItemTemplate `json:"item_templates,omitempty"`
 }
 
 // DatabaseProperty defines a field for items in a database.
@@ -67,6 +265,9 @@ const (
 	PropertyTypeMedia       PropertyType = "media"
 	PropertyTypeFormula     PropertyType = "formula"
 	PropertyTypeRollup      PropertyType = "rollup"
+	// PropertyTypePerson holds the names of the users an item is assigned
+	// to, as a string or a list of strings.
+	PropertyTypePerson PropertyType = "person"
 )
 
 // DatabaseView configures a saved layout for rendering items.
@@ -80,10 +281,133 @@ type DatabaseView struct {
 	Grouping      map[string]any `json:"grouping"`
 	Display       []string       `json:"display_properties"`
 	LayoutOptions map[string]any `json:"layout_options"`
+	Version       int            `json:"version"`
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 }
 
+// DatabaseViewVersion is a snapshot of a view recorded after each change.
+// View is nil for the version that deleted the view.
+type DatabaseViewVersion struct {
+	ViewID       string        `json:"view_id"`
+	DatabaseID   string        `json:"database_id"`
+	Version      int           `json:"version"`
+	Action       string        `json:"action"` // create, update, delete or restore
+	View         *DatabaseView `json:"view"`
+	RestoredFrom *int          `json:"restored_from,omitempty"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+// ViewResult is the filtered item list of a view together with its summary.
+type ViewResult struct {
+	View    DatabaseView   `json:"view"`
+	Items   []DatabaseItem `json:"items"`
+	Summary *ViewSummary   `json:"summary,omitempty"`
+}
+
+// ViewSummary holds the footer aggregates of a view and, for grouped views,
+// the aggregates of every group.
+type ViewSummary struct {
+	Count      int             `json:"count"`
+	Aggregates []ViewAggregate `json:"aggregates,omitempty"`
+	Groups     []ViewGroup     `json:"groups,omitempty"`
+}
+
+// ViewAggregate is the computed value of one aggregation over a property.
+type ViewAggregate struct {
+	PropertyID string `json:"property_id"`
+	Function   string `json:"function"`
+	Value      any    `json:"value"`
+}
+
+// ViewGroup summarizes the items sharing one value of the grouping property.
+// Key is nil for items without a value.
+type ViewGroup struct {
+	Key        any             `json:"key"`
+	Count      int             `json:"count"`
+	Aggregates []ViewAggregate `json:"aggregates,omitempty"`
+}
+
+// PivotHeader labels one row or column of a pivot. Key is the raw dimension
+// value (bucket start date for dates) and is empty for items without a value.
+type PivotHeader struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+}
+
+// PivotResult is a chart-ready matrix: Values[r][c] holds the measure for
+// Rows[r] and Columns[c]. Cells are null when an average has no numbers.
+type PivotResult struct {
+	Measure           string        `json:"measure"`
+	MeasurePropertyID string        `json:"measure_property_id,omitempty"`
+	Rows              []PivotHeader `json:"rows"`
+	Columns           []PivotHeader `json:"columns"`
+	Values            [][]*float64  `json:"values"`
+	RowTotals         []*float64    `json:"row_totals"`
+	ColumnTotals      []*float64    `json:"column_totals"`
+	Total             *float64      `json:"total"`
+}
+
+// ImportResult reports the outcome, or for dry runs the expected outcome, of
+// a CSV import. Rows are numbered from 1, excluding the header.
+type ImportResult struct {
+	DryRun         bool                `json:"dry_run"`
+	Committed      bool                `json:"committed"`
+	TotalRows      int                 `json:"total_rows"`
+	Valid          int                 `json:"valid_rows"`
+	Imported       int                 `json:"imported"`
+	Errors         []ImportRowError    `json:"errors,omitempty"`
+	IgnoredColumns []string            `json:"ignored_columns,omitempty"`
+	NewOptions     map[string][]string `json:"new_options,omitempty"`
+	Preview        []ImportPreviewRow  `json:"preview,omitempty"`
+	ItemIDs        []string            `json:"item_ids,omitempty"`
+}
+
+// ImportRowError describes why a CSV cell or row could not be imported.
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportPreviewRow is a coerced CSV row as it would be stored.
+type ImportPreviewRow struct {
+	Row    int            `json:"row"`
+	Title  string         `json:"title"`
+	Values map[string]any `json:"values"`
+}
+
+// NotionImportReport summarizes a Notion export import. Issues lists
+// everything that could not be mapped; the rest of the archive is still
+// imported.
+type NotionImportReport struct {
+	PagesCreated     int           `json:"pages_created"`
+	PagesUpdated     int           `json:"pages_updated"`
+	DatabasesCreated int           `json:"databases_created"`
+	DatabasesUpdated int           `json:"databases_updated"`
+	ItemsCreated     int           `json:"items_created"`
+	ItemsUpdated     int           `json:"items_updated"`
+	Assets           int           `json:"assets"`
+	Links            int           `json:"links"`
+	Issues           []ImportIssue `json:"issues,omitempty"`
+}
+
+// ImportIssue is a file or value of an import that could not be mapped.
+type ImportIssue struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Asset is an uploaded or imported file stored outside the database.
+type Asset struct {
+	ID          string    `json:"id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	SizeBytes   int64     `json:"size_bytes"`
+	StoragePath string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
 // ViewType enumerates supported database view renderers.
 type ViewType string
 
@@ -108,7 +432,9 @@ type DatabaseItem struct {
 	DatabaseID  string                   `json:"database_id"`
 	Page        Page                     `json:"page"`
 	Position    int                      `json:"position"`
+	Rank        string                   `json:"rank"`
 	IsArchived  bool                     `json:"is_archived"`
+	Version     int                      `json:"version"`
 	CreatedAt   time.Time                `json:"created_at"`
 	UpdatedAt   time.Time                `json:"updated_at"`
 	PropertyMap map[string]DatabaseValue `json:"properties"`
@@ -124,3 +450,44 @@ type DatabaseValue struct {
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
 }
+
+// DatabaseItemTemplate prefills the page and property values of new items.
+type DatabaseItemTemplate struct {
+	ID         string         `json:"id"`
+	DatabaseID string         `json:"database_id"`
+	Name       string         `json:"name"`
+	Title      string         `json:"title"`
+	Summary    string         `json:"summary"`
+	Content    string         `json:"content"`
+	Tags       []string       `json:"tags"`
+	Values     map[string]any `json:"values"` // keyed by property slug
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+// BulkOperationResult reports the outcome of a single operation of a bulk request.
+type BulkOperationResult struct {
+	Index  int                  `json:"index"`
+	Op     string               `json:"op"`
+	ItemID string               `json:"item_id,omitempty"`
+	Status string               `json:"status"` // ok, error, skipped or rolled_back
+	Errors []BulkOperationError `json:"errors,omitempty"`
+	Item   *DatabaseItem        `json:"item,omitempty"`
+}
+
+// BulkOperationError describes why an operation of a bulk request failed, in
+// the shape of an API error: Field names the offending input field, if any.
+type BulkOperationError struct {
+	Code    string `json:"code,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// BulkResult summarizes a bulk request and whether its changes were committed.
+type BulkResult struct {
+	Committed bool                  `json:"committed"`
+	Replayed  bool                  `json:"replayed"`
+	Succeeded int                   `json:"succeeded"`
+	Failed    int                   `json:"failed"`
+	Results   []BulkOperationResult `json:"results"`
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// AssetHandler serves stored files.
+type AssetHandler struct {
+	store storage.Store
+}
+
+// NewAssetHandler constructs handler.
+func NewAssetHandler(store storage.Store) *AssetHandler {
+	return &AssetHandler{store: store}
+}
+
+// GetAsset handles GET /api/assets/{id}, streaming the stored file.
+func (h *AssetHandler) GetAsset(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[assetStore](w, h.store)
+	if !ok {
+		return
+	}
+	asset, err := store.GetAsset(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", asset.ContentType)
+	http.ServeFile(w, r, asset.StoragePath)
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog/log"
+
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// AuditContext attributes the store mutations of a request to the caller in
+// the X-Actor header and to the request ID set by middleware.RequestID.
+func AuditContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := storage.WithAuditInfo(r.Context(), storage.AuditInfo{
+			Actor:     requestActor(r),
+			RequestID: middleware.GetReqID(r.Context()),
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// AuditHandler exposes the audit log.
+type AuditHandler struct {
+	store storage.Store
+}
+
+// NewAuditHandler constructs handler.
+func NewAuditHandler(store storage.Store) *AuditHandler {
+	return &AuditHandler{store: store}
+}
+
+// ListAudit handles GET /api/audit. Entries are returned newest first; meta
+// carries the cursor of the next page.
+func (h *AuditHandler) ListAudit(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[auditStore](w, h.store)
+	if !ok {
+		return
+	}
+	filter, err := auditFilter(r.URL.Query())
+	if err != nil {
+		respondInvalidRequest(w, err.Error())
+		return
+	}
+	entries, next, err := store.ListAuditEntries(r.Context(), filter)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, Envelope{Data: entries, Meta: map[string]any{"next_cursor": next}})
+}
+
+// ExportAudit handles GET /api/audit/export, streaming every matching entry as
+// JSON Lines, oldest first.
+func (h *AuditHandler) ExportAudit(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[auditStore](w, h.store)
+	if !ok {
+		return
+	}
+	filter, err := auditFilter(r.URL.Query())
+	if err != nil {
+		respondInvalidRequest(w, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit.jsonl"`)
+	w.WriteHeader(http.StatusOK)
+	// The status is already sent, so a failure can only truncate the export.
+	if err := store.ExportAuditLog(r.Context(), filter, w); err != nil {
+		log.Error().Err(err).Msg("audit_export_failed")
+	}
+}
+
+func auditFilter(query url.Values) (storage.AuditFilter, error) {
+	filter := storage.AuditFilter{
+		Actor:      query.Get("actor"),
+		RequestID:  query.Get("request_id"),
+		Action:     query.Get("action"),
+		EntityType: query.Get("entity_type"),
+		EntityID:   query.Get("entity_id"),
+		Cursor:     query.Get("cursor"),
+	}
+	for _, bound := range []struct {
+		name string
+		dst  **time.Time
+	}{{"since", &filter.Since}, {"until", &filter.Until}} {
+		v := query.Get(bound.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, errors.New(bound.name + " must be an RFC 3339 timestamp")
+		}
+		*bound.dst = &t
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return filter, errors.New("limit must be a positive integer")
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
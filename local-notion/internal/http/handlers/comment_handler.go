@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// CommentHandler manages discussion threads on pages. Comments are written
+// as the caller named in the X-Actor header, which is required.
+type CommentHandler struct {
+	store storage.Store
+}
+
+// NewCommentHandler constructs handler.
+func NewCommentHandler(store storage.Store) *CommentHandler {
+	return &CommentHandler{store: store}
+}
+
+// CommentAnchorRequest selects a range of page content in characters, end
+// exclusive.
+type CommentAnchorRequest struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// CreateCommentThreadRequest is the payload for POST /api/pages/{id}/threads.
+type CreateCommentThreadRequest struct {
+	Body   string                `json:"body"`
+	Anchor *CommentAnchorRequest `json:"anchor"`
+}
+
+// CommentRequest is the payload for adding or editing a comment.
+type CommentRequest struct {
+	Body string `json:"body"`
+}
+
+// ListCommentThreads handles GET /api/pages/{id}/threads. The status query
+// parameter narrows the list to open or resolved threads.
+func (h *CommentHandler) ListCommentThreads(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[commentStore](w, h.store)
+	if !ok {
+		return
+	}
+	threads, err := store.ListCommentThreads(r.Context(), chi.URLParam(r, "id"), r.URL.Query().Get("status"))
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, Envelope{Data: threads})
+}
+
+// GetCommentThread handles GET /api/pages/{id}/threads/{threadID}.
+func (h *CommentHandler) GetCommentThread(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[commentStore](w, h.store)
+	if !ok {
+		return
+	}
+	thread, err := store.GetCommentThread(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "threadID"))
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, Envelope{Data: thread})
+}
+
+// CreateCommentThread handles POST /api/pages/{id}/threads, starting a thread
+// on the page or on a range of its content.
+func (h *CommentHandler) CreateCommentThread(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[commentStore](w, h.store)
+	if !ok {
+		return
+	}
+	var req CreateCommentThreadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidRequest(w, "invalid request body")
+		return
+	}
+	in := storage.CreateCommentThreadInput{
+		PageID: chi.URLParam(r, "id"),
+		Author: requestActor(r),
+		Body:   req.Body,
+	}
+	if req.Anchor != nil {
+		in.Anchor = &storage.CommentAnchorInput{Start: req.Anchor.Start, End: req.Anchor.End}
+	}
+	thread, err := store.CreateCommentThread(r.Context(), in)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, Envelope{Data: thread})
+}
+
+// ReplyToCommentThread handles POST /api/pages/{id}/threads/{threadID}/comments,
+// returning the thread with the reply.
+func (h *CommentHandler) ReplyToCommentThread(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[commentStore](w, h.store)
+	if !ok {
+		return
+	}
+	var req CommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidRequest(w, "invalid request body")
+		return
+	}
+	thread, err := store.ReplyToCommentThread(r.Context(), storage.ReplyToCommentThreadInput{
+		PageID:   chi.URLParam(r, "id"),
+		ThreadID: chi.URLParam(r, "threadID"),
+		Author:   requestActor(r),
+		Body:     req.Body,
+	})
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, Envelope{Data: thread})
+}
+
+// ResolveCommentThread handles POST /api/pages/{id}/threads/{threadID}/resolve.
+func (h *CommentHandler) ResolveCommentThread(w http.ResponseWriter, r *http.Request) {
+	h.setResolved(w, r, true)
+}
+
+// ReopenCommentThread handles POST /api/pages/{id}/threads/{threadID}/reopen.
+func (h *CommentHandler) ReopenCommentThread(w http.ResponseWriter, r *http.Request) {
+	h.setResolved(w, r, false)
+}
+
+func (h *CommentHandler) setResolved(w http.ResponseWriter, r *http.Request, resolved bool) {
+	store, ok := supports[commentStore](w, h.store)
+	if !ok {
+		return
+	}
+	thread, err := store.ResolveCommentThread(r.Context(), storage.ResolveCommentThreadInput{
+		PageID:   chi.URLParam(r, "id"),
+		ThreadID: chi.URLParam(r, "threadID"),
+		Author:   requestActor(r),
+		Resolved: resolved,
+	})
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, Envelope{Data: thread})
+}
+
+// UpdateComment handles PATCH /api/pages/{id}/threads/{threadID}/comments/{commentID}.
+// Only the comment's author may edit it.
+func (h *CommentHandler) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[commentStore](w, h.store)
+	if !ok {
+		return
+	}
+	var req CommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidRequest(w, "invalid request body")
+		return
+	}
+	comment, err := store.UpdateComment(r.Context(), storage.UpdateCommentInput{
+		PageID:    chi.URLParam(r, "id"),
+		ThreadID:  chi.URLParam(r, "threadID"),
+		CommentID: chi.URLParam(r, "commentID"),
+		Author:    requestActor(r),
+		Body:      req.Body,
+	})
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, Envelope{Data: comment})
+}
+
+// DeleteComment handles DELETE /api/pages/{id}/threads/{threadID}/comments/{commentID}.
+// Only the comment's author may delete it; deleting the last comment deletes
+// the thread.
+func (h *CommentHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[commentStore](w, h.store)
+	if !ok {
+		return
+	}
+	err := store.DeleteComment(r.Context(), storage.DeleteCommentInput{
+		PageID:    chi.URLParam(r, "id"),
+		ThreadID:  chi.URLParam(r, "threadID"),
+		CommentID: chi.URLParam(r, "commentID"),
+		Author:    requestActor(r),
+	})
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+	"github.com/example/agents-playground/internal/storage/memory"
+)
+
+func TestCommentHandlerThreads(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	page, err := store.CreatePage(context.Background(), storage.CreatePageInput{Slug: "spec", Title: "Spec", Content: "Ship it on Friday."})
+	require.NoError(t, err)
+	commentHandler := NewCommentHandler(store)
+
+	request := func(method, body, actor string, params ...string) *http.Request {
+		req := httptest.NewRequest(method, "/", strings.NewReader(body))
+		if actor != "" {
+			req.Header.Set("X-Actor", actor)
+		}
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", page.ID)
+		for i := 0; i+1 < len(params); i += 2 {
+			rctx.URLParams.Add(params[i], params[i+1])
+		}
+		return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	}
+
+	rec := httptest.NewRecorder()
+	commentHandler.CreateCommentThread(rec, request(http.MethodPost, `{"body":"Friday? @bo","anchor":{"start":11,"end":17}}`, ""))
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = httptest.NewRecorder()
+	commentHandler.CreateCommentThread(rec, request(http.MethodPost, `{"body":"Friday? @bo","anchor":{"start":11,"end":17}}`, "ana"))
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var env responseEnvelope
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&env))
+	var thread domain.CommentThread
+	require.NoError(t, json.Unmarshal(env.Data, &thread))
+	require.Equal(t, "Friday", thread.Anchor.Quote)
+	require.Equal(t, []string{"bo"}, thread.Comments[0].Mentions)
+	commentID := thread.Comments[0].ID
+
+	rec = httptest.NewRecorder()
+	commentHandler.UpdateComment(rec, request(http.MethodPatch, `{"body":"Mine now"}`, "bo", "threadID", thread.ID, "commentID", commentID))
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = httptest.NewRecorder()
+	commentHandler.ResolveCommentThread(rec, request(http.MethodPost, "", "bo", "threadID", thread.ID))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	commentHandler.ListCommentThreads(rec, httptest.NewRequest(http.MethodGet, "/?status=open", nil).WithContext(request(http.MethodGet, "", "").Context()))
+	require.Equal(t, http.StatusOK, rec.Code)
+	env = responseEnvelope{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&env))
+	require.JSONEq(t, `[]`, string(env.Data))
+
+	rec = httptest.NewRecorder()
+	commentHandler.DeleteComment(rec, request(http.MethodDelete, "", "ana", "threadID", thread.ID, "commentID", commentID))
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = httptest.NewRecorder()
+	commentHandler.GetCommentThread(rec, request(http.MethodGet, "", "", "threadID", thread.ID))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestCommentHandlerNotImplemented(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewCommentHandler(memory.New()).ListCommentThreads(rec, httptest.NewRequest(http.MethodGet, "/api/pages/p/threads", nil))
+	require.Equal(t, http.StatusNotImplemented, rec.Code)
+}
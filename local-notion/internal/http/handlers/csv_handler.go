@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// ImportCSVRequest is the payload for POST /api/databases/{id}/import.
+// Mapping sends CSV headers to property slugs; omit it to match headers to
+// property slugs or names. CreateOptions defaults to true.
+type ImportCSVRequest struct {
+	CSV             string            `json:"csv"`
+	Mapping         map[string]string `json:"mapping"`
+	TitleColumn     string            `json:"title_column"`
+	DryRun          bool              `json:"dry_run"`
+	CreateOptions   *bool             `json:"create_options"`
+	SkipInvalidRows bool              `json:"skip_invalid_rows"`
+}
+
+// ExportViewCSV handles GET /api/databases/{id}/views/{viewID}/export.csv.
+func (h *DatabaseHandler) ExportViewCSV(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[csvStore](w, h.store)
+	if !ok {
+		return
+	}
+	databaseID, viewID := viewRouteParams(r)
+	var buf bytes.Buffer
+	if err := store.ExportViewCSV(r.Context(), databaseID, viewID, &buf); err != nil {
+		respondError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", viewID+".csv"))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// ImportCSV handles POST /api/databases/{id}/import. Imports rejected because
+// of row errors answer 422 with the report so the caller can fix the rows.
+func (h *DatabaseHandler) ImportCSV(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[csvStore](w, h.store)
+	if !ok {
+		return
+	}
+	var req ImportCSVRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidRequest(w, "invalid request body")
+		return
+	}
+	createOptions := req.CreateOptions == nil || *req.CreateOptions
+	result, err := store.ImportCSV(r.Context(), storage.ImportCSVInput{
+		DatabaseID:      chi.URLParam(r, "id"),
+		Reader:          strings.NewReader(req.CSV),
+		Mapping:         req.Mapping,
+		TitleColumn:     req.TitleColumn,
+		DryRun:          req.DryRun,
+		CreateOptions:   createOptions,
+		SkipInvalidRows: req.SkipInvalidRows,
+	})
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	switch {
+	case result.DryRun:
+		respondJSON(w, http.StatusOK, Envelope{Data: result})
+	case !result.Committed:
+		errs := make([]APIError, 0, len(result.Errors))
+		for _, rowErr := range result.Errors {
+			errs = append(errs, APIError{Message: fmt.Sprintf("row %d: %s", rowErr.Row, rowErr.Message)})
+		}
+		respondJSON(w, http.StatusUnprocessableEntity, Envelope{Data: result, Errors: errs})
+	default:
+		respondJSON(w, http.StatusCreated, Envelope{Data: result})
+	}
+}
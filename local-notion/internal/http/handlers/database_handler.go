@@ -2,22 +2,21 @@ package handlers
 
 import (
 	"encoding/json"
-	"errors"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 
 	"github.com/example/agents-playground/internal/domain"
-	"github.com/example/agents-playground/internal/storage/sqlite"
+	"github.com/example/agents-playground/internal/storage"
 )
 
 // DatabaseHandler manages database endpoints.
 type DatabaseHandler struct {
-	store *sqlite.Store
+	store storage.Store
 }
 
 // NewDatabaseHandler creates a database handler.
-func NewDatabaseHandler(store *sqlite.Store) *DatabaseHandler {
+func NewDatabaseHandler(store storage.Store) *DatabaseHandler {
 	return &DatabaseHandler{store: store}
 }
 
@@ -28,6 +27,7 @@ type CreateDatabaseRequest struct {
 	Description string                    `json:"description"`
 	Icon        *string                   `json:"icon"`
 	CoverImage  *string                   `json:"cover_image_id"`
+	IsTemplate  bool                      `json:"is_template"`
 	Properties  []DatabasePropertyRequest `json:"properties"`
 	Views       []DatabaseViewRequest     `json:"views"`
 }
@@ -56,18 +56,19 @@ type DatabaseViewRequest struct {
 func (h *DatabaseHandler) CreateDatabase(w http.ResponseWriter, r *http.Request) {
 	var req CreateDatabaseRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondJSON(w, http.StatusBadRequest, Envelope{Errors: []APIError{{Message: "invalid request body"}}})
+		respondInvalidRequest(w, "invalid request body")
 		return
 	}
-	input := sqlite.CreateDatabaseInput{
+	input := storage.CreateDatabaseInput{
 		Slug:        req.Slug,
 		Title:       req.Title,
 		Description: req.Description,
 		Icon:        req.Icon,
 		CoverImage:  req.CoverImage,
+		IsTemplate:  req.IsTemplate,
 	}
 	for _, prop := range req.Properties {
-		input.Properties = append(input.Properties, sqlite.DatabasePropertyInput{
+		input.Properties = append(input.Properties, storage.DatabasePropertyInput{
 			Name:       prop.Name,
 			Slug:       prop.Slug,
 			Type:       prop.Type,
@@ -78,7 +79,7 @@ func (h *DatabaseHandler) CreateDatabase(w http.ResponseWriter, r *http.Request)
 		})
 	}
 	for _, view := range req.Views {
-		input.Views = append(input.Views, sqlite.DatabaseViewInput{
+		input.Views = append(input.Views, storage.DatabaseViewInput{
 			Name:          view.Name,
 			Type:          view.Type,
 			Filters:       view.Filters,
@@ -90,7 +91,7 @@ func (h *DatabaseHandler) CreateDatabase(w http.ResponseWriter, r *http.Request)
 	}
 	database, err := h.store.CreateDatabase(r.Context(), input)
 	if err != nil {
-		respondJSON(w, http.StatusBadRequest, Envelope{Errors: []APIError{{Message: err.Error()}}})
+		respondError(w, r, err)
 		return
 	}
 	respondJSON(w, http.StatusCreated, Envelope{Data: database})
@@ -101,14 +102,10 @@ func (h *DatabaseHandler) GetDatabase(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	database, err := h.store.GetDatabase(r.Context(), id)
 	if err != nil {
-		respondJSON(w, http.StatusInternalServerError, Envelope{Errors: []APIError{{Message: err.Error()}}})
+		respondError(w, r, err)
 		return
 	}
-	if database == nil {
-		respondJSON(w, http.StatusNotFound, Envelope{Errors: []APIError{{Message: "database not found"}}})
-		return
-	}
-	respondJSON(w, http.StatusOK, Envelope{Data: database})
+	respondVersioned(w, http.StatusOK, database.Version, database)
 }
 
 // CreateItemRequest handles POST /api/databases/{id}/items.
@@ -120,8 +117,9 @@ type CreateItemRequest struct {
 		Content string   `json:"content"`
 		Tags    []string `json:"tags"`
 	} `json:"page"`
-	Position int            `json:"position"`
-	Values   map[string]any `json:"values"`
+	Position   int            `json:"position"`
+	Values     map[string]any `json:"values"`
+	TemplateID string         `json:"template_id"`
 }
 
 // CreateItem creates a new database item.
@@ -129,12 +127,13 @@ func (h *DatabaseHandler) CreateItem(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	var req CreateItemRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondJSON(w, http.StatusBadRequest, Envelope{Errors: []APIError{{Message: "invalid request body"}}})
+		respondInvalidRequest(w, "invalid request body")
 		return
 	}
-	item, err := h.store.CreateDatabaseItem(r.Context(), sqlite.CreateDatabaseItemInput{
+	item, err := h.store.CreateDatabaseItem(r.Context(), storage.CreateDatabaseItemInput{
 		DatabaseID: id,
-		Page: sqlite.CreatePageInput{
+		TemplateID: req.TemplateID,
+		Page: storage.CreatePageInput{
 			Slug:    req.Page.Slug,
 			Title:   req.Page.Title,
 			Summary: req.Page.Summary,
@@ -145,7 +144,7 @@ func (h *DatabaseHandler) CreateItem(w http.ResponseWriter, r *http.Request) {
 		Values:   req.Values,
 	})
 	if err != nil {
-		respondJSON(w, http.StatusBadRequest, Envelope{Errors: []APIError{{Message: err.Error()}}})
+		respondError(w, r, err)
 		return
 	}
 	respondJSON(w, http.StatusCreated, Envelope{Data: item})
@@ -155,14 +154,342 @@ func (h *DatabaseHandler) CreateItem(w http.ResponseWriter, r *http.Request) {
 func (h *DatabaseHandler) ListViewItems(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	viewID := chi.URLParam(r, "viewID")
-	items, err := h.store.ListViewItems(r.Context(), id, viewID)
+	result, err := h.store.QueryView(r.Context(), id, viewID)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	env := Envelope{Data: result.Items}
+	if result.Summary != nil {
+		env.Meta = result.Summary
+	}
+	respondJSON(w, http.StatusOK, env)
+}
+
+// ListDatabaseTemplates handles GET /api/databases/templates.
+func (h *DatabaseHandler) ListDatabaseTemplates(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[databaseTemplateStore](w, h.store)
+	if !ok {
+		return
+	}
+	databases, err := store.ListDatabaseTemplates(r.Context())
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, Envelope{Data: databases})
+}
+
+// InstantiateDatabaseRequest is the payload for POST /api/databases/{id}/instantiate.
+type InstantiateDatabaseRequest struct {
+	Slug         string            `json:"slug"`
+	Title        string            `json:"title"`
+	IncludeItems bool              `json:"include_items"`
+	Variables    map[string]string `json:"variables"`
+}
+
+// InstantiateDatabaseTemplate creates a database from a template database.
+func (h *DatabaseHandler) InstantiateDatabaseTemplate(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[databaseTemplateStore](w, h.store)
+	if !ok {
+		return
+	}
+	id := chi.URLParam(r, "id")
+	var req InstantiateDatabaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidRequest(w, "invalid request body")
+		return
+	}
+	database, err := store.InstantiateDatabaseTemplate(r.Context(), storage.InstantiateDatabaseTemplateInput{
+		TemplateID:   id,
+		Slug:         req.Slug,
+		Title:        req.Title,
+		IncludeItems: req.IncludeItems,
+		Variables:    req.Variables,
+	})
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, Envelope{Data: database})
+}
+
+// CreateItemTemplateRequest is the payload for POST /api/databases/{id}/item-templates.
+type CreateItemTemplateRequest struct {
+	Name    string         `json:"name"`
+	Title   string         `json:"title"`
+	Summary string         `json:"summary"`
+	Content string         `json:"content"`
+	Tags    []string       `json:"tags"`
+	Values  map[string]any `json:"values"`
+}
+
+// CreateItemTemplate stores an item template for a database.
+func (h *DatabaseHandler) CreateItemTemplate(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[databaseTemplateStore](w, h.store)
+	if !ok {
+		return
+	}
+	id := chi.URLParam(r, "id")
+	var req CreateItemTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidRequest(w, "invalid request body")
+		return
+	}
+	tpl, err := store.CreateItemTemplate(r.Context(), storage.CreateItemTemplateInput{
+		DatabaseID: id,
+		Name:       req.Name,
+		Title:      req.Title,
+		Summary:    req.Summary,
+		Content:    req.Content,
+		Tags:       req.Tags,
+		Values:     req.Values,
+	})
 	if err != nil {
-		if errors.Is(err, sqlite.ErrViewNotFound) {
-			respondJSON(w, http.StatusNotFound, Envelope{Errors: []APIError{{Message: err.Error()}}})
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, Envelope{Data: tpl})
+}
+
+// DuplicateDatabaseRequest is the payload for POST /api/databases/{id}/duplicate.
+type DuplicateDatabaseRequest struct {
+	Slug  string `json:"slug"`
+	Title string `json:"title"`
+}
+
+// DuplicateDatabase copies a database with its schema, items and item pages.
+func (h *DatabaseHandler) DuplicateDatabase(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[duplicateStore](w, h.store)
+	if !ok {
+		return
+	}
+	id := chi.URLParam(r, "id")
+	var req DuplicateDatabaseRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondInvalidRequest(w, "invalid request body")
 			return
 		}
-		respondJSON(w, http.StatusInternalServerError, Envelope{Errors: []APIError{{Message: err.Error()}}})
+	}
+	database, err := store.DuplicateDatabase(r.Context(), storage.DuplicateDatabaseInput{
+		DatabaseID: id,
+		Slug:       req.Slug,
+		Title:      req.Title,
+	})
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, Envelope{Data: database})
+}
+
+// BulkItemsRequest is the payload for POST /api/databases/{id}/items/bulk.
+type BulkItemsRequest struct {
+	IdempotencyKey string                 `json:"idempotency_key"`
+	Mode           string                 `json:"mode"` // atomic (default) or continue_on_error
+	Operations     []BulkItemOperationDTO `json:"operations"`
+}
+
+// BulkItemOperationDTO is a single operation inside a bulk request.
+type BulkItemOperationDTO struct {
+	Op         string         `json:"op"`
+	ItemID     string         `json:"item_id"`
+	TemplateID string         `json:"template_id"`
+	Page       BulkItemPage   `json:"page"`
+	Values     map[string]any `json:"values"`
+	Position   *int           `json:"position"`
+	BeforeID   string         `json:"before_id"`
+	AfterID    string         `json:"after_id"`
+	IsArchived *bool          `json:"is_archived"`
+	Version    *int           `json:"version"` // expected item version of an update
+}
+
+// BulkItemPage carries page fields; omitted fields are left unchanged on update.
+type BulkItemPage struct {
+	Slug    string   `json:"slug"`
+	Title   *string  `json:"title"`
+	Summary *string  `json:"summary"`
+	Content *string  `json:"content"`
+	Tags    []string `json:"tags"`
+}
+
+// BulkItems applies create/update/delete/move operations to items in one transaction.
+func (h *DatabaseHandler) BulkItems(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[bulkItemStore](w, h.store)
+	if !ok {
+		return
+	}
+	id := chi.URLParam(r, "id")
+	var req BulkItemsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidRequest(w, "invalid request body")
+		return
+	}
+	key := r.Header.Get("Idempotency-Key")
+	if key == "" {
+		key = req.IdempotencyKey
+	}
+	input := storage.BulkItemsInput{DatabaseID: id, IdempotencyKey: key, Author: requestActor(r)}
+	switch req.Mode {
+	case "", "atomic":
+	case "continue_on_error":
+		input.ContinueOnError = true
+	default:
+		respondInvalidRequest(w, "mode must be atomic or continue_on_error")
+		return
+	}
+	for _, op := range req.Operations {
+		input.Operations = append(input.Operations, storage.BulkItemOperation{
+			Op:         op.Op,
+			ItemID:     op.ItemID,
+			TemplateID: op.TemplateID,
+			Slug:       op.Page.Slug,
+			Title:      op.Page.Title,
+			Summary:    op.Page.Summary,
+			Content:    op.Page.Content,
+			Tags:       op.Page.Tags,
+			Archived:   op.IsArchived,
+			Values:     op.Values,
+			Position:   op.Position,
+			BeforeID:   op.BeforeID,
+			AfterID:    op.AfterID,
+			Version:    op.Version,
+		})
+	}
+	result, err := store.BulkItems(r.Context(), input)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	if !result.Committed {
+		respondJSON(w, http.StatusUnprocessableEntity, Envelope{Data: result, Errors: []APIError{{Code: CodeBulkRolledBack, Message: "bulk request rolled back"}}})
+		return
+	}
+	respondJSON(w, http.StatusOK, Envelope{Data: result})
+}
+
+func itemRouteParams(r *http.Request) (string, string) {
+	return chi.URLParam(r, "id"), chi.URLParam(r, "itemID")
+}
+
+// GetItem handles GET /api/databases/{id}/items/{itemID}.
+func (h *DatabaseHandler) GetItem(w http.ResponseWriter, r *http.Request) {
+	databaseID, itemID := itemRouteParams(r)
+	item, err := h.store.GetDatabaseItem(r.Context(), databaseID, itemID)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondVersioned(w, http.StatusOK, item.Version, item)
+}
+
+// UpdateItemRequest is the payload for PATCH /api/databases/{id}/items/{itemID}.
+// Omitted fields are left unchanged and a null value clears a property.
+type UpdateItemRequest struct {
+	Page struct {
+		Title   *string  `json:"title"`
+		Summary *string  `json:"summary"`
+		Content *string  `json:"content"`
+		Tags    []string `json:"tags"`
+	} `json:"page"`
+	Values     map[string]any `json:"values"`
+	IsArchived *bool          `json:"is_archived"`
+	Version    *int           `json:"version"`
+}
+
+// UpdateItem handles PATCH /api/databases/{id}/items/{itemID}. The update must
+// name the item version it was based on.
+func (h *DatabaseHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
+	databaseID, itemID := itemRouteParams(r)
+	var req UpdateItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidRequest(w, "invalid request body")
+		return
+	}
+	current := func() (any, int, error) {
+		current, err := h.store.GetDatabaseItem(r.Context(), databaseID, itemID)
+		if err != nil {
+			return nil, 0, err
+		}
+		return current, current.Version, nil
+	}
+	version, ok := expectedVersion(w, r, req.Version, current)
+	if !ok {
+		return
+	}
+	item, err := h.store.UpdateDatabaseItem(r.Context(), storage.UpdateDatabaseItemInput{
+		DatabaseID:      databaseID,
+		ItemID:          itemID,
+		Title:           req.Page.Title,
+		Summary:         req.Page.Summary,
+		Content:         req.Page.Content,
+		Tags:            req.Page.Tags,
+		Archived:        req.IsArchived,
+		Values:          req.Values,
+		Author:          requestActor(r),
+		ExpectedVersion: version,
+	})
+	if err != nil {
+		respondUpdateError(w, r, err, current)
+		return
+	}
+	respondVersioned(w, http.StatusOK, item.Version, item)
+}
+
+// DeleteItem handles DELETE /api/databases/{id}/items/{itemID}.
+func (h *DatabaseHandler) DeleteItem(w http.ResponseWriter, r *http.Request) {
+	databaseID, itemID := itemRouteParams(r)
+	if err := h.store.DeleteDatabaseItem(r.Context(), databaseID, itemID); err != nil {
+		respondError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ArchiveItem handles POST /api/databases/{id}/items/{itemID}/archive.
+func (h *DatabaseHandler) ArchiveItem(w http.ResponseWriter, r *http.Request) {
+	h.setItemArchived(w, r, true)
+}
+
+// RestoreItem handles POST /api/databases/{id}/items/{itemID}/restore.
+func (h *DatabaseHandler) RestoreItem(w http.ResponseWriter, r *http.Request) {
+	h.setItemArchived(w, r, false)
+}
+
+func (h *DatabaseHandler) setItemArchived(w http.ResponseWriter, r *http.Request, archived bool) {
+	databaseID, itemID := itemRouteParams(r)
+	item, err := h.store.ArchiveDatabaseItem(r.Context(), databaseID, itemID, archived)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, Envelope{Data: item})
+}
+
+// MoveItemRequest is the payload for POST /api/databases/{id}/items/{itemID}/move.
+type MoveItemRequest struct {
+	BeforeID string `json:"before_id"`
+	AfterID  string `json:"after_id"`
+}
+
+// MoveItem places an item directly before or after another item.
+func (h *DatabaseHandler) MoveItem(w http.ResponseWriter, r *http.Request) {
+	databaseID, itemID := itemRouteParams(r)
+	var req MoveItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidRequest(w, "invalid request body")
+		return
+	}
+	item, err := h.store.MoveDatabaseItem(r.Context(), storage.MoveDatabaseItemInput{
+		DatabaseID: databaseID,
+		ItemID:     itemID,
+		BeforeID:   req.BeforeID,
+		AfterID:    req.AfterID,
+	})
+	if err != nil {
+		respondError(w, r, err)
 		return
 	}
-	respondJSON(w, http.StatusOK, Envelope{Data: items})
+	respondJSON(w, http.StatusOK, Envelope{Data: item})
 }
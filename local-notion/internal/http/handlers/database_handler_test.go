@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"net/http"
@@ -11,18 +12,19 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/example/agents-playground/internal/domain"
-	"github.com/example/agents-playground/internal/storage/sqlite"
+	"github.com/example/agents-playground/internal/storage"
+	"github.com/example/agents-playground/internal/storage/memory"
 )
 
 func TestDatabaseHandlerListViewItemsNotFound(t *testing.T) {
 	store := newTestSQLiteStore(t)
 	handler := NewDatabaseHandler(store)
 
-	db, err := store.CreateDatabase(context.Background(), sqlite.CreateDatabaseInput{
+	db, err := store.CreateDatabase(context.Background(), storage.CreateDatabaseInput{
 		Slug:       "inventory",
 		Title:      "Inventory",
-		Properties: []sqlite.DatabasePropertyInput{{Name: "Name", Slug: "name", Type: domain.PropertyTypeText}},
-		Views:      []sqlite.DatabaseViewInput{{Name: "Default", Type: domain.ViewTypeTable}},
+		Properties: []storage.DatabasePropertyInput{{Name: "Name", Slug: "name", Type: domain.PropertyTypeText}},
+		Views:      []storage.DatabaseViewInput{{Name: "Default", Type: domain.ViewTypeTable}},
 	})
 	require.NoError(t, err)
 
@@ -45,3 +47,253 @@ func TestDatabaseHandlerListViewItemsNotFound(t *testing.T) {
 	require.Len(t, env.Errors, 1)
 	require.Contains(t, env.Errors[0].Message, "view not found")
 }
+
+func TestDatabaseHandlerBulkItemsRollsBack(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	handler := NewDatabaseHandler(store)
+
+	db, err := store.CreateDatabase(context.Background(), storage.CreateDatabaseInput{
+		Slug:       "inventory",
+		Title:      "Inventory",
+		Properties: []storage.DatabasePropertyInput{{Name: "Name", Slug: "name", Type: domain.PropertyTypeText}},
+	})
+	require.NoError(t, err)
+
+	body := `{"operations":[{"op":"create","page":{"slug":"hammer","title":"Hammer"}},{"op":"delete","item_id":"missing"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/databases/"+db.ID+"/items/bulk", bytes.NewBufferString(body))
+	req.Header.Set("Idempotency-Key", "retry-safe")
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", db.ID)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	rec := httptest.NewRecorder()
+	handler.BulkItems(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+
+	var env responseEnvelope
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&env))
+	var result domain.BulkResult
+	require.NoError(t, json.Unmarshal(env.Data, &result))
+	require.False(t, result.Committed)
+	require.Equal(t, "rolled_back", result.Results[0].Status)
+	require.Equal(t, "error", result.Results[1].Status)
+	require.Equal(t, []domain.BulkOperationError{{Code: "item_not_found", Message: "item not found"}}, result.Results[1].Errors)
+}
+
+func TestDatabaseHandlerListViewItemsServedByMemoryStore(t *testing.T) {
+	store := memory.New()
+	handler := NewDatabaseHandler(store)
+
+	db, err := store.CreateDatabase(context.Background(), storage.CreateDatabaseInput{
+		Slug:  "inventory",
+		Title: "Inventory",
+		Views: []storage.DatabaseViewInput{{Name: "Default", Type: domain.ViewTypeTable}},
+	})
+	require.NoError(t, err)
+	_, err = store.CreateDatabaseItem(context.Background(), storage.CreateDatabaseItemInput{
+		DatabaseID: db.ID,
+		Page:       storage.CreatePageInput{Slug: "widget", Title: "Widget"},
+	})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/databases/"+db.ID+"/views/"+db.Views[0].ID+"/items", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", db.ID)
+	rctx.URLParams.Add("viewID", db.Views[0].ID)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rec := httptest.NewRecorder()
+	handler.ListViewItems(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var env responseEnvelope
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&env))
+	var items []domain.DatabaseItem
+	require.NoError(t, json.Unmarshal(env.Data, &items))
+	require.Len(t, items, 1)
+	require.Equal(t, "Widget", items[0].Page.Title)
+}
+
+func TestDatabaseHandlerMoveItemNotFound(t *testing.T) {
+	store := memory.New()
+	handler := NewDatabaseHandler(store)
+
+	db, err := store.CreateDatabase(context.Background(), storage.CreateDatabaseInput{
+		Slug:       "inventory",
+		Title:      "Inventory",
+		Properties: []storage.DatabasePropertyInput{{Name: "Name", Slug: "name", Type: domain.PropertyTypeText}},
+	})
+	require.NoError(t, err)
+	item, err := store.CreateDatabaseItem(context.Background(), storage.CreateDatabaseItemInput{
+		DatabaseID: db.ID,
+		Page:       storage.CreatePageInput{Slug: "widget", Title: "Widget"},
+	})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/databases/"+db.ID+"/items/"+item.ID+"/move", bytes.NewBufferString(`{"after_id":"missing"}`))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", db.ID)
+	rctx.URLParams.Add("itemID", item.ID)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	rec := httptest.NewRecorder()
+	handler.MoveItem(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	var env responseEnvelope
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&env))
+	require.Len(t, env.Errors, 1)
+	require.Contains(t, env.Errors[0].Message, "item not found")
+}
+
+func TestDatabaseHandlerCreateViewRejectsUnknownProperty(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	handler := NewDatabaseHandler(store)
+
+	db, err := store.CreateDatabase(context.Background(), storage.CreateDatabaseInput{
+		Slug:       "inventory",
+		Title:      "Inventory",
+		Properties: []storage.DatabasePropertyInput{{Name: "Name", Slug: "name", Type: domain.PropertyTypeText}},
+	})
+	require.NoError(t, err)
+
+	body := `{"name":"Board","type":"board","grouping":{"property_id":"missing"}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/databases/"+db.ID+"/views", bytes.NewBufferString(body))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", db.ID)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	rec := httptest.NewRecorder()
+	handler.CreateView(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+
+	var env responseEnvelope
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&env))
+	require.Len(t, env.Errors, 1)
+	require.Contains(t, env.Errors[0].Message, "unknown property")
+}
+
+func TestDatabaseHandlerListViewItemsReturnsAggregates(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	handler := NewDatabaseHandler(store)
+	ctx := context.Background()
+
+	db, err := store.CreateDatabase(ctx, storage.CreateDatabaseInput{
+		Slug:       "inventory",
+		Title:      "Inventory",
+		Properties: []storage.DatabasePropertyInput{{Name: "Qty", Slug: "qty", Type: domain.PropertyTypeNumber}},
+	})
+	require.NoError(t, err)
+	qty := db.Properties[0].ID
+	for _, slug := range []string{"bolts", "nuts"} {
+		_, err := store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
+			DatabaseID: db.ID,
+			Page:       storage.CreatePageInput{Slug: slug, Title: slug},
+			Values:     map[string]any{"qty": 4},
+		})
+		require.NoError(t, err)
+	}
+	view, err := store.CreateDatabaseView(ctx, db.ID, storage.DatabaseViewInput{
+		Name:          "Totals",
+		Type:          domain.ViewTypeTable,
+		LayoutOptions: map[string]any{"aggregations": map[string]any{qty: "sum"}},
+	})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/databases/"+db.ID+"/views/"+view.ID+"/items", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", db.ID)
+	rctx.URLParams.Add("viewID", view.ID)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	rec := httptest.NewRecorder()
+	handler.ListViewItems(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var env responseEnvelope
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&env))
+	var summary domain.ViewSummary
+	require.NoError(t, json.Unmarshal(env.Meta, &summary))
+	require.Equal(t, 2, summary.Count)
+	require.Len(t, summary.Aggregates, 1)
+	require.Equal(t, 8.0, summary.Aggregates[0].Value)
+}
+
+func TestDatabaseHandlerPivotViewRequiresDimension(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	handler := NewDatabaseHandler(store)
+
+	db, err := store.CreateDatabase(context.Background(), storage.CreateDatabaseInput{
+		Slug:       "inventory",
+		Title:      "Inventory",
+		Properties: []storage.DatabasePropertyInput{{Name: "Name", Slug: "name", Type: domain.PropertyTypeText}},
+		Views:      []storage.DatabaseViewInput{{Name: "Default", Type: domain.ViewTypeTable}},
+	})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/databases/"+db.ID+"/views/"+db.Views[0].ID+"/pivot?measure=count", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", db.ID)
+	rctx.URLParams.Add("viewID", db.Views[0].ID)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	rec := httptest.NewRecorder()
+	handler.PivotView(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+
+	var env responseEnvelope
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&env))
+	require.Len(t, env.Errors, 1)
+	require.Equal(t, "invalid_pivot", env.Errors[0].Code)
+	require.Contains(t, env.Errors[0].Message, "unknown dimension property")
+}
+
+func TestDatabaseHandlerImportCSVRejectsInvalidRows(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	handler := NewDatabaseHandler(store)
+
+	db, err := store.CreateDatabase(context.Background(), storage.CreateDatabaseInput{
+		Slug:       "inventory",
+		Title:      "Inventory",
+		Properties: []storage.DatabasePropertyInput{{Name: "Count", Slug: "count", Type: domain.PropertyTypeNumber}},
+		Views:      []storage.DatabaseViewInput{{Name: "Default", Type: domain.ViewTypeTable}},
+	})
+	require.NoError(t, err)
+
+	body := `{"csv": "Title,Count\nBolts,12\nNuts,many\n"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/databases/"+db.ID+"/import", bytes.NewBufferString(body))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", db.ID)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	rec := httptest.NewRecorder()
+	handler.ImportCSV(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+
+	var env responseEnvelope
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&env))
+	require.Len(t, env.Errors, 1)
+	require.Equal(t, `row 2: "many" is not a number`, env.Errors[0].Message)
+}
@@ -7,8 +7,10 @@ type Envelope struct {
 	Errors []APIError `json:"errors,omitempty"`
 }
 
-// APIError represents a structured API error message.
+// APIError represents a structured API error message. Field names the
+// offending input field of a validation or conflict error.
 type APIError struct {
 	Code    string `json:"code,omitempty"`
+	Field   string `json:"field,omitempty"`
 	Message string `json:"message"`
 }
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// API error codes raised by the handlers themselves. Store failures carry the
+// code of their storage.Error.
+const (
+	CodeInvalidRequest = "invalid_request"
+	CodeInternal       = "internal"
+	CodeUnavailable    = "unavailable"
+	CodeBulkRolledBack = "bulk_rolled_back"
+)
+
+var errorKindStatus = map[storage.ErrorKind]int{
+	storage.KindNotFound:           http.StatusNotFound,
+	storage.KindConflict:           http.StatusConflict,
+	storage.KindValidation:         http.StatusUnprocessableEntity,
+	storage.KindPreconditionFailed: http.StatusPreconditionFailed,
+	storage.KindForbidden:          http.StatusForbidden,
+}
+
+// respondError answers a failed store call. Classified errors map onto their
+// status and code, with one APIError per offending field; anything else is
+// logged and answered 500 without leaking its text.
+func respondError(w http.ResponseWriter, r *http.Request, err error) {
+	storeErr := storage.AsError(err)
+	status, ok := http.StatusInternalServerError, false
+	if storeErr != nil {
+		status, ok = errorKindStatus[storeErr.Kind]
+	}
+	if !ok {
+		log.Error().Err(err).Str("method", r.Method).Str("path", r.URL.Path).Msg("request_failed")
+		respondJSON(w, http.StatusInternalServerError, Envelope{Errors: []APIError{{Code: CodeInternal, Message: "internal server error"}}})
+		return
+	}
+	respondJSON(w, status, Envelope{Errors: apiErrors(err, storeErr)})
+}
+
+func apiErrors(err error, storeErr *storage.Error) []APIError {
+	if len(storeErr.Fields) > 0 {
+		out := make([]APIError, len(storeErr.Fields))
+		for i, f := range storeErr.Fields {
+			out[i] = APIError{Code: storeErr.Code, Field: f.Field, Message: f.Message}
+		}
+		return out
+	}
+	// Store errors are often wrapped with details worth showing; a translated
+	// driver error is not, so only its classified message is used.
+	message := storeErr.Message
+	var typed *storage.Error
+	if errors.As(err, &typed) {
+		message = err.Error()
+	}
+	return []APIError{{Code: storeErr.Code, Message: message}}
+}
+
+// respondInvalidRequest answers 400 for a request the handler cannot parse.
+func respondInvalidRequest(w http.ResponseWriter, message string) {
+	respondJSON(w, http.StatusBadRequest, Envelope{Errors: []APIError{{Code: CodeInvalidRequest, Message: message}}})
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// CodePreconditionRequired is returned when an update carries neither an
+// If-Match header nor a version field.
+const CodePreconditionRequired = "precondition_required"
+
+// etag formats a record version as a strong entity tag.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// respondVersioned writes data together with the ETag of its version.
+func respondVersioned(w http.ResponseWriter, status, version int, data any) {
+	w.Header().Set("ETag", etag(version))
+	respondJSON(w, status, Envelope{Data: data})
+}
+
+// expectedVersion returns the version an update is conditioned on, taken from
+// the If-Match header or, for clients that cannot set headers, the version
+// field of the body. If-Match: * matches any version and yields nil. A list of
+// entity tags is resolved against the current version from current: the
+// update is conditioned on that version when any tag names it, and fails with
+// 412 otherwise. When the condition is missing or malformed the error
+// response is written and ok is false.
+func expectedVersion(w http.ResponseWriter, r *http.Request, bodyVersion *int, current func() (any, int, error)) (version *int, ok bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	switch {
+	case header == "*":
+		return nil, true
+	case header != "":
+		versions, err := parseETags(header)
+		if err != nil {
+			respondInvalidRequest(w, err.Error())
+			return nil, false
+		}
+		if len(versions) == 1 {
+			return &versions[0], true
+		}
+		_, v, err := current()
+		if err != nil {
+			respondError(w, r, err)
+			return nil, false
+		}
+		if !slices.Contains(versions, v) {
+			v = -1
+		}
+		return &v, true
+	case bodyVersion != nil:
+		return bodyVersion, true
+	}
+	respondJSON(w, http.StatusPreconditionRequired, Envelope{Errors: []APIError{{
+		Code:    CodePreconditionRequired,
+		Message: "updates require an If-Match header or a version field",
+	}}})
+	return nil, false
+}
+
+// parseETags reads the versions of a comma-separated list of entity tags.
+// Weak tags never match under the strong comparison If-Match requires, so
+// they map to version -1.
+func parseETags(header string) ([]int, error) {
+	var versions []int
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if strings.HasPrefix(tag, "W/") {
+			versions = append(versions, -1)
+			continue
+		}
+		v, err := strconv.Atoi(strings.Trim(tag, `"`))
+		if err != nil || len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+			return nil, errors.New("If-Match must be * or a list of quoted entity tags")
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+// respondUpdateError answers a failed conditional update. A version mismatch
+// is answered 412 with the current representation and its ETag so the client
+// can reconcile and retry; other errors go through respondError.
+func respondUpdateError(w http.ResponseWriter, r *http.Request, err error, current func() (any, int, error)) {
+	if !errors.Is(err, storage.ErrPreconditionFailed) {
+		respondError(w, r, err)
+		return
+	}
+	data, version, loadErr := current()
+	if loadErr != nil {
+		respondError(w, r, loadErr)
+		return
+	}
+	w.Header().Set("ETag", etag(version))
+	respondJSON(w, http.StatusPreconditionFailed, Envelope{Data: data, Errors: apiErrors(err, storage.AsError(err))})
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// ExportPage handles GET /api/pages/{id}/export, streaming the page and its
+// descendants as a zip of Markdown files.
+func (h *PageHandler) ExportPage(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.streamMarkdownExport(w, r, id, "page-"+id+".zip")
+}
+
+// ExportWorkspace handles GET /api/export, streaming every page as a zip of
+// Markdown files.
+func (h *PageHandler) ExportWorkspace(w http.ResponseWriter, r *http.Request) {
+	h.streamMarkdownExport(w, r, "", "workspace.zip")
+}
+
+func (h *PageHandler) streamMarkdownExport(w http.ResponseWriter, r *http.Request, rootID, filename string) {
+	store, ok := supports[markdownExportStore](w, h.store)
+	if !ok {
+		return
+	}
+	export, err := store.PrepareMarkdownExport(r.Context(), rootID)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	// Large archives take longer to stream than the server's write timeout.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+	// The status is already sent, so a failure can only truncate the archive.
+	if err := export.Stream(r.Context(), w); err != nil {
+		log.Error().Err(err).Str("root_page_id", rootID).Msg("markdown_export_failed")
+	}
+}
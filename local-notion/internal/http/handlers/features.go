@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// CodeNotImplemented is returned for endpoints the configured storage backend
+// does not support.
+const CodeNotImplemented = "not_implemented"
+
+// The interfaces below are optional features a backend may offer on top of
+// storage.Store. The sqlite backend implements all of them.
+
+type pageTemplateStore interface {
+	ListPageTemplates(ctx context.Context) ([]domain.Page, error)
+	InstantiatePageTemplate(ctx context.Context, in storage.InstantiatePageTemplateInput) (*domain.Page, error)
+}
+
+type pageRevisionStore interface {
+	ListPageRevisions(ctx context.Context, pageID string) ([]domain.PageRevision, error)
+	DiffPageRevisions(ctx context.Context, pageID string, from, to int) (*domain.PageDiff, error)
+	RestorePageRevision(ctx context.Context, in storage.RestorePageRevisionInput) (*domain.Page, error)
+}
+
+type markdownExportStore interface {
+	PrepareMarkdownExport(ctx context.Context, rootID string) (storage.MarkdownExport, error)
+}
+
+type duplicateStore interface {
+	DuplicatePage(ctx context.Context, in storage.DuplicatePageInput) (*domain.Page, error)
+	DuplicateDatabase(ctx context.Context, in storage.DuplicateDatabaseInput) (*domain.Database, error)
+	DuplicateDatabaseView(ctx context.Context, in storage.DuplicateDatabaseViewInput) (*domain.DatabaseView, error)
+}
+
+type databaseTemplateStore interface {
+	ListDatabaseTemplates(ctx context.Context) ([]domain.Database, error)
+	InstantiateDatabaseTemplate(ctx context.Context, in storage.InstantiateDatabaseTemplateInput) (*domain.Database, error)
+	CreateItemTemplate(ctx context.Context, in storage.CreateItemTemplateInput) (*domain.DatabaseItemTemplate, error)
+}
+
+type bulkItemStore interface {
+	BulkItems(ctx context.Context, in storage.BulkItemsInput) (*domain.BulkResult, error)
+}
+
+type viewStore interface {
+	GetDatabaseView(ctx context.Context, databaseID, viewID string) (*domain.DatabaseView, error)
+	CreateDatabaseView(ctx context.Context, databaseID string, in storage.DatabaseViewInput) (*domain.DatabaseView, error)
+	UpdateDatabaseView(ctx context.Context, in storage.UpdateDatabaseViewInput) (*domain.DatabaseView, error)
+	DeleteDatabaseView(ctx context.Context, databaseID, viewID string) error
+	ListDatabaseViewVersions(ctx context.Context, databaseID, viewID string) ([]domain.DatabaseViewVersion, error)
+	RestoreDatabaseView(ctx context.Context, databaseID, viewID string, version int) (*domain.DatabaseView, error)
+	UndoDatabaseView(ctx context.Context, databaseID, viewID string) (*domain.DatabaseView, error)
+	PivotView(ctx context.Context, in storage.PivotViewInput) (*domain.PivotResult, error)
+}
+
+type assetStore interface {
+	GetAsset(ctx context.Context, id string) (*domain.Asset, error)
+}
+
+type auditStore interface {
+	ListAuditEntries(ctx context.Context, filter storage.AuditFilter) ([]domain.AuditEntry, string, error)
+	ExportAuditLog(ctx context.Context, filter storage.AuditFilter, w io.Writer) error
+}
+
+type notionImportStore interface {
+	ImportNotion(ctx context.Context, in storage.ImportNotionInput) (*domain.NotionImportReport, error)
+}
+
+type csvStore interface {
+	ExportViewCSV(ctx context.Context, databaseID, viewID string, w io.Writer) error
+	ImportCSV(ctx context.Context, in storage.ImportCSVInput) (*domain.ImportResult, error)
+}
+
+type tagStore interface {
+	ListTags(ctx context.Context) ([]domain.Tag, error)
+	ListPagesByTags(ctx context.Context, tags []string, matchAll bool) ([]domain.Page, error)
+	RenameTag(ctx context.Context, in storage.RenameTagInput) (*domain.Tag, error)
+	MergeTags(ctx context.Context, in storage.MergeTagsInput) (*domain.Tag, error)
+}
+
+type slugStore interface {
+	GetPageBySlug(ctx context.Context, slug string) (*domain.Page, error)
+	GetDatabaseBySlug(ctx context.Context, slug string) (*domain.Database, error)
+	UpdateDatabaseSlug(ctx context.Context, in storage.UpdateDatabaseSlugInput) (*domain.Database, error)
+}
+
+type commentStore interface {
+	ListCommentThreads(ctx context.Context, pageID, status string) ([]domain.CommentThread, error)
+	GetCommentThread(ctx context.Context, pageID, threadID string) (*domain.CommentThread, error)
+	CreateCommentThread(ctx context.Context, in storage.CreateCommentThreadInput) (*domain.CommentThread, error)
+	ReplyToCommentThread(ctx context.Context, in storage.ReplyToCommentThreadInput) (*domain.CommentThread, error)
+	ResolveCommentThread(ctx context.Context, in storage.ResolveCommentThreadInput) (*domain.CommentThread, error)
+	UpdateComment(ctx context.Context, in storage.UpdateCommentInput) (*domain.Comment, error)
+	DeleteComment(ctx context.Context, in storage.DeleteCommentInput) error
+}
+
+type notificationStore interface {
+	ListNotifications(ctx context.Context, filter storage.NotificationFilter) ([]domain.Notification, int, error)
+	MarkNotificationRead(ctx context.Context, recipient, id string) (*domain.Notification, error)
+	MarkAllNotificationsRead(ctx context.Context, recipient string) (int, error)
+	GetNotificationPreferences(ctx context.Context, recipient string) (*domain.NotificationPreferences, error)
+	SetNotificationPreferences(ctx context.Context, prefs domain.NotificationPreferences) (*domain.NotificationPreferences, error)
+}
+
+type notificationStreamer interface {
+	SubscribeNotifications(recipient string) (<-chan domain.Notification, func())
+}
+
+type recurrenceStore interface {
+	GetItemRecurrence(ctx context.Context, databaseID, itemID string) (*domain.RecurrenceSeries, error)
+	GetRecurrenceSeries(ctx context.Context, databaseID, seriesID string) (*domain.RecurrenceSeries, error)
+	SetItemRecurrence(ctx context.Context, in storage.SetItemRecurrenceInput) (*domain.RecurrenceSeries, error)
+	UpdateItemRecurrence(ctx context.Context, in storage.UpdateItemRecurrenceInput) (*domain.RecurrenceSeries, error)
+	EndItemRecurrence(ctx context.Context, databaseID, itemID string) error
+}
+
+type reminderStore interface {
+	ListUpcomingReminders(ctx context.Context, filter storage.ReminderFilter) ([]domain.Reminder, error)
+}
+
+type schemaCacheStore interface {
+	SchemaCacheStats() storage.SchemaCacheStats
+}
+
+// supports returns store as the optional feature T, or answers 501 when the
+// backend does not implement it.
+func supports[T any](w http.ResponseWriter, store storage.Store) (T, bool) {
+	feature, ok := store.(T)
+	if !ok {
+		respondJSON(w, http.StatusNotImplemented, Envelope{Errors: []APIError{{
+			Code:    CodeNotImplemented,
+			Message: "not supported by the configured storage backend",
+		}}})
+	}
+	return feature, ok
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// maxNotionUploadBytes caps the size of an uploaded Notion export.
+const maxNotionUploadBytes = 2 << 30
+
+// ImportHandler manages workspace imports.
+type ImportHandler struct {
+	store    storage.Store
+	assetDir string
+}
+
+// NewImportHandler constructs handler. Imported attachments are stored in assetDir.
+func NewImportHandler(store storage.Store, assetDir string) *ImportHandler {
+	return &ImportHandler{store: store, assetDir: assetDir}
+}
+
+// ImportNotion handles POST /api/import/notion. The export zip is sent either
+// as the raw request body or as the "file" field of a multipart form.
+func (h *ImportHandler) ImportNotion(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[notionImportStore](w, h.store)
+	if !ok {
+		return
+	}
+	body := io.Reader(http.MaxBytesReader(w, r.Body, maxNotionUploadBytes))
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			respondInvalidRequest(w, "multipart upload requires a file field")
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+	// zip needs random access, so the upload is spooled to disk first.
+	spool, err := os.CreateTemp("", "notion-import-*.zip")
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	defer func() {
+		_ = spool.Close()
+		_ = os.Remove(spool.Name())
+	}()
+	size, err := io.Copy(spool, body)
+	if err != nil {
+		respondInvalidRequest(w, fmt.Sprintf("read upload: %v", err))
+		return
+	}
+	archive, err := zip.NewReader(spool, size)
+	if err != nil {
+		respondInvalidRequest(w, "upload is not a zip archive")
+		return
+	}
+	report, err := store.ImportNotion(r.Context(), storage.ImportNotionInput{Archive: archive, AssetDir: h.assetDir})
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, Envelope{Data: report})
+}
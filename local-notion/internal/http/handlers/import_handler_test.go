@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportHandlerImportNotion(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	handler := NewImportHandler(store, t.TempDir())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/import/notion", bytes.NewBufferString("not a zip"))
+	rec := httptest.NewRecorder()
+	handler.ImportNotion(rec, req)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, err := zw.Create("Notes 0123456789abcdef0123456789abcdef.md")
+	require.NoError(t, err)
+	_, err = f.Write([]byte("# Notes\n\nhello\n"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	req = httptest.NewRequest(http.MethodPost, "/api/import/notion", &buf)
+	req.Header.Set("Content-Type", "application/zip")
+	rec = httptest.NewRecorder()
+	handler.ImportNotion(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var env responseEnvelope
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&env))
+	var report struct {
+		PagesCreated int `json:"pages_created"`
+	}
+	require.NoError(t, json.Unmarshal(env.Data, &report))
+	require.Equal(t, 1, report.PagesCreated)
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// NotificationHandler serves the notification inbox of the caller named in
+// the X-Actor header.
+type NotificationHandler struct {
+	store storage.Store
+}
+
+// NewNotificationHandler constructs handler.
+func NewNotificationHandler(store storage.Store) *NotificationHandler {
+	return &NotificationHandler{store: store}
+}
+
+// NotificationPreferencesRequest is the payload for PUT
+// /api/notifications/preferences.
+type NotificationPreferencesRequest struct {
+	Muted []string `json:"muted"`
+}
+
+// ListNotifications handles GET /api/notifications. unread=true leaves out
+// read notifications and limit caps the page; meta carries the unread count.
+func (h *NotificationHandler) ListNotifications(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[notificationStore](w, h.store)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	filter := storage.NotificationFilter{Recipient: requestActor(r)}
+	if v := query.Get("unread"); v != "" {
+		unread, err := strconv.ParseBool(v)
+		if err != nil {
+			respondInvalidRequest(w, "unread must be a boolean")
+			return
+		}
+		filter.UnreadOnly = unread
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			respondInvalidRequest(w, "limit must be a positive integer")
+			return
+		}
+		filter.Limit = limit
+	}
+	notifications, unread, err := store.ListNotifications(r.Context(), filter)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, Envelope{Data: notifications, Meta: map[string]any{"unread": unread}})
+}
+
+// MarkNotificationRead handles POST /api/notifications/{id}/read.
+func (h *NotificationHandler) MarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[notificationStore](w, h.store)
+	if !ok {
+		return
+	}
+	notification, err := store.MarkNotificationRead(r.Context(), requestActor(r), chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, Envelope{Data: notification})
+}
+
+// MarkAllNotificationsRead handles POST /api/notifications/read, returning how
+// many notifications were marked.
+func (h *NotificationHandler) MarkAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[notificationStore](w, h.store)
+	if !ok {
+		return
+	}
+	marked, err := store.MarkAllNotificationsRead(r.Context(), requestActor(r))
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, Envelope{Data: map[string]int{"marked": marked}})
+}
+
+// GetNotificationPreferences handles GET /api/notifications/preferences.
+func (h *NotificationHandler) GetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[notificationStore](w, h.store)
+	if !ok {
+		return
+	}
+	prefs, err := store.GetNotificationPreferences(r.Context(), requestActor(r))
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, Envelope{Data: prefs})
+}
+
+// SetNotificationPreferences handles PUT /api/notifications/preferences,
+// replacing the muted sources.
+func (h *NotificationHandler) SetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[notificationStore](w, h.store)
+	if !ok {
+		return
+	}
+	var req NotificationPreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidRequest(w, "invalid request body")
+		return
+	}
+	prefs, err := store.SetNotificationPreferences(r.Context(), domain.NotificationPreferences{
+		Recipient: requestActor(r),
+		Muted:     req.Muted,
+	})
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, Envelope{Data: prefs})
+}
+
+// streamKeepAlive is how often an idle notification stream sends a comment,
+// which keeps proxies from closing it.
+var streamKeepAlive = 25 * time.Second
+
+// StreamNotifications handles GET /api/notifications/stream, pushing the
+// caller's new notifications as server-sent events of type notification as
+// they are committed. The stream ends when the client disconnects or falls
+// too far behind; clients reconnect and reload the inbox to catch up.
+func (h *NotificationHandler) StreamNotifications(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[notificationStreamer](w, h.store)
+	if !ok {
+		return
+	}
+	rc := http.NewResponseController(w)
+	// The stream outlives the server's write timeout.
+	_ = rc.SetWriteDeadline(time.Time{})
+	notifications, cancel := store.SubscribeNotifications(requestActor(r))
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if _, err := fmt.Fprint(w, ": connected\n\n"); err != nil || rc.Flush() != nil {
+		return
+	}
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case n, open := <-notifications:
+			if !open {
+				return
+			}
+			data, err := json.Marshal(n)
+			if err != nil {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: notification\ndata: %s\n\n", n.ID, data); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/storage"
+	"github.com/example/agents-playground/internal/storage/memory"
+)
+
+func TestNotificationHandlerInbox(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	_, err := store.CreatePage(context.Background(), storage.CreatePageInput{Title: "Plan", Content: "@bo please review", Author: "ana"})
+	require.NoError(t, err)
+	notificationHandler := NewNotificationHandler(store)
+
+	request := func(method, target, body string) *http.Request {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("X-Actor", "bo")
+		return req
+	}
+
+	rec := httptest.NewRecorder()
+	notificationHandler.ListNotifications(rec, request(http.MethodGet, "/api/notifications?unread=true", ""))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var env responseEnvelope
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&env))
+	var notifications []map[string]any
+	require.NoError(t, json.Unmarshal(env.Data, &notifications))
+	require.Len(t, notifications, 1)
+	require.Equal(t, "mention", notifications[0]["source"])
+	require.JSONEq(t, `{"unread":1}`, string(env.Meta))
+
+	rec = httptest.NewRecorder()
+	notificationHandler.MarkAllNotificationsRead(rec, request(http.MethodPost, "/api/notifications/read", ""))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `"marked":1`)
+
+	rec = httptest.NewRecorder()
+	notificationHandler.SetNotificationPreferences(rec, request(http.MethodPut, "/api/notifications/preferences", `{"muted":["reply","assignment"]}`))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `"muted":["assignment","reply"]`)
+
+	rec = httptest.NewRecorder()
+	notificationHandler.ListNotifications(rec, request(http.MethodGet, "/api/notifications?limit=0", ""))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestNotificationHandlerStreamsNewNotifications(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	server := httptest.NewServer(http.HandlerFunc(NewNotificationHandler(store).StreamNotifications))
+	defer server.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Actor", "bo")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	lines := bufio.NewScanner(resp.Body)
+	require.True(t, lines.Scan())
+	require.Equal(t, ": connected", lines.Text())
+
+	_, err = store.CreatePage(context.Background(), storage.CreatePageInput{Title: "Plan", Content: "@bo please review", Author: "ana"})
+	require.NoError(t, err)
+	notifications, _, err := store.ListNotifications(context.Background(), storage.NotificationFilter{Recipient: "bo"})
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	var event []string
+	for lines.Scan() {
+		line := lines.Text()
+		if line == "" && len(event) > 0 {
+			break
+		}
+		if line != "" && !strings.HasPrefix(line, ":") {
+			event = append(event, line)
+		}
+	}
+	require.Len(t, event, 3)
+	require.Equal(t, "id: "+notifications[0].ID, event[0])
+	require.Equal(t, "event: notification", event[1])
+	require.True(t, strings.HasPrefix(event[2], "data: "))
+	require.Contains(t, event[2], `"source":"mention"`)
+}
+
+func TestNotificationHandlerNotImplemented(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewNotificationHandler(memory.New()).ListNotifications(rec, httptest.NewRequest(http.MethodGet, "/api/notifications", nil))
+	require.Equal(t, http.StatusNotImplemented, rec.Code)
+	rec = httptest.NewRecorder()
+	NewNotificationHandler(memory.New()).StreamNotifications(rec, httptest.NewRequest(http.MethodGet, "/api/notifications/stream", nil))
+	require.Equal(t, http.StatusNotImplemented, rec.Code)
+}
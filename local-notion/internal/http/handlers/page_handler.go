@@ -6,16 +6,16 @@ import (
 
 	"github.com/go-chi/chi/v5"
 
-	"github.com/example/agents-playground/internal/storage/sqlite"
+	"github.com/example/agents-playground/internal/storage"
 )
 
 // PageHandler manages page endpoints.
 type PageHandler struct {
-	store *sqlite.Store
+	store storage.Store
 }
 
 // NewPageHandler constructs handler.
-func NewPageHandler(store *sqlite.Store) *PageHandler {
+func NewPageHandler(store storage.Store) *PageHandler {
 	return &PageHandler{store: store}
 }
 
@@ -28,16 +28,17 @@ type CreatePageRequest struct {
 	ParentPageID  *string  `json:"parent_page_id"`
 	Tags          []string `json:"tags"`
 	LinkedPageIDs []string `json:"linked_page_ids"`
+	IsTemplate    bool     `json:"is_template"`
 }
 
 // CreatePage handles page creation.
 func (h *PageHandler) CreatePage(w http.ResponseWriter, r *http.Request) {
 	var req CreatePageRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondJSON(w, http.StatusBadRequest, Envelope{Errors: []APIError{{Message: "invalid request body"}}})
+		respondInvalidRequest(w, "invalid request body")
 		return
 	}
-	page, err := h.store.CreatePage(r.Context(), sqlite.CreatePageInput{
+	page, err := h.store.CreatePage(r.Context(), storage.CreatePageInput{
 		Slug:          req.Slug,
 		Title:         req.Title,
 		Summary:       req.Summary,
@@ -45,9 +46,11 @@ func (h *PageHandler) CreatePage(w http.ResponseWriter, r *http.Request) {
 		ParentPageID:  req.ParentPageID,
 		Tags:          req.Tags,
 		LinkedPageIDs: req.LinkedPageIDs,
+		IsTemplate:    req.IsTemplate,
+		Author:        requestActor(r),
 	})
 	if err != nil {
-		respondJSON(w, http.StatusBadRequest, Envelope{Errors: []APIError{{Message: err.Error()}}})
+		respondError(w, r, err)
 		return
 	}
 	respondJSON(w, http.StatusCreated, Envelope{Data: page})
@@ -58,22 +61,104 @@ func (h *PageHandler) GetPage(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	page, err := h.store.GetPage(r.Context(), id)
 	if err != nil {
-		respondJSON(w, http.StatusInternalServerError, Envelope{Errors: []APIError{{Message: err.Error()}}})
+		respondError(w, r, err)
 		return
 	}
-	if page == nil {
-		respondJSON(w, http.StatusNotFound, Envelope{Errors: []APIError{{Message: "page not found"}}})
-		return
-	}
-	respondJSON(w, http.StatusOK, Envelope{Data: page})
+	respondVersioned(w, http.StatusOK, page.Version, page)
 }
 
-// ListPages returns a lightweight listing of pages for linking.
+// ListPages returns a lightweight listing of pages for linking. Repeated tag
+// query parameters narrow it to pages carrying any of the tags, or all of
+// them with match=all.
 func (h *PageHandler) ListPages(w http.ResponseWriter, r *http.Request) {
+	if tags := r.URL.Query()["tag"]; len(tags) > 0 {
+		h.listPagesByTags(w, r, tags)
+		return
+	}
 	pages, err := h.store.ListPages(r.Context())
 	if err != nil {
-		respondJSON(w, http.StatusInternalServerError, Envelope{Errors: []APIError{{Message: err.Error()}}})
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, Envelope{Data: pages})
+}
+
+// ListPageTemplates returns the root pages of all page templates.
+func (h *PageHandler) ListPageTemplates(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[pageTemplateStore](w, h.store)
+	if !ok {
+		return
+	}
+	pages, err := store.ListPageTemplates(r.Context())
+	if err != nil {
+		respondError(w, r, err)
 		return
 	}
 	respondJSON(w, http.StatusOK, Envelope{Data: pages})
 }
+
+// InstantiateTemplateRequest is the payload for POST /api/pages/{id}/instantiate.
+type InstantiateTemplateRequest struct {
+	Slug         string            `json:"slug"`
+	Title        string            `json:"title"`
+	ParentPageID *string           `json:"parent_page_id"`
+	Variables    map[string]string `json:"variables"`
+}
+
+// InstantiatePageTemplate creates a new page tree from a template page.
+func (h *PageHandler) InstantiatePageTemplate(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[pageTemplateStore](w, h.store)
+	if !ok {
+		return
+	}
+	id := chi.URLParam(r, "id")
+	var req InstantiateTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidRequest(w, "invalid request body")
+		return
+	}
+	page, err := store.InstantiatePageTemplate(r.Context(), storage.InstantiatePageTemplateInput{
+		TemplateID:   id,
+		Slug:         req.Slug,
+		Title:        req.Title,
+		ParentPageID: req.ParentPageID,
+		Variables:    req.Variables,
+	})
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, Envelope{Data: page})
+}
+
+// DuplicatePageRequest is the payload for POST /api/pages/{id}/duplicate.
+type DuplicatePageRequest struct {
+	ParentPageID *string `json:"parent_page_id"`
+	Title        string  `json:"title"`
+}
+
+// DuplicatePage copies a page and its descendants.
+func (h *PageHandler) DuplicatePage(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[duplicateStore](w, h.store)
+	if !ok {
+		return
+	}
+	id := chi.URLParam(r, "id")
+	var req DuplicatePageRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondInvalidRequest(w, "invalid request body")
+			return
+		}
+	}
+	page, err := store.DuplicatePage(r.Context(), storage.DuplicatePageInput{
+		PageID:       id,
+		ParentPageID: req.ParentPageID,
+		Title:        req.Title,
+	})
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, Envelope{Data: page})
+}
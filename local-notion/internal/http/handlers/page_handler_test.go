@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"archive/zip"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -8,14 +9,20 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/require"
 
+	"github.com/example/agents-playground/internal/storage"
+	"github.com/example/agents-playground/internal/storage/memory"
 	"github.com/example/agents-playground/internal/storage/sqlite"
 )
 
 type responseEnvelope struct {
 	Data   json.RawMessage `json:"data"`
+	Meta   json.RawMessage `json:"meta"`
 	Errors []struct {
+		Code    string `json:"code"`
+		Field   string `json:"field"`
 		Message string `json:"message"`
 	} `json:"errors"`
 }
@@ -67,14 +74,53 @@ func TestPageHandlerCreatePageInvalidJSON(t *testing.T) {
 	var env responseEnvelope
 	require.NoError(t, json.NewDecoder(res.Body).Decode(&env))
 	require.Len(t, env.Errors, 1)
+	require.Equal(t, CodeInvalidRequest, env.Errors[0].Code)
 	require.Contains(t, env.Errors[0].Message, "invalid request body")
 }
 
+func TestPageHandlerCreatePageMapsStoreErrors(t *testing.T) {
+	store := memory.New()
+	handler := NewPageHandler(store)
+	_, err := store.CreatePage(context.Background(), storage.CreatePageInput{Slug: "welcome", Title: "Welcome"})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		body   string
+		status int
+		code   string
+		field  string
+	}{
+		{`{"slug":"welcome","title":"Again"}`, http.StatusConflict, "slug_conflict", "slug"},
+		{`{"slug":"other"}`, http.StatusUnprocessableEntity, "validation_failed", "title"},
+	} {
+		rec := httptest.NewRecorder()
+		handler.CreatePage(rec, httptest.NewRequest(http.MethodPost, "/api/pages", bytes.NewBufferString(tc.body)))
+		require.Equal(t, tc.status, rec.Code)
+		var env responseEnvelope
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&env))
+		require.Len(t, env.Errors, 1)
+		require.Equal(t, tc.code, env.Errors[0].Code)
+		require.Equal(t, tc.field, env.Errors[0].Field)
+		require.NotContains(t, env.Errors[0].Message, "UNIQUE")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/pages/missing", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "missing")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rec := httptest.NewRecorder()
+	handler.GetPage(rec, req)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	var env responseEnvelope
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&env))
+	require.Equal(t, "page_not_found", env.Errors[0].Code)
+}
+
 func TestPageHandlerListPages(t *testing.T) {
-	store := newTestSQLiteStore(t)
+	store := memory.New()
 	handler := NewPageHandler(store)
 
-	_, err := store.CreatePage(context.Background(), sqlite.CreatePageInput{Slug: "alpha", Title: "Alpha"})
+	_, err := store.CreatePage(context.Background(), storage.CreatePageInput{Slug: "alpha", Title: "Alpha"})
 	require.NoError(t, err)
 
 	req := httptest.NewRequest(http.MethodGet, "/api/pages", nil)
@@ -106,3 +152,160 @@ func newTestSQLiteStore(t *testing.T) *sqlite.Store {
 	})
 	return store
 }
+
+func TestPageHandlerExportPageStreamsZip(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	handler := NewPageHandler(store)
+
+	page, err := store.CreatePage(context.Background(), storage.CreatePageInput{Slug: "notes", Title: "Notes", Content: "hello"})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/pages/"+page.ID+"/export", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", page.ID)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rec := httptest.NewRecorder()
+
+	handler.ExportPage(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/zip", rec.Header().Get("Content-Type"))
+	zr, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	require.NoError(t, err)
+	require.Len(t, zr.File, 1)
+	require.Equal(t, "notes.md", zr.File[0].Name)
+}
+
+func TestPageHandlerExportPageNotImplemented(t *testing.T) {
+	store := memory.New()
+	handler := NewPageHandler(store)
+
+	page, err := store.CreatePage(context.Background(), storage.CreatePageInput{Slug: "notes", Title: "Notes"})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/pages/"+page.ID+"/export", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", page.ID)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rec := httptest.NewRecorder()
+
+	handler.ExportPage(rec, req)
+
+	require.Equal(t, http.StatusNotImplemented, rec.Code)
+	var env responseEnvelope
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&env))
+	require.Len(t, env.Errors, 1)
+	require.Equal(t, CodeNotImplemented, env.Errors[0].Code)
+}
+
+func TestPageHandlerRestorePageRevision(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	handler := NewPageHandler(store)
+	ctx := context.Background()
+
+	page, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "notes", Title: "Notes", Content: "hello"})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/pages/"+page.ID, bytes.NewBufferString(`{"content":"goodbye"}`))
+	req.Header.Set("X-Actor", "ana")
+	req.Header.Set("If-Match", `"1"`)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", page.ID)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rec := httptest.NewRecorder()
+	handler.UpdatePage(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	restore := func(body, ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/pages/"+page.ID+"/revisions/1/restore", bytes.NewBufferString(body))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", page.ID)
+		rctx.URLParams.Add("revision", "1")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		rec := httptest.NewRecorder()
+		handler.RestorePageRevision(rec, req)
+		return rec
+	}
+	rec = restore("", "")
+	require.Equal(t, http.StatusPreconditionRequired, rec.Code)
+	rec = restore(`{"version":1}`, "")
+	require.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	require.Equal(t, `"2"`, rec.Header().Get("ETag"))
+	var env responseEnvelope
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&env))
+	require.Contains(t, string(env.Data), `"content":"goodbye"`)
+	rec = restore("", `"2"`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, `"3"`, rec.Header().Get("ETag"))
+
+	revisions, err := store.ListPageRevisions(ctx, page.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 3)
+	require.Equal(t, "hello", revisions[0].Content)
+	require.Equal(t, "ana", revisions[1].Author)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/pages/"+page.ID+"/revisions/diff?from=1&to=7", nil)
+	rctx = chi.NewRouteContext()
+	rctx.URLParams.Add("id", page.ID)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rec = httptest.NewRecorder()
+	handler.DiffPageRevisions(rec, req)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestPageHandlerUpdatePageRequiresMatchingVersion(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	handler := NewPageHandler(store)
+	page, err := store.CreatePage(context.Background(), storage.CreatePageInput{Slug: "notes", Title: "Notes"})
+	require.NoError(t, err)
+
+	update := func(body, ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/api/pages/"+page.ID, bytes.NewBufferString(body))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", page.ID)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		rec := httptest.NewRecorder()
+		handler.UpdatePage(rec, req)
+		return rec
+	}
+
+	require.Equal(t, http.StatusPreconditionRequired, update(`{"title":"A"}`, "").Code)
+
+	rec := update(`{"title":"A"}`, `"1"`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, `"2"`, rec.Header().Get("ETag"))
+
+	// A second writer still holding version 1 is refused and sent the current page.
+	rec = update(`{"title":"B"}`, `"1"`)
+	require.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	require.Equal(t, `"2"`, rec.Header().Get("ETag"))
+	var env responseEnvelope
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&env))
+	require.Equal(t, "version_mismatch", env.Errors[0].Code)
+	var current struct {
+		Title   string `json:"title"`
+		Version int    `json:"version"`
+	}
+	require.NoError(t, json.Unmarshal(env.Data, &current))
+	require.Equal(t, "A", current.Title)
+	require.Equal(t, 2, current.Version)
+
+	// Clients that cannot set headers send the version in the body.
+	require.Equal(t, http.StatusOK, update(`{"title":"B","version":2}`, "").Code)
+	require.Equal(t, http.StatusOK, update(`{"title":"C"}`, "*").Code)
+
+	// A list matches when any of its strong tags names the current version.
+	rec = update(`{"title":"D"}`, `"3", "4"`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, `"5"`, rec.Header().Get("ETag"))
+	require.Equal(t, http.StatusPreconditionFailed, update(`{"title":"E"}`, `"1", "2"`).Code)
+	require.Equal(t, http.StatusPreconditionFailed, update(`{"title":"E"}`, `W/"5"`).Code)
+	require.Equal(t, http.StatusPreconditionFailed, update(`{"title":"E"}`, `W/"5", "4"`).Code)
+	require.Equal(t, http.StatusBadRequest, update(`{"title":"E"}`, `"5", 6`).Code)
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// RecurrenceHandler manages recurring database items.
+type RecurrenceHandler struct {
+	store storage.Store
+}
+
+// NewRecurrenceHandler constructs handler.
+func NewRecurrenceHandler(store storage.Store) *RecurrenceHandler {
+	return &RecurrenceHandler{store: store}
+}
+
+// SetRecurrenceRequest is the payload for POST
+// /api/databases/{id}/items/{itemID}/recurrence. Property and
+// complete_property are property slugs.
+type SetRecurrenceRequest struct {
+	Property         string   `json:"property"`
+	Rule             string   `json:"rule"`
+	Exceptions       []string `json:"exceptions"`
+	Generate         string   `json:"generate"`
+	CompleteProperty string   `json:"complete_property"`
+}
+
+// UpdateRecurrenceRequest is the payload for PATCH
+// /api/databases/{id}/items/{itemID}/recurrence. Omitted fields are left
+// unchanged.
+type UpdateRecurrenceRequest struct {
+	Scope      string         `json:"scope"`
+	Rule       *string        `json:"rule"`
+	Exceptions []string       `json:"exceptions"`
+	Values     map[string]any `json:"values"`
+}
+
+// GetItemRecurrence handles GET /api/databases/{id}/items/{itemID}/recurrence.
+func (h *RecurrenceHandler) GetItemRecurrence(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[recurrenceStore](w, h.store)
+	if !ok {
+		return
+	}
+	databaseID, itemID := itemRouteParams(r)
+	series, err := store.GetItemRecurrence(r.Context(), databaseID, itemID)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, Envelope{Data: series})
+}
+
+// GetRecurrenceSeries handles GET /api/databases/{id}/series/{seriesID}.
+func (h *RecurrenceHandler) GetRecurrenceSeries(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[recurrenceStore](w, h.store)
+	if !ok {
+		return
+	}
+	series, err := store.GetRecurrenceSeries(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "seriesID"))
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, Envelope{Data: series})
+}
+
+// SetItemRecurrence handles POST /api/databases/{id}/items/{itemID}/recurrence,
+// starting a series at the item.
+func (h *RecurrenceHandler) SetItemRecurrence(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[recurrenceStore](w, h.store)
+	if !ok {
+		return
+	}
+	var req SetRecurrenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidRequest(w, "invalid request body")
+		return
+	}
+	databaseID, itemID := itemRouteParams(r)
+	series, err := store.SetItemRecurrence(r.Context(), storage.SetItemRecurrenceInput{
+		DatabaseID:       databaseID,
+		ItemID:           itemID,
+		Property:         req.Property,
+		Rule:             req.Rule,
+		Exceptions:       req.Exceptions,
+		Generate:         req.Generate,
+		CompleteProperty: req.CompleteProperty,
+	})
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, Envelope{Data: series})
+}
+
+// UpdateItemRecurrence handles PATCH
+// /api/databases/{id}/items/{itemID}/recurrence. scope "following" edits the
+// item and the ones after it by splitting the series.
+func (h *RecurrenceHandler) UpdateItemRecurrence(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[recurrenceStore](w, h.store)
+	if !ok {
+		return
+	}
+	var req UpdateRecurrenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidRequest(w, "invalid request body")
+		return
+	}
+	databaseID, itemID := itemRouteParams(r)
+	series, err := store.UpdateItemRecurrence(r.Context(), storage.UpdateItemRecurrenceInput{
+		DatabaseID: databaseID,
+		ItemID:     itemID,
+		Scope:      req.Scope,
+		Rule:       req.Rule,
+		Exceptions: req.Exceptions,
+		Values:     req.Values,
+		Author:     requestActor(r),
+	})
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, Envelope{Data: series})
+}
+
+// EndItemRecurrence handles DELETE
+// /api/databases/{id}/items/{itemID}/recurrence. The series stops creating
+// items; existing items are kept.
+func (h *RecurrenceHandler) EndItemRecurrence(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[recurrenceStore](w, h.store)
+	if !ok {
+		return
+	}
+	databaseID, itemID := itemRouteParams(r)
+	if err := store.EndItemRecurrence(r.Context(), databaseID, itemID); err != nil {
+		respondError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
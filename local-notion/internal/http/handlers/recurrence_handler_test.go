@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+	"github.com/example/agents-playground/internal/storage/memory"
+)
+
+func TestRecurrenceHandlerSeries(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+	db, err := store.CreateDatabase(ctx, storage.CreateDatabaseInput{
+		Slug: "chores", Title: "Chores",
+		Properties: []storage.DatabasePropertyInput{
+			{Slug: "due", Name: "Due", Type: domain.PropertyTypeDate},
+			{Slug: "done", Name: "Done", Type: domain.PropertyTypeCheckbox},
+		},
+	})
+	require.NoError(t, err)
+	item, err := store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
+		DatabaseID: db.ID,
+		Page:       storage.CreatePageInput{Title: "Vacuum"},
+		Values:     map[string]any{"due": "2026-03-07"},
+	})
+	require.NoError(t, err)
+	recurrenceHandler := NewRecurrenceHandler(store)
+
+	request := func(method, body string, params ...string) *http.Request {
+		req := httptest.NewRequest(method, "/", strings.NewReader(body))
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", db.ID)
+		for i := 0; i+1 < len(params); i += 2 {
+			rctx.URLParams.Add(params[i], params[i+1])
+		}
+		return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	}
+
+	rec := httptest.NewRecorder()
+	recurrenceHandler.GetItemRecurrence(rec, request(http.MethodGet, "", "itemID", item.ID))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	recurrenceHandler.SetItemRecurrence(rec, request(http.MethodPost, `{"property":"due","rule":"FREQ=FORTNIGHTLY"}`, "itemID", item.ID))
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = httptest.NewRecorder()
+	recurrenceHandler.SetItemRecurrence(rec, request(http.MethodPost, `{"property":"due","rule":"FREQ=WEEKLY;BYDAY=SA,SU","complete_property":"done"}`, "itemID", item.ID))
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var env responseEnvelope
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&env))
+	var series domain.RecurrenceSeries
+	require.NoError(t, json.Unmarshal(env.Data, &series))
+	require.Equal(t, "on_complete", series.Generate)
+	require.Equal(t, "2026-03-08", series.Next.Format("2006-01-02"))
+
+	rec = httptest.NewRecorder()
+	recurrenceHandler.UpdateItemRecurrence(rec, request(http.MethodPatch, `{"exceptions":["2026-03-08"]}`, "itemID", item.ID))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	recurrenceHandler.GetRecurrenceSeries(rec, request(http.MethodGet, "", "seriesID", series.ID))
+	require.Equal(t, http.StatusOK, rec.Code)
+	env = responseEnvelope{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&env))
+	require.NoError(t, json.Unmarshal(env.Data, &series))
+	require.Equal(t, []string{"2026-03-08"}, series.Exceptions)
+	require.Equal(t, "2026-03-14", series.Next.Format("2006-01-02"))
+
+	rec = httptest.NewRecorder()
+	recurrenceHandler.EndItemRecurrence(rec, request(http.MethodDelete, "", "itemID", item.ID))
+	require.Equal(t, http.StatusNoContent, rec.Code)
+}
+
+func TestRecurrenceHandlerNotImplemented(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewRecurrenceHandler(memory.New()).GetItemRecurrence(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusNotImplemented, rec.Code)
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// ReminderHandler lists scheduled date reminders.
+type ReminderHandler struct {
+	store storage.Store
+}
+
+// NewReminderHandler constructs handler.
+func NewReminderHandler(store storage.Store) *ReminderHandler {
+	return &ReminderHandler{store: store}
+}
+
+// ListUpcomingReminders handles GET /api/reminders. database_id narrows the
+// list to one database, until (RFC 3339) to reminders firing before it and
+// limit caps the page.
+func (h *ReminderHandler) ListUpcomingReminders(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[reminderStore](w, h.store)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	filter := storage.ReminderFilter{DatabaseID: query.Get("database_id")}
+	if v := query.Get("until"); v != "" {
+		until, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			respondInvalidRequest(w, "until must be an RFC 3339 timestamp")
+			return
+		}
+		filter.Until = &until
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			respondInvalidRequest(w, "limit must be a positive integer")
+			return
+		}
+		filter.Limit = limit
+	}
+	reminders, err := store.ListUpcomingReminders(r.Context(), filter)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, Envelope{Data: reminders})
+}
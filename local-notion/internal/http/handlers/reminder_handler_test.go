@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+	"github.com/example/agents-playground/internal/storage/memory"
+)
+
+func TestReminderHandlerListsUpcomingReminders(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+	db, err := store.CreateDatabase(ctx, storage.CreateDatabaseInput{
+		Slug: "tasks", Title: "Tasks",
+		Properties: []storage.DatabasePropertyInput{{Slug: "due", Name: "Due", Type: domain.PropertyTypeDate,
+			Config: map[string]any{"time_zone": "Europe/Berlin", "reminder": map[string]any{"before": 1, "unit": "days", "time": "08:30"}}}},
+	})
+	require.NoError(t, err)
+	_, err = store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
+		DatabaseID: db.ID,
+		Page:       storage.CreatePageInput{Title: "Renew passport"},
+		Values:     map[string]any{"due": "2099-03-29"},
+	})
+	require.NoError(t, err)
+	reminderHandler := NewReminderHandler(store)
+
+	rec := httptest.NewRecorder()
+	reminderHandler.ListUpcomingReminders(rec, httptest.NewRequest(http.MethodGet, "/api/reminders?database_id="+db.ID, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var env responseEnvelope
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&env))
+	var reminders []domain.Reminder
+	require.NoError(t, json.Unmarshal(env.Data, &reminders))
+	require.Len(t, reminders, 1)
+	require.Equal(t, "Renew passport", reminders[0].Title)
+	require.True(t, time.Date(2099, 3, 28, 7, 30, 0, 0, time.UTC).Equal(reminders[0].FireAt))
+
+	rec = httptest.NewRecorder()
+	reminderHandler.ListUpcomingReminders(rec, httptest.NewRequest(http.MethodGet, "/api/reminders?until=2030-01-01T00:00:00Z", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	env = responseEnvelope{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&env))
+	require.JSONEq(t, `[]`, string(env.Data))
+
+	rec = httptest.NewRecorder()
+	reminderHandler.ListUpcomingReminders(rec, httptest.NewRequest(http.MethodGet, "/api/reminders?until=tomorrow", nil))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestReminderHandlerNotImplemented(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewReminderHandler(memory.New()).ListUpcomingReminders(rec, httptest.NewRequest(http.MethodGet, "/api/reminders", nil))
+	require.Equal(t, http.StatusNotImplemented, rec.Code)
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// UpdatePageRequest is the payload for PATCH /api/pages/{id}. Omitted fields
+// are left unchanged. Version stands in for If-Match.
+type UpdatePageRequest struct {
+	Slug          *string  `json:"slug"`
+	Title         *string  `json:"title"`
+	Summary       *string  `json:"summary"`
+	Content       *string  `json:"content"`
+	Tags          []string `json:"tags"`
+	LinkedPageIDs []string `json:"linked_page_ids"`
+	Version       *int     `json:"version"`
+}
+
+// UpdatePage applies a partial update to a page and records a revision. The
+// update must name the version it was based on.
+func (h *PageHandler) UpdatePage(w http.ResponseWriter, r *http.Request) {
+	var req UpdatePageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidRequest(w, "invalid request body")
+		return
+	}
+	id := chi.URLParam(r, "id")
+	current := func() (any, int, error) {
+		current, err := h.store.GetPage(r.Context(), id)
+		if err != nil {
+			return nil, 0, err
+		}
+		return current, current.Version, nil
+	}
+	version, ok := expectedVersion(w, r, req.Version, current)
+	if !ok {
+		return
+	}
+	page, err := h.store.UpdatePage(r.Context(), storage.UpdatePageInput{
+		PageID:          id,
+		Slug:            req.Slug,
+		Title:           req.Title,
+		Summary:         req.Summary,
+		Content:         req.Content,
+		Tags:            req.Tags,
+		LinkedPageIDs:   req.LinkedPageIDs,
+		Author:          requestActor(r),
+		ExpectedVersion: version,
+	})
+	if err != nil {
+		respondUpdateError(w, r, err, current)
+		return
+	}
+	respondVersioned(w, http.StatusOK, page.Version, page)
+}
+
+// ListPageRevisions returns the revision history of a page, newest first.
+func (h *PageHandler) ListPageRevisions(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[pageRevisionStore](w, h.store)
+	if !ok {
+		return
+	}
+	revisions, err := store.ListPageRevisions(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, Envelope{Data: revisions})
+}
+
+// DiffPageRevisions compares two revisions given by the from and to query
+// parameters; to defaults to the latest revision.
+func (h *PageHandler) DiffPageRevisions(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[pageRevisionStore](w, h.store)
+	if !ok {
+		return
+	}
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil || from < 1 {
+		respondInvalidRequest(w, "from must be a revision number")
+		return
+	}
+	to := 0
+	if v := r.URL.Query().Get("to"); v != "" {
+		if to, err = strconv.Atoi(v); err != nil || to < 1 {
+			respondInvalidRequest(w, "to must be a revision number")
+			return
+		}
+	}
+	diff, err := store.DiffPageRevisions(r.Context(), chi.URLParam(r, "id"), from, to)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, Envelope{Data: diff})
+}
+
+// RestorePageRevisionRequest is the optional payload of POST
+// /api/pages/{id}/revisions/{revision}/restore. Version stands in for If-Match.
+type RestorePageRevisionRequest struct {
+	Version *int `json:"version"`
+}
+
+// RestorePageRevision puts an earlier revision back on the page. Like an
+// update, the restore must name the page version it was based on.
+func (h *PageHandler) RestorePageRevision(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[pageRevisionStore](w, h.store)
+	if !ok {
+		return
+	}
+	revision, err := strconv.Atoi(chi.URLParam(r, "revision"))
+	if err != nil || revision < 1 {
+		respondInvalidRequest(w, "invalid revision number")
+		return
+	}
+	var req RestorePageRevisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondInvalidRequest(w, "invalid request body")
+		return
+	}
+	id := chi.URLParam(r, "id")
+	current := func() (any, int, error) {
+		current, err := h.store.GetPage(r.Context(), id)
+		if err != nil {
+			return nil, 0, err
+		}
+		return current, current.Version, nil
+	}
+	version, ok := expectedVersion(w, r, req.Version, current)
+	if !ok {
+		return
+	}
+	page, err := store.RestorePageRevision(r.Context(), storage.RestorePageRevisionInput{
+		PageID:          id,
+		Revision:        revision,
+		Author:          requestActor(r),
+		ExpectedVersion: version,
+	})
+	if err != nil {
+		respondUpdateError(w, r, err, current)
+		return
+	}
+	respondVersioned(w, http.StatusOK, page.Version, page)
+}
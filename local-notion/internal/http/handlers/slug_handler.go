@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// UpdateDatabaseSlugRequest is the payload for PUT /api/databases/{id}/slug.
+// Version stands in for If-Match.
+type UpdateDatabaseSlugRequest struct {
+	Slug    string `json:"slug"`
+	Version *int   `json:"version"`
+}
+
+// GetPageBySlug handles GET /api/pages/by-slug/{slug}. A slug the page used
+// before being renamed answers 301 pointing at its current slug, with the
+// page in the body for clients that do not follow redirects.
+func (h *PageHandler) GetPageBySlug(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[slugStore](w, h.store)
+	if !ok {
+		return
+	}
+	slug := chi.URLParam(r, "slug")
+	page, err := store.GetPageBySlug(r.Context(), slug)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	if page.Slug != slug {
+		respondSlugRedirect(w, "/api/pages/by-slug/", page.Slug, page.Version, page)
+		return
+	}
+	respondVersioned(w, http.StatusOK, page.Version, page)
+}
+
+// GetDatabaseBySlug handles GET /api/databases/by-slug/{slug}, redirecting
+// former slugs like GetPageBySlug.
+func (h *DatabaseHandler) GetDatabaseBySlug(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[slugStore](w, h.store)
+	if !ok {
+		return
+	}
+	slug := chi.URLParam(r, "slug")
+	database, err := store.GetDatabaseBySlug(r.Context(), slug)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	if database.Slug != slug {
+		respondSlugRedirect(w, "/api/databases/by-slug/", database.Slug, database.Version, database)
+		return
+	}
+	respondVersioned(w, http.StatusOK, database.Version, database)
+}
+
+// UpdateDatabaseSlug handles PUT /api/databases/{id}/slug. The old slug keeps
+// resolving to the database. The update must name the version it was based
+// on.
+func (h *DatabaseHandler) UpdateDatabaseSlug(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[slugStore](w, h.store)
+	if !ok {
+		return
+	}
+	var req UpdateDatabaseSlugRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidRequest(w, "invalid request body")
+		return
+	}
+	id := chi.URLParam(r, "id")
+	current := func() (any, int, error) {
+		current, err := h.store.GetDatabase(r.Context(), id)
+		if err != nil {
+			return nil, 0, err
+		}
+		return current, current.Version, nil
+	}
+	version, ok := expectedVersion(w, r, req.Version, current)
+	if !ok {
+		return
+	}
+	database, err := store.UpdateDatabaseSlug(r.Context(), storage.UpdateDatabaseSlugInput{
+		DatabaseID:      id,
+		Slug:            req.Slug,
+		ExpectedVersion: version,
+	})
+	if err != nil {
+		respondUpdateError(w, r, err, current)
+		return
+	}
+	respondVersioned(w, http.StatusOK, database.Version, database)
+}
+
+// respondSlugRedirect answers 301 with the location of the current slug under
+// prefix.
+func respondSlugRedirect(w http.ResponseWriter, prefix, slug string, version int, data any) {
+	w.Header().Set("Location", prefix+url.PathEscape(slug))
+	respondVersioned(w, http.StatusMovedPermanently, version, data)
+}
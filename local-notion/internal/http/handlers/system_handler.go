@@ -2,30 +2,47 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/example/agents-playground/internal/config"
-	"github.com/example/agents-playground/internal/storage/sqlite"
+	"github.com/example/agents-playground/internal/storage"
 )
 
 // HealthHandler returns liveness info.
-func HealthHandler(store *sqlite.Store) http.HandlerFunc {
+func HealthHandler(store storage.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if err := store.Ping(r.Context()); err != nil {
-			respondJSON(w, http.StatusServiceUnavailable, Envelope{Errors: []APIError{{Message: err.Error()}}})
+			respondJSON(w, http.StatusServiceUnavailable, Envelope{Errors: []APIError{{Code: CodeUnavailable, Message: err.Error()}}})
 			return
 		}
 		respondJSON(w, http.StatusOK, Envelope{Data: map[string]any{"status": "ok", "time": time.Now().UTC()}})
 	}
 }
 
-// MetricsHandler exposes simple metrics placeholder.
-func MetricsHandler() http.HandlerFunc {
+// MetricsHandler exposes metrics in the Prometheus text format, including the
+// schema cache counters of backends that keep one.
+func MetricsHandler(store storage.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
 		_, _ = w.Write([]byte("# TYPE platform_requests_total counter\n"))
 		_, _ = w.Write([]byte("platform_requests_total 0\n"))
+		if cache, ok := store.(schemaCacheStore); ok {
+			stats := cache.SchemaCacheStats()
+			for _, metric := range []struct {
+				name, kind string
+				value      any
+			}{
+				{"schema_cache_hits_total", "counter", stats.Hits},
+				{"schema_cache_misses_total", "counter", stats.Misses},
+				{"schema_cache_evictions_total", "counter", stats.Evictions},
+				{"schema_cache_entries", "gauge", stats.Entries},
+				{"schema_cache_capacity", "gauge", stats.Capacity},
+			} {
+				_, _ = fmt.Fprintf(w, "# TYPE %s %s\n%s %v\n", metric.name, metric.kind, metric.name, metric.value)
+			}
+		}
 	}
 }
 
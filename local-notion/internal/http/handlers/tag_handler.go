@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// TagHandler manages page tags across the workspace.
+type TagHandler struct {
+	store storage.Store
+}
+
+// NewTagHandler constructs handler.
+func NewTagHandler(store storage.Store) *TagHandler {
+	return &TagHandler{store: store}
+}
+
+// RenameTagRequest is the payload for PATCH /api/tags/{name}.
+type RenameTagRequest struct {
+	Name string `json:"name"`
+}
+
+// MergeTagsRequest is the payload for POST /api/tags/merge.
+type MergeTagsRequest struct {
+	Sources []string `json:"sources"`
+	Target  string   `json:"target"`
+}
+
+// ListTags handles GET /api/tags, returning every tag in use with its page
+// count, most used first.
+func (h *TagHandler) ListTags(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[tagStore](w, h.store)
+	if !ok {
+		return
+	}
+	tags, err := store.ListTags(r.Context())
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, Envelope{Data: tags})
+}
+
+// RenameTag handles PATCH /api/tags/{name}, renaming the tag on every page.
+// Renaming onto an existing tag merges the two.
+func (h *TagHandler) RenameTag(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[tagStore](w, h.store)
+	if !ok {
+		return
+	}
+	var req RenameTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidRequest(w, "invalid request body")
+		return
+	}
+	tag, err := store.RenameTag(r.Context(), storage.RenameTagInput{
+		Name:    chi.URLParam(r, "name"),
+		NewName: req.Name,
+		Author:  requestActor(r),
+	})
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, Envelope{Data: tag})
+}
+
+// MergeTags handles POST /api/tags/merge, replacing the source tags with the
+// target on every page.
+func (h *TagHandler) MergeTags(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[tagStore](w, h.store)
+	if !ok {
+		return
+	}
+	var req MergeTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidRequest(w, "invalid request body")
+		return
+	}
+	tag, err := store.MergeTags(r.Context(), storage.MergeTagsInput{
+		Sources: req.Sources,
+		Target:  req.Target,
+		Author:  requestActor(r),
+	})
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, Envelope{Data: tag})
+}
+
+func (h *PageHandler) listPagesByTags(w http.ResponseWriter, r *http.Request, tags []string) {
+	store, ok := supports[tagStore](w, h.store)
+	if !ok {
+		return
+	}
+	var matchAll bool
+	switch r.URL.Query().Get("match") {
+	case "", "any":
+	case "all":
+		matchAll = true
+	default:
+		respondInvalidRequest(w, "match must be any or all")
+		return
+	}
+	pages, err := store.ListPagesByTags(r.Context(), tags, matchAll)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, Envelope{Data: pages})
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+	"github.com/example/agents-playground/internal/storage/memory"
+)
+
+func TestTagHandlerRenameAndQueryPages(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+	for slug, tags := range map[string][]string{"alpha": {"golang", "db"}, "beta": {"go"}} {
+		_, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: slug, Title: slug, Tags: tags})
+		require.NoError(t, err)
+	}
+	tagHandler := NewTagHandler(store)
+
+	rename := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPatch, "/api/tags/golang", strings.NewReader(`{"name":"go"}`))
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("name", "golang")
+		return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	}
+	rec := httptest.NewRecorder()
+	tagHandler.RenameTag(rec, rename())
+	require.Equal(t, http.StatusOK, rec.Code)
+	var env responseEnvelope
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&env))
+	var tag domain.Tag
+	require.NoError(t, json.Unmarshal(env.Data, &tag))
+	require.Equal(t, domain.Tag{Name: "go", PageCount: 2}, tag)
+
+	rec = httptest.NewRecorder()
+	tagHandler.RenameTag(rec, rename())
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	NewPageHandler(store).ListPages(rec, httptest.NewRequest(http.MethodGet, "/api/pages?tag=go&tag=db&match=all", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	env = responseEnvelope{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&env))
+	var pages []map[string]any
+	require.NoError(t, json.Unmarshal(env.Data, &pages))
+	require.Len(t, pages, 1)
+	require.Equal(t, "alpha", pages[0]["slug"])
+
+	rec = httptest.NewRecorder()
+	NewPageHandler(store).ListPages(rec, httptest.NewRequest(http.MethodGet, "/api/pages?tag=go&match=some", nil))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestTagHandlerNotImplemented(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewTagHandler(memory.New()).ListTags(rec, httptest.NewRequest(http.MethodGet, "/api/tags", nil))
+	require.Equal(t, http.StatusNotImplemented, rec.Code)
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 )
 
 // respondJSON writes JSON responses with envelope structure.
@@ -11,3 +12,9 @@ func respondJSON(w http.ResponseWriter, status int, envelope Envelope) {
 	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(envelope)
 }
+
+// requestActor returns the caller named in the X-Actor header, or "" when the
+// request is anonymous.
+func requestActor(r *http.Request) string {
+	return strings.TrimSpace(r.Header.Get("X-Actor"))
+}
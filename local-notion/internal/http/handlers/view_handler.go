@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// UpdateViewRequest is the payload for PATCH /api/databases/{id}/views/{viewID}.
+// Omitted fields are left unchanged.
+type UpdateViewRequest struct {
+	Name          *string           `json:"name"`
+	Type          *domain.ViewType  `json:"type"`
+	Filters       map[string]any    `json:"filters"`
+	Sorts         []domain.ViewSort `json:"sorts"`
+	Grouping      map[string]any    `json:"grouping"`
+	Display       []string          `json:"display_properties"`
+	LayoutOptions map[string]any    `json:"layout_options"`
+	Version       *int              `json:"version"`
+}
+
+// DuplicateViewRequest is the optional payload for POST /api/databases/{id}/views/{viewID}/duplicate.
+type DuplicateViewRequest struct {
+	Name string `json:"name"`
+}
+
+func viewRouteParams(r *http.Request) (string, string) {
+	return chi.URLParam(r, "id"), chi.URLParam(r, "viewID")
+}
+
+// CreateView handles POST /api/databases/{id}/views.
+func (h *DatabaseHandler) CreateView(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[viewStore](w, h.store)
+	if !ok {
+		return
+	}
+	var req DatabaseViewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidRequest(w, "invalid request body")
+		return
+	}
+	view, err := store.CreateDatabaseView(r.Context(), chi.URLParam(r, "id"), storage.DatabaseViewInput{
+		Name:          req.Name,
+		Type:          req.Type,
+		Filters:       req.Filters,
+		Sorts:         req.Sorts,
+		Grouping:      req.Grouping,
+		Display:       req.Display,
+		LayoutOptions: req.LayoutOptions,
+	})
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, Envelope{Data: view})
+}
+
+// GetView handles GET /api/databases/{id}/views/{viewID}.
+func (h *DatabaseHandler) GetView(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[viewStore](w, h.store)
+	if !ok {
+		return
+	}
+	databaseID, viewID := viewRouteParams(r)
+	view, err := store.GetDatabaseView(r.Context(), databaseID, viewID)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondVersioned(w, http.StatusOK, view.Version, view)
+}
+
+// UpdateView handles PATCH /api/databases/{id}/views/{viewID}. The update must
+// name the view version it was based on.
+func (h *DatabaseHandler) UpdateView(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[viewStore](w, h.store)
+	if !ok {
+		return
+	}
+	databaseID, viewID := viewRouteParams(r)
+	var req UpdateViewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidRequest(w, "invalid request body")
+		return
+	}
+	current := func() (any, int, error) {
+		current, err := store.GetDatabaseView(r.Context(), databaseID, viewID)
+		if err != nil {
+			return nil, 0, err
+		}
+		return current, current.Version, nil
+	}
+	version, ok := expectedVersion(w, r, req.Version, current)
+	if !ok {
+		return
+	}
+	view, err := store.UpdateDatabaseView(r.Context(), storage.UpdateDatabaseViewInput{
+		DatabaseID:      databaseID,
+		ViewID:          viewID,
+		Name:            req.Name,
+		Type:            req.Type,
+		Filters:         req.Filters,
+		Sorts:           req.Sorts,
+		Grouping:        req.Grouping,
+		Display:         req.Display,
+		LayoutOptions:   req.LayoutOptions,
+		ExpectedVersion: version,
+	})
+	if err != nil {
+		respondUpdateError(w, r, err, current)
+		return
+	}
+	respondVersioned(w, http.StatusOK, view.Version, view)
+}
+
+// DeleteView handles DELETE /api/databases/{id}/views/{viewID}.
+func (h *DatabaseHandler) DeleteView(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[viewStore](w, h.store)
+	if !ok {
+		return
+	}
+	databaseID, viewID := viewRouteParams(r)
+	if err := store.DeleteDatabaseView(r.Context(), databaseID, viewID); err != nil {
+		respondError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DuplicateView handles POST /api/databases/{id}/views/{viewID}/duplicate.
+func (h *DatabaseHandler) DuplicateView(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[duplicateStore](w, h.store)
+	if !ok {
+		return
+	}
+	databaseID, viewID := viewRouteParams(r)
+	var req DuplicateViewRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondInvalidRequest(w, "invalid request body")
+			return
+		}
+	}
+	view, err := store.DuplicateDatabaseView(r.Context(), storage.DuplicateDatabaseViewInput{DatabaseID: databaseID, ViewID: viewID, Name: req.Name})
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, Envelope{Data: view})
+}
+
+// ListViewVersions handles GET /api/databases/{id}/views/{viewID}/versions.
+func (h *DatabaseHandler) ListViewVersions(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[viewStore](w, h.store)
+	if !ok {
+		return
+	}
+	databaseID, viewID := viewRouteParams(r)
+	versions, err := store.ListDatabaseViewVersions(r.Context(), databaseID, viewID)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, Envelope{Data: versions})
+}
+
+// RestoreViewVersion handles POST /api/databases/{id}/views/{viewID}/versions/{version}/restore.
+// Restoring a version that deleted the view deletes it again and answers 204.
+func (h *DatabaseHandler) RestoreViewVersion(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[viewStore](w, h.store)
+	if !ok {
+		return
+	}
+	databaseID, viewID := viewRouteParams(r)
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil || version < 1 {
+		respondInvalidRequest(w, "version must be a positive integer")
+		return
+	}
+	view, err := store.RestoreDatabaseView(r.Context(), databaseID, viewID, version)
+	respondRestoredView(w, r, view, err)
+}
+
+// UndoView handles POST /api/databases/{id}/views/{viewID}/undo.
+func (h *DatabaseHandler) UndoView(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[viewStore](w, h.store)
+	if !ok {
+		return
+	}
+	databaseID, viewID := viewRouteParams(r)
+	view, err := store.UndoDatabaseView(r.Context(), databaseID, viewID)
+	respondRestoredView(w, r, view, err)
+}
+
+func respondRestoredView(w http.ResponseWriter, r *http.Request, view *domain.DatabaseView, err error) {
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	if view == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	respondJSON(w, http.StatusOK, Envelope{Data: view})
+}
+
+// PivotView handles GET /api/databases/{id}/views/{viewID}/pivot. Query
+// parameters: rows and columns name dimension property ids, rows_bucket and
+// columns_bucket set the date bucket, measure is count, sum or average and
+// measure_property names the number property for sum and average.
+func (h *DatabaseHandler) PivotView(w http.ResponseWriter, r *http.Request) {
+	store, ok := supports[viewStore](w, h.store)
+	if !ok {
+		return
+	}
+	databaseID, viewID := viewRouteParams(r)
+	query := r.URL.Query()
+	in := storage.PivotViewInput{
+		DatabaseID:        databaseID,
+		ViewID:            viewID,
+		Rows:              storage.PivotDimension{PropertyID: query.Get("rows"), Bucket: query.Get("rows_bucket")},
+		Measure:           query.Get("measure"),
+		MeasurePropertyID: query.Get("measure_property"),
+	}
+	if in.Measure == "" {
+		in.Measure = storage.PivotMeasureCount
+	}
+	if columns := query.Get("columns"); columns != "" {
+		in.Columns = &storage.PivotDimension{PropertyID: columns, Bucket: query.Get("columns_bucket")}
+	}
+	result, err := store.PivotView(r.Context(), in)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, Envelope{Data: result})
+}
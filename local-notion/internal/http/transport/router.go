@@ -11,42 +11,129 @@ import (
 	"github.com/example/agents-playground/internal/config"
 	"github.com/example/agents-playground/internal/http/handlers"
 	"github.com/example/agents-playground/internal/logging"
-	"github.com/example/agents-playground/internal/storage/sqlite"
+	"github.com/example/agents-playground/internal/storage"
 )
 
 // NewRouter wires up HTTP routing for the platform API.
-func NewRouter(cfg config.Config, store *sqlite.Store) http.Handler {
+func NewRouter(cfg config.Config, store storage.Store) http.Handler {
 	r := chi.NewRouter()
 	r.Use(middleware.RequestID)
+	r.Use(handlers.AuditContext)
 	r.Use(logging.RequestLogger)
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(30 * time.Second))
 
 	pageHandler := handlers.NewPageHandler(store)
 	databaseHandler := handlers.NewDatabaseHandler(store)
+	importHandler := handlers.NewImportHandler(store, cfg.AssetDir)
+	assetHandler := handlers.NewAssetHandler(store)
+	auditHandler := handlers.NewAuditHandler(store)
+	tagHandler := handlers.NewTagHandler(store)
+	commentHandler := handlers.NewCommentHandler(store)
+	notificationHandler := handlers.NewNotificationHandler(store)
+	reminderHandler := handlers.NewReminderHandler(store)
+	recurrenceHandler := handlers.NewRecurrenceHandler(store)
 
-	r.Get("/", handlers.IndexHandler())
-	r.Get("/favicon.ico", handlers.FaviconHandler())
+	// The notification stream stays open for as long as the client listens,
+	// and Markdown exports stream archives of any size, so these routes run
+	// without a request timeout.
+	r.Get("/api/notifications/stream", notificationHandler.StreamNotifications)
+	r.Get("/api/export", pageHandler.ExportWorkspace)
+	r.Get("/api/pages/{id}/export", pageHandler.ExportPage)
 
-	r.Route("/api", func(api chi.Router) {
-		api.Get("/health", handlers.HealthHandler(store))
-		api.Get("/metrics", handlers.MetricsHandler())
-		api.Get("/config", handlers.ConfigHandler(cfg))
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Timeout(30 * time.Second))
+		r.Get("/", handlers.IndexHandler())
+		r.Get("/favicon.ico", handlers.FaviconHandler())
 
-		api.Route("/pages", func(pr chi.Router) {
-			pr.Get("/", pageHandler.ListPages)
-			pr.Post("/", pageHandler.CreatePage)
-			pr.Route("/{id}", func(r chi.Router) {
-				r.Get("/", pageHandler.GetPage)
+		r.Route("/api", func(api chi.Router) {
+			api.Get("/health", handlers.HealthHandler(store))
+			api.Get("/metrics", handlers.MetricsHandler(store))
+			api.Get("/config", handlers.ConfigHandler(cfg))
+			api.Post("/import/notion", importHandler.ImportNotion)
+			api.Get("/assets/{id}", assetHandler.GetAsset)
+			api.Get("/audit", auditHandler.ListAudit)
+			api.Get("/audit/export", auditHandler.ExportAudit)
+			api.Get("/tags", tagHandler.ListTags)
+			api.Post("/tags/merge", tagHandler.MergeTags)
+			api.Patch("/tags/{name}", tagHandler.RenameTag)
+
+			api.Route("/notifications", func(nr chi.Router) {
+				nr.Get("/", notificationHandler.ListNotifications)
+				nr.Post("/read", notificationHandler.MarkAllNotificationsRead)
+				nr.Get("/preferences", notificationHandler.GetNotificationPreferences)
+				nr.Put("/preferences", notificationHandler.SetNotificationPreferences)
+				nr.Post("/{id}/read", notificationHandler.MarkNotificationRead)
+			})
+			api.Get("/reminders", reminderHandler.ListUpcomingReminders)
+
+			api.Route("/pages", func(pr chi.Router) {
+				pr.Get("/", pageHandler.ListPages)
+				pr.Post("/", pageHandler.CreatePage)
+				pr.Get("/templates", pageHandler.ListPageTemplates)
+				pr.Get("/by-slug/{slug}", pageHandler.GetPageBySlug)
+				pr.Route("/{id}", func(r chi.Router) {
+					r.Get("/", pageHandler.GetPage)
+					r.Patch("/", pageHandler.UpdatePage)
+					r.Get("/revisions", pageHandler.ListPageRevisions)
+					r.Get("/revisions/diff", pageHandler.DiffPageRevisions)
+					r.Post("/revisions/{revision}/restore", pageHandler.RestorePageRevision)
+					r.Post("/instantiate", pageHandler.InstantiatePageTemplate)
+					r.Post("/duplicate", pageHandler.DuplicatePage)
+					r.Route("/threads", func(tr chi.Router) {
+						tr.Get("/", commentHandler.ListCommentThreads)
+						tr.Post("/", commentHandler.CreateCommentThread)
+						tr.Route("/{threadID}", func(r chi.Router) {
+							r.Get("/", commentHandler.GetCommentThread)
+							r.Post("/comments", commentHandler.ReplyToCommentThread)
+							r.Patch("/comments/{commentID}", commentHandler.UpdateComment)
+							r.Delete("/comments/{commentID}", commentHandler.DeleteComment)
+							r.Post("/resolve", commentHandler.ResolveCommentThread)
+							r.Post("/reopen", commentHandler.ReopenCommentThread)
+						})
+					})
+				})
 			})
-		})
 
-		api.Route("/databases", func(dr chi.Router) {
-			dr.Post("/", databaseHandler.CreateDatabase)
-			dr.Route("/{id}", func(r chi.Router) {
-				r.Get("/", databaseHandler.GetDatabase)
-				r.Post("/items", databaseHandler.CreateItem)
-				r.Get("/views/{viewID}/items", databaseHandler.ListViewItems)
+			api.Route("/databases", func(dr chi.Router) {
+				dr.Post("/", databaseHandler.CreateDatabase)
+				dr.Get("/templates", databaseHandler.ListDatabaseTemplates)
+				dr.Get("/by-slug/{slug}", databaseHandler.GetDatabaseBySlug)
+				dr.Route("/{id}", func(r chi.Router) {
+					r.Get("/", databaseHandler.GetDatabase)
+					r.Put("/slug", databaseHandler.UpdateDatabaseSlug)
+					r.Post("/instantiate", databaseHandler.InstantiateDatabaseTemplate)
+					r.Post("/duplicate", databaseHandler.DuplicateDatabase)
+					r.Post("/item-templates", databaseHandler.CreateItemTemplate)
+					r.Post("/items", databaseHandler.CreateItem)
+					r.Post("/items/bulk", databaseHandler.BulkItems)
+					r.Post("/import", databaseHandler.ImportCSV)
+					r.Route("/items/{itemID}", func(ir chi.Router) {
+						ir.Get("/", databaseHandler.GetItem)
+						ir.Patch("/", databaseHandler.UpdateItem)
+						ir.Delete("/", databaseHandler.DeleteItem)
+						ir.Post("/archive", databaseHandler.ArchiveItem)
+						ir.Post("/restore", databaseHandler.RestoreItem)
+						ir.Post("/move", databaseHandler.MoveItem)
+						ir.Get("/recurrence", recurrenceHandler.GetItemRecurrence)
+						ir.Post("/recurrence", recurrenceHandler.SetItemRecurrence)
+						ir.Patch("/recurrence", recurrenceHandler.UpdateItemRecurrence)
+						ir.Delete("/recurrence", recurrenceHandler.EndItemRecurrence)
+					})
+					r.Get("/series/{seriesID}", recurrenceHandler.GetRecurrenceSeries)
+					r.Post("/views", databaseHandler.CreateView)
+					r.Route("/views/{viewID}", func(vr chi.Router) {
+						vr.Get("/", databaseHandler.GetView)
+						vr.Patch("/", databaseHandler.UpdateView)
+						vr.Delete("/", databaseHandler.DeleteView)
+						vr.Post("/duplicate", databaseHandler.DuplicateView)
+						vr.Post("/undo", databaseHandler.UndoView)
+						vr.Get("/versions", databaseHandler.ListViewVersions)
+						vr.Post("/versions/{version}/restore", databaseHandler.RestoreViewVersion)
+						vr.Get("/items", databaseHandler.ListViewItems)
+						vr.Get("/pivot", databaseHandler.PivotView)
+						vr.Get("/export.csv", databaseHandler.ExportViewCSV)
+					})
+				})
 			})
 		})
 	})
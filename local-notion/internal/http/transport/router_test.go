@@ -1,8 +1,10 @@
 package transport
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -43,3 +45,141 @@ func TestRouterHandlesFaviconRequest(t *testing.T) {
 	require.Equal(t, http.StatusNoContent, resp.Code)
 	require.Empty(t, resp.Body.Len())
 }
+
+func TestRouterAttributesAuditEntries(t *testing.T) {
+	store, err := sqlite.Open(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	router := NewRouter(config.Config{}, store)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/pages", strings.NewReader(`{"slug":"notes","title":"Notes"}`))
+	req.Header.Set("X-Actor", "ana")
+	req.Header.Set("X-Request-Id", "req-42")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusCreated, resp.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/audit?actor=ana&entity_type=page", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+	var body struct {
+		Data []struct {
+			Action    string `json:"action"`
+			RequestID string `json:"request_id"`
+		} `json:"data"`
+		Meta struct {
+			NextCursor string `json:"next_cursor"`
+		} `json:"meta"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Len(t, body.Data, 1)
+	require.Equal(t, "create", body.Data[0].Action)
+	require.Equal(t, "req-42", body.Data[0].RequestID)
+	require.Empty(t, body.Meta.NextCursor)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/audit/export?actor=ana", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, "application/x-ndjson", resp.Header().Get("Content-Type"))
+	require.Equal(t, 1, strings.Count(resp.Body.String(), "\n"))
+}
+
+func TestRouterExposesSchemaCacheMetrics(t *testing.T) {
+	store, err := sqlite.Open(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	router := NewRouter(config.Config{}, store)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/metrics", nil)
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Contains(t, resp.Body.String(), "# TYPE schema_cache_hits_total counter\nschema_cache_hits_total 0\n")
+	require.Contains(t, resp.Body.String(), "schema_cache_capacity 256\n")
+}
+
+func TestRouterRedirectsRenamedSlugs(t *testing.T) {
+	store, err := sqlite.Open(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	router := NewRouter(config.Config{}, store)
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("If-Match", "*")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+	var created struct {
+		Data struct {
+			ID   string `json:"id"`
+			Slug string `json:"slug"`
+		} `json:"data"`
+	}
+
+	resp := serve(http.MethodPost, "/api/pages", `{"title":"Café Notes"}`)
+	require.Equal(t, http.StatusCreated, resp.Code)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	require.Equal(t, "cafe-notes", created.Data.Slug)
+	resp = serve(http.MethodPatch, "/api/pages/"+created.Data.ID, `{"slug":"coffee"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	resp = serve(http.MethodGet, "/api/pages/by-slug/coffee", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	resp = serve(http.MethodGet, "/api/pages/by-slug/cafe-notes", "")
+	require.Equal(t, http.StatusMovedPermanently, resp.Code)
+	require.Equal(t, "/api/pages/by-slug/coffee", resp.Header().Get("Location"))
+	resp = serve(http.MethodGet, "/api/pages/by-slug/unknown", "")
+	require.Equal(t, http.StatusNotFound, resp.Code)
+
+	resp = serve(http.MethodPost, "/api/databases", `{"title":"Reading List"}`)
+	require.Equal(t, http.StatusCreated, resp.Code)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	require.Equal(t, "reading-list", created.Data.Slug)
+	resp = serve(http.MethodPut, "/api/databases/"+created.Data.ID+"/slug", `{"slug":"books"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, `"2"`, resp.Header().Get("ETag"))
+	resp = serve(http.MethodGet, "/api/databases/by-slug/reading-list", "")
+	require.Equal(t, http.StatusMovedPermanently, resp.Code)
+	require.Equal(t, "/api/databases/by-slug/books", resp.Header().Get("Location"))
+}
+
+func TestRouterStreamsMarkdownExports(t *testing.T) {
+	store, err := sqlite.Open(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	router := NewRouter(config.Config{}, store)
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+	var created struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+
+	resp := serve(http.MethodPost, "/api/pages", `{"title":"Notes"}`)
+	require.Equal(t, http.StatusCreated, resp.Code)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+
+	resp = serve(http.MethodGet, "/api/pages/"+created.Data.ID+"/export", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, "application/zip", resp.Header().Get("Content-Type"))
+	resp = serve(http.MethodGet, "/api/export", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, "application/zip", resp.Header().Get("Content-Type"))
+	resp = serve(http.MethodGet, "/api/pages/"+created.Data.ID, "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	resp = serve(http.MethodGet, "/api/pages/missing/export", "")
+	require.Equal(t, http.StatusNotFound, resp.Code)
+}
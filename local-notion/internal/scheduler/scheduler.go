@@ -0,0 +1,195 @@
+// Package scheduler fires date reminders and creates the next items of
+// scheduled recurring series. Both are persisted by the store, so a
+// restarted scheduler picks up where it stopped and catches up on everything
+// that came due while it was down.
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/example/agents-playground/internal/domain"
+)
+
+// EventReminderFired is the type of the webhook event sent for a reminder.
+const EventReminderFired = "reminder.fired"
+
+// batchSize bounds the reminders loaded per query.
+const batchSize = 100
+
+// Store is the storage the scheduler needs. FireReminder records the
+// notification and returns nil when the reminder was already fired;
+// UndeliveredReminders returns fired reminders whose webhook event has not
+// been delivered, and RecordReminderDelivery records an attempt to deliver
+// one. AdvanceDueSeries returns the number of recurring items it created.
+type Store interface {
+	DueReminders(ctx context.Context, now time.Time, limit int) ([]domain.Reminder, error)
+	FireReminder(ctx context.Context, id string, now time.Time) (*domain.Reminder, error)
+	UndeliveredReminders(ctx context.Context, limit int) ([]domain.Reminder, error)
+	RecordReminderDelivery(ctx context.Context, id string, at time.Time, delivered bool) error
+	AdvanceDueSeries(ctx context.Context, now time.Time) (int, error)
+}
+
+// Options configure a Scheduler.
+type Options struct {
+	// Interval between polls; defaults to 30 seconds.
+	Interval time.Duration
+	// WebhookURL, when set, receives an Event for every fired reminder.
+	WebhookURL string
+	// Client sends webhooks; defaults to a client with a 5 second timeout.
+	Client *http.Client
+}
+
+// Event is the JSON body posted to the webhook.
+type Event struct {
+	Type     string          `json:"type"`
+	Reminder domain.Reminder `json:"reminder"`
+	FiredAt  time.Time       `json:"fired_at"`
+}
+
+// Scheduler polls the store for due reminders and fires them.
+type Scheduler struct {
+	store      Store
+	interval   time.Duration
+	webhookURL string
+	client     *http.Client
+	now        func() time.Time
+}
+
+// New constructs a scheduler.
+func New(store Store, opts Options) *Scheduler {
+	if opts.Interval <= 0 {
+		opts.Interval = 30 * time.Second
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &Scheduler{store: store, interval: opts.Interval, webhookURL: opts.WebhookURL, client: opts.Client, now: time.Now}
+}
+
+// Run does the due work immediately and then every interval until ctx is
+// done.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.store.AdvanceDueSeries(ctx, s.now()); err != nil && ctx.Err() == nil {
+			log.Error().Err(err).Msg("recurrence_run_failed")
+		}
+		if _, err := s.RunDue(ctx); err != nil && ctx.Err() == nil {
+			log.Error().Err(err).Msg("reminder_run_failed")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue fires every reminder that is due and returns how many fired.
+// Webhook failures are logged and do not stop the run: the reminder is
+// already recorded as fired and its notifications are in the inbox, and its
+// event stays undelivered until a later run delivers it. Undelivered events
+// are retried, oldest first, before new reminders fire.
+func (s *Scheduler) RunDue(ctx context.Context) (int, error) {
+	if err := s.retryUndelivered(ctx); err != nil {
+		return 0, err
+	}
+	fired := 0
+	for {
+		now := s.now().UTC()
+		due, err := s.store.DueReminders(ctx, now, batchSize)
+		if err != nil {
+			return fired, err
+		}
+		for _, reminder := range due {
+			r, err := s.store.FireReminder(ctx, reminder.ID, now)
+			if err != nil {
+				return fired, err
+			}
+			if r == nil {
+				continue
+			}
+			fired++
+			if _, err := s.deliver(ctx, *r); err != nil {
+				return fired, err
+			}
+		}
+		if len(due) < batchSize {
+			return fired, nil
+		}
+	}
+}
+
+// retryUndelivered delivers the events of reminders that fired earlier but
+// whose webhook failed. It stops at the first batch with a failure, so an
+// unreachable webhook is tried once per batch and run.
+func (s *Scheduler) retryUndelivered(ctx context.Context) error {
+	for {
+		pending, err := s.store.UndeliveredReminders(ctx, batchSize)
+		if err != nil {
+			return err
+		}
+		failed := false
+		for _, r := range pending {
+			delivered, err := s.deliver(ctx, r)
+			if err != nil {
+				return err
+			}
+			failed = failed || !delivered
+		}
+		if failed || len(pending) < batchSize {
+			return nil
+		}
+	}
+}
+
+// deliver posts the event of a fired reminder and records the attempt. A
+// failed post is logged and reported as not delivered; only failing to
+// record the attempt is returned as an error.
+func (s *Scheduler) deliver(ctx context.Context, r domain.Reminder) (bool, error) {
+	firedAt := s.now().UTC()
+	if r.FiredAt != nil {
+		firedAt = *r.FiredAt
+	}
+	delivered := true
+	if err := s.post(ctx, Event{Type: EventReminderFired, Reminder: r, FiredAt: firedAt}); err != nil {
+		log.Error().Err(err).Str("reminder_id", r.ID).Int("attempt", r.DeliveryAttempts+1).Msg("reminder_webhook_failed")
+		delivered = false
+	}
+	if err := s.store.RecordReminderDelivery(ctx, r.ID, s.now(), delivered); err != nil {
+		return false, err
+	}
+	return delivered, nil
+}
+
+func (s *Scheduler) post(ctx context.Context, event Event) error {
+	if s.webhookURL == "" {
+		return nil
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/domain"
+)
+
+type fakeStore struct {
+	mu        sync.Mutex
+	reminders []domain.Reminder
+}
+
+func (f *fakeStore) DueReminders(_ context.Context, now time.Time, limit int) ([]domain.Reminder, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var due []domain.Reminder
+	for _, r := range f.reminders {
+		if r.FiredAt == nil && !r.FireAt.After(now) && len(due) < limit {
+			due = append(due, r)
+		}
+	}
+	return due, nil
+}
+
+func (f *fakeStore) FireReminder(_ context.Context, id string, now time.Time) (*domain.Reminder, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.reminders {
+		if f.reminders[i].ID == id && f.reminders[i].FiredAt == nil {
+			f.reminders[i].FiredAt = &now
+			r := f.reminders[i]
+			return &r, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeStore) UndeliveredReminders(_ context.Context, limit int) ([]domain.Reminder, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var pending []domain.Reminder
+	for _, r := range f.reminders {
+		if r.FiredAt != nil && r.DeliveredAt == nil && len(pending) < limit {
+			pending = append(pending, r)
+		}
+	}
+	return pending, nil
+}
+
+func (f *fakeStore) RecordReminderDelivery(_ context.Context, id string, at time.Time, delivered bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.reminders {
+		if f.reminders[i].ID == id {
+			f.reminders[i].DeliveryAttempts++
+			if delivered {
+				f.reminders[i].DeliveredAt = &at
+			}
+		}
+	}
+	return nil
+}
+
+func (f *fakeStore) AdvanceDueSeries(context.Context, time.Time) (int, error) {
+	return 0, nil
+}
+
+func TestRunDueFiresOverdueRemindersAndPostsWebhooks(t *testing.T) {
+	now := time.Date(2026, 3, 28, 8, 0, 0, 0, time.UTC)
+	store := &fakeStore{reminders: []domain.Reminder{
+		{ID: "missed", Title: "Missed while down", FireAt: now.Add(-6 * time.Hour)},
+		{ID: "due", Title: "Due now", FireAt: now},
+		{ID: "later", Title: "Later", FireAt: now.Add(time.Minute)},
+	}}
+	var mu sync.Mutex
+	var events []Event
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event Event
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	}))
+	defer webhook.Close()
+
+	s := New(store, Options{WebhookURL: webhook.URL})
+	s.now = func() time.Time { return now }
+	fired, err := s.RunDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, fired)
+	require.Len(t, events, 2)
+	require.Equal(t, EventReminderFired, events[0].Type)
+	require.Equal(t, "missed", events[0].Reminder.ID)
+	require.True(t, now.Equal(events[1].FiredAt))
+
+	fired, err = s.RunDue(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, fired)
+
+	s.now = func() time.Time { return now.Add(time.Hour) }
+	fired, err = s.RunDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, fired)
+}
+
+func TestRunDueSurvivesWebhookFailures(t *testing.T) {
+	now := time.Now()
+	store := &fakeStore{reminders: []domain.Reminder{{ID: "a", FireAt: now}, {ID: "b", FireAt: now}}}
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer webhook.Close()
+
+	fired, err := New(store, Options{WebhookURL: webhook.URL}).RunDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, fired)
+}
+
+func TestRunDueRetriesUndeliveredEvents(t *testing.T) {
+	now := time.Date(2026, 3, 28, 8, 0, 0, 0, time.UTC)
+	store := &fakeStore{reminders: []domain.Reminder{{ID: "a", FireAt: now}}}
+	var mu sync.Mutex
+	up := false
+	var delivered []Event
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !up {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var event Event
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		delivered = append(delivered, event)
+	}))
+	defer webhook.Close()
+
+	s := New(store, Options{WebhookURL: webhook.URL})
+	s.now = func() time.Time { return now }
+	fired, err := s.RunDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, fired)
+	require.Nil(t, store.reminders[0].DeliveredAt)
+	require.Equal(t, 1, store.reminders[0].DeliveryAttempts)
+
+	// The next run posts the event again, with the time the reminder fired.
+	mu.Lock()
+	up = true
+	mu.Unlock()
+	s.now = func() time.Time { return now.Add(time.Minute) }
+	fired, err = s.RunDue(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, fired)
+	require.Len(t, delivered, 1)
+	require.Equal(t, "a", delivered[0].Reminder.ID)
+	require.True(t, now.Equal(delivered[0].FiredAt))
+	require.NotNil(t, store.reminders[0].DeliveredAt)
+	require.Equal(t, 2, store.reminders[0].DeliveryAttempts)
+
+	_, err = s.RunDue(context.Background())
+	require.NoError(t, err)
+	require.Len(t, delivered, 1)
+}
@@ -0,0 +1,298 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/example/agents-playground/internal/domain"
+)
+
+// Aggregations are configured per view in layout_options.aggregations as a map
+// of property id to function name, e.g. {"<points id>": "sum"}.
+const aggregationsKey = "aggregations"
+
+type aggregateKind int
+
+const (
+	aggregateAny aggregateKind = iota
+	aggregateNumeric
+	aggregateCheckbox
+	aggregateDate
+)
+
+// aggregateFunctions maps each supported function to the kind of property it
+// accepts.
+var aggregateFunctions = map[string]aggregateKind{
+	"count":           aggregateAny,
+	"count_values":    aggregateAny,
+	"count_unique":    aggregateAny,
+	"empty":           aggregateAny,
+	"not_empty":       aggregateAny,
+	"sum":             aggregateNumeric,
+	"average":         aggregateNumeric,
+	"median":          aggregateNumeric,
+	"min":             aggregateNumeric,
+	"max":             aggregateNumeric,
+	"range":           aggregateNumeric,
+	"percent_checked": aggregateCheckbox,
+	"earliest":        aggregateDate,
+	"latest":          aggregateDate,
+}
+
+var numericPropertyTypes = map[domain.PropertyType]bool{
+	domain.PropertyTypeNumber:  true,
+	domain.PropertyTypeFormula: true,
+	domain.PropertyTypeRollup:  true,
+}
+
+// IsNumericPropertyType reports whether values of a property type aggregate
+// as numbers.
+func IsNumericPropertyType(typ domain.PropertyType) bool {
+	return numericPropertyTypes[typ]
+}
+
+// viewAggregations reads the aggregation config of a view in a stable order.
+func viewAggregations(view domain.DatabaseView) map[string]string {
+	raw, _ := view.LayoutOptions[aggregationsKey].(map[string]any)
+	config := make(map[string]string, len(raw))
+	for propertyID, fn := range raw {
+		name, _ := fn.(string)
+		config[propertyID] = name
+	}
+	return config
+}
+
+// ValidateAggregations checks a view's aggregations against its properties.
+func ValidateAggregations(view domain.DatabaseView, props map[string]domain.PropertyType) error {
+	raw, ok := view.LayoutOptions[aggregationsKey]
+	if !ok || raw == nil {
+		return nil
+	}
+	entries, ok := raw.(map[string]any)
+	if !ok {
+		return fmt.Errorf("%w: layout_options.%s must map property ids to functions", ErrInvalidView, aggregationsKey)
+	}
+	for propertyID, fn := range entries {
+		propType, ok := props[propertyID]
+		if !ok {
+			return fmt.Errorf("%w: layout_options.%s references unknown property %q", ErrInvalidView, aggregationsKey, propertyID)
+		}
+		name, _ := fn.(string)
+		kind, ok := aggregateFunctions[name]
+		if !ok {
+			return fmt.Errorf("%w: unknown aggregation %v", ErrInvalidView, fn)
+		}
+		compatible := kind == aggregateAny ||
+			(kind == aggregateNumeric && numericPropertyTypes[propType]) ||
+			(kind == aggregateCheckbox && propType == domain.PropertyTypeCheckbox) ||
+			(kind == aggregateDate && propType == domain.PropertyTypeDate)
+		if !compatible {
+			return fmt.Errorf("%w: %s cannot aggregate a %s property", ErrInvalidView, name, propType)
+		}
+	}
+	return nil
+}
+
+// SummarizeView computes the footer aggregates of a view and, when the view is
+// grouped, the count and aggregates of every group. It returns nil for views
+// with neither aggregations nor grouping.
+func SummarizeView(view domain.DatabaseView, items []domain.DatabaseItem, values []map[string]any, options map[string][]string) *domain.ViewSummary {
+	config := viewAggregations(view)
+	groupBy, _ := view.Grouping["property_id"].(string)
+	if len(config) == 0 && groupBy == "" {
+		return nil
+	}
+	all := make([]int, len(items))
+	for i := range all {
+		all[i] = i
+	}
+	summary := &domain.ViewSummary{Count: len(items), Aggregates: computeAggregates(config, values, all)}
+	if groupBy == "" {
+		return summary
+	}
+	keys, members := groupItems(values, groupBy, options[groupBy])
+	for _, key := range keys {
+		var groupKey any
+		if key != "" {
+			groupKey = key
+		}
+		summary.Groups = append(summary.Groups, domain.ViewGroup{
+			Key:        groupKey,
+			Count:      len(members[key]),
+			Aggregates: computeAggregates(config, values, members[key]),
+		})
+	}
+	return summary
+}
+
+// groupItems buckets item indexes by their value of the grouping property. A
+// multi-valued item joins every group it has a value for. Groups follow the
+// property's configured options, then first appearance, with items without a
+// value last under the empty key.
+func groupItems(values []map[string]any, groupBy string, options []string) ([]string, map[string][]int) {
+	members := make(map[string][]int)
+	var keys []string
+	seen := make(map[string]bool)
+	add := func(key string) {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	for _, option := range options {
+		add(option)
+	}
+	for idx, itemValues := range values {
+		value := itemValues[groupBy]
+		if IsEmptyValue(value) {
+			members[""] = append(members[""], idx)
+			continue
+		}
+		list, ok := value.([]any)
+		if !ok {
+			list = []any{value}
+		}
+		for _, el := range list {
+			key := fmt.Sprint(el)
+			add(key)
+			members[key] = append(members[key], idx)
+		}
+	}
+	if len(members[""]) > 0 {
+		keys = append(keys, "")
+	}
+	return keys, members
+}
+
+func computeAggregates(config map[string]string, values []map[string]any, indexes []int) []domain.ViewAggregate {
+	if len(config) == 0 {
+		return nil
+	}
+	propertyIDs := make([]string, 0, len(config))
+	for propertyID := range config {
+		propertyIDs = append(propertyIDs, propertyID)
+	}
+	sort.Strings(propertyIDs)
+	out := make([]domain.ViewAggregate, 0, len(config))
+	for _, propertyID := range propertyIDs {
+		column := make([]any, len(indexes))
+		for i, idx := range indexes {
+			column[i] = values[idx][propertyID]
+		}
+		out = append(out, domain.ViewAggregate{
+			PropertyID: propertyID,
+			Function:   config[propertyID],
+			Value:      aggregate(config[propertyID], column),
+		})
+	}
+	return out
+}
+
+// aggregate applies one function to a column of values. Numeric and date
+// functions ignore values they cannot interpret and return nil when nothing is
+// left to aggregate.
+func aggregate(fn string, column []any) any {
+	switch fn {
+	case "count":
+		return len(column)
+	case "count_values", "count_unique":
+		unique := make(map[string]bool)
+		count := 0
+		for _, value := range column {
+			if IsEmptyValue(value) {
+				continue
+			}
+			list, ok := value.([]any)
+			if !ok {
+				list = []any{value}
+			}
+			for _, el := range list {
+				count++
+				unique[fmt.Sprint(el)] = true
+			}
+		}
+		if fn == "count_unique" {
+			return len(unique)
+		}
+		return count
+	case "empty", "not_empty":
+		empty := 0
+		for _, value := range column {
+			if IsEmptyValue(value) {
+				empty++
+			}
+		}
+		if fn == "empty" {
+			return empty
+		}
+		return len(column) - empty
+	case "percent_checked":
+		if len(column) == 0 {
+			return nil
+		}
+		checked := 0
+		for _, value := range column {
+			if value == true {
+				checked++
+			}
+		}
+		return RoundAggregate(float64(checked) * 100 / float64(len(column)))
+	case "earliest", "latest":
+		var best any
+		var bestKey string
+		for _, value := range column {
+			t, ok := DateValue(value)
+			if !ok {
+				continue
+			}
+			key := t.UTC().Format("2006-01-02T15:04:05.000000000")
+			if best == nil || (fn == "earliest" && key < bestKey) || (fn == "latest" && key > bestKey) {
+				best, bestKey = value, key
+			}
+		}
+		return best
+	}
+	var numbers []float64
+	for _, value := range column {
+		if f, ok := NumericValue(value); ok {
+			numbers = append(numbers, f)
+		}
+	}
+	if len(numbers) == 0 {
+		if fn == "sum" {
+			return 0.0
+		}
+		return nil
+	}
+	sort.Float64s(numbers)
+	switch fn {
+	case "sum", "average":
+		total := 0.0
+		for _, n := range numbers {
+			total += n
+		}
+		if fn == "sum" {
+			return RoundAggregate(total)
+		}
+		return RoundAggregate(total / float64(len(numbers)))
+	case "median":
+		mid := len(numbers) / 2
+		if len(numbers)%2 == 0 {
+			return RoundAggregate((numbers[mid-1] + numbers[mid]) / 2)
+		}
+		return numbers[mid]
+	case "min":
+		return numbers[0]
+	case "max":
+		return numbers[len(numbers)-1]
+	case "range":
+		return RoundAggregate(numbers[len(numbers)-1] - numbers[0])
+	}
+	return nil
+}
+
+// RoundAggregate trims floating point noise such as 0.30000000000000004.
+func RoundAggregate(v float64) float64 {
+	return math.Round(v*1e9) / 1e9
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregateFunctions(t *testing.T) {
+	numbers := []any{3.0, nil, 1.0, 8.0, 4.0}
+	require.Equal(t, 5, aggregate("count", numbers))
+	require.Equal(t, 4, aggregate("count_values", numbers))
+	require.Equal(t, 1, aggregate("empty", numbers))
+	require.Equal(t, 4, aggregate("not_empty", numbers))
+	require.Equal(t, 16.0, aggregate("sum", numbers))
+	require.Equal(t, 4.0, aggregate("average", numbers))
+	require.Equal(t, 3.5, aggregate("median", numbers))
+	require.Equal(t, 1.0, aggregate("min", numbers))
+	require.Equal(t, 8.0, aggregate("max", numbers))
+	require.Equal(t, 7.0, aggregate("range", numbers))
+	require.Equal(t, 0.3, aggregate("sum", []any{0.1, 0.2}))
+	require.Equal(t, 0.0, aggregate("sum", []any{nil}))
+	require.Nil(t, aggregate("average", []any{nil}))
+
+	tags := []any{[]any{"a", "b"}, []any{"b"}, "c", []any{}}
+	require.Equal(t, 4, aggregate("count_values", tags))
+	require.Equal(t, 3, aggregate("count_unique", tags))
+
+	require.Equal(t, 50.0, aggregate("percent_checked", []any{true, false, nil, true}))
+
+	dates := []any{"2024-03-01", "2024-01-15T09:00:00Z", nil, map[string]any{"start": "2023-12-31"}}
+	require.Equal(t, map[string]any{"start": "2023-12-31"}, aggregate("earliest", dates))
+	require.Equal(t, "2024-03-01", aggregate("latest", dates))
+}
+
+func TestMatchesFilter(t *testing.T) {
+	values := map[string]any{"status": "Doing", "points": 5.0, "tags": []any{"ops", "infra"}, "due": "2024-05-01"}
+	cases := []struct {
+		filter map[string]any
+		want   bool
+	}{
+		{map[string]any{}, true},
+		{map[string]any{"property_id": "status", "operator": "equals", "value": "Doing"}, true},
+		{map[string]any{"property_id": "status", "operator": "not_equals", "value": "Doing"}, false},
+		{map[string]any{"property_id": "tags", "operator": "contains", "value": "ops"}, true},
+		{map[string]any{"property_id": "status", "operator": "contains", "value": "do"}, true},
+		{map[string]any{"property_id": "points", "operator": "greater_than", "value": 4}, true},
+		{map[string]any{"property_id": "points", "operator": "less_than_or_equal", "value": 4}, false},
+		{map[string]any{"property_id": "due", "operator": "before", "value": "2024-06-01"}, true},
+		{map[string]any{"property_id": "missing", "operator": "is_empty"}, true},
+		{map[string]any{"property_id": "missing", "operator": "greater_than", "value": 1}, false},
+		{map[string]any{"and": []any{
+			map[string]any{"property_id": "status", "operator": "equals", "value": "Doing"},
+			map[string]any{"or": []any{
+				map[string]any{"property_id": "points", "operator": "greater_than", "value": 10},
+				map[string]any{"property_id": "tags", "operator": "contains", "value": "infra"},
+			}},
+		}}, true},
+		{map[string]any{"or": []any{
+			map[string]any{"property_id": "status", "operator": "equals", "value": "Done"},
+			map[string]any{"property_id": "points", "operator": "equals", "value": "6"},
+		}}, false},
+	}
+	for _, tc := range cases {
+		require.Equal(t, tc.want, MatchesFilter(tc.filter, values), "filter %v", tc.filter)
+	}
+}
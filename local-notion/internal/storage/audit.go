@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/example/agents-playground/internal/domain"
+)
+
+// Audited entity types.
+const (
+	AuditEntityPage     = "page"
+	AuditEntityDatabase = "database"
+	AuditEntityProperty = "property"
+	AuditEntityView     = "view"
+	AuditEntityItem     = "item"
+	AuditEntityValue    = "value"
+	AuditEntityAsset    = "asset"
+
+	AuditEntityItemTemplate  = "item_template"
+	AuditEntityCommentThread = "comment_thread"
+	AuditEntityComment       = "comment"
+	AuditEntitySeries        = "recurrence_series"
+)
+
+// Audited actions.
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionMove    = "move"
+	AuditActionRestore = "restore"
+)
+
+// Audit list page sizes.
+const (
+	DefaultAuditLimit = 100
+	MaxAuditLimit     = 1000
+)
+
+// ErrInvalidAuditCursor is returned when a pagination cursor cannot be decoded.
+var ErrInvalidAuditCursor = InvalidError("invalid_cursor", "invalid audit cursor")
+
+// AuditFilter narrows an audit log query. Empty fields match everything.
+// Cursor continues a listing from the NextCursor of the previous page.
+type AuditFilter struct {
+	Actor      string
+	RequestID  string
+	Action     string
+	EntityType string
+	EntityID   string
+	Since      *time.Time
+	Until      *time.Time
+	Cursor     string
+	Limit      int
+}
+
+// AuditInfo identifies who made a change and in which request.
+type AuditInfo struct {
+	Actor     string
+	RequestID string
+}
+
+type auditInfoKey struct{}
+
+// WithAuditInfo returns a context whose store mutations are attributed to info.
+func WithAuditInfo(ctx context.Context, info AuditInfo) context.Context {
+	return context.WithValue(ctx, auditInfoKey{}, info)
+}
+
+// AuditInfoFrom returns the attribution WithAuditInfo stored in ctx.
+func AuditInfoFrom(ctx context.Context) AuditInfo {
+	info, _ := ctx.Value(auditInfoKey{}).(AuditInfo)
+	return info
+}
+
+// AuditPage clamps the limit of filter to the audit page sizes and decodes
+// its cursor, the id entries must sort below; zero means the first page.
+func AuditPage(filter AuditFilter) (limit int, before int64, err error) {
+	limit = filter.Limit
+	if limit <= 0 {
+		limit = DefaultAuditLimit
+	}
+	limit = min(limit, MaxAuditLimit)
+	if filter.Cursor != "" {
+		before, err = strconv.ParseInt(filter.Cursor, 10, 64)
+		if err != nil || before <= 0 {
+			return 0, 0, ErrInvalidAuditCursor
+		}
+	}
+	return limit, before, nil
+}
+
+// AuditChange is an audit entry about to be recorded. Before and After are
+// the states of the entity, nil when it did not exist.
+type AuditChange struct {
+	Action     string
+	EntityType string
+	EntityID   string
+	Before     any
+	After      any
+}
+
+// AuditSnapshot encodes a state of an audited entity as JSON, or returns nil
+// for a missing state so it is stored as NULL.
+func AuditSnapshot(v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("marshal audit snapshot: %w", err)
+	}
+	if string(raw) == "null" {
+		return nil, nil
+	}
+	return string(raw), nil
+}
+
+// DatabaseCreateChanges returns the entries recording a new database along
+// with each of its properties and views.
+func DatabaseCreateChanges(db *domain.Database) []AuditChange {
+	changes := []AuditChange{{Action: AuditActionCreate, EntityType: AuditEntityDatabase, EntityID: db.ID, After: db}}
+	for i := range db.Properties {
+		changes = append(changes, AuditChange{Action: AuditActionCreate, EntityType: AuditEntityProperty, EntityID: db.Properties[i].ID, After: db.Properties[i]})
+	}
+	for i := range db.Views {
+		changes = append(changes, AuditChange{Action: AuditActionCreate, EntityType: AuditEntityView, EntityID: db.Views[i].ID, After: db.Views[i]})
+	}
+	return changes
+}
+
+// ItemUpdateChanges returns the entries recording the changes between two
+// states of an item: one item entry when its page fields or archive flag
+// were part of the update and one value entry for every property value that
+// changed.
+func ItemUpdateChanges(in UpdateDatabaseItemInput, before, after *domain.DatabaseItem) []AuditChange {
+	var changes []AuditChange
+	if in.Title != nil || in.Summary != nil || in.Content != nil || in.Tags != nil || in.Archived != nil {
+		changes = append(changes, AuditChange{Action: AuditActionUpdate, EntityType: AuditEntityItem, EntityID: after.ID, Before: ItemWithoutValues(before), After: ItemWithoutValues(after)})
+	}
+	for slug := range in.Values {
+		old, hadOld := before.PropertyMap[slug]
+		cur, hasCur := after.PropertyMap[slug]
+		if hadOld == hasCur && (!hasCur || sameJSON(old.RawValue, cur.RawValue)) {
+			continue
+		}
+		change := AuditChange{Action: AuditActionUpdate, EntityType: AuditEntityValue}
+		if hadOld {
+			change.Before, change.EntityID = &old, old.ID
+		}
+		if hasCur {
+			change.After, change.EntityID = &cur, cur.ID
+		}
+		switch {
+		case !hadOld:
+			change.Action = AuditActionCreate
+		case !hasCur:
+			change.Action = AuditActionDelete
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// ItemWithoutValues returns a copy of item without its values, the form item
+// entries are recorded in.
+func ItemWithoutValues(item *domain.DatabaseItem) *domain.DatabaseItem {
+	copied := *item
+	copied.PropertyMap = nil
+	return &copied
+}
+
+func sameJSON(a, b any) bool {
+	ra, errA := json.Marshal(a)
+	rb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ra) == string(rb)
+}
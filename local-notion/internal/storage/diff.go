@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"strings"
+
+	"github.com/example/agents-playground/internal/domain"
+)
+
+// Line diff operations.
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// maxDiffCells bounds the LCS table of a diff. Larger changes are reported
+// as the old lines deleted followed by the new lines inserted.
+const maxDiffCells = 4_000_000
+
+// DiffLines returns a line-level diff turning a into b. Common leading and
+// trailing lines are matched first; the changed middle is aligned with a
+// longest common subsequence.
+func DiffLines(a, b string) []domain.DiffLine {
+	oldLines, newLines := splitLines(a), splitLines(b)
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+	out := make([]domain.DiffLine, 0, len(oldLines)+len(newLines)-prefix-suffix)
+	for i := 0; i < prefix; i++ {
+		out = append(out, domain.DiffLine{Op: DiffEqual, Text: oldLines[i], OldLine: i + 1, NewLine: i + 1})
+	}
+	oldMid := oldLines[prefix : len(oldLines)-suffix]
+	newMid := newLines[prefix : len(newLines)-suffix]
+	out = append(out, diffMiddle(oldMid, newMid, prefix)...)
+	for i := 0; i < suffix; i++ {
+		oi, ni := len(oldLines)-suffix+i, len(newLines)-suffix+i
+		out = append(out, domain.DiffLine{Op: DiffEqual, Text: oldLines[oi], OldLine: oi + 1, NewLine: ni + 1})
+	}
+	return out
+}
+
+func diffMiddle(a, b []string, offset int) []domain.DiffLine {
+	var out []domain.DiffLine
+	if len(a)*len(b) > maxDiffCells {
+		for i, line := range a {
+			out = append(out, domain.DiffLine{Op: DiffDelete, Text: line, OldLine: offset + i + 1})
+		}
+		for j, line := range b {
+			out = append(out, domain.DiffLine{Op: DiffInsert, Text: line, NewLine: offset + j + 1})
+		}
+		return out
+	}
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	width := len(b) + 1
+	lcs := make([]int32, (len(a)+1)*width)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i*width+j] = lcs[(i+1)*width+j+1] + 1
+			} else {
+				lcs[i*width+j] = max(lcs[(i+1)*width+j], lcs[i*width+j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			out = append(out, domain.DiffLine{Op: DiffEqual, Text: a[i], OldLine: offset + i + 1, NewLine: offset + j + 1})
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i*width+j+1] >= lcs[(i+1)*width+j]):
+			out = append(out, domain.DiffLine{Op: DiffInsert, Text: b[j], NewLine: offset + j + 1})
+			j++
+		default:
+			out = append(out, domain.DiffLine{Op: DiffDelete, Text: a[i], OldLine: offset + i + 1})
+			i++
+		}
+	}
+	return out
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(strings.ReplaceAll(text, "\r\n", "\n"), "\n"), "\n")
+}
+
+// DiffSets returns the elements only in b (added) and only in a (removed).
+func DiffSets(a, b []string) (added, removed []string) {
+	inA := make(map[string]bool, len(a))
+	for _, v := range a {
+		inA[v] = true
+	}
+	inB := make(map[string]bool, len(b))
+	for _, v := range b {
+		inB[v] = true
+		if !inA[v] {
+			added = append(added, v)
+		}
+	}
+	for _, v := range a {
+		if !inB[v] {
+			removed = append(removed, v)
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,17 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffLinesLargeChange(t *testing.T) {
+	require.Empty(t, DiffLines("", ""))
+	lines := DiffLines("a\nb", "c")
+	require.Len(t, lines, 3)
+	require.Equal(t, DiffInsert, lines[0].Op)
+	require.Equal(t, 1, lines[0].NewLine)
+	require.Equal(t, DiffDelete, lines[2].Op)
+	require.Equal(t, 2, lines[2].OldLine)
+}
@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrorKind classifies store errors so callers can react to a failure
+// without matching its text.
+type ErrorKind string
+
+// Store error kinds.
+const (
+	KindNotFound           ErrorKind = "not_found"
+	KindConflict           ErrorKind = "conflict"
+	KindValidation         ErrorKind = "validation"
+	KindPreconditionFailed ErrorKind = "precondition_failed"
+	KindForbidden          ErrorKind = "forbidden"
+)
+
+// FieldError describes why one input field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is a classified store error. Code is a stable, machine-readable
+// identifier such as "page_not_found"; Fields lists the offending input
+// fields of a validation or conflict error.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	Fields  []FieldError
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is matches errors with the same code, and the kind sentinels ErrNotFound,
+// ErrConflict, ErrValidation, ErrPreconditionFailed and ErrForbidden against
+// any error of their kind.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	switch t {
+	case ErrNotFound, ErrConflict, ErrValidation, ErrPreconditionFailed, ErrForbidden:
+		return e.Kind == t.Kind
+	}
+	return e.Code == t.Code
+}
+
+// Kind sentinels, for use with errors.Is.
+var (
+	ErrNotFound           = &Error{Kind: KindNotFound, Code: "not_found", Message: "not found"}
+	ErrConflict           = &Error{Kind: KindConflict, Code: "conflict", Message: "conflict"}
+	ErrValidation         = &Error{Kind: KindValidation, Code: "validation_failed", Message: "validation failed"}
+	ErrPreconditionFailed = &Error{Kind: KindPreconditionFailed, Code: "precondition_failed", Message: "precondition failed"}
+	ErrForbidden          = &Error{Kind: KindForbidden, Code: "forbidden", Message: "forbidden"}
+)
+
+// Errors shared by every backend.
+var (
+	// ErrPageNotFound is returned by operations that require an existing page.
+	ErrPageNotFound = NotFoundError("page_not_found", "page not found")
+	// ErrDatabaseNotFound is returned by operations that require an existing database.
+	ErrDatabaseNotFound = NotFoundError("database_not_found", "database not found")
+	// ErrItemNotFound is returned when a database item does not exist in the given database.
+	ErrItemNotFound = NotFoundError("item_not_found", "item not found")
+	// ErrTemplateNotFound is returned when a page, database or item template does not exist.
+	ErrTemplateNotFound = NotFoundError("template_not_found", "template not found")
+	// ErrViewNotFound is returned when a database view does not exist.
+	ErrViewNotFound = NotFoundError("view_not_found", "view not found")
+	// ErrInvalidView wraps validation failures of a view definition.
+	ErrInvalidView = InvalidError("invalid_view", "invalid view")
+	// ErrSlugConflict is returned when a page, database or property slug is
+	// already taken.
+	ErrSlugConflict = &Error{Kind: KindConflict, Code: "slug_conflict", Message: "slug already in use", Fields: []FieldError{{Field: "slug", Message: "already in use"}}}
+	// ErrVersionMismatch is returned when an update names a version other than
+	// the current version of the record it changes.
+	ErrVersionMismatch = &Error{Kind: KindPreconditionFailed, Code: "version_mismatch", Message: "record was changed since it was read"}
+)
+
+// NotFoundError returns a not-found error with the given code.
+func NotFoundError(code, message string) *Error {
+	return &Error{Kind: KindNotFound, Code: code, Message: message}
+}
+
+// ConflictError returns a conflict error with the given code.
+func ConflictError(code, message string) *Error {
+	return &Error{Kind: KindConflict, Code: code, Message: message}
+}
+
+// ForbiddenError returns an error for a change the caller may not make.
+func ForbiddenError(code, message string) *Error {
+	return &Error{Kind: KindForbidden, Code: code, Message: message}
+}
+
+// InvalidError returns a validation error with the given code that is not
+// tied to a single field.
+func InvalidError(code, message string) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message}
+}
+
+// ValidationError rejects input with one entry per offending field.
+func ValidationError(fields ...FieldError) *Error {
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		parts[i] = f.Field + " " + f.Message
+	}
+	return &Error{Kind: KindValidation, Code: ErrValidation.Code, Message: strings.Join(parts, "; "), Fields: fields}
+}
+
+// InvalidField rejects a single input field.
+func InvalidField(field, format string, args ...any) *Error {
+	return ValidationError(FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// RequireFields returns a validation error naming every blank field, or nil
+// when all are set. Arguments alternate field name and value.
+func RequireFields(pairs ...string) error {
+	var fields []FieldError
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if strings.TrimSpace(pairs[i+1]) == "" {
+			fields = append(fields, FieldError{Field: pairs[i], Message: "is required"})
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return ValidationError(fields...)
+}
+
+// SlugConflict reports that slug is already taken.
+func SlugConflict(slug string) *Error {
+	return &Error{Kind: KindConflict, Code: ErrSlugConflict.Code, Message: fmt.Sprintf("slug %q is already in use", slug), Fields: ErrSlugConflict.Fields}
+}
+
+// CheckVersion rejects an update whose expected version is set and differs
+// from current.
+func CheckVersion(expected *int, current int) error {
+	if expected != nil && *expected != current {
+		return fmt.Errorf("%w: expected version %d, current version is %d", ErrVersionMismatch, *expected, current)
+	}
+	return nil
+}
+
+var (
+	classifiersMu sync.RWMutex
+	classifiers   []func(error) *Error
+)
+
+// RegisterErrorClassifier lets a backend translate its driver errors, such as
+// constraint violations, for AsError. It is meant to be called from init.
+func RegisterErrorClassifier(classify func(error) *Error) {
+	classifiersMu.Lock()
+	defer classifiersMu.Unlock()
+	classifiers = append(classifiers, classify)
+}
+
+// AsError returns the classified form of err: the Error it wraps, or the
+// translation of a backend driver error. It returns nil for errors that are
+// not the caller's to fix.
+func AsError(err error) *Error {
+	var storeErr *Error
+	if errors.As(err, &storeErr) {
+		return storeErr
+	}
+	classifiersMu.RLock()
+	defer classifiersMu.RUnlock()
+	for _, classify := range classifiers {
+		if classified := classify(err); classified != nil {
+			return classified
+		}
+	}
+	return nil
+}
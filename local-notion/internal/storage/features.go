@@ -0,0 +1,296 @@
+package storage
+
+import (
+	"archive/zip"
+	"context"
+	"io"
+	"time"
+
+	"github.com/example/agents-playground/internal/domain"
+)
+
+// The inputs and filters below belong to the optional features a backend may
+// offer on top of Store, such as templates, duplication, bulk edits, pivots,
+// imports, comments and reminders.
+
+// MarkdownExport is a planned zip export of pages, written out on demand.
+type MarkdownExport interface {
+	// Stream writes the archive to w. Errors after the first byte leave a
+	// truncated archive, so callers should not retry on the same writer.
+	Stream(ctx context.Context, w io.Writer) error
+}
+
+// InstantiatePageTemplateInput describes how to materialize a page template.
+type InstantiatePageTemplateInput struct {
+	TemplateID   string
+	Slug         string
+	Title        string // defaults to the expanded template title
+	ParentPageID *string
+	Variables    map[string]string
+}
+
+// InstantiateDatabaseTemplateInput describes how to materialize a database template.
+type InstantiateDatabaseTemplateInput struct {
+	TemplateID   string
+	Slug         string
+	Title        string // defaults to the expanded template title
+	IncludeItems bool   // seed the new database with copies of the template items
+	Variables    map[string]string
+}
+
+// CreateItemTemplateInput describes a reusable item template for a database.
+type CreateItemTemplateInput struct {
+	DatabaseID string
+	Name       string
+	Title      string
+	Summary    string
+	Content    string
+	Tags       []string
+	Values     map[string]any // keyed by property slug
+}
+
+// DuplicatePageInput describes a page subtree copy.
+type DuplicatePageInput struct {
+	PageID       string
+	ParentPageID *string // destination parent; nil keeps the source parent
+	Title        string  // defaults to "<title> (copy)"
+}
+
+// DuplicateDatabaseInput describes a database copy.
+type DuplicateDatabaseInput struct {
+	DatabaseID string
+	Slug       string // defaults to a unique "<slug>-copy"
+	Title      string // defaults to "<title> (copy)"
+}
+
+// UpdateDatabaseViewInput replaces the non-nil parts of a view. An empty map
+// or slice clears the corresponding setting.
+type UpdateDatabaseViewInput struct {
+	DatabaseID    string
+	ViewID        string
+	Name          *string
+	Type          *domain.ViewType
+	Filters       map[string]any
+	Sorts         []domain.ViewSort
+	Grouping      map[string]any
+	Display       []string
+	LayoutOptions map[string]any
+	// ExpectedVersion, when set, rejects the update with ErrVersionMismatch
+	// unless the view is still at that version.
+	ExpectedVersion *int
+}
+
+// DuplicateDatabaseViewInput copies a view within its database.
+type DuplicateDatabaseViewInput struct {
+	DatabaseID string
+	ViewID     string
+	Name       string // defaults to "<name> (copy)"
+}
+
+// Pivot measures.
+const (
+	PivotMeasureCount   = "count"
+	PivotMeasureSum     = "sum"
+	PivotMeasureAverage = "average"
+)
+
+// Date buckets for date dimensions.
+const (
+	PivotBucketDay     = "day"
+	PivotBucketWeek    = "week"
+	PivotBucketMonth   = "month"
+	PivotBucketQuarter = "quarter"
+	PivotBucketYear    = "year"
+)
+
+// PivotViewInput describes a pivot over the filtered items of a view. Columns
+// is optional; without it the result has a single "total" column.
+type PivotViewInput struct {
+	DatabaseID        string
+	ViewID            string
+	Rows              PivotDimension
+	Columns           *PivotDimension
+	Measure           string
+	MeasurePropertyID string // number property for sum and average
+}
+
+// PivotDimension groups items by one property. Bucket is required for date
+// properties and ignored otherwise.
+type PivotDimension struct {
+	PropertyID string
+	Bucket     string
+}
+
+// BulkItemsInput describes a batch of item operations on one database.
+type BulkItemsInput struct {
+	DatabaseID      string
+	IdempotencyKey  string
+	ContinueOnError bool // when false, the first failure rolls back the whole batch
+	Operations      []BulkItemOperation
+	Author          string // recorded on the page revisions of updated items
+}
+
+// BulkItemOperation is one create, update, delete or move of a database item.
+// Create uses Slug, Title, Summary, Content, Tags, Values, Position and
+// TemplateID; update uses ItemID plus any page field, value or archive flag to
+// change; delete only needs ItemID; move needs ItemID and one of BeforeID or
+// AfterID.
+type BulkItemOperation struct {
+	Op         string
+	ItemID     string
+	TemplateID string
+	Slug       string
+	Title      *string
+	Summary    *string
+	Content    *string
+	Tags       []string
+	Archived   *bool
+	Values     map[string]any
+	Position   *int
+	BeforeID   string
+	AfterID    string
+	Version    *int // expected item version of an update
+}
+
+// ImportCSVInput describes a CSV import into a database. Mapping sends column
+// headers to property slugs; when it is nil, columns whose header matches a
+// property slug or name are mapped automatically. TitleColumn names the column
+// used for item titles and defaults to a "Title" or "Name" column, then to the
+// first column.
+type ImportCSVInput struct {
+	DatabaseID      string
+	Reader          io.Reader
+	Mapping         map[string]string
+	TitleColumn     string
+	DryRun          bool
+	CreateOptions   bool // add unknown select and multi-select values as options
+	SkipInvalidRows bool // import the valid rows even if some rows fail
+}
+
+// ImportNotionInput describes a Notion "Markdown & CSV" export to import.
+// Attachments are written to AssetDir; when it is empty they are reported and
+// their links left untouched.
+type ImportNotionInput struct {
+	Archive  *zip.Reader
+	AssetDir string
+}
+
+// RenameTagInput renames a tag on every page carrying it.
+type RenameTagInput struct {
+	Name    string
+	NewName string
+	Author  string
+}
+
+// MergeTagsInput replaces several tags with a single one.
+type MergeTagsInput struct {
+	Sources []string
+	Target  string
+	Author  string
+}
+
+// UpdateDatabaseSlugInput renames a database's slug.
+type UpdateDatabaseSlugInput struct {
+	DatabaseID string
+	Slug       string
+	// ExpectedVersion, when set, rejects the update with ErrVersionMismatch
+	// unless the database is still at that version.
+	ExpectedVersion *int
+}
+
+// CreateCommentThreadInput starts a discussion on a page, or on a range of
+// its content when Anchor is set.
+type CreateCommentThreadInput struct {
+	PageID string
+	Author string
+	Body   string
+	Anchor *CommentAnchorInput
+}
+
+// CommentAnchorInput selects a range of page content in runes, End exclusive.
+type CommentAnchorInput struct {
+	Start int
+	End   int
+}
+
+// ReplyToCommentThreadInput adds a comment to a thread.
+type ReplyToCommentThreadInput struct {
+	PageID   string
+	ThreadID string
+	Author   string
+	Body     string
+}
+
+// UpdateCommentInput edits a comment's body.
+type UpdateCommentInput struct {
+	PageID    string
+	ThreadID  string
+	CommentID string
+	Author    string
+	Body      string
+}
+
+// DeleteCommentInput removes a comment.
+type DeleteCommentInput struct {
+	PageID    string
+	ThreadID  string
+	CommentID string
+	Author    string
+}
+
+// ResolveCommentThreadInput resolves or reopens a thread.
+type ResolveCommentThreadInput struct {
+	PageID   string
+	ThreadID string
+	Author   string
+	Resolved bool
+}
+
+// NotificationFilter selects a user's notifications.
+type NotificationFilter struct {
+	Recipient  string
+	UnreadOnly bool
+	Limit      int
+}
+
+// SetItemRecurrenceInput starts a series at an item. Property and
+// CompleteProperty are property slugs; Generate defaults to on_complete when
+// CompleteProperty is set and to scheduled otherwise.
+type SetItemRecurrenceInput struct {
+	DatabaseID       string
+	ItemID           string
+	Property         string
+	Rule             string
+	Exceptions       []string
+	Generate         string
+	CompleteProperty string
+}
+
+// UpdateItemRecurrenceInput edits the series of an item, for every item or
+// for the item and the ones after it. A nil Rule or Exceptions keeps the
+// current one; Values are applied to the items in scope.
+type UpdateItemRecurrenceInput struct {
+	DatabaseID string
+	ItemID     string
+	Scope      string
+	Rule       *string
+	Exceptions []string
+	Values     map[string]any
+	Author     string
+}
+
+// ReminderFilter selects upcoming reminders.
+type ReminderFilter struct {
+	DatabaseID string
+	Until      *time.Time
+	Limit      int
+}
+
+// SchemaCacheStats reports how well the schema cache is doing.
+type SchemaCacheStats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Entries   int    `json:"entries"`
+	Capacity  int    `json:"capacity"`
+}
@@ -0,0 +1,219 @@
+package storage
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// View filters are JSON trees. A node is either a group {"and": [...]} or
+// {"or": [...]}, or a condition {"property_id": ..., "operator": ..., "value": ...}.
+// An empty filter matches every item.
+var filterOperators = map[string]bool{
+	"equals":                true,
+	"not_equals":            true,
+	"contains":              true,
+	"not_contains":          true,
+	"is_empty":              true,
+	"is_not_empty":          true,
+	"greater_than":          true,
+	"greater_than_or_equal": true,
+	"less_than":             true,
+	"less_than_or_equal":    true,
+	"before":                true,
+	"after":                 true,
+}
+
+// ValidateFilterOperators reports the first condition with an unknown operator.
+func ValidateFilterOperators(node any) error {
+	switch v := node.(type) {
+	case map[string]any:
+		if op, ok := v["operator"]; ok {
+			name, _ := op.(string)
+			if !filterOperators[name] {
+				return fmt.Errorf("%w: unknown filter operator %v", ErrInvalidView, op)
+			}
+		}
+		for _, key := range []string{"and", "or"} {
+			if err := ValidateFilterOperators(v[key]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, child := range v {
+			if err := ValidateFilterOperators(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// MatchesFilter evaluates a filter tree against item values keyed by property
+// id. Conditions it cannot interpret, such as filters saved before views were
+// validated, match every item rather than hiding data.
+func MatchesFilter(node any, values map[string]any) bool {
+	filter, ok := node.(map[string]any)
+	if !ok || len(filter) == 0 {
+		return true
+	}
+	if children, ok := filter["and"].([]any); ok {
+		for _, child := range children {
+			if !MatchesFilter(child, values) {
+				return false
+			}
+		}
+		return true
+	}
+	if children, ok := filter["or"].([]any); ok {
+		if len(children) == 0 {
+			return true
+		}
+		for _, child := range children {
+			if MatchesFilter(child, values) {
+				return true
+			}
+		}
+		return false
+	}
+	propertyID, _ := filter["property_id"].(string)
+	operator, _ := filter["operator"].(string)
+	if propertyID == "" || operator == "" {
+		return true
+	}
+	return matchesCondition(operator, values[propertyID], filter["value"])
+}
+
+func matchesCondition(operator string, actual, expected any) bool {
+	switch operator {
+	case "is_empty":
+		return IsEmptyValue(actual)
+	case "is_not_empty":
+		return !IsEmptyValue(actual)
+	case "equals":
+		return anyElement(actual, func(v any) bool { return valuesEqual(v, expected) })
+	case "not_equals":
+		return !anyElement(actual, func(v any) bool { return valuesEqual(v, expected) })
+	case "contains":
+		return containsValue(actual, expected)
+	case "not_contains":
+		return !containsValue(actual, expected)
+	case "greater_than", "after":
+		cmp, ok := compareValues(actual, expected)
+		return ok && cmp > 0
+	case "greater_than_or_equal":
+		cmp, ok := compareValues(actual, expected)
+		return ok && cmp >= 0
+	case "less_than", "before":
+		cmp, ok := compareValues(actual, expected)
+		return ok && cmp < 0
+	case "less_than_or_equal":
+		cmp, ok := compareValues(actual, expected)
+		return ok && cmp <= 0
+	}
+	return true
+}
+
+// IsEmptyValue reports whether v is nil, blank text or an empty list or object.
+func IsEmptyValue(v any) bool {
+	switch val := v.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(val) == ""
+	case []any:
+		return len(val) == 0
+	case map[string]any:
+		return len(val) == 0
+	}
+	return false
+}
+
+// anyElement applies fn to a scalar value or to each element of a list value.
+func anyElement(v any, fn func(any) bool) bool {
+	if list, ok := v.([]any); ok {
+		for _, el := range list {
+			if fn(el) {
+				return true
+			}
+		}
+		return false
+	}
+	return fn(v)
+}
+
+func valuesEqual(a, b any) bool {
+	if af, ok := NumericValue(a); ok {
+		if bf, ok := NumericValue(b); ok {
+			return af == bf
+		}
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+func containsValue(actual, expected any) bool {
+	if _, ok := actual.([]any); ok {
+		return anyElement(actual, func(v any) bool { return valuesEqual(v, expected) })
+	}
+	text, ok := actual.(string)
+	if !ok {
+		return false
+	}
+	return strings.Contains(strings.ToLower(text), strings.ToLower(fmt.Sprint(expected)))
+}
+
+// compareValues orders numbers numerically, dates chronologically and
+// anything else as text.
+func compareValues(a, b any) (int, bool) {
+	if IsEmptyValue(a) || b == nil {
+		return 0, false
+	}
+	if af, ok := NumericValue(a); ok {
+		if bf, ok := NumericValue(b); ok {
+			switch {
+			case af < bf:
+				return -1, true
+			case af > bf:
+				return 1, true
+			}
+			return 0, true
+		}
+	}
+	if at, ok := DateValue(a); ok {
+		if bt, ok := DateValue(b); ok {
+			return at.Compare(bt), true
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b)), true
+}
+
+// NumericValue reads v as a number, parsing numeric strings.
+func NumericValue(v any) (float64, bool) {
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case int:
+		return float64(val), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// DateValue parses RFC 3339 timestamps and plain dates. Date values stored as
+// {"start": ...} objects use their start.
+func DateValue(v any) (time.Time, bool) {
+	switch val := v.(type) {
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04", "2006-01-02"} {
+			if t, err := time.Parse(layout, strings.TrimSpace(val)); err == nil {
+				return t, true
+			}
+		}
+	case map[string]any:
+		return DateValue(val["start"])
+	}
+	return time.Time{}, false
+}
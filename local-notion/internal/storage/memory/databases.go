@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// CreateDatabase persists a database with properties and views. A database
+// without a slug gets a unique one generated from its title.
+func (s *Store) CreateDatabase(ctx context.Context, in storage.CreateDatabaseInput) (*domain.Database, error) {
+	if err := storage.RequireFields("title", in.Title); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if strings.TrimSpace(in.Slug) == "" {
+		in.Slug, _ = storage.UniqueSlug(storage.SlugOrDefault(in.Title, "database"), func(candidate string) (bool, error) {
+			return s.databaseSlugTaken(candidate), nil
+		})
+	}
+	if s.databaseSlugTaken(in.Slug) {
+		return nil, storage.SlugConflict(in.Slug)
+	}
+	ts := now()
+	database := &domain.Database{
+		ID:          uuid.NewString(),
+		Slug:        in.Slug,
+		Title:       in.Title,
+		Description: in.Description,
+		Icon:        in.Icon,
+		CoverImage:  in.CoverImage,
+		IsTemplate:  in.IsTemplate,
+		Version:     1,
+		CreatedAt:   ts,
+		UpdatedAt:   ts,
+		Properties:  make([]domain.DatabaseProperty, 0, len(in.Properties)),
+		Views:       make([]domain.DatabaseView, 0, len(in.Views)),
+	}
+	slugs := make(map[string]bool, len(in.Properties))
+	for _, propInput := range in.Properties {
+		if slugs[propInput.Slug] {
+			return nil, storage.SlugConflict(propInput.Slug)
+		}
+		slugs[propInput.Slug] = true
+		database.Properties = append(database.Properties, domain.DatabaseProperty{
+			ID:         uuid.NewString(),
+			DatabaseID: database.ID,
+			Name:       propInput.Name,
+			Slug:       propInput.Slug,
+			Type:       propInput.Type,
+			Config:     propInput.Config,
+			IsRequired: propInput.IsRequired,
+			Default:    propInput.Default,
+			OrderIndex: propInput.OrderIndex,
+			CreatedAt:  ts,
+			UpdatedAt:  ts,
+		})
+	}
+	for _, viewInput := range in.Views {
+		database.Views = append(database.Views, domain.DatabaseView{
+			ID:            uuid.NewString(),
+			DatabaseID:    database.ID,
+			Name:          viewInput.Name,
+			Type:          viewInput.Type,
+			Filters:       viewInput.Filters,
+			Sorts:         viewInput.Sorts,
+			Grouping:      viewInput.Grouping,
+			Display:       viewInput.Display,
+			LayoutOptions: viewInput.LayoutOptions,
+			Version:       1,
+			CreatedAt:     ts,
+			UpdatedAt:     ts,
+		})
+	}
+	stored, err := roundTrip(database)
+	if err != nil {
+		return nil, fmt.Errorf("marshal database: %w", err)
+	}
+	// Databases read back without properties or views hold nil slices.
+	if len(stored.Properties) == 0 {
+		stored.Properties = nil
+	}
+	if len(stored.Views) == 0 {
+		stored.Views = nil
+	}
+	sort.SliceStable(stored.Properties, func(i, j int) bool {
+		return stored.Properties[i].OrderIndex < stored.Properties[j].OrderIndex
+	})
+	s.databases[database.ID] = stored
+	return database, nil
+}
+
+// GetDatabase fetches a database with its properties and views. It returns
+// ErrDatabaseNotFound when the database does not exist.
+func (s *Store) GetDatabase(ctx context.Context, id string) (*domain.Database, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	db, ok := s.databases[id]
+	if !ok {
+		return nil, storage.ErrDatabaseNotFound
+	}
+	return clone(db), nil
+}
+
+// databaseSlugTaken reports whether a database already uses slug. The caller
+// must hold the lock.
+func (s *Store) databaseSlugTaken(slug string) bool {
+	for _, db := range s.databases {
+		if db.Slug == slug {
+			return true
+		}
+	}
+	return false
+}
+
+// propertySlugs maps property slugs to identifiers for a database. The
+// caller must hold the lock.
+func (s *Store) propertySlugs(databaseID string) map[string]string {
+	slugs := make(map[string]string)
+	if db, ok := s.databases[databaseID]; ok {
+		for _, prop := range db.Properties {
+			slugs[prop.Slug] = prop.ID
+		}
+	}
+	return slugs
+}
@@ -0,0 +1,409 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// itemRecord is a stored item. Its page lives with the other pages and its
+// values are kept as JSON keyed by property ID, as the sqlite backend does.
+type itemRecord struct {
+	item   domain.DatabaseItem // without Page and PropertyMap
+	pageID string
+	values map[string]*storedValue
+}
+
+type storedValue struct {
+	id        string
+	raw       []byte
+	createdAt time.Time
+	updatedAt time.Time
+}
+
+func (r *itemRecord) touch(ts time.Time) {
+	r.item.Version++
+	r.item.UpdatedAt = ts
+}
+
+// CreateDatabaseItem persists a new item, its page and its values. Item
+// templates are not supported, so any TemplateID is reported as not found.
+func (s *Store) CreateDatabaseItem(ctx context.Context, in storage.CreateDatabaseItemInput) (*domain.DatabaseItem, error) {
+	if err := storage.RequireFields("database_id", in.DatabaseID); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.databases[in.DatabaseID]; !ok {
+		return nil, storage.ErrDatabaseNotFound
+	}
+	if in.TemplateID != "" {
+		return nil, storage.ErrTemplateNotFound
+	}
+	if strings.TrimSpace(in.Page.Slug) == "" {
+		in.Page.Slug = s.uniquePageSlug(storage.SlugOrDefault(in.Page.Title, "item"))
+	}
+	if s.slugTaken(in.Page.Slug) {
+		return nil, storage.SlugConflict(in.Page.Slug)
+	}
+	ts := now()
+	rec := &itemRecord{pageID: uuid.NewString(), values: make(map[string]*storedValue, len(in.Values))}
+	rec.item = domain.DatabaseItem{
+		ID:         uuid.NewString(),
+		DatabaseID: in.DatabaseID,
+		Position:   in.Position,
+		Version:    1,
+		CreatedAt:  ts,
+		UpdatedAt:  ts,
+	}
+	propMap := s.propertySlugs(in.DatabaseID)
+	storedValues := make(map[string]domain.DatabaseValue, len(in.Values))
+	for slug, value := range in.Values {
+		propID, ok := propMap[slug]
+		if !ok {
+			return nil, storage.InvalidField("values."+slug, "refers to an unknown property")
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("marshal value %s: %w", slug, err)
+		}
+		stored := &storedValue{id: uuid.NewString(), raw: raw, createdAt: ts, updatedAt: ts}
+		rec.values[propID] = stored
+		storedValues[slug] = domain.DatabaseValue{
+			ID:         stored.id,
+			ItemID:     rec.item.ID,
+			PropertyID: propID,
+			RawValue:   value,
+			CreatedAt:  ts,
+			UpdatedAt:  ts,
+		}
+	}
+	page := &domain.Page{
+		ID:           rec.pageID,
+		Slug:         in.Page.Slug,
+		Title:        in.Page.Title,
+		Summary:      in.Page.Summary,
+		Content:      in.Page.Content,
+		ParentPageID: in.Page.ParentPageID,
+		Tags:         in.Page.Tags,
+		Version:      1,
+		CreatedAt:    ts,
+		UpdatedAt:    ts,
+	}
+	rec.item.Rank = s.nextItemRank(in.DatabaseID)
+	s.pages[page.ID] = clone(page)
+	s.items[rec.item.ID] = rec
+	s.pageItems[page.ID] = rec.item.ID
+
+	item := rec.item
+	item.Page = *page
+	item.PropertyMap = storedValues
+	return &item, nil
+}
+
+// GetDatabaseItem loads a single item with its page and values.
+func (s *Store) GetDatabaseItem(ctx context.Context, databaseID, itemID string) (*domain.DatabaseItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rec, err := s.item(databaseID, itemID)
+	if err != nil {
+		return nil, err
+	}
+	return s.loadItem(rec), nil
+}
+
+// UpdateDatabaseItem applies a partial update to an item's page and values.
+func (s *Store) UpdateDatabaseItem(ctx context.Context, in storage.UpdateDatabaseItemInput) (*domain.DatabaseItem, error) {
+	if err := storage.RequireFields("database_id", in.DatabaseID, "item_id", in.ItemID); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, err := s.item(in.DatabaseID, in.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.CheckVersion(in.ExpectedVersion, rec.item.Version); err != nil {
+		return nil, err
+	}
+	if in.Title != nil && strings.TrimSpace(*in.Title) == "" {
+		return nil, storage.InvalidField("title", "cannot be empty")
+	}
+	// Validate every value before changing anything, since there is no
+	// transaction to roll back.
+	propMap := s.propertySlugs(in.DatabaseID)
+	raws := make(map[string][]byte, len(in.Values))
+	for slug, value := range in.Values {
+		propID, ok := propMap[slug]
+		if !ok {
+			return nil, storage.InvalidField("values."+slug, "refers to an unknown property")
+		}
+		if value == nil {
+			raws[propID] = nil
+			continue
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("marshal value %s: %w", slug, err)
+		}
+		raws[propID] = raw
+	}
+
+	ts := now()
+	if page, ok := s.pages[rec.pageID]; ok {
+		if in.Title != nil {
+			page.Title = *in.Title
+		}
+		if in.Summary != nil {
+			page.Summary = *in.Summary
+		}
+		if in.Content != nil {
+			page.Content = *in.Content
+		}
+		if in.Tags != nil {
+			page.Tags = slices.Clone(in.Tags)
+		}
+		if in.Archived != nil {
+			page.IsArchived = *in.Archived
+		}
+		page.Version++
+		page.UpdatedAt = ts
+	}
+	for propID, raw := range raws {
+		switch stored, ok := rec.values[propID]; {
+		case raw == nil:
+			delete(rec.values, propID)
+		case ok:
+			stored.raw = raw
+			stored.updatedAt = ts
+		default:
+			rec.values[propID] = &storedValue{id: uuid.NewString(), raw: raw, createdAt: ts, updatedAt: ts}
+		}
+	}
+	if in.Archived != nil {
+		rec.item.IsArchived = *in.Archived
+	}
+	rec.touch(ts)
+	return s.loadItem(rec), nil
+}
+
+// ArchiveDatabaseItem archives or restores an item and its backing page.
+func (s *Store) ArchiveDatabaseItem(ctx context.Context, databaseID, itemID string, archived bool) (*domain.DatabaseItem, error) {
+	return s.UpdateDatabaseItem(ctx, storage.UpdateDatabaseItemInput{DatabaseID: databaseID, ItemID: itemID, Archived: &archived})
+}
+
+// DeleteDatabaseItem permanently removes an item, its values and its page.
+// Child pages of the item page are detached.
+func (s *Store) DeleteDatabaseItem(ctx context.Context, databaseID, itemID string) error {
+	if err := storage.RequireFields("database_id", databaseID, "item_id", itemID); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, err := s.item(databaseID, itemID)
+	if err != nil {
+		return err
+	}
+	delete(s.items, itemID)
+	delete(s.pageItems, rec.pageID)
+	s.deletePage(rec.pageID)
+	return nil
+}
+
+// MoveDatabaseItem gives an item a rank between its new neighbours,
+// rebalancing the database's ranks when the keys there have run out of room.
+func (s *Store) MoveDatabaseItem(ctx context.Context, in storage.MoveDatabaseItemInput) (*domain.DatabaseItem, error) {
+	if (in.BeforeID == "") == (in.AfterID == "") {
+		return nil, storage.ValidationError(
+			storage.FieldError{Field: "before_id", Message: "exactly one of before_id or after_id is required"},
+			storage.FieldError{Field: "after_id", Message: "exactly one of before_id or after_id is required"},
+		)
+	}
+	if in.BeforeID == in.ItemID || in.AfterID == in.ItemID {
+		return nil, storage.InvalidField("item_id", "cannot move an item relative to itself")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, err := s.item(in.DatabaseID, in.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	var rank string
+	for attempt := 0; attempt < 2; attempt++ {
+		lower, upper, err := s.moveBounds(in)
+		if err != nil {
+			return nil, err
+		}
+		rank, err = storage.RankBetween(lower, upper)
+		if err == nil && len(rank) <= storage.MaxRankLength {
+			break
+		}
+		if attempt == 1 {
+			return nil, fmt.Errorf("compute item rank: %w", storage.ErrRankOrder)
+		}
+		s.rebalanceItemRanks(in.DatabaseID)
+	}
+	rec.item.Rank = rank
+	rec.touch(now())
+	return s.loadItem(rec), nil
+}
+
+// QueryView returns the non-archived items of a view that match its filters,
+// ordered by its sorts and then by rank, with the aggregates configured in its
+// layout options.
+func (s *Store) QueryView(ctx context.Context, databaseID, viewID string) (*domain.ViewResult, error) {
+	if err := storage.RequireFields("database_id", databaseID, "view_id", viewID); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	db, ok := s.databases[databaseID]
+	if !ok {
+		return nil, storage.ErrDatabaseNotFound
+	}
+	var items []domain.DatabaseItem
+	for _, rec := range s.databaseItems(databaseID) {
+		if !rec.item.IsArchived {
+			items = append(items, *s.loadItem(rec))
+		}
+	}
+	return storage.QueryViewItems(clone(db), viewID, items)
+}
+
+// item returns the record of an item of the given database. The caller must
+// hold the lock.
+func (s *Store) item(databaseID, itemID string) (*itemRecord, error) {
+	rec, ok := s.items[itemID]
+	if !ok || rec.item.DatabaseID != databaseID {
+		return nil, storage.ErrItemNotFound
+	}
+	return rec, nil
+}
+
+// loadItem copies an item with its page and values keyed by property slug.
+// The caller must hold the lock.
+func (s *Store) loadItem(rec *itemRecord) *domain.DatabaseItem {
+	item := rec.item
+	if page, ok := s.pages[rec.pageID]; ok {
+		item.Page = *clone(page)
+	}
+	propSlugs := make(map[string]string)
+	for slug, id := range s.propertySlugs(item.DatabaseID) {
+		propSlugs[id] = slug
+	}
+	item.PropertyMap = make(map[string]domain.DatabaseValue, len(rec.values))
+	for propID, stored := range rec.values {
+		slug, ok := propSlugs[propID]
+		if !ok {
+			continue
+		}
+		value := domain.DatabaseValue{
+			ID:         stored.id,
+			ItemID:     item.ID,
+			PropertyID: propID,
+			CreatedAt:  stored.createdAt,
+			UpdatedAt:  stored.updatedAt,
+		}
+		var parsed any
+		if err := json.Unmarshal(stored.raw, &parsed); err == nil {
+			value.RawValue = parsed
+		}
+		item.PropertyMap[slug] = value
+	}
+	return &item
+}
+
+// databaseItems returns the records of a database. The caller must hold the
+// lock.
+func (s *Store) databaseItems(databaseID string) []*itemRecord {
+	var records []*itemRecord
+	for _, rec := range s.items {
+		if rec.item.DatabaseID == databaseID {
+			records = append(records, rec)
+		}
+	}
+	return records
+}
+
+// moveBounds returns the ranks the moved item must fall between, ignoring the
+// moved item itself. The caller must hold the lock.
+func (s *Store) moveBounds(in storage.MoveDatabaseItemInput) (string, string, error) {
+	anchorID := in.AfterID
+	if in.BeforeID != "" {
+		anchorID = in.BeforeID
+	}
+	anchorRec, err := s.item(in.DatabaseID, anchorID)
+	if err != nil {
+		return "", "", err
+	}
+	anchor := anchorRec.item.Rank
+	var neighbour string
+	for _, rec := range s.databaseItems(in.DatabaseID) {
+		r := rec.item.Rank
+		if rec.item.ID == in.ItemID {
+			continue
+		}
+		if in.AfterID != "" && r > anchor && (neighbour == "" || r < neighbour) {
+			neighbour = r
+		}
+		if in.BeforeID != "" && r < anchor && r > neighbour {
+			neighbour = r
+		}
+	}
+	if in.AfterID != "" {
+		return anchor, neighbour, nil
+	}
+	return neighbour, anchor, nil
+}
+
+// nextItemRank returns a rank that sorts after every item of the database.
+// The caller must hold the lock.
+func (s *Store) nextItemRank(databaseID string) string {
+	rank := storage.RankAfter(s.lastItemRank(databaseID))
+	if len(rank) <= storage.MaxRankLength {
+		return rank
+	}
+	s.rebalanceItemRanks(databaseID)
+	return storage.RankAfter(s.lastItemRank(databaseID))
+}
+
+func (s *Store) lastItemRank(databaseID string) string {
+	var last string
+	for _, rec := range s.databaseItems(databaseID) {
+		if rec.item.Rank > last {
+			last = rec.item.Rank
+		}
+	}
+	return last
+}
+
+// rebalanceItemRanks rewrites every rank of a database with evenly spaced
+// keys while preserving the current order, leaving versions and updated_at
+// alone. The caller must hold the lock.
+func (s *Store) rebalanceItemRanks(databaseID string) {
+	records := s.databaseItems(databaseID)
+	sort.Slice(records, func(i, j int) bool {
+		a, b := records[i].item, records[j].item
+		switch {
+		case a.Rank != b.Rank:
+			return a.Rank < b.Rank
+		case a.Position != b.Position:
+			return a.Position < b.Position
+		case !a.CreatedAt.Equal(b.CreatedAt):
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+	for idx, key := range storage.RankSequence(len(records)) {
+		records[idx].item.Rank = key
+	}
+}
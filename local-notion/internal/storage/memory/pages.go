@@ -0,0 +1,215 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// CreatePage persists a new page. A page without a slug gets a unique one
+// generated from its title.
+func (s *Store) CreatePage(ctx context.Context, in storage.CreatePageInput) (*domain.Page, error) {
+	if err := storage.RequireFields("title", in.Title); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if strings.TrimSpace(in.Slug) == "" {
+		in.Slug = s.uniquePageSlug(storage.SlugOrDefault(in.Title, "page"))
+	}
+	if s.slugTaken(in.Slug) {
+		return nil, storage.SlugConflict(in.Slug)
+	}
+	ts := now()
+	page := &domain.Page{
+		ID:           uuid.NewString(),
+		Slug:         in.Slug,
+		Title:        in.Title,
+		Summary:      in.Summary,
+		Content:      in.Content,
+		ParentPageID: in.ParentPageID,
+		Tags:         in.Tags,
+		IsTemplate:   in.IsTemplate,
+		Version:      1,
+		CreatedAt:    ts,
+		UpdatedAt:    ts,
+	}
+	s.pages[page.ID] = clone(page)
+	links := storage.UniquePageLinks(in.LinkedPageIDs, page.ID)
+	if links != nil {
+		s.links[page.ID] = links
+	}
+	page.LinkedPageIDs = links
+	return page, nil
+}
+
+// GetPage retrieves a page by id. It returns ErrPageNotFound when the page does
+// not exist.
+func (s *Store) GetPage(ctx context.Context, id string) (*domain.Page, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.loadPage(id)
+}
+
+// ListPages returns a lightweight listing of stored pages. Template pages are
+// excluded.
+func (s *Store) ListPages(ctx context.Context) ([]domain.Page, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var pages []domain.Page
+	for _, page := range s.pages {
+		if page.IsTemplate {
+			continue
+		}
+		pages = append(pages, domain.Page{
+			ID:           page.ID,
+			Slug:         page.Slug,
+			Title:        page.Title,
+			Summary:      page.Summary,
+			ParentPageID: clone(page.ParentPageID),
+		})
+	}
+	sort.SliceStable(pages, func(i, j int) bool {
+		if pages[i].Title != pages[j].Title {
+			return pages[i].Title < pages[j].Title
+		}
+		return pages[i].ID < pages[j].ID
+	})
+	return pages, nil
+}
+
+// UpdatePage applies a partial update to a page. Updating the page of a
+// database item also bumps the item's version.
+func (s *Store) UpdatePage(ctx context.Context, in storage.UpdatePageInput) (*domain.Page, error) {
+	if in.Title != nil && strings.TrimSpace(*in.Title) == "" {
+		return nil, storage.InvalidField("title", "cannot be empty")
+	}
+	if in.Slug != nil && strings.TrimSpace(*in.Slug) == "" {
+		return nil, storage.InvalidField("slug", "cannot be empty")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	page, ok := s.pages[in.PageID]
+	if !ok {
+		return nil, storage.ErrPageNotFound
+	}
+	if err := storage.CheckVersion(in.ExpectedVersion, page.Version); err != nil {
+		return nil, err
+	}
+	if in.Slug != nil && *in.Slug != page.Slug {
+		if s.slugTaken(*in.Slug) {
+			return nil, storage.SlugConflict(*in.Slug)
+		}
+		page.Slug = *in.Slug
+	}
+	ts := now()
+	if in.Title != nil {
+		page.Title = *in.Title
+	}
+	if in.Summary != nil {
+		page.Summary = *in.Summary
+	}
+	if in.Content != nil {
+		page.Content = *in.Content
+	}
+	if in.Tags != nil {
+		page.Tags = slices.Clone(in.Tags)
+	}
+	page.Version++
+	page.UpdatedAt = ts
+	if itemID, ok := s.pageItems[page.ID]; ok {
+		s.items[itemID].touch(ts)
+	}
+	if in.LinkedPageIDs != nil {
+		if links := storage.UniquePageLinks(in.LinkedPageIDs, page.ID); links != nil {
+			s.links[page.ID] = links
+		} else {
+			delete(s.links, page.ID)
+		}
+	}
+	return s.loadPage(page.ID)
+}
+
+// ListPageLinks returns the pages a page links to and the pages linking to it.
+func (s *Store) ListPageLinks(ctx context.Context, pageID string) (*domain.PageLinks, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, ok := s.pages[pageID]; !ok {
+		return nil, storage.ErrPageNotFound
+	}
+	return s.pageLinks(pageID), nil
+}
+
+// loadPage returns a copy of a page together with its links. The caller must
+// hold the lock.
+func (s *Store) loadPage(id string) (*domain.Page, error) {
+	stored, ok := s.pages[id]
+	if !ok {
+		return nil, storage.ErrPageNotFound
+	}
+	page := clone(stored)
+	links := s.pageLinks(id)
+	page.LinkedPageIDs = links.Outbound
+	page.BacklinkedPageIDs = links.Inbound
+	return page, nil
+}
+
+// pageLinks collects the outbound and inbound links of a page, both sorted.
+// The caller must hold the lock.
+func (s *Store) pageLinks(id string) *domain.PageLinks {
+	links := &domain.PageLinks{PageID: id, Outbound: slices.Clone(s.links[id])}
+	for source, targets := range s.links {
+		if _, found := slices.BinarySearch(targets, id); found {
+			links.Inbound = append(links.Inbound, source)
+		}
+	}
+	sort.Strings(links.Inbound)
+	return links
+}
+
+// slugTaken reports whether a page already uses slug. The caller must hold
+// the lock.
+func (s *Store) slugTaken(slug string) bool {
+	for _, page := range s.pages {
+		if page.Slug == slug {
+			return true
+		}
+	}
+	return false
+}
+
+// uniquePageSlug returns the first variant of base no page uses. The caller
+// must hold the lock.
+func (s *Store) uniquePageSlug(base string) string {
+	slug, _ := storage.UniqueSlug(base, func(candidate string) (bool, error) {
+		return s.slugTaken(candidate), nil
+	})
+	return slug
+}
+
+// deletePage removes a page and every link from or to it, and detaches its
+// child pages. The caller must hold the lock.
+func (s *Store) deletePage(id string) {
+	delete(s.links, id)
+	for source, targets := range s.links {
+		if idx, found := slices.BinarySearch(targets, id); found {
+			s.links[source] = slices.Delete(targets, idx, idx+1)
+			if len(s.links[source]) == 0 {
+				delete(s.links, source)
+			}
+		}
+	}
+	for _, page := range s.pages {
+		if page.ParentPageID != nil && *page.ParentPageID == id {
+			page.ParentPageID = nil
+			page.Version++
+		}
+	}
+	delete(s.pages, id)
+}
@@ -0,0 +1,76 @@
+// Package memory is an in-process storage backend. It keeps every record in
+// maps guarded by a single lock and mirrors the behaviour of the sqlite
+// backend, which makes it a fast stand-in for handler tests.
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// Store holds pages, databases and items in memory. It is safe for
+// concurrent use.
+type Store struct {
+	mu        sync.RWMutex
+	pages     map[string]*domain.Page // without links
+	links     map[string][]string     // source page ID to sorted target IDs
+	databases map[string]*domain.Database
+	items     map[string]*itemRecord
+	pageItems map[string]string // page ID to the ID of the item it backs
+}
+
+var _ storage.Store = (*Store)(nil)
+
+// New returns an empty store.
+func New() *Store {
+	return &Store{
+		pages:     make(map[string]*domain.Page),
+		links:     make(map[string][]string),
+		databases: make(map[string]*domain.Database),
+		items:     make(map[string]*itemRecord),
+		pageItems: make(map[string]string),
+	}
+}
+
+// Ping always succeeds.
+func (s *Store) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// Close is a no-op; the store's data is released with it.
+func (s *Store) Close() error {
+	return nil
+}
+
+// roundTrip returns a deep copy of v as it would read back from a JSON
+// column, so values come back with the same types the sqlite backend
+// returns.
+func roundTrip[T any](v T) (T, error) {
+	var out T
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return out, err
+	}
+	err = json.Unmarshal(raw, &out)
+	return out, err
+}
+
+// clone copies a stored record before it leaves the lock. Stored records
+// went through roundTrip on the way in, so copying them cannot fail.
+func clone[T any](v T) T {
+	out, err := roundTrip(v)
+	if err != nil {
+		panic(fmt.Sprintf("memory: clone %T: %v", v, err))
+	}
+	return out
+}
+
+func now() time.Time {
+	return time.Now().UTC()
+}
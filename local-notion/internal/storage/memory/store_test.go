@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/storage"
+	"github.com/example/agents-playground/internal/storage/storagetest"
+)
+
+func TestStoreConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Store { return New() })
+}
+
+func TestStoreConcurrentWrites(t *testing.T) {
+	ctx := context.Background()
+	store := New()
+	page, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "shared", Title: "Shared"})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: fmt.Sprintf("page-%d", i), Title: "Page", LinkedPageIDs: []string{page.ID}}); err != nil {
+				errs <- err
+				return
+			}
+			if _, err := store.UpdatePage(ctx, storage.UpdatePageInput{PageID: page.ID, Content: &[]string{"edit"}[0]}); err != nil {
+				errs <- err
+				return
+			}
+			if _, err := store.GetPage(ctx, page.ID); err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	got, err := store.GetPage(ctx, page.ID)
+	require.NoError(t, err)
+	require.Equal(t, 17, got.Version)
+	require.Len(t, got.BacklinkedPageIDs, 16)
+}
@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// mentionPattern matches an @name that does not continue a word or an email
+// address.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@.])@(\w[\w.-]*)`)
+
+// Mentions returns the distinct users mentioned as @name in text, sorted.
+// Trailing dots and hyphens are taken as punctuation, not part of the name.
+func Mentions(text string) []string {
+	mentions := []string{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		name := strings.TrimRight(match[1], ".-")
+		if name != "" && !slices.Contains(mentions, name) {
+			mentions = append(mentions, name)
+		}
+	}
+	slices.Sort(mentions)
+	return mentions
+}
@@ -0,0 +1,21 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMentions(t *testing.T) {
+	cases := map[string][]string{
+		"":                                {},
+		"no mentions here":                {},
+		"@ana please review":              {"ana"},
+		"thanks @bo.li, and @ana.":        {"ana", "bo.li"},
+		"(@ana) @ana @carl-d-":            {"ana", "carl-d"},
+		"mail ana@example.com or @ @@bob": {},
+	}
+	for text, want := range cases {
+		require.Equal(t, want, Mentions(text), text)
+	}
+}
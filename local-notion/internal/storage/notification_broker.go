@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"sync"
+
+	"github.com/example/agents-playground/internal/domain"
+)
+
+// notificationBufferSize is the number of notifications a subscriber may fall
+// behind by before it is dropped.
+const notificationBufferSize = 64
+
+// NotificationBroker fans committed notifications out to the subscribers of
+// their recipient. Backends queue notifications on the transaction that
+// creates them and publish them once it commits, so subscribers never see a
+// notification that was rolled back.
+type NotificationBroker struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan domain.Notification]struct{} // by recipient
+}
+
+// NewNotificationBroker returns a broker without subscribers.
+func NewNotificationBroker() *NotificationBroker {
+	return &NotificationBroker{subscribers: make(map[string]map[chan domain.Notification]struct{})}
+}
+
+// Subscribe streams the notifications published for recipient from now on,
+// in publish order. The channel is closed by cancel, or when the subscriber
+// falls too far behind; cancel must be called either way.
+func (b *NotificationBroker) Subscribe(recipient string) (<-chan domain.Notification, func()) {
+	ch := make(chan domain.Notification, notificationBufferSize)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subscribers[recipient] == nil {
+		b.subscribers[recipient] = make(map[chan domain.Notification]struct{})
+	}
+	b.subscribers[recipient][ch] = struct{}{}
+	return ch, func() { b.unsubscribe(recipient, ch) }
+}
+
+func (b *NotificationBroker) unsubscribe(recipient string, ch chan domain.Notification) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(recipient, ch)
+}
+
+// remove closes and forgets a subscriber; b.mu must be held.
+func (b *NotificationBroker) remove(recipient string, ch chan domain.Notification) {
+	subs := b.subscribers[recipient]
+	if _, ok := subs[ch]; !ok {
+		return
+	}
+	delete(subs, ch)
+	close(ch)
+	if len(subs) == 0 {
+		delete(b.subscribers, recipient)
+	}
+}
+
+// Publish sends notifications to the subscribers of their recipients. A
+// subscriber whose buffer is full is closed rather than blocking the writer;
+// it has to reload the inbox and subscribe again.
+func (b *NotificationBroker) Publish(notifications []domain.Notification) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, n := range notifications {
+		for ch := range b.subscribers[n.Recipient] {
+			select {
+			case ch <- n:
+			default:
+				b.remove(n.Recipient, ch)
+			}
+		}
+	}
+}
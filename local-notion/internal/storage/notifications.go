@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/example/agents-playground/internal/domain"
+)
+
+// Notification sources.
+const (
+	NotificationSourceMention    = "mention"     // @name in page content or a comment
+	NotificationSourceReply      = "reply"       // a reply in a thread the user took part in
+	NotificationSourceAssignment = "assignment"  // added to a person property of an item
+	NotificationSourceItemUpdate = "item_update" // a change to an item the user is assigned to
+	NotificationSourceReminder   = "reminder"    // a date reminder on an item the user is assigned to
+)
+
+var notificationSources = []string{
+	NotificationSourceMention,
+	NotificationSourceReply,
+	NotificationSourceAssignment,
+	NotificationSourceItemUpdate,
+	NotificationSourceReminder,
+}
+
+// Notification list page sizes.
+const (
+	DefaultNotificationLimit = 50
+	MaxNotificationLimit     = 500
+)
+
+// maxExcerptRunes bounds the text a notification quotes.
+const maxExcerptRunes = 140
+
+// ErrNotificationNotFound is returned when a user has no notification with
+// the given id.
+var ErrNotificationNotFound = NotFoundError("notification_not_found", "notification not found")
+
+// NotificationLimit returns the page size of a notification listing.
+func NotificationLimit(filter NotificationFilter) int {
+	if filter.Limit <= 0 {
+		return DefaultNotificationLimit
+	}
+	return min(filter.Limit, MaxNotificationLimit)
+}
+
+// CheckMutedSources rejects notification preferences muting unknown sources.
+func CheckMutedSources(prefs domain.NotificationPreferences) error {
+	if err := RequireFields("recipient", prefs.Recipient); err != nil {
+		return err
+	}
+	for i, source := range prefs.Muted {
+		if !slices.Contains(notificationSources, source) {
+			return InvalidField(fmt.Sprintf("muted[%d]", i), "must be one of %s", strings.Join(notificationSources, ", "))
+		}
+	}
+	return nil
+}
+
+// NotificationExcerpt shortens text for a notification.
+func NotificationExcerpt(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= maxExcerptRunes {
+		return text
+	}
+	return string(runes[:maxExcerptRunes-1]) + "…"
+}
+
+// MentionContext returns the text around the first mention of name, so that
+// a notification about a long page quotes the relevant part.
+func MentionContext(text, name string) string {
+	i := strings.Index(text, "@"+name)
+	if i < 0 {
+		return text
+	}
+	runes := []rune(text)
+	at := len([]rune(text[:i]))
+	start := max(0, at-maxExcerptRunes/2)
+	prefix := ""
+	if start > 0 {
+		prefix = "…"
+	}
+	return prefix + string(runes[start:])
+}
+
+// NewMentions returns the users mentioned in updated but not in original.
+func NewMentions(original, updated string) []string {
+	before := Mentions(original)
+	var added []string
+	for _, name := range Mentions(updated) {
+		if !slices.Contains(before, name) {
+			added = append(added, name)
+		}
+	}
+	return added
+}
+
+// ChangeActor returns the author a change names, or the caller it is
+// attributed to in the audit log.
+func ChangeActor(ctx context.Context, author string) string {
+	if author != "" {
+		return author
+	}
+	return AuditInfoFrom(ctx).Actor
+}
+
+// ItemPeople returns the distinct users named in the values of item whose
+// property isPerson reports as a person property, sorted.
+func ItemPeople(item *domain.DatabaseItem, isPerson func(propertyID string) bool) []string {
+	var people []string
+	for _, value := range item.PropertyMap {
+		if !isPerson(value.PropertyID) {
+			continue
+		}
+		for _, name := range personNames(value.RawValue) {
+			if name = strings.TrimSpace(name); name != "" && !slices.Contains(people, name) {
+				people = append(people, name)
+			}
+		}
+	}
+	slices.Sort(people)
+	return people
+}
+
+// SplitItemPeople splits the people of an item after a change into those the
+// change added and those already there before it.
+func SplitItemPeople(before, after []string) (assigned, watching []string) {
+	for _, name := range after {
+		if slices.Contains(before, name) {
+			watching = append(watching, name)
+		} else {
+			assigned = append(assigned, name)
+		}
+	}
+	return assigned, watching
+}
+
+func personNames(value any) []string {
+	switch v := value.(type) {
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, el := range v {
+			out = append(out, fmt.Sprint(el))
+		}
+		return out
+	case []string:
+		return v
+	case string:
+		return []string{v}
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// ListAuditEntries returns matching audit entries newest first, together with
+// the cursor of the next page or "" when there are no more entries.
+func (s *Store) ListAuditEntries(ctx context.Context, filter storage.AuditFilter) ([]domain.AuditEntry, string, error) {
+	limit, before, err := storage.AuditPage(filter)
+	if err != nil {
+		return nil, "", err
+	}
+	where, args := auditWhere(filter)
+	if before > 0 {
+		args = append(args, before)
+		where = append(where, "id < $"+strconv.Itoa(len(args)))
+	}
+	entries, err := queryAudit(ctx, s.db, where, args, "DESC", limit+1)
+	if err != nil {
+		return nil, "", err
+	}
+	next := ""
+	if len(entries) > limit {
+		entries = entries[:limit]
+		next = strconv.FormatInt(entries[limit-1].ID, 10)
+	}
+	return entries, next, nil
+}
+
+// ExportAuditLog writes every matching audit entry to w as JSON Lines, oldest
+// first. Cursor and Limit are ignored. Entries are read in batches so the
+// connection is not held for the whole export.
+func (s *Store) ExportAuditLog(ctx context.Context, filter storage.AuditFilter, w io.Writer) error {
+	where, args := auditWhere(filter)
+	enc := json.NewEncoder(w)
+	var after int64
+	for {
+		batchArgs := append(append([]any{}, args...), after)
+		batchWhere := append(append([]string{}, where...), "id > $"+strconv.Itoa(len(batchArgs)))
+		entries, err := queryAudit(ctx, s.db, batchWhere, batchArgs, "ASC", storage.MaxAuditLimit)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := enc.Encode(entry); err != nil {
+				return fmt.Errorf("write audit entry: %w", err)
+			}
+		}
+		if len(entries) < storage.MaxAuditLimit {
+			return nil
+		}
+		after = entries[len(entries)-1].ID
+	}
+}
+
+func auditWhere(filter storage.AuditFilter) ([]string, []any) {
+	var where []string
+	var args []any
+	cond := func(column, op string, value any) {
+		args = append(args, value)
+		where = append(where, column+" "+op+" $"+strconv.Itoa(len(args)))
+	}
+	for _, c := range []struct{ column, value string }{
+		{"actor", filter.Actor},
+		{"request_id", filter.RequestID},
+		{"action", filter.Action},
+		{"entity_type", filter.EntityType},
+		{"entity_id", filter.EntityID},
+	} {
+		if c.value != "" {
+			cond(c.column, "=", c.value)
+		}
+	}
+	if filter.Since != nil {
+		cond("created_at", ">=", filter.Since.UTC())
+	}
+	if filter.Until != nil {
+		cond("created_at", "<", filter.Until.UTC())
+	}
+	return where, args
+}
+
+func queryAudit(ctx context.Context, q queryer, where []string, args []any, order string, limit int) ([]domain.AuditEntry, error) {
+	query := `SELECT id, actor, request_id, action, entity_type, entity_id, before_json, after_json, created_at FROM audit_log`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	args = append(args, limit)
+	query += ` ORDER BY id ` + order + ` LIMIT $` + strconv.Itoa(len(args))
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query audit log: %w", err)
+	}
+	defer rows.Close()
+	entries := []domain.AuditEntry{}
+	for rows.Next() {
+		var entry domain.AuditEntry
+		var before, after sql.NullString
+		if err := rows.Scan(&entry.ID, &entry.Actor, &entry.RequestID, &entry.Action, &entry.EntityType, &entry.EntityID, &before, &after, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan audit entry: %w", err)
+		}
+		if before.Valid {
+			entry.Before = json.RawMessage(before.String)
+		}
+		if after.Valid {
+			entry.After = json.RawMessage(after.String)
+		}
+		entry.CreatedAt = entry.CreatedAt.UTC()
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// recordAudit appends an audit entry using q, so it commits or rolls back
+// with the mutation it describes. before and after are JSON encoded; nil
+// values are stored as NULL.
+func recordAudit(ctx context.Context, q queryer, action, entityType, entityID string, before, after any, ts time.Time) error {
+	beforeJSON, err := storage.AuditSnapshot(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := storage.AuditSnapshot(after)
+	if err != nil {
+		return err
+	}
+	info := storage.AuditInfoFrom(ctx)
+	if _, err := q.ExecContext(ctx, `INSERT INTO audit_log(actor, request_id, action, entity_type, entity_id, before_json, after_json, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		info.Actor, info.RequestID, action, entityType, entityID, beforeJSON, afterJSON, ts); err != nil {
+		return fmt.Errorf("insert audit entry: %w", err)
+	}
+	return nil
+}
+
+func recordAuditChanges(ctx context.Context, q queryer, changes []storage.AuditChange, ts time.Time) error {
+	for _, change := range changes {
+		if err := recordAudit(ctx, q, change.Action, change.EntityType, change.EntityID, change.Before, change.After, ts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// auditPageChange records the change of a page from its before state to its
+// current state. A nil before records the page as created.
+func auditPageChange(ctx context.Context, q queryer, action, pageID string, before *domain.Page, ts time.Time) error {
+	after, err := loadAuditPage(ctx, q, pageID)
+	if err != nil {
+		return err
+	}
+	if before == nil {
+		action = storage.AuditActionCreate
+	}
+	return recordAudit(ctx, q, action, storage.AuditEntityPage, pageID, before, after, ts)
+}
+
+// loadAuditPage loads a page with its outbound links for an audit snapshot.
+func loadAuditPage(ctx context.Context, q queryer, id string) (*domain.Page, error) {
+	page, err := loadPage(ctx, q, id)
+	if err != nil {
+		return nil, err
+	}
+	if page.LinkedPageIDs, err = loadOutboundLinks(ctx, q, id); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// loadOutboundLinks returns the sorted targets of a page's links, never nil.
+func loadOutboundLinks(ctx context.Context, q queryer, pageID string) ([]string, error) {
+	links, err := queryStrings(ctx, q, `SELECT target_page_id FROM page_links WHERE source_page_id = $1 ORDER BY target_page_id`, pageID)
+	if err != nil {
+		return nil, fmt.Errorf("select page links: %w", err)
+	}
+	if links == nil {
+		links = []string{}
+	}
+	return links, nil
+}
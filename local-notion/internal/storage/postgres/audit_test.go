@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+func TestStoreAuditRecordsMutations(t *testing.T) {
+	store := newTestStore(t)
+	ctx := storage.WithAuditInfo(context.Background(), storage.AuditInfo{Actor: "ana", RequestID: "req-1"})
+
+	page, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "notes", Title: "Notes"})
+	require.NoError(t, err)
+	renamed := "Renamed"
+	_, err = store.UpdatePage(ctx, storage.UpdatePageInput{PageID: page.ID, Title: &renamed})
+	require.NoError(t, err)
+	db, err := store.CreateDatabase(context.Background(), storage.CreateDatabaseInput{Slug: "tasks", Title: "Tasks", Properties: []storage.DatabasePropertyInput{
+		{Name: "Status", Slug: "status", Type: domain.PropertyTypeText},
+	}})
+	require.NoError(t, err)
+	item, err := store.CreateDatabaseItem(context.Background(), storage.CreateDatabaseItemInput{DatabaseID: db.ID, Page: storage.CreatePageInput{Title: "Task"}})
+	require.NoError(t, err)
+	_, err = store.UpdateDatabaseItem(ctx, storage.UpdateDatabaseItemInput{DatabaseID: db.ID, ItemID: item.ID, Values: map[string]any{"status": "Done"}})
+	require.NoError(t, err)
+	require.NoError(t, store.DeleteDatabaseItem(ctx, db.ID, item.ID))
+
+	entries, next, err := store.ListAuditEntries(context.Background(), storage.AuditFilter{Actor: "ana"})
+	require.NoError(t, err)
+	require.Empty(t, next)
+	var got []string
+	for _, entry := range entries {
+		require.Equal(t, "req-1", entry.RequestID)
+		got = append(got, entry.Action+" "+entry.EntityType)
+	}
+	require.Equal(t, []string{"delete item", "create value", "update page", "create page"}, got)
+
+	var before, after domain.Page
+	require.NoError(t, json.Unmarshal(entries[2].Before, &before))
+	require.NoError(t, json.Unmarshal(entries[2].After, &after))
+	require.Equal(t, "Notes", before.Title)
+	require.Equal(t, "Renamed", after.Title)
+	require.Nil(t, entries[0].After)
+
+	entries, _, err = store.ListAuditEntries(context.Background(), storage.AuditFilter{EntityType: storage.AuditEntityProperty})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "", entries[0].Actor)
+
+	_, err = store.db.ExecContext(context.Background(), `DELETE FROM audit_log`)
+	require.ErrorContains(t, err, "append-only")
+}
+
+func TestStoreAuditPaginationAndExport(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	for _, slug := range []string{"a", "b", "c"} {
+		_, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: slug, Title: slug})
+		require.NoError(t, err)
+	}
+
+	first, next, err := store.ListAuditEntries(ctx, storage.AuditFilter{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, first, 2)
+	require.NotEmpty(t, next)
+	rest, next, err := store.ListAuditEntries(ctx, storage.AuditFilter{Limit: 2, Cursor: next})
+	require.NoError(t, err)
+	require.Len(t, rest, 1)
+	require.Empty(t, next)
+
+	var buf bytes.Buffer
+	require.NoError(t, store.ExportAuditLog(ctx, storage.AuditFilter{EntityType: storage.AuditEntityPage}, &buf))
+	scanner := bufio.NewScanner(&buf)
+	var ids []int64
+	for scanner.Scan() {
+		var entry domain.AuditEntry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		ids = append(ids, entry.ID)
+	}
+	require.Equal(t, []int64{rest[0].ID, first[1].ID, first[0].ID}, ids)
+}
@@ -0,0 +1,256 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// CreateDatabase persists a database with properties and views. A database
+// without a slug gets a unique one generated from its title.
+func (s *Store) CreateDatabase(ctx context.Context, in storage.CreateDatabaseInput) (*domain.Database, error) {
+	if err := storage.RequireFields("title", in.Title); err != nil {
+		return nil, err
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	if strings.TrimSpace(in.Slug) == "" {
+		if in.Slug, err = uniqueSlug(ctx, tx, "databases", storage.SlugOrDefault(in.Title, "database")); err != nil {
+			return nil, err
+		}
+	}
+	ts := now()
+	dbID := uuid.NewString()
+	if _, err := tx.ExecContext(ctx, `INSERT INTO databases(id, slug, title, description, icon, cover_image_id, is_template, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)`,
+		dbID, in.Slug, in.Title, in.Description, in.Icon, in.CoverImage, in.IsTemplate, ts); err != nil {
+		return nil, insertError("database", in.Slug, err)
+	}
+	props := make([]domain.DatabaseProperty, 0, len(in.Properties))
+	for _, propInput := range in.Properties {
+		propID := uuid.NewString()
+		cfg, err := json.Marshal(propInput.Config)
+		if err != nil {
+			return nil, fmt.Errorf("marshal property config: %w", err)
+		}
+		defVal, err := json.Marshal(propInput.Default)
+		if err != nil {
+			return nil, fmt.Errorf("marshal property default: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO database_properties(id, database_id, name, slug, type, config, is_required, default_value, order_index, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)`,
+			propID, dbID, propInput.Name, propInput.Slug, string(propInput.Type), string(cfg), propInput.IsRequired, string(defVal), propInput.OrderIndex, ts); err != nil {
+			return nil, insertError("property", propInput.Slug, err)
+		}
+		props = append(props, domain.DatabaseProperty{
+			ID:         propID,
+			DatabaseID: dbID,
+			Name:       propInput.Name,
+			Slug:       propInput.Slug,
+			Type:       propInput.Type,
+			Config:     propInput.Config,
+			IsRequired: propInput.IsRequired,
+			Default:    propInput.Default,
+			OrderIndex: propInput.OrderIndex,
+			CreatedAt:  ts,
+			UpdatedAt:  ts,
+		})
+	}
+	views := make([]domain.DatabaseView, 0, len(in.Views))
+	for _, viewInput := range in.Views {
+		view := domain.DatabaseView{
+			ID:            uuid.NewString(),
+			DatabaseID:    dbID,
+			Name:          viewInput.Name,
+			Type:          viewInput.Type,
+			Filters:       viewInput.Filters,
+			Sorts:         viewInput.Sorts,
+			Grouping:      viewInput.Grouping,
+			Display:       viewInput.Display,
+			LayoutOptions: viewInput.LayoutOptions,
+			Version:       1,
+			CreatedAt:     ts,
+			UpdatedAt:     ts,
+		}
+		if err := insertView(ctx, tx, view); err != nil {
+			return nil, err
+		}
+		views = append(views, view)
+	}
+	database := &domain.Database{
+		ID:          dbID,
+		Slug:        in.Slug,
+		Title:       in.Title,
+		Description: in.Description,
+		Icon:        in.Icon,
+		CoverImage:  in.CoverImage,
+		IsTemplate:  in.IsTemplate,
+		Version:     1,
+		CreatedAt:   ts,
+		UpdatedAt:   ts,
+		Properties:  props,
+		Views:       views,
+	}
+	if err := recordAuditChanges(ctx, tx, storage.DatabaseCreateChanges(database), ts); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit db: %w", err)
+	}
+	return database, nil
+}
+
+// GetDatabase fetches a database and eager loads properties and views. It
+// returns storage.ErrDatabaseNotFound when the database does not exist.
+func (s *Store) GetDatabase(ctx context.Context, id string) (*domain.Database, error) {
+	return loadDatabase(ctx, s.db, id)
+}
+
+// loadDatabase reads a database with its properties and views through q.
+func loadDatabase(ctx context.Context, q queryer, id string) (*domain.Database, error) {
+	var db domain.Database
+	var icon, cover sql.NullString
+	err := q.QueryRowContext(ctx, `SELECT id, slug, title, description, icon, cover_image_id, is_archived, is_template, version, created_at, updated_at FROM databases WHERE id = $1`, id).
+		Scan(&db.ID, &db.Slug, &db.Title, &db.Description, &icon, &cover, &db.IsArchived, &db.IsTemplate, &db.Version, &db.CreatedAt, &db.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrDatabaseNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan database: %w", err)
+	}
+	if icon.Valid {
+		db.Icon = &icon.String
+	}
+	if cover.Valid {
+		db.CoverImage = &cover.String
+	}
+	db.CreatedAt = db.CreatedAt.UTC()
+	db.UpdatedAt = db.UpdatedAt.UTC()
+	if db.Properties, err = loadProperties(ctx, q, db.ID); err != nil {
+		return nil, err
+	}
+	if db.Views, err = loadViews(ctx, q, db.ID); err != nil {
+		return nil, err
+	}
+	return &db, nil
+}
+
+func loadProperties(ctx context.Context, q queryer, databaseID string) ([]domain.DatabaseProperty, error) {
+	rows, err := q.QueryContext(ctx, `SELECT id, name, slug, type, config, is_required, default_value, order_index, created_at, updated_at FROM database_properties WHERE database_id = $1 ORDER BY order_index ASC, created_at ASC`, databaseID)
+	if err != nil {
+		return nil, fmt.Errorf("query properties: %w", err)
+	}
+	defer rows.Close()
+	var props []domain.DatabaseProperty
+	for rows.Next() {
+		var prop domain.DatabaseProperty
+		var cfg, def sql.NullString
+		if err := rows.Scan(&prop.ID, &prop.Name, &prop.Slug, &prop.Type, &cfg, &prop.IsRequired, &def, &prop.OrderIndex, &prop.CreatedAt, &prop.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan property: %w", err)
+		}
+		prop.DatabaseID = databaseID
+		if cfg.String != "" {
+			_ = json.Unmarshal([]byte(cfg.String), &prop.Config)
+		}
+		if def.String != "" {
+			var raw any
+			if err := json.Unmarshal([]byte(def.String), &raw); err == nil {
+				prop.Default = raw
+			}
+		}
+		prop.CreatedAt = prop.CreatedAt.UTC()
+		prop.UpdatedAt = prop.UpdatedAt.UTC()
+		props = append(props, prop)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate properties: %w", err)
+	}
+	return props, nil
+}
+
+// propertySlugs maps property slugs to identifiers for a database.
+func propertySlugs(ctx context.Context, q queryer, databaseID string) (map[string]string, error) {
+	rows, err := q.QueryContext(ctx, `SELECT id, slug FROM database_properties WHERE database_id = $1`, databaseID)
+	if err != nil {
+		return nil, fmt.Errorf("query properties: %w", err)
+	}
+	defer rows.Close()
+	slugs := make(map[string]string)
+	for rows.Next() {
+		var id, slug string
+		if err := rows.Scan(&id, &slug); err != nil {
+			return nil, fmt.Errorf("scan property: %w", err)
+		}
+		slugs[slug] = id
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate properties: %w", err)
+	}
+	return slugs, nil
+}
+
+func insertView(ctx context.Context, q queryer, view domain.DatabaseView) error {
+	columns := make([]any, 0, 5)
+	for _, part := range []any{view.Filters, view.Sorts, view.Grouping, view.Display, view.LayoutOptions} {
+		raw, err := json.Marshal(part)
+		if err != nil {
+			return fmt.Errorf("marshal view %s: %w", view.Name, err)
+		}
+		columns = append(columns, string(raw))
+	}
+	if _, err := q.ExecContext(ctx, `INSERT INTO database_views(id, database_id, name, type, filters, sorts, grouping, display_properties, layout_options, version, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		view.ID, view.DatabaseID, view.Name, string(view.Type), columns[0], columns[1], columns[2], columns[3], columns[4], view.Version, view.CreatedAt, view.UpdatedAt); err != nil {
+		return fmt.Errorf("insert view: %w", err)
+	}
+	return nil
+}
+
+func loadViews(ctx context.Context, q queryer, databaseID string) ([]domain.DatabaseView, error) {
+	rows, err := q.QueryContext(ctx, `SELECT id, name, type, filters, sorts, grouping, display_properties, layout_options, version, created_at, updated_at FROM database_views WHERE database_id = $1 ORDER BY created_at ASC, id ASC`, databaseID)
+	if err != nil {
+		return nil, fmt.Errorf("query views: %w", err)
+	}
+	defer rows.Close()
+	var views []domain.DatabaseView
+	for rows.Next() {
+		var view domain.DatabaseView
+		var filters, sorts, grouping, display, layout sql.NullString
+		if err := rows.Scan(&view.ID, &view.Name, &view.Type, &filters, &sorts, &grouping, &display, &layout, &view.Version, &view.CreatedAt, &view.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan view: %w", err)
+		}
+		view.DatabaseID = databaseID
+		for _, part := range []struct {
+			raw  sql.NullString
+			dest any
+		}{
+			{filters, &view.Filters},
+			{sorts, &view.Sorts},
+			{grouping, &view.Grouping},
+			{display, &view.Display},
+			{layout, &view.LayoutOptions},
+		} {
+			if part.raw.String == "" {
+				continue
+			}
+			if err := json.Unmarshal([]byte(part.raw.String), part.dest); err != nil {
+				return nil, fmt.Errorf("unmarshal view %s: %w", view.ID, err)
+			}
+		}
+		view.CreatedAt = view.CreatedAt.UTC()
+		view.UpdatedAt = view.UpdatedAt.UTC()
+		views = append(views, view)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate views: %w", err)
+	}
+	return views, nil
+}
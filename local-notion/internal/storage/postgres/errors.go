@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// SQLSTATE codes of the integrity constraint violations classified below.
+const (
+	codeNotNullViolation    = "23502"
+	codeForeignKeyViolation = "23503"
+	codeUniqueViolation     = "23505"
+	codeCheckViolation      = "23514"
+)
+
+func init() {
+	storage.RegisterErrorClassifier(constraintError)
+}
+
+// insertError reports a failed insert of the record labelled by slug,
+// translating a slug uniqueness violation into a slug conflict.
+func insertError(what, slug string, err error) error {
+	if classified := constraintError(err); classified != nil {
+		if classified.Code == storage.ErrSlugConflict.Code {
+			return storage.SlugConflict(slug)
+		}
+		return classified
+	}
+	return fmt.Errorf("insert %s %s: %w", what, slug, err)
+}
+
+// slugConflict returns a slug conflict naming slug when err is a slug
+// uniqueness violation, and nil otherwise.
+func slugConflict(slug string, err error) error {
+	if classified := constraintError(err); classified != nil && classified.Code == storage.ErrSlugConflict.Code {
+		return storage.SlugConflict(slug)
+	}
+	return nil
+}
+
+// constraintError classifies a PostgreSQL integrity violation as a conflict or
+// validation error, and returns nil for any other error.
+func constraintError(err error) *storage.Error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return nil
+	}
+	switch pgErr.Code {
+	case codeUniqueViolation:
+		if strings.HasSuffix(pgErr.ConstraintName, "slug_key") {
+			return storage.ErrSlugConflict
+		}
+		return storage.ConflictError(storage.ErrConflict.Code, "a record with the same key already exists")
+	case codeForeignKeyViolation:
+		return storage.InvalidError("reference_not_found", "a referenced record does not exist")
+	case codeNotNullViolation, codeCheckViolation:
+		return storage.InvalidError(storage.ErrValidation.Code, "a required value is missing or invalid")
+	}
+	return nil
+}
@@ -0,0 +1,501 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// CreateDatabaseItem persists a new item and associated page/values. Item
+// templates are not supported by this backend.
+func (s *Store) CreateDatabaseItem(ctx context.Context, in storage.CreateDatabaseItemInput) (*domain.DatabaseItem, error) {
+	if err := storage.RequireFields("database_id", in.DatabaseID); err != nil {
+		return nil, err
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	if err := lockDatabase(ctx, tx, in.DatabaseID); err != nil {
+		return nil, err
+	}
+	if in.TemplateID != "" {
+		return nil, storage.ErrTemplateNotFound
+	}
+	if strings.TrimSpace(in.Page.Slug) == "" {
+		if in.Page.Slug, err = uniqueSlug(ctx, tx, "pages", storage.SlugOrDefault(in.Page.Title, "item")); err != nil {
+			return nil, err
+		}
+	}
+	ts := now()
+	pageID := uuid.NewString()
+	tagJSON, err := json.Marshal(in.Page.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("marshal page tags: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO pages(id, slug, title, summary, content, parent_page_id, tags, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)`,
+		pageID, in.Page.Slug, in.Page.Title, in.Page.Summary, in.Page.Content, in.Page.ParentPageID, string(tagJSON), ts); err != nil {
+		return nil, insertError("item page", in.Page.Slug, err)
+	}
+	rank, err := nextItemRank(ctx, tx, in.DatabaseID)
+	if err != nil {
+		return nil, err
+	}
+	itemID := uuid.NewString()
+	if _, err := tx.ExecContext(ctx, `INSERT INTO database_items(id, database_id, page_id, position, rank, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $6)`,
+		itemID, in.DatabaseID, pageID, in.Position, rank, ts); err != nil {
+		return nil, fmt.Errorf("insert database item: %w", err)
+	}
+	propMap, err := propertySlugs(ctx, tx, in.DatabaseID)
+	if err != nil {
+		return nil, err
+	}
+	storedValues := make(map[string]domain.DatabaseValue)
+	for slug, value := range in.Values {
+		propID, ok := propMap[slug]
+		if !ok {
+			return nil, storage.InvalidField("values."+slug, "refers to an unknown property")
+		}
+		valueID := uuid.NewString()
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("marshal value %s: %w", slug, err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO database_values(id, database_item_id, property_id, value, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $5)`,
+			valueID, itemID, propID, string(raw), ts); err != nil {
+			return nil, fmt.Errorf("insert value %s: %w", slug, err)
+		}
+		storedValues[slug] = domain.DatabaseValue{
+			ID:         valueID,
+			ItemID:     itemID,
+			PropertyID: propID,
+			RawValue:   value,
+			CreatedAt:  ts,
+			UpdatedAt:  ts,
+		}
+	}
+	item := &domain.DatabaseItem{
+		ID:         itemID,
+		DatabaseID: in.DatabaseID,
+		Page: domain.Page{
+			ID:           pageID,
+			Slug:         in.Page.Slug,
+			Title:        in.Page.Title,
+			Summary:      in.Page.Summary,
+			Content:      in.Page.Content,
+			ParentPageID: in.Page.ParentPageID,
+			Tags:         in.Page.Tags,
+			Version:      1,
+			CreatedAt:    ts,
+			UpdatedAt:    ts,
+		},
+		Position:    in.Position,
+		Rank:        rank,
+		Version:     1,
+		CreatedAt:   ts,
+		UpdatedAt:   ts,
+		PropertyMap: storedValues,
+	}
+	actor := storage.ChangeActor(ctx, in.Page.Author)
+	if err := notifyContentMentions(ctx, tx, pageID, actor, "", in.Page.Content, ts); err != nil {
+		return nil, err
+	}
+	if err := notifyItemChange(ctx, tx, actor, nil, item, ts); err != nil {
+		return nil, err
+	}
+	if err := recordAudit(ctx, tx, storage.AuditActionCreate, storage.AuditEntityItem, itemID, nil, item, ts); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit item: %w", err)
+	}
+	return item, nil
+}
+
+// GetDatabaseItem loads a single item with its page and values.
+func (s *Store) GetDatabaseItem(ctx context.Context, databaseID, itemID string) (*domain.DatabaseItem, error) {
+	item, err := loadDatabaseItem(ctx, s.db, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if item.DatabaseID != databaseID {
+		return nil, storage.ErrItemNotFound
+	}
+	return item, nil
+}
+
+// UpdateDatabaseItem applies a partial update to an item's page and values and
+// records a revision of its page.
+func (s *Store) UpdateDatabaseItem(ctx context.Context, in storage.UpdateDatabaseItemInput) (*domain.DatabaseItem, error) {
+	if err := storage.RequireFields("database_id", in.DatabaseID, "item_id", in.ItemID); err != nil {
+		return nil, err
+	}
+	if in.Title != nil && strings.TrimSpace(*in.Title) == "" {
+		return nil, storage.InvalidField("title", "cannot be empty")
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	pageID, version, err := lockItem(ctx, tx, in.DatabaseID, in.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.CheckVersion(in.ExpectedVersion, version); err != nil {
+		return nil, err
+	}
+	before, err := loadDatabaseItem(ctx, tx, in.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	ts := now()
+	if err := ensurePageBaseline(ctx, tx, pageID, ts); err != nil {
+		return nil, err
+	}
+	sets := []string{"updated_at = $1", "version = version + 1"}
+	args := []any{ts}
+	set := func(column string, value any) {
+		args = append(args, value)
+		sets = append(sets, column+" = $"+strconv.Itoa(len(args)))
+	}
+	if in.Title != nil {
+		set("title", *in.Title)
+	}
+	if in.Summary != nil {
+		set("summary", *in.Summary)
+	}
+	if in.Content != nil {
+		set("content", *in.Content)
+	}
+	if in.Tags != nil {
+		tagJSON, err := json.Marshal(in.Tags)
+		if err != nil {
+			return nil, fmt.Errorf("marshal tags: %w", err)
+		}
+		set("tags", string(tagJSON))
+	}
+	if in.Archived != nil {
+		set("is_archived", *in.Archived)
+	}
+	args = append(args, pageID)
+	if _, err := tx.ExecContext(ctx, `UPDATE pages SET `+strings.Join(sets, ", ")+` WHERE id = $`+strconv.Itoa(len(args)), args...); err != nil {
+		return nil, fmt.Errorf("update item page: %w", err)
+	}
+	if len(in.Values) > 0 {
+		propMap, err := propertySlugs(ctx, tx, in.DatabaseID)
+		if err != nil {
+			return nil, err
+		}
+		for slug, value := range in.Values {
+			propID, ok := propMap[slug]
+			if !ok {
+				return nil, storage.InvalidField("values."+slug, "refers to an unknown property")
+			}
+			if value == nil {
+				if _, err := tx.ExecContext(ctx, `DELETE FROM database_values WHERE database_item_id = $1 AND property_id = $2`, in.ItemID, propID); err != nil {
+					return nil, fmt.Errorf("clear value %s: %w", slug, err)
+				}
+				continue
+			}
+			raw, err := json.Marshal(value)
+			if err != nil {
+				return nil, fmt.Errorf("marshal value %s: %w", slug, err)
+			}
+			if _, err := tx.ExecContext(ctx, `INSERT INTO database_values(id, database_item_id, property_id, value, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $5)
+ON CONFLICT (database_item_id, property_id) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+				uuid.NewString(), in.ItemID, propID, string(raw), ts); err != nil {
+				return nil, fmt.Errorf("upsert value %s: %w", slug, err)
+			}
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE database_items SET is_archived = COALESCE($1, is_archived), version = version + 1, updated_at = $2 WHERE id = $3`, in.Archived, ts, in.ItemID); err != nil {
+		return nil, fmt.Errorf("touch item: %w", err)
+	}
+	if _, err := s.recordPageRevision(ctx, tx, pageID, storage.RevisionActionUpdate, in.Author, nil, ts); err != nil {
+		return nil, err
+	}
+	item, err := loadDatabaseItem(ctx, tx, in.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	actor := storage.ChangeActor(ctx, in.Author)
+	if in.Content != nil {
+		if err := notifyContentMentions(ctx, tx, pageID, actor, before.Page.Content, *in.Content, ts); err != nil {
+			return nil, err
+		}
+	}
+	if err := notifyItemChange(ctx, tx, actor, before, item, ts); err != nil {
+		return nil, err
+	}
+	if err := recordAuditChanges(ctx, tx, storage.ItemUpdateChanges(in, before, item), ts); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit item update: %w", err)
+	}
+	return item, nil
+}
+
+// ArchiveDatabaseItem archives or restores an item and its backing page.
+func (s *Store) ArchiveDatabaseItem(ctx context.Context, databaseID, itemID string, archived bool) (*domain.DatabaseItem, error) {
+	return s.UpdateDatabaseItem(ctx, storage.UpdateDatabaseItemInput{DatabaseID: databaseID, ItemID: itemID, Archived: &archived})
+}
+
+// DeleteDatabaseItem permanently removes an item, its values and its page.
+// Child pages of the item page are detached.
+func (s *Store) DeleteDatabaseItem(ctx context.Context, databaseID, itemID string) error {
+	if err := storage.RequireFields("database_id", databaseID, "item_id", itemID); err != nil {
+		return err
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	pageID, _, err := lockItem(ctx, tx, databaseID, itemID)
+	if err != nil {
+		return err
+	}
+	before, err := loadDatabaseItem(ctx, tx, itemID)
+	if err != nil {
+		return err
+	}
+	statements := []struct {
+		query string
+		label string
+	}{
+		{`DELETE FROM database_items WHERE page_id = $1`, "item"},
+		{`DELETE FROM page_links WHERE source_page_id = $1 OR target_page_id = $1`, "page links"},
+		{`UPDATE pages SET parent_page_id = NULL, version = version + 1 WHERE parent_page_id = $1`, "child pages"},
+		{`DELETE FROM pages WHERE id = $1`, "page"},
+	}
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt.query, pageID); err != nil {
+			return fmt.Errorf("delete item %s: %w", stmt.label, err)
+		}
+	}
+	if err := recordAudit(ctx, tx, storage.AuditActionDelete, storage.AuditEntityItem, itemID, before, nil, now()); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit item delete: %w", err)
+	}
+	return nil
+}
+
+// MoveDatabaseItem reorders an item by giving it a rank between its new
+// neighbours, rebalancing the database when the keys have run out of room.
+func (s *Store) MoveDatabaseItem(ctx context.Context, in storage.MoveDatabaseItemInput) (*domain.DatabaseItem, error) {
+	if (in.BeforeID == "") == (in.AfterID == "") {
+		return nil, storage.ValidationError(
+			storage.FieldError{Field: "before_id", Message: "exactly one of before_id or after_id is required"},
+			storage.FieldError{Field: "after_id", Message: "exactly one of before_id or after_id is required"},
+		)
+	}
+	if in.BeforeID == in.ItemID || in.AfterID == in.ItemID {
+		return nil, storage.InvalidField("item_id", "cannot move an item relative to itself")
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	if err := lockDatabase(ctx, tx, in.DatabaseID); err != nil {
+		if errors.Is(err, storage.ErrDatabaseNotFound) {
+			return nil, storage.ErrItemNotFound
+		}
+		return nil, err
+	}
+	if _, _, err := lockItem(ctx, tx, in.DatabaseID, in.ItemID); err != nil {
+		return nil, err
+	}
+	before, err := loadDatabaseItem(ctx, tx, in.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	var rank string
+	for attempt := 0; attempt < 2; attempt++ {
+		lower, upper, err := moveBounds(ctx, tx, in)
+		if err != nil {
+			return nil, err
+		}
+		rank, err = storage.RankBetween(lower, upper)
+		if err == nil && len(rank) <= storage.MaxRankLength {
+			break
+		}
+		if attempt == 1 {
+			return nil, fmt.Errorf("compute item rank: %w", storage.ErrRankOrder)
+		}
+		if err := rebalanceItemRanks(ctx, tx, in.DatabaseID); err != nil {
+			return nil, err
+		}
+	}
+	ts := now()
+	if _, err := tx.ExecContext(ctx, `UPDATE database_items SET rank = $1, version = version + 1, updated_at = $2 WHERE id = $3`, rank, ts, in.ItemID); err != nil {
+		return nil, fmt.Errorf("move item: %w", err)
+	}
+	item, err := loadDatabaseItem(ctx, tx, in.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordAudit(ctx, tx, storage.AuditActionMove, storage.AuditEntityItem, in.ItemID, storage.ItemWithoutValues(before), storage.ItemWithoutValues(item), ts); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit item move: %w", err)
+	}
+	return item, nil
+}
+
+// lockDatabase takes a row lock on a database, serialising the rank
+// assignments of concurrent creates and moves within it.
+func lockDatabase(ctx context.Context, q queryer, databaseID string) error {
+	var id string
+	err := q.QueryRowContext(ctx, `SELECT id FROM databases WHERE id = $1 FOR UPDATE`, databaseID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.ErrDatabaseNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("lock database: %w", err)
+	}
+	return nil
+}
+
+// lockItem takes a row lock on an item and returns its page and version.
+func lockItem(ctx context.Context, q queryer, databaseID, itemID string) (string, int, error) {
+	var pageID string
+	var version int
+	err := q.QueryRowContext(ctx, `SELECT page_id, version FROM database_items WHERE id = $1 AND database_id = $2 FOR UPDATE`, itemID, databaseID).Scan(&pageID, &version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", 0, storage.ErrItemNotFound
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("lock item: %w", err)
+	}
+	return pageID, version, nil
+}
+
+// moveBounds returns the ranks the moved item must fall between, ignoring the
+// moved item itself.
+func moveBounds(ctx context.Context, q queryer, in storage.MoveDatabaseItemInput) (string, string, error) {
+	anchorID := in.AfterID
+	if in.BeforeID != "" {
+		anchorID = in.BeforeID
+	}
+	var anchor string
+	err := q.QueryRowContext(ctx, `SELECT rank FROM database_items WHERE id = $1 AND database_id = $2`, anchorID, in.DatabaseID).Scan(&anchor)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", storage.ErrItemNotFound
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("load anchor item: %w", err)
+	}
+	var neighbour sql.NullString
+	if in.AfterID != "" {
+		err = q.QueryRowContext(ctx, `SELECT MIN(rank) FROM database_items WHERE database_id = $1 AND id != $2 AND rank > $3`, in.DatabaseID, in.ItemID, anchor).Scan(&neighbour)
+		if err != nil {
+			return "", "", fmt.Errorf("load next item: %w", err)
+		}
+		return anchor, neighbour.String, nil
+	}
+	err = q.QueryRowContext(ctx, `SELECT MAX(rank) FROM database_items WHERE database_id = $1 AND id != $2 AND rank < $3`, in.DatabaseID, in.ItemID, anchor).Scan(&neighbour)
+	if err != nil {
+		return "", "", fmt.Errorf("load previous item: %w", err)
+	}
+	return neighbour.String, anchor, nil
+}
+
+// nextItemRank returns a rank that sorts after every item of the database.
+func nextItemRank(ctx context.Context, q queryer, databaseID string) (string, error) {
+	var last sql.NullString
+	if err := q.QueryRowContext(ctx, `SELECT MAX(rank) FROM database_items WHERE database_id = $1`, databaseID).Scan(&last); err != nil {
+		return "", fmt.Errorf("load last item rank: %w", err)
+	}
+	rank := storage.RankAfter(last.String)
+	if len(rank) <= storage.MaxRankLength {
+		return rank, nil
+	}
+	if err := rebalanceItemRanks(ctx, q, databaseID); err != nil {
+		return "", err
+	}
+	if err := q.QueryRowContext(ctx, `SELECT MAX(rank) FROM database_items WHERE database_id = $1`, databaseID).Scan(&last); err != nil {
+		return "", fmt.Errorf("load last item rank: %w", err)
+	}
+	return storage.RankAfter(last.String), nil
+}
+
+// rebalanceItemRanks rewrites every rank of a database with evenly spaced keys
+// while preserving the current order. Respacing is not an edit of the items,
+// so their version and updated_at are left alone.
+func rebalanceItemRanks(ctx context.Context, q queryer, databaseID string) error {
+	ids, err := queryStrings(ctx, q, `SELECT id FROM database_items WHERE database_id = $1 ORDER BY rank ASC, position ASC, created_at ASC, id ASC`, databaseID)
+	if err != nil {
+		return fmt.Errorf("query item order: %w", err)
+	}
+	for idx, key := range storage.RankSequence(len(ids)) {
+		if _, err := q.ExecContext(ctx, `UPDATE database_items SET rank = $1 WHERE id = $2`, key, ids[idx]); err != nil {
+			return fmt.Errorf("rebalance item rank: %w", err)
+		}
+	}
+	return nil
+}
+
+// loadDatabaseItem loads an item with its page and values keyed by property slug.
+func loadDatabaseItem(ctx context.Context, q queryer, itemID string) (*domain.DatabaseItem, error) {
+	var item domain.DatabaseItem
+	var pageID string
+	err := q.QueryRowContext(ctx, `SELECT id, database_id, page_id, position, rank, is_archived, version, created_at, updated_at FROM database_items WHERE id = $1`, itemID).
+		Scan(&item.ID, &item.DatabaseID, &pageID, &item.Position, &item.Rank, &item.IsArchived, &item.Version, &item.CreatedAt, &item.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrItemNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load item: %w", err)
+	}
+	item.CreatedAt = item.CreatedAt.UTC()
+	item.UpdatedAt = item.UpdatedAt.UTC()
+	page, err := loadPage(ctx, q, pageID)
+	if err != nil {
+		return nil, err
+	}
+	item.Page = *page
+	rows, err := q.QueryContext(ctx, `SELECT dv.id, dv.property_id, dp.slug, dv.value, dv.is_computed, dv.created_at, dv.updated_at FROM database_values dv JOIN database_properties dp ON dp.id = dv.property_id WHERE dv.database_item_id = $1`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("query item values: %w", err)
+	}
+	defer rows.Close()
+	item.PropertyMap = make(map[string]domain.DatabaseValue)
+	for rows.Next() {
+		var value domain.DatabaseValue
+		var slug string
+		var raw sql.NullString
+		if err := rows.Scan(&value.ID, &value.PropertyID, &slug, &raw, &value.IsComputed, &value.CreatedAt, &value.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan value: %w", err)
+		}
+		if raw.String != "" {
+			var parsed any
+			if err := json.Unmarshal([]byte(raw.String), &parsed); err == nil {
+				value.RawValue = parsed
+			}
+		}
+		value.ItemID = itemID
+		value.CreatedAt = value.CreatedAt.UTC()
+		value.UpdatedAt = value.UpdatedAt.UTC()
+		item.PropertyMap[slug] = value
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate item values: %w", err)
+	}
+	return &item, nil
+}
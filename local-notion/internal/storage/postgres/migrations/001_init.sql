@@ -0,0 +1,103 @@
+CREATE TABLE IF NOT EXISTS pages (
+    id TEXT PRIMARY KEY,
+    slug TEXT UNIQUE NOT NULL,
+    title TEXT NOT NULL,
+    summary TEXT,
+    content TEXT,
+    parent_page_id TEXT REFERENCES pages(id) ON DELETE SET NULL,
+    cover_image_id TEXT,
+    icon TEXT,
+    tags JSONB,
+    is_archived BOOLEAN NOT NULL DEFAULT FALSE,
+    is_template BOOLEAN NOT NULL DEFAULT FALSE,
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS assets (
+    id TEXT PRIMARY KEY,
+    filename TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    storage_path TEXT NOT NULL,
+    thumbnail_path TEXT,
+    created_at TIMESTAMPTZ NOT NULL,
+    uploaded_by TEXT
+);
+
+CREATE TABLE IF NOT EXISTS databases (
+    id TEXT PRIMARY KEY,
+    slug TEXT UNIQUE NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT,
+    icon TEXT,
+    cover_image_id TEXT,
+    is_archived BOOLEAN NOT NULL DEFAULT FALSE,
+    is_template BOOLEAN NOT NULL DEFAULT FALSE,
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS database_properties (
+    id TEXT PRIMARY KEY,
+    database_id TEXT NOT NULL REFERENCES databases(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    slug TEXT NOT NULL,
+    type TEXT NOT NULL,
+    config JSONB,
+    is_required BOOLEAN NOT NULL DEFAULT FALSE,
+    default_value JSONB,
+    order_index INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    CONSTRAINT database_properties_slug_key UNIQUE (database_id, slug)
+);
+
+CREATE TABLE IF NOT EXISTS database_views (
+    id TEXT PRIMARY KEY,
+    database_id TEXT NOT NULL REFERENCES databases(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    type TEXT NOT NULL,
+    filters JSONB,
+    sorts JSONB,
+    grouping JSONB,
+    display_properties JSONB,
+    layout_options JSONB,
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+-- Ranks are base-62 keys compared byte by byte, so they must not follow the
+-- database's linguistic collation.
+CREATE TABLE IF NOT EXISTS database_items (
+    id TEXT PRIMARY KEY,
+    database_id TEXT NOT NULL REFERENCES databases(id) ON DELETE CASCADE,
+    page_id TEXT NOT NULL REFERENCES pages(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL DEFAULT 0,
+    rank TEXT COLLATE "C" NOT NULL DEFAULT '',
+    is_archived BOOLEAN NOT NULL DEFAULT FALSE,
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS database_values (
+    id TEXT PRIMARY KEY,
+    database_item_id TEXT NOT NULL REFERENCES database_items(id) ON DELETE CASCADE,
+    property_id TEXT NOT NULL REFERENCES database_properties(id) ON DELETE CASCADE,
+    value JSONB,
+    is_computed BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (database_item_id, property_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pages_parent ON pages(parent_page_id);
+CREATE INDEX IF NOT EXISTS idx_pages_template ON pages(is_template);
+CREATE INDEX IF NOT EXISTS idx_database_items_database ON database_items(database_id);
+CREATE INDEX IF NOT EXISTS idx_database_items_rank ON database_items(database_id, rank);
+CREATE INDEX IF NOT EXISTS idx_database_items_page ON database_items(page_id);
+CREATE INDEX IF NOT EXISTS idx_database_values_property ON database_values(property_id);
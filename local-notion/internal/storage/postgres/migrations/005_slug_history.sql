@@ -0,0 +1,52 @@
+CREATE TABLE IF NOT EXISTS page_slug_history (
+    slug TEXT PRIMARY KEY,
+    page_id TEXT NOT NULL REFERENCES pages(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_page_slug_history_page ON page_slug_history(page_id);
+
+CREATE TABLE IF NOT EXISTS database_slug_history (
+    slug TEXT PRIMARY KEY,
+    database_id TEXT NOT NULL REFERENCES databases(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_database_slug_history_database ON database_slug_history(database_id);
+
+-- A slug that is given up keeps pointing at its record until another record
+-- takes it; a record's current slug always wins over the history.
+
+CREATE OR REPLACE FUNCTION page_slug_history_track() RETURNS trigger LANGUAGE plpgsql AS $$
+BEGIN
+    DELETE FROM page_slug_history WHERE slug = NEW.slug;
+    IF TG_OP = 'UPDATE' THEN
+        INSERT INTO page_slug_history(slug, page_id, created_at) VALUES (OLD.slug, NEW.id, NEW.updated_at)
+            ON CONFLICT (slug) DO UPDATE SET page_id = excluded.page_id, created_at = excluded.created_at;
+    END IF;
+    RETURN NULL;
+END;
+$$;
+
+CREATE TRIGGER pages_slug_insert AFTER INSERT ON pages
+    FOR EACH ROW EXECUTE FUNCTION page_slug_history_track();
+
+CREATE TRIGGER pages_slug_update AFTER UPDATE OF slug ON pages
+    FOR EACH ROW WHEN (OLD.slug IS DISTINCT FROM NEW.slug) EXECUTE FUNCTION page_slug_history_track();
+
+CREATE OR REPLACE FUNCTION database_slug_history_track() RETURNS trigger LANGUAGE plpgsql AS $$
+BEGIN
+    DELETE FROM database_slug_history WHERE slug = NEW.slug;
+    IF TG_OP = 'UPDATE' THEN
+        INSERT INTO database_slug_history(slug, database_id, created_at) VALUES (OLD.slug, NEW.id, NEW.updated_at)
+            ON CONFLICT (slug) DO UPDATE SET database_id = excluded.database_id, created_at = excluded.created_at;
+    END IF;
+    RETURN NULL;
+END;
+$$;
+
+CREATE TRIGGER databases_slug_insert AFTER INSERT ON databases
+    FOR EACH ROW EXECUTE FUNCTION database_slug_history_track();
+
+CREATE TRIGGER databases_slug_update AFTER UPDATE OF slug ON databases
+    FOR EACH ROW WHEN (OLD.slug IS DISTINCT FROM NEW.slug) EXECUTE FUNCTION database_slug_history_track();
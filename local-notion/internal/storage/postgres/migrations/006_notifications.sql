@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS notifications (
+    id TEXT PRIMARY KEY,
+    seq BIGINT GENERATED ALWAYS AS IDENTITY,
+    recipient TEXT NOT NULL,
+    source TEXT NOT NULL,
+    actor TEXT NOT NULL DEFAULT '',
+    page_id TEXT,
+    thread_id TEXT,
+    comment_id TEXT,
+    database_id TEXT,
+    item_id TEXT,
+    excerpt TEXT NOT NULL DEFAULT '',
+    read_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_recipient ON notifications(recipient, created_at);
+CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications(recipient) WHERE read_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS notification_mutes (
+    recipient TEXT NOT NULL,
+    source TEXT NOT NULL,
+    PRIMARY KEY (recipient, source)
+);
+
+-- Notifications about a page go with it.
+
+CREATE OR REPLACE FUNCTION pages_notifications_delete() RETURNS trigger LANGUAGE plpgsql AS $$
+BEGIN
+    DELETE FROM notifications WHERE page_id = OLD.id;
+    RETURN NULL;
+END;
+$$;
+
+CREATE TRIGGER pages_notifications_delete AFTER DELETE ON pages
+    FOR EACH ROW EXECUTE FUNCTION pages_notifications_delete();
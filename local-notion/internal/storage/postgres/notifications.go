@@ -0,0 +1,241 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// Notifications are written in the transaction of the change that causes
+// them, for every recipient except the user making the change, and skipped
+// when the recipient muted their source.
+
+const notificationColumns = `id, recipient, source, actor, page_id, thread_id, comment_id, database_id, item_id, excerpt, read_at, created_at`
+
+// ListNotifications returns a user's notifications, newest first, together
+// with the number of unread ones.
+func (s *Store) ListNotifications(ctx context.Context, filter storage.NotificationFilter) ([]domain.Notification, int, error) {
+	if err := storage.RequireFields("recipient", filter.Recipient); err != nil {
+		return nil, 0, err
+	}
+	where := `recipient = $1`
+	if filter.UnreadOnly {
+		where += ` AND read_at IS NULL`
+	}
+	notifications, err := queryNotifications(ctx, s.db, `SELECT `+notificationColumns+` FROM notifications WHERE `+where+` ORDER BY created_at DESC, seq DESC LIMIT $2`,
+		filter.Recipient, storage.NotificationLimit(filter))
+	if err != nil {
+		return nil, 0, err
+	}
+	var unread int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM notifications WHERE recipient = $1 AND read_at IS NULL`, filter.Recipient).Scan(&unread); err != nil {
+		return nil, 0, fmt.Errorf("count unread notifications: %w", err)
+	}
+	return notifications, unread, nil
+}
+
+// MarkNotificationRead marks one of a user's notifications read. Marking a
+// read notification again keeps its original read time.
+func (s *Store) MarkNotificationRead(ctx context.Context, recipient, id string) (*domain.Notification, error) {
+	if err := storage.RequireFields("recipient", recipient); err != nil {
+		return nil, err
+	}
+	notifications, err := queryNotifications(ctx, s.db, `UPDATE notifications SET read_at = COALESCE(read_at, $1) WHERE id = $2 AND recipient = $3 RETURNING `+notificationColumns,
+		now(), id, recipient)
+	if err != nil {
+		return nil, err
+	}
+	if len(notifications) == 0 {
+		return nil, storage.ErrNotificationNotFound
+	}
+	return &notifications[0], nil
+}
+
+// MarkAllNotificationsRead marks every unread notification of a user read and
+// returns how many there were.
+func (s *Store) MarkAllNotificationsRead(ctx context.Context, recipient string) (int, error) {
+	if err := storage.RequireFields("recipient", recipient); err != nil {
+		return 0, err
+	}
+	res, err := s.db.ExecContext(ctx, `UPDATE notifications SET read_at = $1 WHERE recipient = $2 AND read_at IS NULL`, now(), recipient)
+	if err != nil {
+		return 0, fmt.Errorf("mark notifications read: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// GetNotificationPreferences returns the sources a user has muted.
+func (s *Store) GetNotificationPreferences(ctx context.Context, recipient string) (*domain.NotificationPreferences, error) {
+	if err := storage.RequireFields("recipient", recipient); err != nil {
+		return nil, err
+	}
+	return loadNotificationPreferences(ctx, s.db, recipient)
+}
+
+// SetNotificationPreferences replaces the sources a user has muted. Muting
+// only affects notifications created afterwards.
+func (s *Store) SetNotificationPreferences(ctx context.Context, prefs domain.NotificationPreferences) (*domain.NotificationPreferences, error) {
+	if err := storage.CheckMutedSources(prefs); err != nil {
+		return nil, err
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.ExecContext(ctx, `DELETE FROM notification_mutes WHERE recipient = $1`, prefs.Recipient); err != nil {
+		return nil, fmt.Errorf("clear notification mutes: %w", err)
+	}
+	for _, source := range prefs.Muted {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO notification_mutes(recipient, source) VALUES ($1, $2) ON CONFLICT DO NOTHING`, prefs.Recipient, source); err != nil {
+			return nil, fmt.Errorf("insert notification mute: %w", err)
+		}
+	}
+	saved, err := loadNotificationPreferences(ctx, tx, prefs.Recipient)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit notification preferences: %w", err)
+	}
+	return saved, nil
+}
+
+// SubscribeNotifications streams the notifications created for recipient
+// from now on, in commit order. The channel is closed by cancel, or when the
+// subscriber falls too far behind; cancel must be called either way.
+func (s *Store) SubscribeNotifications(recipient string) (<-chan domain.Notification, func()) {
+	return s.notifications.Subscribe(recipient)
+}
+
+func loadNotificationPreferences(ctx context.Context, q queryer, recipient string) (*domain.NotificationPreferences, error) {
+	muted, err := queryStrings(ctx, q, `SELECT source FROM notification_mutes WHERE recipient = $1 ORDER BY source`, recipient)
+	if err != nil {
+		return nil, fmt.Errorf("query notification mutes: %w", err)
+	}
+	if muted == nil {
+		muted = []string{}
+	}
+	return &domain.NotificationPreferences{Recipient: recipient, Muted: muted}, nil
+}
+
+func queryNotifications(ctx context.Context, q queryer, query string, args ...any) ([]domain.Notification, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query notifications: %w", err)
+	}
+	defer rows.Close()
+	notifications := []domain.Notification{}
+	for rows.Next() {
+		var n domain.Notification
+		var pageID, threadID, commentID, databaseID, itemID sql.NullString
+		var readAt sql.NullTime
+		if err := rows.Scan(&n.ID, &n.Recipient, &n.Source, &n.Actor, &pageID, &threadID, &commentID, &databaseID, &itemID, &n.Excerpt, &readAt, &n.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan notification: %w", err)
+		}
+		n.PageID, n.ThreadID, n.CommentID = pageID.String, threadID.String, commentID.String
+		n.DatabaseID, n.ItemID = databaseID.String, itemID.String
+		if readAt.Valid {
+			readAt.Time = readAt.Time.UTC()
+			n.ReadAt = &readAt.Time
+		}
+		n.CreatedAt = n.CreatedAt.UTC()
+		notifications = append(notifications, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate notifications: %w", err)
+	}
+	return notifications, nil
+}
+
+// notify records a notification for each recipient other than the actor who
+// has not muted its source. The recipient and id fields of n are filled in.
+// q must be a write transaction, which pushes the notifications to
+// subscribers once it commits.
+func notify(ctx context.Context, q queryer, n domain.Notification, recipients []string) error {
+	tx, ok := q.(*writeTx)
+	if !ok {
+		return errors.New("notify outside a write transaction")
+	}
+	n.Excerpt = storage.NotificationExcerpt(n.Excerpt)
+	for _, recipient := range recipients {
+		if recipient == "" || recipient == n.Actor {
+			continue
+		}
+		n.ID, n.Recipient = uuid.NewString(), recipient
+		res, err := tx.ExecContext(ctx, `INSERT INTO notifications(id, recipient, source, actor, page_id, thread_id, comment_id, database_id, item_id, excerpt, created_at)
+SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+WHERE NOT EXISTS (SELECT 1 FROM notification_mutes WHERE recipient = $2 AND source = $3)`,
+			n.ID, recipient, n.Source, n.Actor, nullString(n.PageID), nullString(n.ThreadID), nullString(n.CommentID),
+			nullString(n.DatabaseID), nullString(n.ItemID), n.Excerpt, n.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("insert notification: %w", err)
+		}
+		inserted, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("insert notification: %w", err)
+		}
+		if inserted == 1 {
+			tx.notified = append(tx.notified, n)
+		}
+	}
+	return nil
+}
+
+// notifyContentMentions notifies the users mentioned in a page's content who
+// were not already mentioned before the change.
+func notifyContentMentions(ctx context.Context, q queryer, pageID, actor, oldContent, newContent string, ts time.Time) error {
+	for _, name := range storage.NewMentions(oldContent, newContent) {
+		if err := notify(ctx, q, domain.Notification{
+			Source: storage.NotificationSourceMention, Actor: actor, PageID: pageID, Excerpt: storage.MentionContext(newContent, name), CreatedAt: ts,
+		}, []string{name}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notifyItemChange notifies the users in the person properties of an item:
+// those added by the change are assigned, the others are told about the
+// update. A nil before means the item was created.
+func notifyItemChange(ctx context.Context, q queryer, actor string, before, after *domain.DatabaseItem, ts time.Time) error {
+	personIDs, err := queryStrings(ctx, q, `SELECT id FROM database_properties WHERE database_id = $1 AND type = $2`, after.DatabaseID, string(domain.PropertyTypePerson))
+	if err != nil {
+		return fmt.Errorf("select person properties: %w", err)
+	}
+	if len(personIDs) == 0 {
+		return nil
+	}
+	isPerson := func(propertyID string) bool { return slices.Contains(personIDs, propertyID) }
+	var previous []string
+	if before != nil {
+		previous = storage.ItemPeople(before, isPerson)
+	}
+	assigned, watching := storage.SplitItemPeople(previous, storage.ItemPeople(after, isPerson))
+	n := domain.Notification{
+		Actor: actor, PageID: after.Page.ID, DatabaseID: after.DatabaseID, ItemID: after.ID,
+		Excerpt: after.Page.Title, CreatedAt: ts,
+	}
+	n.Source = storage.NotificationSourceAssignment
+	if err := notify(ctx, q, n, assigned); err != nil {
+		return err
+	}
+	n.Source = storage.NotificationSourceItemUpdate
+	return notify(ctx, q, n, watching)
+}
+
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
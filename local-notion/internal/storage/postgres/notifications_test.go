@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+func inbox(t *testing.T, store *Store, recipient string) []domain.Notification {
+	t.Helper()
+	notifications, _, err := store.ListNotifications(context.Background(), storage.NotificationFilter{Recipient: recipient})
+	require.NoError(t, err)
+	return notifications
+}
+
+func TestNotificationsFromMentionsAndAssignments(t *testing.T) {
+	store := newTestStore(t)
+	ctx := storage.WithAuditInfo(context.Background(), storage.AuditInfo{Actor: "ana"})
+	stream, cancel := store.SubscribeNotifications("bo")
+	defer cancel()
+
+	page, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "plan", Title: "Plan", Content: "@bo owns this, @ana reviews."})
+	require.NoError(t, err)
+	require.Empty(t, inbox(t, store, "ana"))
+	mentions := inbox(t, store, "bo")
+	require.Len(t, mentions, 1)
+	require.Equal(t, storage.NotificationSourceMention, mentions[0].Source)
+	require.Equal(t, page.ID, mentions[0].PageID)
+	require.Equal(t, mentions[0], <-stream)
+
+	// Only mentions added by an edit notify.
+	content := "@bo owns this, @carl reviews."
+	_, err = store.UpdatePage(ctx, storage.UpdatePageInput{PageID: page.ID, Content: &content})
+	require.NoError(t, err)
+	require.Len(t, inbox(t, store, "bo"), 1)
+	require.Len(t, inbox(t, store, "carl"), 1)
+
+	db, err := store.CreateDatabase(ctx, storage.CreateDatabaseInput{
+		Slug: "tasks", Title: "Tasks",
+		Properties: []storage.DatabasePropertyInput{{Slug: "owner", Name: "Owner", Type: domain.PropertyTypePerson}},
+	})
+	require.NoError(t, err)
+	item, err := store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
+		DatabaseID: db.ID,
+		Page:       storage.CreatePageInput{Title: "Write docs"},
+		Values:     map[string]any{"owner": []any{"ana", "bo"}},
+	})
+	require.NoError(t, err)
+	assigned := inbox(t, store, "bo")[0]
+	require.Equal(t, storage.NotificationSourceAssignment, assigned.Source)
+	require.Equal(t, item.ID, assigned.ItemID)
+
+	_, err = store.UpdateDatabaseItem(ctx, storage.UpdateDatabaseItemInput{
+		DatabaseID: db.ID, ItemID: item.ID, Values: map[string]any{"owner": []any{"bo", "carl"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, storage.NotificationSourceItemUpdate, inbox(t, store, "bo")[0].Source)
+	require.Equal(t, storage.NotificationSourceAssignment, inbox(t, store, "carl")[0].Source)
+}
+
+func TestNotificationReadStateAndMutes(t *testing.T) {
+	store := newTestStore(t)
+	ctx := storage.WithAuditInfo(context.Background(), storage.AuditInfo{Actor: "ana"})
+	for _, title := range []string{"One", "Two"} {
+		_, err := store.CreatePage(ctx, storage.CreatePageInput{Title: title, Content: "@bo"})
+		require.NoError(t, err)
+	}
+
+	notifications, unread, err := store.ListNotifications(ctx, storage.NotificationFilter{Recipient: "bo"})
+	require.NoError(t, err)
+	require.Equal(t, 2, unread)
+	read, err := store.MarkNotificationRead(ctx, "bo", notifications[0].ID)
+	require.NoError(t, err)
+	require.NotNil(t, read.ReadAt)
+	_, err = store.MarkNotificationRead(ctx, "carl", notifications[1].ID)
+	require.ErrorIs(t, err, storage.ErrNotificationNotFound)
+	marked, err := store.MarkAllNotificationsRead(ctx, "bo")
+	require.NoError(t, err)
+	require.Equal(t, 1, marked)
+
+	prefs, err := store.SetNotificationPreferences(ctx, domain.NotificationPreferences{Recipient: "bo", Muted: []string{storage.NotificationSourceMention}})
+	require.NoError(t, err)
+	require.Equal(t, []string{storage.NotificationSourceMention}, prefs.Muted)
+	_, err = store.CreatePage(ctx, storage.CreatePageInput{Title: "Three", Content: "@bo"})
+	require.NoError(t, err)
+	require.Len(t, inbox(t, store, "bo"), 2)
+
+	_, err = store.SetNotificationPreferences(ctx, domain.NotificationPreferences{Recipient: "bo", Muted: []string{"everything"}})
+	require.ErrorIs(t, err, storage.ErrValidation)
+}
@@ -0,0 +1,343 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+const pageColumns = `id, slug, title, summary, content, parent_page_id, cover_image_id, icon, tags, is_archived, is_template, version, created_at, updated_at`
+
+// CreatePage persists a new page. A page without a slug gets a unique one
+// generated from its title.
+func (s *Store) CreatePage(ctx context.Context, in storage.CreatePageInput) (*domain.Page, error) {
+	if err := storage.RequireFields("title", in.Title); err != nil {
+		return nil, err
+	}
+	tagJSON, err := json.Marshal(in.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("marshal tags: %w", err)
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	if strings.TrimSpace(in.Slug) == "" {
+		if in.Slug, err = uniqueSlug(ctx, tx, "pages", storage.SlugOrDefault(in.Title, "page")); err != nil {
+			return nil, err
+		}
+	}
+	ts := now()
+	id := uuid.NewString()
+	if _, err := tx.ExecContext(ctx, `INSERT INTO pages(id, slug, title, summary, content, parent_page_id, tags, is_template, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)`,
+		id, in.Slug, in.Title, in.Summary, in.Content, in.ParentPageID, string(tagJSON), in.IsTemplate, ts); err != nil {
+		return nil, insertError("page", in.Slug, err)
+	}
+	links := storage.UniquePageLinks(in.LinkedPageIDs, id)
+	if err := insertPageLinks(ctx, tx, id, links, ts); err != nil {
+		return nil, err
+	}
+	if err := notifyContentMentions(ctx, tx, id, storage.ChangeActor(ctx, in.Author), "", in.Content, ts); err != nil {
+		return nil, err
+	}
+	if _, err := s.recordPageRevision(ctx, tx, id, storage.RevisionActionCreate, in.Author, nil, ts); err != nil {
+		return nil, err
+	}
+	if err := auditPageChange(ctx, tx, storage.AuditActionCreate, id, nil, ts); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit page: %w", err)
+	}
+	return &domain.Page{
+		ID:            id,
+		Slug:          in.Slug,
+		Title:         in.Title,
+		Summary:       in.Summary,
+		Content:       in.Content,
+		ParentPageID:  in.ParentPageID,
+		Tags:          in.Tags,
+		LinkedPageIDs: links,
+		IsTemplate:    in.IsTemplate,
+		Version:       1,
+		CreatedAt:     ts,
+		UpdatedAt:     ts,
+	}, nil
+}
+
+// GetPage retrieves a page by id. It returns storage.ErrPageNotFound when the
+// page does not exist.
+func (s *Store) GetPage(ctx context.Context, id string) (*domain.Page, error) {
+	page, err := loadPage(ctx, s.db, id)
+	if err != nil {
+		return nil, err
+	}
+	links, err := loadPageLinks(ctx, s.db, id)
+	if err != nil {
+		return nil, err
+	}
+	page.LinkedPageIDs = links.Outbound
+	page.BacklinkedPageIDs = links.Inbound
+	return page, nil
+}
+
+// ListPages returns a lightweight listing of stored pages. Template pages are
+// excluded.
+func (s *Store) ListPages(ctx context.Context) ([]domain.Page, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, slug, title, summary, parent_page_id FROM pages WHERE NOT is_template ORDER BY title, id`)
+	if err != nil {
+		return nil, fmt.Errorf("select pages: %w", err)
+	}
+	defer rows.Close()
+	var pages []domain.Page
+	for rows.Next() {
+		var page domain.Page
+		var summary, parent sql.NullString
+		if err := rows.Scan(&page.ID, &page.Slug, &page.Title, &summary, &parent); err != nil {
+			return nil, fmt.Errorf("scan page row: %w", err)
+		}
+		page.Summary = summary.String
+		if parent.Valid {
+			page.ParentPageID = &parent.String
+		}
+		pages = append(pages, page)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate pages: %w", err)
+	}
+	return pages, nil
+}
+
+// UpdatePage applies a partial update to a page and records a revision of
+// the result. Updating the page of a database item also bumps the item's
+// version.
+func (s *Store) UpdatePage(ctx context.Context, in storage.UpdatePageInput) (*domain.Page, error) {
+	if in.Title != nil && strings.TrimSpace(*in.Title) == "" {
+		return nil, storage.InvalidField("title", "cannot be empty")
+	}
+	if in.Slug != nil && strings.TrimSpace(*in.Slug) == "" {
+		return nil, storage.InvalidField("slug", "cannot be empty")
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	version, err := lockPage(ctx, tx, in.PageID)
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.CheckVersion(in.ExpectedVersion, version); err != nil {
+		return nil, err
+	}
+	before, err := loadAuditPage(ctx, tx, in.PageID)
+	if err != nil {
+		return nil, err
+	}
+	ts := now()
+	if err := ensurePageBaseline(ctx, tx, in.PageID, ts); err != nil {
+		return nil, err
+	}
+	if err := updatePageFields(ctx, tx, in, ts); err != nil {
+		return nil, err
+	}
+	if _, err := s.recordPageRevision(ctx, tx, in.PageID, storage.RevisionActionUpdate, in.Author, nil, ts); err != nil {
+		return nil, err
+	}
+	if err := auditPageChange(ctx, tx, storage.AuditActionUpdate, in.PageID, before, ts); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit page update: %w", err)
+	}
+	return s.GetPage(ctx, in.PageID)
+}
+
+// lockPage takes a row lock on a page and returns its version.
+func lockPage(ctx context.Context, q queryer, pageID string) (int, error) {
+	var version int
+	err := q.QueryRowContext(ctx, `SELECT version FROM pages WHERE id = $1 FOR UPDATE`, pageID).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, storage.ErrPageNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("lock page: %w", err)
+	}
+	return version, nil
+}
+
+// updatePageFields writes the non-nil fields of in to a locked page and
+// notifies the users newly mentioned in its content.
+func updatePageFields(ctx context.Context, q queryer, in storage.UpdatePageInput, ts time.Time) error {
+	var oldContent sql.NullString
+	if in.Content != nil {
+		if err := q.QueryRowContext(ctx, `SELECT content FROM pages WHERE id = $1`, in.PageID).Scan(&oldContent); err != nil {
+			return fmt.Errorf("load page content: %w", err)
+		}
+	}
+	sets := []string{"updated_at = $1", "version = version + 1"}
+	args := []any{ts}
+	set := func(column string, value any) {
+		args = append(args, value)
+		sets = append(sets, column+" = $"+strconv.Itoa(len(args)))
+	}
+	if in.Slug != nil {
+		set("slug", *in.Slug)
+	}
+	if in.Title != nil {
+		set("title", *in.Title)
+	}
+	if in.Summary != nil {
+		set("summary", *in.Summary)
+	}
+	if in.Content != nil {
+		set("content", *in.Content)
+	}
+	if in.Tags != nil {
+		tagJSON, err := json.Marshal(in.Tags)
+		if err != nil {
+			return fmt.Errorf("marshal tags: %w", err)
+		}
+		set("tags", string(tagJSON))
+	}
+	args = append(args, in.PageID)
+	if _, err := q.ExecContext(ctx, `UPDATE pages SET `+strings.Join(sets, ", ")+` WHERE id = $`+strconv.Itoa(len(args)), args...); err != nil {
+		if in.Slug != nil {
+			if conflict := slugConflict(*in.Slug, err); conflict != nil {
+				return conflict
+			}
+		}
+		return fmt.Errorf("update page: %w", err)
+	}
+	if in.Content != nil {
+		if err := notifyContentMentions(ctx, q, in.PageID, storage.ChangeActor(ctx, in.Author), oldContent.String, *in.Content, ts); err != nil {
+			return err
+		}
+	}
+	// An item is represented with its page, so it changes with it.
+	if _, err := q.ExecContext(ctx, `UPDATE database_items SET version = version + 1, updated_at = $1 WHERE page_id = $2`, ts, in.PageID); err != nil {
+		return fmt.Errorf("touch page item: %w", err)
+	}
+	if in.LinkedPageIDs != nil {
+		if _, err := q.ExecContext(ctx, `DELETE FROM page_links WHERE source_page_id = $1`, in.PageID); err != nil {
+			return fmt.Errorf("clear page links: %w", err)
+		}
+		if err := insertPageLinks(ctx, q, in.PageID, storage.UniquePageLinks(in.LinkedPageIDs, in.PageID), ts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListPageLinks returns the pages a page links to and the pages linking to it.
+func (s *Store) ListPageLinks(ctx context.Context, pageID string) (*domain.PageLinks, error) {
+	if _, err := loadPage(ctx, s.db, pageID); err != nil {
+		return nil, err
+	}
+	return loadPageLinks(ctx, s.db, pageID)
+}
+
+// uniqueSlug returns base when it is unused in table, otherwise the first free
+// "<base>-<n>" variant. Slugs a record used to have count as used, so old
+// links keep resolving to it. table must be a key of slugHistoryTables.
+func uniqueSlug(ctx context.Context, q queryer, table, base string) (string, error) {
+	return storage.UniqueSlug(base, func(candidate string) (bool, error) {
+		var exists int
+		err := q.QueryRowContext(ctx, `SELECT 1 FROM `+table+` WHERE slug = $1 UNION ALL SELECT 1 FROM `+slugHistoryTables[table]+` WHERE slug = $1 LIMIT 1`, candidate).Scan(&exists)
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("check slug: %w", err)
+		}
+		return true, nil
+	})
+}
+
+// loadPage loads a single page without its links. It returns
+// storage.ErrPageNotFound when the page does not exist.
+func loadPage(ctx context.Context, q queryer, id string) (*domain.Page, error) {
+	var page domain.Page
+	var summary, content, parent, cover, icon, tags sql.NullString
+	err := q.QueryRowContext(ctx, `SELECT `+pageColumns+` FROM pages WHERE id = $1`, id).
+		Scan(&page.ID, &page.Slug, &page.Title, &summary, &content, &parent, &cover, &icon, &tags, &page.IsArchived, &page.IsTemplate, &page.Version, &page.CreatedAt, &page.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrPageNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan page: %w", err)
+	}
+	page.Summary = summary.String
+	page.Content = content.String
+	if parent.Valid {
+		page.ParentPageID = &parent.String
+	}
+	if cover.Valid {
+		page.CoverImageID = &cover.String
+	}
+	if icon.Valid {
+		page.Icon = &icon.String
+	}
+	if tags.String != "" {
+		if err := json.Unmarshal([]byte(tags.String), &page.Tags); err != nil {
+			return nil, fmt.Errorf("unmarshal tags: %w", err)
+		}
+	}
+	page.CreatedAt = page.CreatedAt.UTC()
+	page.UpdatedAt = page.UpdatedAt.UTC()
+	return &page, nil
+}
+
+func loadPageLinks(ctx context.Context, q queryer, id string) (*domain.PageLinks, error) {
+	outbound, err := queryStrings(ctx, q, `SELECT target_page_id FROM page_links WHERE source_page_id = $1 ORDER BY target_page_id`, id)
+	if err != nil {
+		return nil, fmt.Errorf("select outbound links: %w", err)
+	}
+	inbound, err := queryStrings(ctx, q, `SELECT source_page_id FROM page_links WHERE target_page_id = $1 ORDER BY source_page_id`, id)
+	if err != nil {
+		return nil, fmt.Errorf("select inbound links: %w", err)
+	}
+	return &domain.PageLinks{PageID: id, Outbound: outbound, Inbound: inbound}, nil
+}
+
+func insertPageLinks(ctx context.Context, q queryer, sourceID string, targets []string, ts time.Time) error {
+	for _, targetID := range targets {
+		if _, err := q.ExecContext(ctx, `INSERT INTO page_links(source_page_id, target_page_id, created_at) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`,
+			sourceID, targetID, ts); err != nil {
+			if classified := constraintError(err); classified != nil {
+				return classified
+			}
+			return fmt.Errorf("insert page link: %w", err)
+		}
+	}
+	return nil
+}
+
+// queryStrings returns the single text column of every row of query.
+func queryStrings(ctx context.Context, q queryer, query string, args ...any) ([]string, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
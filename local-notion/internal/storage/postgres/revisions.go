@@ -0,0 +1,266 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// SetRevisionPolicy replaces the revision policy used by later writes.
+func (s *Store) SetRevisionPolicy(policy storage.RevisionPolicy) {
+	s.revisions = policy
+}
+
+// ListPageRevisions returns the revisions of a page, newest first.
+func (s *Store) ListPageRevisions(ctx context.Context, pageID string) ([]domain.PageRevision, error) {
+	if _, err := loadPage(ctx, s.db, pageID); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT `+revisionColumns+` FROM page_revisions WHERE page_id = $1 ORDER BY revision DESC`, pageID)
+	if err != nil {
+		return nil, fmt.Errorf("list page revisions: %w", err)
+	}
+	defer rows.Close()
+	revisions := []domain.PageRevision{}
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan page revision: %w", err)
+		}
+		revisions = append(revisions, *rev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate page revisions: %w", err)
+	}
+	return revisions, nil
+}
+
+// DiffPageRevisions compares revision from with revision to. A zero to
+// compares against the newest revision.
+func (s *Store) DiffPageRevisions(ctx context.Context, pageID string, from, to int) (*domain.PageDiff, error) {
+	if to == 0 {
+		if err := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(revision), 0) FROM page_revisions WHERE page_id = $1`, pageID).Scan(&to); err != nil {
+			return nil, fmt.Errorf("load latest revision: %w", err)
+		}
+	}
+	a, err := loadRevision(ctx, s.db, pageID, from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := loadRevision(ctx, s.db, pageID, to)
+	if err != nil {
+		return nil, err
+	}
+	return storage.DiffRevisions(a, b), nil
+}
+
+// RestorePageRevision puts the title, content, tags and links of a revision
+// back on the page and records the result as a new revision.
+func (s *Store) RestorePageRevision(ctx context.Context, in storage.RestorePageRevisionInput) (*domain.Page, error) {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	version, err := lockPage(ctx, tx, in.PageID)
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.CheckVersion(in.ExpectedVersion, version); err != nil {
+		return nil, err
+	}
+	rev, err := loadRevision(ctx, tx, in.PageID, in.Revision)
+	if err != nil {
+		return nil, err
+	}
+	before, err := loadAuditPage(ctx, tx, in.PageID)
+	if err != nil {
+		return nil, err
+	}
+	tags := rev.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	links := rev.LinkedPageIDs
+	if links == nil {
+		links = []string{}
+	}
+	ts := now()
+	if err := updatePageFields(ctx, tx, storage.UpdatePageInput{PageID: in.PageID, Title: &rev.Title, Content: &rev.Content, Tags: tags, LinkedPageIDs: links}, ts); err != nil {
+		return nil, err
+	}
+	if _, err := s.recordPageRevision(ctx, tx, in.PageID, storage.RevisionActionRestore, in.Author, &in.Revision, ts); err != nil {
+		return nil, err
+	}
+	if err := auditPageChange(ctx, tx, storage.AuditActionRestore, in.PageID, before, ts); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit revision restore: %w", err)
+	}
+	return s.GetPage(ctx, in.PageID)
+}
+
+const revisionColumns = `page_id, revision, action, title, content, tags, links, author, restored_from, created_at`
+
+func scanRevision(row interface{ Scan(...any) error }) (*domain.PageRevision, error) {
+	var rev domain.PageRevision
+	var tags, links string
+	var restoredFrom sql.NullInt64
+	if err := row.Scan(&rev.PageID, &rev.Revision, &rev.Action, &rev.Title, &rev.Content, &tags, &links, &rev.Author, &restoredFrom, &rev.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(tags), &rev.Tags); err != nil {
+		return nil, fmt.Errorf("unmarshal revision tags: %w", err)
+	}
+	if err := json.Unmarshal([]byte(links), &rev.LinkedPageIDs); err != nil {
+		return nil, fmt.Errorf("unmarshal revision links: %w", err)
+	}
+	if restoredFrom.Valid {
+		v := int(restoredFrom.Int64)
+		rev.RestoredFrom = &v
+	}
+	rev.CreatedAt = rev.CreatedAt.UTC()
+	return &rev, nil
+}
+
+func loadRevision(ctx context.Context, q queryer, pageID string, revision int) (*domain.PageRevision, error) {
+	rev, err := scanRevision(q.QueryRowContext(ctx, `SELECT `+revisionColumns+` FROM page_revisions WHERE page_id = $1 AND revision = $2`, pageID, revision))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrRevisionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load revision: %w", err)
+	}
+	return rev, nil
+}
+
+// pageSnapshot reads the revisioned fields of a page.
+func pageSnapshot(ctx context.Context, q queryer, pageID string) (*domain.PageRevision, error) {
+	page, err := loadAuditPage(ctx, q, pageID)
+	if err != nil {
+		return nil, err
+	}
+	snap := &domain.PageRevision{PageID: pageID, Title: page.Title, Content: page.Content, Tags: page.Tags, LinkedPageIDs: page.LinkedPageIDs}
+	if snap.Tags == nil {
+		snap.Tags = []string{}
+	}
+	return snap, nil
+}
+
+// ensurePageBaseline records the current state of a page as its first
+// revision when the page predates revision history, so the change about to be
+// made can be diffed and undone.
+func ensurePageBaseline(ctx context.Context, q queryer, pageID string, ts time.Time) error {
+	var exists bool
+	if err := q.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM page_revisions WHERE page_id = $1)`, pageID).Scan(&exists); err != nil {
+		return fmt.Errorf("check page revisions: %w", err)
+	}
+	if exists {
+		return nil
+	}
+	snap, err := pageSnapshot(ctx, q, pageID)
+	if err != nil {
+		return err
+	}
+	snap.Revision = 1
+	snap.Action = storage.RevisionActionCreate
+	snap.CreatedAt = ts
+	return insertRevision(ctx, q, snap)
+}
+
+// recordPageRevision snapshots a page after a change. Updates that leave the
+// snapshot unchanged record nothing, and updates inside the coalesce window
+// rewrite the previous revision instead of adding one. It returns the
+// revision number holding the new state.
+func (s *Store) recordPageRevision(ctx context.Context, q queryer, pageID, action, author string, restoredFrom *int, ts time.Time) (int, error) {
+	snap, err := pageSnapshot(ctx, q, pageID)
+	if err != nil {
+		return 0, err
+	}
+	latest, err := scanRevision(q.QueryRowContext(ctx, `SELECT `+revisionColumns+` FROM page_revisions WHERE page_id = $1 ORDER BY revision DESC LIMIT 1`, pageID))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("load latest revision: %w", err)
+	}
+	if latest != nil && action == storage.RevisionActionUpdate {
+		if storage.SameRevisionSnapshot(latest, snap) {
+			return latest.Revision, nil
+		}
+		if s.revisions.Coalesces(latest, author, ts) {
+			snap.Revision = latest.Revision
+			if err := writeRevisionSnapshot(ctx, q, snap, ts); err != nil {
+				return 0, err
+			}
+			return latest.Revision, nil
+		}
+	}
+	snap.Revision = 1
+	if latest != nil {
+		snap.Revision = latest.Revision + 1
+	}
+	snap.Action = action
+	snap.Author = author
+	snap.RestoredFrom = restoredFrom
+	snap.CreatedAt = ts
+	if err := insertRevision(ctx, q, snap); err != nil {
+		return 0, err
+	}
+	if err := s.pruneRevisions(ctx, q, pageID, snap.Revision, ts); err != nil {
+		return 0, err
+	}
+	return snap.Revision, nil
+}
+
+func insertRevision(ctx context.Context, q queryer, rev *domain.PageRevision) error {
+	tags, err := json.Marshal(rev.Tags)
+	if err != nil {
+		return fmt.Errorf("marshal revision tags: %w", err)
+	}
+	links, err := json.Marshal(rev.LinkedPageIDs)
+	if err != nil {
+		return fmt.Errorf("marshal revision links: %w", err)
+	}
+	if _, err := q.ExecContext(ctx, `INSERT INTO page_revisions(page_id, revision, action, title, content, tags, links, author, restored_from, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		rev.PageID, rev.Revision, rev.Action, rev.Title, rev.Content, string(tags), string(links), rev.Author, rev.RestoredFrom, rev.CreatedAt); err != nil {
+		return fmt.Errorf("insert page revision: %w", err)
+	}
+	return nil
+}
+
+func writeRevisionSnapshot(ctx context.Context, q queryer, rev *domain.PageRevision, ts time.Time) error {
+	tags, err := json.Marshal(rev.Tags)
+	if err != nil {
+		return fmt.Errorf("marshal revision tags: %w", err)
+	}
+	links, err := json.Marshal(rev.LinkedPageIDs)
+	if err != nil {
+		return fmt.Errorf("marshal revision links: %w", err)
+	}
+	if _, err := q.ExecContext(ctx, `UPDATE page_revisions SET title = $1, content = $2, tags = $3, links = $4, created_at = $5 WHERE page_id = $6 AND revision = $7`,
+		rev.Title, rev.Content, string(tags), string(links), ts, rev.PageID, rev.Revision); err != nil {
+		return fmt.Errorf("coalesce page revision: %w", err)
+	}
+	return nil
+}
+
+// pruneRevisions applies the retention policy to a page, never removing the
+// newest revision.
+func (s *Store) pruneRevisions(ctx context.Context, q queryer, pageID string, newest int, ts time.Time) error {
+	if s.revisions.MaxRevisions > 0 {
+		if _, err := q.ExecContext(ctx, `DELETE FROM page_revisions WHERE page_id = $1 AND revision <= $2`, pageID, newest-s.revisions.MaxRevisions); err != nil {
+			return fmt.Errorf("prune page revisions: %w", err)
+		}
+	}
+	if s.revisions.MaxAge > 0 {
+		if _, err := q.ExecContext(ctx, `DELETE FROM page_revisions WHERE page_id = $1 AND revision < $2 AND created_at < $3`, pageID, newest, ts.Add(-s.revisions.MaxAge)); err != nil {
+			return fmt.Errorf("prune expired page revisions: %w", err)
+		}
+	}
+	return nil
+}
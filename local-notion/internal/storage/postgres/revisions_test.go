@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/storage"
+)
+
+func TestStorePageRevisionsCoalesceAndRestore(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	other, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "other", Title: "Other"})
+	require.NoError(t, err)
+	page, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "notes", Title: "Notes", Content: "one\ntwo\nthree", Tags: []string{"a"}, Author: "ana"})
+	require.NoError(t, err)
+
+	edited, renamed := "one\n2\nthree\nfour", "Renamed"
+	_, err = store.UpdatePage(ctx, storage.UpdatePageInput{PageID: page.ID, Content: &edited, Author: "ana"})
+	require.NoError(t, err)
+	// A second edit by the same author inside the window folds into revision 2.
+	_, err = store.UpdatePage(ctx, storage.UpdatePageInput{PageID: page.ID, Tags: []string{"b"}, Author: "ana"})
+	require.NoError(t, err)
+	_, err = store.UpdatePage(ctx, storage.UpdatePageInput{PageID: page.ID, Title: &renamed, LinkedPageIDs: []string{other.ID}, Author: "bo"})
+	require.NoError(t, err)
+
+	revisions, err := store.ListPageRevisions(ctx, page.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 3)
+	require.Equal(t, storage.RevisionActionCreate, revisions[2].Action)
+	require.Equal(t, []string{"b"}, revisions[1].Tags)
+	require.Equal(t, []string{other.ID}, revisions[0].LinkedPageIDs)
+
+	diff, err := store.DiffPageRevisions(ctx, page.ID, 1, 0)
+	require.NoError(t, err)
+	require.Equal(t, 3, diff.To)
+	require.Equal(t, "Renamed", diff.Title.To)
+	require.Equal(t, []string{other.ID}, diff.LinksAdded)
+
+	stale := 1
+	_, err = store.RestorePageRevision(ctx, storage.RestorePageRevisionInput{PageID: page.ID, Revision: 1, Author: "cy", ExpectedVersion: &stale})
+	require.ErrorIs(t, err, storage.ErrVersionMismatch)
+
+	restored, err := store.RestorePageRevision(ctx, storage.RestorePageRevisionInput{PageID: page.ID, Revision: 1, Author: "cy"})
+	require.NoError(t, err)
+	require.Equal(t, "Notes", restored.Title)
+	require.Equal(t, "one\ntwo\nthree", restored.Content)
+	require.Empty(t, restored.LinkedPageIDs)
+
+	_, err = store.RestorePageRevision(ctx, storage.RestorePageRevisionInput{PageID: page.ID, Revision: 9, Author: "cy"})
+	require.ErrorIs(t, err, storage.ErrRevisionNotFound)
+}
+
+func TestStorePageRevisionRetention(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	store.SetRevisionPolicy(storage.RevisionPolicy{MaxRevisions: 2})
+	page, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "log", Title: "Log"})
+	require.NoError(t, err)
+	for _, content := range []string{"a", "b", "c"} {
+		_, err = store.UpdatePage(ctx, storage.UpdatePageInput{PageID: page.ID, Content: &content})
+		require.NoError(t, err)
+	}
+	revisions, err := store.ListPageRevisions(ctx, page.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	require.Equal(t, "c", revisions[0].Content)
+}
@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/storage"
+)
+
+func TestPageSlugHistory(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	page, err := store.CreatePage(ctx, storage.CreatePageInput{Title: "Meeting Notes"})
+	require.NoError(t, err)
+
+	for _, slug := range []string{"notes", "minutes"} {
+		_, err = store.UpdatePage(ctx, storage.UpdatePageInput{PageID: page.ID, Slug: &slug})
+		require.NoError(t, err)
+	}
+	for _, slug := range []string{"meeting-notes", "notes", "minutes"} {
+		got, err := store.GetPageBySlug(ctx, slug)
+		require.NoError(t, err, slug)
+		require.Equal(t, page.ID, got.ID)
+		require.Equal(t, "minutes", got.Slug)
+	}
+	_, err = store.GetPageBySlug(ctx, "missing")
+	require.ErrorIs(t, err, storage.ErrPageNotFound)
+
+	// Generated slugs skip former slugs, but an explicit slug takes one over.
+	generated, err := store.CreatePage(ctx, storage.CreatePageInput{Title: "Notes"})
+	require.NoError(t, err)
+	require.Equal(t, "notes-2", generated.Slug)
+	other, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "notes", Title: "Other notes"})
+	require.NoError(t, err)
+	got, err := store.GetPageBySlug(ctx, "notes")
+	require.NoError(t, err)
+	require.Equal(t, other.ID, got.ID)
+}
+
+func TestDatabaseSlugHistory(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db, err := store.CreateDatabase(ctx, storage.CreateDatabaseInput{Title: "Reading List"})
+	require.NoError(t, err)
+	_, err = store.CreateDatabase(ctx, storage.CreateDatabaseInput{Slug: "books", Title: "Books"})
+	require.NoError(t, err)
+
+	_, err = store.UpdateDatabaseSlug(ctx, storage.UpdateDatabaseSlugInput{DatabaseID: db.ID, Slug: "books"})
+	require.ErrorIs(t, err, storage.ErrSlugConflict)
+	stale := 2
+	_, err = store.UpdateDatabaseSlug(ctx, storage.UpdateDatabaseSlugInput{DatabaseID: db.ID, Slug: "reading", ExpectedVersion: &stale})
+	require.ErrorIs(t, err, storage.ErrVersionMismatch)
+	updated, err := store.UpdateDatabaseSlug(ctx, storage.UpdateDatabaseSlugInput{DatabaseID: db.ID, Slug: "reading"})
+	require.NoError(t, err)
+	require.Equal(t, "reading", updated.Slug)
+	require.Equal(t, 2, updated.Version)
+
+	got, err := store.GetDatabaseBySlug(ctx, "reading-list")
+	require.NoError(t, err)
+	require.Equal(t, db.ID, got.ID)
+	_, err = store.UpdateDatabaseSlug(ctx, storage.UpdateDatabaseSlugInput{DatabaseID: "missing", Slug: "x"})
+	require.ErrorIs(t, err, storage.ErrDatabaseNotFound)
+
+	entries, _, err := store.ListAuditEntries(ctx, storage.AuditFilter{EntityType: storage.AuditEntityDatabase, EntityID: db.ID, Action: storage.AuditActionUpdate})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
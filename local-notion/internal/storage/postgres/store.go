@@ -0,0 +1,170 @@
+// Package postgres implements storage.Store on PostgreSQL. Values, filters and
+// configuration are kept as JSONB. Besides the core repositories it records
+// the audit log, page revisions, slug history and notifications that every
+// write produces; other features, such as templates and view editing, are
+// only offered by the sqlite backend.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// migrationLockID serialises migrations of servers starting concurrently
+// against the same database.
+const migrationLockID = 7_386_420_517
+
+// Store wraps access to the PostgreSQL database.
+type Store struct {
+	db        *sql.DB
+	revisions storage.RevisionPolicy
+	// notifications pushes committed notifications to subscribers.
+	notifications *storage.NotificationBroker
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx so read helpers can run
+// inside or outside a transaction.
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// writeTx is a write transaction. Notifications created inside it are held
+// back until it commits and then pushed to subscribers; a rollback discards
+// them with the transaction.
+type writeTx struct {
+	*sql.Tx
+	store    *Store
+	notified []domain.Notification
+}
+
+// begin starts a write transaction.
+func (s *Store) begin(ctx context.Context) (*writeTx, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &writeTx{Tx: tx, store: s}, nil
+}
+
+// Commit commits the transaction and publishes its notifications.
+func (tx *writeTx) Commit() error {
+	if err := tx.Tx.Commit(); err != nil {
+		return err
+	}
+	tx.store.notifications.Publish(tx.notified)
+	return nil
+}
+
+// IsDSN reports whether dsn names a PostgreSQL database, i.e. uses the
+// postgres:// or postgresql:// scheme.
+func IsDSN(dsn string) bool {
+	return strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://")
+}
+
+// Open connects to the PostgreSQL database at dsn and applies pending
+// migrations.
+func Open(dsn string) (*Store, error) {
+	if strings.TrimSpace(dsn) == "" {
+		return nil, fmt.Errorf("open postgres: empty dsn")
+	}
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open postgres: %w", err)
+	}
+	if err := applyMigrations(db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &Store{db: db, revisions: storage.DefaultRevisionPolicy, notifications: storage.NewNotificationBroker()}, nil
+}
+
+// Close closes underlying db.
+func (s *Store) Close() error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// Ping verifies database connectivity.
+func (s *Store) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// applyMigrations runs every embedded migration that has not been recorded in
+// schema_migrations yet, holding an advisory lock for the duration.
+func applyMigrations(db *sql.DB) error {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("connect postgres: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("lock migrations: %w", err)
+	}
+	defer func() { _, _ = conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, migrationLockID) }()
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version TEXT PRIMARY KEY,
+    applied_at TIMESTAMPTZ NOT NULL
+)`); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	entries, err := migrationsFS.ReadDir("migrations")
+	if err != nil {
+		return fmt.Errorf("read migrations: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		var applied int
+		err := conn.QueryRowContext(ctx, `SELECT COUNT(1) FROM schema_migrations WHERE version = $1`, entry.Name()).Scan(&applied)
+		if err != nil {
+			return fmt.Errorf("check migration %s: %w", entry.Name(), err)
+		}
+		if applied > 0 {
+			continue
+		}
+		sqlBytes, err := migrationsFS.ReadFile("migrations/" + entry.Name())
+		if err != nil {
+			return fmt.Errorf("read migration %s: %w", entry.Name(), err)
+		}
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("begin migration %s: %w", entry.Name(), err)
+		}
+		if _, err := tx.ExecContext(ctx, string(sqlBytes)); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("exec migration %s: %w", entry.Name(), err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations(version, applied_at) VALUES ($1, $2)`, entry.Name(), now()); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("record migration %s: %w", entry.Name(), err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("commit migration %s: %w", entry.Name(), err)
+		}
+	}
+	return nil
+}
+
+// now returns the current time at the microsecond precision PostgreSQL keeps,
+// so returned records compare equal to what is read back later.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
@@ -0,0 +1,41 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// QueryView returns the non-archived items of a view that match its filters,
+// ordered by its sorts and then by rank, with the aggregates configured in its
+// layout options. The database and its items are read from one snapshot.
+func (s *Store) QueryView(ctx context.Context, databaseID, viewID string) (*domain.ViewResult, error) {
+	if err := storage.RequireFields("database_id", databaseID, "view_id", viewID); err != nil {
+		return nil, err
+	}
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	db, err := loadDatabase(ctx, tx, databaseID)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := queryStrings(ctx, tx, `SELECT id FROM database_items WHERE database_id = $1 AND NOT is_archived ORDER BY rank ASC, created_at ASC`, databaseID)
+	if err != nil {
+		return nil, fmt.Errorf("query view items: %w", err)
+	}
+	items := make([]domain.DatabaseItem, 0, len(ids))
+	for _, id := range ids {
+		item, err := loadDatabaseItem(ctx, tx, id)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+	return storage.QueryViewItems(db, viewID, items)
+}
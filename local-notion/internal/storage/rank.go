@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+)
+
+// Item ranks are base-62 strings compared lexicographically. Each key is read
+// as the fraction 0.<digits>, so there is always room for a key between two
+// others and a drag-and-drop move only rewrites the moved row. Keys never end
+// in the zero digit, which guarantees a key can always be placed before them.
+const rankDigits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+const rankBase = len(rankDigits)
+
+// MaxRankLength triggers a rebalance of a database's ranks once repeated
+// inserts at the same spot have made keys this long.
+const MaxRankLength = 48
+
+// ErrRankOrder is returned by RankBetween when lower does not sort before upper.
+var ErrRankOrder = errors.New("rank bounds out of order")
+
+func rankDigit(c byte) int {
+	return strings.IndexByte(rankDigits, c)
+}
+
+// RankBetween returns a key strictly between lower and upper. An empty lower
+// means "before everything" and an empty upper means "after everything".
+func RankBetween(lower, upper string) (string, error) {
+	if upper != "" && lower >= upper {
+		return "", ErrRankOrder
+	}
+	return rankMidpoint(lower, upper), nil
+}
+
+func rankMidpoint(lower, upper string) string {
+	if upper != "" {
+		n := 0
+		for n < len(upper) && rankCharAt(lower, n) == upper[n] {
+			n++
+		}
+		if n > 0 {
+			rest := ""
+			if n < len(lower) {
+				rest = lower[n:]
+			}
+			return upper[:n] + rankMidpoint(rest, upper[n:])
+		}
+	}
+	digitLower := 0
+	if lower != "" {
+		digitLower = rankDigit(lower[0])
+	}
+	digitUpper := rankBase
+	if upper != "" {
+		digitUpper = rankDigit(upper[0])
+	}
+	if digitUpper-digitLower > 1 {
+		return string(rankDigits[(digitLower+digitUpper+1)/2])
+	}
+	if len(upper) > 1 {
+		return upper[:1]
+	}
+	rest := ""
+	if len(lower) > 1 {
+		rest = lower[1:]
+	}
+	return string(rankDigits[digitLower]) + rankMidpoint(rest, "")
+}
+
+func rankCharAt(key string, idx int) byte {
+	if idx < len(key) {
+		return key[idx]
+	}
+	return rankDigits[0]
+}
+
+// Appended and respaced keys are rankWidth digits wide. Appends step by
+// rankAppendStep, leaving that many keys free between neighbours, and
+// respacing only uses the middle half of the key space, so a run of
+// appends or inserts at either end has room for millions of keys before a
+// key grows past rankWidth.
+const (
+	rankWidth      = 6
+	rankAppendStep = rankBase * rankBase
+)
+
+// rankSpace is the number of distinct rankWidth-digit keys.
+var rankSpace = rankPow(rankWidth)
+
+func rankPow(n int) int {
+	v := 1
+	for i := 0; i < n; i++ {
+		v *= rankBase
+	}
+	return v
+}
+
+// RankAfter returns a key greater than last, used when appending items. The
+// key is the first rankWidth digits of last plus rankAppendStep, so repeated
+// appends never make keys longer than rankWidth. Only once the fixed-width
+// space is used up does it fall back to a longer key.
+func RankAfter(last string) string {
+	if last == "" {
+		return encodeRank(rankSpace/4, rankWidth)
+	}
+	value := 0
+	for i := 0; i < rankWidth; i++ {
+		value = value*rankBase + rankDigit(rankCharAt(last, i))
+	}
+	if next := value + rankAppendStep; next < rankSpace {
+		return encodeRank(next, rankWidth)
+	}
+	return rankMidpoint(last, "")
+}
+
+// RankSequence returns n ascending keys spread evenly over the middle half of
+// the key space.
+func RankSequence(n int) []string {
+	width := rankWidth
+	space := rankSpace
+	for space < 8*(n+1) {
+		width++
+		space *= rankBase
+	}
+	step := space / 2 / (n + 1)
+	keys := make([]string, n)
+	for i := 0; i < n; i++ {
+		keys[i] = encodeRank(space/4+(i+1)*step, width)
+	}
+	return keys
+}
+
+// encodeRank formats value as a width-digit key without trailing zero digits.
+func encodeRank(value, width int) string {
+	buf := make([]byte, width)
+	for pos := width - 1; pos >= 0; pos-- {
+		buf[pos] = rankDigits[value%rankBase]
+		value /= rankBase
+	}
+	return strings.TrimRight(string(buf), rankDigits[:1])
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRankBetween(t *testing.T) {
+	cases := []struct{ lower, upper string }{
+		{"", ""},
+		{"", "V"},
+		{"V", ""},
+		{"V", "W"},
+		{"V", "V1"},
+		{"Vz", "W"},
+		{"zzz", ""},
+		{"", "01"},
+	}
+	for _, tc := range cases {
+		key, err := RankBetween(tc.lower, tc.upper)
+		require.NoError(t, err)
+		require.Greater(t, key, tc.lower, "lower %q upper %q", tc.lower, tc.upper)
+		if tc.upper != "" {
+			require.Less(t, key, tc.upper, "lower %q upper %q", tc.lower, tc.upper)
+		}
+		require.NotEqual(t, byte('0'), key[len(key)-1])
+	}
+
+	_, err := RankBetween("b", "a")
+	require.ErrorIs(t, err, ErrRankOrder)
+}
+
+func TestRankBetweenRepeatedInsertsStayOrdered(t *testing.T) {
+	lower, upper := "a", "b"
+	for i := 0; i < 200; i++ {
+		key, err := RankBetween(lower, upper)
+		require.NoError(t, err)
+		require.Greater(t, key, lower)
+		require.Less(t, key, upper)
+		upper = key
+	}
+}
+
+func TestRankAfterAndSequence(t *testing.T) {
+	last := ""
+	for i := 0; i < 500; i++ {
+		next := RankAfter(last)
+		require.Greater(t, next, last)
+		last = next
+	}
+
+	keys := RankSequence(1000)
+	require.LessOrEqual(t, len(keys[len(keys)-1]), rankWidth)
+	require.Len(t, keys, 1000)
+	for i := 1; i < len(keys); i++ {
+		require.Less(t, keys[i-1], keys[i])
+	}
+}
+
+func TestRankAfterKeepsKeysBounded(t *testing.T) {
+	last := ""
+	for i := 0; i < 10000; i++ {
+		next := RankAfter(last)
+		require.Greater(t, next, last)
+		require.LessOrEqual(t, len(next), rankWidth)
+		last = next
+	}
+	// Room is left at both ends for items dropped before the first or after
+	// the last key.
+	first := RankAfter("")
+	before, err := RankBetween("", first)
+	require.NoError(t, err)
+	require.LessOrEqual(t, len(before), 2)
+	require.LessOrEqual(t, len(RankAfter(RankSequence(10000)[9999])), rankWidth)
+}
@@ -0,0 +1,243 @@
+package storage
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recurrence frequencies.
+const (
+	FrequencyDaily   = "DAILY"
+	FrequencyWeekly  = "WEEKLY"
+	FrequencyMonthly = "MONTHLY"
+	FrequencyYearly  = "YEARLY"
+)
+
+// maxRecurrencePeriods bounds how far a rule is expanded, so a rule whose
+// every candidate is skipped cannot loop forever.
+const maxRecurrencePeriods = 50000
+
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// RecurrenceRule is the supported subset of an RFC 5545 RRULE: FREQ
+// (DAILY, WEEKLY, MONTHLY or YEARLY), INTERVAL, BYDAY for weekly rules,
+// BYMONTHDAY for monthly rules and one of COUNT or UNTIL.
+type RecurrenceRule struct {
+	Freq       string
+	Interval   int
+	ByDay      []time.Weekday // Monday first
+	ByMonthDay []int          // 1 to 31, or -1 to -31 counting from the month end
+	Count      int
+	Until      *time.Time
+}
+
+// ParseRecurrenceRule parses an RRULE value such as
+// "FREQ=WEEKLY;BYDAY=MO,TH;COUNT=10", with or without the "RRULE:" prefix.
+// A date-only UNTIL includes that whole day in loc.
+func ParseRecurrenceRule(text string, loc *time.Location) (*RecurrenceRule, error) {
+	const field = "rule"
+	rule := &RecurrenceRule{Interval: 1}
+	text = strings.TrimPrefix(strings.TrimSpace(text), "RRULE:")
+	if text == "" {
+		return nil, InvalidField(field, "is required")
+	}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(text, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if !ok || value == "" || seen[name] {
+			return nil, InvalidField(field, "has a malformed or repeated part %q", part)
+		}
+		seen[name] = true
+		switch name {
+		case "FREQ":
+			switch freq := strings.ToUpper(value); freq {
+			case FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyYearly:
+				rule.Freq = freq
+			default:
+				return nil, InvalidField(field, "FREQ must be DAILY, WEEKLY, MONTHLY or YEARLY")
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, InvalidField(field, "INTERVAL must be a positive integer")
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, InvalidField(field, "COUNT must be a positive integer")
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := parseUntil(value, loc)
+			if err != nil {
+				return nil, InvalidField(field, "UNTIL must look like 20261231 or 20261231T170000Z")
+			}
+			rule.Until = &until
+		case "BYDAY":
+			for _, code := range strings.Split(strings.ToUpper(value), ",") {
+				day, ok := weekdayCodes[code]
+				if !ok {
+					return nil, InvalidField(field, "BYDAY must list MO, TU, WE, TH, FR, SA or SU")
+				}
+				if !slices.Contains(rule.ByDay, day) {
+					rule.ByDay = append(rule.ByDay, day)
+				}
+			}
+			slices.SortFunc(rule.ByDay, func(a, b time.Weekday) int { return mondayOffset(a) - mondayOffset(b) })
+		case "BYMONTHDAY":
+			for _, v := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(v)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, InvalidField(field, "BYMONTHDAY must list days from 1 to 31 or -1 to -31")
+				}
+				if !slices.Contains(rule.ByMonthDay, n) {
+					rule.ByMonthDay = append(rule.ByMonthDay, n)
+				}
+			}
+		default:
+			return nil, InvalidField(field, "%s is not supported", name)
+		}
+	}
+	switch {
+	case rule.Freq == "":
+		return nil, InvalidField(field, "FREQ is required")
+	case rule.Count > 0 && rule.Until != nil:
+		return nil, InvalidField(field, "cannot have both COUNT and UNTIL")
+	case len(rule.ByDay) > 0 && rule.Freq != FrequencyWeekly:
+		return nil, InvalidField(field, "BYDAY is only supported with FREQ=WEEKLY")
+	case len(rule.ByMonthDay) > 0 && rule.Freq != FrequencyMonthly:
+		return nil, InvalidField(field, "BYMONTHDAY is only supported with FREQ=MONTHLY")
+	}
+	return rule, nil
+}
+
+func parseUntil(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("20060102T150405", value, loc); err == nil {
+		return t, nil
+	}
+	day, err := time.ParseInLocation("20060102", value, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return day.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
+
+// String formats the rule as an RRULE value.
+func (r *RecurrenceRule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		codes := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			codes[i] = strings.ToUpper(day.String()[:2])
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, day := range r.ByMonthDay {
+			days[i] = strconv.Itoa(day)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Each calls fn with the index and time of every occurrence starting at
+// start, in order, until fn returns false or the rule ends. start is the
+// first occurrence and fixes the time of day; occurrences keep that wall
+// clock time in start's location across DST changes, and dates that do not
+// exist in a month or year, such as the 31st of April, are skipped. COUNT
+// counts every occurrence, including ones the caller treats as exceptions.
+func (r *RecurrenceRule) Each(start time.Time, fn func(index int, at time.Time) bool) {
+	index := 0
+	for period := 0; period < maxRecurrencePeriods; period++ {
+		for _, at := range r.period(start, period*r.Interval) {
+			if at.Before(start) {
+				continue
+			}
+			if (r.Until != nil && at.After(*r.Until)) || (r.Count > 0 && index >= r.Count) {
+				return
+			}
+			if !fn(index, at) {
+				return
+			}
+			index++
+		}
+	}
+}
+
+// period returns the candidate occurrences of the period n frequency units
+// after the one containing start.
+func (r *RecurrenceRule) period(start time.Time, n int) []time.Time {
+	switch r.Freq {
+	case FrequencyDaily:
+		return []time.Time{start.AddDate(0, 0, n)}
+	case FrequencyWeekly:
+		if len(r.ByDay) == 0 {
+			return []time.Time{start.AddDate(0, 0, 7*n)}
+		}
+		monday := start.AddDate(0, 0, 7*n-mondayOffset(start.Weekday()))
+		candidates := make([]time.Time, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			candidates = append(candidates, monday.AddDate(0, 0, mondayOffset(day)))
+		}
+		return candidates
+	case FrequencyMonthly:
+		first := time.Date(start.Year(), start.Month()+time.Month(n), 1, 0, 0, 0, 0, start.Location())
+		last := first.AddDate(0, 1, -1).Day()
+		days := r.ByMonthDay
+		if len(days) == 0 {
+			days = []int{start.Day()}
+		}
+		var resolved []int
+		for _, day := range days {
+			if day < 0 {
+				day = last + 1 + day
+			}
+			if day >= 1 && day <= last && !slices.Contains(resolved, day) {
+				resolved = append(resolved, day)
+			}
+		}
+		slices.Sort(resolved)
+		candidates := make([]time.Time, 0, len(resolved))
+		for _, day := range resolved {
+			candidates = append(candidates, atClock(first.Year(), first.Month(), day, start))
+		}
+		return candidates
+	default:
+		at := atClock(start.Year()+n, start.Month(), start.Day(), start)
+		if at.Day() != start.Day() {
+			return nil
+		}
+		return []time.Time{at}
+	}
+}
+
+// atClock returns the given date at the wall clock time of start.
+func atClock(year int, month time.Month, day int, start time.Time) time.Time {
+	return time.Date(year, month, day, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+}
+
+// mondayOffset counts the days from Monday to day.
+func mondayOffset(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
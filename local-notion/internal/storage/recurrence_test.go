@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func occurrences(t *testing.T, rule string, start time.Time, limit int) []string {
+	t.Helper()
+	r, err := ParseRecurrenceRule(rule, start.Location())
+	require.NoError(t, err)
+	var got []string
+	r.Each(start, func(_ int, at time.Time) bool {
+		got = append(got, at.Format("2006-01-02 15:04 MST"))
+		return len(got) < limit
+	})
+	return got
+}
+
+func TestRecurrenceRuleOccurrences(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	// Wednesday 2026-03-25, the week clocks go forward in Berlin.
+	start := time.Date(2026, 3, 25, 9, 0, 0, 0, berlin)
+
+	require.Equal(t, []string{"2026-03-25 09:00 CET", "2026-03-27 09:00 CET", "2026-03-30 09:00 CEST", "2026-04-01 09:00 CEST"},
+		occurrences(t, "FREQ=WEEKLY;BYDAY=MO,WE,FR", start, 4))
+	require.Equal(t, []string{"2026-03-25 09:00 CET", "2026-04-08 09:00 CEST", "2026-04-22 09:00 CEST"},
+		occurrences(t, "RRULE:FREQ=WEEKLY;INTERVAL=2", start, 10)[:3])
+	require.Equal(t, []string{"2026-03-25 09:00 CET", "2026-03-26 09:00 CET"},
+		occurrences(t, "FREQ=DAILY;COUNT=2", start, 10))
+	require.Equal(t, []string{"2026-03-25 09:00 CET", "2026-03-26 09:00 CET", "2026-03-27 09:00 CET"},
+		occurrences(t, "FREQ=DAILY;UNTIL=20260327", start, 10))
+
+	monthEnd := time.Date(2026, 1, 31, 18, 30, 0, 0, time.UTC)
+	require.Equal(t, []string{"2026-01-31 18:30 UTC", "2026-03-31 18:30 UTC", "2026-05-31 18:30 UTC"},
+		occurrences(t, "FREQ=MONTHLY", monthEnd, 3))
+	require.Equal(t, []string{"2026-01-31 18:30 UTC", "2026-02-15 18:30 UTC", "2026-02-28 18:30 UTC"},
+		occurrences(t, "FREQ=MONTHLY;BYMONTHDAY=15,-1", monthEnd, 3))
+	leap := time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, []string{"2028-02-29 00:00 UTC", "2032-02-29 00:00 UTC"}, occurrences(t, "FREQ=YEARLY", leap, 2))
+}
+
+func TestParseRecurrenceRule(t *testing.T) {
+	rule, err := ParseRecurrenceRule("freq=weekly;byday=FR,MO;interval=2;count=4", time.UTC)
+	require.NoError(t, err)
+	require.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR;COUNT=4", rule.String())
+
+	for _, text := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;COUNT=2;UNTIL=20261231",
+		"FREQ=DAILY;BYDAY=MO",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=DAILY;BYHOUR=9",
+		"FREQ=DAILY;FREQ=WEEKLY",
+	} {
+		_, err := ParseRecurrenceRule(text, time.UTC)
+		require.ErrorIs(t, err, ErrValidation, text)
+	}
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"slices"
+	"time"
+
+	"github.com/example/agents-playground/internal/domain"
+)
+
+// ErrRevisionNotFound is returned when a page revision does not exist.
+var ErrRevisionNotFound = NotFoundError("revision_not_found", "revision not found")
+
+// Page revision actions.
+const (
+	RevisionActionCreate  = "create"
+	RevisionActionUpdate  = "update"
+	RevisionActionRestore = "restore"
+)
+
+// RestorePageRevisionInput puts revision Revision of a page back on it.
+type RestorePageRevisionInput struct {
+	PageID   string
+	Revision int
+	Author   string
+	// ExpectedVersion, when set, rejects the restore with ErrVersionMismatch
+	// unless the page is still at that version.
+	ExpectedVersion *int
+}
+
+// RevisionPolicy controls how page revisions are coalesced and retained.
+type RevisionPolicy struct {
+	// CoalesceWindow folds an update into the previous revision when both are
+	// updates by the same author and the previous one is younger than this.
+	CoalesceWindow time.Duration
+	// MaxRevisions keeps only the newest revisions of a page; zero keeps all.
+	MaxRevisions int
+	// MaxAge drops revisions older than this, always keeping the newest; zero
+	// keeps all.
+	MaxAge time.Duration
+}
+
+// DefaultRevisionPolicy coalesces edits made within five minutes and keeps
+// every revision.
+var DefaultRevisionPolicy = RevisionPolicy{CoalesceWindow: 5 * time.Minute}
+
+// Coalesces reports whether an update by author at now rewrites the latest
+// revision instead of adding one.
+func (p RevisionPolicy) Coalesces(latest *domain.PageRevision, author string, now time.Time) bool {
+	return latest.Action == RevisionActionUpdate && latest.Author == author && now.Sub(latest.CreatedAt) < p.CoalesceWindow
+}
+
+// Expired reports whether a revision created at createdAt is past MaxAge.
+func (p RevisionPolicy) Expired(createdAt, now time.Time) bool {
+	return p.MaxAge > 0 && now.Sub(createdAt) > p.MaxAge
+}
+
+// SameRevisionSnapshot reports whether two revisions hold the same page state.
+func SameRevisionSnapshot(a, b *domain.PageRevision) bool {
+	return a.Title == b.Title && a.Content == b.Content && slices.Equal(a.Tags, b.Tags) && slices.Equal(a.LinkedPageIDs, b.LinkedPageIDs)
+}
+
+// DiffRevisions compares revision a with revision b of the same page.
+func DiffRevisions(a, b *domain.PageRevision) *domain.PageDiff {
+	diff := &domain.PageDiff{PageID: a.PageID, From: a.Revision, To: b.Revision, Lines: DiffLines(a.Content, b.Content)}
+	if a.Title != b.Title {
+		diff.Title = &domain.TextChange{From: a.Title, To: b.Title}
+	}
+	diff.TagsAdded, diff.TagsRemoved = DiffSets(a.Tags, b.Tags)
+	diff.LinksAdded, diff.LinksRemoved = DiffSets(a.LinkedPageIDs, b.LinkedPageIDs)
+	return diff
+}
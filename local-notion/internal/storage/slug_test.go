@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlugify(t *testing.T) {
+	cases := map[string]string{
+		"Hello, World!":         "hello-world",
+		"  Déjà vu -- encore  ": "deja-vu-encore",
+		"Ærø Łódź":              "aero-lodz",
+		"Привет мир":            "privet-mir",
+		"Αθήνα 2024":            "athina-2024",
+		"C++ & Go":              "c-go",
+		"東京":                    "",
+		"":                      "",
+	}
+	for in, want := range cases {
+		require.Equal(t, want, Slugify(in), "input %q", in)
+	}
+	long := Slugify(strings.Repeat("word ", 40))
+	require.LessOrEqual(t, len(long), maxSlugLength)
+	require.False(t, strings.HasSuffix(long, "-"))
+}
+
+func TestUniqueSlug(t *testing.T) {
+	taken := map[string]bool{"notes": true, "notes-2": true}
+	slug, err := UniqueSlug("notes", func(s string) (bool, error) { return taken[s], nil })
+	require.NoError(t, err)
+	require.Equal(t, "notes-3", slug)
+	slug, err = UniqueSlug("ideas", func(s string) (bool, error) { return taken[s], nil })
+	require.NoError(t, err)
+	require.Equal(t, "ideas", slug)
+}
@@ -0,0 +1,82 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// ErrAssetNotFound is returned when an asset row does not exist.
+var ErrAssetNotFound = storage.NotFoundError("asset_not_found", "asset not found")
+
+// GetAsset loads the metadata of an asset.
+func (s *Store) GetAsset(ctx context.Context, id string) (*domain.Asset, error) {
+	return loadAsset(ctx, s.reader, id)
+}
+
+func loadAsset(ctx context.Context, q queryer, id string) (*domain.Asset, error) {
+	var asset domain.Asset
+	err := q.QueryRowContext(ctx, `SELECT id, filename, content_type, size_bytes, storage_path, created_at FROM assets WHERE id = ?`, id).
+		Scan(&asset.ID, &asset.Filename, &asset.ContentType, &asset.SizeBytes, &asset.StoragePath, &asset.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAssetNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load asset: %w", err)
+	}
+	return &asset, nil
+}
+
+// saveAsset copies r into dir as <id><ext> and records the asset row,
+// replacing the row and file of an earlier asset with the same id.
+func saveAsset(ctx context.Context, q queryer, dir, id, filename string, r io.Reader, now time.Time) (*domain.Asset, error) {
+	before, err := loadAsset(ctx, q, id)
+	if err != nil && !errors.Is(err, ErrAssetNotFound) {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create asset dir: %w", err)
+	}
+	ext := strings.ToLower(filepath.Ext(filename))
+	storagePath := filepath.Join(dir, id+ext)
+	f, err := os.Create(storagePath)
+	if err != nil {
+		return nil, fmt.Errorf("create asset file: %w", err)
+	}
+	size, err := io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("write asset file: %w", err)
+	}
+	contentType := mime.TypeByExtension(ext)
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	asset := &domain.Asset{ID: id, Filename: filepath.Base(filename), ContentType: contentType, SizeBytes: size, StoragePath: storagePath, CreatedAt: now}
+	if _, err := q.ExecContext(ctx, `INSERT INTO assets(id, filename, content_type, size_bytes, storage_path, created_at) VALUES(?, ?, ?, ?, ?, ?)
+ON CONFLICT(id) DO UPDATE SET filename = excluded.filename, content_type = excluded.content_type, size_bytes = excluded.size_bytes, storage_path = excluded.storage_path`,
+		asset.ID, asset.Filename, asset.ContentType, asset.SizeBytes, asset.StoragePath, now); err != nil {
+		return nil, fmt.Errorf("insert asset: %w", err)
+	}
+	action := storage.AuditActionCreate
+	if before != nil {
+		action = storage.AuditActionUpdate
+		asset.CreatedAt = before.CreatedAt
+	}
+	if err := recordAudit(ctx, q, action, storage.AuditEntityAsset, id, before, asset, now); err != nil {
+		return nil, err
+	}
+	return asset, nil
+}
@@ -0,0 +1,201 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// ListAuditEntries returns matching audit entries newest first, together with
+// the cursor of the next page or "" when there are no more entries.
+func (s *Store) ListAuditEntries(ctx context.Context, filter storage.AuditFilter) ([]domain.AuditEntry, string, error) {
+	limit, before, err := storage.AuditPage(filter)
+	if err != nil {
+		return nil, "", err
+	}
+	where, args := auditWhere(filter)
+	if before > 0 {
+		where = append(where, "id < ?")
+		args = append(args, before)
+	}
+	entries, err := queryAudit(ctx, s.reader, where, args, "DESC", limit+1)
+	if err != nil {
+		return nil, "", err
+	}
+	next := ""
+	if len(entries) > limit {
+		entries = entries[:limit]
+		next = strconv.FormatInt(entries[limit-1].ID, 10)
+	}
+	return entries, next, nil
+}
+
+// ExportAuditLog writes every matching audit entry to w as JSON Lines, oldest
+// first. Cursor and Limit are ignored. Entries are read in batches so the
+// connection is not held for the whole export.
+func (s *Store) ExportAuditLog(ctx context.Context, filter storage.AuditFilter, w io.Writer) error {
+	where, args := auditWhere(filter)
+	enc := json.NewEncoder(w)
+	var after int64
+	for {
+		batchWhere := append(append([]string{}, where...), "id > ?")
+		batchArgs := append(append([]any{}, args...), after)
+		entries, err := queryAudit(ctx, s.reader, batchWhere, batchArgs, "ASC", storage.MaxAuditLimit)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := enc.Encode(entry); err != nil {
+				return fmt.Errorf("write audit entry: %w", err)
+			}
+		}
+		if len(entries) < storage.MaxAuditLimit {
+			return nil
+		}
+		after = entries[len(entries)-1].ID
+	}
+}
+
+func auditWhere(filter storage.AuditFilter) ([]string, []any) {
+	var where []string
+	var args []any
+	for _, cond := range []struct{ column, value string }{
+		{"actor", filter.Actor},
+		{"request_id", filter.RequestID},
+		{"action", filter.Action},
+		{"entity_type", filter.EntityType},
+		{"entity_id", filter.EntityID},
+	} {
+		if cond.value != "" {
+			where = append(where, cond.column+" = ?")
+			args = append(args, cond.value)
+		}
+	}
+	if filter.Since != nil {
+		where = append(where, "created_at >= ?")
+		args = append(args, filter.Since.UTC())
+	}
+	if filter.Until != nil {
+		where = append(where, "created_at < ?")
+		args = append(args, filter.Until.UTC())
+	}
+	return where, args
+}
+
+func queryAudit(ctx context.Context, q queryer, where []string, args []any, order string, limit int) ([]domain.AuditEntry, error) {
+	query := `SELECT id, actor, request_id, action, entity_type, entity_id, before_json, after_json, created_at FROM audit_log`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	query += ` ORDER BY id ` + order + ` LIMIT ?`
+	rows, err := q.QueryContext(ctx, query, append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("query audit log: %w", err)
+	}
+	defer rows.Close()
+	entries := []domain.AuditEntry{}
+	for rows.Next() {
+		var entry domain.AuditEntry
+		var before, after sql.NullString
+		if err := rows.Scan(&entry.ID, &entry.Actor, &entry.RequestID, &entry.Action, &entry.EntityType, &entry.EntityID, &before, &after, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan audit entry: %w", err)
+		}
+		if before.Valid {
+			entry.Before = json.RawMessage(before.String)
+		}
+		if after.Valid {
+			entry.After = json.RawMessage(after.String)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// recordAudit appends an audit entry using q, so it commits or rolls back
+// with the mutation it describes. before and after are JSON encoded; nil
+// values are stored as NULL.
+func recordAudit(ctx context.Context, q queryer, action, entityType, entityID string, before, after any, now time.Time) error {
+	beforeJSON, err := storage.AuditSnapshot(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := storage.AuditSnapshot(after)
+	if err != nil {
+		return err
+	}
+	info := storage.AuditInfoFrom(ctx)
+	if _, err := q.ExecContext(ctx, `INSERT INTO audit_log(actor, request_id, action, entity_type, entity_id, before_json, after_json, created_at) VALUES(?, ?, ?, ?, ?, ?, ?, ?)`,
+		info.Actor, info.RequestID, action, entityType, entityID, beforeJSON, afterJSON, now); err != nil {
+		return fmt.Errorf("insert audit entry: %w", err)
+	}
+	return nil
+}
+
+// auditPageChange records the change of a page from its before state to its
+// current state. A nil before records the page as created.
+func auditPageChange(ctx context.Context, q queryer, action, pageID string, before *domain.Page, now time.Time) error {
+	after, err := loadAuditPage(ctx, q, pageID)
+	if err != nil {
+		return err
+	}
+	if before == nil {
+		action = storage.AuditActionCreate
+	}
+	return recordAudit(ctx, q, action, storage.AuditEntityPage, pageID, before, after, now)
+}
+
+// auditPagesCreated records the copies of a page tree, where newIDs maps each
+// source page to its copy.
+func auditPagesCreated(ctx context.Context, q queryer, tree []domain.Page, newIDs map[string]string, now time.Time) error {
+	for _, src := range tree {
+		if err := auditPageChange(ctx, q, storage.AuditActionCreate, newIDs[src.ID], nil, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// auditDatabaseCreate records a new database along with each of its
+// properties and views.
+func auditDatabaseCreate(ctx context.Context, q queryer, db *domain.Database, now time.Time) error {
+	return recordAuditChanges(ctx, q, storage.DatabaseCreateChanges(db), now)
+}
+
+// auditItemUpdate records the changes between two states of an item.
+func auditItemUpdate(ctx context.Context, q queryer, in storage.UpdateDatabaseItemInput, before, after *domain.DatabaseItem, now time.Time) error {
+	return recordAuditChanges(ctx, q, storage.ItemUpdateChanges(in, before, after), now)
+}
+
+func recordAuditChanges(ctx context.Context, q queryer, changes []storage.AuditChange, now time.Time) error {
+	for _, change := range changes {
+		if err := recordAudit(ctx, q, change.Action, change.EntityType, change.EntityID, change.Before, change.After, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadAuditPage loads a page with its outbound links for an audit snapshot.
+// It returns nil when the page does not exist.
+func loadAuditPage(ctx context.Context, q queryer, id string) (*domain.Page, error) {
+	page, err := loadPageRow(ctx, q, id)
+	if err != nil || page == nil {
+		return page, err
+	}
+	page.LinkedPageIDs, err = loadOutboundLinks(ctx, q, id)
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
@@ -0,0 +1,114 @@
+package sqlite
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+func TestStoreAuditRecordsMutations(t *testing.T) {
+	store := newTestStore(t)
+	ctx := storage.WithAuditInfo(context.Background(), storage.AuditInfo{Actor: "ana", RequestID: "req-1"})
+
+	page, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "notes", Title: "Notes"})
+	require.NoError(t, err)
+	_, err = store.UpdatePage(ctx, storage.UpdatePageInput{PageID: page.ID, Title: strPtr("Renamed")})
+	require.NoError(t, err)
+	db := newBulkTestDatabase(t, store)
+	item := createTestItems(t, store, db, "task")[0]
+	_, err = store.UpdateDatabaseItem(ctx, storage.UpdateDatabaseItemInput{DatabaseID: db.ID, ItemID: item.ID, Values: map[string]any{"status": "Done"}})
+	require.NoError(t, err)
+	require.NoError(t, store.DeleteDatabaseItem(ctx, db.ID, item.ID))
+
+	entries, next, err := store.ListAuditEntries(context.Background(), storage.AuditFilter{Actor: "ana"})
+	require.NoError(t, err)
+	require.Empty(t, next)
+	var got []string
+	for _, entry := range entries {
+		require.Equal(t, "req-1", entry.RequestID)
+		got = append(got, entry.Action+" "+entry.EntityType)
+	}
+	require.Equal(t, []string{"delete item", "create value", "update page", "create page"}, got)
+
+	var before, after domain.Page
+	require.NoError(t, json.Unmarshal(entries[2].Before, &before))
+	require.NoError(t, json.Unmarshal(entries[2].After, &after))
+	require.Equal(t, "Notes", before.Title)
+	require.Equal(t, "Renamed", after.Title)
+	require.Nil(t, entries[0].After)
+
+	// Mutations without audit info are still recorded, with an empty actor.
+	entries, _, err = store.ListAuditEntries(context.Background(), storage.AuditFilter{EntityType: storage.AuditEntityDatabase, EntityID: db.ID})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "", entries[0].Actor)
+	entries, _, err = store.ListAuditEntries(context.Background(), storage.AuditFilter{EntityType: storage.AuditEntityProperty})
+	require.NoError(t, err)
+	require.Len(t, entries, len(db.Properties))
+}
+
+func TestStoreAuditRolledBackWithMutation(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db := newBulkTestDatabase(t, store)
+	item := createTestItems(t, store, db, "task")[0]
+	since := time.Now().UTC()
+
+	_, err := store.BulkItems(ctx, storage.BulkItemsInput{DatabaseID: db.ID, IdempotencyKey: "k", Operations: []storage.BulkItemOperation{
+		{Op: BulkOpUpdate, ItemID: item.ID, Title: strPtr("Changed")},
+		{Op: BulkOpDelete, ItemID: "missing"},
+	}})
+	require.NoError(t, err)
+	entries, _, err := store.ListAuditEntries(ctx, storage.AuditFilter{Since: &since})
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	_, err = store.db.ExecContext(ctx, `DELETE FROM audit_log`)
+	require.ErrorContains(t, err, "append-only")
+}
+
+func TestStoreAuditPaginationAndExport(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	since := time.Now().UTC().Add(-time.Second)
+	for _, slug := range []string{"a", "b", "c"} {
+		_, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: slug, Title: slug})
+		require.NoError(t, err)
+	}
+
+	first, next, err := store.ListAuditEntries(ctx, storage.AuditFilter{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, first, 2)
+	require.NotEmpty(t, next)
+	rest, next, err := store.ListAuditEntries(ctx, storage.AuditFilter{Limit: 2, Cursor: next})
+	require.NoError(t, err)
+	require.Len(t, rest, 1)
+	require.Empty(t, next)
+	require.Greater(t, first[1].ID, rest[0].ID)
+
+	windowed, _, err := store.ListAuditEntries(ctx, storage.AuditFilter{Since: &since})
+	require.NoError(t, err)
+	require.Len(t, windowed, 3)
+
+	_, _, err = store.ListAuditEntries(ctx, storage.AuditFilter{Cursor: "nope"})
+	require.ErrorIs(t, err, storage.ErrInvalidAuditCursor)
+
+	var buf bytes.Buffer
+	require.NoError(t, store.ExportAuditLog(ctx, storage.AuditFilter{EntityType: storage.AuditEntityPage}, &buf))
+	scanner := bufio.NewScanner(&buf)
+	var ids []int64
+	for scanner.Scan() {
+		var entry domain.AuditEntry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		ids = append(ids, entry.ID)
+	}
+	require.Equal(t, []int64{rest[0].ID, first[1].ID, first[0].ID}, ids)
+}
@@ -0,0 +1,247 @@
+package sqlite
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// Bulk operation kinds accepted by BulkItems.
+const (
+	BulkOpCreate = "create"
+	BulkOpUpdate = "update"
+	BulkOpDelete = "delete"
+	BulkOpMove   = "move"
+)
+
+// Bulk operation statuses reported in domain.BulkOperationResult.
+const (
+	BulkStatusOK         = "ok"
+	BulkStatusError      = "error"
+	BulkStatusSkipped    = "skipped"
+	BulkStatusRolledBack = "rolled_back"
+)
+
+// MaxBulkOperations caps the number of operations accepted in one bulk request.
+const MaxBulkOperations = 1000
+
+// ErrIdempotencyConflict is returned when an idempotency key is reused with a different payload.
+var ErrIdempotencyConflict = storage.ConflictError("idempotency_conflict", "idempotency key reused with a different request")
+
+// BulkItems runs a batch of item operations in a single transaction and
+// reports the outcome of each one. In all-or-nothing mode the first failure
+// rolls back every change; with ContinueOnError each failed operation is rolled
+// back on its own savepoint and the remaining operations still run.
+//
+// The response of a committed batch is stored under its idempotency key, so a
+// retry with the same key and payload replays the stored result instead of
+// applying the operations twice.
+func (s *Store) BulkItems(ctx context.Context, in storage.BulkItemsInput) (*domain.BulkResult, error) {
+	if err := storage.RequireFields("database_id", in.DatabaseID, "idempotency_key", in.IdempotencyKey); err != nil {
+		return nil, err
+	}
+	if len(in.Operations) == 0 {
+		return nil, storage.InvalidField("operations", "must contain at least one operation")
+	}
+	if len(in.Operations) > MaxBulkOperations {
+		return nil, storage.InvalidField("operations", "has %d operations, exceeding the limit of %d", len(in.Operations), MaxBulkOperations)
+	}
+	hash, err := bulkRequestHash(in)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var storedHash, storedResponse string
+	err = tx.QueryRowContext(ctx, `SELECT request_hash, response FROM bulk_requests WHERE database_id = ? AND idempotency_key = ?`, in.DatabaseID, in.IdempotencyKey).
+		Scan(&storedHash, &storedResponse)
+	switch {
+	case err == nil:
+		if storedHash != hash {
+			return nil, ErrIdempotencyConflict
+		}
+		var replay domain.BulkResult
+		if err := json.Unmarshal([]byte(storedResponse), &replay); err != nil {
+			return nil, fmt.Errorf("decode stored bulk response: %w", err)
+		}
+		replay.Replayed = true
+		return &replay, nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, fmt.Errorf("load idempotency key: %w", err)
+	}
+	var exists int
+	if err := tx.QueryRowContext(ctx, `SELECT 1 FROM databases WHERE id = ?`, in.DatabaseID).Scan(&exists); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrDatabaseNotFound
+		}
+		return nil, fmt.Errorf("verify database: %w", err)
+	}
+
+	now := time.Now().UTC()
+	result := &domain.BulkResult{Results: make([]domain.BulkOperationResult, len(in.Operations))}
+	aborted := false
+	for idx, op := range in.Operations {
+		res := &result.Results[idx]
+		res.Index = idx
+		res.Op = op.Op
+		res.ItemID = op.ItemID
+		if aborted {
+			res.Status = BulkStatusSkipped
+			continue
+		}
+		var sp txSavepoint
+		if in.ContinueOnError {
+			if sp, err = tx.savepoint(ctx, "bulk_op"); err != nil {
+				return nil, err
+			}
+		}
+		item, opErr := s.applyBulkOperation(ctx, tx, in.DatabaseID, in.Author, op, now)
+		if opErr != nil {
+			res.Status = BulkStatusError
+			res.Errors = bulkOperationErrors(opErr)
+			result.Failed++
+			if !in.ContinueOnError {
+				aborted = true
+				continue
+			}
+			if err := tx.rollbackTo(ctx, sp); err != nil {
+				return nil, err
+			}
+		} else {
+			res.Status = BulkStatusOK
+			res.Item = item
+			if item != nil {
+				res.ItemID = item.ID
+			}
+			result.Succeeded++
+		}
+		if in.ContinueOnError {
+			if err := tx.release(ctx, sp); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if aborted {
+		for idx := range result.Results {
+			if result.Results[idx].Status == BulkStatusOK {
+				result.Results[idx].Status = BulkStatusRolledBack
+				result.Results[idx].Item = nil
+			}
+		}
+		result.Succeeded = 0
+		return result, nil
+	}
+	result.Committed = true
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("encode bulk response: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO bulk_requests(database_id, idempotency_key, request_hash, response, created_at) VALUES(?, ?, ?, ?, ?)`,
+		in.DatabaseID, in.IdempotencyKey, hash, string(encoded), now); err != nil {
+		return nil, fmt.Errorf("store idempotency key: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit bulk: %w", err)
+	}
+	return result, nil
+}
+
+func (s *Store) applyBulkOperation(ctx context.Context, q queryer, databaseID, author string, op storage.BulkItemOperation, now time.Time) (*domain.DatabaseItem, error) {
+	switch op.Op {
+	case BulkOpCreate:
+		in := storage.CreateDatabaseItemInput{
+			DatabaseID: databaseID,
+			TemplateID: op.TemplateID,
+			Page:       storage.CreatePageInput{Slug: op.Slug, Tags: op.Tags},
+			Values:     op.Values,
+		}
+		if op.Title != nil {
+			in.Page.Title = *op.Title
+		}
+		if op.Summary != nil {
+			in.Page.Summary = *op.Summary
+		}
+		if op.Content != nil {
+			in.Page.Content = *op.Content
+		}
+		if op.Position != nil {
+			in.Position = *op.Position
+		}
+		return s.createDatabaseItem(ctx, q, in, now)
+	case BulkOpUpdate:
+		if op.ItemID == "" {
+			return nil, storage.InvalidField("item_id", "is required")
+		}
+		return s.reviseDatabaseItem(ctx, q, storage.UpdateDatabaseItemInput{
+			DatabaseID:      databaseID,
+			ItemID:          op.ItemID,
+			Title:           op.Title,
+			Summary:         op.Summary,
+			Content:         op.Content,
+			Tags:            op.Tags,
+			Archived:        op.Archived,
+			Values:          op.Values,
+			Author:          author,
+			ExpectedVersion: op.Version,
+		}, now)
+	case BulkOpDelete:
+		if op.ItemID == "" {
+			return nil, storage.InvalidField("item_id", "is required")
+		}
+		return nil, deleteDatabaseItem(ctx, q, databaseID, op.ItemID)
+	case BulkOpMove:
+		if op.ItemID == "" {
+			return nil, storage.InvalidField("item_id", "is required")
+		}
+		return moveDatabaseItem(ctx, q, storage.MoveDatabaseItemInput{
+			DatabaseID: databaseID,
+			ItemID:     op.ItemID,
+			BeforeID:   op.BeforeID,
+			AfterID:    op.AfterID,
+		}, now)
+	default:
+		return nil, storage.InvalidField("op", "unknown operation %q", op.Op)
+	}
+}
+
+// bulkOperationErrors reports a failed operation by the stable code, message
+// and fields of its classified error. Anything else is reported as an internal
+// error without leaking its text.
+func bulkOperationErrors(err error) []domain.BulkOperationError {
+	storeErr := storage.AsError(err)
+	if storeErr == nil {
+		return []domain.BulkOperationError{{Code: "internal", Message: "internal error"}}
+	}
+	if len(storeErr.Fields) > 0 {
+		out := make([]domain.BulkOperationError, len(storeErr.Fields))
+		for i, f := range storeErr.Fields {
+			out[i] = domain.BulkOperationError{Code: storeErr.Code, Field: f.Field, Message: f.Message}
+		}
+		return out
+	}
+	return []domain.BulkOperationError{{Code: storeErr.Code, Message: storeErr.Message}}
+}
+
+func bulkRequestHash(in storage.BulkItemsInput) (string, error) {
+	payload, err := json.Marshal(struct {
+		ContinueOnError bool
+		Operations      []storage.BulkItemOperation
+	}{in.ContinueOnError, in.Operations})
+	if err != nil {
+		return "", fmt.Errorf("encode bulk request: %w", err)
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,215 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+func newBulkTestDatabase(t *testing.T, store *Store) *domain.Database {
+	t.Helper()
+	db, err := store.CreateDatabase(context.Background(), storage.CreateDatabaseInput{
+		Slug:  "tasks",
+		Title: "Tasks",
+		Properties: []storage.DatabasePropertyInput{
+			{Name: "Status", Slug: "status", Type: domain.PropertyTypeSelect},
+			{Name: "Points", Slug: "points", Type: domain.PropertyTypeNumber},
+		},
+		Views: []storage.DatabaseViewInput{{Name: "Table", Type: domain.ViewTypeTable}},
+	})
+	require.NoError(t, err)
+	return db
+}
+
+func strPtr(v string) *string { return &v }
+
+func intPtr(v int) *int { return &v }
+
+func TestStoreBulkItemsAtomic(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db := newBulkTestDatabase(t, store)
+
+	existing, err := store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
+		DatabaseID: db.ID,
+		Page:       storage.CreatePageInput{Slug: "old", Title: "Old"},
+		Values:     map[string]any{"status": "Todo", "points": 3},
+	})
+	require.NoError(t, err)
+	doomed, err := store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{DatabaseID: db.ID, Page: storage.CreatePageInput{Slug: "doomed", Title: "Doomed"}})
+	require.NoError(t, err)
+
+	result, err := store.BulkItems(ctx, storage.BulkItemsInput{
+		DatabaseID:     db.ID,
+		IdempotencyKey: "batch-1",
+		Operations: []storage.BulkItemOperation{
+			{Op: BulkOpCreate, Slug: "new", Title: strPtr("New"), Values: map[string]any{"status": "Doing"}, Position: intPtr(2)},
+			{Op: BulkOpUpdate, ItemID: existing.ID, Title: strPtr("Renamed"), Values: map[string]any{"status": "Done", "points": nil}},
+			{Op: BulkOpMove, ItemID: existing.ID, AfterID: doomed.ID},
+			{Op: BulkOpDelete, ItemID: doomed.ID},
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, result.Committed)
+	require.Equal(t, 4, result.Succeeded)
+	require.Equal(t, BulkStatusOK, result.Results[0].Status)
+	require.NotEmpty(t, result.Results[0].ItemID)
+
+	items, err := store.ListViewItems(ctx, db.ID, db.Views[0].ID)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	require.Equal(t, "Renamed", items[0].Page.Title)
+	require.Equal(t, "New", items[1].Page.Title)
+	require.Equal(t, "Done", items[0].PropertyMap["status"].RawValue)
+	_, hasPoints := items[0].PropertyMap["points"]
+	require.False(t, hasPoints)
+
+	_, err = store.GetPage(ctx, doomed.Page.ID)
+	require.ErrorIs(t, err, storage.ErrPageNotFound)
+}
+
+func TestStoreBulkItemsAtomicRollsBackOnFailure(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db := newBulkTestDatabase(t, store)
+
+	result, err := store.BulkItems(ctx, storage.BulkItemsInput{
+		DatabaseID:     db.ID,
+		IdempotencyKey: "batch-1",
+		Operations: []storage.BulkItemOperation{
+			{Op: BulkOpCreate, Slug: "a", Title: strPtr("A")},
+			{Op: BulkOpUpdate, ItemID: "missing", Title: strPtr("X")},
+			{Op: BulkOpCreate, Slug: "b", Title: strPtr("B")},
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, result.Committed)
+	require.Equal(t, BulkStatusRolledBack, result.Results[0].Status)
+	require.Equal(t, BulkStatusError, result.Results[1].Status)
+	require.Equal(t, []domain.BulkOperationError{{Code: "item_not_found", Message: "item not found"}}, result.Results[1].Errors)
+	require.Equal(t, BulkStatusSkipped, result.Results[2].Status)
+
+	items, err := store.ListViewItems(ctx, db.ID, db.Views[0].ID)
+	require.NoError(t, err)
+	require.Empty(t, items)
+
+	// A failed batch is not recorded, so the same key can be retried after a fix.
+	retry, err := store.BulkItems(ctx, storage.BulkItemsInput{
+		DatabaseID:     db.ID,
+		IdempotencyKey: "batch-1",
+		Operations:     []storage.BulkItemOperation{{Op: BulkOpCreate, Slug: "a", Title: strPtr("A")}},
+	})
+	require.NoError(t, err)
+	require.True(t, retry.Committed)
+}
+
+func TestStoreBulkItemsContinueOnError(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db := newBulkTestDatabase(t, store)
+
+	result, err := store.BulkItems(ctx, storage.BulkItemsInput{
+		DatabaseID:      db.ID,
+		IdempotencyKey:  "batch-1",
+		ContinueOnError: true,
+		Operations: []storage.BulkItemOperation{
+			{Op: BulkOpCreate, Slug: "a", Title: strPtr("A")},
+			{Op: BulkOpCreate, Slug: "b", Title: strPtr("B"), Values: map[string]any{"unknown": 1}},
+			{Op: BulkOpCreate, Slug: "c", Title: strPtr("C")},
+			{Op: "explode"},
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, result.Committed)
+	require.Equal(t, 2, result.Succeeded)
+	require.Equal(t, 2, result.Failed)
+	require.Equal(t, BulkStatusError, result.Results[1].Status)
+	require.Equal(t, BulkStatusError, result.Results[3].Status)
+	require.Equal(t, "values.unknown", result.Results[1].Errors[0].Field)
+	require.Equal(t, []domain.BulkOperationError{{Code: "validation_failed", Field: "op", Message: `unknown operation "explode"`}}, result.Results[3].Errors)
+
+	items, err := store.ListViewItems(ctx, db.ID, db.Views[0].ID)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+
+	var orphanPages int
+	require.NoError(t, store.db.QueryRow(`SELECT COUNT(1) FROM pages WHERE slug = 'b'`).Scan(&orphanPages))
+	require.Zero(t, orphanPages)
+}
+
+func TestStoreBulkItemsContinueOnErrorDropsNotificationsOfFailedOps(t *testing.T) {
+	store := newTestStore(t)
+	ctx := storage.WithAuditInfo(context.Background(), storage.AuditInfo{Actor: "ana"})
+	db, err := store.CreateDatabase(ctx, storage.CreateDatabaseInput{
+		Slug: "tasks", Title: "Tasks",
+		Properties: []storage.DatabasePropertyInput{{Slug: "owner", Name: "Owner", Type: domain.PropertyTypePerson}},
+	})
+	require.NoError(t, err)
+	item, err := store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{DatabaseID: db.ID, Page: storage.CreatePageInput{Title: "Task"}})
+	require.NoError(t, err)
+	// Fail the update after it has notified, when its page revision is written.
+	_, err = store.db.ExecContext(ctx, `CREATE TEMP TRIGGER reject_revision BEFORE INSERT ON page_revisions WHEN NEW.title = 'Broken'
+BEGIN SELECT RAISE(ABORT, 'revision rejected'); END`)
+	require.NoError(t, err)
+	bo, cancel := store.SubscribeNotifications("bo")
+	defer cancel()
+
+	result, err := store.BulkItems(ctx, storage.BulkItemsInput{
+		DatabaseID:      db.ID,
+		IdempotencyKey:  "batch-1",
+		ContinueOnError: true,
+		Operations: []storage.BulkItemOperation{
+			{Op: BulkOpUpdate, ItemID: item.ID, Title: strPtr("Broken"), Content: strPtr("@bo have a look"), Values: map[string]any{"owner": []any{"carl"}}},
+			{Op: BulkOpCreate, Title: strPtr("Other"), Values: map[string]any{"owner": []any{"dee"}}},
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, result.Committed)
+	require.Equal(t, BulkStatusError, result.Results[0].Status)
+	require.Equal(t, []domain.BulkOperationError{{Code: "internal", Message: "internal error"}}, result.Results[0].Errors)
+	require.Equal(t, BulkStatusOK, result.Results[1].Status)
+
+	require.Empty(t, inbox(t, store, "bo"))
+	require.Empty(t, inbox(t, store, "carl"))
+	require.Len(t, inbox(t, store, "dee"), 1)
+	select {
+	case n := <-bo:
+		t.Fatalf("notification of a failed operation was pushed: %+v", n)
+	default:
+	}
+}
+
+func TestStoreBulkItemsIdempotency(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db := newBulkTestDatabase(t, store)
+
+	input := storage.BulkItemsInput{
+		DatabaseID:     db.ID,
+		IdempotencyKey: "import-42",
+		Operations:     []storage.BulkItemOperation{{Op: BulkOpCreate, Slug: "a", Title: strPtr("A")}},
+	}
+	first, err := store.BulkItems(ctx, input)
+	require.NoError(t, err)
+	require.False(t, first.Replayed)
+
+	second, err := store.BulkItems(ctx, input)
+	require.NoError(t, err)
+	require.True(t, second.Replayed)
+	require.Equal(t, first.Results[0].ItemID, second.Results[0].ItemID)
+
+	items, err := store.ListViewItems(ctx, db.ID, db.Views[0].ID)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+
+	input.Operations[0].Slug = "different"
+	_, err = store.BulkItems(ctx, input)
+	require.ErrorIs(t, err, ErrIdempotencyConflict)
+
+	_, err = store.BulkItems(ctx, storage.BulkItemsInput{DatabaseID: db.ID, Operations: input.Operations})
+	require.Error(t, err)
+}
@@ -0,0 +1,604 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// Comment errors.
+var (
+	ErrCommentThreadNotFound = storage.NotFoundError("comment_thread_not_found", "comment thread not found")
+	ErrCommentNotFound       = storage.NotFoundError("comment_not_found", "comment not found")
+	ErrNotCommentAuthor      = storage.ForbiddenError("not_comment_author", "only the author of a comment can change it")
+)
+
+// Comment thread list filters.
+const (
+	CommentThreadsAll      = "all"
+	CommentThreadsOpen     = "open"
+	CommentThreadsResolved = "resolved"
+)
+
+// anchorContextRunes is how much text around an anchor is kept to tell
+// repeated occurrences of its quote apart when re-anchoring.
+const anchorContextRunes = 32
+
+// Anchored threads remember the quoted text and its surroundings. Whenever a
+// page's content is rewritten the anchors are searched for in the new text
+// (see reanchorCommentThreads); a thread whose quote is gone is marked
+// detached, and re-attaches if the quote comes back.
+
+// ListCommentThreads returns the threads on a page with their comments,
+// oldest first. status is one of the CommentThreads filters; empty means all.
+func (s *Store) ListCommentThreads(ctx context.Context, pageID, status string) ([]domain.CommentThread, error) {
+	where := `page_id = ?`
+	switch status {
+	case "", CommentThreadsAll:
+	case CommentThreadsOpen:
+		where += ` AND resolved_at IS NULL`
+	case CommentThreadsResolved:
+		where += ` AND resolved_at IS NOT NULL`
+	default:
+		return nil, storage.InvalidField("status", "must be all, open or resolved")
+	}
+	page, err := loadPageRow(ctx, s.reader, pageID)
+	if err != nil {
+		return nil, err
+	}
+	if page == nil {
+		return nil, storage.ErrPageNotFound
+	}
+	return loadCommentThreads(ctx, s.reader, where, pageID)
+}
+
+// GetCommentThread returns a thread on a page with its comments.
+func (s *Store) GetCommentThread(ctx context.Context, pageID, threadID string) (*domain.CommentThread, error) {
+	return loadCommentThread(ctx, s.reader, pageID, threadID)
+}
+
+// CreateCommentThread starts a thread with its first comment. An anchored
+// thread must cover a non-empty range of the page content.
+func (s *Store) CreateCommentThread(ctx context.Context, in storage.CreateCommentThreadInput) (*domain.CommentThread, error) {
+	if err := storage.RequireFields("author", in.Author, "body", in.Body); err != nil {
+		return nil, err
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	page, err := loadPageRow(ctx, tx, in.PageID)
+	if err != nil {
+		return nil, err
+	}
+	if page == nil {
+		return nil, storage.ErrPageNotFound
+	}
+	now := time.Now().UTC()
+	threadID := uuid.NewString()
+	var anchor *commentAnchor
+	if in.Anchor != nil {
+		content := []rune(page.Content)
+		if in.Anchor.Start < 0 || in.Anchor.End <= in.Anchor.Start || in.Anchor.End > len(content) {
+			return nil, storage.InvalidField("anchor", "must be a non-empty range within the page content (0 to %d)", len(content))
+		}
+		anchor = newCommentAnchor(content, in.Anchor.Start, in.Anchor.End)
+	}
+	if anchor != nil {
+		_, err = tx.ExecContext(ctx, `INSERT INTO comment_threads(id, page_id, anchor_start, anchor_end, anchor_quote, anchor_prefix, anchor_suffix, created_by, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, threadID, in.PageID, anchor.start, anchor.end, anchor.quote, anchor.prefix, anchor.suffix, in.Author, now, now)
+	} else {
+		_, err = tx.ExecContext(ctx, `INSERT INTO comment_threads(id, page_id, created_by, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`,
+			threadID, in.PageID, in.Author, now, now)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("insert comment thread: %w", err)
+	}
+	comment, err := insertComment(ctx, tx, threadID, in.Author, in.Body, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := notifyComment(ctx, tx, in.PageID, comment, comment.Mentions, nil); err != nil {
+		return nil, err
+	}
+	thread, err := loadCommentThread(ctx, tx, in.PageID, threadID)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordAudit(ctx, tx, storage.AuditActionCreate, storage.AuditEntityCommentThread, threadID, nil, thread, now); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit comment thread: %w", err)
+	}
+	return thread, nil
+}
+
+// ReplyToCommentThread adds a comment to a thread and returns the thread.
+// Replying does not reopen a resolved thread.
+func (s *Store) ReplyToCommentThread(ctx context.Context, in storage.ReplyToCommentThreadInput) (*domain.CommentThread, error) {
+	if err := storage.RequireFields("author", in.Author, "body", in.Body); err != nil {
+		return nil, err
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	before, err := loadCommentThread(ctx, tx, in.PageID, in.ThreadID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	comment, err := insertComment(ctx, tx, in.ThreadID, in.Author, in.Body, now)
+	if err != nil {
+		return nil, err
+	}
+	participants := []string{before.CreatedBy}
+	for _, earlier := range before.Comments {
+		participants = append(participants, earlier.Author)
+	}
+	if err := notifyComment(ctx, tx, in.PageID, comment, comment.Mentions, participants); err != nil {
+		return nil, err
+	}
+	if err := touchCommentThread(ctx, tx, in.ThreadID, now); err != nil {
+		return nil, err
+	}
+	if err := recordAudit(ctx, tx, storage.AuditActionCreate, storage.AuditEntityComment, comment.ID, nil, comment, now); err != nil {
+		return nil, err
+	}
+	thread, err := loadCommentThread(ctx, tx, in.PageID, in.ThreadID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit comment: %w", err)
+	}
+	return thread, nil
+}
+
+// UpdateComment replaces the body of a comment. Only its author may edit it.
+func (s *Store) UpdateComment(ctx context.Context, in storage.UpdateCommentInput) (*domain.Comment, error) {
+	if err := storage.RequireFields("author", in.Author, "body", in.Body); err != nil {
+		return nil, err
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	before, err := loadOwnComment(ctx, tx, in.PageID, in.ThreadID, in.CommentID, in.Author)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if _, err := tx.ExecContext(ctx, `UPDATE comments SET body = ?, edited_at = ? WHERE id = ?`, in.Body, now, in.CommentID); err != nil {
+		return nil, fmt.Errorf("update comment: %w", err)
+	}
+	if err := replaceCommentMentions(ctx, tx, in.CommentID, in.Body); err != nil {
+		return nil, err
+	}
+	if err := touchCommentThread(ctx, tx, in.ThreadID, now); err != nil {
+		return nil, err
+	}
+	after, err := loadComment(ctx, tx, in.ThreadID, in.CommentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordAudit(ctx, tx, storage.AuditActionUpdate, storage.AuditEntityComment, in.CommentID, before, after, now); err != nil {
+		return nil, err
+	}
+	if err := notifyComment(ctx, tx, in.PageID, after, storage.NewMentions(before.Body, after.Body), nil); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit comment: %w", err)
+	}
+	return after, nil
+}
+
+// DeleteComment removes a comment. Only its author may delete it. Deleting
+// the last comment of a thread deletes the thread.
+func (s *Store) DeleteComment(ctx context.Context, in storage.DeleteCommentInput) error {
+	if err := storage.RequireFields("author", in.Author); err != nil {
+		return err
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	thread, err := loadCommentThread(ctx, tx, in.PageID, in.ThreadID)
+	if err != nil {
+		return err
+	}
+	before, err := loadOwnComment(ctx, tx, in.PageID, in.ThreadID, in.CommentID, in.Author)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	statements := []struct {
+		query string
+		what  string
+	}{
+		{`DELETE FROM comment_mentions WHERE comment_id = ?`, "comment mentions"},
+		{`DELETE FROM comments WHERE id = ?`, "comment"},
+	}
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt.query, in.CommentID); err != nil {
+			return fmt.Errorf("delete %s: %w", stmt.what, err)
+		}
+	}
+	if err := recordAudit(ctx, tx, storage.AuditActionDelete, storage.AuditEntityComment, in.CommentID, before, nil, now); err != nil {
+		return err
+	}
+	if len(thread.Comments) == 1 {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM comment_threads WHERE id = ?`, in.ThreadID); err != nil {
+			return fmt.Errorf("delete comment thread: %w", err)
+		}
+		if err := recordAudit(ctx, tx, storage.AuditActionDelete, storage.AuditEntityCommentThread, in.ThreadID, thread, nil, now); err != nil {
+			return err
+		}
+	} else if err := touchCommentThread(ctx, tx, in.ThreadID, now); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit comment delete: %w", err)
+	}
+	return nil
+}
+
+// ResolveCommentThread resolves a thread, or reopens it when in.Resolved is
+// false. Anyone may resolve or reopen a thread; doing so again is a no-op.
+func (s *Store) ResolveCommentThread(ctx context.Context, in storage.ResolveCommentThreadInput) (*domain.CommentThread, error) {
+	if err := storage.RequireFields("author", in.Author); err != nil {
+		return nil, err
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	before, err := loadCommentThread(ctx, tx, in.PageID, in.ThreadID)
+	if err != nil {
+		return nil, err
+	}
+	if before.Resolved == in.Resolved {
+		return before, nil
+	}
+	now := time.Now().UTC()
+	if in.Resolved {
+		_, err = tx.ExecContext(ctx, `UPDATE comment_threads SET resolved_at = ?, resolved_by = ?, updated_at = ? WHERE id = ?`, now, in.Author, now, in.ThreadID)
+	} else {
+		_, err = tx.ExecContext(ctx, `UPDATE comment_threads SET resolved_at = NULL, resolved_by = NULL, updated_at = ? WHERE id = ?`, now, in.ThreadID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("resolve comment thread: %w", err)
+	}
+	after, err := loadCommentThread(ctx, tx, in.PageID, in.ThreadID)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordAudit(ctx, tx, storage.AuditActionUpdate, storage.AuditEntityCommentThread, in.ThreadID, before, after, now); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit comment thread: %w", err)
+	}
+	return after, nil
+}
+
+// notifyComment tells the users mentioned in a comment about it, and the
+// participants of its thread who were not mentioned about the reply.
+func notifyComment(ctx context.Context, q queryer, pageID string, comment *domain.Comment, mentioned, participants []string) error {
+	n := domain.Notification{
+		Source: storage.NotificationSourceMention, Actor: comment.Author, PageID: pageID, ThreadID: comment.ThreadID, CommentID: comment.ID,
+		Excerpt: comment.Body, CreatedAt: comment.CreatedAt,
+	}
+	if comment.EditedAt != nil {
+		n.CreatedAt = *comment.EditedAt
+	}
+	if err := notify(ctx, q, n, mentioned); err != nil {
+		return err
+	}
+	var others []string
+	for _, name := range participants {
+		if !slices.Contains(mentioned, name) && !slices.Contains(others, name) {
+			others = append(others, name)
+		}
+	}
+	n.Source = storage.NotificationSourceReply
+	return notify(ctx, q, n, others)
+}
+
+// loadCommentCounts counts the threads and comments on a page.
+func loadCommentCounts(ctx context.Context, q queryer, pageID string) (*domain.CommentCounts, error) {
+	var counts domain.CommentCounts
+	err := q.QueryRowContext(ctx, `SELECT COUNT(*), COALESCE(SUM(resolved_at IS NULL), 0),
+    (SELECT COUNT(*) FROM comments c JOIN comment_threads t ON t.id = c.thread_id WHERE t.page_id = ?)
+FROM comment_threads WHERE page_id = ?`, pageID, pageID).Scan(&counts.Threads, &counts.OpenThreads, &counts.Comments)
+	if err != nil {
+		return nil, fmt.Errorf("count comments: %w", err)
+	}
+	return &counts, nil
+}
+
+func insertComment(ctx context.Context, q queryer, threadID, author, body string, now time.Time) (*domain.Comment, error) {
+	comment := &domain.Comment{
+		ID:        uuid.NewString(),
+		ThreadID:  threadID,
+		Author:    author,
+		Body:      body,
+		Mentions:  storage.Mentions(body),
+		CreatedAt: now,
+	}
+	if _, err := q.ExecContext(ctx, `INSERT INTO comments(id, thread_id, author, body, created_at) VALUES (?, ?, ?, ?, ?)`,
+		comment.ID, threadID, author, body, now); err != nil {
+		return nil, fmt.Errorf("insert comment: %w", err)
+	}
+	if err := insertCommentMentions(ctx, q, comment.ID, comment.Mentions); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+func replaceCommentMentions(ctx context.Context, q queryer, commentID, body string) error {
+	if _, err := q.ExecContext(ctx, `DELETE FROM comment_mentions WHERE comment_id = ?`, commentID); err != nil {
+		return fmt.Errorf("clear comment mentions: %w", err)
+	}
+	return insertCommentMentions(ctx, q, commentID, storage.Mentions(body))
+}
+
+func insertCommentMentions(ctx context.Context, q queryer, commentID string, mentions []string) error {
+	for _, name := range mentions {
+		if _, err := q.ExecContext(ctx, `INSERT OR IGNORE INTO comment_mentions(comment_id, mentioned) VALUES (?, ?)`, commentID, name); err != nil {
+			return fmt.Errorf("insert comment mention: %w", err)
+		}
+	}
+	return nil
+}
+
+func touchCommentThread(ctx context.Context, q queryer, threadID string, now time.Time) error {
+	if _, err := q.ExecContext(ctx, `UPDATE comment_threads SET updated_at = ? WHERE id = ?`, now, threadID); err != nil {
+		return fmt.Errorf("touch comment thread: %w", err)
+	}
+	return nil
+}
+
+// loadOwnComment returns a comment of the thread, or ErrNotCommentAuthor when
+// author did not write it.
+func loadOwnComment(ctx context.Context, q queryer, pageID, threadID, commentID, author string) (*domain.Comment, error) {
+	if _, err := loadCommentThread(ctx, q, pageID, threadID); err != nil {
+		return nil, err
+	}
+	comment, err := loadComment(ctx, q, threadID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.Author != author {
+		return nil, ErrNotCommentAuthor
+	}
+	return comment, nil
+}
+
+func loadCommentThread(ctx context.Context, q queryer, pageID, threadID string) (*domain.CommentThread, error) {
+	threads, err := loadCommentThreads(ctx, q, `page_id = ? AND id = ?`, pageID, threadID)
+	if err != nil {
+		return nil, err
+	}
+	if len(threads) == 0 {
+		return nil, ErrCommentThreadNotFound
+	}
+	return &threads[0], nil
+}
+
+// loadCommentThreads returns the threads matching where with their comments.
+func loadCommentThreads(ctx context.Context, q queryer, where string, args ...any) ([]domain.CommentThread, error) {
+	rows, err := q.QueryContext(ctx, `SELECT id, page_id, anchor_start, anchor_end, anchor_quote, detached, resolved_at, resolved_by, created_by, created_at, updated_at
+FROM comment_threads WHERE `+where+` ORDER BY created_at ASC, rowid ASC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query comment threads: %w", err)
+	}
+	threads := []domain.CommentThread{}
+	for rows.Next() {
+		var thread domain.CommentThread
+		var start, end sql.NullInt64
+		var quote, resolvedBy sql.NullString
+		var resolvedAt sql.NullTime
+		if err := rows.Scan(&thread.ID, &thread.PageID, &start, &end, &quote, &thread.Detached, &resolvedAt, &resolvedBy, &thread.CreatedBy, &thread.CreatedAt, &thread.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan comment thread: %w", err)
+		}
+		if quote.Valid {
+			thread.Anchor = &domain.CommentAnchor{Start: int(start.Int64), End: int(end.Int64), Quote: quote.String}
+		}
+		if resolvedAt.Valid {
+			thread.Resolved = true
+			thread.ResolvedAt = &resolvedAt.Time
+			thread.ResolvedBy = resolvedBy.String
+		}
+		threads = append(threads, thread)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i := range threads {
+		if threads[i].Comments, err = loadComments(ctx, q, `c.thread_id = ?`, threads[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return threads, nil
+}
+
+func loadComment(ctx context.Context, q queryer, threadID, commentID string) (*domain.Comment, error) {
+	comments, err := loadComments(ctx, q, `c.thread_id = ? AND c.id = ?`, threadID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if len(comments) == 0 {
+		return nil, ErrCommentNotFound
+	}
+	return &comments[0], nil
+}
+
+func loadComments(ctx context.Context, q queryer, where string, args ...any) ([]domain.Comment, error) {
+	rows, err := q.QueryContext(ctx, `SELECT c.id, c.thread_id, c.author, c.body, c.edited_at, c.created_at,
+    COALESCE((SELECT group_concat(mentioned, char(10)) FROM (SELECT mentioned FROM comment_mentions WHERE comment_id = c.id ORDER BY mentioned)), '')
+FROM comments c WHERE `+where+` ORDER BY c.created_at ASC, c.rowid ASC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query comments: %w", err)
+	}
+	defer rows.Close()
+	comments := []domain.Comment{}
+	for rows.Next() {
+		var comment domain.Comment
+		var editedAt sql.NullTime
+		var mentions string
+		if err := rows.Scan(&comment.ID, &comment.ThreadID, &comment.Author, &comment.Body, &editedAt, &comment.CreatedAt, &mentions); err != nil {
+			return nil, fmt.Errorf("scan comment: %w", err)
+		}
+		if editedAt.Valid {
+			comment.EditedAt = &editedAt.Time
+		}
+		comment.Mentions = []string{}
+		if mentions != "" {
+			comment.Mentions = strings.Split(mentions, "\n")
+		}
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
+
+// commentAnchor is the stored form of a thread's anchor.
+type commentAnchor struct {
+	start, end            int
+	quote, prefix, suffix string
+	detached              bool
+}
+
+// newCommentAnchor anchors content[start:end] with its surrounding context.
+func newCommentAnchor(content []rune, start, end int) *commentAnchor {
+	return &commentAnchor{
+		start:  start,
+		end:    end,
+		quote:  string(content[start:end]),
+		prefix: string(content[max(0, start-anchorContextRunes):start]),
+		suffix: string(content[end:min(len(content), end+anchorContextRunes)]),
+	}
+}
+
+// reanchor finds the anchor's quote in content. The occurrence whose
+// surroundings best match the remembered context wins, and the one nearest
+// the old position among equals, so text inserted before the anchor does not
+// hand it to another occurrence that now sits where it was. It returns a
+// detached copy of the anchor when the quote no longer occurs.
+func reanchor(anchor commentAnchor, content string) commentAnchor {
+	best, bestScore, bestDistance := -1, -1, 0
+	for offset := 0; ; {
+		i := strings.Index(content[offset:], anchor.quote)
+		if i < 0 {
+			break
+		}
+		byteStart := offset + i
+		start := utf8.RuneCountInString(content[:byteStart])
+		score := commonSuffix(anchor.prefix, content[:byteStart]) + commonPrefix(anchor.suffix, content[byteStart+len(anchor.quote):])
+		distance := start - anchor.start
+		if distance < 0 {
+			distance = -distance
+		}
+		if score > bestScore || (score == bestScore && distance < bestDistance) {
+			best, bestScore, bestDistance = start, score, distance
+		}
+		_, size := utf8.DecodeRuneInString(content[byteStart:])
+		offset = byteStart + size
+	}
+	if best < 0 {
+		anchor.detached = true
+		return anchor
+	}
+	return *newCommentAnchor([]rune(content), best, best+utf8.RuneCountInString(anchor.quote))
+}
+
+// commonSuffix counts the runes a and b end with in common.
+func commonSuffix(a, b string) int {
+	n := 0
+	for a != "" && b != "" {
+		ra, sa := utf8.DecodeLastRuneInString(a)
+		rb, sb := utf8.DecodeLastRuneInString(b)
+		if ra != rb {
+			break
+		}
+		a, b = a[:len(a)-sa], b[:len(b)-sb]
+		n++
+	}
+	return n
+}
+
+// commonPrefix counts the runes a and b start with in common.
+func commonPrefix(a, b string) int {
+	n := 0
+	for a != "" && b != "" {
+		ra, sa := utf8.DecodeRuneInString(a)
+		rb, sb := utf8.DecodeRuneInString(b)
+		if ra != rb {
+			break
+		}
+		a, b = a[sa:], b[sb:]
+		n++
+	}
+	return n
+}
+
+// reanchorCommentThreads moves the anchored threads of a page onto its new
+// content, detaching those whose quote is gone. It must be called whenever a
+// page's content changes.
+func reanchorCommentThreads(ctx context.Context, q queryer, pageID, content string) error {
+	rows, err := q.QueryContext(ctx, `SELECT id, anchor_start, anchor_end, anchor_quote, anchor_prefix, anchor_suffix, detached
+FROM comment_threads WHERE page_id = ? AND anchor_quote IS NOT NULL`, pageID)
+	if err != nil {
+		return fmt.Errorf("query comment anchors: %w", err)
+	}
+	type anchored struct {
+		id     string
+		anchor commentAnchor
+	}
+	var threads []anchored
+	for rows.Next() {
+		var t anchored
+		if err := rows.Scan(&t.id, &t.anchor.start, &t.anchor.end, &t.anchor.quote, &t.anchor.prefix, &t.anchor.suffix, &t.anchor.detached); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan comment anchor: %w", err)
+		}
+		threads = append(threads, t)
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, t := range threads {
+		moved := reanchor(t.anchor, content)
+		if moved == t.anchor {
+			continue
+		}
+		if _, err := q.ExecContext(ctx, `UPDATE comment_threads SET anchor_start = ?, anchor_end = ?, anchor_prefix = ?, anchor_suffix = ?, detached = ? WHERE id = ?`,
+			moved.start, moved.end, moved.prefix, moved.suffix, boolToInt(moved.detached), t.id); err != nil {
+			return fmt.Errorf("update comment anchor: %w", err)
+		}
+	}
+	return nil
+}
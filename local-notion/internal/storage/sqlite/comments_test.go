@@ -0,0 +1,177 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+func TestCommentThreadLifecycle(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	page, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "spec", Title: "Spec", Content: "Ship it on Friday."})
+	require.NoError(t, err)
+
+	thread, err := store.CreateCommentThread(ctx, storage.CreateCommentThreadInput{
+		PageID: page.ID, Author: "ana", Body: "@bo can we make Friday? cc @carl.",
+		Anchor: &storage.CommentAnchorInput{Start: 11, End: 17},
+	})
+	require.NoError(t, err)
+	require.Equal(t, &domain.CommentAnchor{Start: 11, End: 17, Quote: "Friday"}, thread.Anchor)
+	require.Equal(t, "ana", thread.CreatedBy)
+	require.Len(t, thread.Comments, 1)
+	require.Equal(t, []string{"bo", "carl"}, thread.Comments[0].Mentions)
+
+	thread, err = store.ReplyToCommentThread(ctx, storage.ReplyToCommentThreadInput{PageID: page.ID, ThreadID: thread.ID, Author: "bo", Body: "Yes."})
+	require.NoError(t, err)
+	require.Len(t, thread.Comments, 2)
+	require.Equal(t, "bo", thread.Comments[1].Author)
+	require.Equal(t, []string{}, thread.Comments[1].Mentions)
+
+	_, err = store.CreateCommentThread(ctx, storage.CreateCommentThreadInput{PageID: page.ID, Author: "carl", Body: "Looks good overall."})
+	require.NoError(t, err)
+
+	got, err := store.GetPage(ctx, page.ID)
+	require.NoError(t, err)
+	require.Equal(t, &domain.CommentCounts{Threads: 2, OpenThreads: 2, Comments: 3}, got.CommentCounts)
+
+	thread, err = store.ResolveCommentThread(ctx, storage.ResolveCommentThreadInput{PageID: page.ID, ThreadID: thread.ID, Author: "ana", Resolved: true})
+	require.NoError(t, err)
+	require.True(t, thread.Resolved)
+	require.Equal(t, "ana", thread.ResolvedBy)
+	open, err := store.ListCommentThreads(ctx, page.ID, CommentThreadsOpen)
+	require.NoError(t, err)
+	require.Len(t, open, 1)
+	resolved, err := store.ListCommentThreads(ctx, page.ID, CommentThreadsResolved)
+	require.NoError(t, err)
+	require.Len(t, resolved, 1)
+	require.Equal(t, thread.ID, resolved[0].ID)
+	_, err = store.ListCommentThreads(ctx, page.ID, "stale")
+	require.ErrorIs(t, err, storage.ErrValidation)
+
+	thread, err = store.ResolveCommentThread(ctx, storage.ResolveCommentThreadInput{PageID: page.ID, ThreadID: thread.ID, Author: "bo"})
+	require.NoError(t, err)
+	require.False(t, thread.Resolved)
+	require.Nil(t, thread.ResolvedAt)
+
+	_, err = store.ReplyToCommentThread(ctx, storage.ReplyToCommentThreadInput{PageID: "missing", ThreadID: thread.ID, Author: "bo", Body: "Hi"})
+	require.ErrorIs(t, err, ErrCommentThreadNotFound)
+	_, err = store.CreateCommentThread(ctx, storage.CreateCommentThreadInput{PageID: page.ID, Body: "Anonymous"})
+	require.ErrorIs(t, err, storage.ErrValidation)
+	_, err = store.CreateCommentThread(ctx, storage.CreateCommentThreadInput{PageID: page.ID, Author: "ana", Body: "Out of range", Anchor: &storage.CommentAnchorInput{Start: 5, End: 40}})
+	require.ErrorIs(t, err, storage.ErrValidation)
+}
+
+func TestCommentsAreDeletedWithTheirPage(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db, err := store.CreateDatabase(ctx, storage.CreateDatabaseInput{Slug: "tasks", Title: "Tasks"})
+	require.NoError(t, err)
+	item, err := store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
+		DatabaseID: db.ID,
+		Page:       storage.CreatePageInput{Slug: "task", Title: "Task", Content: "Due soon"},
+	})
+	require.NoError(t, err)
+	_, err = store.CreateCommentThread(ctx, storage.CreateCommentThreadInput{
+		PageID: item.Page.ID, Author: "ana", Body: "@bo when?", Anchor: &storage.CommentAnchorInput{Start: 4, End: 8},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, store.DeleteDatabaseItem(ctx, db.ID, item.ID))
+	var remaining int
+	require.NoError(t, store.db.QueryRowContext(ctx, `SELECT (SELECT COUNT(*) FROM comment_threads) + (SELECT COUNT(*) FROM comments) + (SELECT COUNT(*) FROM comment_mentions)`).Scan(&remaining))
+	require.Zero(t, remaining)
+}
+
+func TestCommentsCanOnlyBeChangedByTheirAuthor(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	page, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "notes", Title: "Notes"})
+	require.NoError(t, err)
+	thread, err := store.CreateCommentThread(ctx, storage.CreateCommentThreadInput{PageID: page.ID, Author: "ana", Body: "First"})
+	require.NoError(t, err)
+	first := thread.Comments[0]
+	thread, err = store.ReplyToCommentThread(ctx, storage.ReplyToCommentThreadInput{PageID: page.ID, ThreadID: thread.ID, Author: "bo", Body: "Second"})
+	require.NoError(t, err)
+	second := thread.Comments[1]
+
+	_, err = store.UpdateComment(ctx, storage.UpdateCommentInput{PageID: page.ID, ThreadID: thread.ID, CommentID: first.ID, Author: "bo", Body: "Hijacked"})
+	require.ErrorIs(t, err, ErrNotCommentAuthor)
+	require.ErrorIs(t, err, storage.ErrForbidden)
+	err = store.DeleteComment(ctx, storage.DeleteCommentInput{PageID: page.ID, ThreadID: thread.ID, CommentID: first.ID, Author: "bo"})
+	require.ErrorIs(t, err, ErrNotCommentAuthor)
+
+	edited, err := store.UpdateComment(ctx, storage.UpdateCommentInput{PageID: page.ID, ThreadID: thread.ID, CommentID: first.ID, Author: "ana", Body: "First, @bo"})
+	require.NoError(t, err)
+	require.Equal(t, "First, @bo", edited.Body)
+	require.Equal(t, []string{"bo"}, edited.Mentions)
+	require.NotNil(t, edited.EditedAt)
+
+	require.NoError(t, store.DeleteComment(ctx, storage.DeleteCommentInput{PageID: page.ID, ThreadID: thread.ID, CommentID: first.ID, Author: "ana"}))
+	thread, err = store.GetCommentThread(ctx, page.ID, thread.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{second.ID}, []string{thread.Comments[0].ID})
+
+	require.NoError(t, store.DeleteComment(ctx, storage.DeleteCommentInput{PageID: page.ID, ThreadID: thread.ID, CommentID: second.ID, Author: "bo"}))
+	_, err = store.GetCommentThread(ctx, page.ID, thread.ID)
+	require.ErrorIs(t, err, ErrCommentThreadNotFound)
+}
+
+func TestCommentAnchorsFollowContentEdits(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	page, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "plan", Title: "Plan", Content: "Step one: test. Step two: test again."})
+	require.NoError(t, err)
+	// The second "test" is anchored; the first one must not capture it.
+	thread, err := store.CreateCommentThread(ctx, storage.CreateCommentThreadInput{
+		PageID: page.ID, Author: "ana", Body: "Which suite?", Anchor: &storage.CommentAnchorInput{Start: 26, End: 30},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "test", thread.Anchor.Quote)
+
+	update := func(content string) *domain.CommentThread {
+		t.Helper()
+		current, err := store.GetPage(ctx, page.ID)
+		require.NoError(t, err)
+		_, err = store.UpdatePage(ctx, storage.UpdatePageInput{PageID: page.ID, Content: &content, ExpectedVersion: &current.Version})
+		require.NoError(t, err)
+		thread, err := store.GetCommentThread(ctx, page.ID, thread.ID)
+		require.NoError(t, err)
+		return thread
+	}
+
+	moved := update("Intro. Step one: test. Step two: test again.")
+	require.False(t, moved.Detached)
+	require.Equal(t, &domain.CommentAnchor{Start: 33, End: 37, Quote: "test"}, moved.Anchor)
+
+	detached := update("Intro. Step one: check. Step two: check again.")
+	require.True(t, detached.Detached)
+	require.Equal(t, moved.Anchor, detached.Anchor)
+
+	reattached := update("Step two: test again.")
+	require.False(t, reattached.Detached)
+	require.Equal(t, &domain.CommentAnchor{Start: 10, End: 14, Quote: "test"}, reattached.Anchor)
+}
+
+func TestReanchorPrefersMatchingContext(t *testing.T) {
+	content := []rune("ab x cd x ef")
+	anchor := *newCommentAnchor(content, 8, 9)
+	require.Equal(t, "ab x cd ", anchor.prefix)
+
+	moved := reanchor(anchor, "ab x ef x cd x ef")
+	require.Equal(t, 13, moved.start)
+	require.False(t, moved.detached)
+
+	unicode := *newCommentAnchor([]rune("héllo wörld"), 6, 11)
+	moved = reanchor(unicode, "¡héllo wörld!")
+	require.Equal(t, 7, moved.start)
+	require.Equal(t, "wörld", moved.quote)
+
+	gone := reanchor(unicode, "hello world")
+	require.True(t, gone.detached)
+	require.Equal(t, 6, gone.start)
+}
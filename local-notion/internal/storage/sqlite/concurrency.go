@@ -0,0 +1,16 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// touchDatabase bumps the version of a database whose properties or views
+// changed, since both are part of its representation.
+func touchDatabase(ctx context.Context, q queryer, databaseID string, now time.Time) error {
+	if _, err := q.ExecContext(ctx, `UPDATE databases SET version = version + 1, updated_at = ? WHERE id = ?`, now, databaseID); err != nil {
+		return fmt.Errorf("touch database: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/example/agents-playground/internal/storage"
+	"github.com/example/agents-playground/internal/storage/storagetest"
+)
+
+func TestStoreConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Store { return newTestStore(t) })
+}
@@ -0,0 +1,563 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// MaxImportRows caps the number of data rows accepted in one CSV import.
+const MaxImportRows = 10000
+
+// importPreviewRows is how many coerced rows a dry run echoes back.
+const importPreviewRows = 20
+
+// ErrInvalidImport wraps CSV imports that cannot be mapped onto the database.
+var ErrInvalidImport = storage.InvalidError("invalid_import", "invalid import")
+
+// ExportViewCSV writes the filtered items of a view as CSV: a Title column
+// followed by the view's displayed properties, or every property when the
+// view does not pick any.
+func (s *Store) ExportViewCSV(ctx context.Context, databaseID, viewID string, w io.Writer) error {
+	result, err := s.QueryView(ctx, databaseID, viewID)
+	if err != nil {
+		return err
+	}
+	db, err := s.loadDatabase(ctx, s.reader, databaseID)
+	if err != nil {
+		return err
+	}
+	if db == nil {
+		return storage.ErrDatabaseNotFound
+	}
+	columns := exportColumns(db.Properties, result.View.Display)
+	var relationIDs []string
+	for _, item := range result.Items {
+		for _, prop := range columns {
+			if prop.Type == domain.PropertyTypeRelation {
+				relationIDs = append(relationIDs, stringList(item.PropertyMap[prop.Slug].RawValue)...)
+			}
+		}
+	}
+	titles, err := pageTitles(ctx, s.reader, relationIDs)
+	if err != nil {
+		return err
+	}
+	out := csv.NewWriter(w)
+	header := []string{"Title"}
+	for _, prop := range columns {
+		header = append(header, prop.Name)
+	}
+	if err := out.Write(header); err != nil {
+		return fmt.Errorf("write csv header: %w", err)
+	}
+	for _, item := range result.Items {
+		record := []string{item.Page.Title}
+		for _, prop := range columns {
+			record = append(record, formatCSVValue(prop, item.PropertyMap[prop.Slug].RawValue, titles))
+		}
+		if err := out.Write(record); err != nil {
+			return fmt.Errorf("write csv row: %w", err)
+		}
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		return fmt.Errorf("flush csv: %w", err)
+	}
+	return nil
+}
+
+func exportColumns(props []domain.DatabaseProperty, display []string) []domain.DatabaseProperty {
+	if len(display) == 0 {
+		return props
+	}
+	var columns []domain.DatabaseProperty
+	for _, ref := range display {
+		for _, prop := range props {
+			if prop.ID == ref || prop.Slug == ref {
+				columns = append(columns, prop)
+				break
+			}
+		}
+	}
+	return columns
+}
+
+// formatCSVValue renders a stored value for a spreadsheet: lists are comma
+// separated, relations show page titles, checkboxes read Yes/No and date
+// ranges are written as start/end.
+func formatCSVValue(prop domain.DatabaseProperty, value any, titles map[string]string) string {
+	if value == nil {
+		if prop.Type == domain.PropertyTypeCheckbox {
+			return "No"
+		}
+		return ""
+	}
+	switch prop.Type {
+	case domain.PropertyTypeCheckbox:
+		if value == true {
+			return "Yes"
+		}
+		return "No"
+	case domain.PropertyTypeRelation:
+		ids := stringList(value)
+		names := make([]string, len(ids))
+		for i, id := range ids {
+			names[i] = id
+			if title, ok := titles[id]; ok {
+				names[i] = title
+			}
+		}
+		return strings.Join(names, ", ")
+	case domain.PropertyTypeDate:
+		if span, ok := value.(map[string]any); ok {
+			start, _ := span["start"].(string)
+			if end, _ := span["end"].(string); end != "" {
+				return start + "/" + end
+			}
+			return start
+		}
+	}
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case []any:
+		return strings.Join(stringList(v), ", ")
+	}
+	raw, _ := json.Marshal(value)
+	return string(raw)
+}
+
+func stringList(value any) []string {
+	switch v := value.(type) {
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, el := range v {
+			out = append(out, fmt.Sprint(el))
+		}
+		return out
+	case string:
+		return []string{v}
+	}
+	return nil
+}
+
+// ImportCSV maps CSV rows onto new database items. Every row is coerced with
+// the target property's type before anything is written, and the write runs
+// in one transaction: either all valid rows become items or, when rows fail
+// and SkipInvalidRows is off, nothing does. A dry run reports the outcome
+// without writing.
+func (s *Store) ImportCSV(ctx context.Context, in storage.ImportCSVInput) (*domain.ImportResult, error) {
+	if in.Reader == nil {
+		return nil, fmt.Errorf("%w: csv content required", ErrInvalidImport)
+	}
+	reader := csv.NewReader(in.Reader)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: parse csv: %v", ErrInvalidImport, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: csv has no header row", ErrInvalidImport)
+	}
+	if len(records)-1 > MaxImportRows {
+		return nil, fmt.Errorf("%w: %d rows exceeds limit of %d", ErrInvalidImport, len(records)-1, MaxImportRows)
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	db, err := s.loadDatabase(ctx, tx, in.DatabaseID)
+	if err != nil {
+		return nil, err
+	}
+	if db == nil {
+		return nil, storage.ErrDatabaseNotFound
+	}
+	plan, err := planImport(db, records[0], in)
+	if err != nil {
+		return nil, err
+	}
+	result := &domain.ImportResult{DryRun: in.DryRun, TotalRows: len(records) - 1, IgnoredColumns: plan.ignored}
+	coercer := &csvCoercer{ctx: ctx, q: tx, createOptions: in.CreateOptions, newOptions: make(map[string][]string)}
+	var valid []importRow
+	for idx, record := range records[1:] {
+		row, rowErrors := plan.coerce(coercer, idx+1, record)
+		if len(rowErrors) > 0 {
+			result.Errors = append(result.Errors, rowErrors...)
+			continue
+		}
+		valid = append(valid, row)
+		if len(result.Preview) < importPreviewRows {
+			result.Preview = append(result.Preview, domain.ImportPreviewRow{Row: row.line, Title: row.title, Values: row.values})
+		}
+	}
+	if len(coercer.newOptions) > 0 {
+		result.NewOptions = coercer.newOptions
+	}
+	result.Valid = len(valid)
+	if in.DryRun || (len(result.Errors) > 0 && !in.SkipInvalidRows) {
+		return result, nil
+	}
+	now := time.Now().UTC()
+	for _, prop := range db.Properties {
+		if added := coercer.newOptions[prop.Slug]; len(added) > 0 {
+			if err := addPropertyOptions(ctx, tx, prop, added, now); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, row := range valid {
+		slug, err := uniqueSlug(ctx, tx, "pages", storage.SlugOrDefault(row.title, "item"))
+		if err != nil {
+			return nil, err
+		}
+		item, err := s.createDatabaseItem(ctx, tx, storage.CreateDatabaseItemInput{
+			DatabaseID: db.ID,
+			Page:       storage.CreatePageInput{Slug: slug, Title: row.title},
+			Values:     row.values,
+		}, now)
+		if err != nil {
+			return nil, fmt.Errorf("import row %d: %w", row.line, err)
+		}
+		result.ItemIDs = append(result.ItemIDs, item.ID)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit import: %w", err)
+	}
+	result.Committed = true
+	result.Imported = len(valid)
+	return result, nil
+}
+
+type importRow struct {
+	line   int
+	title  string
+	values map[string]any
+}
+
+type importPlan struct {
+	titleColumn int
+	columns     map[int]domain.DatabaseProperty
+	headers     []string
+	ignored     []string
+}
+
+func planImport(db *domain.Database, header []string, in storage.ImportCSVInput) (*importPlan, error) {
+	plan := &importPlan{titleColumn: -1, columns: make(map[int]domain.DatabaseProperty), headers: header}
+	bySlug := make(map[string]domain.DatabaseProperty)
+	for _, prop := range db.Properties {
+		bySlug[prop.Slug] = prop
+	}
+	titleColumn := in.TitleColumn
+	if titleColumn == "" {
+		for _, name := range header {
+			if _, mapped := in.Mapping[name]; !mapped && (strings.EqualFold(name, "title") || strings.EqualFold(name, "name")) {
+				titleColumn = name
+				break
+			}
+		}
+	}
+	mappedSlugs := make(map[string]bool)
+	for idx, name := range header {
+		if name == titleColumn {
+			plan.titleColumn = idx
+			continue
+		}
+		var prop domain.DatabaseProperty
+		var ok bool
+		if in.Mapping != nil {
+			slug, mapped := in.Mapping[name]
+			if !mapped || slug == "" {
+				plan.ignored = append(plan.ignored, name)
+				continue
+			}
+			if prop, ok = bySlug[slug]; !ok {
+				return nil, fmt.Errorf("%w: column %q maps to unknown property %q", ErrInvalidImport, name, slug)
+			}
+		} else {
+			for _, candidate := range db.Properties {
+				if strings.EqualFold(candidate.Slug, name) || strings.EqualFold(candidate.Name, name) {
+					prop, ok = candidate, true
+					break
+				}
+			}
+			if !ok {
+				plan.ignored = append(plan.ignored, name)
+				continue
+			}
+		}
+		switch prop.Type {
+		case domain.PropertyTypeFormula, domain.PropertyTypeRollup:
+			return nil, fmt.Errorf("%w: %s properties are computed and cannot be imported", ErrInvalidImport, prop.Type)
+		}
+		if mappedSlugs[prop.Slug] {
+			return nil, fmt.Errorf("%w: property %q is mapped by more than one column", ErrInvalidImport, prop.Slug)
+		}
+		mappedSlugs[prop.Slug] = true
+		plan.columns[idx] = prop
+	}
+	if in.TitleColumn != "" && plan.titleColumn < 0 {
+		return nil, fmt.Errorf("%w: title column %q not found", ErrInvalidImport, in.TitleColumn)
+	}
+	if plan.titleColumn < 0 {
+		if len(header) == 0 || plan.columns[0].ID != "" {
+			return nil, fmt.Errorf("%w: no title column", ErrInvalidImport)
+		}
+		plan.titleColumn = 0
+		plan.ignored = removeString(plan.ignored, header[0])
+	}
+	for _, prop := range db.Properties {
+		if prop.IsRequired && !mappedSlugs[prop.Slug] {
+			return nil, fmt.Errorf("%w: required property %q is not mapped", ErrInvalidImport, prop.Slug)
+		}
+	}
+	return plan, nil
+}
+
+func removeString(list []string, value string) []string {
+	out := list[:0]
+	for _, v := range list {
+		if v != value {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func (p *importPlan) coerce(c *csvCoercer, line int, record []string) (importRow, []domain.ImportRowError) {
+	row := importRow{line: line, values: make(map[string]any)}
+	var errs []domain.ImportRowError
+	if p.titleColumn < len(record) {
+		row.title = strings.TrimSpace(record[p.titleColumn])
+	}
+	if row.title == "" {
+		errs = append(errs, domain.ImportRowError{Row: line, Column: p.headers[p.titleColumn], Message: "title is required"})
+	}
+	for idx, prop := range p.columns {
+		cell := ""
+		if idx < len(record) {
+			cell = strings.TrimSpace(record[idx])
+		}
+		if cell == "" {
+			if prop.IsRequired {
+				errs = append(errs, domain.ImportRowError{Row: line, Column: p.headers[idx], Message: "value is required"})
+			}
+			continue
+		}
+		value, err := c.coerce(prop, cell)
+		if err != nil {
+			errs = append(errs, domain.ImportRowError{Row: line, Column: p.headers[idx], Message: err.Error()})
+			continue
+		}
+		row.values[prop.Slug] = value
+	}
+	return row, errs
+}
+
+// csvCoercer converts cells to stored values according to property types and
+// remembers select options it had to invent.
+type csvCoercer struct {
+	ctx           context.Context
+	q             queryer
+	createOptions bool
+	newOptions    map[string][]string
+}
+
+func (c *csvCoercer) coerce(prop domain.DatabaseProperty, cell string) (any, error) {
+	switch prop.Type {
+	case domain.PropertyTypeNumber:
+		f, err := strconv.ParseFloat(strings.ReplaceAll(cell, ",", ""), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", cell)
+		}
+		return f, nil
+	case domain.PropertyTypeCheckbox:
+		switch strings.ToLower(cell) {
+		case "yes", "true", "1", "x", "checked":
+			return true, nil
+		case "no", "false", "0", "unchecked":
+			return false, nil
+		}
+		return nil, fmt.Errorf("%q is not a checkbox value", cell)
+	case domain.PropertyTypeDate:
+		if value, err := coerceDate(cell); err == nil {
+			return value, nil
+		}
+		start, end, isRange := strings.Cut(cell, "/")
+		if !isRange {
+			return nil, fmt.Errorf("%q is not a date", cell)
+		}
+		startValue, err := coerceDate(start)
+		if err != nil {
+			return nil, err
+		}
+		endValue, err := coerceDate(end)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"start": startValue, "end": endValue}, nil
+	case domain.PropertyTypeSelect:
+		return c.option(prop, cell)
+	case domain.PropertyTypeMultiSelect:
+		var values []any
+		for _, part := range splitList(cell) {
+			value, err := c.option(prop, part)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	case domain.PropertyTypeRelation:
+		var ids []any
+		for _, part := range splitList(cell) {
+			id, err := c.relation(part)
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+		return ids, nil
+	case domain.PropertyTypePerson:
+		var names []any
+		for _, part := range splitList(cell) {
+			names = append(names, part)
+		}
+		return names, nil
+	case domain.PropertyTypeEmail:
+		if !strings.Contains(cell, "@") {
+			return nil, fmt.Errorf("%q is not an email address", cell)
+		}
+	}
+	return cell, nil
+}
+
+func coerceDate(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if t, ok := storage.DateValue(text); ok {
+		if len(text) == len("2006-01-02") {
+			return t.Format("2006-01-02"), nil
+		}
+		return t.UTC().Format(time.RFC3339), nil
+	}
+	for _, layout := range []string{"01/02/2006", "2006/01/02", "Jan 2, 2006", "January 2, 2006"} {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t.Format("2006-01-02"), nil
+		}
+	}
+	// Date-times as written by spreadsheet and Notion exports.
+	for _, layout := range []string{"January 2, 2006 3:04 PM", "Jan 2, 2006 3:04 PM", "01/02/2006 15:04"} {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t.Format(time.RFC3339), nil
+		}
+	}
+	return "", fmt.Errorf("%q is not a date", text)
+}
+
+func splitList(cell string) []string {
+	var out []string
+	for _, part := range strings.Split(cell, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+func (c *csvCoercer) option(prop domain.DatabaseProperty, value string) (string, error) {
+	for _, option := range storage.PropertyOptions(prop.Config) {
+		if strings.EqualFold(option, value) {
+			return option, nil
+		}
+	}
+	for _, option := range c.newOptions[prop.Slug] {
+		if strings.EqualFold(option, value) {
+			return option, nil
+		}
+	}
+	if !c.createOptions {
+		return "", fmt.Errorf("%q is not an option of %s", value, prop.Name)
+	}
+	c.newOptions[prop.Slug] = append(c.newOptions[prop.Slug], value)
+	return value, nil
+}
+
+// relation resolves a page reference given either as a page id or a title.
+func (c *csvCoercer) relation(ref string) (string, error) {
+	var ids []string
+	rows, err := c.q.QueryContext(c.ctx, `SELECT id FROM pages WHERE id = ? OR title = ? LIMIT 2`, ref, ref)
+	if err != nil {
+		return "", fmt.Errorf("resolve relation: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return "", fmt.Errorf("scan relation: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("resolve relation: %w", err)
+	}
+	switch len(ids) {
+	case 0:
+		return "", fmt.Errorf("no page matches %q", ref)
+	case 1:
+		return ids[0], nil
+	}
+	return "", fmt.Errorf("more than one page is titled %q", ref)
+}
+
+// addPropertyOptions appends options to a select property, keeping the shape
+// (plain names or objects) of the options already configured.
+func addPropertyOptions(ctx context.Context, q queryer, prop domain.DatabaseProperty, added []string, now time.Time) error {
+	before, err := json.Marshal(prop)
+	if err != nil {
+		return fmt.Errorf("marshal property: %w", err)
+	}
+	config := prop.Config
+	if config == nil {
+		config = make(map[string]any)
+	}
+	existing, _ := config["options"].([]any)
+	asObjects := len(existing) > 0
+	if asObjects {
+		_, asObjects = existing[0].(map[string]any)
+	}
+	for _, name := range added {
+		if asObjects {
+			existing = append(existing, map[string]any{"name": name})
+		} else {
+			existing = append(existing, name)
+		}
+	}
+	config["options"] = existing
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("marshal property config: %w", err)
+	}
+	if _, err := q.ExecContext(ctx, `UPDATE database_properties SET config = ?, updated_at = ? WHERE id = ?`, string(raw), now, prop.ID); err != nil {
+		return fmt.Errorf("add property options: %w", err)
+	}
+	if err := touchDatabase(ctx, q, prop.DatabaseID, now); err != nil {
+		return err
+	}
+	prop.Config = config
+	prop.UpdatedAt = now
+	return recordAudit(ctx, q, storage.AuditActionUpdate, storage.AuditEntityProperty, prop.ID, json.RawMessage(before), prop, now)
+}
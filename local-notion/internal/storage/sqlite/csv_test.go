@@ -0,0 +1,127 @@
+package sqlite
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+func newCSVTestDatabase(t *testing.T, store *Store) *domain.Database {
+	t.Helper()
+	db, err := store.CreateDatabase(context.Background(), storage.CreateDatabaseInput{
+		Slug:  "tasks",
+		Title: "Tasks",
+		Properties: []storage.DatabasePropertyInput{
+			{Name: "Status", Slug: "status", Type: domain.PropertyTypeSelect, Config: map[string]any{"options": []string{"Todo", "Done"}}},
+			{Name: "Tags", Slug: "tags", Type: domain.PropertyTypeMultiSelect},
+			{Name: "Points", Slug: "points", Type: domain.PropertyTypeNumber},
+			{Name: "Due", Slug: "due", Type: domain.PropertyTypeDate},
+			{Name: "Done", Slug: "done", Type: domain.PropertyTypeCheckbox},
+		},
+		Views: []storage.DatabaseViewInput{{Name: "Table", Type: domain.ViewTypeTable}},
+	})
+	require.NoError(t, err)
+	return db
+}
+
+func TestStoreImportCSV(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db := newCSVTestDatabase(t, store)
+	data := "Name,Status,Tags,Points,Due,Done,Ignored\n" +
+		"Write docs,todo,\"docs, writing\",3,2024-02-01,yes,x\n" +
+		"Ship,Blocked,,1.5,01/15/2024,no,\n" +
+		"Broken,Done,,lots,someday,maybe,\n"
+
+	preview, err := store.ImportCSV(ctx, storage.ImportCSVInput{DatabaseID: db.ID, Reader: strings.NewReader(data), DryRun: true, CreateOptions: true})
+	require.NoError(t, err)
+	require.False(t, preview.Committed)
+	require.Equal(t, 3, preview.TotalRows)
+	require.Equal(t, 2, preview.Valid)
+	require.Equal(t, []string{"Ignored"}, preview.IgnoredColumns)
+	require.Len(t, preview.Errors, 3)
+	require.Equal(t, 3, preview.Errors[0].Row)
+	require.Equal(t, map[string][]string{"status": {"Blocked"}, "tags": {"docs", "writing"}}, preview.NewOptions)
+	require.Equal(t, map[string]any{"status": "Todo", "tags": []any{"docs", "writing"}, "points": 3.0, "due": "2024-02-01", "done": true}, preview.Preview[0].Values)
+	require.Equal(t, "2024-01-15", preview.Preview[1].Values["due"])
+
+	// Row errors reject the whole import unless invalid rows are skipped.
+	rejected, err := store.ImportCSV(ctx, storage.ImportCSVInput{DatabaseID: db.ID, Reader: strings.NewReader(data), CreateOptions: true})
+	require.NoError(t, err)
+	require.False(t, rejected.Committed)
+	require.Empty(t, viewItemTitles(t, store, db))
+
+	imported, err := store.ImportCSV(ctx, storage.ImportCSVInput{DatabaseID: db.ID, Reader: strings.NewReader(data), CreateOptions: true, SkipInvalidRows: true})
+	require.NoError(t, err)
+	require.True(t, imported.Committed)
+	require.Equal(t, 2, imported.Imported)
+	require.Equal(t, []string{"Write docs", "Ship"}, viewItemTitles(t, store, db))
+	items, err := store.ListViewItems(ctx, db.ID, db.Views[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, "write-docs", items[0].Page.Slug)
+	require.Equal(t, "Blocked", items[1].PropertyMap["status"].RawValue)
+
+	reloaded, err := store.GetDatabase(ctx, db.ID)
+	require.NoError(t, err)
+	for _, prop := range reloaded.Properties {
+		if prop.Slug == "status" {
+			require.Equal(t, []string{"Todo", "Done", "Blocked"}, storage.PropertyOptions(prop.Config))
+		}
+	}
+}
+
+func TestStoreImportCSVMapping(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db := newCSVTestDatabase(t, store)
+
+	_, err := store.ImportCSV(ctx, storage.ImportCSVInput{
+		DatabaseID: db.ID,
+		Reader:     strings.NewReader("Task,State\nA,Todo\n"),
+		Mapping:    map[string]string{"State": "missing"},
+	})
+	require.ErrorIs(t, err, ErrInvalidImport)
+
+	result, err := store.ImportCSV(ctx, storage.ImportCSVInput{
+		DatabaseID: db.ID,
+		Reader:     strings.NewReader("Task,State\nA,Todo\nB,Unknown\n"),
+		Mapping:    map[string]string{"State": "status"},
+	})
+	require.NoError(t, err)
+	require.False(t, result.Committed)
+	require.Equal(t, []domain.ImportRowError{{Row: 2, Column: "State", Message: `"Unknown" is not an option of Status`}}, result.Errors)
+}
+
+func TestStoreExportViewCSV(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db := newCSVTestDatabase(t, store)
+	_, err := store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
+		DatabaseID: db.ID,
+		Page:       storage.CreatePageInput{Slug: "a", Title: "Alpha, first"},
+		Values:     map[string]any{"status": "Done", "tags": []string{"x", "y"}, "points": 2.5, "due": map[string]any{"start": "2024-01-01", "end": "2024-01-03"}, "done": true},
+	})
+	require.NoError(t, err)
+	_, err = store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{DatabaseID: db.ID, Page: storage.CreatePageInput{Slug: "b", Title: "Beta"}})
+	require.NoError(t, err)
+	_, err = store.UpdateDatabaseView(ctx, storage.UpdateDatabaseViewInput{DatabaseID: db.ID, ViewID: db.Views[0].ID, Display: []string{"status", "tags", "points", "due", "done"}})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, store.ExportViewCSV(ctx, db.ID, db.Views[0].ID, &buf))
+	require.Equal(t, "Title,Status,Tags,Points,Due,Done\n"+
+		"\"Alpha, first\",Done,\"x, y\",2.5,2024-01-01/2024-01-03,Yes\n"+
+		"Beta,,,,,No\n", buf.String())
+
+	// An exported file imports back into the same shape.
+	result, err := store.ImportCSV(ctx, storage.ImportCSVInput{DatabaseID: db.ID, Reader: &buf, CreateOptions: true})
+	require.NoError(t, err)
+	require.Empty(t, result.Errors)
+	require.Equal(t, 2, result.Imported)
+}
@@ -0,0 +1,332 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// ErrDuplicateTooLarge is returned when a duplicate would copy more rows than maxDuplicateRows.
+var ErrDuplicateTooLarge = storage.InvalidError("duplicate_too_large", "duplicate exceeds size limit")
+
+// maxDuplicateRows caps the number of pages, items and values copied by a
+// single duplicate so one request cannot hold the write transaction forever.
+var maxDuplicateRows = 20000
+
+// DuplicatePage copies a page together with all of its descendants in one
+// transaction. Every copy gets a new identifier and a unique slug, and links
+// between pages of the copied subtree are remapped onto the copies.
+func (s *Store) DuplicatePage(ctx context.Context, in storage.DuplicatePageInput) (*domain.Page, error) {
+	if in.PageID == "" {
+		return nil, storage.InvalidField("page_id", "is required")
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	tree, err := loadPageSubtree(ctx, tx, in.PageID)
+	if err != nil {
+		return nil, err
+	}
+	if len(tree) == 0 {
+		return nil, storage.ErrPageNotFound
+	}
+	if len(tree) > maxDuplicateRows {
+		return nil, ErrDuplicateTooLarge
+	}
+	now := time.Now().UTC()
+	newIDs := make(map[string]string, len(tree))
+	for _, page := range tree {
+		newIDs[page.ID] = uuid.NewString()
+	}
+	for idx, src := range tree {
+		page := src
+		page.ID = newIDs[src.ID]
+		page.CreatedAt = now
+		page.UpdatedAt = now
+		if idx == 0 {
+			page.Title = in.Title
+			if page.Title == "" {
+				page.Title = src.Title + " (copy)"
+			}
+			if in.ParentPageID != nil {
+				page.ParentPageID = in.ParentPageID
+			}
+		} else {
+			newParent := newIDs[*src.ParentPageID]
+			page.ParentPageID = &newParent
+		}
+		if page.Slug, err = uniqueSlug(ctx, tx, "pages", src.Slug+"-copy"); err != nil {
+			return nil, err
+		}
+		if err := insertPageRow(ctx, tx, page); err != nil {
+			return nil, err
+		}
+	}
+	if err := copyPageLinks(ctx, tx, tree, newIDs, now); err != nil {
+		return nil, err
+	}
+	if err := auditPagesCreated(ctx, tx, tree, newIDs, now); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit duplicate: %w", err)
+	}
+	return s.GetPage(ctx, newIDs[tree[0].ID])
+}
+
+// DuplicateDatabase copies a database with its properties, views, item
+// templates, items, values and item pages in one transaction. Relation values
+// and page links that point at items inside the copied database are remapped to
+// the new identifiers; references to other databases are kept as-is.
+func (s *Store) DuplicateDatabase(ctx context.Context, in storage.DuplicateDatabaseInput) (*domain.Database, error) {
+	if in.DatabaseID == "" {
+		return nil, storage.InvalidField("database_id", "is required")
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	src, err := s.loadDatabase(ctx, tx, in.DatabaseID)
+	if err != nil {
+		return nil, err
+	}
+	if src == nil {
+		return nil, storage.ErrDatabaseNotFound
+	}
+	var rowCount int
+	if err := tx.QueryRowContext(ctx, `SELECT
+    (SELECT COUNT(1) FROM database_items WHERE database_id = ?) +
+    (SELECT COUNT(1) FROM database_values dv JOIN database_items di ON di.id = dv.database_item_id WHERE di.database_id = ?)`,
+		src.ID, src.ID).Scan(&rowCount); err != nil {
+		return nil, fmt.Errorf("count database rows: %w", err)
+	}
+	if rowCount > maxDuplicateRows {
+		return nil, ErrDuplicateTooLarge
+	}
+	now := time.Now().UTC()
+	dbID := uuid.NewString()
+	slug := in.Slug
+	if slug == "" {
+		if slug, err = uniqueSlug(ctx, tx, "databases", src.Slug+"-copy"); err != nil {
+			return nil, err
+		}
+	}
+	title := in.Title
+	if title == "" {
+		title = src.Title + " (copy)"
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO databases(id, slug, title, description, icon, cover_image_id, is_archived, is_template, created_at, updated_at) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		dbID, slug, title, src.Description, src.Icon, src.CoverImage, boolToInt(src.IsArchived), boolToInt(src.IsTemplate), now, now); err != nil {
+		return nil, insertError("database", slug, err)
+	}
+	ids := map[string]string{src.ID: dbID}
+	if err := copyDatabaseSchema(ctx, tx, src, dbID, ids, now); err != nil {
+		return nil, err
+	}
+	if err := s.auditDatabaseCopy(ctx, tx, dbID, now); err != nil {
+		return nil, err
+	}
+	err = copyDatabaseItems(ctx, tx, src.ID, dbID, ids, now, func(page *domain.Page) (func(any) any, error) {
+		var err error
+		page.Slug, err = uniqueSlug(ctx, tx, "pages", page.Slug+"-copy")
+		return nil, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit duplicate: %w", err)
+	}
+	return s.GetDatabase(ctx, dbID)
+}
+
+// copyDatabaseSchema copies properties, views and item templates of src onto
+// the database dstID. ids receives the property mapping and is used to rewrite
+// property references in views and property configuration.
+func copyDatabaseSchema(ctx context.Context, q queryer, src *domain.Database, dstID string, ids map[string]string, now time.Time) error {
+	for _, prop := range src.Properties {
+		ids[prop.ID] = uuid.NewString()
+	}
+	for _, prop := range src.Properties {
+		prop.ID = ids[prop.ID]
+		prop.DatabaseID = dstID
+		if prop.Config != nil {
+			prop.Config = remapIDs(prop.Config, ids).(map[string]any)
+		}
+		if err := insertPropertyRow(ctx, q, prop, now); err != nil {
+			return err
+		}
+	}
+	for _, view := range src.Views {
+		view = remapView(view, ids)
+		view.ID = uuid.NewString()
+		view.DatabaseID = dstID
+		if err := insertViewRow(ctx, q, view, now); err != nil {
+			return err
+		}
+	}
+	for _, tpl := range src.ItemTemplates {
+		tpl.ID = uuid.NewString()
+		tpl.DatabaseID = dstID
+		if err := insertItemTemplateRow(ctx, q, tpl, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// itemRewriter adjusts a copied item page in place and may return a transform
+// applied to each of the item's values before identifiers are remapped.
+type itemRewriter func(page *domain.Page) (func(any) any, error)
+
+// copyDatabaseItems copies every item of srcID, including its backing page,
+// values and the links between item pages, into dstID. ids must already hold
+// the property mapping and is extended with item and page identifiers so that
+// relation values between copied items point at the copies.
+func copyDatabaseItems(ctx context.Context, q queryer, srcID, dstID string, ids map[string]string, now time.Time, rewrite itemRewriter) error {
+	rows, err := q.QueryContext(ctx, `SELECT id, page_id, position, rank, is_archived FROM database_items WHERE database_id = ? ORDER BY rank ASC, created_at ASC`, srcID)
+	if err != nil {
+		return fmt.Errorf("query items: %w", err)
+	}
+	type sourceItem struct {
+		id         string
+		pageID     string
+		position   int
+		rank       string
+		isArchived bool
+	}
+	var items []sourceItem
+	for rows.Next() {
+		var item sourceItem
+		if err := rows.Scan(&item.id, &item.pageID, &item.position, &item.rank, &item.isArchived); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan item: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("iterate items: %w", err)
+	}
+	rows.Close()
+	for _, item := range items {
+		ids[item.id] = uuid.NewString()
+		ids[item.pageID] = uuid.NewString()
+	}
+	var copiedPages []domain.Page
+	var copiedItems []string
+	for _, item := range items {
+		page, err := loadPageRow(ctx, q, item.pageID)
+		if err != nil {
+			return err
+		}
+		if page == nil {
+			continue
+		}
+		copiedPages = append(copiedPages, *page)
+		page.ID = ids[item.pageID]
+		page.CreatedAt = now
+		page.UpdatedAt = now
+		var transform func(any) any
+		if rewrite != nil {
+			if transform, err = rewrite(page); err != nil {
+				return err
+			}
+		}
+		if err := insertPageRow(ctx, q, *page); err != nil {
+			return err
+		}
+		if _, err := q.ExecContext(ctx, `INSERT INTO database_items(id, database_id, page_id, position, rank, is_archived, created_at, updated_at) VALUES(?, ?, ?, ?, ?, ?, ?, ?)`,
+			ids[item.id], dstID, page.ID, item.position, item.rank, boolToInt(item.isArchived), now, now); err != nil {
+			return fmt.Errorf("insert copied item: %w", err)
+		}
+		copiedItems = append(copiedItems, ids[item.id])
+		values, err := loadItemValues(ctx, q, item.id)
+		if err != nil {
+			return err
+		}
+		for _, value := range values {
+			propID, ok := ids[value.PropertyID]
+			if !ok {
+				continue
+			}
+			raw := value.RawValue
+			if transform != nil {
+				raw = transform(raw)
+			}
+			encoded, err := json.Marshal(remapIDs(raw, ids))
+			if err != nil {
+				return fmt.Errorf("marshal copied value: %w", err)
+			}
+			if _, err := q.ExecContext(ctx, `INSERT INTO database_values(id, database_item_id, property_id, value, is_computed, created_at, updated_at) VALUES(?, ?, ?, ?, ?, ?, ?)`,
+				uuid.NewString(), ids[item.id], propID, string(encoded), boolToInt(value.IsComputed), now, now); err != nil {
+				return fmt.Errorf("insert copied value: %w", err)
+			}
+			if err := indexValue(ctx, q, ids[item.id], propID, encoded); err != nil {
+				return err
+			}
+		}
+	}
+	if err := copyPageLinks(ctx, q, copiedPages, ids, now); err != nil {
+		return err
+	}
+	for _, itemID := range copiedItems {
+		item, err := loadDatabaseItem(ctx, q, itemID)
+		if err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, q, storage.AuditActionCreate, storage.AuditEntityItem, itemID, nil, item, now); err != nil {
+			return err
+		}
+		if err := scheduleItemReminders(ctx, q, itemID, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// auditDatabaseCopy records a database created by copying another one, once
+// its schema is in place.
+func (s *Store) auditDatabaseCopy(ctx context.Context, q queryer, dbID string, now time.Time) error {
+	db, err := s.loadDatabase(ctx, q, dbID)
+	if err != nil {
+		return err
+	}
+	return auditDatabaseCreate(ctx, q, db, now)
+}
+
+// slugHistoryTables names the slug history table of each table with slugs.
+var slugHistoryTables = map[string]string{
+	"pages":     "page_slug_history",
+	"databases": "database_slug_history",
+}
+
+// uniqueSlug returns base when it is unused in table, otherwise the first free
+// "<base>-<n>" variant. Slugs a record used to have count as used, so old
+// links keep resolving to it. table must be a key of slugHistoryTables.
+func uniqueSlug(ctx context.Context, q queryer, table, base string) (string, error) {
+	return storage.UniqueSlug(base, func(candidate string) (bool, error) {
+		var exists int
+		err := q.QueryRowContext(ctx, `SELECT 1 FROM `+table+` WHERE slug = ? UNION ALL SELECT 1 FROM `+slugHistoryTables[table]+` WHERE slug = ? LIMIT 1`, candidate, candidate).Scan(&exists)
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("check slug: %w", err)
+		}
+		return true, nil
+	})
+}
@@ -0,0 +1,151 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+func TestStoreDuplicatePageSubtree(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	outside, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "outside", Title: "Outside"})
+	require.NoError(t, err)
+	root, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "handbook", Title: "Handbook", Tags: []string{"docs"}})
+	require.NoError(t, err)
+	child, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "onboarding", Title: "Onboarding", ParentPageID: &root.ID, LinkedPageIDs: []string{root.ID, outside.ID}})
+	require.NoError(t, err)
+	_, err = store.CreatePage(ctx, storage.CreatePageInput{Slug: "week-one", Title: "Week one", ParentPageID: &child.ID})
+	require.NoError(t, err)
+
+	copied, err := store.DuplicatePage(ctx, storage.DuplicatePageInput{PageID: root.ID})
+	require.NoError(t, err)
+	require.NotEqual(t, root.ID, copied.ID)
+	require.Equal(t, "Handbook (copy)", copied.Title)
+	require.Equal(t, "handbook-copy", copied.Slug)
+	require.Equal(t, []string{"docs"}, copied.Tags)
+
+	tree, err := loadPageSubtree(ctx, store.db, copied.ID)
+	require.NoError(t, err)
+	require.Len(t, tree, 3)
+	require.Equal(t, "onboarding-copy", tree[1].Slug)
+	require.Equal(t, copied.ID, *tree[1].ParentPageID)
+	require.Equal(t, tree[1].ID, *tree[2].ParentPageID)
+
+	copiedChild, err := store.GetPage(ctx, tree[1].ID)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{copied.ID, outside.ID}, copiedChild.LinkedPageIDs)
+
+	again, err := store.DuplicatePage(ctx, storage.DuplicatePageInput{PageID: root.ID, Title: "Handbook v2"})
+	require.NoError(t, err)
+	require.Equal(t, "handbook-copy-2", again.Slug)
+	require.Equal(t, "Handbook v2", again.Title)
+
+	_, err = store.DuplicatePage(ctx, storage.DuplicatePageInput{PageID: "missing"})
+	require.ErrorIs(t, err, storage.ErrPageNotFound)
+}
+
+func TestStoreDuplicatePageRejectsDeepSubtree(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	root, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "level-0", Title: "Level 0"})
+	require.NoError(t, err)
+	parent := root
+	for depth := 1; depth <= maxSubtreeDepth+1; depth++ {
+		parent, err = store.CreatePage(ctx, storage.CreatePageInput{Slug: fmt.Sprintf("level-%d", depth), Title: "Level", ParentPageID: &parent.ID})
+		require.NoError(t, err)
+	}
+
+	_, err = store.DuplicatePage(ctx, storage.DuplicatePageInput{PageID: root.ID})
+	require.ErrorIs(t, err, ErrSubtreeTooDeep)
+	require.ErrorIs(t, err, storage.ErrValidation)
+
+	tree, err := loadPageSubtree(ctx, store.db, *parent.ParentPageID)
+	require.NoError(t, err)
+	require.Len(t, tree, 2)
+}
+
+func TestStoreDuplicateDatabase(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	db, err := store.CreateDatabase(ctx, storage.CreateDatabaseInput{
+		Slug:  "tasks",
+		Title: "Tasks",
+		Properties: []storage.DatabasePropertyInput{
+			{Name: "Name", Slug: "name", Type: domain.PropertyTypeText},
+			{Name: "Blocked by", Slug: "blocked_by", Type: domain.PropertyTypeRelation},
+		},
+		Views: []storage.DatabaseViewInput{{Name: "Table", Type: domain.ViewTypeTable}},
+	})
+	require.NoError(t, err)
+	first, err := store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
+		DatabaseID: db.ID,
+		Page:       storage.CreatePageInput{Slug: "design", Title: "Design"},
+		Values:     map[string]any{"name": "Design"},
+	})
+	require.NoError(t, err)
+	external, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "spec", Title: "Spec"})
+	require.NoError(t, err)
+	_, err = store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
+		DatabaseID: db.ID,
+		Page:       storage.CreatePageInput{Slug: "build", Title: "Build"},
+		Position:   1,
+		Values:     map[string]any{"name": "Build", "blocked_by": []string{first.Page.ID, external.ID}},
+	})
+	require.NoError(t, err)
+
+	copied, err := store.DuplicateDatabase(ctx, storage.DuplicateDatabaseInput{DatabaseID: db.ID})
+	require.NoError(t, err)
+	require.Equal(t, "tasks-copy", copied.Slug)
+	require.Equal(t, "Tasks (copy)", copied.Title)
+	require.Len(t, copied.Properties, 2)
+	require.Len(t, copied.Views, 1)
+
+	items, err := store.ListViewItems(ctx, copied.ID, copied.Views[0].ID)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	require.Equal(t, "design-copy", items[0].Page.Slug)
+	require.NotEqual(t, first.Page.ID, items[0].Page.ID)
+	blockedBy := items[1].PropertyMap["blocked_by"].RawValue
+	require.Equal(t, []any{items[0].Page.ID, external.ID}, blockedBy)
+
+	original, err := store.ListViewItems(ctx, db.ID, db.Views[0].ID)
+	require.NoError(t, err)
+	require.Len(t, original, 2)
+	require.Equal(t, []any{first.Page.ID, external.ID}, original[1].PropertyMap["blocked_by"].RawValue)
+}
+
+func TestStoreDuplicateDatabaseSizeGuard(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	db, err := store.CreateDatabase(ctx, storage.CreateDatabaseInput{
+		Slug:       "big",
+		Title:      "Big",
+		Properties: []storage.DatabasePropertyInput{{Name: "Name", Slug: "name", Type: domain.PropertyTypeText}},
+	})
+	require.NoError(t, err)
+	for _, slug := range []string{"a", "b", "c"} {
+		_, err := store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{DatabaseID: db.ID, Page: storage.CreatePageInput{Slug: slug, Title: slug}, Values: map[string]any{"name": slug}})
+		require.NoError(t, err)
+	}
+
+	previous := maxDuplicateRows
+	maxDuplicateRows = 5
+	t.Cleanup(func() { maxDuplicateRows = previous })
+
+	_, err = store.DuplicateDatabase(ctx, storage.DuplicateDatabaseInput{DatabaseID: db.ID})
+	require.ErrorIs(t, err, ErrDuplicateTooLarge)
+
+	var count int
+	require.NoError(t, store.db.QueryRow(`SELECT COUNT(1) FROM databases`).Scan(&count))
+	require.Equal(t, 1, count)
+}
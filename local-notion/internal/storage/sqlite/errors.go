@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	moderncsqlite "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"github.com/example/agents-playground/internal/storage"
+)
+
+func init() {
+	storage.RegisterErrorClassifier(constraintError)
+}
+
+// insertError reports a failed insert of the record labelled by slug,
+// translating a slug uniqueness violation into a slug conflict.
+func insertError(what, slug string, err error) error {
+	if classified := constraintError(err); classified != nil {
+		if classified.Code == storage.ErrSlugConflict.Code {
+			return storage.SlugConflict(slug)
+		}
+		return classified
+	}
+	return fmt.Errorf("insert %s %s: %w", what, slug, err)
+}
+
+// slugConflict returns a slug conflict naming slug when err is a slug
+// uniqueness violation, and nil otherwise.
+func slugConflict(slug string, err error) error {
+	if classified := constraintError(err); classified != nil && classified.Code == storage.ErrSlugConflict.Code {
+		return storage.SlugConflict(slug)
+	}
+	return nil
+}
+
+// constraintError classifies a SQLite constraint failure as a conflict or
+// validation error, and returns nil for any other error.
+func constraintError(err error) *storage.Error {
+	var driverErr *moderncsqlite.Error
+	if !errors.As(err, &driverErr) {
+		return nil
+	}
+	switch driverErr.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		if strings.Contains(driverErr.Error(), ".slug") {
+			return storage.ErrSlugConflict
+		}
+		return storage.ConflictError(storage.ErrConflict.Code, "a record with the same key already exists")
+	case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		return storage.InvalidError("reference_not_found", "a referenced record does not exist")
+	case sqlite3.SQLITE_CONSTRAINT_NOTNULL, sqlite3.SQLITE_CONSTRAINT_CHECK:
+		return storage.InvalidError(storage.ErrValidation.Code, "a required value is missing or invalid")
+	}
+	return nil
+}
@@ -0,0 +1,453 @@
+package sqlite
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// markdownExport is a planned zip export of pages. Planning only loads page
+// metadata; page content, database rows and assets are read one at a time
+// while the archive is written, so exports never hold the workspace in memory.
+type markdownExport struct {
+	store     *Store
+	pages     []exportPage
+	byID      map[string]*exportPage
+	databases []exportDatabase
+	assets    []exportAsset
+}
+
+type exportPage struct {
+	id       string
+	slug     string
+	title    string
+	parentID string
+	coverID  string
+	file     string // zip path of the page's Markdown file
+	children string // zip folder holding the page's children
+}
+
+type exportDatabase struct {
+	id      string
+	slug    string
+	title   string
+	folder  string
+	pageIDs map[string]bool
+}
+
+type exportAsset struct {
+	id          string
+	storagePath string
+	file        string
+}
+
+// PrepareMarkdownExport plans an export of the page rootID and its
+// descendants, or of every non-template page when rootID is empty. Each page
+// becomes <slug>.md with its children in a <slug>/ folder next to it; database
+// items whose parent is not exported are placed in a folder named after their
+// database, next to a CSV of the database.
+func (s *Store) PrepareMarkdownExport(ctx context.Context, rootID string) (storage.MarkdownExport, error) {
+	pages, err := loadExportPages(ctx, s.reader, rootID)
+	if err != nil {
+		return nil, err
+	}
+	if rootID != "" && len(pages) == 0 {
+		return nil, storage.ErrPageNotFound
+	}
+	export := &markdownExport{store: s, pages: pages, byID: make(map[string]*exportPage, len(pages))}
+	for i := range export.pages {
+		export.byID[export.pages[i].id] = &export.pages[i]
+	}
+	if err := export.planDatabases(ctx, s.reader); err != nil {
+		return nil, err
+	}
+	itemFolders := make(map[string]string)
+	for _, db := range export.databases {
+		for pageID := range db.pageIDs {
+			itemFolders[pageID] = db.folder
+		}
+	}
+	for i := range export.pages {
+		export.placePage(&export.pages[i], itemFolders, 0)
+	}
+	if err := export.planAssets(ctx, s.reader); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// loadExportPages loads the metadata of the exported pages, parents first.
+func loadExportPages(ctx context.Context, q queryer, rootID string) ([]exportPage, error) {
+	var rows *sql.Rows
+	var err error
+	if rootID == "" {
+		rows, err = q.QueryContext(ctx, `SELECT id, slug, title, parent_page_id, cover_image_id FROM pages WHERE is_template = 0 ORDER BY title, id`)
+	} else {
+		rows, err = q.QueryContext(ctx, `WITH RECURSIVE subtree(id, depth) AS (
+    SELECT id, 0 FROM pages WHERE id = ?
+    UNION ALL
+    SELECT child.id, subtree.depth + 1 FROM pages child JOIN subtree ON child.parent_page_id = subtree.id
+    WHERE subtree.depth < ?
+)
+SELECT p.id, p.slug, p.title, p.parent_page_id, p.cover_image_id FROM pages p JOIN subtree ON subtree.id = p.id ORDER BY subtree.depth ASC, p.title ASC`, rootID, maxSubtreeDepth)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query export pages: %w", err)
+	}
+	defer rows.Close()
+	var pages []exportPage
+	for rows.Next() {
+		var page exportPage
+		var parent, cover sql.NullString
+		if err := rows.Scan(&page.id, &page.slug, &page.title, &parent, &cover); err != nil {
+			return nil, fmt.Errorf("scan export page: %w", err)
+		}
+		page.parentID = parent.String
+		page.coverID = cover.String
+		pages = append(pages, page)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate export pages: %w", err)
+	}
+	if rootID != "" && len(pages) > 0 {
+		pages[0].parentID = "" // the root is exported at the top of the archive
+	}
+	return pages, nil
+}
+
+func (e *markdownExport) planDatabases(ctx context.Context, q queryer) error {
+	rows, err := q.QueryContext(ctx, `SELECT di.page_id, d.id, d.slug, d.title FROM database_items di JOIN databases d ON d.id = di.database_id WHERE d.is_template = 0 ORDER BY d.title, d.id`)
+	if err != nil {
+		return fmt.Errorf("query export items: %w", err)
+	}
+	defer rows.Close()
+	index := make(map[string]int)
+	for rows.Next() {
+		var pageID string
+		var db exportDatabase
+		if err := rows.Scan(&pageID, &db.id, &db.slug, &db.title); err != nil {
+			return fmt.Errorf("scan export item: %w", err)
+		}
+		if _, ok := e.byID[pageID]; !ok {
+			continue
+		}
+		idx, ok := index[db.id]
+		if !ok {
+			db.folder = exportName(db.slug, db.id)
+			db.pageIDs = make(map[string]bool)
+			idx = len(e.databases)
+			index[db.id] = idx
+			e.databases = append(e.databases, db)
+		}
+		e.databases[idx].pageIDs[pageID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate export items: %w", err)
+	}
+	return nil
+}
+
+// placePage assigns the page's file and child folder, placing its parent first.
+func (e *markdownExport) placePage(page *exportPage, itemFolders map[string]string, depth int) {
+	if page.file != "" {
+		return
+	}
+	dir := itemFolders[page.id]
+	if parent, ok := e.byID[page.parentID]; ok && depth < maxSubtreeDepth {
+		e.placePage(parent, itemFolders, depth+1)
+		dir = parent.children
+	}
+	name := exportName(page.slug, page.id)
+	page.file = path.Join(dir, name+".md")
+	page.children = path.Join(dir, name)
+}
+
+// assetLinkPattern matches Markdown links and images pointing at a stored
+// asset, capturing its id.
+var assetLinkPattern = regexp.MustCompile(`\]\(/api/assets/([^)\s]+)\)`)
+
+// planAssets bundles the cover images of the exported pages and the assets
+// their content links to. Content is scanned one page at a time.
+func (e *markdownExport) planAssets(ctx context.Context, q queryer) error {
+	var ids []string
+	seen := make(map[string]bool)
+	add := func(id string) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, page := range e.pages {
+		if page.coverID != "" {
+			add(page.coverID)
+		}
+	}
+	rows, err := q.QueryContext(ctx, `SELECT id, content FROM pages WHERE content LIKE '%/api/assets/%' ORDER BY title, id`)
+	if err != nil {
+		return fmt.Errorf("query export asset links: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var pageID, content string
+		if err := rows.Scan(&pageID, &content); err != nil {
+			return fmt.Errorf("scan export asset links: %w", err)
+		}
+		if _, ok := e.byID[pageID]; !ok {
+			continue
+		}
+		for _, m := range assetLinkPattern.FindAllStringSubmatch(content, -1) {
+			add(m[1])
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate export asset links: %w", err)
+	}
+	for _, id := range ids {
+		var filename, storagePath string
+		err := q.QueryRowContext(ctx, `SELECT filename, storage_path FROM assets WHERE id = ?`, id).Scan(&filename, &storagePath)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("load asset %s: %w", id, err)
+		}
+		if _, err := os.Stat(storagePath); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		e.assets = append(e.assets, exportAsset{
+			id:          id,
+			storagePath: storagePath,
+			file:        path.Join("assets", exportName(id+"-"+filepath.Base(filename), id)),
+		})
+	}
+	return nil
+}
+
+// exportName makes a slug safe to use as a single path element.
+func exportName(slug, fallback string) string {
+	name := strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|', 0:
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(slug))
+	if name == "" || name == "." || name == ".." {
+		return fallback
+	}
+	return name
+}
+
+// Stream writes the archive to w. Errors after the first byte leave a
+// truncated archive, so callers should not retry on the same writer.
+func (e *markdownExport) Stream(ctx context.Context, w io.Writer) error {
+	zw := zip.NewWriter(w)
+	assetFiles := make(map[string]string, len(e.assets))
+	for _, asset := range e.assets {
+		assetFiles[asset.id] = asset.file
+	}
+	for _, page := range e.pages {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := e.writePage(ctx, zw, page, assetFiles); err != nil {
+			return err
+		}
+	}
+	for _, db := range e.databases {
+		if err := e.writeDatabase(ctx, zw, db); err != nil {
+			return err
+		}
+	}
+	for _, asset := range e.assets {
+		if err := writeAsset(zw, asset); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("close export archive: %w", err)
+	}
+	return nil
+}
+
+func (e *markdownExport) writePage(ctx context.Context, zw *zip.Writer, meta exportPage, assetFiles map[string]string) error {
+	page, err := loadPageRow(ctx, e.store.reader, meta.id)
+	if err != nil {
+		return err
+	}
+	if page == nil {
+		return nil // deleted since the export was planned
+	}
+	links, err := e.store.loadPageLinks(ctx, meta.id)
+	if err != nil {
+		return err
+	}
+	dir := path.Dir(meta.file)
+	var b strings.Builder
+	b.WriteString("---\n")
+	writeFrontMatter(&b, "id", page.ID)
+	writeFrontMatter(&b, "title", page.Title)
+	writeFrontMatter(&b, "slug", page.Slug)
+	tags := page.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	writeFrontMatter(&b, "tags", tags)
+	if page.Icon != nil && *page.Icon != "" {
+		writeFrontMatter(&b, "icon", *page.Icon)
+	}
+	if page.CoverImageID != nil {
+		if file, ok := assetFiles[*page.CoverImageID]; ok {
+			writeFrontMatter(&b, "cover", relativePath(dir, file))
+		}
+	}
+	fmt.Fprintf(&b, "created_at: %s\n", page.CreatedAt.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "updated_at: %s\n", page.UpdatedAt.UTC().Format(time.RFC3339))
+	b.WriteString("---\n\n")
+	fmt.Fprintf(&b, "# %s\n", page.Title)
+	content := page.Content
+	for _, target := range links.Outbound {
+		if linked, ok := e.byID[target]; ok {
+			rel := relativePath(dir, linked.file)
+			content = strings.ReplaceAll(content, "]("+target+")", "]("+rel+")")
+			content = strings.ReplaceAll(content, "](/pages/"+target+")", "]("+rel+")")
+		}
+	}
+	content = assetLinkPattern.ReplaceAllStringFunc(content, func(match string) string {
+		if file, ok := assetFiles[assetLinkPattern.FindStringSubmatch(match)[1]]; ok {
+			return "](" + relativePath(dir, file) + ")"
+		}
+		return match
+	})
+	if content = strings.TrimSpace(content); content != "" {
+		b.WriteString("\n" + content + "\n")
+	}
+	if len(links.Outbound) > 0 {
+		b.WriteString("\n## Linked pages\n\n")
+		for _, target := range links.Outbound {
+			if linked, ok := e.byID[target]; ok {
+				fmt.Fprintf(&b, "- [%s](%s)\n", linked.title, relativePath(dir, linked.file))
+			} else {
+				fmt.Fprintf(&b, "- %s (not exported)\n", target)
+			}
+		}
+	}
+	return writeZipFile(zw, meta.file, page.UpdatedAt, strings.NewReader(b.String()))
+}
+
+// writeFrontMatter writes a YAML key. Values are JSON encoded, which YAML
+// reads as quoted strings and flow sequences.
+func writeFrontMatter(b *strings.Builder, key string, value any) {
+	raw, _ := json.Marshal(value)
+	fmt.Fprintf(b, "%s: %s\n", key, raw)
+}
+
+func relativePath(fromDir, target string) string {
+	rel, err := filepath.Rel(filepath.FromSlash(fromDir), filepath.FromSlash(target))
+	if err != nil {
+		return target
+	}
+	return filepath.ToSlash(rel)
+}
+
+// writeDatabase writes the exported items of a database as <folder>/<slug>.csv
+// with one column per property and a relative Page link per row.
+func (e *markdownExport) writeDatabase(ctx context.Context, zw *zip.Writer, meta exportDatabase) error {
+	db, err := e.store.loadDatabase(ctx, e.store.reader, meta.id)
+	if err != nil {
+		return err
+	}
+	if db == nil {
+		return nil
+	}
+	items, err := e.store.listDatabaseItems(ctx, meta.id)
+	if err != nil {
+		return err
+	}
+	var relationIDs []string
+	for _, item := range items {
+		for _, prop := range db.Properties {
+			if prop.Type == domain.PropertyTypeRelation {
+				relationIDs = append(relationIDs, stringList(item.PropertyMap[prop.Slug].RawValue)...)
+			}
+		}
+	}
+	titles, err := pageTitles(ctx, e.store.reader, relationIDs)
+	if err != nil {
+		return err
+	}
+	file, err := zw.CreateHeader(&zip.FileHeader{Name: path.Join(meta.folder, meta.folder+".csv"), Method: zip.Deflate, Modified: db.UpdatedAt})
+	if err != nil {
+		return fmt.Errorf("add %s: %w", meta.folder, err)
+	}
+	out := csv.NewWriter(file)
+	header := []string{"Title"}
+	for _, prop := range db.Properties {
+		header = append(header, prop.Name)
+	}
+	header = append(header, "Page")
+	if err := out.Write(header); err != nil {
+		return fmt.Errorf("write csv header: %w", err)
+	}
+	for _, item := range items {
+		page, ok := e.byID[item.Page.ID]
+		if !ok || !meta.pageIDs[item.Page.ID] {
+			continue
+		}
+		record := []string{item.Page.Title}
+		for _, prop := range db.Properties {
+			record = append(record, formatCSVValue(prop, item.PropertyMap[prop.Slug].RawValue, titles))
+		}
+		record = append(record, relativePath(meta.folder, page.file))
+		if err := out.Write(record); err != nil {
+			return fmt.Errorf("write csv row: %w", err)
+		}
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		return fmt.Errorf("flush csv: %w", err)
+	}
+	return nil
+}
+
+func writeAsset(zw *zip.Writer, asset exportAsset) error {
+	f, err := os.Open(asset.storagePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open asset %s: %w", asset.id, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("stat asset %s: %w", asset.id, err)
+	}
+	return writeZipFile(zw, asset.file, info.ModTime(), f)
+}
+
+func writeZipFile(zw *zip.Writer, name string, modified time.Time, r io.Reader) error {
+	file, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return fmt.Errorf("add %s: %w", name, err)
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
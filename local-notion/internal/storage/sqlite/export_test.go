@@ -0,0 +1,115 @@
+package sqlite
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+func readExport(t *testing.T, store *Store, rootID string) map[string]string {
+	t.Helper()
+	export, err := store.PrepareMarkdownExport(context.Background(), rootID)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, export.Stream(context.Background(), &buf))
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		body, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+		files[f.Name] = string(body)
+	}
+	return files
+}
+
+func TestStoreMarkdownExportSubtree(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	root, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "handbook", Title: "Handbook", Tags: []string{"team"}})
+	require.NoError(t, err)
+	faq, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "faq", Title: "FAQ", ParentPageID: &root.ID})
+	require.NoError(t, err)
+	outside, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "outside", Title: "Outside", Content: "![chart](/api/assets/asset-3)"})
+	require.NoError(t, err)
+	_, err = store.CreatePage(ctx, storage.CreatePageInput{
+		Slug:          "onboarding",
+		Title:         "Onboarding",
+		Content:       "Read the [FAQ](" + faq.ID + ") first.\n\n![Diagram](/api/assets/asset-2) and [the slides](/api/assets/missing).",
+		ParentPageID:  &faq.ID,
+		LinkedPageIDs: []string{faq.ID, outside.ID},
+	})
+	require.NoError(t, err)
+
+	cover := filepath.Join(t.TempDir(), "cover.png")
+	require.NoError(t, os.WriteFile(cover, []byte("png"), 0o600))
+	_, err = store.db.ExecContext(ctx, `INSERT INTO assets(id, filename, content_type, size_bytes, storage_path, created_at) VALUES('asset-1', 'cover.png', 'image/png', 3, ?, ?)`, cover, time.Now())
+	require.NoError(t, err)
+	for _, id := range []string{"asset-2", "asset-3"} {
+		file := filepath.Join(t.TempDir(), id+".svg")
+		require.NoError(t, os.WriteFile(file, []byte("svg"), 0o600))
+		_, err = store.db.ExecContext(ctx, `INSERT INTO assets(id, filename, content_type, size_bytes, storage_path, created_at) VALUES(?, 'diagram.svg', 'image/svg+xml', 3, ?, ?)`, id, file, time.Now())
+		require.NoError(t, err)
+	}
+	_, err = store.db.ExecContext(ctx, `UPDATE pages SET cover_image_id = 'asset-1', icon = '📘' WHERE id = ?`, root.ID)
+	require.NoError(t, err)
+
+	files := readExport(t, store, root.ID)
+	require.ElementsMatch(t, []string{"handbook.md", "handbook/faq.md", "handbook/faq/onboarding.md", "assets/asset-1-cover.png", "assets/asset-2-diagram.svg"}, keys(files))
+	require.Equal(t, "png", files["assets/asset-1-cover.png"])
+	require.Contains(t, files["handbook.md"], "slug: \"handbook\"\ntags: [\"team\"]\nicon: \"📘\"\ncover: \"assets/asset-1-cover.png\"\n")
+	onboarding := files["handbook/faq/onboarding.md"]
+	require.Contains(t, onboarding, "Read the [FAQ](../faq.md) first.")
+	require.Contains(t, onboarding, "![Diagram](../../assets/asset-2-diagram.svg) and [the slides](/api/assets/missing).")
+	require.Contains(t, onboarding, "\n- [FAQ](../faq.md)\n")
+	require.Contains(t, onboarding, "\n- "+outside.ID+" (not exported)\n")
+
+	_, err = store.PrepareMarkdownExport(ctx, "missing")
+	require.ErrorIs(t, err, storage.ErrPageNotFound)
+}
+
+func TestStoreMarkdownExportDatabases(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db, err := store.CreateDatabase(ctx, storage.CreateDatabaseInput{
+		Slug:       "tasks",
+		Title:      "Tasks",
+		Properties: []storage.DatabasePropertyInput{{Name: "Status", Slug: "status", Type: domain.PropertyTypeSelect}},
+	})
+	require.NoError(t, err)
+	for _, title := range []string{"First", "Second"} {
+		_, err := store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
+			DatabaseID: db.ID,
+			Page:       storage.CreatePageInput{Slug: storage.Slugify(title), Title: title},
+			Values:     map[string]any{"status": "Todo"},
+		})
+		require.NoError(t, err)
+	}
+	_, err = store.CreatePage(ctx, storage.CreatePageInput{Slug: "home", Title: "Home"})
+	require.NoError(t, err)
+
+	files := readExport(t, store, "")
+	require.ElementsMatch(t, []string{"home.md", "tasks/first.md", "tasks/second.md", "tasks/tasks.csv"}, keys(files))
+	require.Equal(t, "Title,Status,Page\nFirst,Todo,first.md\nSecond,Todo,second.md\n", files["tasks/tasks.csv"])
+}
+
+func keys(m map[string]string) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
@@ -0,0 +1,306 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// Property values are stored as JSON. Every value row also carries typed
+// shadow columns, and list values one database_value_options row per element,
+// so view queries can narrow and order items with indexes instead of decoding
+// every value. The columns mirror the comparisons in storage/filter.go:
+//
+//   - value_number holds storage.NumericValue of a scalar value,
+//   - value_text holds the text a scalar value compares as,
+//   - value_date holds storage.DateValue in UTC at fixed width, so it orders as text,
+//   - value_bool holds checkbox values as 0 or 1.
+//
+// SQL conditions built from them admit a superset of the matching items;
+// storage.MatchesFilter still makes the final decision.
+
+// indexedDateLayout formats value_date so that text order is time order.
+const indexedDateLayout = "2006-01-02T15:04:05.000000000Z"
+
+// indexedValue is the typed form of a stored value.
+type indexedValue struct {
+	number  sql.NullFloat64
+	text    sql.NullString
+	date    sql.NullString
+	boolean sql.NullInt64
+	options []indexedOption
+}
+
+// indexedOption is one element of a list value.
+type indexedOption struct {
+	text   string
+	number sql.NullFloat64
+}
+
+// indexValueOf derives the typed columns of the JSON value raw.
+func indexValueOf(raw []byte) indexedValue {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil || v == nil {
+		return indexedValue{}
+	}
+	var indexed indexedValue
+	if list, ok := v.([]any); ok {
+		seen := make(map[string]bool, len(list))
+		for _, el := range list {
+			option := indexedOption{text: fmt.Sprint(el), number: indexedNumber(el)}
+			if seen[option.text] {
+				continue
+			}
+			seen[option.text] = true
+			indexed.options = append(indexed.options, option)
+		}
+		return indexed
+	}
+	indexed.number = indexedNumber(v)
+	indexed.text = sql.NullString{String: fmt.Sprint(v), Valid: true}
+	if t, ok := storage.DateValue(v); ok {
+		indexed.date = sql.NullString{String: t.UTC().Format(indexedDateLayout), Valid: true}
+	}
+	switch val := v.(type) {
+	case bool:
+		indexed.boolean = sql.NullInt64{Int64: int64(boolToInt(val)), Valid: true}
+	case string:
+		if val == "true" || val == "false" {
+			indexed.boolean = sql.NullInt64{Int64: int64(boolToInt(val == "true")), Valid: true}
+		}
+	}
+	return indexed
+}
+
+func indexedNumber(v any) sql.NullFloat64 {
+	f, ok := storage.NumericValue(v)
+	if !ok || math.IsNaN(f) {
+		return sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: f, Valid: true}
+}
+
+// indexValue refreshes the typed columns and options of the value an item
+// holds for a property. Call it after every write of database_values.value.
+func indexValue(ctx context.Context, q queryer, itemID, propertyID string, raw []byte) error {
+	indexed := indexValueOf(raw)
+	if _, err := q.ExecContext(ctx, `UPDATE database_values SET value_number = ?, value_text = ?, value_date = ?, value_bool = ? WHERE database_item_id = ? AND property_id = ?`,
+		indexed.number, indexed.text, indexed.date, indexed.boolean, itemID, propertyID); err != nil {
+		return fmt.Errorf("index value: %w", err)
+	}
+	if err := clearValueOptions(ctx, q, itemID, propertyID); err != nil {
+		return err
+	}
+	for _, option := range indexed.options {
+		if _, err := q.ExecContext(ctx, `INSERT INTO database_value_options(database_item_id, property_id, option, option_number) VALUES(?, ?, ?, ?)`,
+			itemID, propertyID, option.text, option.number); err != nil {
+			return fmt.Errorf("index value option: %w", err)
+		}
+	}
+	return nil
+}
+
+// clearValueOptions removes the indexed options of a value.
+func clearValueOptions(ctx context.Context, q queryer, itemID, propertyID string) error {
+	if _, err := q.ExecContext(ctx, `DELETE FROM database_value_options WHERE database_item_id = ? AND property_id = ?`, itemID, propertyID); err != nil {
+		return fmt.Errorf("clear value options: %w", err)
+	}
+	return nil
+}
+
+// backfillIndexedValues indexes the values stored before the typed columns
+// existed. It runs inside the migration adding them.
+func backfillIndexedValues(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, `SELECT database_item_id, property_id, value FROM database_values`)
+	if err != nil {
+		return fmt.Errorf("query values: %w", err)
+	}
+	type storedValue struct {
+		itemID, propertyID string
+		raw                []byte
+	}
+	var values []storedValue
+	for rows.Next() {
+		var value storedValue
+		var raw sql.NullString
+		if err := rows.Scan(&value.itemID, &value.propertyID, &raw); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan value: %w", err)
+		}
+		value.raw = []byte(raw.String)
+		values = append(values, value)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate values: %w", err)
+	}
+	for _, value := range values {
+		if err := indexValue(ctx, tx, value.itemID, value.propertyID, value.raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// itemQuery narrows and orders a listing of database items with the indexed
+// values. The zero value lists every item in rank order.
+type itemQuery struct {
+	where    string
+	args     []any
+	joins    string
+	joinArgs []any
+	order    string
+}
+
+// viewItemQuery translates the filters and sorts of a view. types maps
+// property ids to their types, which pick the column a sort orders by.
+func viewItemQuery(view domain.DatabaseView, types map[string]domain.PropertyType) itemQuery {
+	var query itemQuery
+	query.where, query.args = filterClause(view.Filters)
+	var joins, order []string
+	for i, sort := range view.Sorts {
+		alias := fmt.Sprintf("sv%d", i)
+		joins = append(joins, fmt.Sprintf(`LEFT JOIN database_values %[1]s ON %[1]s.database_item_id = di.id AND %[1]s.property_id = ?`, alias))
+		query.joinArgs = append(query.joinArgs, sort.PropertyID)
+		column := alias + "." + sortColumn(types[sort.PropertyID])
+		direction := "ASC"
+		if sort.Direction == "desc" {
+			direction = "DESC"
+		}
+		order = append(order, column+" IS NULL", column+" "+direction)
+	}
+	query.joins = strings.Join(joins, " ")
+	query.order = strings.Join(order, ", ")
+	return query
+}
+
+// sortColumn returns the typed column values of a property type order by.
+func sortColumn(typ domain.PropertyType) string {
+	switch typ {
+	case domain.PropertyTypeNumber:
+		return "value_number"
+	case domain.PropertyTypeDate:
+		return "value_date"
+	case domain.PropertyTypeCheckbox:
+		return "value_bool"
+	}
+	return "value_text"
+}
+
+// filterClause translates a filter tree into a condition on the items table,
+// aliased di, that holds for every item storage.MatchesFilter accepts. Conditions
+// without a usable index translate to an empty clause, i.e. no restriction.
+func filterClause(node any) (string, []any) {
+	filter, ok := node.(map[string]any)
+	if !ok || len(filter) == 0 {
+		return "", nil
+	}
+	if children, ok := filter["and"].([]any); ok {
+		var clauses []string
+		var args []any
+		for _, child := range children {
+			clause, childArgs := filterClause(child)
+			if clause == "" {
+				continue
+			}
+			clauses = append(clauses, clause)
+			args = append(args, childArgs...)
+		}
+		if len(clauses) == 0 {
+			return "", nil
+		}
+		return "(" + strings.Join(clauses, " AND ") + ")", args
+	}
+	if children, ok := filter["or"].([]any); ok {
+		if len(children) == 0 {
+			return "", nil
+		}
+		clauses := make([]string, 0, len(children))
+		var args []any
+		for _, child := range children {
+			clause, childArgs := filterClause(child)
+			if clause == "" {
+				return "", nil
+			}
+			clauses = append(clauses, clause)
+			args = append(args, childArgs...)
+		}
+		return "(" + strings.Join(clauses, " OR ") + ")", args
+	}
+	propertyID, _ := filter["property_id"].(string)
+	operator, _ := filter["operator"].(string)
+	if propertyID == "" || operator == "" {
+		return "", nil
+	}
+	return conditionClause(propertyID, operator, filter["value"])
+}
+
+const (
+	valueCondition  = `di.id IN (SELECT database_item_id FROM database_values WHERE property_id = ? AND %s)`
+	optionCondition = `di.id IN (SELECT database_item_id FROM database_value_options WHERE property_id = ? AND %s)`
+)
+
+var comparisonOperators = map[string]string{
+	"greater_than":          ">",
+	"after":                 ">",
+	"greater_than_or_equal": ">=",
+	"less_than":             "<",
+	"before":                "<",
+	"less_than_or_equal":    "<=",
+}
+
+// conditionClause translates a single condition. Equality is answered by the
+// number or text column of scalars and by the options of lists; comparisons by
+// the number or date column, keeping values of another kind since those
+// compare as text.
+func conditionClause(propertyID, operator string, expected any) (string, []any) {
+	switch operator {
+	case "is_not_empty":
+		return `di.id IN (SELECT database_item_id FROM database_values WHERE property_id = ?)`, []any{propertyID}
+	case "equals":
+		if expected == nil {
+			return "", nil
+		}
+		if x, ok := storage.NumericValue(expected); ok {
+			if math.IsNaN(x) {
+				return "", nil
+			}
+			return "(" + fmt.Sprintf(valueCondition, "value_number = ?") + " OR " + fmt.Sprintf(optionCondition, "option_number = ?") + ")",
+				[]any{propertyID, x, propertyID, x}
+		}
+		text := fmt.Sprint(expected)
+		if text == fmt.Sprint(nil) {
+			// A missing value prints the same way.
+			return "", nil
+		}
+		return "(" + fmt.Sprintf(valueCondition, "value_text = ?") + " OR " + fmt.Sprintf(optionCondition, "option = ?") + ")",
+			[]any{propertyID, text, propertyID, text}
+	}
+	op, ok := comparisonOperators[operator]
+	if !ok || expected == nil {
+		return "", nil
+	}
+	if x, ok := storage.NumericValue(expected); ok {
+		if math.IsNaN(x) {
+			return "", nil
+		}
+		return comparisonClause(propertyID, "value_number", op, x)
+	}
+	if t, ok := storage.DateValue(expected); ok {
+		return comparisonClause(propertyID, "value_date", op, t.UTC().Format(indexedDateLayout))
+	}
+	return "", nil
+}
+
+// comparisonClause compares a typed column, keeping the values without one.
+// The two sides are separate subqueries so each can use the index.
+func comparisonClause(propertyID, column, op string, bound any) (string, []any) {
+	return "(" + fmt.Sprintf(valueCondition, column+" "+op+" ?") + " OR " + fmt.Sprintf(valueCondition, column+" IS NULL") + ")",
+		[]any{propertyID, bound, propertyID}
+}
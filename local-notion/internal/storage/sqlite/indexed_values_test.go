@@ -0,0 +1,253 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+func newIndexTestDatabase(t *testing.T, store *Store) (*domain.Database, []string) {
+	t.Helper()
+	ctx := context.Background()
+	db, err := store.CreateDatabase(ctx, storage.CreateDatabaseInput{
+		Slug:  "tasks",
+		Title: "Tasks",
+		Properties: []storage.DatabasePropertyInput{
+			{Name: "Status", Slug: "status", Type: domain.PropertyTypeSelect},
+			{Name: "Points", Slug: "points", Type: domain.PropertyTypeNumber},
+			{Name: "Due", Slug: "due", Type: domain.PropertyTypeDate},
+			{Name: "Tags", Slug: "tags", Type: domain.PropertyTypeMultiSelect},
+			{Name: "Done", Slug: "done", Type: domain.PropertyTypeCheckbox},
+		},
+	})
+	require.NoError(t, err)
+	rows := []map[string]any{
+		{"status": "Todo", "points": 3, "due": "2024-05-01", "tags": []any{"ops", "infra"}, "done": false},
+		{"status": "Doing", "points": "8", "due": "2024-05-01T23:30:00-02:00", "tags": []any{"ops"}, "done": true},
+		{"status": "Done", "points": 5, "due": map[string]any{"start": "2024-04-15"}, "tags": []any{}, "done": "true"},
+		{"status": "3", "points": "many", "due": "soon", "tags": []any{3, "x"}},
+		{"points": 13, "due": "2024-06-01T08:00:00Z"},
+		{},
+	}
+	ids := make([]string, 0, len(rows))
+	for idx, values := range rows {
+		item, err := store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
+			DatabaseID: db.ID,
+			Page:       storage.CreatePageInput{Slug: string(rune('a' + idx)), Title: string(rune('A' + idx))},
+			Values:     values,
+		})
+		require.NoError(t, err)
+		ids = append(ids, item.ID)
+	}
+	return db, ids
+}
+
+func TestFilterClauseKeepsMatchingItems(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db, _ := newIndexTestDatabase(t, store)
+	status := propertyIDBySlug(t, db, "status")
+	points := propertyIDBySlug(t, db, "points")
+	due := propertyIDBySlug(t, db, "due")
+	tags := propertyIDBySlug(t, db, "tags")
+	done := propertyIDBySlug(t, db, "done")
+
+	condition := func(prop, operator string, value any) map[string]any {
+		return map[string]any{"property_id": prop, "operator": operator, "value": value}
+	}
+	filters := []map[string]any{
+		condition(status, "equals", "Doing"),
+		condition(status, "equals", 3),
+		condition(points, "equals", 8),
+		condition(points, "equals", "5"),
+		condition(tags, "equals", "ops"),
+		condition(tags, "equals", 3),
+		condition(done, "equals", true),
+		condition(done, "equals", "true"),
+		condition(points, "greater_than", 4),
+		condition(points, "less_than_or_equal", "5"),
+		condition(points, "greater_than", "many"),
+		condition(due, "before", "2024-05-02"),
+		condition(due, "after", "2024-05-01T12:00:00Z"),
+		condition(due, "greater_than_or_equal", "2024-05-02T01:30:00Z"),
+		condition(tags, "is_not_empty", nil),
+		condition(tags, "is_empty", nil),
+		condition(status, "not_equals", "Todo"),
+		condition(status, "contains", "do"),
+		{"and": []any{condition(points, "greater_than", 2), condition(tags, "equals", "ops")}},
+		{"or": []any{condition(status, "equals", "Done"), condition(points, "equals", 13)}},
+		{"or": []any{condition(status, "equals", "Done"), condition(status, "contains", "o")}},
+	}
+
+	all, err := store.listDatabaseItems(ctx, db.ID)
+	require.NoError(t, err)
+	for _, filter := range filters {
+		var want []string
+		for _, item := range all {
+			if storage.MatchesFilter(filter, storage.ItemValuesByID(item)) {
+				want = append(want, item.ID)
+			}
+		}
+		where, args := filterClause(filter)
+		narrowed, err := store.queryDatabaseItems(ctx, db.ID, itemQuery{where: where, args: args})
+		require.NoError(t, err, "filter %v", filter)
+		got := make([]string, 0, len(narrowed))
+		for _, item := range narrowed {
+			got = append(got, item.ID)
+		}
+		require.Subset(t, got, want, "filter %v", filter)
+
+		view, err := store.CreateDatabaseView(ctx, db.ID, storage.DatabaseViewInput{Name: "Filtered", Type: domain.ViewTypeTable, Filters: filter})
+		require.NoError(t, err)
+		result, err := store.QueryView(ctx, db.ID, view.ID)
+		require.NoError(t, err)
+		var matched []string
+		for _, item := range result.Items {
+			matched = append(matched, item.ID)
+		}
+		require.Equal(t, want, matched, "filter %v", filter)
+	}
+
+	// Conditions with an index narrow the items in SQL.
+	where, args := filterClause(condition(points, "equals", 8))
+	narrowed, err := store.queryDatabaseItems(ctx, db.ID, itemQuery{where: where, args: args})
+	require.NoError(t, err)
+	require.Len(t, narrowed, 1)
+	require.Equal(t, "b", narrowed[0].Page.Slug)
+	require.Len(t, narrowed[0].PropertyMap, 5)
+}
+
+func TestStoreQueryViewSorts(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db, _ := newIndexTestDatabase(t, store)
+	points := propertyIDBySlug(t, db, "points")
+	due := propertyIDBySlug(t, db, "due")
+	done := propertyIDBySlug(t, db, "done")
+
+	slugs := func(sorts ...domain.ViewSort) []string {
+		t.Helper()
+		view, err := store.CreateDatabaseView(ctx, db.ID, storage.DatabaseViewInput{Name: "Sorted", Type: domain.ViewTypeTable, Sorts: sorts})
+		require.NoError(t, err)
+		result, err := store.QueryView(ctx, db.ID, view.ID)
+		require.NoError(t, err)
+		out := make([]string, 0, len(result.Items))
+		for _, item := range result.Items {
+			out = append(out, item.Page.Slug)
+		}
+		return out
+	}
+
+	require.Equal(t, []string{"a", "b", "c", "d", "e", "f"}, slugs())
+	// Values that are not numbers sort with missing values, after the rest.
+	require.Equal(t, []string{"e", "b", "c", "a", "d", "f"}, slugs(domain.ViewSort{PropertyID: points, Direction: "desc"}))
+	// Dates order by instant regardless of their offset.
+	require.Equal(t, []string{"c", "a", "b", "e", "d", "f"}, slugs(domain.ViewSort{PropertyID: due, Direction: "asc"}))
+	require.Equal(t, []string{"c", "b", "a", "e", "d", "f"}, slugs(
+		domain.ViewSort{PropertyID: done, Direction: "desc"},
+		domain.ViewSort{PropertyID: points, Direction: "asc"},
+	))
+}
+
+func TestIndexedValuesFollowWrites(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db, ids := newIndexTestDatabase(t, store)
+	tags := propertyIDBySlug(t, db, "tags")
+	points := propertyIDBySlug(t, db, "points")
+
+	options := func(itemID string) []string {
+		t.Helper()
+		rows, err := store.db.QueryContext(ctx, `SELECT option FROM database_value_options WHERE database_item_id = ? ORDER BY option`, itemID)
+		require.NoError(t, err)
+		defer rows.Close()
+		var out []string
+		for rows.Next() {
+			var option string
+			require.NoError(t, rows.Scan(&option))
+			out = append(out, option)
+		}
+		require.NoError(t, rows.Err())
+		return out
+	}
+	number := func(itemID string) sql.NullFloat64 {
+		t.Helper()
+		var value sql.NullFloat64
+		err := store.db.QueryRowContext(ctx, `SELECT value_number FROM database_values WHERE database_item_id = ? AND property_id = ?`, itemID, points).Scan(&value)
+		require.NoError(t, err)
+		return value
+	}
+
+	require.Equal(t, []string{"infra", "ops"}, options(ids[0]))
+	require.Equal(t, sql.NullFloat64{Float64: 3, Valid: true}, number(ids[0]))
+
+	_, err := store.UpdateDatabaseItem(ctx, storage.UpdateDatabaseItemInput{
+		DatabaseID: db.ID,
+		ItemID:     ids[0],
+		Values:     map[string]any{"tags": []any{"web"}, "points": "n/a"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"web"}, options(ids[0]))
+	require.False(t, number(ids[0]).Valid)
+
+	_, err = store.UpdateDatabaseItem(ctx, storage.UpdateDatabaseItemInput{
+		DatabaseID: db.ID,
+		ItemID:     ids[0],
+		Values:     map[string]any{"tags": nil},
+	})
+	require.NoError(t, err)
+	require.Empty(t, options(ids[0]))
+
+	require.NoError(t, store.DeleteDatabaseItem(ctx, db.ID, ids[1]))
+	require.Empty(t, options(ids[1]))
+
+	copied, err := store.DuplicateDatabase(ctx, storage.DuplicateDatabaseInput{DatabaseID: db.ID, Slug: "tasks-copy", Title: "Tasks copy"})
+	require.NoError(t, err)
+	copiedTags := propertyIDBySlug(t, copied, "tags")
+	var count int
+	require.NoError(t, store.db.QueryRowContext(ctx, `SELECT COUNT(1) FROM database_value_options WHERE property_id = ?`, copiedTags).Scan(&count))
+	require.Equal(t, 2, count)
+	require.NotEqual(t, tags, copiedTags)
+}
+
+func TestMigrationBackfillsIndexedValues(t *testing.T) {
+	store := newFileTestStore(t, 0)
+	ctx := context.Background()
+	db, ids := newIndexTestDatabase(t, store)
+	points := propertyIDBySlug(t, db, "points")
+
+	// Roll the schema back to before the typed columns existed.
+	for _, stmt := range []string{
+		`DROP INDEX idx_database_values_number`,
+		`DROP INDEX idx_database_values_text`,
+		`DROP INDEX idx_database_values_date`,
+		`DROP INDEX idx_database_values_bool`,
+		`DROP TABLE database_value_options`,
+		`ALTER TABLE database_values DROP COLUMN value_number`,
+		`ALTER TABLE database_values DROP COLUMN value_text`,
+		`ALTER TABLE database_values DROP COLUMN value_date`,
+		`ALTER TABLE database_values DROP COLUMN value_bool`,
+		`DELETE FROM schema_migrations WHERE version = '011_typed_values.sql'`,
+	} {
+		_, err := store.db.ExecContext(ctx, stmt)
+		require.NoError(t, err, stmt)
+	}
+	require.NoError(t, applyMigrations(store.db))
+
+	var number sql.NullFloat64
+	var date sql.NullString
+	err := store.db.QueryRowContext(ctx, `SELECT value_number FROM database_values WHERE database_item_id = ? AND property_id = ?`, ids[1], points).Scan(&number)
+	require.NoError(t, err)
+	require.Equal(t, sql.NullFloat64{Float64: 8, Valid: true}, number)
+	err = store.db.QueryRowContext(ctx, `SELECT value_date FROM database_values WHERE database_item_id = ? AND property_id = ?`, ids[1], propertyIDBySlug(t, db, "due")).Scan(&date)
+	require.NoError(t, err)
+	require.Equal(t, "2024-05-02T01:30:00.000000000Z", date.String)
+	var options int
+	require.NoError(t, store.db.QueryRowContext(ctx, `SELECT COUNT(1) FROM database_value_options`).Scan(&options))
+	require.Equal(t, 5, options)
+}
@@ -0,0 +1,477 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// GetDatabaseItem loads a single item with its page and values.
+func (s *Store) GetDatabaseItem(ctx context.Context, databaseID, itemID string) (*domain.DatabaseItem, error) {
+	item, err := loadDatabaseItem(ctx, s.reader, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if item.DatabaseID != databaseID {
+		return nil, storage.ErrItemNotFound
+	}
+	return item, nil
+}
+
+// UpdateDatabaseItem applies a partial update to an item's page and values.
+func (s *Store) UpdateDatabaseItem(ctx context.Context, in storage.UpdateDatabaseItemInput) (*domain.DatabaseItem, error) {
+	if err := storage.RequireFields("database_id", in.DatabaseID, "item_id", in.ItemID); err != nil {
+		return nil, err
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	item, err := s.reviseDatabaseItem(ctx, tx, in, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit item update: %w", err)
+	}
+	return item, nil
+}
+
+// ArchiveDatabaseItem archives or restores an item and its backing page.
+// Archived items are hidden from view listings but keep their values.
+func (s *Store) ArchiveDatabaseItem(ctx context.Context, databaseID, itemID string, archived bool) (*domain.DatabaseItem, error) {
+	return s.UpdateDatabaseItem(ctx, storage.UpdateDatabaseItemInput{DatabaseID: databaseID, ItemID: itemID, Archived: &archived})
+}
+
+// DeleteDatabaseItem permanently removes an item, its values and its page.
+func (s *Store) DeleteDatabaseItem(ctx context.Context, databaseID, itemID string) error {
+	if err := storage.RequireFields("database_id", databaseID, "item_id", itemID); err != nil {
+		return err
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	if err := deleteDatabaseItem(ctx, tx, databaseID, itemID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit item delete: %w", err)
+	}
+	return nil
+}
+
+// MoveDatabaseItem reorders an item by giving it a rank between its new
+// neighbours. Only the moved row is rewritten unless the keys around the
+// target have run out of room, in which case the database is rebalanced.
+func (s *Store) MoveDatabaseItem(ctx context.Context, in storage.MoveDatabaseItemInput) (*domain.DatabaseItem, error) {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	item, err := moveDatabaseItem(ctx, tx, in, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit item move: %w", err)
+	}
+	return item, nil
+}
+
+// reviseDatabaseItem updates an item and records a revision of its page.
+func (s *Store) reviseDatabaseItem(ctx context.Context, q queryer, in storage.UpdateDatabaseItemInput, now time.Time) (*domain.DatabaseItem, error) {
+	pageID, err := itemPageID(ctx, q, in.DatabaseID, in.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensurePageBaseline(ctx, q, pageID, now); err != nil {
+		return nil, err
+	}
+	item, err := s.updateDatabaseItem(ctx, q, in, now)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.recordPageRevision(ctx, q, pageID, storage.RevisionActionUpdate, in.Author, nil, now); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// updateDatabaseItem applies a partial update to an item page and its values.
+func (s *Store) updateDatabaseItem(ctx context.Context, q queryer, in storage.UpdateDatabaseItemInput, now time.Time) (*domain.DatabaseItem, error) {
+	pageID, err := itemPageID(ctx, q, in.DatabaseID, in.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	before, err := loadDatabaseItem(ctx, q, in.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.CheckVersion(in.ExpectedVersion, before.Version); err != nil {
+		return nil, err
+	}
+	sets := []string{"updated_at = ?", "version = version + 1"}
+	args := []any{now}
+	if in.Title != nil {
+		if strings.TrimSpace(*in.Title) == "" {
+			return nil, storage.InvalidField("title", "cannot be empty")
+		}
+		sets = append(sets, "title = ?")
+		args = append(args, *in.Title)
+	}
+	if in.Summary != nil {
+		sets = append(sets, "summary = ?")
+		args = append(args, *in.Summary)
+	}
+	if in.Content != nil {
+		sets = append(sets, "content = ?")
+		args = append(args, *in.Content)
+	}
+	if in.Tags != nil {
+		tagJSON, err := json.Marshal(in.Tags)
+		if err != nil {
+			return nil, fmt.Errorf("marshal tags: %w", err)
+		}
+		sets = append(sets, "tags = ?")
+		args = append(args, string(tagJSON))
+	}
+	if in.Archived != nil {
+		sets = append(sets, "is_archived = ?")
+		args = append(args, boolToInt(*in.Archived))
+	}
+	args = append(args, pageID)
+	if _, err := q.ExecContext(ctx, `UPDATE pages SET `+strings.Join(sets, ", ")+` WHERE id = ?`, args...); err != nil {
+		return nil, fmt.Errorf("update item page: %w", err)
+	}
+	if in.Content != nil {
+		if err := reanchorCommentThreads(ctx, q, pageID, *in.Content); err != nil {
+			return nil, err
+		}
+	}
+	if len(in.Values) > 0 {
+		schema, err := s.schema(ctx, q, in.DatabaseID)
+		if err != nil {
+			return nil, err
+		}
+		for slug, value := range in.Values {
+			propID, ok := schema.slugs[slug]
+			if !ok {
+				return nil, storage.InvalidField("values."+slug, "refers to an unknown property")
+			}
+			if value == nil {
+				if _, err := q.ExecContext(ctx, `DELETE FROM database_values WHERE database_item_id = ? AND property_id = ?`, in.ItemID, propID); err != nil {
+					return nil, fmt.Errorf("clear value %s: %w", slug, err)
+				}
+				if err := clearValueOptions(ctx, q, in.ItemID, propID); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			raw, err := json.Marshal(value)
+			if err != nil {
+				return nil, fmt.Errorf("marshal value %s: %w", slug, err)
+			}
+			if _, err := q.ExecContext(ctx, `INSERT INTO database_values(id, database_item_id, property_id, value, is_computed, created_at, updated_at) VALUES(?, ?, ?, ?, 0, ?, ?)
+ON CONFLICT(database_item_id, property_id) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+				uuid.NewString(), in.ItemID, propID, string(raw), now, now); err != nil {
+				return nil, fmt.Errorf("upsert value %s: %w", slug, err)
+			}
+			if err := indexValue(ctx, q, in.ItemID, propID, raw); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if in.Archived != nil {
+		if _, err := q.ExecContext(ctx, `UPDATE database_items SET is_archived = ?, version = version + 1, updated_at = ? WHERE id = ?`, boolToInt(*in.Archived), now, in.ItemID); err != nil {
+			return nil, fmt.Errorf("archive item: %w", err)
+		}
+	} else if _, err := q.ExecContext(ctx, `UPDATE database_items SET version = version + 1, updated_at = ? WHERE id = ?`, now, in.ItemID); err != nil {
+		return nil, fmt.Errorf("touch item: %w", err)
+	}
+	after, err := loadDatabaseItem(ctx, q, in.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	if err := auditItemUpdate(ctx, q, in, before, after, now); err != nil {
+		return nil, err
+	}
+	actor := storage.ChangeActor(ctx, in.Author)
+	if in.Content != nil {
+		if err := notifyContentMentions(ctx, q, pageID, actor, before.Page.Content, *in.Content, now); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.notifyItemChange(ctx, q, actor, before, after, now); err != nil {
+		return nil, err
+	}
+	if err := scheduleItemReminders(ctx, q, in.ItemID, now); err != nil {
+		return nil, err
+	}
+	if err := s.advanceCompletedSeries(ctx, q, before, after, now); err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// deleteDatabaseItem removes an item together with its values, backing page
+// and the links of that page. Child pages of the item page are detached.
+func deleteDatabaseItem(ctx context.Context, q queryer, databaseID, itemID string) error {
+	pageID, err := itemPageID(ctx, q, databaseID, itemID)
+	if err != nil {
+		return err
+	}
+	before, err := loadDatabaseItem(ctx, q, itemID)
+	if err != nil {
+		return err
+	}
+	statements := []struct {
+		query string
+		arg   string
+		label string
+	}{
+		{`DELETE FROM database_value_options WHERE database_item_id = ?`, itemID, "value options"},
+		{`DELETE FROM database_values WHERE database_item_id = ?`, itemID, "values"},
+		{`DELETE FROM database_items WHERE id = ?`, itemID, "item"},
+		{`DELETE FROM page_links WHERE source_page_id = ?1 OR target_page_id = ?1`, pageID, "page links"},
+		{`UPDATE pages SET parent_page_id = NULL, version = version + 1 WHERE parent_page_id = ?`, pageID, "child pages"},
+		{`DELETE FROM pages WHERE id = ?`, pageID, "page"},
+	}
+	for _, stmt := range statements {
+		if _, err := q.ExecContext(ctx, stmt.query, stmt.arg); err != nil {
+			return fmt.Errorf("delete item %s: %w", stmt.label, err)
+		}
+	}
+	return recordAudit(ctx, q, storage.AuditActionDelete, storage.AuditEntityItem, itemID, before, nil, time.Now().UTC())
+}
+
+// moveDatabaseItem computes a rank between the target neighbours and stores it
+// on the moved item.
+func moveDatabaseItem(ctx context.Context, q queryer, in storage.MoveDatabaseItemInput, now time.Time) (*domain.DatabaseItem, error) {
+	if (in.BeforeID == "") == (in.AfterID == "") {
+		return nil, storage.ValidationError(
+			storage.FieldError{Field: "before_id", Message: "exactly one of before_id or after_id is required"},
+			storage.FieldError{Field: "after_id", Message: "exactly one of before_id or after_id is required"},
+		)
+	}
+	if in.BeforeID == in.ItemID || in.AfterID == in.ItemID {
+		return nil, storage.InvalidField("item_id", "cannot move an item relative to itself")
+	}
+	if _, err := itemPageID(ctx, q, in.DatabaseID, in.ItemID); err != nil {
+		return nil, err
+	}
+	before, err := loadDatabaseItem(ctx, q, in.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	var rank string
+	for attempt := 0; attempt < 2; attempt++ {
+		lower, upper, err := moveBounds(ctx, q, in)
+		if err != nil {
+			return nil, err
+		}
+		rank, err = storage.RankBetween(lower, upper)
+		if err == nil && len(rank) <= storage.MaxRankLength {
+			break
+		}
+		if attempt == 1 {
+			return nil, fmt.Errorf("compute item rank: %w", storage.ErrRankOrder)
+		}
+		if err := rebalanceItemRanks(ctx, q, in.DatabaseID); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := q.ExecContext(ctx, `UPDATE database_items SET rank = ?, version = version + 1, updated_at = ? WHERE id = ?`, rank, now, in.ItemID); err != nil {
+		return nil, fmt.Errorf("move item: %w", err)
+	}
+	after, err := loadDatabaseItem(ctx, q, in.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordAudit(ctx, q, storage.AuditActionMove, storage.AuditEntityItem, in.ItemID, storage.ItemWithoutValues(before), storage.ItemWithoutValues(after), now); err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// moveBounds returns the ranks the moved item must fall between, ignoring the
+// moved item itself.
+func moveBounds(ctx context.Context, q queryer, in storage.MoveDatabaseItemInput) (string, string, error) {
+	anchorID := in.AfterID
+	if in.BeforeID != "" {
+		anchorID = in.BeforeID
+	}
+	var anchor string
+	err := q.QueryRowContext(ctx, `SELECT rank FROM database_items WHERE id = ? AND database_id = ?`, anchorID, in.DatabaseID).Scan(&anchor)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", storage.ErrItemNotFound
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("load anchor item: %w", err)
+	}
+	var neighbour sql.NullString
+	if in.AfterID != "" {
+		err = q.QueryRowContext(ctx, `SELECT MIN(rank) FROM database_items WHERE database_id = ? AND id != ? AND rank > ?`, in.DatabaseID, in.ItemID, anchor).Scan(&neighbour)
+		if err != nil {
+			return "", "", fmt.Errorf("load next item: %w", err)
+		}
+		return anchor, neighbour.String, nil
+	}
+	err = q.QueryRowContext(ctx, `SELECT MAX(rank) FROM database_items WHERE database_id = ? AND id != ? AND rank < ?`, in.DatabaseID, in.ItemID, anchor).Scan(&neighbour)
+	if err != nil {
+		return "", "", fmt.Errorf("load previous item: %w", err)
+	}
+	return neighbour.String, anchor, nil
+}
+
+// nextItemRank returns a rank that sorts after every item of the database.
+func nextItemRank(ctx context.Context, q queryer, databaseID string) (string, error) {
+	var last sql.NullString
+	if err := q.QueryRowContext(ctx, `SELECT MAX(rank) FROM database_items WHERE database_id = ?`, databaseID).Scan(&last); err != nil {
+		return "", fmt.Errorf("load last item rank: %w", err)
+	}
+	rank := storage.RankAfter(last.String)
+	if len(rank) <= storage.MaxRankLength {
+		return rank, nil
+	}
+	if err := rebalanceItemRanks(ctx, q, databaseID); err != nil {
+		return "", err
+	}
+	if err := q.QueryRowContext(ctx, `SELECT MAX(rank) FROM database_items WHERE database_id = ?`, databaseID).Scan(&last); err != nil {
+		return "", fmt.Errorf("load last item rank: %w", err)
+	}
+	return storage.RankAfter(last.String), nil
+}
+
+// rebalanceItemRanks rewrites every rank of a database with evenly spaced keys
+// while preserving the current order. Items without a rank yet keep their
+// legacy position order. Respacing is not an edit of the items, so their
+// version and updated_at are left alone and open editors keep a valid ETag.
+func rebalanceItemRanks(ctx context.Context, q queryer, databaseID string) error {
+	rows, err := q.QueryContext(ctx, `SELECT id FROM database_items WHERE database_id = ? ORDER BY rank ASC, position ASC, created_at ASC, id ASC`, databaseID)
+	if err != nil {
+		return fmt.Errorf("query item order: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan item order: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("iterate item order: %w", err)
+	}
+	rows.Close()
+	for idx, key := range storage.RankSequence(len(ids)) {
+		if _, err := q.ExecContext(ctx, `UPDATE database_items SET rank = ? WHERE id = ?`, key, ids[idx]); err != nil {
+			return fmt.Errorf("rebalance item rank: %w", err)
+		}
+	}
+	return nil
+}
+
+// backfillItemRanks assigns ranks to items created before ranks existed,
+// following their legacy integer positions.
+func backfillItemRanks(db *sql.DB) error {
+	ctx := context.Background()
+	rows, err := db.QueryContext(ctx, `SELECT DISTINCT database_id FROM database_items WHERE rank = ''`)
+	if err != nil {
+		return fmt.Errorf("query unranked items: %w", err)
+	}
+	var databaseIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan unranked database: %w", err)
+		}
+		databaseIDs = append(databaseIDs, id)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("iterate unranked items: %w", err)
+	}
+	rows.Close()
+	for _, id := range databaseIDs {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("begin rank backfill: %w", err)
+		}
+		if err := rebalanceItemRanks(ctx, tx, id); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("commit rank backfill: %w", err)
+		}
+	}
+	return nil
+}
+
+func itemPageID(ctx context.Context, q queryer, databaseID, itemID string) (string, error) {
+	var pageID string
+	err := q.QueryRowContext(ctx, `SELECT page_id FROM database_items WHERE id = ? AND database_id = ?`, itemID, databaseID).Scan(&pageID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", storage.ErrItemNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("load item: %w", err)
+	}
+	return pageID, nil
+}
+
+// loadDatabaseItem loads an item with its page and values keyed by property slug.
+func loadDatabaseItem(ctx context.Context, q queryer, itemID string) (*domain.DatabaseItem, error) {
+	var item domain.DatabaseItem
+	var pageID string
+	err := q.QueryRowContext(ctx, `SELECT id, database_id, page_id, position, rank, is_archived, version, created_at, updated_at FROM database_items WHERE id = ?`, itemID).
+		Scan(&item.ID, &item.DatabaseID, &pageID, &item.Position, &item.Rank, &item.IsArchived, &item.Version, &item.CreatedAt, &item.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrItemNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load item: %w", err)
+	}
+	page, err := loadPageRow(ctx, q, pageID)
+	if err != nil {
+		return nil, err
+	}
+	if page != nil {
+		item.Page = *page
+	}
+	slugs, err := propertySlugs(ctx, q, item.DatabaseID)
+	if err != nil {
+		return nil, err
+	}
+	propSlugs := make(map[string]string, len(slugs))
+	for slug, id := range slugs {
+		propSlugs[id] = slug
+	}
+	values, err := loadItemValues(ctx, q, item.ID)
+	if err != nil {
+		return nil, err
+	}
+	item.PropertyMap = make(map[string]domain.DatabaseValue, len(values))
+	for _, value := range values {
+		if slug, ok := propSlugs[value.PropertyID]; ok {
+			item.PropertyMap[slug] = value
+		}
+	}
+	return &item, nil
+}
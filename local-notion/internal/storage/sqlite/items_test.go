@@ -0,0 +1,188 @@
+package sqlite
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+func createTestItems(t *testing.T, store *Store, db *domain.Database, slugs ...string) []*domain.DatabaseItem {
+	t.Helper()
+	items := make([]*domain.DatabaseItem, 0, len(slugs))
+	for _, slug := range slugs {
+		item, err := store.CreateDatabaseItem(context.Background(), storage.CreateDatabaseItemInput{
+			DatabaseID: db.ID,
+			Page:       storage.CreatePageInput{Slug: slug, Title: strings.ToUpper(slug)},
+		})
+		require.NoError(t, err)
+		items = append(items, item)
+	}
+	return items
+}
+
+func viewItemTitles(t *testing.T, store *Store, db *domain.Database) []string {
+	t.Helper()
+	items, err := store.ListViewItems(context.Background(), db.ID, db.Views[0].ID)
+	require.NoError(t, err)
+	titles := make([]string, 0, len(items))
+	for _, item := range items {
+		titles = append(titles, item.Page.Title)
+	}
+	return titles
+}
+
+func TestStoreUpdateAndArchiveDatabaseItem(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db := newBulkTestDatabase(t, store)
+	items := createTestItems(t, store, db, "a", "b")
+
+	updated, err := store.UpdateDatabaseItem(ctx, storage.UpdateDatabaseItemInput{
+		DatabaseID: db.ID,
+		ItemID:     items[0].ID,
+		Title:      strPtr("Alpha"),
+		Tags:       []string{"urgent"},
+		Values:     map[string]any{"status": "Done", "points": 5},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Alpha", updated.Page.Title)
+	require.Equal(t, []string{"urgent"}, updated.Page.Tags)
+	require.Equal(t, "Done", updated.PropertyMap["status"].RawValue)
+
+	cleared, err := store.UpdateDatabaseItem(ctx, storage.UpdateDatabaseItemInput{DatabaseID: db.ID, ItemID: items[0].ID, Values: map[string]any{"points": nil}})
+	require.NoError(t, err)
+	_, hasPoints := cleared.PropertyMap["points"]
+	require.False(t, hasPoints)
+	require.Equal(t, "Done", cleared.PropertyMap["status"].RawValue)
+
+	_, err = store.UpdateDatabaseItem(ctx, storage.UpdateDatabaseItemInput{DatabaseID: db.ID, ItemID: items[0].ID, Values: map[string]any{"unknown": 1}})
+	require.Error(t, err)
+
+	archived, err := store.ArchiveDatabaseItem(ctx, db.ID, items[1].ID, true)
+	require.NoError(t, err)
+	require.True(t, archived.IsArchived)
+	require.Equal(t, []string{"Alpha"}, viewItemTitles(t, store, db))
+
+	_, err = store.ArchiveDatabaseItem(ctx, db.ID, items[1].ID, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Alpha", "B"}, viewItemTitles(t, store, db))
+
+	_, err = store.GetDatabaseItem(ctx, "other-db", items[0].ID)
+	require.ErrorIs(t, err, storage.ErrItemNotFound)
+}
+
+func TestStoreDatabaseItemVersions(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db := newBulkTestDatabase(t, store)
+	item := createTestItems(t, store, db, "a")[0]
+	require.Equal(t, 1, item.Version)
+
+	updated, err := store.UpdateDatabaseItem(ctx, storage.UpdateDatabaseItemInput{DatabaseID: db.ID, ItemID: item.ID, Values: map[string]any{"status": "Done"}, ExpectedVersion: intPtr(1)})
+	require.NoError(t, err)
+	require.Equal(t, 2, updated.Version)
+
+	_, err = store.UpdateDatabaseItem(ctx, storage.UpdateDatabaseItemInput{DatabaseID: db.ID, ItemID: item.ID, Title: strPtr("Stale"), ExpectedVersion: intPtr(1)})
+	require.ErrorIs(t, err, storage.ErrVersionMismatch)
+	require.ErrorIs(t, err, storage.ErrPreconditionFailed)
+
+	// Editing the backing page changes the item too.
+	_, err = store.UpdatePage(ctx, storage.UpdatePageInput{PageID: item.Page.ID, Content: strPtr("notes")})
+	require.NoError(t, err)
+	reloaded, err := store.GetDatabaseItem(ctx, db.ID, item.ID)
+	require.NoError(t, err)
+	require.Equal(t, 3, reloaded.Version)
+	require.Equal(t, 3, reloaded.Page.Version)
+
+	// A view change is part of the database representation.
+	_, err = store.UpdateDatabaseView(ctx, storage.UpdateDatabaseViewInput{DatabaseID: db.ID, ViewID: db.Views[0].ID, Name: strPtr("Renamed"), ExpectedVersion: intPtr(1)})
+	require.NoError(t, err)
+	loaded, err := store.GetDatabase(ctx, db.ID)
+	require.NoError(t, err)
+	require.Equal(t, db.Version+1, loaded.Version)
+}
+
+func TestStoreDeleteDatabaseItem(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db := newBulkTestDatabase(t, store)
+	items := createTestItems(t, store, db, "a", "b")
+
+	require.NoError(t, store.DeleteDatabaseItem(ctx, db.ID, items[0].ID))
+	require.Equal(t, []string{"B"}, viewItemTitles(t, store, db))
+
+	_, err := store.GetPage(ctx, items[0].Page.ID)
+	require.ErrorIs(t, err, storage.ErrPageNotFound)
+
+	require.ErrorIs(t, store.DeleteDatabaseItem(ctx, db.ID, items[0].ID), storage.ErrItemNotFound)
+}
+
+func TestStoreMoveDatabaseItem(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db := newBulkTestDatabase(t, store)
+	items := createTestItems(t, store, db, "a", "b", "c", "d")
+
+	moved, err := store.MoveDatabaseItem(ctx, storage.MoveDatabaseItemInput{DatabaseID: db.ID, ItemID: items[3].ID, BeforeID: items[0].ID})
+	require.NoError(t, err)
+	require.Less(t, moved.Rank, items[0].Rank)
+	require.Equal(t, []string{"D", "A", "B", "C"}, viewItemTitles(t, store, db))
+
+	_, err = store.MoveDatabaseItem(ctx, storage.MoveDatabaseItemInput{DatabaseID: db.ID, ItemID: items[0].ID, AfterID: items[2].ID})
+	require.NoError(t, err)
+	require.Equal(t, []string{"D", "B", "C", "A"}, viewItemTitles(t, store, db))
+
+	// Only the moved row is rewritten.
+	b, err := store.GetDatabaseItem(ctx, db.ID, items[1].ID)
+	require.NoError(t, err)
+	require.Equal(t, items[1].Rank, b.Rank)
+
+	_, err = store.MoveDatabaseItem(ctx, storage.MoveDatabaseItemInput{DatabaseID: db.ID, ItemID: items[0].ID})
+	require.Error(t, err)
+	_, err = store.MoveDatabaseItem(ctx, storage.MoveDatabaseItemInput{DatabaseID: db.ID, ItemID: items[0].ID, AfterID: "missing"})
+	require.ErrorIs(t, err, storage.ErrItemNotFound)
+}
+
+func TestStoreMoveDatabaseItemRebalances(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db := newBulkTestDatabase(t, store)
+	items := createTestItems(t, store, db, "a", "b", "c")
+
+	// Repeatedly dropping items into the same gap grows the keys until the
+	// database has to be respaced.
+	for i := 0; i < 400; i++ {
+		mover := items[1+i%2]
+		item, err := store.MoveDatabaseItem(ctx, storage.MoveDatabaseItemInput{DatabaseID: db.ID, ItemID: mover.ID, AfterID: items[0].ID})
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(item.Rank), storage.MaxRankLength)
+	}
+	titles := viewItemTitles(t, store, db)
+	require.Equal(t, "A", titles[0])
+	require.Len(t, titles, 3)
+
+	// Respacing rewrote the rank of the item that was never moved without
+	// touching its version.
+	first, err := store.GetDatabaseItem(ctx, db.ID, items[0].ID)
+	require.NoError(t, err)
+	require.NotEqual(t, items[0].Rank, first.Rank)
+	require.Equal(t, items[0].Version, first.Version)
+	require.True(t, items[0].UpdatedAt.Equal(first.UpdatedAt))
+}
+
+func TestOpenBackfillsItemRanks(t *testing.T) {
+	store := newTestStore(t)
+	db := newBulkTestDatabase(t, store)
+	items := createTestItems(t, store, db, "a", "b", "c")
+
+	_, err := store.db.Exec(`UPDATE database_items SET rank = '', position = CASE id WHEN ? THEN 0 WHEN ? THEN 2 ELSE 1 END`, items[2].ID, items[0].ID)
+	require.NoError(t, err)
+	require.NoError(t, backfillItemRanks(store.db))
+
+	require.Equal(t, []string{"C", "B", "A"}, viewItemTitles(t, store, db))
+}
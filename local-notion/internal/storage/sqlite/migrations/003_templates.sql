@@ -0,0 +1,18 @@
+ALTER TABLE pages ADD COLUMN is_template INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE databases ADD COLUMN is_template INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS database_item_templates (
+    id TEXT PRIMARY KEY,
+    database_id TEXT NOT NULL REFERENCES databases(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    title TEXT,
+    summary TEXT,
+    content TEXT,
+    tags TEXT,
+    item_values TEXT,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_pages_template ON pages(is_template);
+CREATE INDEX IF NOT EXISTS idx_database_item_templates_database ON database_item_templates(database_id);
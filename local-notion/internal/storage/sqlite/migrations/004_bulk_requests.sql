@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS bulk_requests (
+    database_id TEXT NOT NULL REFERENCES databases(id) ON DELETE CASCADE,
+    idempotency_key TEXT NOT NULL,
+    request_hash TEXT NOT NULL,
+    response TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (database_id, idempotency_key)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE database_items ADD COLUMN rank TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_database_items_rank ON database_items(database_id, rank);
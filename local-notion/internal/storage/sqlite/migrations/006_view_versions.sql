@@ -0,0 +1,12 @@
+ALTER TABLE database_views ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+CREATE TABLE IF NOT EXISTS database_view_versions (
+    view_id TEXT NOT NULL,
+    database_id TEXT NOT NULL REFERENCES databases(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    snapshot TEXT,
+    restored_from INTEGER,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (view_id, version)
+);
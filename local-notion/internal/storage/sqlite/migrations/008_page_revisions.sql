@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS page_revisions (
+    page_id TEXT NOT NULL REFERENCES pages(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    title TEXT NOT NULL,
+    content TEXT NOT NULL,
+    tags TEXT NOT NULL,
+    links TEXT NOT NULL,
+    author TEXT NOT NULL DEFAULT '',
+    restored_from INTEGER,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (page_id, revision)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE pages ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE databases ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE database_items ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
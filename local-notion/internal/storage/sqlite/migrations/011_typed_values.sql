@@ -0,0 +1,19 @@
+ALTER TABLE database_values ADD COLUMN value_number REAL;
+ALTER TABLE database_values ADD COLUMN value_text TEXT;
+ALTER TABLE database_values ADD COLUMN value_date TEXT;
+ALTER TABLE database_values ADD COLUMN value_bool INTEGER;
+
+CREATE TABLE IF NOT EXISTS database_value_options (
+    database_item_id TEXT NOT NULL REFERENCES database_items(id) ON DELETE CASCADE,
+    property_id TEXT NOT NULL REFERENCES database_properties(id) ON DELETE CASCADE,
+    option TEXT NOT NULL,
+    option_number REAL,
+    PRIMARY KEY (database_item_id, property_id, option)
+);
+
+CREATE INDEX IF NOT EXISTS idx_database_values_number ON database_values(property_id, value_number);
+CREATE INDEX IF NOT EXISTS idx_database_values_text ON database_values(property_id, value_text);
+CREATE INDEX IF NOT EXISTS idx_database_values_date ON database_values(property_id, value_date);
+CREATE INDEX IF NOT EXISTS idx_database_values_bool ON database_values(property_id, value_bool);
+CREATE INDEX IF NOT EXISTS idx_database_value_options_option ON database_value_options(property_id, option);
+CREATE INDEX IF NOT EXISTS idx_database_value_options_number ON database_value_options(property_id, option_number);
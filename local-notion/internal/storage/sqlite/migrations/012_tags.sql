@@ -0,0 +1,57 @@
+CREATE TABLE IF NOT EXISTS tags (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS page_tags (
+    page_id TEXT NOT NULL REFERENCES pages(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (page_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_page_tags_tag ON page_tags(tag_id, page_id);
+
+-- pages.tags stays the source of truth; the triggers below keep the index in
+-- step with every write of it. Invalid JSON indexes no tags.
+
+CREATE TRIGGER IF NOT EXISTS pages_tags_insert AFTER INSERT ON pages
+BEGIN
+    INSERT OR IGNORE INTO tags(name)
+        SELECT value FROM json_each(CASE WHEN json_valid(NEW.tags) THEN NEW.tags END)
+        WHERE type = 'text' AND value <> '';
+    INSERT OR IGNORE INTO page_tags(page_id, tag_id)
+        SELECT NEW.id, t.id FROM tags t
+        WHERE t.name IN (SELECT value FROM json_each(CASE WHEN json_valid(NEW.tags) THEN NEW.tags END) WHERE type = 'text');
+END;
+
+CREATE TRIGGER IF NOT EXISTS pages_tags_update AFTER UPDATE OF tags ON pages
+WHEN OLD.tags IS NOT NEW.tags
+BEGIN
+    DELETE FROM page_tags WHERE page_id = NEW.id;
+    INSERT OR IGNORE INTO tags(name)
+        SELECT value FROM json_each(CASE WHEN json_valid(NEW.tags) THEN NEW.tags END)
+        WHERE type = 'text' AND value <> '';
+    INSERT OR IGNORE INTO page_tags(page_id, tag_id)
+        SELECT NEW.id, t.id FROM tags t
+        WHERE t.name IN (SELECT value FROM json_each(CASE WHEN json_valid(NEW.tags) THEN NEW.tags END) WHERE type = 'text');
+    DELETE FROM tags
+        WHERE name IN (SELECT value FROM json_each(CASE WHEN json_valid(OLD.tags) THEN OLD.tags END) WHERE type = 'text')
+        AND NOT EXISTS (SELECT 1 FROM page_tags pt WHERE pt.tag_id = tags.id);
+END;
+
+CREATE TRIGGER IF NOT EXISTS pages_tags_delete AFTER DELETE ON pages
+BEGIN
+    DELETE FROM page_tags WHERE page_id = OLD.id;
+    DELETE FROM tags
+        WHERE name IN (SELECT value FROM json_each(CASE WHEN json_valid(OLD.tags) THEN OLD.tags END) WHERE type = 'text')
+        AND NOT EXISTS (SELECT 1 FROM page_tags pt WHERE pt.tag_id = tags.id);
+END;
+
+-- Backfill the tags of existing pages.
+INSERT OR IGNORE INTO tags(name)
+    SELECT DISTINCT j.value FROM pages p, json_each(CASE WHEN json_valid(p.tags) THEN p.tags END) j
+    WHERE j.type = 'text' AND j.value <> '';
+INSERT OR IGNORE INTO page_tags(page_id, tag_id)
+    SELECT p.id, t.id FROM pages p, json_each(CASE WHEN json_valid(p.tags) THEN p.tags END) j
+    JOIN tags t ON t.name = j.value
+    WHERE j.type = 'text';
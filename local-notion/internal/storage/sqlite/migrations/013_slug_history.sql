@@ -0,0 +1,47 @@
+CREATE TABLE IF NOT EXISTS page_slug_history (
+    slug TEXT PRIMARY KEY,
+    page_id TEXT NOT NULL REFERENCES pages(id) ON DELETE CASCADE,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_page_slug_history_page ON page_slug_history(page_id);
+
+CREATE TABLE IF NOT EXISTS database_slug_history (
+    slug TEXT PRIMARY KEY,
+    database_id TEXT NOT NULL REFERENCES databases(id) ON DELETE CASCADE,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_database_slug_history_database ON database_slug_history(database_id);
+
+-- A slug that is given up keeps pointing at its record until another record
+-- takes it; a record's current slug always wins over the history.
+
+CREATE TRIGGER IF NOT EXISTS pages_slug_insert AFTER INSERT ON pages
+BEGIN
+    DELETE FROM page_slug_history WHERE slug = NEW.slug;
+END;
+
+CREATE TRIGGER IF NOT EXISTS pages_slug_update AFTER UPDATE OF slug ON pages
+WHEN OLD.slug IS NOT NEW.slug
+BEGIN
+    DELETE FROM page_slug_history WHERE slug = NEW.slug;
+    INSERT OR REPLACE INTO page_slug_history(slug, page_id, created_at) VALUES (OLD.slug, NEW.id, NEW.updated_at);
+END;
+
+CREATE TRIGGER IF NOT EXISTS pages_slug_delete AFTER DELETE ON pages
+BEGIN
+    DELETE FROM page_slug_history WHERE page_id = OLD.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS databases_slug_insert AFTER INSERT ON databases
+BEGIN
+    DELETE FROM database_slug_history WHERE slug = NEW.slug;
+END;
+
+CREATE TRIGGER IF NOT EXISTS databases_slug_update AFTER UPDATE OF slug ON databases
+WHEN OLD.slug IS NOT NEW.slug
+BEGIN
+    DELETE FROM database_slug_history WHERE slug = NEW.slug;
+    INSERT OR REPLACE INTO database_slug_history(slug, database_id, created_at) VALUES (OLD.slug, NEW.id, NEW.updated_at);
+END;
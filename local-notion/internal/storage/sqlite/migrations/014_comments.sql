@@ -0,0 +1,47 @@
+CREATE TABLE IF NOT EXISTS comment_threads (
+    id TEXT PRIMARY KEY,
+    page_id TEXT NOT NULL REFERENCES pages(id) ON DELETE CASCADE,
+    anchor_start INTEGER,
+    anchor_end INTEGER,
+    anchor_quote TEXT,
+    anchor_prefix TEXT,
+    anchor_suffix TEXT,
+    detached INTEGER NOT NULL DEFAULT 0,
+    resolved_at DATETIME,
+    resolved_by TEXT,
+    created_by TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_comment_threads_page ON comment_threads(page_id);
+
+CREATE TABLE IF NOT EXISTS comments (
+    id TEXT PRIMARY KEY,
+    thread_id TEXT NOT NULL REFERENCES comment_threads(id) ON DELETE CASCADE,
+    author TEXT NOT NULL,
+    body TEXT NOT NULL,
+    edited_at DATETIME,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_comments_thread ON comments(thread_id, created_at);
+
+CREATE TABLE IF NOT EXISTS comment_mentions (
+    comment_id TEXT NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    mentioned TEXT NOT NULL,
+    PRIMARY KEY (comment_id, mentioned)
+);
+
+CREATE INDEX IF NOT EXISTS idx_comment_mentions_mentioned ON comment_mentions(mentioned);
+
+-- Foreign keys are not enforced, so discussions are removed with their page.
+
+CREATE TRIGGER IF NOT EXISTS pages_comments_delete AFTER DELETE ON pages
+BEGIN
+    DELETE FROM comment_mentions WHERE comment_id IN (
+        SELECT c.id FROM comments c JOIN comment_threads t ON t.id = c.thread_id WHERE t.page_id = OLD.id
+    );
+    DELETE FROM comments WHERE thread_id IN (SELECT id FROM comment_threads WHERE page_id = OLD.id);
+    DELETE FROM comment_threads WHERE page_id = OLD.id;
+END;
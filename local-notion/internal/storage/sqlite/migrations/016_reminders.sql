@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS reminders (
+    id TEXT PRIMARY KEY,
+    database_id TEXT NOT NULL,
+    item_id TEXT NOT NULL REFERENCES database_items(id) ON DELETE CASCADE,
+    property_id TEXT NOT NULL REFERENCES database_properties(id) ON DELETE CASCADE,
+    due_at DATETIME NOT NULL,
+    fire_at DATETIME NOT NULL,
+    time_zone TEXT NOT NULL,
+    fired_at DATETIME,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    UNIQUE (item_id, property_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reminders_pending ON reminders(fire_at) WHERE fired_at IS NULL;
+
+-- Reminders follow the date value they were scheduled for.
+
+CREATE TRIGGER IF NOT EXISTS database_values_reminders_delete AFTER DELETE ON database_values
+BEGIN
+    DELETE FROM reminders WHERE item_id = OLD.database_item_id AND property_id = OLD.property_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS database_items_reminders_delete AFTER DELETE ON database_items
+BEGIN
+    DELETE FROM reminders WHERE item_id = OLD.id;
+END;
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS recurrence_series (
+    id TEXT PRIMARY KEY,
+    database_id TEXT NOT NULL REFERENCES databases(id) ON DELETE CASCADE,
+    property_id TEXT NOT NULL REFERENCES database_properties(id) ON DELETE CASCADE,
+    rule TEXT NOT NULL,
+    exceptions TEXT NOT NULL DEFAULT '[]',
+    generate TEXT NOT NULL,
+    complete_property_id TEXT,
+    time_zone TEXT NOT NULL,
+    starts_at DATETIME NOT NULL,
+    previous_series_id TEXT,
+    ended INTEGER NOT NULL DEFAULT 0,
+    generate_at DATETIME,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_recurrence_series_generate ON recurrence_series(generate_at) WHERE ended = 0;
+
+CREATE TABLE IF NOT EXISTS recurrence_occurrences (
+    series_id TEXT NOT NULL REFERENCES recurrence_series(id) ON DELETE CASCADE,
+    item_id TEXT NOT NULL UNIQUE REFERENCES database_items(id) ON DELETE CASCADE,
+    occurrence_index INTEGER NOT NULL,
+    occurs_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_recurrence_occurrences_series ON recurrence_occurrences(series_id, occurs_at);
+
+CREATE TRIGGER IF NOT EXISTS database_items_recurrence_delete AFTER DELETE ON database_items
+BEGIN
+    DELETE FROM recurrence_occurrences WHERE item_id = OLD.id;
+END;
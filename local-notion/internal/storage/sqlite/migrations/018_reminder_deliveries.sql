@@ -0,0 +1,7 @@
+ALTER TABLE reminders ADD COLUMN delivered_at DATETIME;
+ALTER TABLE reminders ADD COLUMN delivery_attempts INTEGER NOT NULL DEFAULT 0;
+
+-- Reminders fired before deliveries were tracked are not sent again.
+UPDATE reminders SET delivered_at = fired_at WHERE fired_at IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_reminders_undelivered ON reminders(fired_at) WHERE fired_at IS NOT NULL AND delivered_at IS NULL;
@@ -0,0 +1,246 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// Notifications are written in the transaction of the change that causes
+// them, for every recipient except the user making the change, and skipped
+// when the recipient muted their source.
+
+// ListNotifications returns a user's notifications, newest first, together
+// with the number of unread ones.
+func (s *Store) ListNotifications(ctx context.Context, filter storage.NotificationFilter) ([]domain.Notification, int, error) {
+	if err := storage.RequireFields("recipient", filter.Recipient); err != nil {
+		return nil, 0, err
+	}
+	limit := storage.NotificationLimit(filter)
+	where := `recipient = ?`
+	if filter.UnreadOnly {
+		where += ` AND read_at IS NULL`
+	}
+	notifications, err := loadNotifications(ctx, s.reader, where+` ORDER BY created_at DESC, rowid DESC LIMIT ?`, filter.Recipient, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	var unread int
+	if err := s.reader.QueryRowContext(ctx, `SELECT COUNT(*) FROM notifications WHERE recipient = ? AND read_at IS NULL`, filter.Recipient).Scan(&unread); err != nil {
+		return nil, 0, fmt.Errorf("count unread notifications: %w", err)
+	}
+	return notifications, unread, nil
+}
+
+// MarkNotificationRead marks one of a user's notifications read. Marking a
+// read notification again keeps its original read time.
+func (s *Store) MarkNotificationRead(ctx context.Context, recipient, id string) (*domain.Notification, error) {
+	if err := storage.RequireFields("recipient", recipient); err != nil {
+		return nil, err
+	}
+	res, err := s.db.ExecContext(ctx, `UPDATE notifications SET read_at = COALESCE(read_at, ?) WHERE id = ? AND recipient = ?`, time.Now().UTC(), id, recipient)
+	if err != nil {
+		return nil, fmt.Errorf("mark notification read: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, storage.ErrNotificationNotFound
+	}
+	notifications, err := loadNotifications(ctx, s.db, `id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	return &notifications[0], nil
+}
+
+// MarkAllNotificationsRead marks every unread notification of a user read and
+// returns how many there were.
+func (s *Store) MarkAllNotificationsRead(ctx context.Context, recipient string) (int, error) {
+	if err := storage.RequireFields("recipient", recipient); err != nil {
+		return 0, err
+	}
+	res, err := s.db.ExecContext(ctx, `UPDATE notifications SET read_at = ? WHERE recipient = ? AND read_at IS NULL`, time.Now().UTC(), recipient)
+	if err != nil {
+		return 0, fmt.Errorf("mark notifications read: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// GetNotificationPreferences returns the sources a user has muted.
+func (s *Store) GetNotificationPreferences(ctx context.Context, recipient string) (*domain.NotificationPreferences, error) {
+	if err := storage.RequireFields("recipient", recipient); err != nil {
+		return nil, err
+	}
+	return loadNotificationPreferences(ctx, s.reader, recipient)
+}
+
+// SetNotificationPreferences replaces the sources a user has muted. Muting
+// only affects notifications created afterwards.
+func (s *Store) SetNotificationPreferences(ctx context.Context, prefs domain.NotificationPreferences) (*domain.NotificationPreferences, error) {
+	if err := storage.CheckMutedSources(prefs); err != nil {
+		return nil, err
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.ExecContext(ctx, `DELETE FROM notification_mutes WHERE recipient = ?`, prefs.Recipient); err != nil {
+		return nil, fmt.Errorf("clear notification mutes: %w", err)
+	}
+	for _, source := range prefs.Muted {
+		if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO notification_mutes(recipient, source) VALUES (?, ?)`, prefs.Recipient, source); err != nil {
+			return nil, fmt.Errorf("insert notification mute: %w", err)
+		}
+	}
+	saved, err := loadNotificationPreferences(ctx, tx, prefs.Recipient)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit notification preferences: %w", err)
+	}
+	return saved, nil
+}
+
+func loadNotificationPreferences(ctx context.Context, q queryer, recipient string) (*domain.NotificationPreferences, error) {
+	rows, err := q.QueryContext(ctx, `SELECT source FROM notification_mutes WHERE recipient = ? ORDER BY source`, recipient)
+	if err != nil {
+		return nil, fmt.Errorf("query notification mutes: %w", err)
+	}
+	defer rows.Close()
+	prefs := &domain.NotificationPreferences{Recipient: recipient, Muted: []string{}}
+	for rows.Next() {
+		var source string
+		if err := rows.Scan(&source); err != nil {
+			return nil, fmt.Errorf("scan notification mute: %w", err)
+		}
+		prefs.Muted = append(prefs.Muted, source)
+	}
+	return prefs, rows.Err()
+}
+
+func loadNotifications(ctx context.Context, q queryer, where string, args ...any) ([]domain.Notification, error) {
+	rows, err := q.QueryContext(ctx, `SELECT id, recipient, source, actor, page_id, thread_id, comment_id, database_id, item_id, excerpt, read_at, created_at
+FROM notifications WHERE `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query notifications: %w", err)
+	}
+	defer rows.Close()
+	notifications := []domain.Notification{}
+	for rows.Next() {
+		var n domain.Notification
+		var pageID, threadID, commentID, databaseID, itemID sql.NullString
+		var readAt sql.NullTime
+		if err := rows.Scan(&n.ID, &n.Recipient, &n.Source, &n.Actor, &pageID, &threadID, &commentID, &databaseID, &itemID, &n.Excerpt, &readAt, &n.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan notification: %w", err)
+		}
+		n.PageID, n.ThreadID, n.CommentID = pageID.String, threadID.String, commentID.String
+		n.DatabaseID, n.ItemID = databaseID.String, itemID.String
+		if readAt.Valid {
+			n.ReadAt = &readAt.Time
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
+
+// notify records a notification for each recipient other than the actor who
+// has not muted its source. The recipient and id fields of n are filled in.
+// q must be a write transaction, which pushes the notifications to
+// subscribers once it commits.
+func notify(ctx context.Context, q queryer, n domain.Notification, recipients []string) error {
+	tx, ok := q.(*writeTx)
+	if !ok {
+		return errors.New("notify outside a write transaction")
+	}
+	n.Excerpt = storage.NotificationExcerpt(n.Excerpt)
+	for _, recipient := range recipients {
+		if recipient == "" || recipient == n.Actor {
+			continue
+		}
+		n.ID, n.Recipient = uuid.NewString(), recipient
+		res, err := tx.ExecContext(ctx, `INSERT INTO notifications(id, recipient, source, actor, page_id, thread_id, comment_id, database_id, item_id, excerpt, created_at)
+SELECT ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+WHERE NOT EXISTS (SELECT 1 FROM notification_mutes WHERE recipient = ? AND source = ?)`,
+			n.ID, recipient, n.Source, n.Actor, nullString(n.PageID), nullString(n.ThreadID), nullString(n.CommentID),
+			nullString(n.DatabaseID), nullString(n.ItemID), n.Excerpt, n.CreatedAt, recipient, n.Source)
+		if err != nil {
+			return fmt.Errorf("insert notification: %w", err)
+		}
+		inserted, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("insert notification: %w", err)
+		}
+		if inserted == 1 {
+			tx.notified = append(tx.notified, n)
+		}
+	}
+	return nil
+}
+
+// notifyContentMentions notifies the users mentioned in a page's content who
+// were not already mentioned before the change.
+func notifyContentMentions(ctx context.Context, q queryer, pageID, actor, oldContent, newContent string, now time.Time) error {
+	for _, name := range storage.NewMentions(oldContent, newContent) {
+		if err := notify(ctx, q, domain.Notification{
+			Source: storage.NotificationSourceMention, Actor: actor, PageID: pageID, Excerpt: storage.MentionContext(newContent, name), CreatedAt: now,
+		}, []string{name}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notifyItemChange notifies the users in the person properties of an item:
+// those added by the change are assigned, the others are told about the
+// update. A nil before means the item was created.
+func (s *Store) notifyItemChange(ctx context.Context, q queryer, actor string, before, after *domain.DatabaseItem, now time.Time) error {
+	schema, err := s.schema(ctx, q, after.DatabaseID)
+	if err != nil {
+		return err
+	}
+	var previous []string
+	if before != nil {
+		previous = itemPeople(schema, before)
+	}
+	assigned, watching := storage.SplitItemPeople(previous, itemPeople(schema, after))
+	n := domain.Notification{
+		Actor: actor, PageID: after.Page.ID, DatabaseID: after.DatabaseID, ItemID: after.ID,
+		Excerpt: after.Page.Title, CreatedAt: now,
+	}
+	n.Source = storage.NotificationSourceAssignment
+	if err := notify(ctx, q, n, assigned); err != nil {
+		return err
+	}
+	n.Source = storage.NotificationSourceItemUpdate
+	return notify(ctx, q, n, watching)
+}
+
+// itemPeople returns the distinct users named in an item's person properties.
+func itemPeople(schema *databaseSchema, item *domain.DatabaseItem) []string {
+	return storage.ItemPeople(item, func(propertyID string) bool {
+		return schema.types[propertyID] == domain.PropertyTypePerson
+	})
+}
+
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// SubscribeNotifications streams the notifications created for recipient
+// from now on, in commit order. The channel is closed by cancel, or when the
+// subscriber falls too far behind; cancel must be called either way.
+func (s *Store) SubscribeNotifications(recipient string) (<-chan domain.Notification, func()) {
+	return s.notifications.Subscribe(recipient)
+}
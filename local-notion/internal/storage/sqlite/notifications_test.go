@@ -0,0 +1,151 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+func inbox(t *testing.T, store *Store, recipient string) []domain.Notification {
+	t.Helper()
+	notifications, _, err := store.ListNotifications(context.Background(), storage.NotificationFilter{Recipient: recipient})
+	require.NoError(t, err)
+	return notifications
+}
+
+func notificationSourcesOf(notifications []domain.Notification) []string {
+	var sources []string
+	for _, n := range notifications {
+		sources = append(sources, n.Source)
+	}
+	return sources
+}
+
+func TestNotificationsFromPageAndCommentMentions(t *testing.T) {
+	store := newTestStore(t)
+	ctx := storage.WithAuditInfo(context.Background(), storage.AuditInfo{Actor: "ana"})
+	page, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "plan", Title: "Plan", Content: "@bo owns this, @ana reviews."})
+	require.NoError(t, err)
+	require.Empty(t, inbox(t, store, "ana"))
+	mentions := inbox(t, store, "bo")
+	require.Len(t, mentions, 1)
+	require.Equal(t, domain.Notification{
+		ID: mentions[0].ID, Recipient: "bo", Source: storage.NotificationSourceMention, Actor: "ana", PageID: page.ID,
+		Excerpt: "@bo owns this, @ana reviews.", CreatedAt: mentions[0].CreatedAt,
+	}, mentions[0])
+
+	// Only mentions added by an edit notify.
+	content := "@bo owns this, @carl reviews."
+	_, err = store.UpdatePage(ctx, storage.UpdatePageInput{PageID: page.ID, Content: &content, ExpectedVersion: &page.Version})
+	require.NoError(t, err)
+	require.Len(t, inbox(t, store, "bo"), 1)
+	require.Len(t, inbox(t, store, "carl"), 1)
+
+	thread, err := store.CreateCommentThread(ctx, storage.CreateCommentThreadInput{PageID: page.ID, Author: "bo", Body: "@carl can you start?"})
+	require.NoError(t, err)
+	_, err = store.ReplyToCommentThread(ctx, storage.ReplyToCommentThreadInput{PageID: page.ID, ThreadID: thread.ID, Author: "carl", Body: "Sure"})
+	require.NoError(t, err)
+	_, err = store.ReplyToCommentThread(ctx, storage.ReplyToCommentThreadInput{PageID: page.ID, ThreadID: thread.ID, Author: "dee", Body: "@bo me too"})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{storage.NotificationSourceReply, storage.NotificationSourceMention, storage.NotificationSourceMention}, notificationSourcesOf(inbox(t, store, "carl")))
+	bo := inbox(t, store, "bo")
+	require.Equal(t, []string{storage.NotificationSourceMention, storage.NotificationSourceReply, storage.NotificationSourceMention}, notificationSourcesOf(bo))
+	require.Equal(t, thread.ID, bo[0].ThreadID)
+	require.Equal(t, "dee", bo[0].Actor)
+	require.Empty(t, inbox(t, store, "dee"))
+}
+
+func TestNotificationsForAssignedItems(t *testing.T) {
+	store := newTestStore(t)
+	ctx := storage.WithAuditInfo(context.Background(), storage.AuditInfo{Actor: "ana"})
+	db, err := store.CreateDatabase(ctx, storage.CreateDatabaseInput{
+		Slug: "tasks", Title: "Tasks",
+		Properties: []storage.DatabasePropertyInput{{Slug: "owner", Name: "Owner", Type: domain.PropertyTypePerson}},
+	})
+	require.NoError(t, err)
+	item, err := store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
+		DatabaseID: db.ID,
+		Page:       storage.CreatePageInput{Title: "Write docs"},
+		Values:     map[string]any{"owner": []any{"ana", "bo"}},
+	})
+	require.NoError(t, err)
+	require.Empty(t, inbox(t, store, "ana"))
+	assigned := inbox(t, store, "bo")
+	require.Equal(t, []string{storage.NotificationSourceAssignment}, notificationSourcesOf(assigned))
+	require.Equal(t, item.ID, assigned[0].ItemID)
+	require.Equal(t, "Write docs", assigned[0].Excerpt)
+
+	title := "Write the docs"
+	_, err = store.UpdateDatabaseItem(ctx, storage.UpdateDatabaseItemInput{
+		DatabaseID: db.ID, ItemID: item.ID, Title: &title, Values: map[string]any{"owner": []any{"bo", "carl"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{storage.NotificationSourceItemUpdate, storage.NotificationSourceAssignment}, notificationSourcesOf(inbox(t, store, "bo")))
+	require.Equal(t, []string{storage.NotificationSourceAssignment}, notificationSourcesOf(inbox(t, store, "carl")))
+}
+
+func TestNotificationReadStateAndMutes(t *testing.T) {
+	store := newTestStore(t)
+	ctx := storage.WithAuditInfo(context.Background(), storage.AuditInfo{Actor: "ana"})
+	_, err := store.CreatePage(ctx, storage.CreatePageInput{Title: "One", Content: "@bo"})
+	require.NoError(t, err)
+	_, err = store.CreatePage(ctx, storage.CreatePageInput{Title: "Two", Content: "@bo"})
+	require.NoError(t, err)
+
+	notifications, unread, err := store.ListNotifications(ctx, storage.NotificationFilter{Recipient: "bo"})
+	require.NoError(t, err)
+	require.Equal(t, 2, unread)
+	read, err := store.MarkNotificationRead(ctx, "bo", notifications[0].ID)
+	require.NoError(t, err)
+	require.NotNil(t, read.ReadAt)
+	_, err = store.MarkNotificationRead(ctx, "carl", notifications[1].ID)
+	require.ErrorIs(t, err, storage.ErrNotificationNotFound)
+	notifications, unread, err = store.ListNotifications(ctx, storage.NotificationFilter{Recipient: "bo", UnreadOnly: true})
+	require.NoError(t, err)
+	require.Equal(t, 1, unread)
+	require.Len(t, notifications, 1)
+	marked, err := store.MarkAllNotificationsRead(ctx, "bo")
+	require.NoError(t, err)
+	require.Equal(t, 1, marked)
+
+	prefs, err := store.SetNotificationPreferences(ctx, domain.NotificationPreferences{Recipient: "bo", Muted: []string{storage.NotificationSourceMention}})
+	require.NoError(t, err)
+	require.Equal(t, []string{storage.NotificationSourceMention}, prefs.Muted)
+	_, err = store.CreatePage(ctx, storage.CreatePageInput{Title: "Three", Content: "@bo"})
+	require.NoError(t, err)
+	require.Len(t, inbox(t, store, "bo"), 2)
+
+	_, err = store.SetNotificationPreferences(ctx, domain.NotificationPreferences{Recipient: "bo", Muted: []string{"everything"}})
+	require.ErrorIs(t, err, storage.ErrValidation)
+	_, _, err = store.ListNotifications(ctx, storage.NotificationFilter{})
+	require.ErrorIs(t, err, storage.ErrValidation)
+}
+
+func TestSubscribeNotificationsPushesCommittedNotifications(t *testing.T) {
+	store := newTestStore(t)
+	ctx := storage.WithAuditInfo(context.Background(), storage.AuditInfo{Actor: "ana"})
+	stream, cancel := store.SubscribeNotifications("bo")
+	defer cancel()
+	other, cancelOther := store.SubscribeNotifications("cy")
+	defer cancelOther()
+
+	_, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "plan", Title: "Plan", Content: "@bo owns this."})
+	require.NoError(t, err)
+	pushed := <-stream
+	require.Equal(t, inbox(t, store, "bo")[0], pushed)
+	require.Empty(t, other)
+
+	// A write that fails pushes nothing.
+	_, err = store.CreatePage(ctx, storage.CreatePageInput{Slug: "plan", Title: "Again", Content: "@bo again"})
+	require.Error(t, err)
+	require.Empty(t, stream)
+
+	cancel()
+	_, open := <-stream
+	require.False(t, open)
+}
@@ -0,0 +1,880 @@
+package sqlite
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// ErrInvalidNotionExport is returned when an archive holds no Notion pages or databases.
+var ErrInvalidNotionExport = storage.InvalidError("invalid_notion_export", "invalid notion export")
+
+// maxNestedArchiveBytes caps the size of a zip nested in a Notion export,
+// which has to be read into memory to be opened.
+const maxNestedArchiveBytes = 512 << 20
+
+// maxInferredOptions is the most distinct values a column may have to be
+// imported as a select or multi-select.
+const maxInferredOptions = 50
+
+const notionSource = "notion"
+
+// Kinds of rows recorded in import_sources.
+const (
+	importKindPage     = "page"
+	importKindDatabase = "database"
+	importKindAsset    = "asset"
+)
+
+var (
+	notionIDPattern       = regexp.MustCompile(`^(.*?)\s+([0-9a-f]{32})$`)
+	notionURLIDPattern    = regexp.MustCompile(`([0-9a-f]{32})(?:[?#].*)?$`)
+	markdownLinkPattern   = regexp.MustCompile(`(!?)\[([^\]]*)\]\(([^)\s]+)\)`)
+	notionRelationPattern = regexp.MustCompile(`\(([^()]*\.md)\)`)
+	notionPropertyLine    = regexp.MustCompile(`^([^:]+):\s?(.*)$`)
+)
+
+type notionPage struct {
+	sourceID string
+	title    string
+	path     string
+	parentID string // source id of the parent page or database
+	body     string
+	localID  string
+	exists   bool
+}
+
+type notionDatabase struct {
+	sourceID string
+	title    string
+	path     string
+	all      bool // read from the _all.csv variant, which wins over the view CSV
+	header   []string
+	rows     [][]string
+	localID  string
+}
+
+type notionArchive struct {
+	pages     []*notionPage
+	pageByID  map[string]*notionPage
+	databases []*notionDatabase
+	dbByID    map[string]*notionDatabase
+	files     map[string]*zip.File
+	issues    []domain.ImportIssue
+}
+
+// ImportNotion imports a Notion export: ID suffixes are stripped from file
+// names, folders become the page hierarchy and every CSV becomes a database
+// with inferred property types whose rows are matched to the item pages in
+// the folder of the same name. Links between exported pages become
+// page_links and attached files become assets. Each page, database and asset
+// is recorded by its Notion ID, so importing the same export again updates
+// the earlier import instead of duplicating it. Everything is written in one
+// transaction.
+func (s *Store) ImportNotion(ctx context.Context, in storage.ImportNotionInput) (*domain.NotionImportReport, error) {
+	if in.Archive == nil {
+		return nil, fmt.Errorf("%w: archive required", ErrInvalidNotionExport)
+	}
+	archive := &notionArchive{pageByID: make(map[string]*notionPage), dbByID: make(map[string]*notionDatabase), files: make(map[string]*zip.File)}
+	if err := archive.collect(in.Archive, true); err != nil {
+		return nil, err
+	}
+	if len(archive.pages) == 0 && len(archive.databases) == 0 {
+		return nil, fmt.Errorf("%w: no pages or databases found", ErrInvalidNotionExport)
+	}
+	archive.link()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	imp := &notionImport{store: s, ctx: ctx, q: tx, archive: archive, assetDir: in.AssetDir, now: time.Now().UTC(), report: &domain.NotionImportReport{}, assets: make(map[string]string)}
+	if err := imp.run(); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit notion import: %w", err)
+	}
+	imp.report.Issues = archive.issues
+	return imp.report, nil
+}
+
+func (a *notionArchive) issue(p, format string, args ...any) {
+	a.issues = append(a.issues, domain.ImportIssue{Path: p, Message: fmt.Sprintf(format, args...)})
+}
+
+// splitNotionName splits "Title 0123...cdef" into its title and Notion ID.
+func splitNotionName(name string) (string, string) {
+	if m := notionIDPattern.FindStringSubmatch(name); m != nil {
+		return strings.TrimSpace(m[1]), m[2]
+	}
+	return strings.TrimSpace(name), ""
+}
+
+// collect reads every entry of the archive. Notion wraps large exports in a
+// zip of zips, so archives nested one level deep are opened as well.
+func (a *notionArchive) collect(zr *zip.Reader, top bool) error {
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || strings.HasPrefix(path.Base(f.Name), ".") || strings.HasPrefix(f.Name, "__MACOSX/") {
+			continue
+		}
+		name := path.Clean(f.Name)
+		switch strings.ToLower(path.Ext(name)) {
+		case ".zip":
+			if !top {
+				a.issue(name, "nested archive skipped")
+				continue
+			}
+			if f.UncompressedSize64 > maxNestedArchiveBytes {
+				a.issue(name, "nested archive larger than %d bytes skipped", maxNestedArchiveBytes)
+				continue
+			}
+			raw, err := readZipFile(f)
+			if err != nil {
+				return err
+			}
+			nested, err := zip.NewReader(bytes.NewReader(raw), int64(len(raw)))
+			if err != nil {
+				a.issue(name, "unreadable nested archive: %v", err)
+				continue
+			}
+			if err := a.collect(nested, false); err != nil {
+				return err
+			}
+		case ".md":
+			raw, err := readZipFile(f)
+			if err != nil {
+				return err
+			}
+			title, id := splitNotionName(strings.TrimSuffix(path.Base(name), path.Ext(name)))
+			if id == "" {
+				id = "path:" + name
+			}
+			if title == "" {
+				title = "Untitled"
+			}
+			page := &notionPage{sourceID: id, title: title, path: name, parentID: notionParentID(path.Dir(name)), body: string(raw)}
+			if _, dup := a.pageByID[id]; dup {
+				a.issue(name, "duplicate page id %s skipped", id)
+				continue
+			}
+			a.pageByID[id] = page
+			a.pages = append(a.pages, page)
+		case ".csv":
+			if err := a.collectDatabase(f, name); err != nil {
+				return err
+			}
+		default:
+			a.files[name] = f
+		}
+	}
+	return nil
+}
+
+func (a *notionArchive) collectDatabase(f *zip.File, name string) error {
+	base := strings.TrimSuffix(path.Base(name), path.Ext(name))
+	all := strings.HasSuffix(base, "_all")
+	title, id := splitNotionName(strings.TrimSuffix(base, "_all"))
+	if id == "" {
+		a.issue(name, "CSV without a Notion ID skipped")
+		return nil
+	}
+	if existing, ok := a.dbByID[id]; ok && (existing.all || !all) {
+		return nil
+	}
+	raw, err := readZipFile(f)
+	if err != nil {
+		return err
+	}
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(raw, []byte("\ufeff"))))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		a.issue(name, "unreadable CSV: %v", err)
+		return nil
+	}
+	if len(records) == 0 || len(records[0]) == 0 {
+		a.issue(name, "CSV without a header skipped")
+		return nil
+	}
+	db := &notionDatabase{sourceID: id, title: title, path: name, all: all, header: records[0], rows: records[1:]}
+	if existing, ok := a.dbByID[id]; ok {
+		*existing = *db
+		return nil
+	}
+	a.dbByID[id] = db
+	a.databases = append(a.databases, db)
+	return nil
+}
+
+// notionParentID returns the Notion ID of the closest folder that carries one.
+func notionParentID(dir string) string {
+	for dir != "." && dir != "/" && dir != "" {
+		if _, id := splitNotionName(path.Base(dir)); id != "" {
+			return id
+		}
+		dir = path.Dir(dir)
+	}
+	return ""
+}
+
+// link drops parent references that do not point into the archive and orders
+// pages so that parents come first.
+func (a *notionArchive) link() {
+	for _, page := range a.pages {
+		if page.parentID == "" {
+			continue
+		}
+		if _, ok := a.pageByID[page.parentID]; ok {
+			continue
+		}
+		if _, ok := a.dbByID[page.parentID]; ok {
+			continue
+		}
+		a.issue(page.path, "parent %s is not part of the export; imported at the top level", page.parentID)
+		page.parentID = ""
+	}
+	sort.SliceStable(a.pages, func(i, j int) bool {
+		return strings.Count(a.pages[i].path, "/") < strings.Count(a.pages[j].path, "/")
+	})
+	sort.SliceStable(a.databases, func(i, j int) bool { return a.databases[i].path < a.databases[j].path })
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+	raw, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", f.Name, err)
+	}
+	return raw, nil
+}
+
+// notionImport writes a collected archive inside one transaction.
+type notionImport struct {
+	store    *Store
+	ctx      context.Context
+	q        queryer
+	archive  *notionArchive
+	assetDir string
+	now      time.Time
+	report   *domain.NotionImportReport
+	assets   map[string]string // archive path -> asset id
+	noAssets bool
+}
+
+func (imp *notionImport) run() error {
+	for _, page := range imp.archive.pages {
+		localID, err := imp.lookupSource(page.sourceID, importKindPage)
+		if err != nil {
+			return err
+		}
+		if localID != "" {
+			existing, err := loadPageRow(imp.ctx, imp.q, localID)
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				page.localID, page.exists = localID, true
+				continue
+			}
+		}
+		page.localID = uuid.NewString()
+	}
+	for _, db := range imp.archive.databases {
+		if err := imp.importDatabase(db); err != nil {
+			return err
+		}
+	}
+	for _, page := range imp.archive.pages {
+		if err := imp.importPage(page); err != nil {
+			return err
+		}
+	}
+	for _, db := range imp.archive.databases {
+		if err := imp.importRows(db); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (imp *notionImport) lookupSource(sourceID, kind string) (string, error) {
+	var localID string
+	err := imp.q.QueryRowContext(imp.ctx, `SELECT local_id FROM import_sources WHERE source = ? AND source_id = ? AND kind = ?`, notionSource, sourceID, kind).Scan(&localID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("lookup import source: %w", err)
+	}
+	return localID, nil
+}
+
+func (imp *notionImport) recordSource(sourceID, kind, localID string) error {
+	if _, err := imp.q.ExecContext(imp.ctx, `INSERT INTO import_sources(source, source_id, kind, local_id, created_at, updated_at) VALUES(?, ?, ?, ?, ?, ?)
+ON CONFLICT(source, source_id) DO UPDATE SET kind = excluded.kind, local_id = excluded.local_id, updated_at = excluded.updated_at`,
+		notionSource, sourceID, kind, localID, imp.now, imp.now); err != nil {
+		return fmt.Errorf("record import source: %w", err)
+	}
+	return nil
+}
+
+// importDatabase creates the database of a CSV, or adds the columns that are
+// missing from a database imported earlier. Existing properties keep their
+// type.
+func (imp *notionImport) importDatabase(src *notionDatabase) error {
+	localID, err := imp.lookupSource(src.sourceID, importKindDatabase)
+	if err != nil {
+		return err
+	}
+	var db *domain.Database
+	if localID != "" {
+		if db, err = imp.store.loadDatabase(imp.ctx, imp.q, localID); err != nil {
+			return err
+		}
+	}
+	before := db
+	if db == nil {
+		slug, err := uniqueSlug(imp.ctx, imp.q, "databases", storage.SlugOrDefault(src.title, "database"))
+		if err != nil {
+			return err
+		}
+		db = &domain.Database{ID: uuid.NewString(), Slug: slug, Title: src.title}
+		if _, err := imp.q.ExecContext(imp.ctx, `INSERT INTO databases(id, slug, title, description, is_archived, is_template, created_at, updated_at) VALUES(?, ?, ?, '', 0, 0, ?, ?)`,
+			db.ID, db.Slug, db.Title, imp.now, imp.now); err != nil {
+			return insertError("database", db.Slug, err)
+		}
+		if _, err := createView(imp.ctx, imp.q, domain.DatabaseView{ID: uuid.NewString(), DatabaseID: db.ID, Name: "All", Type: domain.ViewTypeTable}, imp.now); err != nil {
+			return err
+		}
+		imp.report.DatabasesCreated++
+	} else {
+		if _, err := imp.q.ExecContext(imp.ctx, `UPDATE databases SET title = ?, version = version + 1, updated_at = ? WHERE id = ?`, src.title, imp.now, db.ID); err != nil {
+			return fmt.Errorf("update database: %w", err)
+		}
+		imp.report.DatabasesUpdated++
+	}
+	src.localID = db.ID
+	byName := make(map[string]bool, len(db.Properties))
+	slugs := make(map[string]bool, len(db.Properties))
+	for _, prop := range db.Properties {
+		byName[prop.Name] = true
+		slugs[prop.Slug] = true
+	}
+	for col, name := range src.header {
+		if col == 0 || byName[name] {
+			continue
+		}
+		values := make([]string, 0, len(src.rows))
+		for _, row := range src.rows {
+			if col < len(row) {
+				values = append(values, strings.TrimSpace(row[col]))
+			}
+		}
+		propType, options := inferNotionType(values)
+		slug := storage.SlugOrDefault(name, "property")
+		for base, n := slug, 2; slugs[slug]; n++ {
+			slug = base + "-" + strconv.Itoa(n)
+		}
+		slugs[slug] = true
+		prop := domain.DatabaseProperty{ID: uuid.NewString(), DatabaseID: db.ID, Name: name, Slug: slug, Type: propType, OrderIndex: col - 1}
+		if options != nil {
+			prop.Config = map[string]any{"options": options}
+		}
+		if err := insertPropertyRow(imp.ctx, imp.q, prop, imp.now); err != nil {
+			return err
+		}
+		if before != nil {
+			if err := recordAudit(imp.ctx, imp.q, storage.AuditActionCreate, storage.AuditEntityProperty, prop.ID, nil, prop, imp.now); err != nil {
+				return err
+			}
+		}
+	}
+	after, err := imp.store.loadDatabase(imp.ctx, imp.q, db.ID)
+	if err != nil {
+		return err
+	}
+	if before == nil {
+		err = auditDatabaseCreate(imp.ctx, imp.q, after, imp.now)
+	} else {
+		err = recordAudit(imp.ctx, imp.q, storage.AuditActionUpdate, storage.AuditEntityDatabase, db.ID, before, after, imp.now)
+	}
+	if err != nil {
+		return err
+	}
+	return imp.recordSource(src.sourceID, importKindDatabase, db.ID)
+}
+
+// inferNotionType guesses a property type from the non-empty values of a
+// column and returns the select options for select and multi-select columns.
+func inferNotionType(values []string) (domain.PropertyType, []any) {
+	var filled []string
+	for _, v := range values {
+		if v != "" {
+			filled = append(filled, v)
+		}
+	}
+	if len(filled) == 0 {
+		return domain.PropertyTypeText, nil
+	}
+	all := func(match func(string) bool) bool {
+		for _, v := range filled {
+			if !match(v) {
+				return false
+			}
+		}
+		return true
+	}
+	switch {
+	case all(func(v string) bool { return v == "Yes" || v == "No" }):
+		return domain.PropertyTypeCheckbox, nil
+	case all(isNotionNumber):
+		return domain.PropertyTypeNumber, nil
+	case all(isNotionDate):
+		return domain.PropertyTypeDate, nil
+	case all(func(v string) bool { return notionRelationPattern.MatchString(v) }):
+		return domain.PropertyTypeRelation, nil
+	case all(func(v string) bool { return strings.HasPrefix(v, "http://") || strings.HasPrefix(v, "https://") }):
+		return domain.PropertyTypeURL, nil
+	case all(func(v string) bool { return strings.Contains(v, "@") && !strings.ContainsAny(v, " ,") }):
+		return domain.PropertyTypeEmail, nil
+	}
+	var options []any
+	seen := make(map[string]bool)
+	tokens, multi := 0, false
+	for _, v := range filled {
+		parts := splitList(v)
+		multi = multi || len(parts) > 1
+		for _, part := range parts {
+			tokens++
+			if !seen[part] {
+				seen[part] = true
+				options = append(options, part)
+			}
+		}
+	}
+	// Repeated values from a small set are options; anything else is text.
+	if len(options) > maxInferredOptions || len(options) == tokens {
+		return domain.PropertyTypeText, nil
+	}
+	if multi {
+		return domain.PropertyTypeMultiSelect, options
+	}
+	return domain.PropertyTypeSelect, options
+}
+
+func isNotionNumber(v string) bool {
+	_, err := strconv.ParseFloat(strings.ReplaceAll(v, ",", ""), 64)
+	return err == nil
+}
+
+func isNotionDate(v string) bool {
+	_, err := coerceNotionDate(v)
+	return err == nil
+}
+
+// coerceNotionDate accepts single dates and Notion's "start → end" ranges.
+func coerceNotionDate(v string) (any, error) {
+	start, end, isRange := strings.Cut(v, "→")
+	startValue, err := coerceDate(start)
+	if err != nil {
+		return nil, err
+	}
+	if !isRange {
+		return startValue, nil
+	}
+	endValue, err := coerceDate(end)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"start": startValue, "end": endValue}, nil
+}
+
+// importPage writes one page. Item pages (pages in a database folder) get a
+// database_items row; their property lines are dropped from the body because
+// the values are read from the CSV.
+func (imp *notionImport) importPage(page *notionPage) error {
+	var parentID *string
+	var db *notionDatabase
+	if parent, ok := imp.archive.pageByID[page.parentID]; ok {
+		parentID = &parent.localID
+	} else if parentDB, ok := imp.archive.dbByID[page.parentID]; ok {
+		db = parentDB
+	}
+	content, targets := imp.rewriteLinks(page, notionBody(page, db))
+	var before *domain.Page
+	if page.exists {
+		var err error
+		if before, err = loadAuditPage(imp.ctx, imp.q, page.localID); err != nil {
+			return err
+		}
+	}
+	if page.exists {
+		if _, err := imp.q.ExecContext(imp.ctx, `UPDATE pages SET title = ?, content = ?, parent_page_id = ?, version = version + 1, updated_at = ? WHERE id = ?`,
+			page.title, content, parentID, imp.now, page.localID); err != nil {
+			return fmt.Errorf("update page: %w", err)
+		}
+		if err := reanchorCommentThreads(imp.ctx, imp.q, page.localID, content); err != nil {
+			return err
+		}
+		imp.report.PagesUpdated++
+	} else {
+		slug, err := uniqueSlug(imp.ctx, imp.q, "pages", storage.SlugOrDefault(page.title, "page"))
+		if err != nil {
+			return err
+		}
+		if err := insertPageRow(imp.ctx, imp.q, domain.Page{
+			ID: page.localID, Slug: slug, Title: page.title, Content: content, ParentPageID: parentID, Tags: []string{}, CreatedAt: imp.now, UpdatedAt: imp.now,
+		}); err != nil {
+			return err
+		}
+		imp.report.PagesCreated++
+	}
+	if db != nil {
+		if err := imp.ensureItem(db.localID, page.localID); err != nil {
+			return err
+		}
+	}
+	if _, err := imp.q.ExecContext(imp.ctx, `DELETE FROM page_links WHERE source_page_id = ?`, page.localID); err != nil {
+		return fmt.Errorf("clear page links: %w", err)
+	}
+	for _, target := range targets {
+		if _, err := imp.q.ExecContext(imp.ctx, `INSERT OR IGNORE INTO page_links(source_page_id, target_page_id, created_at) VALUES(?, ?, ?)`, page.localID, target, imp.now); err != nil {
+			return fmt.Errorf("insert page link: %w", err)
+		}
+		imp.report.Links++
+	}
+	if err := auditPageChange(imp.ctx, imp.q, storage.AuditActionUpdate, page.localID, before, imp.now); err != nil {
+		return err
+	}
+	return imp.recordSource(page.sourceID, importKindPage, page.localID)
+}
+
+// ensureItem makes pageID an item of databaseID unless it already is one.
+func (imp *notionImport) ensureItem(databaseID, pageID string) error {
+	var itemID string
+	err := imp.q.QueryRowContext(imp.ctx, `SELECT id FROM database_items WHERE page_id = ? AND database_id = ?`, pageID, databaseID).Scan(&itemID)
+	if err == nil {
+		imp.report.ItemsUpdated++
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("lookup item: %w", err)
+	}
+	rank, err := nextItemRank(imp.ctx, imp.q, databaseID)
+	if err != nil {
+		return err
+	}
+	itemID = uuid.NewString()
+	if _, err := imp.q.ExecContext(imp.ctx, `INSERT INTO database_items(id, database_id, page_id, position, rank, is_archived, created_at, updated_at) VALUES(?, ?, ?, 0, ?, 0, ?, ?)`,
+		itemID, databaseID, pageID, rank, imp.now, imp.now); err != nil {
+		return fmt.Errorf("insert database item: %w", err)
+	}
+	imp.report.ItemsCreated++
+	item, err := loadDatabaseItem(imp.ctx, imp.q, itemID)
+	if err != nil {
+		return err
+	}
+	return recordAudit(imp.ctx, imp.q, storage.AuditActionCreate, storage.AuditEntityItem, itemID, nil, item, imp.now)
+}
+
+// notionBody strips the "# Title" heading Notion writes at the top of every
+// page and, for database items, the property lines that follow it.
+func notionBody(page *notionPage, db *notionDatabase) string {
+	lines := strings.Split(strings.ReplaceAll(page.body, "\r\n", "\n"), "\n")
+	idx := 0
+	for idx < len(lines) && strings.TrimSpace(lines[idx]) == "" {
+		idx++
+	}
+	if idx < len(lines) && strings.HasPrefix(lines[idx], "# ") {
+		idx++
+	}
+	if db != nil {
+		columns := make(map[string]bool, len(db.header))
+		for _, name := range db.header {
+			columns[name] = true
+		}
+		for idx < len(lines) && strings.TrimSpace(lines[idx]) == "" {
+			idx++
+		}
+		for idx < len(lines) {
+			m := notionPropertyLine.FindStringSubmatch(lines[idx])
+			if m == nil || !columns[m[1]] {
+				break
+			}
+			idx++
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines[idx:], "\n"))
+}
+
+// rewriteLinks points Markdown links at imported pages (/pages/{id}),
+// databases (/databases/{id}) and assets (/api/assets/{id}) and returns the
+// local ids of the linked pages.
+func (imp *notionImport) rewriteLinks(page *notionPage, body string) (string, []string) {
+	var targets []string
+	seen := make(map[string]bool)
+	dir := path.Dir(page.path)
+	rewritten := markdownLinkPattern.ReplaceAllStringFunc(body, func(match string) string {
+		m := markdownLinkPattern.FindStringSubmatch(match)
+		image, text, target := m[1], m[2], m[3]
+		local, isPage, ok := imp.resolveLink(page, dir, target)
+		if !ok {
+			return match
+		}
+		if isPage && !seen[local] && local != page.localID {
+			seen[local] = true
+			targets = append(targets, local)
+		}
+		prefix := "/pages/"
+		switch {
+		case !isPage && strings.HasPrefix(local, "db:"):
+			prefix, local = "/databases/", strings.TrimPrefix(local, "db:")
+		case !isPage:
+			prefix = "/api/assets/"
+		}
+		return image + "[" + text + "](" + prefix + local + ")"
+	})
+	return rewritten, targets
+}
+
+// resolveLink maps a link target to a local page id (isPage), a database id
+// prefixed with "db:" or an asset id. ok is false for external links and
+// targets that stay unresolved, which are reported.
+func (imp *notionImport) resolveLink(page *notionPage, dir, target string) (local string, isPage, ok bool) {
+	if strings.HasPrefix(target, "#") || strings.HasPrefix(target, "mailto:") {
+		return "", false, false
+	}
+	if strings.Contains(target, "://") {
+		if !strings.Contains(target, "notion.so/") && !strings.Contains(target, "notion.site/") {
+			return "", false, false
+		}
+		if m := notionURLIDPattern.FindStringSubmatch(target); m != nil {
+			if linked, found := imp.archive.pageByID[m[1]]; found {
+				return linked.localID, true, true
+			}
+		}
+		imp.archive.issue(page.path, "link to %s points outside the export", target)
+		return "", false, false
+	}
+	unescaped, err := url.PathUnescape(target)
+	if err != nil {
+		unescaped = target
+	}
+	resolved := path.Join(dir, unescaped)
+	base := path.Base(resolved)
+	switch strings.ToLower(path.Ext(resolved)) {
+	case ".md":
+		if _, id := splitNotionName(strings.TrimSuffix(base, path.Ext(base))); id != "" {
+			if linked, found := imp.archive.pageByID[id]; found {
+				return linked.localID, true, true
+			}
+		}
+	case ".csv":
+		_, id := splitNotionName(strings.TrimSuffix(strings.TrimSuffix(base, path.Ext(base)), "_all"))
+		if db, found := imp.archive.dbByID[id]; found {
+			return "db:" + db.localID, false, true
+		}
+	default:
+		if f, found := imp.archive.files[resolved]; found {
+			assetID, err := imp.importAsset(resolved, f)
+			if err != nil {
+				imp.archive.issue(resolved, "attachment not imported: %v", err)
+				return "", false, false
+			}
+			if assetID == "" {
+				return "", false, false
+			}
+			return assetID, false, true
+		}
+	}
+	imp.archive.issue(page.path, "link to %s could not be resolved", unescaped)
+	return "", false, false
+}
+
+// importAsset stores an attachment once per archive path and returns its id.
+// It returns an empty id when attachments cannot be stored.
+func (imp *notionImport) importAsset(name string, f *zip.File) (string, error) {
+	if id, ok := imp.assets[name]; ok {
+		return id, nil
+	}
+	if imp.assetDir == "" {
+		if !imp.noAssets {
+			imp.noAssets = true
+			imp.archive.issue(name, "attachments skipped: no asset directory configured")
+		}
+		return "", nil
+	}
+	id, err := imp.lookupSource("file:"+name, importKindAsset)
+	if err != nil {
+		return "", err
+	}
+	if id == "" {
+		id = uuid.NewString()
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	if _, err := saveAsset(imp.ctx, imp.q, imp.assetDir, id, path.Base(name), rc, imp.now); err != nil {
+		return "", err
+	}
+	if err := imp.recordSource("file:"+name, importKindAsset, id); err != nil {
+		return "", err
+	}
+	imp.assets[name] = id
+	imp.report.Assets++
+	return id, nil
+}
+
+// importRows copies the CSV values onto the items whose page has the row's
+// title. Rows without an item page in the export become new items.
+func (imp *notionImport) importRows(src *notionDatabase) error {
+	db, err := imp.store.loadDatabase(imp.ctx, imp.q, src.localID)
+	if err != nil {
+		return err
+	}
+	props := make(map[string]domain.DatabaseProperty, len(db.Properties))
+	for _, prop := range db.Properties {
+		props[prop.Name] = prop
+	}
+	itemsByTitle := make(map[string]string)
+	for _, page := range imp.archive.pages {
+		if page.parentID == src.sourceID {
+			if _, dup := itemsByTitle[page.title]; !dup {
+				itemsByTitle[page.title] = page.localID
+			}
+		}
+	}
+	coercer := &csvCoercer{ctx: imp.ctx, q: imp.q, createOptions: true, newOptions: make(map[string][]string)}
+	for idx, row := range src.rows {
+		if len(row) == 0 || strings.TrimSpace(row[0]) == "" {
+			continue
+		}
+		title := strings.TrimSpace(row[0])
+		pageID, ok := itemsByTitle[title]
+		if !ok {
+			if pageID, err = imp.importRowPage(src, title); err != nil {
+				return err
+			}
+			itemsByTitle[title] = pageID
+		}
+		var itemID string
+		if err := imp.q.QueryRowContext(imp.ctx, `SELECT id FROM database_items WHERE page_id = ? AND database_id = ?`, pageID, db.ID).Scan(&itemID); err != nil {
+			return fmt.Errorf("lookup item: %w", err)
+		}
+		values := make(map[string]any)
+		for col := 1; col < len(row) && col < len(src.header); col++ {
+			prop, ok := props[src.header[col]]
+			cell := strings.TrimSpace(row[col])
+			if !ok {
+				continue
+			}
+			if cell == "" {
+				values[prop.Slug] = nil
+				continue
+			}
+			value, err := imp.coerceNotionValue(coercer, prop, cell)
+			if err != nil {
+				imp.archive.issue(src.path, "row %d column %s: %v", idx+1, prop.Name, err)
+				continue
+			}
+			values[prop.Slug] = value
+		}
+		if _, err := imp.store.updateDatabaseItem(imp.ctx, imp.q, storage.UpdateDatabaseItemInput{DatabaseID: db.ID, ItemID: itemID, Values: values}, imp.now); err != nil {
+			return err
+		}
+	}
+	for _, prop := range db.Properties {
+		if added := coercer.newOptions[prop.Slug]; len(added) > 0 {
+			if err := addPropertyOptions(imp.ctx, imp.q, prop, added, imp.now); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// importRowPage creates (or, on re-import, finds) the page of a CSV row that
+// has no Markdown file of its own.
+func (imp *notionImport) importRowPage(src *notionDatabase, title string) (string, error) {
+	sourceID := src.sourceID + "/" + title
+	pageID, err := imp.lookupSource(sourceID, importKindPage)
+	if err != nil {
+		return "", err
+	}
+	if pageID != "" {
+		existing, err := loadPageRow(imp.ctx, imp.q, pageID)
+		if err != nil {
+			return "", err
+		}
+		if existing == nil {
+			pageID = ""
+		}
+	}
+	if pageID == "" {
+		pageID = uuid.NewString()
+		slug, err := uniqueSlug(imp.ctx, imp.q, "pages", storage.SlugOrDefault(title, "item"))
+		if err != nil {
+			return "", err
+		}
+		if err := insertPageRow(imp.ctx, imp.q, domain.Page{ID: pageID, Slug: slug, Title: title, Tags: []string{}, CreatedAt: imp.now, UpdatedAt: imp.now}); err != nil {
+			return "", err
+		}
+		if err := auditPageChange(imp.ctx, imp.q, storage.AuditActionCreate, pageID, nil, imp.now); err != nil {
+			return "", err
+		}
+		imp.report.PagesCreated++
+	}
+	if err := imp.ensureItem(src.localID, pageID); err != nil {
+		return "", err
+	}
+	return pageID, imp.recordSource(sourceID, importKindPage, pageID)
+}
+
+func (imp *notionImport) coerceNotionValue(coercer *csvCoercer, prop domain.DatabaseProperty, cell string) (any, error) {
+	switch prop.Type {
+	case domain.PropertyTypeRelation:
+		var ids []any
+		for _, m := range notionRelationPattern.FindAllStringSubmatch(cell, -1) {
+			target, err := url.PathUnescape(m[1])
+			if err != nil {
+				target = m[1]
+			}
+			base := path.Base(target)
+			_, id := splitNotionName(strings.TrimSuffix(base, path.Ext(base)))
+			linked, ok := imp.archive.pageByID[id]
+			if !ok {
+				return nil, fmt.Errorf("related page %s is not part of the export", target)
+			}
+			ids = append(ids, linked.localID)
+		}
+		return ids, nil
+	case domain.PropertyTypeDate:
+		return coerceNotionDate(cell)
+	}
+	return coercer.coerce(prop, cell)
+}
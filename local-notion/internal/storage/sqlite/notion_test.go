@@ -0,0 +1,148 @@
+package sqlite
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+const (
+	notionHomeID  = "11111111111111111111111111111111"
+	notionTasksID = "22222222222222222222222222222222"
+	notionChildID = "33333333333333333333333333333333"
+)
+
+func notionTestArchive(t *testing.T, files map[string]string) *zip.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range files {
+		f, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(body))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	return zr
+}
+
+func notionTestExport() map[string]string {
+	home := "Export/Home " + notionHomeID
+	tasks := home + "/Tasks " + notionTasksID
+	return map[string]string{
+		home + ".md":                             "# Home\n\nSee [Tasks](Home%20" + notionHomeID + "/Tasks%20" + notionTasksID + ".csv) and ![logo](Home%20" + notionHomeID + "/logo.png)\n",
+		home + "/logo.png":                       "png",
+		home + "/Child " + notionChildID + ".md": "# Child\n\nBack to [Home](../Home%20" + notionHomeID + ".md), not [Gone](Gone%2077777777777777777777777777777777.md).\n",
+		tasks + ".csv": "\ufeffName,Status,Points,Due,Done,Related\n" +
+			"Write,Todo,3,\"January 2, 2024\",Yes,Child (../Home%20" + notionHomeID + "/Child%20" + notionChildID + ".md)\n" +
+			"Ship,Done,5,\"January 5, 2024 → January 9, 2024\",No,\n" +
+			"Test,Todo,1,,No,\n" +
+			"Orphan,Done,2,,No,\n",
+		tasks + "/Write 44444444444444444444444444444444.md": "# Write\n\nStatus: Todo\nPoints: 3\n\nDraft the docs.\n",
+		tasks + "/Ship 55555555555555555555555555555555.md":  "# Ship\n\nStatus: Done\n",
+		tasks + "/Test 66666666666666666666666666666666.md":  "# Test\n",
+	}
+}
+
+func TestStoreImportNotion(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	assetDir := t.TempDir()
+
+	report, err := store.ImportNotion(ctx, storage.ImportNotionInput{Archive: notionTestArchive(t, notionTestExport()), AssetDir: assetDir})
+	require.NoError(t, err)
+	require.Equal(t, 6, report.PagesCreated)
+	require.Equal(t, 1, report.DatabasesCreated)
+	require.Equal(t, 4, report.ItemsCreated)
+	require.Equal(t, 1, report.Assets)
+	require.Equal(t, 1, report.Links)
+	require.Len(t, report.Issues, 1)
+	require.Contains(t, report.Issues[0].Message, "Gone 77777777777777777777777777777777.md could not be resolved")
+
+	pages, err := store.ListPages(ctx)
+	require.NoError(t, err)
+	byTitle := make(map[string]domain.Page)
+	for _, page := range pages {
+		byTitle[page.Title] = page
+	}
+	require.Len(t, byTitle, 6)
+	home, err := store.GetPage(ctx, byTitle["Home"].ID)
+	require.NoError(t, err)
+	require.Contains(t, home.Content, "![logo](/api/assets/")
+	require.Contains(t, home.Content, "[Tasks](/databases/")
+	child, err := store.GetPage(ctx, byTitle["Child"].ID)
+	require.NoError(t, err)
+	require.Equal(t, home.ID, *child.ParentPageID)
+	require.Equal(t, []string{home.ID}, child.LinkedPageIDs)
+	write, err := store.GetPage(ctx, byTitle["Write"].ID)
+	require.NoError(t, err)
+	require.Equal(t, "Draft the docs.", write.Content)
+
+	var dbID string
+	require.NoError(t, store.db.QueryRowContext(ctx, `SELECT local_id FROM import_sources WHERE source_id = ?`, notionTasksID).Scan(&dbID))
+	db, err := store.GetDatabase(ctx, dbID)
+	require.NoError(t, err)
+	types := make(map[string]domain.PropertyType)
+	for _, prop := range db.Properties {
+		types[prop.Slug] = prop.Type
+	}
+	require.Equal(t, map[string]domain.PropertyType{
+		"status":  domain.PropertyTypeSelect,
+		"points":  domain.PropertyTypeNumber,
+		"due":     domain.PropertyTypeDate,
+		"done":    domain.PropertyTypeCheckbox,
+		"related": domain.PropertyTypeRelation,
+	}, types)
+	items, err := store.ListViewItems(ctx, db.ID, db.Views[0].ID)
+	require.NoError(t, err)
+	require.Len(t, items, 4)
+	values := make(map[string]map[string]any)
+	for _, item := range items {
+		values[item.Page.Title] = itemValuesBySlug(item)
+	}
+	require.Equal(t, map[string]any{"status": "Todo", "points": 3.0, "due": "2024-01-02", "done": true, "related": []any{child.ID}}, values["Write"])
+	require.Equal(t, map[string]any{"start": "2024-01-05", "end": "2024-01-09"}, values["Ship"]["due"])
+
+	// Importing the same export again updates the earlier import in place.
+	report, err = store.ImportNotion(ctx, storage.ImportNotionInput{Archive: notionTestArchive(t, notionTestExport()), AssetDir: assetDir})
+	require.NoError(t, err)
+	require.Zero(t, report.PagesCreated)
+	require.Equal(t, 5, report.PagesUpdated)
+	require.Equal(t, 1, report.DatabasesUpdated)
+	require.Zero(t, report.ItemsCreated)
+	pages, err = store.ListPages(ctx)
+	require.NoError(t, err)
+	require.Len(t, pages, 6)
+}
+
+func TestStoreImportNotionRejectsEmptyArchive(t *testing.T) {
+	store := newTestStore(t)
+	_, err := store.ImportNotion(context.Background(), storage.ImportNotionInput{Archive: notionTestArchive(t, map[string]string{"notes.txt": "hi"})})
+	require.ErrorIs(t, err, ErrInvalidNotionExport)
+}
+
+func TestInferNotionType(t *testing.T) {
+	propType, options := inferNotionType([]string{"a, b", "b", ""})
+	require.Equal(t, domain.PropertyTypeMultiSelect, propType)
+	require.Equal(t, []any{"a", "b"}, options)
+	propType, _ = inferNotionType([]string{"first note", "second note"})
+	require.Equal(t, domain.PropertyTypeText, propType)
+	propType, _ = inferNotionType([]string{"https://example.com", ""})
+	require.Equal(t, domain.PropertyTypeURL, propType)
+}
+
+func itemValuesBySlug(item domain.DatabaseItem) map[string]any {
+	out := make(map[string]any, len(item.PropertyMap))
+	for slug, value := range item.PropertyMap {
+		out[slug] = value.RawValue
+	}
+	return out
+}
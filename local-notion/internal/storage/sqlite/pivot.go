@@ -0,0 +1,363 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// maxPivotKeys bounds the number of rows or columns a pivot may produce, which
+// matters once empty date buckets are filled in.
+const maxPivotKeys = 1000
+
+// ErrInvalidPivot wraps pivot requests that do not fit the database schema.
+var ErrInvalidPivot = storage.InvalidError("invalid_pivot", "invalid pivot")
+
+var pivotDimensionTypes = map[domain.PropertyType]bool{
+	domain.PropertyTypeSelect:      true,
+	domain.PropertyTypeMultiSelect: true,
+	domain.PropertyTypeCheckbox:    true,
+	domain.PropertyTypeRelation:    true,
+	domain.PropertyTypeDate:        true,
+}
+
+// PivotView aggregates the items of a view into a row by column matrix. Items
+// with several values for a dimension (multi-selects, relations) count once
+// in each matching cell.
+func (s *Store) PivotView(ctx context.Context, in storage.PivotViewInput) (*domain.PivotResult, error) {
+	view, err := s.QueryView(ctx, in.DatabaseID, in.ViewID)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := s.schema(ctx, s.reader, in.DatabaseID)
+	if err != nil {
+		return nil, err
+	}
+	props, options := schema.types, schema.options
+	if err := validatePivot(in, props); err != nil {
+		return nil, err
+	}
+	values := make([]map[string]any, len(view.Items))
+	for idx, item := range view.Items {
+		values[idx] = storage.ItemValuesByID(item)
+	}
+	rowDim := newPivotAxis(in.Rows, props[in.Rows.PropertyID], options[in.Rows.PropertyID])
+	colDim := &pivotAxis{}
+	if in.Columns != nil {
+		colDim = newPivotAxis(*in.Columns, props[in.Columns.PropertyID], options[in.Columns.PropertyID])
+	}
+	type cellKey struct{ row, col string }
+	cells := make(map[cellKey]*pivotAccumulator)
+	rowTotals := make(map[string]*pivotAccumulator)
+	colTotals := make(map[string]*pivotAccumulator)
+	grand := &pivotAccumulator{}
+	accumulator := func(m map[string]*pivotAccumulator, key string) *pivotAccumulator {
+		if m[key] == nil {
+			m[key] = &pivotAccumulator{}
+		}
+		return m[key]
+	}
+	for _, itemValues := range values {
+		measure, hasMeasure := 0.0, true
+		if in.Measure != storage.PivotMeasureCount {
+			measure, hasMeasure = storage.NumericValue(itemValues[in.MeasurePropertyID])
+		}
+		rowKeys := rowDim.keys(itemValues[in.Rows.PropertyID])
+		colKeys := []string{""}
+		if in.Columns != nil {
+			colKeys = colDim.keys(itemValues[in.Columns.PropertyID])
+		}
+		for _, row := range rowKeys {
+			for _, col := range colKeys {
+				cell := cells[cellKey{row, col}]
+				if cell == nil {
+					cell = &pivotAccumulator{}
+					cells[cellKey{row, col}] = cell
+				}
+				cell.add(measure, hasMeasure)
+			}
+			accumulator(rowTotals, row).add(measure, hasMeasure)
+		}
+		for _, col := range colKeys {
+			accumulator(colTotals, col).add(measure, hasMeasure)
+		}
+		grand.add(measure, hasMeasure)
+	}
+	rowHeaders, err := rowDim.headers(ctx, s.reader)
+	if err != nil {
+		return nil, err
+	}
+	colHeaders := []domain.PivotHeader{{Key: "", Label: "Total"}}
+	if in.Columns != nil {
+		if colHeaders, err = colDim.headers(ctx, s.reader); err != nil {
+			return nil, err
+		}
+	}
+	result := &domain.PivotResult{
+		Measure:           in.Measure,
+		MeasurePropertyID: in.MeasurePropertyID,
+		Rows:              rowHeaders,
+		Columns:           colHeaders,
+		Values:            make([][]*float64, len(rowHeaders)),
+		RowTotals:         make([]*float64, len(rowHeaders)),
+		ColumnTotals:      make([]*float64, len(colHeaders)),
+		Total:             grand.value(in.Measure),
+	}
+	for r, row := range rowHeaders {
+		result.Values[r] = make([]*float64, len(colHeaders))
+		for c, col := range colHeaders {
+			result.Values[r][c] = cells[cellKey{row.Key, col.Key}].value(in.Measure)
+		}
+		result.RowTotals[r] = rowTotals[row.Key].value(in.Measure)
+	}
+	for c, col := range colHeaders {
+		result.ColumnTotals[c] = colTotals[col.Key].value(in.Measure)
+	}
+	return result, nil
+}
+
+func validatePivot(in storage.PivotViewInput, props map[string]domain.PropertyType) error {
+	dims := []storage.PivotDimension{in.Rows}
+	if in.Columns != nil {
+		dims = append(dims, *in.Columns)
+	}
+	for _, dim := range dims {
+		propType, ok := props[dim.PropertyID]
+		if !ok {
+			return fmt.Errorf("%w: unknown dimension property %q", ErrInvalidPivot, dim.PropertyID)
+		}
+		if !pivotDimensionTypes[propType] {
+			return fmt.Errorf("%w: cannot pivot on a %s property", ErrInvalidPivot, propType)
+		}
+		if propType == domain.PropertyTypeDate {
+			switch dim.Bucket {
+			case storage.PivotBucketDay, storage.PivotBucketWeek, storage.PivotBucketMonth, storage.PivotBucketQuarter, storage.PivotBucketYear:
+			default:
+				return fmt.Errorf("%w: date dimensions need a bucket of day, week, month, quarter or year", ErrInvalidPivot)
+			}
+		}
+	}
+	switch in.Measure {
+	case storage.PivotMeasureCount:
+	case storage.PivotMeasureSum, storage.PivotMeasureAverage:
+		if !storage.IsNumericPropertyType(props[in.MeasurePropertyID]) {
+			return fmt.Errorf("%w: %s needs a number measure property", ErrInvalidPivot, in.Measure)
+		}
+	default:
+		return fmt.Errorf("%w: measure must be count, sum or average", ErrInvalidPivot)
+	}
+	return nil
+}
+
+type pivotAccumulator struct {
+	count int
+	sum   float64
+	n     int
+}
+
+func (a *pivotAccumulator) add(v float64, ok bool) {
+	a.count++
+	if ok {
+		a.sum += v
+		a.n++
+	}
+}
+
+// value returns the measure of the accumulated items. Empty cells count as
+// zero, while averages without any number are nil so charts can leave a gap.
+func (a *pivotAccumulator) value(measure string) *float64 {
+	var v float64
+	switch {
+	case a == nil && measure == storage.PivotMeasureAverage:
+		return nil
+	case a == nil:
+	case measure == storage.PivotMeasureCount:
+		v = float64(a.count)
+	case measure == storage.PivotMeasureSum:
+		v = storage.RoundAggregate(a.sum)
+	case a.n == 0:
+		return nil
+	default:
+		v = storage.RoundAggregate(a.sum / float64(a.n))
+	}
+	return &v
+}
+
+// pivotAxis turns raw values into dimension keys and collects the keys seen.
+type pivotAxis struct {
+	dim      storage.PivotDimension
+	propType domain.PropertyType
+	options  []string
+	seen     map[string]bool
+	order    []string
+	empty    bool
+}
+
+func newPivotAxis(dim storage.PivotDimension, propType domain.PropertyType, options []string) *pivotAxis {
+	return &pivotAxis{dim: dim, propType: propType, options: options, seen: make(map[string]bool)}
+}
+
+func (a *pivotAxis) keys(value any) []string {
+	var keys []string
+	switch a.propType {
+	case domain.PropertyTypeCheckbox:
+		keys = []string{fmt.Sprint(value == true)}
+	case domain.PropertyTypeDate:
+		if t, ok := storage.DateValue(value); ok {
+			keys = []string{bucketStart(t, a.dim.Bucket).Format("2006-01-02")}
+		}
+	default:
+		if !storage.IsEmptyValue(value) {
+			list, ok := value.([]any)
+			if !ok {
+				list = []any{value}
+			}
+			for _, el := range list {
+				keys = append(keys, fmt.Sprint(el))
+			}
+		}
+	}
+	if len(keys) == 0 {
+		a.empty = true
+		return []string{""}
+	}
+	for _, key := range keys {
+		if !a.seen[key] {
+			a.seen[key] = true
+			a.order = append(a.order, key)
+		}
+	}
+	return keys
+}
+
+// headers lists the axis keys in chart order: option order for selects,
+// false before true for checkboxes, every bucket between the first and last
+// date, and relation targets by page title. Items without a value come last.
+func (a *pivotAxis) headers(ctx context.Context, q queryer) ([]domain.PivotHeader, error) {
+	var out []domain.PivotHeader
+	switch a.propType {
+	case domain.PropertyTypeCheckbox:
+		out = []domain.PivotHeader{{Key: "false", Label: "Unchecked"}, {Key: "true", Label: "Checked"}}
+	case domain.PropertyTypeDate:
+		keys := append([]string(nil), a.order...)
+		sort.Strings(keys)
+		if len(keys) > 0 {
+			first, _ := time.Parse("2006-01-02", keys[0])
+			last, _ := time.Parse("2006-01-02", keys[len(keys)-1])
+			for t := first; !t.After(last); t = nextBucket(t, a.dim.Bucket) {
+				if len(out) >= maxPivotKeys {
+					return nil, fmt.Errorf("%w: more than %d %s buckets", ErrInvalidPivot, maxPivotKeys, a.dim.Bucket)
+				}
+				out = append(out, domain.PivotHeader{Key: t.Format("2006-01-02"), Label: bucketLabel(t, a.dim.Bucket)})
+			}
+		}
+	case domain.PropertyTypeRelation:
+		titles, err := pageTitles(ctx, q, a.order)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range a.order {
+			label := titles[key]
+			if label == "" {
+				label = key
+			}
+			out = append(out, domain.PivotHeader{Key: key, Label: label})
+		}
+		sort.SliceStable(out, func(i, j int) bool { return out[i].Label < out[j].Label })
+	default:
+		listed := make(map[string]bool)
+		for _, option := range a.options {
+			listed[option] = true
+			out = append(out, domain.PivotHeader{Key: option, Label: option})
+		}
+		for _, key := range a.order {
+			if !listed[key] {
+				out = append(out, domain.PivotHeader{Key: key, Label: key})
+			}
+		}
+	}
+	if a.empty {
+		out = append(out, domain.PivotHeader{Key: "", Label: "No value"})
+	}
+	if len(out) > maxPivotKeys {
+		return nil, fmt.Errorf("%w: more than %d distinct values", ErrInvalidPivot, maxPivotKeys)
+	}
+	return out, nil
+}
+
+// bucketStart truncates a time to the start of its bucket. Weeks start on Monday.
+func bucketStart(t time.Time, bucket string) time.Time {
+	y, m, d := t.UTC().Date()
+	switch bucket {
+	case storage.PivotBucketWeek:
+		day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case storage.PivotBucketMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	case storage.PivotBucketQuarter:
+		return time.Date(y, m-(m-1)%3, 1, 0, 0, 0, 0, time.UTC)
+	case storage.PivotBucketYear:
+		return time.Date(y, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func nextBucket(t time.Time, bucket string) time.Time {
+	switch bucket {
+	case storage.PivotBucketWeek:
+		return t.AddDate(0, 0, 7)
+	case storage.PivotBucketMonth:
+		return t.AddDate(0, 1, 0)
+	case storage.PivotBucketQuarter:
+		return t.AddDate(0, 3, 0)
+	case storage.PivotBucketYear:
+		return t.AddDate(1, 0, 0)
+	}
+	return t.AddDate(0, 0, 1)
+}
+
+func bucketLabel(t time.Time, bucket string) string {
+	switch bucket {
+	case storage.PivotBucketWeek:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case storage.PivotBucketMonth:
+		return t.Format("2006-01")
+	case storage.PivotBucketQuarter:
+		return fmt.Sprintf("%d-Q%d", t.Year(), (int(t.Month())-1)/3+1)
+	case storage.PivotBucketYear:
+		return t.Format("2006")
+	}
+	return t.Format("2006-01-02")
+}
+
+func pageTitles(ctx context.Context, q queryer, ids []string) (map[string]string, error) {
+	titles := make(map[string]string, len(ids))
+	if len(ids) == 0 {
+		return titles, nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	rows, err := q.QueryContext(ctx, `SELECT id, title FROM pages WHERE id IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query page titles: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, title string
+		if err := rows.Scan(&id, &title); err != nil {
+			return nil, fmt.Errorf("scan page title: %w", err)
+		}
+		titles[id] = title
+	}
+	return titles, rows.Err()
+}
@@ -0,0 +1,131 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+func TestStorePivotView(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db, err := store.CreateDatabase(ctx, storage.CreateDatabaseInput{
+		Slug:  "tasks",
+		Title: "Tasks",
+		Properties: []storage.DatabasePropertyInput{
+			{Name: "Status", Slug: "status", Type: domain.PropertyTypeSelect, Config: map[string]any{"options": []string{"Todo", "Done"}}},
+			{Name: "Due", Slug: "due", Type: domain.PropertyTypeDate},
+			{Name: "Points", Slug: "points", Type: domain.PropertyTypeNumber},
+			{Name: "Notes", Slug: "notes", Type: domain.PropertyTypeText},
+		},
+		Views: []storage.DatabaseViewInput{{Name: "Table", Type: domain.ViewTypeTable}},
+	})
+	require.NoError(t, err)
+	status := propertyIDBySlug(t, db, "status")
+	due := propertyIDBySlug(t, db, "due")
+	points := propertyIDBySlug(t, db, "points")
+
+	rows := []map[string]any{
+		{"status": "Todo", "due": "2024-01-01", "points": 1},  // Monday, week 1
+		{"status": "Done", "due": "2024-01-03", "points": 2},  // week 1
+		{"status": "Done", "due": "2024-01-17", "points": 4},  // week 3
+		{"status": "Todo", "points": 8},                       // no due date
+		{"status": "Done", "due": "2024-01-16", "points": 16}, // week 3
+	}
+	for idx, values := range rows {
+		_, err := store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
+			DatabaseID: db.ID,
+			Page:       storage.CreatePageInput{Slug: string(rune('a' + idx)), Title: string(rune('A' + idx))},
+			Values:     values,
+		})
+		require.NoError(t, err)
+	}
+
+	result, err := store.PivotView(ctx, storage.PivotViewInput{
+		DatabaseID: db.ID,
+		ViewID:     db.Views[0].ID,
+		Rows:       storage.PivotDimension{PropertyID: due, Bucket: storage.PivotBucketWeek},
+		Columns:    &storage.PivotDimension{PropertyID: status},
+		Measure:    storage.PivotMeasureCount,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []domain.PivotHeader{
+		{Key: "2024-01-01", Label: "2024-W01"},
+		{Key: "2024-01-08", Label: "2024-W02"},
+		{Key: "2024-01-15", Label: "2024-W03"},
+		{Key: "", Label: "No value"},
+	}, result.Rows)
+	require.Equal(t, []domain.PivotHeader{{Key: "Todo", Label: "Todo"}, {Key: "Done", Label: "Done"}}, result.Columns)
+	require.Equal(t, [][]float64{{1, 1}, {0, 0}, {0, 2}, {1, 0}}, derefMatrix(result.Values))
+	require.Equal(t, 5.0, *result.Total)
+
+	sums, err := store.PivotView(ctx, storage.PivotViewInput{
+		DatabaseID:        db.ID,
+		ViewID:            db.Views[0].ID,
+		Rows:              storage.PivotDimension{PropertyID: status},
+		Measure:           storage.PivotMeasureAverage,
+		MeasurePropertyID: points,
+	})
+	require.NoError(t, err)
+	require.Len(t, sums.Columns, 1)
+	require.Equal(t, [][]float64{{4.5}, {7.333333333}}, derefMatrix(sums.Values))
+
+	// The view's filters apply to the pivot.
+	_, err = store.UpdateDatabaseView(ctx, storage.UpdateDatabaseViewInput{
+		DatabaseID: db.ID,
+		ViewID:     db.Views[0].ID,
+		Filters:    map[string]any{"property_id": points, "operator": "greater_than", "value": 3},
+	})
+	require.NoError(t, err)
+	filtered, err := store.PivotView(ctx, storage.PivotViewInput{
+		DatabaseID:        db.ID,
+		ViewID:            db.Views[0].ID,
+		Rows:              storage.PivotDimension{PropertyID: status},
+		Measure:           storage.PivotMeasureSum,
+		MeasurePropertyID: points,
+	})
+	require.NoError(t, err)
+	require.Equal(t, [][]float64{{8}, {20}}, derefMatrix(filtered.Values))
+
+	invalid := []storage.PivotViewInput{
+		{Rows: storage.PivotDimension{PropertyID: propertyIDBySlug(t, db, "notes")}, Measure: storage.PivotMeasureCount},
+		{Rows: storage.PivotDimension{PropertyID: due}, Measure: storage.PivotMeasureCount},
+		{Rows: storage.PivotDimension{PropertyID: status}, Measure: storage.PivotMeasureSum, MeasurePropertyID: status},
+		{Rows: storage.PivotDimension{PropertyID: status}, Measure: "mode"},
+	}
+	for _, in := range invalid {
+		in.DatabaseID, in.ViewID = db.ID, db.Views[0].ID
+		_, err := store.PivotView(ctx, in)
+		require.ErrorIs(t, err, ErrInvalidPivot)
+	}
+
+	_, err = store.PivotView(ctx, storage.PivotViewInput{DatabaseID: db.ID, ViewID: "missing", Rows: storage.PivotDimension{PropertyID: status}, Measure: storage.PivotMeasureCount})
+	require.ErrorIs(t, err, storage.ErrViewNotFound)
+}
+
+func TestBucketStart(t *testing.T) {
+	ts := time.Date(2024, time.August, 18, 15, 30, 0, 0, time.UTC) // a Sunday
+	require.Equal(t, "2024-08-12", bucketStart(ts, storage.PivotBucketWeek).Format("2006-01-02"))
+	require.Equal(t, "2024-08-01", bucketStart(ts, storage.PivotBucketMonth).Format("2006-01-02"))
+	require.Equal(t, "2024-07-01", bucketStart(ts, storage.PivotBucketQuarter).Format("2006-01-02"))
+	require.Equal(t, "2024-Q3", bucketLabel(bucketStart(ts, storage.PivotBucketQuarter), storage.PivotBucketQuarter))
+	require.Equal(t, "2024-01-01", bucketStart(ts, storage.PivotBucketYear).Format("2006-01-02"))
+}
+
+func derefMatrix(values [][]*float64) [][]float64 {
+	out := make([][]float64, len(values))
+	for r, row := range values {
+		out[r] = make([]float64, len(row))
+		for c, v := range row {
+			if v != nil {
+				out[r][c] = *v
+			}
+		}
+	}
+	return out
+}
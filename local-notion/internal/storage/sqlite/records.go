@@ -0,0 +1,279 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// maxSubtreeDepth bounds recursive page walks so a corrupted parent chain
+// cannot loop forever.
+const maxSubtreeDepth = 64
+
+// ErrSubtreeTooDeep is returned when a page subtree nests deeper than maxSubtreeDepth.
+var ErrSubtreeTooDeep = storage.InvalidError("subtree_too_deep", fmt.Sprintf("page subtree is nested deeper than %d levels", maxSubtreeDepth))
+
+const pageColumns = `p.id, p.slug, p.title, p.summary, p.content, p.parent_page_id, p.cover_image_id, p.icon, p.tags, p.is_archived, p.is_template, p.version, p.created_at, p.updated_at`
+
+func scanPageRow(scan func(dest ...any) error) (*domain.Page, error) {
+	var page domain.Page
+	var summary, content, tags sql.NullString
+	var parent, cover, icon sql.NullString
+	if err := scan(&page.ID, &page.Slug, &page.Title, &summary, &content, &parent, &cover, &icon, &tags, &page.IsArchived, &page.IsTemplate, &page.Version, &page.CreatedAt, &page.UpdatedAt); err != nil {
+		return nil, err
+	}
+	page.Summary = summary.String
+	page.Content = content.String
+	if parent.Valid {
+		page.ParentPageID = &parent.String
+	}
+	if cover.Valid {
+		page.CoverImageID = &cover.String
+	}
+	if icon.Valid {
+		page.Icon = &icon.String
+	}
+	if tags.String != "" {
+		if err := json.Unmarshal([]byte(tags.String), &page.Tags); err != nil {
+			return nil, fmt.Errorf("unmarshal tags: %w", err)
+		}
+	}
+	return &page, nil
+}
+
+// loadPageRow loads a single page without its links. It returns nil when the
+// page does not exist.
+func loadPageRow(ctx context.Context, q queryer, id string) (*domain.Page, error) {
+	row := q.QueryRowContext(ctx, `SELECT `+pageColumns+` FROM pages p WHERE p.id = ?`, id)
+	page, err := scanPageRow(row.Scan)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("scan page: %w", err)
+	}
+	return page, nil
+}
+
+// loadPageSubtree returns the page identified by rootID followed by all of its
+// descendants, ordered so that every parent precedes its children. It returns
+// ErrSubtreeTooDeep rather than a truncated tree when pages nest deeper than
+// maxSubtreeDepth.
+func loadPageSubtree(ctx context.Context, q queryer, rootID string) ([]domain.Page, error) {
+	rows, err := q.QueryContext(ctx, `WITH RECURSIVE subtree(id, depth) AS (
+    SELECT id, 0 FROM pages WHERE id = ?
+    UNION ALL
+    SELECT child.id, subtree.depth + 1 FROM pages child JOIN subtree ON child.parent_page_id = subtree.id
+    WHERE subtree.depth < ?
+)
+SELECT `+pageColumns+`, subtree.depth FROM pages p JOIN subtree ON subtree.id = p.id ORDER BY subtree.depth ASC, p.title ASC`, rootID, maxSubtreeDepth+1)
+	if err != nil {
+		return nil, fmt.Errorf("query page subtree: %w", err)
+	}
+	defer rows.Close()
+	var pages []domain.Page
+	for rows.Next() {
+		var depth int
+		page, err := scanPageRow(func(dest ...any) error { return rows.Scan(append(dest, &depth)...) })
+		if err != nil {
+			return nil, fmt.Errorf("scan subtree page: %w", err)
+		}
+		if depth > maxSubtreeDepth {
+			return nil, ErrSubtreeTooDeep
+		}
+		pages = append(pages, *page)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate page subtree: %w", err)
+	}
+	return pages, nil
+}
+
+// insertPageRow writes every column of page, including template and archive flags.
+func insertPageRow(ctx context.Context, q queryer, page domain.Page) error {
+	tagJSON, err := json.Marshal(page.Tags)
+	if err != nil {
+		return fmt.Errorf("marshal tags: %w", err)
+	}
+	if _, err := q.ExecContext(ctx, `INSERT INTO pages(
+    id, slug, title, summary, content, parent_page_id, cover_image_id, icon, tags, is_archived, is_template, created_at, updated_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		page.ID, page.Slug, page.Title, page.Summary, page.Content, page.ParentPageID, page.CoverImageID, page.Icon, string(tagJSON),
+		boolToInt(page.IsArchived), boolToInt(page.IsTemplate), page.CreatedAt, page.UpdatedAt); err != nil {
+		return insertError("page", page.Slug, err)
+	}
+	return nil
+}
+
+// copyPageLinks duplicates outbound links of the source pages onto their
+// copies. Links whose target is part of the copied set are pointed at the copy;
+// links to pages outside the set keep their original target.
+func copyPageLinks(ctx context.Context, q queryer, pages []domain.Page, newIDs map[string]string, now time.Time) error {
+	if len(pages) == 0 {
+		return nil
+	}
+	placeholders := make([]string, len(pages))
+	args := make([]any, len(pages))
+	for i, page := range pages {
+		placeholders[i] = "?"
+		args[i] = page.ID
+	}
+	rows, err := q.QueryContext(ctx, `SELECT source_page_id, target_page_id FROM page_links WHERE source_page_id IN (`+strings.Join(placeholders, ", ")+`)`, args...)
+	if err != nil {
+		return fmt.Errorf("select page links: %w", err)
+	}
+	var links [][2]string
+	for rows.Next() {
+		var source, target string
+		if err := rows.Scan(&source, &target); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan page link: %w", err)
+		}
+		links = append(links, [2]string{source, target})
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("iterate page links: %w", err)
+	}
+	rows.Close()
+	for _, link := range links {
+		target := link[1]
+		if mapped, ok := newIDs[target]; ok {
+			target = mapped
+		}
+		if _, err := q.ExecContext(ctx, `INSERT OR IGNORE INTO page_links(source_page_id, target_page_id, created_at) VALUES (?, ?, ?)`,
+			newIDs[link[0]], target, now); err != nil {
+			return fmt.Errorf("insert page link: %w", err)
+		}
+	}
+	return nil
+}
+
+func insertPropertyRow(ctx context.Context, q queryer, prop domain.DatabaseProperty, now time.Time) error {
+	cfg, err := json.Marshal(prop.Config)
+	if err != nil {
+		return fmt.Errorf("marshal property config: %w", err)
+	}
+	defVal, err := json.Marshal(prop.Default)
+	if err != nil {
+		return fmt.Errorf("marshal property default: %w", err)
+	}
+	if _, err := q.ExecContext(ctx, `INSERT INTO database_properties(id, database_id, name, slug, type, config, is_required, default_value, order_index, created_at, updated_at) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		prop.ID, prop.DatabaseID, prop.Name, prop.Slug, string(prop.Type), string(cfg), boolToInt(prop.IsRequired), string(defVal), prop.OrderIndex, now, now); err != nil {
+		return insertError("property", prop.Slug, err)
+	}
+	return nil
+}
+
+const viewColumns = `id, database_id, name, type, filters, sorts, grouping, display_properties, layout_options, version, created_at, updated_at`
+
+func scanViewRow(row interface{ Scan(...any) error }) (*domain.DatabaseView, error) {
+	var view domain.DatabaseView
+	var filters, sorts, grouping, display, layout sql.NullString
+	if err := row.Scan(&view.ID, &view.DatabaseID, &view.Name, &view.Type, &filters, &sorts, &grouping, &display, &layout, &view.Version, &view.CreatedAt, &view.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("scan view: %w", err)
+	}
+	if filters.String != "" {
+		_ = json.Unmarshal([]byte(filters.String), &view.Filters)
+	}
+	if sorts.String != "" {
+		_ = json.Unmarshal([]byte(sorts.String), &view.Sorts)
+	}
+	if grouping.String != "" {
+		_ = json.Unmarshal([]byte(grouping.String), &view.Grouping)
+	}
+	if display.String != "" {
+		_ = json.Unmarshal([]byte(display.String), &view.Display)
+	}
+	if layout.String != "" {
+		_ = json.Unmarshal([]byte(layout.String), &view.LayoutOptions)
+	}
+	return &view, nil
+}
+
+func insertViewRow(ctx context.Context, q queryer, view domain.DatabaseView, now time.Time) error {
+	filters, err := json.Marshal(view.Filters)
+	if err != nil {
+		return fmt.Errorf("marshal view filters: %w", err)
+	}
+	sorts, err := json.Marshal(view.Sorts)
+	if err != nil {
+		return fmt.Errorf("marshal view sorts: %w", err)
+	}
+	grouping, err := json.Marshal(view.Grouping)
+	if err != nil {
+		return fmt.Errorf("marshal view grouping: %w", err)
+	}
+	display, err := json.Marshal(view.Display)
+	if err != nil {
+		return fmt.Errorf("marshal view display: %w", err)
+	}
+	layout, err := json.Marshal(view.LayoutOptions)
+	if err != nil {
+		return fmt.Errorf("marshal view layout: %w", err)
+	}
+	if _, err := q.ExecContext(ctx, `INSERT INTO database_views(id, database_id, name, type, filters, sorts, grouping, display_properties, layout_options, created_at, updated_at) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		view.ID, view.DatabaseID, view.Name, string(view.Type), string(filters), string(sorts), string(grouping), string(display), string(layout), now, now); err != nil {
+		return fmt.Errorf("insert view: %w", err)
+	}
+	return nil
+}
+
+// loadItemValues returns the stored values of a single item.
+func loadItemValues(ctx context.Context, q queryer, itemID string) ([]domain.DatabaseValue, error) {
+	rows, err := q.QueryContext(ctx, `SELECT id, property_id, value, is_computed, created_at, updated_at FROM database_values WHERE database_item_id = ?`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("query item values: %w", err)
+	}
+	defer rows.Close()
+	var values []domain.DatabaseValue
+	for rows.Next() {
+		var value domain.DatabaseValue
+		var raw sql.NullString
+		var isComputed int
+		if err := rows.Scan(&value.ID, &value.PropertyID, &raw, &isComputed, &value.CreatedAt, &value.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan value: %w", err)
+		}
+		if raw.String != "" {
+			var parsed any
+			if err := json.Unmarshal([]byte(raw.String), &parsed); err == nil {
+				value.RawValue = parsed
+			}
+		}
+		value.ItemID = itemID
+		value.IsComputed = isComputed == 1
+		values = append(values, value)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate item values: %w", err)
+	}
+	return values, nil
+}
+
+// propertySlugs maps property slugs to identifiers for a database.
+func propertySlugs(ctx context.Context, q queryer, databaseID string) (map[string]string, error) {
+	rows, err := q.QueryContext(ctx, `SELECT id, slug FROM database_properties WHERE database_id = ?`, databaseID)
+	if err != nil {
+		return nil, fmt.Errorf("query properties: %w", err)
+	}
+	defer rows.Close()
+	slugs := make(map[string]string)
+	for rows.Next() {
+		var id, slug string
+		if err := rows.Scan(&id, &slug); err != nil {
+			return nil, fmt.Errorf("scan property: %w", err)
+		}
+		slugs[slug] = id
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate properties: %w", err)
+	}
+	return slugs, nil
+}
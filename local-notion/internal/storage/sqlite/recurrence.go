@@ -0,0 +1,671 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// Recurrence generation modes.
+const (
+	RecurrenceOnComplete = "on_complete" // when the latest item's complete checkbox is ticked
+	RecurrenceScheduled  = "scheduled"   // when the latest item's date arrives
+)
+
+// Recurrence edit scopes.
+const (
+	RecurrenceScopeAll       = "all"       // every item of the series
+	RecurrenceScopeFollowing = "following" // the item and the ones after it
+)
+
+// maxCatchUpOccurrences bounds the items one scheduler run creates for a
+// series that fell behind.
+const maxCatchUpOccurrences = 1000
+
+var (
+	// ErrItemNotRecurring is returned when an item is not part of a series.
+	ErrItemNotRecurring = storage.NotFoundError("item_not_recurring", "item is not part of a recurrence series")
+	// ErrSeriesNotFound is returned when a series does not exist in the given database.
+	ErrSeriesNotFound = storage.NotFoundError("series_not_found", "recurrence series not found")
+	// ErrItemAlreadyRecurring is returned when an item already belongs to a series.
+	ErrItemAlreadyRecurring = storage.ConflictError("item_already_recurring", "item is already part of a recurrence series")
+)
+
+// An item becomes the first occurrence of a series when a rule is set on one
+// of its date properties. The next occurrence is a copy of the latest item,
+// values included, with the date moved to the next date of the rule that is
+// not an exception, and the complete checkbox cleared. Occurrences are
+// created one at a time, so editing the rule changes every occurrence not
+// created yet. Editing "this and following" splits the series: the old one
+// ends and a new one starts at the edited item, taking it and the items
+// after it along.
+
+// GetItemRecurrence returns the series an item belongs to.
+func (s *Store) GetItemRecurrence(ctx context.Context, databaseID, itemID string) (*domain.RecurrenceSeries, error) {
+	series, _, err := itemSeries(ctx, s.reader, databaseID, itemID)
+	return series, err
+}
+
+// GetRecurrenceSeries returns a series with its occurrences.
+func (s *Store) GetRecurrenceSeries(ctx context.Context, databaseID, seriesID string) (*domain.RecurrenceSeries, error) {
+	series, err := loadSeries(ctx, s.reader, seriesID)
+	if err != nil {
+		return nil, err
+	}
+	if series.DatabaseID != databaseID {
+		return nil, ErrSeriesNotFound
+	}
+	return series, nil
+}
+
+// SetItemRecurrence starts a series with the item as its first occurrence.
+// The item's value of the date property is the series start.
+func (s *Store) SetItemRecurrence(ctx context.Context, in storage.SetItemRecurrenceInput) (*domain.RecurrenceSeries, error) {
+	if err := storage.RequireFields("property", in.Property, "rule", in.Rule); err != nil {
+		return nil, err
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := itemPageID(ctx, tx, in.DatabaseID, in.ItemID); err != nil {
+		return nil, err
+	}
+	if _, _, err := itemSeries(ctx, tx, in.DatabaseID, in.ItemID); err == nil {
+		return nil, ErrItemAlreadyRecurring
+	} else if !errors.Is(err, ErrItemNotRecurring) {
+		return nil, err
+	}
+	schema, err := s.schema(ctx, tx, in.DatabaseID)
+	if err != nil {
+		return nil, err
+	}
+	prop, ok := schemaProperty(schema, in.Property)
+	if !ok || prop.Type != domain.PropertyTypeDate {
+		return nil, storage.InvalidField("property", "must name a date property")
+	}
+	var completeID string
+	if in.CompleteProperty != "" {
+		complete, ok := schemaProperty(schema, in.CompleteProperty)
+		if !ok || complete.Type != domain.PropertyTypeCheckbox {
+			return nil, storage.InvalidField("complete_property", "must name a checkbox property")
+		}
+		completeID = complete.ID
+	}
+	if in.Generate == "" {
+		in.Generate = RecurrenceScheduled
+		if completeID != "" {
+			in.Generate = RecurrenceOnComplete
+		}
+	}
+	switch in.Generate {
+	case RecurrenceScheduled:
+	case RecurrenceOnComplete:
+		if completeID == "" {
+			return nil, storage.InvalidField("complete_property", "is required to generate on completion")
+		}
+	default:
+		return nil, storage.InvalidField("generate", "must be on_complete or scheduled")
+	}
+	loc := propertyLocation(prop.Config)
+	rule, err := storage.ParseRecurrenceRule(in.Rule, loc)
+	if err != nil {
+		return nil, err
+	}
+	exceptions, err := recurrenceExceptions(in.Exceptions)
+	if err != nil {
+		return nil, err
+	}
+	item, err := loadDatabaseItem(ctx, tx, in.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	start, _, ok := itemDate(item, in.Property, loc)
+	if !ok {
+		return nil, storage.InvalidField("property", "the item has no date in %s", in.Property)
+	}
+	now := time.Now().UTC()
+	series := &domain.RecurrenceSeries{
+		ID: uuid.NewString(), DatabaseID: in.DatabaseID, PropertyID: prop.ID, Rule: rule.String(), Exceptions: exceptions,
+		Generate: in.Generate, CompletePropertyID: completeID, TimeZone: loc.String(), StartsAt: start.UTC(),
+		CreatedAt: now, UpdatedAt: now,
+	}
+	if err := insertSeries(ctx, tx, series, series.StartsAt); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO recurrence_occurrences(series_id, item_id, occurrence_index, occurs_at) VALUES(?, ?, 0, ?)`,
+		series.ID, in.ItemID, series.StartsAt); err != nil {
+		return nil, fmt.Errorf("insert occurrence: %w", err)
+	}
+	if series, err = loadSeries(ctx, tx, series.ID); err != nil {
+		return nil, err
+	}
+	if err := recordAudit(ctx, tx, storage.AuditActionCreate, storage.AuditEntitySeries, series.ID, nil, series, now); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit series: %w", err)
+	}
+	return series, nil
+}
+
+// UpdateItemRecurrence edits the series of an item and returns the series
+// the item belongs to afterwards, which is a new one when only the item and
+// the ones after it were edited. A split keeps the remaining COUNT of the
+// old rule unless a new rule is given. Setting a rule restarts an ended
+// series.
+func (s *Store) UpdateItemRecurrence(ctx context.Context, in storage.UpdateItemRecurrenceInput) (*domain.RecurrenceSeries, error) {
+	if in.Scope == "" {
+		in.Scope = RecurrenceScopeAll
+	}
+	if in.Scope != RecurrenceScopeAll && in.Scope != RecurrenceScopeFollowing {
+		return nil, storage.InvalidField("scope", "must be all or following")
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	series, occurrence, err := itemSeries(ctx, tx, in.DatabaseID, in.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := s.schema(ctx, tx, in.DatabaseID)
+	if err != nil {
+		return nil, err
+	}
+	for slug := range in.Values {
+		if id := schema.slugs[slug]; id != "" && (id == series.PropertyID || id == series.CompletePropertyID) {
+			return nil, storage.InvalidField("values."+slug, "is managed by the recurrence")
+		}
+	}
+	loc := propertyLocation(map[string]any{"time_zone": series.TimeZone})
+	rule, err := storage.ParseRecurrenceRule(series.Rule, loc)
+	if err != nil {
+		return nil, err
+	}
+	if in.Rule != nil {
+		if rule, err = storage.ParseRecurrenceRule(*in.Rule, loc); err != nil {
+			return nil, err
+		}
+	}
+	exceptions := series.Exceptions
+	if in.Exceptions != nil {
+		if exceptions, err = recurrenceExceptions(in.Exceptions); err != nil {
+			return nil, err
+		}
+	}
+	now := time.Now().UTC()
+	before := *series
+	targets := series.Occurrences
+	if in.Scope == RecurrenceScopeFollowing && occurrence.ItemID != series.Occurrences[0].ItemID {
+		targets = slices.DeleteFunc(slices.Clone(series.Occurrences), func(o domain.RecurrenceOccurrence) bool {
+			return o.OccursAt.Before(occurrence.OccursAt)
+		})
+		if in.Rule == nil && rule.Count > 0 {
+			rule.Count = max(rule.Count-occurrence.Index, 1)
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE recurrence_series SET ended = 1, generate_at = NULL, updated_at = ? WHERE id = ?`, now, series.ID); err != nil {
+			return nil, fmt.Errorf("end series: %w", err)
+		}
+		ended, err := loadSeries(ctx, tx, series.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := recordAudit(ctx, tx, storage.AuditActionUpdate, storage.AuditEntitySeries, series.ID, before, ended, now); err != nil {
+			return nil, err
+		}
+		split := &domain.RecurrenceSeries{
+			ID: uuid.NewString(), DatabaseID: series.DatabaseID, PropertyID: series.PropertyID, Rule: rule.String(),
+			Exceptions: exceptions, Generate: series.Generate, CompletePropertyID: series.CompletePropertyID,
+			TimeZone: series.TimeZone, StartsAt: occurrence.OccursAt, PreviousSeriesID: series.ID, CreatedAt: now, UpdatedAt: now,
+		}
+		if err := insertSeries(ctx, tx, split, targets[len(targets)-1].OccursAt); err != nil {
+			return nil, err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE recurrence_occurrences SET series_id = ?, occurrence_index = occurrence_index - ? WHERE series_id = ? AND occurs_at >= ?`,
+			split.ID, occurrence.Index, series.ID, occurrence.OccursAt); err != nil {
+			return nil, fmt.Errorf("move occurrences: %w", err)
+		}
+		if series, err = loadSeries(ctx, tx, split.ID); err != nil {
+			return nil, err
+		}
+		if err := recordAudit(ctx, tx, storage.AuditActionCreate, storage.AuditEntitySeries, series.ID, nil, series, now); err != nil {
+			return nil, err
+		}
+	} else {
+		exceptionJSON, err := json.Marshal(exceptions)
+		if err != nil {
+			return nil, fmt.Errorf("marshal exceptions: %w", err)
+		}
+		sets := `rule = ?, exceptions = ?, updated_at = ?`
+		args := []any{rule.String(), string(exceptionJSON), now}
+		if in.Rule != nil {
+			sets += `, ended = 0, generate_at = ?`
+			args = append(args, generateAt(series.Generate, series.Occurrences[len(series.Occurrences)-1].OccursAt))
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE recurrence_series SET `+sets+` WHERE id = ?`, append(args, series.ID)...); err != nil {
+			return nil, fmt.Errorf("update series: %w", err)
+		}
+		if series, err = loadSeries(ctx, tx, series.ID); err != nil {
+			return nil, err
+		}
+		if err := recordAudit(ctx, tx, storage.AuditActionUpdate, storage.AuditEntitySeries, series.ID, before, series, now); err != nil {
+			return nil, err
+		}
+	}
+	if len(in.Values) > 0 {
+		for _, target := range targets {
+			if _, err := s.reviseDatabaseItem(ctx, tx, storage.UpdateDatabaseItemInput{
+				DatabaseID: in.DatabaseID, ItemID: target.ItemID, Values: in.Values, Author: in.Author,
+			}, now); err != nil {
+				return nil, err
+			}
+		}
+		if series, err = loadSeries(ctx, tx, series.ID); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit series: %w", err)
+	}
+	return series, nil
+}
+
+// EndItemRecurrence stops the series of an item from creating occurrences.
+// Its items are kept.
+func (s *Store) EndItemRecurrence(ctx context.Context, databaseID, itemID string) error {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	series, _, err := itemSeries(ctx, tx, databaseID, itemID)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	if _, err := tx.ExecContext(ctx, `UPDATE recurrence_series SET ended = 1, generate_at = NULL, updated_at = ? WHERE id = ?`, now, series.ID); err != nil {
+		return fmt.Errorf("end series: %w", err)
+	}
+	ended, err := loadSeries(ctx, tx, series.ID)
+	if err != nil {
+		return err
+	}
+	if err := recordAudit(ctx, tx, storage.AuditActionUpdate, storage.AuditEntitySeries, series.ID, series, ended, now); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit series: %w", err)
+	}
+	return nil
+}
+
+// AdvanceDueSeries creates the next occurrence of every scheduled series
+// whose latest occurrence has arrived, catching up on the occurrences missed
+// while the server was down. It returns the number of items created.
+func (s *Store) AdvanceDueSeries(ctx context.Context, now time.Time) (int, error) {
+	ids, err := dueSeries(ctx, s.reader, now.UTC())
+	if err != nil {
+		return 0, err
+	}
+	created := 0
+	for _, id := range ids {
+		n, err := s.advanceSeries(ctx, id, now.UTC())
+		created += n
+		if err != nil {
+			return created, err
+		}
+	}
+	return created, nil
+}
+
+func dueSeries(ctx context.Context, q queryer, now time.Time) ([]string, error) {
+	rows, err := q.QueryContext(ctx, `SELECT id FROM recurrence_series WHERE ended = 0 AND generate = ? AND generate_at <= ? ORDER BY generate_at, id`,
+		RecurrenceScheduled, now)
+	if err != nil {
+		return nil, fmt.Errorf("query due series: %w", err)
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan due series: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (s *Store) advanceSeries(ctx context.Context, id string, now time.Time) (int, error) {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	series, err := loadSeries(ctx, tx, id)
+	if err != nil {
+		return 0, err
+	}
+	created := 0
+	for created < maxCatchUpOccurrences && !series.Ended && series.Generate == RecurrenceScheduled {
+		if len(series.Occurrences) > 0 && series.Occurrences[len(series.Occurrences)-1].OccursAt.After(now) {
+			break
+		}
+		item, err := s.createNextOccurrence(ctx, tx, series, now)
+		if err != nil {
+			return 0, err
+		}
+		if item == nil {
+			break
+		}
+		created++
+		if series, err = loadSeries(ctx, tx, id); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit series: %w", err)
+	}
+	return created, nil
+}
+
+// advanceCompletedSeries creates the next occurrence when an update ticks
+// the complete checkbox of the latest item of an on_complete series.
+func (s *Store) advanceCompletedSeries(ctx context.Context, q queryer, before, after *domain.DatabaseItem, now time.Time) error {
+	series, _, err := itemSeries(ctx, q, after.DatabaseID, after.ID)
+	if errors.Is(err, ErrItemNotRecurring) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if series.Ended || series.Generate != RecurrenceOnComplete || series.Occurrences[len(series.Occurrences)-1].ItemID != after.ID {
+		return nil
+	}
+	if !itemChecked(after, series.CompletePropertyID) || itemChecked(before, series.CompletePropertyID) {
+		return nil
+	}
+	_, err = s.createNextOccurrence(ctx, q, series, now)
+	return err
+}
+
+// createNextOccurrence copies the latest item of a series to the next date
+// of its rule. It ends the series and returns nil when the rule has no
+// further dates or the series has no items left to copy.
+func (s *Store) createNextOccurrence(ctx context.Context, q queryer, series *domain.RecurrenceSeries, now time.Time) (*domain.DatabaseItem, error) {
+	var index int
+	var at time.Time
+	ok := len(series.Occurrences) > 0
+	if ok {
+		latest := series.Occurrences[len(series.Occurrences)-1]
+		index, at, ok = nextOccurrence(series, latest.OccursAt)
+	}
+	if !ok {
+		if _, err := q.ExecContext(ctx, `UPDATE recurrence_series SET ended = 1, generate_at = NULL, updated_at = ? WHERE id = ?`, now, series.ID); err != nil {
+			return nil, fmt.Errorf("end series: %w", err)
+		}
+		return nil, nil
+	}
+	source, err := loadDatabaseItem(ctx, q, series.Occurrences[len(series.Occurrences)-1].ItemID)
+	if err != nil {
+		return nil, err
+	}
+	loc := propertyLocation(map[string]any{"time_zone": series.TimeZone})
+	values := make(map[string]any, len(source.PropertyMap))
+	for slug, value := range source.PropertyMap {
+		switch {
+		case value.IsComputed:
+		case value.PropertyID == series.PropertyID:
+			values[slug] = occurrenceValue(value.RawValue, at, loc)
+		case value.PropertyID == series.CompletePropertyID:
+			values[slug] = false
+		default:
+			values[slug] = value.RawValue
+		}
+	}
+	item, err := s.createDatabaseItem(ctx, q, storage.CreateDatabaseItemInput{
+		DatabaseID: series.DatabaseID,
+		Page: storage.CreatePageInput{
+			Title:        source.Page.Title,
+			Summary:      source.Page.Summary,
+			Content:      source.Page.Content,
+			ParentPageID: source.Page.ParentPageID,
+			Tags:         source.Page.Tags,
+		},
+		Values: values,
+	}, now)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := q.ExecContext(ctx, `INSERT INTO recurrence_occurrences(series_id, item_id, occurrence_index, occurs_at) VALUES(?, ?, ?, ?)`,
+		series.ID, item.ID, index, at.UTC()); err != nil {
+		return nil, fmt.Errorf("insert occurrence: %w", err)
+	}
+	if _, err := q.ExecContext(ctx, `UPDATE recurrence_series SET generate_at = ?, updated_at = ? WHERE id = ?`,
+		generateAt(series.Generate, at.UTC()), now, series.ID); err != nil {
+		return nil, fmt.Errorf("update series: %w", err)
+	}
+	return item, nil
+}
+
+// nextOccurrence returns the first date of the series rule after after that
+// is not an exception.
+func nextOccurrence(series *domain.RecurrenceSeries, after time.Time) (int, time.Time, bool) {
+	loc := propertyLocation(map[string]any{"time_zone": series.TimeZone})
+	rule, err := storage.ParseRecurrenceRule(series.Rule, loc)
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	var index int
+	var next time.Time
+	found := false
+	rule.Each(series.StartsAt.In(loc), func(i int, at time.Time) bool {
+		if !at.After(after) || slices.Contains(series.Exceptions, at.Format(dateOnlyLayout)) {
+			return true
+		}
+		index, next, found = i, at, true
+		return false
+	})
+	return index, next, found
+}
+
+// occurrenceValue writes at in the layout of the date value it replaces. A
+// date range keeps its length.
+func occurrenceValue(raw any, at time.Time, loc *time.Location) any {
+	switch val := raw.(type) {
+	case map[string]any:
+		moved := maps.Clone(val)
+		start, _ := val["start"].(string)
+		from, layout, ok := parseDateIn(start, loc)
+		if !ok {
+			return raw
+		}
+		moved["start"] = formatDateIn(at, layout, loc)
+		if end, ok := val["end"].(string); ok {
+			if to, endLayout, ok := parseDateIn(end, loc); ok {
+				moved["end"] = formatDateIn(at.Add(to.Sub(from)), endLayout, loc)
+			}
+		}
+		return moved
+	case string:
+		if _, layout, ok := parseDateIn(val, loc); ok {
+			return formatDateIn(at, layout, loc)
+		}
+	}
+	return raw
+}
+
+func formatDateIn(t time.Time, layout string, loc *time.Location) string {
+	if layout == time.RFC3339Nano {
+		layout = time.RFC3339
+	}
+	return t.In(loc).Format(layout)
+}
+
+// itemDate reads an item's date value. Date-only values start at midnight
+// in loc.
+func itemDate(item *domain.DatabaseItem, slug string, loc *time.Location) (time.Time, string, bool) {
+	raw := item.PropertyMap[slug].RawValue
+	if m, ok := raw.(map[string]any); ok {
+		raw = m["start"]
+	}
+	text, _ := raw.(string)
+	return parseDateIn(text, loc)
+}
+
+func itemChecked(item *domain.DatabaseItem, propertyID string) bool {
+	if item == nil {
+		return false
+	}
+	for _, value := range item.PropertyMap {
+		if value.PropertyID == propertyID {
+			checked, _ := value.RawValue.(bool)
+			return checked
+		}
+	}
+	return false
+}
+
+// propertyLocation returns the time zone a date property config names, or
+// UTC.
+func propertyLocation(config map[string]any) *time.Location {
+	if name, _ := config["time_zone"].(string); name != "" {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
+
+func schemaProperty(schema *databaseSchema, slug string) (*domain.DatabaseProperty, bool) {
+	id, ok := schema.slugs[slug]
+	if !ok {
+		return nil, false
+	}
+	for i := range schema.properties {
+		if schema.properties[i].ID == id {
+			return &schema.properties[i], true
+		}
+	}
+	return nil, false
+}
+
+// recurrenceExceptions validates and sorts exception dates.
+func recurrenceExceptions(dates []string) ([]string, error) {
+	exceptions := []string{}
+	for i, date := range dates {
+		if _, err := time.Parse(dateOnlyLayout, date); err != nil {
+			return nil, storage.InvalidField(fmt.Sprintf("exceptions[%d]", i), "must be a date like 2026-12-24")
+		}
+		if !slices.Contains(exceptions, date) {
+			exceptions = append(exceptions, date)
+		}
+	}
+	slices.Sort(exceptions)
+	return exceptions, nil
+}
+
+// generateAt is when a scheduled series creates its next occurrence: when
+// its latest one arrives.
+func generateAt(generate string, latest time.Time) any {
+	if generate != RecurrenceScheduled {
+		return nil
+	}
+	return latest.UTC()
+}
+
+func insertSeries(ctx context.Context, q queryer, series *domain.RecurrenceSeries, latest time.Time) error {
+	exceptions, err := json.Marshal(series.Exceptions)
+	if err != nil {
+		return fmt.Errorf("marshal exceptions: %w", err)
+	}
+	if _, err := q.ExecContext(ctx, `INSERT INTO recurrence_series(id, database_id, property_id, rule, exceptions, generate, complete_property_id, time_zone, starts_at, previous_series_id, ended, generate_at, created_at, updated_at)
+VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0, ?, ?, ?)`,
+		series.ID, series.DatabaseID, series.PropertyID, series.Rule, string(exceptions), series.Generate, nullString(series.CompletePropertyID),
+		series.TimeZone, series.StartsAt, nullString(series.PreviousSeriesID), generateAt(series.Generate, latest), series.CreatedAt, series.UpdatedAt); err != nil {
+		return fmt.Errorf("insert series: %w", err)
+	}
+	return nil
+}
+
+// itemSeries returns the series of an item and the item's occurrence in it.
+func itemSeries(ctx context.Context, q queryer, databaseID, itemID string) (*domain.RecurrenceSeries, *domain.RecurrenceOccurrence, error) {
+	if _, err := itemPageID(ctx, q, databaseID, itemID); err != nil {
+		return nil, nil, err
+	}
+	var seriesID string
+	err := q.QueryRowContext(ctx, `SELECT series_id FROM recurrence_occurrences WHERE item_id = ?`, itemID).Scan(&seriesID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, ErrItemNotRecurring
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("load occurrence: %w", err)
+	}
+	series, err := loadSeries(ctx, q, seriesID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range series.Occurrences {
+		if series.Occurrences[i].ItemID == itemID {
+			return series, &series.Occurrences[i], nil
+		}
+	}
+	return nil, nil, ErrItemNotRecurring
+}
+
+func loadSeries(ctx context.Context, q queryer, id string) (*domain.RecurrenceSeries, error) {
+	var series domain.RecurrenceSeries
+	var exceptions string
+	var completeID, previousID sql.NullString
+	err := q.QueryRowContext(ctx, `SELECT id, database_id, property_id, rule, exceptions, generate, complete_property_id, time_zone, starts_at, previous_series_id, ended, created_at, updated_at
+FROM recurrence_series WHERE id = ?`, id).Scan(&series.ID, &series.DatabaseID, &series.PropertyID, &series.Rule, &exceptions, &series.Generate,
+		&completeID, &series.TimeZone, &series.StartsAt, &previousID, &series.Ended, &series.CreatedAt, &series.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSeriesNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load series: %w", err)
+	}
+	series.CompletePropertyID, series.PreviousSeriesID = completeID.String, previousID.String
+	series.Exceptions = []string{}
+	_ = json.Unmarshal([]byte(exceptions), &series.Exceptions)
+	rows, err := q.QueryContext(ctx, `SELECT o.item_id, i.page_id, COALESCE(p.title, ''), o.occurrence_index, o.occurs_at
+FROM recurrence_occurrences o
+JOIN database_items i ON i.id = o.item_id
+LEFT JOIN pages p ON p.id = i.page_id
+WHERE o.series_id = ? ORDER BY o.occurs_at, o.occurrence_index`, id)
+	if err != nil {
+		return nil, fmt.Errorf("query occurrences: %w", err)
+	}
+	defer rows.Close()
+	series.Occurrences = []domain.RecurrenceOccurrence{}
+	for rows.Next() {
+		var o domain.RecurrenceOccurrence
+		if err := rows.Scan(&o.ItemID, &o.PageID, &o.Title, &o.Index, &o.OccursAt); err != nil {
+			return nil, fmt.Errorf("scan occurrence: %w", err)
+		}
+		series.Occurrences = append(series.Occurrences, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate occurrences: %w", err)
+	}
+	if !series.Ended && len(series.Occurrences) > 0 {
+		if _, next, ok := nextOccurrence(&series, series.Occurrences[len(series.Occurrences)-1].OccursAt); ok {
+			series.Next = &next
+		}
+	}
+	return &series, nil
+}
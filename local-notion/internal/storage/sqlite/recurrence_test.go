@@ -0,0 +1,177 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+func newChoresDatabase(t *testing.T, store *Store, dueConfig map[string]any) *domain.Database {
+	t.Helper()
+	db, err := store.CreateDatabase(context.Background(), storage.CreateDatabaseInput{
+		Slug: "chores", Title: "Chores",
+		Properties: []storage.DatabasePropertyInput{
+			{Slug: "due", Name: "Due", Type: domain.PropertyTypeDate, Config: dueConfig},
+			{Slug: "done", Name: "Done", Type: domain.PropertyTypeCheckbox},
+			{Slug: "owner", Name: "Owner", Type: domain.PropertyTypePerson},
+		},
+	})
+	require.NoError(t, err)
+	return db
+}
+
+func TestRecurringItemsRegenerateOnCompletion(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db := newChoresDatabase(t, store, nil)
+	first, err := store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
+		DatabaseID: db.ID,
+		Page:       storage.CreatePageInput{Title: "Water plants", Content: "Both balconies"},
+		Values:     map[string]any{"due": "2026-03-02", "done": false, "owner": "bo"},
+	})
+	require.NoError(t, err)
+
+	_, err = store.SetItemRecurrence(ctx, storage.SetItemRecurrenceInput{DatabaseID: db.ID, ItemID: first.ID, Property: "owner", Rule: "FREQ=WEEKLY"})
+	require.ErrorIs(t, err, storage.ErrValidation)
+	series, err := store.SetItemRecurrence(ctx, storage.SetItemRecurrenceInput{
+		DatabaseID: db.ID, ItemID: first.ID, Property: "due", CompleteProperty: "done",
+		Rule: "FREQ=WEEKLY;COUNT=3", Exceptions: []string{"2026-03-09"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, RecurrenceOnComplete, series.Generate)
+	require.Equal(t, time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC), series.Next.UTC())
+	_, err = store.SetItemRecurrence(ctx, storage.SetItemRecurrenceInput{DatabaseID: db.ID, ItemID: first.ID, Property: "due", Rule: "FREQ=DAILY"})
+	require.ErrorIs(t, err, ErrItemAlreadyRecurring)
+
+	complete := func(itemID string) *domain.RecurrenceSeries {
+		t.Helper()
+		_, err := store.UpdateDatabaseItem(ctx, storage.UpdateDatabaseItemInput{DatabaseID: db.ID, ItemID: itemID, Values: map[string]any{"done": true}})
+		require.NoError(t, err)
+		series, err := store.GetItemRecurrence(ctx, db.ID, itemID)
+		require.NoError(t, err)
+		return series
+	}
+
+	series = complete(first.ID)
+	require.Len(t, series.Occurrences, 2)
+	next := series.Occurrences[1]
+	require.Equal(t, 2, next.Index)
+	require.Equal(t, "Water plants", next.Title)
+	item, err := store.GetDatabaseItem(ctx, db.ID, next.ItemID)
+	require.NoError(t, err)
+	require.Equal(t, "2026-03-16", item.PropertyMap["due"].RawValue)
+	require.Equal(t, false, item.PropertyMap["done"].RawValue)
+	require.Equal(t, "bo", item.PropertyMap["owner"].RawValue)
+	require.Equal(t, "Both balconies", item.Page.Content)
+
+	// Completing an earlier occurrence again does not create another one.
+	_, err = store.UpdateDatabaseItem(ctx, storage.UpdateDatabaseItemInput{DatabaseID: db.ID, ItemID: first.ID, Values: map[string]any{"done": false}})
+	require.NoError(t, err)
+	require.Len(t, complete(first.ID).Occurrences, 2)
+
+	// COUNT=3 counts the skipped 2026-03-09, so the series ends here.
+	series = complete(next.ItemID)
+	require.Len(t, series.Occurrences, 2)
+	require.True(t, series.Ended)
+	require.Nil(t, series.Next)
+
+	_, err = store.GetItemRecurrence(ctx, db.ID, "missing")
+	require.ErrorIs(t, err, storage.ErrItemNotFound)
+}
+
+func TestScheduledSeriesCatchUpAcrossDST(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db := newChoresDatabase(t, store, map[string]any{"time_zone": "America/New_York"})
+	first, err := store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
+		DatabaseID: db.ID,
+		Page:       storage.CreatePageInput{Title: "Stand-up"},
+		Values:     map[string]any{"due": map[string]any{"start": "2026-03-07T09:00", "end": "2026-03-07T09:15"}},
+	})
+	require.NoError(t, err)
+	series, err := store.SetItemRecurrence(ctx, storage.SetItemRecurrenceInput{DatabaseID: db.ID, ItemID: first.ID, Property: "due", Rule: "FREQ=DAILY"})
+	require.NoError(t, err)
+	require.Equal(t, RecurrenceScheduled, series.Generate)
+
+	// Clocks go forward on 2026-03-08 in New York; meetings stay at 09:00.
+	created, err := store.AdvanceDueSeries(ctx, time.Date(2026, 3, 9, 14, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, 3, created)
+	series, err = store.GetRecurrenceSeries(ctx, db.ID, series.ID)
+	require.NoError(t, err)
+	require.Len(t, series.Occurrences, 4)
+	require.Equal(t, time.Date(2026, 3, 10, 13, 0, 0, 0, time.UTC), series.Occurrences[3].OccursAt.UTC())
+	latest, err := store.GetDatabaseItem(ctx, db.ID, series.Occurrences[3].ItemID)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"start": "2026-03-10T09:00", "end": "2026-03-10T09:15"}, latest.PropertyMap["due"].RawValue)
+
+	created, err = store.AdvanceDueSeries(ctx, time.Date(2026, 3, 9, 14, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Zero(t, created)
+}
+
+func TestEditingThisAndFollowingOccurrencesSplitsTheSeries(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db := newChoresDatabase(t, store, nil)
+	first, err := store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
+		DatabaseID: db.ID,
+		Page:       storage.CreatePageInput{Title: "Bins"},
+		Values:     map[string]any{"due": "2026-03-02", "owner": "bo"},
+	})
+	require.NoError(t, err)
+	series, err := store.SetItemRecurrence(ctx, storage.SetItemRecurrenceInput{DatabaseID: db.ID, ItemID: first.ID, Property: "due", Rule: "FREQ=WEEKLY;COUNT=10"})
+	require.NoError(t, err)
+	_, err = store.AdvanceDueSeries(ctx, time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	series, err = store.GetRecurrenceSeries(ctx, db.ID, series.ID)
+	require.NoError(t, err)
+	require.Len(t, series.Occurrences, 3)
+	second := series.Occurrences[1]
+
+	_, err = store.UpdateItemRecurrence(ctx, storage.UpdateItemRecurrenceInput{DatabaseID: db.ID, ItemID: second.ItemID, Values: map[string]any{"due": "2026-03-10"}})
+	require.ErrorIs(t, err, storage.ErrValidation)
+
+	split, err := store.UpdateItemRecurrence(ctx, storage.UpdateItemRecurrenceInput{
+		DatabaseID: db.ID, ItemID: second.ItemID, Scope: RecurrenceScopeFollowing, Values: map[string]any{"owner": "carl"},
+	})
+	require.NoError(t, err)
+	require.NotEqual(t, series.ID, split.ID)
+	require.Equal(t, series.ID, split.PreviousSeriesID)
+	require.Equal(t, "FREQ=WEEKLY;COUNT=9", split.Rule)
+	require.Equal(t, []int{0, 1}, []int{split.Occurrences[0].Index, split.Occurrences[1].Index})
+	old, err := store.GetRecurrenceSeries(ctx, db.ID, series.ID)
+	require.NoError(t, err)
+	require.True(t, old.Ended)
+	require.Len(t, old.Occurrences, 1)
+
+	owners := map[string]any{}
+	for _, o := range append(old.Occurrences, split.Occurrences...) {
+		item, err := store.GetDatabaseItem(ctx, db.ID, o.ItemID)
+		require.NoError(t, err)
+		owners[item.PropertyMap["due"].RawValue.(string)] = item.PropertyMap["owner"].RawValue
+	}
+	require.Equal(t, map[string]any{"2026-03-02": "bo", "2026-03-09": "carl", "2026-03-16": "carl"}, owners)
+
+	// A new rule for every item applies to the occurrences not created yet.
+	rule := "FREQ=WEEKLY;BYDAY=TU"
+	updated, err := store.UpdateItemRecurrence(ctx, storage.UpdateItemRecurrenceInput{
+		DatabaseID: db.ID, ItemID: second.ItemID, Rule: &rule, Exceptions: []string{"2026-03-17"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, split.ID, updated.ID)
+	require.Equal(t, time.Date(2026, 3, 24, 0, 0, 0, 0, time.UTC), updated.Next.UTC())
+
+	require.NoError(t, store.EndItemRecurrence(ctx, db.ID, second.ItemID))
+	ended, err := store.GetItemRecurrence(ctx, db.ID, second.ItemID)
+	require.NoError(t, err)
+	require.True(t, ended.Ended)
+	require.NoError(t, store.DeleteDatabaseItem(ctx, db.ID, second.ItemID))
+	_, err = store.GetItemRecurrence(ctx, db.ID, first.ID)
+	require.NoError(t, err)
+}
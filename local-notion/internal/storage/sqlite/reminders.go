@@ -0,0 +1,361 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// Reminder units of a date property's reminder config.
+const (
+	ReminderUnitMinutes = "minutes"
+	ReminderUnitHours   = "hours"
+	ReminderUnitDays    = "days"
+)
+
+// Reminder list page sizes.
+const (
+	DefaultReminderLimit = 50
+	MaxReminderLimit     = 500
+)
+
+// defaultReminderTime is the time of day date-only values are due at.
+const defaultReminderTime = "09:00"
+
+// A date property opts into reminders through its config:
+//
+//	{"time_zone": "Europe/Berlin", "reminder": {"before": 1, "unit": "days", "time": "08:30"}}
+//
+// time_zone (default UTC) places values without an offset and date-only
+// values, which are due at reminder.time (default 09:00). before 0 reminds
+// at the due time. Day offsets move the wall clock date in the property's
+// zone, so a reminder a day before 09:00 stays at 09:00 across a DST change;
+// minute and hour offsets are exact durations.
+//
+// Reminders are stored per item and date property whenever an item is
+// written, and fired by the scheduler package.
+
+// reminderSettings is the parsed reminder config of a date property.
+type reminderSettings struct {
+	location *time.Location
+	before   int
+	unit     string
+	hour     int
+	minute   int
+}
+
+// parseReminderConfig validates the time_zone and reminder keys of a date
+// property config. It returns nil settings when the property has no
+// reminder; field names the config in validation errors.
+func parseReminderConfig(field string, config map[string]any) (*reminderSettings, error) {
+	settings := &reminderSettings{location: time.UTC, unit: ReminderUnitMinutes}
+	if raw, ok := config["time_zone"]; ok && raw != nil {
+		name, _ := raw.(string)
+		loc, err := time.LoadLocation(name)
+		if name == "" || err != nil {
+			return nil, storage.InvalidField(field+".time_zone", "must be an IANA time zone name")
+		}
+		settings.location = loc
+	}
+	raw, ok := config["reminder"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	reminder, ok := raw.(map[string]any)
+	if !ok {
+		return nil, storage.InvalidField(field+".reminder", "must be an object")
+	}
+	switch before := reminder["before"].(type) {
+	case nil:
+	case int:
+		settings.before = before
+	case float64:
+		if before != math.Trunc(before) || before > math.MaxInt32 {
+			return nil, storage.InvalidField(field+".reminder.before", "must be a whole number")
+		}
+		settings.before = int(before)
+	default:
+		return nil, storage.InvalidField(field+".reminder.before", "must be a whole number")
+	}
+	if settings.before < 0 {
+		return nil, storage.InvalidField(field+".reminder.before", "cannot be negative")
+	}
+	if unit, ok := reminder["unit"]; ok && unit != nil {
+		switch unit {
+		case ReminderUnitMinutes, ReminderUnitHours, ReminderUnitDays:
+			settings.unit = unit.(string)
+		default:
+			return nil, storage.InvalidField(field+".reminder.unit", "must be minutes, hours or days")
+		}
+	}
+	clock := defaultReminderTime
+	if at, ok := reminder["time"]; ok && at != nil {
+		clock, _ = at.(string)
+	}
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return nil, storage.InvalidField(field+".reminder.time", "must be a time of day like 09:00")
+	}
+	settings.hour, settings.minute = t.Hour(), t.Minute()
+	return settings, nil
+}
+
+// dueAt resolves a date value in the property's zone. Date-only values are
+// due at the configured time of day.
+func (r *reminderSettings) dueAt(value any) (time.Time, bool) {
+	switch val := value.(type) {
+	case map[string]any:
+		return r.dueAt(val["start"])
+	case string:
+		t, layout, ok := parseDateIn(val, r.location)
+		if ok && layout == dateOnlyLayout {
+			t = time.Date(t.Year(), t.Month(), t.Day(), r.hour, r.minute, 0, 0, r.location)
+		}
+		return t, ok
+	}
+	return time.Time{}, false
+}
+
+// dateOnlyLayout is the layout of date values without a time of day.
+const dateOnlyLayout = "2006-01-02"
+
+// parseDateIn parses a date value, placing values without an offset in loc.
+// It also returns the layout the value was written in.
+func parseDateIn(text string, loc *time.Location) (time.Time, string, bool) {
+	text = strings.TrimSpace(text)
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04", dateOnlyLayout} {
+		if t, err := time.ParseInLocation(layout, text, loc); err == nil {
+			return t, layout, true
+		}
+	}
+	return time.Time{}, "", false
+}
+
+// fireAt moves due back by the reminder offset.
+func (r *reminderSettings) fireAt(due time.Time) time.Time {
+	switch r.unit {
+	case ReminderUnitDays:
+		return due.In(r.location).AddDate(0, 0, -r.before)
+	case ReminderUnitHours:
+		return due.Add(-time.Duration(r.before) * time.Hour)
+	default:
+		return due.Add(-time.Duration(r.before) * time.Minute)
+	}
+}
+
+// validatePropertyConfig checks the config keys the store interprets.
+func validatePropertyConfig(field string, prop storage.DatabasePropertyInput) error {
+	if prop.Type != domain.PropertyTypeDate {
+		return nil
+	}
+	_, err := parseReminderConfig(field, prop.Config)
+	return err
+}
+
+// scheduleItemReminders brings the reminders of an item in line with its
+// date values. A reminder whose fire time did not change is left alone, so
+// a fired reminder stays fired and an overdue one still fires; a new fire
+// time in the past is not scheduled.
+func scheduleItemReminders(ctx context.Context, q queryer, itemID string, now time.Time) error {
+	rows, err := q.QueryContext(ctx, `SELECT p.id, COALESCE(p.config, ''), i.database_id, i.is_archived, v.value, r.fire_at
+FROM database_items i
+JOIN database_properties p ON p.database_id = i.database_id AND p.type = ?
+LEFT JOIN database_values v ON v.database_item_id = i.id AND v.property_id = p.id
+LEFT JOIN reminders r ON r.item_id = i.id AND r.property_id = p.id
+WHERE i.id = ?`, string(domain.PropertyTypeDate), itemID)
+	if err != nil {
+		return fmt.Errorf("query item dates: %w", err)
+	}
+	type dated struct {
+		propertyID, config, databaseID string
+		archived                       bool
+		value                          sql.NullString
+		scheduled                      sql.NullTime
+	}
+	var values []dated
+	for rows.Next() {
+		var v dated
+		if err := rows.Scan(&v.propertyID, &v.config, &v.databaseID, &v.archived, &v.value, &v.scheduled); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan item date: %w", err)
+		}
+		values = append(values, v)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("iterate item dates: %w", err)
+	}
+	rows.Close()
+	for _, v := range values {
+		var config map[string]any
+		_ = json.Unmarshal([]byte(v.config), &config)
+		settings, err := parseReminderConfig("config", config)
+		if err != nil {
+			settings = nil
+		}
+		var raw any
+		if v.value.Valid {
+			_ = json.Unmarshal([]byte(v.value.String), &raw)
+		}
+		var due time.Time
+		ok := settings != nil && !v.archived
+		if ok {
+			due, ok = settings.dueAt(raw)
+		}
+		if !ok {
+			if _, err := q.ExecContext(ctx, `DELETE FROM reminders WHERE item_id = ? AND property_id = ?`, itemID, v.propertyID); err != nil {
+				return fmt.Errorf("delete reminder: %w", err)
+			}
+			continue
+		}
+		fire := settings.fireAt(due).UTC()
+		if v.scheduled.Valid && v.scheduled.Time.Equal(fire) {
+			continue
+		}
+		if !fire.After(now) {
+			if _, err := q.ExecContext(ctx, `DELETE FROM reminders WHERE item_id = ? AND property_id = ?`, itemID, v.propertyID); err != nil {
+				return fmt.Errorf("delete reminder: %w", err)
+			}
+			continue
+		}
+		if _, err := q.ExecContext(ctx, `INSERT INTO reminders(id, database_id, item_id, property_id, due_at, fire_at, time_zone, fired_at, created_at, updated_at)
+VALUES(?, ?, ?, ?, ?, ?, ?, NULL, ?, ?)
+ON CONFLICT(item_id, property_id) DO UPDATE SET due_at = excluded.due_at, fire_at = excluded.fire_at, time_zone = excluded.time_zone, fired_at = NULL, delivered_at = NULL, delivery_attempts = 0, updated_at = excluded.updated_at`,
+			uuid.NewString(), v.databaseID, itemID, v.propertyID, due.UTC(), fire, settings.location.String(), now, now); err != nil {
+			return fmt.Errorf("upsert reminder: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListUpcomingReminders returns reminders that have not fired yet, soonest
+// first, optionally limited to one database and to those firing before
+// filter.Until.
+func (s *Store) ListUpcomingReminders(ctx context.Context, filter storage.ReminderFilter) ([]domain.Reminder, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultReminderLimit
+	}
+	limit = min(limit, MaxReminderLimit)
+	where := []string{"r.fired_at IS NULL"}
+	var args []any
+	if filter.DatabaseID != "" {
+		where = append(where, "r.database_id = ?")
+		args = append(args, filter.DatabaseID)
+	}
+	if filter.Until != nil {
+		where = append(where, "r.fire_at < ?")
+		args = append(args, filter.Until.UTC())
+	}
+	return loadReminders(ctx, s.reader, strings.Join(where, " AND ")+` ORDER BY r.fire_at, r.id LIMIT ?`, append(args, limit)...)
+}
+
+// DueReminders returns up to limit reminders that should have fired by now,
+// oldest first.
+func (s *Store) DueReminders(ctx context.Context, now time.Time, limit int) ([]domain.Reminder, error) {
+	return loadReminders(ctx, s.reader, `r.fired_at IS NULL AND r.fire_at <= ? ORDER BY r.fire_at, r.id LIMIT ?`, now.UTC(), limit)
+}
+
+// UndeliveredReminders returns up to limit fired reminders whose webhook
+// event has not been delivered yet, in the order they fired.
+func (s *Store) UndeliveredReminders(ctx context.Context, limit int) ([]domain.Reminder, error) {
+	return loadReminders(ctx, s.reader, `r.fired_at IS NOT NULL AND r.delivered_at IS NULL ORDER BY r.fired_at, r.id LIMIT ?`, limit)
+}
+
+// RecordReminderDelivery counts an attempt to deliver the webhook event of a
+// fired reminder and, when delivered, marks it delivered at. A reminder that
+// no longer exists is ignored.
+func (s *Store) RecordReminderDelivery(ctx context.Context, id string, at time.Time, delivered bool) error {
+	at = at.UTC()
+	var deliveredAt any
+	if delivered {
+		deliveredAt = at
+	}
+	if _, err := s.db.ExecContext(ctx, `UPDATE reminders SET delivery_attempts = delivery_attempts + 1, delivered_at = COALESCE(?, delivered_at), updated_at = ? WHERE id = ? AND fired_at IS NOT NULL`, deliveredAt, at, id); err != nil {
+		return fmt.Errorf("record reminder delivery: %w", err)
+	}
+	return nil
+}
+
+// FireReminder marks a reminder fired and notifies the people assigned to
+// its item. It returns nil without error when the reminder already fired or
+// no longer exists, so concurrent schedulers fire each reminder once.
+func (s *Store) FireReminder(ctx context.Context, id string, now time.Time) (*domain.Reminder, error) {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	now = now.UTC()
+	res, err := tx.ExecContext(ctx, `UPDATE reminders SET fired_at = ?, updated_at = ? WHERE id = ? AND fired_at IS NULL`, now, now, id)
+	if err != nil {
+		return nil, fmt.Errorf("fire reminder: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return nil, err
+	}
+	reminders, err := loadReminders(ctx, tx, `r.id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(reminders) == 0 {
+		return nil, nil
+	}
+	reminder := reminders[0]
+	item, err := loadDatabaseItem(ctx, tx, reminder.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := s.schema(ctx, tx, reminder.DatabaseID)
+	if err != nil {
+		return nil, err
+	}
+	n := domain.Notification{
+		Source: storage.NotificationSourceReminder, PageID: reminder.PageID, DatabaseID: reminder.DatabaseID, ItemID: reminder.ItemID,
+		Excerpt: reminder.Title, CreatedAt: now,
+	}
+	if err := notify(ctx, tx, n, itemPeople(schema, item)); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit reminder: %w", err)
+	}
+	return &reminder, nil
+}
+
+func loadReminders(ctx context.Context, q queryer, where string, args ...any) ([]domain.Reminder, error) {
+	rows, err := q.QueryContext(ctx, `SELECT r.id, r.database_id, r.item_id, i.page_id, r.property_id, COALESCE(p.title, ''), r.due_at, r.fire_at, r.time_zone, r.fired_at, r.delivered_at, r.delivery_attempts
+FROM reminders r
+JOIN database_items i ON i.id = r.item_id
+LEFT JOIN pages p ON p.id = i.page_id
+WHERE `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query reminders: %w", err)
+	}
+	defer rows.Close()
+	reminders := []domain.Reminder{}
+	for rows.Next() {
+		var r domain.Reminder
+		var firedAt, deliveredAt sql.NullTime
+		if err := rows.Scan(&r.ID, &r.DatabaseID, &r.ItemID, &r.PageID, &r.PropertyID, &r.Title, &r.DueAt, &r.FireAt, &r.TimeZone, &firedAt, &deliveredAt, &r.DeliveryAttempts); err != nil {
+			return nil, fmt.Errorf("scan reminder: %w", err)
+		}
+		if firedAt.Valid {
+			r.FiredAt = &firedAt.Time
+		}
+		if deliveredAt.Valid {
+			r.DeliveredAt = &deliveredAt.Time
+		}
+		reminders = append(reminders, r)
+	}
+	return reminders, rows.Err()
+}
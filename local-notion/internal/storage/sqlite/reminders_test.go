@@ -0,0 +1,144 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+func TestReminderFireTimesAcrossDST(t *testing.T) {
+	settings, err := parseReminderConfig("config", map[string]any{
+		"time_zone": "Europe/Berlin",
+		"reminder":  map[string]any{"before": float64(1), "unit": "days"},
+	})
+	require.NoError(t, err)
+
+	// Clocks go forward on 2026-03-29 in Berlin; the day-before reminder
+	// keeps its 09:00 wall clock time.
+	due, ok := settings.dueAt("2026-03-29")
+	require.True(t, ok)
+	require.Equal(t, time.Date(2026, 3, 29, 7, 0, 0, 0, time.UTC), due.UTC())
+	require.Equal(t, time.Date(2026, 3, 28, 8, 0, 0, 0, time.UTC), settings.fireAt(due).UTC())
+
+	settings.unit, settings.before = ReminderUnitHours, 24
+	require.Equal(t, time.Date(2026, 3, 28, 7, 0, 0, 0, time.UTC), settings.fireAt(due).UTC())
+
+	due, ok = settings.dueAt(map[string]any{"start": "2026-10-25T10:30"})
+	require.True(t, ok)
+	require.Equal(t, time.Date(2026, 10, 25, 9, 30, 0, 0, time.UTC), due.UTC())
+	due, ok = settings.dueAt("2026-10-25T10:30:00Z")
+	require.True(t, ok)
+	require.Equal(t, time.Date(2026, 10, 25, 10, 30, 0, 0, time.UTC), due)
+
+	none, err := parseReminderConfig("config", map[string]any{"time_zone": "UTC"})
+	require.NoError(t, err)
+	require.Nil(t, none)
+	for _, config := range []map[string]any{
+		{"time_zone": "Mars/Olympus"},
+		{"reminder": map[string]any{"before": -1}},
+		{"reminder": map[string]any{"before": 1.5}},
+		{"reminder": map[string]any{"unit": "weeks"}},
+		{"reminder": map[string]any{"time": "9am"}},
+	} {
+		_, err := parseReminderConfig("config", config)
+		require.ErrorIs(t, err, storage.ErrValidation, config)
+	}
+}
+
+func TestRemindersFollowItemDates(t *testing.T) {
+	store := newTestStore(t)
+	ctx := storage.WithAuditInfo(context.Background(), storage.AuditInfo{Actor: "ana"})
+	_, err := store.CreateDatabase(ctx, storage.CreateDatabaseInput{
+		Title: "Broken",
+		Properties: []storage.DatabasePropertyInput{{Slug: "due", Name: "Due", Type: domain.PropertyTypeDate,
+			Config: map[string]any{"reminder": map[string]any{"unit": "weeks"}}}},
+	})
+	require.ErrorIs(t, err, storage.ErrValidation)
+
+	db, err := store.CreateDatabase(ctx, storage.CreateDatabaseInput{
+		Slug: "tasks", Title: "Tasks",
+		Properties: []storage.DatabasePropertyInput{
+			{Slug: "owner", Name: "Owner", Type: domain.PropertyTypePerson},
+			{Slug: "due", Name: "Due", Type: domain.PropertyTypeDate, Config: map[string]any{
+				"time_zone": "America/New_York", "reminder": map[string]any{"before": 30, "unit": "minutes"},
+			}},
+			{Slug: "started", Name: "Started", Type: domain.PropertyTypeDate},
+		},
+	})
+	require.NoError(t, err)
+	now := time.Now().UTC()
+	due := now.Add(48 * time.Hour).Truncate(time.Second)
+	item, err := store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
+		DatabaseID: db.ID,
+		Page:       storage.CreatePageInput{Title: "Ship"},
+		Values:     map[string]any{"owner": []any{"bo"}, "due": due.Format(time.RFC3339), "started": now.Format(time.RFC3339)},
+	})
+	require.NoError(t, err)
+
+	upcoming, err := store.ListUpcomingReminders(ctx, storage.ReminderFilter{DatabaseID: db.ID})
+	require.NoError(t, err)
+	require.Len(t, upcoming, 1)
+	require.Equal(t, "Ship", upcoming[0].Title)
+	require.Equal(t, item.Page.ID, upcoming[0].PageID)
+	require.Equal(t, "America/New_York", upcoming[0].TimeZone)
+	require.True(t, due.Add(-30*time.Minute).Equal(upcoming[0].FireAt))
+	until := now.Add(time.Hour)
+	upcoming, err = store.ListUpcomingReminders(ctx, storage.ReminderFilter{Until: &until})
+	require.NoError(t, err)
+	require.Empty(t, upcoming)
+
+	dueNow, err := store.DueReminders(ctx, due, 10)
+	require.NoError(t, err)
+	require.Len(t, dueNow, 1)
+	fired, err := store.FireReminder(ctx, dueNow[0].ID, due)
+	require.NoError(t, err)
+	require.NotNil(t, fired.FiredAt)
+	again, err := store.FireReminder(ctx, dueNow[0].ID, due)
+	require.NoError(t, err)
+	require.Nil(t, again)
+	undelivered, err := store.UndeliveredReminders(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, undelivered, 1)
+	require.NoError(t, store.RecordReminderDelivery(ctx, fired.ID, due, false))
+	undelivered, err = store.UndeliveredReminders(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, undelivered, 1)
+	require.Equal(t, 1, undelivered[0].DeliveryAttempts)
+	require.NoError(t, store.RecordReminderDelivery(ctx, fired.ID, due.Add(time.Minute), true))
+	undelivered, err = store.UndeliveredReminders(ctx, 10)
+	require.NoError(t, err)
+	require.Empty(t, undelivered)
+	notifications := inbox(t, store, "bo")
+	require.Equal(t, []string{storage.NotificationSourceReminder, storage.NotificationSourceAssignment}, notificationSourcesOf(notifications))
+	require.Equal(t, item.ID, notifications[0].ItemID)
+
+	// A new date schedules the reminder again; clearing it drops it.
+	_, err = store.UpdateDatabaseItem(ctx, storage.UpdateDatabaseItemInput{
+		DatabaseID: db.ID, ItemID: item.ID, Values: map[string]any{"due": due.Add(24 * time.Hour).Format(time.RFC3339)},
+	})
+	require.NoError(t, err)
+	upcoming, err = store.ListUpcomingReminders(ctx, storage.ReminderFilter{})
+	require.NoError(t, err)
+	require.Len(t, upcoming, 1)
+	require.Nil(t, upcoming[0].FiredAt)
+	require.Nil(t, upcoming[0].DeliveredAt)
+	require.Zero(t, upcoming[0].DeliveryAttempts)
+
+	_, err = store.UpdateDatabaseItem(ctx, storage.UpdateDatabaseItemInput{DatabaseID: db.ID, ItemID: item.ID, Values: map[string]any{"due": nil}})
+	require.NoError(t, err)
+	upcoming, err = store.ListUpcomingReminders(ctx, storage.ReminderFilter{})
+	require.NoError(t, err)
+	require.Empty(t, upcoming)
+
+	_, err = store.UpdateDatabaseItem(ctx, storage.UpdateDatabaseItemInput{DatabaseID: db.ID, ItemID: item.ID, Values: map[string]any{"due": due.Format(time.RFC3339)}})
+	require.NoError(t, err)
+	require.NoError(t, store.DeleteDatabaseItem(ctx, db.ID, item.ID))
+	var remaining int
+	require.NoError(t, store.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM reminders`).Scan(&remaining))
+	require.Zero(t, remaining)
+}
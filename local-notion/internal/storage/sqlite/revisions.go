@@ -0,0 +1,433 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// SetRevisionPolicy replaces the revision policy used by later writes.
+func (s *Store) SetRevisionPolicy(policy storage.RevisionPolicy) {
+	s.revisions = policy
+}
+
+// UpdatePage changes a page and records a revision of the result.
+func (s *Store) UpdatePage(ctx context.Context, in storage.UpdatePageInput) (*domain.Page, error) {
+	if in.Title != nil && strings.TrimSpace(*in.Title) == "" {
+		return nil, storage.InvalidField("title", "cannot be empty")
+	}
+	if in.Slug != nil && strings.TrimSpace(*in.Slug) == "" {
+		return nil, storage.InvalidField("slug", "cannot be empty")
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	now := time.Now().UTC()
+	before, err := loadAuditPage(ctx, tx, in.PageID)
+	if err != nil {
+		return nil, err
+	}
+	if before == nil {
+		return nil, storage.ErrPageNotFound
+	}
+	if err := storage.CheckVersion(in.ExpectedVersion, before.Version); err != nil {
+		return nil, err
+	}
+	if err := ensurePageBaseline(ctx, tx, in.PageID, now); err != nil {
+		return nil, err
+	}
+	if err := updatePageFields(ctx, tx, in, now); err != nil {
+		return nil, err
+	}
+	if _, err := s.recordPageRevision(ctx, tx, in.PageID, storage.RevisionActionUpdate, in.Author, nil, now); err != nil {
+		return nil, err
+	}
+	if err := auditPageChange(ctx, tx, storage.AuditActionUpdate, in.PageID, before, now); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit page update: %w", err)
+	}
+	return s.GetPage(ctx, in.PageID)
+}
+
+func updatePageFields(ctx context.Context, q queryer, in storage.UpdatePageInput, now time.Time) error {
+	var oldContent string
+	if in.Content != nil {
+		if err := q.QueryRowContext(ctx, `SELECT content FROM pages WHERE id = ?`, in.PageID).Scan(&oldContent); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("load page content: %w", err)
+		}
+	}
+	sets := []string{"updated_at = ?", "version = version + 1"}
+	args := []any{now}
+	if in.Slug != nil {
+		sets = append(sets, "slug = ?")
+		args = append(args, *in.Slug)
+	}
+	if in.Title != nil {
+		sets = append(sets, "title = ?")
+		args = append(args, *in.Title)
+	}
+	if in.Summary != nil {
+		sets = append(sets, "summary = ?")
+		args = append(args, *in.Summary)
+	}
+	if in.Content != nil {
+		sets = append(sets, "content = ?")
+		args = append(args, *in.Content)
+	}
+	if in.Tags != nil {
+		tagJSON, err := json.Marshal(in.Tags)
+		if err != nil {
+			return fmt.Errorf("marshal tags: %w", err)
+		}
+		sets = append(sets, "tags = ?")
+		args = append(args, string(tagJSON))
+	}
+	args = append(args, in.PageID)
+	res, err := q.ExecContext(ctx, `UPDATE pages SET `+strings.Join(sets, ", ")+` WHERE id = ?`, args...)
+	if err != nil {
+		if in.Slug != nil {
+			if conflict := slugConflict(*in.Slug, err); conflict != nil {
+				return conflict
+			}
+		}
+		return fmt.Errorf("update page: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return storage.ErrPageNotFound
+	}
+	if in.Content != nil {
+		if err := reanchorCommentThreads(ctx, q, in.PageID, *in.Content); err != nil {
+			return err
+		}
+		if err := notifyContentMentions(ctx, q, in.PageID, storage.ChangeActor(ctx, in.Author), oldContent, *in.Content, now); err != nil {
+			return err
+		}
+	}
+	// An item is represented with its page, so it changes with it.
+	if _, err := q.ExecContext(ctx, `UPDATE database_items SET version = version + 1, updated_at = ? WHERE page_id = ?`, now, in.PageID); err != nil {
+		return fmt.Errorf("touch page item: %w", err)
+	}
+	if in.LinkedPageIDs != nil {
+		if _, err := q.ExecContext(ctx, `DELETE FROM page_links WHERE source_page_id = ?`, in.PageID); err != nil {
+			return fmt.Errorf("clear page links: %w", err)
+		}
+		for _, target := range storage.UniquePageLinks(in.LinkedPageIDs, in.PageID) {
+			if _, err := q.ExecContext(ctx, `INSERT OR IGNORE INTO page_links(source_page_id, target_page_id, created_at) VALUES (?, ?, ?)`, in.PageID, target, now); err != nil {
+				return fmt.Errorf("insert page link: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// ListPageRevisions returns the revisions of a page, newest first.
+func (s *Store) ListPageRevisions(ctx context.Context, pageID string) ([]domain.PageRevision, error) {
+	page, err := loadPageRow(ctx, s.reader, pageID)
+	if err != nil {
+		return nil, err
+	}
+	if page == nil {
+		return nil, storage.ErrPageNotFound
+	}
+	rows, err := s.reader.QueryContext(ctx, `SELECT `+revisionColumns+` FROM page_revisions WHERE page_id = ? ORDER BY revision DESC`, pageID)
+	if err != nil {
+		return nil, fmt.Errorf("list page revisions: %w", err)
+	}
+	defer rows.Close()
+	revisions := []domain.PageRevision{}
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, *rev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate page revisions: %w", err)
+	}
+	return revisions, nil
+}
+
+// DiffPageRevisions compares revision from with revision to. A zero to
+// compares against the newest revision.
+func (s *Store) DiffPageRevisions(ctx context.Context, pageID string, from, to int) (*domain.PageDiff, error) {
+	if to == 0 {
+		if err := s.reader.QueryRowContext(ctx, `SELECT COALESCE(MAX(revision), 0) FROM page_revisions WHERE page_id = ?`, pageID).Scan(&to); err != nil {
+			return nil, fmt.Errorf("load latest revision: %w", err)
+		}
+	}
+	a, err := loadRevision(ctx, s.reader, pageID, from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := loadRevision(ctx, s.reader, pageID, to)
+	if err != nil {
+		return nil, err
+	}
+	return storage.DiffRevisions(a, b), nil
+}
+
+// RestorePageRevision puts the title, content, tags and links of a revision
+// back on the page and records the result as a new revision.
+func (s *Store) RestorePageRevision(ctx context.Context, in storage.RestorePageRevisionInput) (*domain.Page, error) {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	before, err := loadAuditPage(ctx, tx, in.PageID)
+	if err != nil {
+		return nil, err
+	}
+	if before == nil {
+		return nil, storage.ErrPageNotFound
+	}
+	if err := storage.CheckVersion(in.ExpectedVersion, before.Version); err != nil {
+		return nil, err
+	}
+	rev, err := loadRevision(ctx, tx, in.PageID, in.Revision)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	tags := rev.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	links := rev.LinkedPageIDs
+	if links == nil {
+		links = []string{}
+	}
+	if err := updatePageFields(ctx, tx, storage.UpdatePageInput{PageID: in.PageID, Title: &rev.Title, Content: &rev.Content, Tags: tags, LinkedPageIDs: links}, now); err != nil {
+		return nil, err
+	}
+	if _, err := s.recordPageRevision(ctx, tx, in.PageID, storage.RevisionActionRestore, in.Author, &in.Revision, now); err != nil {
+		return nil, err
+	}
+	if err := auditPageChange(ctx, tx, storage.AuditActionRestore, in.PageID, before, now); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit revision restore: %w", err)
+	}
+	return s.GetPage(ctx, in.PageID)
+}
+
+const revisionColumns = `page_id, revision, action, title, content, tags, links, author, restored_from, created_at`
+
+func scanRevision(row interface{ Scan(...any) error }) (*domain.PageRevision, error) {
+	var rev domain.PageRevision
+	var tags, links string
+	var restoredFrom sql.NullInt64
+	if err := row.Scan(&rev.PageID, &rev.Revision, &rev.Action, &rev.Title, &rev.Content, &tags, &links, &rev.Author, &restoredFrom, &rev.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(tags), &rev.Tags); err != nil {
+		return nil, fmt.Errorf("unmarshal revision tags: %w", err)
+	}
+	if err := json.Unmarshal([]byte(links), &rev.LinkedPageIDs); err != nil {
+		return nil, fmt.Errorf("unmarshal revision links: %w", err)
+	}
+	if restoredFrom.Valid {
+		v := int(restoredFrom.Int64)
+		rev.RestoredFrom = &v
+	}
+	return &rev, nil
+}
+
+func loadRevision(ctx context.Context, q queryer, pageID string, revision int) (*domain.PageRevision, error) {
+	rev, err := scanRevision(q.QueryRowContext(ctx, `SELECT `+revisionColumns+` FROM page_revisions WHERE page_id = ? AND revision = ?`, pageID, revision))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrRevisionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load revision: %w", err)
+	}
+	return rev, nil
+}
+
+// pageSnapshot reads the revisioned fields of a page.
+func pageSnapshot(ctx context.Context, q queryer, pageID string) (*domain.PageRevision, error) {
+	page, err := loadPageRow(ctx, q, pageID)
+	if err != nil {
+		return nil, err
+	}
+	if page == nil {
+		return nil, storage.ErrPageNotFound
+	}
+	snap := &domain.PageRevision{PageID: pageID, Title: page.Title, Content: page.Content, Tags: page.Tags}
+	if snap.Tags == nil {
+		snap.Tags = []string{}
+	}
+	if snap.LinkedPageIDs, err = loadOutboundLinks(ctx, q, pageID); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// loadOutboundLinks returns the sorted targets of a page's links, never nil.
+func loadOutboundLinks(ctx context.Context, q queryer, pageID string) ([]string, error) {
+	rows, err := q.QueryContext(ctx, `SELECT target_page_id FROM page_links WHERE source_page_id = ? ORDER BY target_page_id`, pageID)
+	if err != nil {
+		return nil, fmt.Errorf("select page links: %w", err)
+	}
+	defer rows.Close()
+	links := []string{}
+	for rows.Next() {
+		var target string
+		if err := rows.Scan(&target); err != nil {
+			return nil, fmt.Errorf("scan page link: %w", err)
+		}
+		links = append(links, target)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate page links: %w", err)
+	}
+	return links, nil
+}
+
+// ensurePageBaseline records the current state of a page as its first
+// revision when the page predates revision history, so the change about to be
+// made can be diffed and undone.
+func ensurePageBaseline(ctx context.Context, q queryer, pageID string, now time.Time) error {
+	var count int
+	if err := q.QueryRowContext(ctx, `SELECT COUNT(*) FROM page_revisions WHERE page_id = ?`, pageID).Scan(&count); err != nil {
+		return fmt.Errorf("count page revisions: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+	snap, err := pageSnapshot(ctx, q, pageID)
+	if err != nil {
+		return err
+	}
+	snap.Revision = 1
+	snap.Action = storage.RevisionActionCreate
+	snap.CreatedAt = now
+	return insertRevision(ctx, q, snap)
+}
+
+// recordPageRevision snapshots a page after a change. Updates that leave the
+// snapshot unchanged record nothing, and updates inside the coalesce window
+// rewrite the previous revision instead of adding one. It returns the
+// revision number holding the new state.
+func (s *Store) recordPageRevision(ctx context.Context, q queryer, pageID, action, author string, restoredFrom *int, now time.Time) (int, error) {
+	snap, err := pageSnapshot(ctx, q, pageID)
+	if err != nil {
+		return 0, err
+	}
+	latest, err := scanRevision(q.QueryRowContext(ctx, `SELECT `+revisionColumns+` FROM page_revisions WHERE page_id = ? ORDER BY revision DESC LIMIT 1`, pageID))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("load latest revision: %w", err)
+	}
+	if latest != nil && action == storage.RevisionActionUpdate {
+		if storage.SameRevisionSnapshot(latest, snap) {
+			return latest.Revision, nil
+		}
+		if s.revisions.Coalesces(latest, author, now) {
+			snap.Revision = latest.Revision
+			if err := writeRevisionSnapshot(ctx, q, snap, now); err != nil {
+				return 0, err
+			}
+			return latest.Revision, nil
+		}
+	}
+	snap.Revision = 1
+	if latest != nil {
+		snap.Revision = latest.Revision + 1
+	}
+	snap.Action = action
+	snap.Author = author
+	snap.RestoredFrom = restoredFrom
+	snap.CreatedAt = now
+	if err := insertRevision(ctx, q, snap); err != nil {
+		return 0, err
+	}
+	if err := s.pruneRevisions(ctx, q, pageID, snap.Revision, now); err != nil {
+		return 0, err
+	}
+	return snap.Revision, nil
+}
+
+func insertRevision(ctx context.Context, q queryer, rev *domain.PageRevision) error {
+	tags, err := json.Marshal(rev.Tags)
+	if err != nil {
+		return fmt.Errorf("marshal revision tags: %w", err)
+	}
+	links, err := json.Marshal(rev.LinkedPageIDs)
+	if err != nil {
+		return fmt.Errorf("marshal revision links: %w", err)
+	}
+	if _, err := q.ExecContext(ctx, `INSERT INTO page_revisions(page_id, revision, action, title, content, tags, links, author, restored_from, created_at) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rev.PageID, rev.Revision, rev.Action, rev.Title, rev.Content, string(tags), string(links), rev.Author, rev.RestoredFrom, rev.CreatedAt); err != nil {
+		return fmt.Errorf("insert page revision: %w", err)
+	}
+	return nil
+}
+
+func writeRevisionSnapshot(ctx context.Context, q queryer, rev *domain.PageRevision, now time.Time) error {
+	tags, err := json.Marshal(rev.Tags)
+	if err != nil {
+		return fmt.Errorf("marshal revision tags: %w", err)
+	}
+	links, err := json.Marshal(rev.LinkedPageIDs)
+	if err != nil {
+		return fmt.Errorf("marshal revision links: %w", err)
+	}
+	if _, err := q.ExecContext(ctx, `UPDATE page_revisions SET title = ?, content = ?, tags = ?, links = ?, created_at = ? WHERE page_id = ? AND revision = ?`,
+		rev.Title, rev.Content, string(tags), string(links), now, rev.PageID, rev.Revision); err != nil {
+		return fmt.Errorf("coalesce page revision: %w", err)
+	}
+	return nil
+}
+
+// pruneRevisions applies the retention policy to a page, never removing the
+// newest revision.
+func (s *Store) pruneRevisions(ctx context.Context, q queryer, pageID string, newest int, now time.Time) error {
+	if s.revisions.MaxRevisions > 0 {
+		if _, err := q.ExecContext(ctx, `DELETE FROM page_revisions WHERE page_id = ? AND revision <= ?`, pageID, newest-s.revisions.MaxRevisions); err != nil {
+			return fmt.Errorf("prune page revisions: %w", err)
+		}
+	}
+	if s.revisions.MaxAge <= 0 {
+		return nil
+	}
+	rows, err := q.QueryContext(ctx, `SELECT revision, created_at FROM page_revisions WHERE page_id = ? AND revision < ?`, pageID, newest)
+	if err != nil {
+		return fmt.Errorf("select page revisions: %w", err)
+	}
+	var expired []int
+	for rows.Next() {
+		var revision int
+		var createdAt time.Time
+		if err := rows.Scan(&revision, &createdAt); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan page revision: %w", err)
+		}
+		if s.revisions.Expired(createdAt, now) {
+			expired = append(expired, revision)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("iterate page revisions: %w", err)
+	}
+	rows.Close()
+	for _, revision := range expired {
+		if _, err := q.ExecContext(ctx, `DELETE FROM page_revisions WHERE page_id = ? AND revision = ?`, pageID, revision); err != nil {
+			return fmt.Errorf("prune page revision: %w", err)
+		}
+	}
+	return nil
+}
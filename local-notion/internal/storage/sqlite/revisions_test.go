@@ -0,0 +1,114 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/storage"
+)
+
+func TestStorePageRevisionsCoalesceAndRestore(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	other, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "other", Title: "Other"})
+	require.NoError(t, err)
+	page, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "notes", Title: "Notes", Content: "one\ntwo\nthree", Tags: []string{"a"}, Author: "ana"})
+	require.NoError(t, err)
+
+	_, err = store.UpdatePage(ctx, storage.UpdatePageInput{PageID: page.ID, Content: strPtr("one\n2\nthree"), Author: "ana"})
+	require.NoError(t, err)
+	// A second edit by the same author inside the window folds into revision 2.
+	_, err = store.UpdatePage(ctx, storage.UpdatePageInput{PageID: page.ID, Content: strPtr("one\n2\nthree\nfour"), Tags: []string{"b"}, Author: "ana"})
+	require.NoError(t, err)
+	// An update that changes nothing is not recorded.
+	_, err = store.UpdatePage(ctx, storage.UpdatePageInput{PageID: page.ID, Author: "bo"})
+	require.NoError(t, err)
+	_, err = store.UpdatePage(ctx, storage.UpdatePageInput{PageID: page.ID, Title: strPtr("Renamed"), LinkedPageIDs: []string{other.ID}, Author: "bo"})
+	require.NoError(t, err)
+
+	revisions, err := store.ListPageRevisions(ctx, page.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 3)
+	require.Equal(t, []int{3, 2, 1}, []int{revisions[0].Revision, revisions[1].Revision, revisions[2].Revision})
+	require.Equal(t, storage.RevisionActionCreate, revisions[2].Action)
+	require.Equal(t, "one\n2\nthree\nfour", revisions[1].Content)
+	require.Equal(t, "bo", revisions[0].Author)
+	require.Equal(t, []string{other.ID}, revisions[0].LinkedPageIDs)
+
+	diff, err := store.DiffPageRevisions(ctx, page.ID, 1, 0)
+	require.NoError(t, err)
+	require.Equal(t, 3, diff.To)
+	require.Equal(t, "Renamed", diff.Title.To)
+	require.Equal(t, []string{"b"}, diff.TagsAdded)
+	require.Equal(t, []string{"a"}, diff.TagsRemoved)
+	require.Equal(t, []string{other.ID}, diff.LinksAdded)
+	var ops []string
+	for _, line := range diff.Lines {
+		ops = append(ops, line.Op+":"+line.Text)
+	}
+	require.Equal(t, []string{"equal:one", "insert:2", "delete:two", "equal:three", "insert:four"}, ops)
+
+	_, err = store.RestorePageRevision(ctx, storage.RestorePageRevisionInput{PageID: page.ID, Revision: 1, Author: "cy", ExpectedVersion: intPtr(1)})
+	require.ErrorIs(t, err, storage.ErrVersionMismatch)
+
+	restored, err := store.RestorePageRevision(ctx, storage.RestorePageRevisionInput{PageID: page.ID, Revision: 1, Author: "cy", ExpectedVersion: intPtr(5)})
+	require.NoError(t, err)
+	require.Equal(t, 6, restored.Version)
+	require.Equal(t, "Notes", restored.Title)
+	require.Equal(t, "one\ntwo\nthree", restored.Content)
+	require.Equal(t, []string{"a"}, restored.Tags)
+	require.Empty(t, restored.LinkedPageIDs)
+
+	revisions, err = store.ListPageRevisions(ctx, page.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 4)
+	require.Equal(t, storage.RevisionActionRestore, revisions[0].Action)
+	require.Equal(t, 1, *revisions[0].RestoredFrom)
+
+	_, err = store.RestorePageRevision(ctx, storage.RestorePageRevisionInput{PageID: page.ID, Revision: 9, Author: "cy"})
+	require.ErrorIs(t, err, storage.ErrRevisionNotFound)
+}
+
+func TestStorePageRevisionRetention(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	store.SetRevisionPolicy(storage.RevisionPolicy{MaxRevisions: 2})
+	page, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "log", Title: "Log"})
+	require.NoError(t, err)
+	for _, content := range []string{"a", "b", "c"} {
+		_, err = store.UpdatePage(ctx, storage.UpdatePageInput{PageID: page.ID, Content: strPtr(content)})
+		require.NoError(t, err)
+	}
+	revisions, err := store.ListPageRevisions(ctx, page.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	require.Equal(t, 4, revisions[0].Revision)
+	require.Equal(t, "c", revisions[0].Content)
+
+	store.SetRevisionPolicy(storage.RevisionPolicy{MaxAge: time.Nanosecond})
+	_, err = store.UpdatePage(ctx, storage.UpdatePageInput{PageID: page.ID, Content: strPtr("d")})
+	require.NoError(t, err)
+	revisions, err = store.ListPageRevisions(ctx, page.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	require.Equal(t, 5, revisions[0].Revision)
+}
+
+func TestStoreItemUpdateRecordsBaselineRevision(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db := newBulkTestDatabase(t, store)
+	item := createTestItems(t, store, db, "task")[0]
+
+	_, err := store.UpdateDatabaseItem(ctx, storage.UpdateDatabaseItemInput{DatabaseID: db.ID, ItemID: item.ID, Content: strPtr("done"), Author: "ana"})
+	require.NoError(t, err)
+	revisions, err := store.ListPageRevisions(ctx, item.Page.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	require.Equal(t, "", revisions[1].Content)
+	require.Equal(t, "done", revisions[0].Content)
+	require.Equal(t, "ana", revisions[0].Author)
+}
@@ -0,0 +1,232 @@
+package sqlite
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// DefaultSchemaCacheSize is the number of database schemas kept in memory
+// unless SetSchemaCacheSize says otherwise.
+const DefaultSchemaCacheSize = 256
+
+// databaseSchema holds the properties and views of a database as of a
+// database version, with the lookups derived from them. Cached schemas are
+// shared between requests and must not be modified.
+type databaseSchema struct {
+	databaseID string
+	version    int
+	properties []domain.DatabaseProperty
+	views      []domain.DatabaseView
+	slugs      map[string]string              // property slug to id
+	types      map[string]domain.PropertyType // property id to type
+	options    map[string][]string            // property id to option names
+}
+
+// view returns a copy of the view with the given id.
+func (s *databaseSchema) view(viewID string) (*domain.DatabaseView, bool) {
+	for _, view := range s.views {
+		if view.ID == viewID {
+			return &view, true
+		}
+	}
+	return nil, false
+}
+
+// schemaCache is a bounded LRU of database schemas keyed by database id. An
+// entry is only valid for the database version it was loaded at; every
+// change to properties or views bumps that version (see touchDatabase), so
+// stale entries are never returned and are replaced on the next load.
+type schemaCache struct {
+	mu        sync.Mutex
+	capacity  int
+	entries   map[string]*list.Element
+	order     *list.List // most recently used first
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+func newSchemaCache(capacity int) *schemaCache {
+	return &schemaCache{capacity: capacity, entries: make(map[string]*list.Element), order: list.New()}
+}
+
+// get returns the cached schema of a database if it is at version.
+func (c *schemaCache) get(databaseID string, version int) (*databaseSchema, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[databaseID]; ok {
+		if schema := el.Value.(*databaseSchema); schema.version == version {
+			c.order.MoveToFront(el)
+			c.hits++
+			return schema, true
+		}
+	}
+	c.misses++
+	return nil, false
+}
+
+// put stores a schema unless a newer version of it is cached already, which
+// happens when concurrent readers load different versions.
+func (c *schemaCache) put(schema *databaseSchema) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.capacity <= 0 {
+		return
+	}
+	if el, ok := c.entries[schema.databaseID]; ok {
+		if el.Value.(*databaseSchema).version <= schema.version {
+			el.Value = schema
+		}
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[schema.databaseID] = c.order.PushFront(schema)
+	c.evict()
+}
+
+// resize changes the capacity, evicting the least recently used entries that
+// no longer fit. A capacity of zero disables the cache.
+func (c *schemaCache) resize(capacity int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.capacity = max(capacity, 0)
+	c.evict()
+}
+
+func (c *schemaCache) evict() {
+	for c.order.Len() > c.capacity {
+		el := c.order.Back()
+		c.order.Remove(el)
+		delete(c.entries, el.Value.(*databaseSchema).databaseID)
+		c.evictions++
+	}
+}
+
+func (c *schemaCache) stats() storage.SchemaCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return storage.SchemaCacheStats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Entries:   c.order.Len(),
+		Capacity:  c.capacity,
+	}
+}
+
+// SetSchemaCacheSize bounds the number of database schemas kept in memory;
+// zero disables the cache.
+func (s *Store) SetSchemaCacheSize(size int) {
+	s.schemas.resize(size)
+}
+
+// SchemaCacheStats returns the hit, miss and eviction counts of the schema
+// cache.
+func (s *Store) SchemaCacheStats() storage.SchemaCacheStats {
+	return s.schemas.stats()
+}
+
+// schema returns the properties and views of a database. It returns
+// storage.ErrDatabaseNotFound when the database does not exist.
+//
+// The database version is always read through q, so the result is current
+// for q even inside a transaction. Cached schemas only ever hold committed
+// state, so a transaction is answered from the cache when its version
+// matches. A schema loaded inside a write transaction may include changes
+// that are rolled back, so it is cached only once the transaction commits.
+func (s *Store) schema(ctx context.Context, q queryer, databaseID string) (*databaseSchema, error) {
+	var version int
+	err := q.QueryRowContext(ctx, `SELECT version FROM databases WHERE id = ?`, databaseID).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrDatabaseNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load database version: %w", err)
+	}
+	tx, _ := q.(*writeTx)
+	if schema := tx.schema(databaseID, version); schema != nil {
+		return schema, nil
+	}
+	if schema, ok := s.schemas.get(databaseID, version); ok {
+		return schema, nil
+	}
+	// The version was read first, so a change committed in between makes
+	// the properties and views newer than the version they are cached at,
+	// and the next lookup reloads them.
+	schema, err := loadSchema(ctx, q, databaseID, version)
+	if err != nil {
+		return nil, err
+	}
+	if tx != nil {
+		tx.loaded = append(tx.loaded, schema)
+	} else if q == queryer(s.reader) {
+		s.schemas.put(schema)
+	}
+	return schema, nil
+}
+
+func loadSchema(ctx context.Context, q queryer, databaseID string, version int) (*databaseSchema, error) {
+	schema := &databaseSchema{
+		databaseID: databaseID,
+		version:    version,
+		slugs:      make(map[string]string),
+		types:      make(map[string]domain.PropertyType),
+		options:    make(map[string][]string),
+	}
+	rows, err := q.QueryContext(ctx, `SELECT id, name, slug, type, config, is_required, default_value, order_index, created_at, updated_at FROM database_properties WHERE database_id = ? ORDER BY order_index ASC`, databaseID)
+	if err != nil {
+		return nil, fmt.Errorf("query properties: %w", err)
+	}
+	for rows.Next() {
+		var prop domain.DatabaseProperty
+		var cfg, def sql.NullString
+		if err := rows.Scan(&prop.ID, &prop.Name, &prop.Slug, &prop.Type, &cfg, &prop.IsRequired, &def, &prop.OrderIndex, &prop.CreatedAt, &prop.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan property: %w", err)
+		}
+		prop.DatabaseID = databaseID
+		if cfg.String != "" {
+			_ = json.Unmarshal([]byte(cfg.String), &prop.Config)
+		}
+		if def.String != "" {
+			var raw any
+			if err := json.Unmarshal([]byte(def.String), &raw); err == nil {
+				prop.Default = raw
+			}
+		}
+		schema.properties = append(schema.properties, prop)
+		schema.slugs[prop.Slug] = prop.ID
+		schema.types[prop.ID] = prop.Type
+		if names := storage.PropertyOptions(prop.Config); len(names) > 0 {
+			schema.options[prop.ID] = names
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate properties: %w", err)
+	}
+	viewRows, err := q.QueryContext(ctx, `SELECT `+viewColumns+` FROM database_views WHERE database_id = ? ORDER BY created_at ASC`, databaseID)
+	if err != nil {
+		return nil, fmt.Errorf("query views: %w", err)
+	}
+	defer viewRows.Close()
+	for viewRows.Next() {
+		view, err := scanViewRow(viewRows)
+		if err != nil {
+			return nil, err
+		}
+		schema.views = append(schema.views, *view)
+	}
+	if err := viewRows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate views: %w", err)
+	}
+	return schema, nil
+}
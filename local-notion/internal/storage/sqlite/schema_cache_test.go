@@ -0,0 +1,184 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+func TestSchemaCacheFollowsSchemaChanges(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db := newViewTestDatabase(t, store)
+	status := propertyIDBySlug(t, db, "status")
+	viewID := db.Views[0].ID
+	for _, value := range []string{"Todo", "Done"} {
+		_, err := store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
+			DatabaseID: db.ID,
+			Page:       storage.CreatePageInput{Slug: value, Title: value},
+			Values:     map[string]any{"status": value},
+		})
+		require.NoError(t, err)
+	}
+
+	result, err := store.QueryView(ctx, db.ID, viewID)
+	require.NoError(t, err)
+	require.Len(t, result.Items, 2)
+	before := store.SchemaCacheStats()
+	_, err = store.QueryView(ctx, db.ID, viewID)
+	require.NoError(t, err)
+	after := store.SchemaCacheStats()
+	require.Equal(t, before.Hits+1, after.Hits)
+	require.Equal(t, before.Misses, after.Misses)
+	require.Equal(t, 1, after.Entries)
+
+	// Changing a view bumps the database version, so the cached schema is
+	// replaced rather than served stale.
+	_, err = store.UpdateDatabaseView(ctx, storage.UpdateDatabaseViewInput{
+		DatabaseID: db.ID,
+		ViewID:     viewID,
+		Filters:    map[string]any{"property_id": status, "operator": "equals", "value": "Done"},
+	})
+	require.NoError(t, err)
+	result, err = store.QueryView(ctx, db.ID, viewID)
+	require.NoError(t, err)
+	require.Len(t, result.Items, 1)
+	require.Equal(t, before.Misses+1, store.SchemaCacheStats().Misses)
+
+	created, err := store.CreateDatabaseView(ctx, db.ID, storage.DatabaseViewInput{Name: "Board", Type: domain.ViewTypeBoard, Grouping: map[string]any{"property_id": status}})
+	require.NoError(t, err)
+	result, err = store.QueryView(ctx, db.ID, created.ID)
+	require.NoError(t, err)
+	require.Len(t, result.Items, 2)
+
+	_, err = store.QueryView(ctx, "missing", viewID)
+	require.ErrorIs(t, err, storage.ErrDatabaseNotFound)
+	_, err = store.QueryView(ctx, db.ID, "missing")
+	require.ErrorIs(t, err, storage.ErrViewNotFound)
+}
+
+func TestSchemaCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	store.SetSchemaCacheSize(2)
+	var views [][2]string
+	for idx := range 3 {
+		db, err := store.CreateDatabase(ctx, storage.CreateDatabaseInput{
+			Slug:  fmt.Sprintf("db-%d", idx),
+			Title: "Database",
+			Views: []storage.DatabaseViewInput{{Name: "Table", Type: domain.ViewTypeTable}},
+		})
+		require.NoError(t, err)
+		views = append(views, [2]string{db.ID, db.Views[0].ID})
+	}
+	query := func(idx int) {
+		t.Helper()
+		_, err := store.QueryView(ctx, views[idx][0], views[idx][1])
+		require.NoError(t, err)
+	}
+
+	query(0)
+	query(1)
+	query(0)
+	query(2) // evicts 1, the least recently used
+	stats := store.SchemaCacheStats()
+	require.Equal(t, storage.SchemaCacheStats{Hits: 1, Misses: 3, Evictions: 1, Entries: 2, Capacity: 2}, stats)
+	query(0)
+	query(1)
+	stats = store.SchemaCacheStats()
+	require.Equal(t, uint64(2), stats.Hits)
+	require.Equal(t, uint64(4), stats.Misses)
+
+	store.SetSchemaCacheSize(0)
+	query(0)
+	stats = store.SchemaCacheStats()
+	require.Zero(t, stats.Entries)
+	require.Equal(t, uint64(4), stats.Evictions)
+}
+
+func TestSchemaCacheConcurrentWriters(t *testing.T) {
+	store := newFileTestStore(t, readerPoolSize)
+	ctx := context.Background()
+	db := newViewTestDatabase(t, store)
+	status := propertyIDBySlug(t, db, "status")
+
+	const workers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*workers)
+	for idx := range workers {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := store.CreateDatabaseView(ctx, db.ID, storage.DatabaseViewInput{
+				Name:    fmt.Sprintf("View %d", idx),
+				Type:    domain.ViewTypeTable,
+				Filters: map[string]any{"property_id": status, "operator": "equals", "value": "Done"},
+			})
+			errs <- err
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
+				DatabaseID: db.ID,
+				Page:       storage.CreatePageInput{Slug: fmt.Sprintf("item-%d", idx), Title: "Item"},
+				Values:     map[string]any{"status": "Done"},
+			})
+			if err == nil {
+				_, err = store.QueryView(ctx, db.ID, db.Views[0].ID)
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	// Every view created concurrently is visible through the cache.
+	loaded, err := store.GetDatabase(ctx, db.ID)
+	require.NoError(t, err)
+	require.Len(t, loaded.Views, workers+1)
+	for _, view := range loaded.Views {
+		result, err := store.QueryView(ctx, db.ID, view.ID)
+		require.NoError(t, err)
+		require.Len(t, result.Items, workers)
+	}
+}
+
+func TestSchemaCacheServesAndFillsTransactions(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db := newViewTestDatabase(t, store)
+
+	// The schema loaded inside the item transaction is cached once it
+	// commits, so the next write is answered from the cache.
+	create := func(slug string, values map[string]any) error {
+		_, err := store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
+			DatabaseID: db.ID,
+			Page:       storage.CreatePageInput{Slug: slug, Title: slug},
+			Values:     values,
+		})
+		return err
+	}
+	require.NoError(t, create("first", map[string]any{"status": "Todo"}))
+	stats := store.SchemaCacheStats()
+	require.Equal(t, uint64(1), stats.Misses)
+	require.Equal(t, 1, stats.Entries)
+	require.NoError(t, create("second", map[string]any{"status": "Done"}))
+	stats = store.SchemaCacheStats()
+	require.NotZero(t, stats.Hits)
+	require.Equal(t, uint64(1), stats.Misses)
+
+	// A schema loaded in a transaction that rolls back is not cached.
+	store.SetSchemaCacheSize(0)
+	store.SetSchemaCacheSize(DefaultSchemaCacheSize)
+	require.Error(t, create("bad", map[string]any{"missing": "value"}))
+	require.Zero(t, store.SchemaCacheStats().Entries)
+}
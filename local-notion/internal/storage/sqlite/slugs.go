@@ -0,0 +1,108 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// Slugs a page or database gave up are kept in page_slug_history and
+// database_slug_history by triggers (see migration 013_slug_history.sql), so
+// links to an old slug keep resolving until another record takes it.
+
+// GetPageBySlug returns the page currently or formerly known by slug. The
+// returned page carries its current slug, which differs from slug when the
+// page has been renamed since. It returns storage.ErrPageNotFound when no
+// page ever used slug.
+func (s *Store) GetPageBySlug(ctx context.Context, slug string) (*domain.Page, error) {
+	id, err := resolveSlug(ctx, s.reader, "pages", slug)
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		return nil, storage.ErrPageNotFound
+	}
+	return s.GetPage(ctx, id)
+}
+
+// GetDatabaseBySlug returns the database currently or formerly known by slug,
+// like GetPageBySlug. It returns storage.ErrDatabaseNotFound when no database
+// ever used slug.
+func (s *Store) GetDatabaseBySlug(ctx context.Context, slug string) (*domain.Database, error) {
+	id, err := resolveSlug(ctx, s.reader, "databases", slug)
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		return nil, storage.ErrDatabaseNotFound
+	}
+	return s.GetDatabase(ctx, id)
+}
+
+// resolveSlug returns the id of the record of table using slug, falling back
+// to the slug history, or "" when there is none. table must be a key of
+// slugHistoryTables.
+func resolveSlug(ctx context.Context, q queryer, table, slug string) (string, error) {
+	idColumn := strings.TrimSuffix(table, "s") + "_id"
+	var id string
+	err := q.QueryRowContext(ctx, `SELECT id FROM `+table+` WHERE slug = ?
+UNION ALL SELECT `+idColumn+` FROM `+slugHistoryTables[table]+` WHERE slug = ?
+LIMIT 1`, slug, slug).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("resolve slug: %w", err)
+	}
+	return id, nil
+}
+
+// UpdateDatabaseSlug gives a database a new slug. The old slug keeps
+// resolving to the database through GetDatabaseBySlug.
+func (s *Store) UpdateDatabaseSlug(ctx context.Context, in storage.UpdateDatabaseSlugInput) (*domain.Database, error) {
+	if strings.TrimSpace(in.Slug) == "" {
+		return nil, storage.InvalidField("slug", "cannot be empty")
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	before, err := s.loadDatabase(ctx, tx, in.DatabaseID)
+	if err != nil {
+		return nil, err
+	}
+	if before == nil {
+		return nil, storage.ErrDatabaseNotFound
+	}
+	if err := storage.CheckVersion(in.ExpectedVersion, before.Version); err != nil {
+		return nil, err
+	}
+	if before.Slug == in.Slug {
+		return before, nil
+	}
+	now := time.Now().UTC()
+	if _, err := tx.ExecContext(ctx, `UPDATE databases SET slug = ?, version = version + 1, updated_at = ? WHERE id = ?`, in.Slug, now, in.DatabaseID); err != nil {
+		if conflict := slugConflict(in.Slug, err); conflict != nil {
+			return nil, conflict
+		}
+		return nil, fmt.Errorf("update database slug: %w", err)
+	}
+	after, err := s.loadDatabase(ctx, tx, in.DatabaseID)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordAudit(ctx, tx, storage.AuditActionUpdate, storage.AuditEntityDatabase, in.DatabaseID, before, after, now); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit database slug: %w", err)
+	}
+	return after, nil
+}
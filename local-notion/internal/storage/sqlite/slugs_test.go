@@ -0,0 +1,97 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/storage"
+)
+
+func TestPageSlugHistory(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	page, err := store.CreatePage(ctx, storage.CreatePageInput{Title: "Meeting Notes"})
+	require.NoError(t, err)
+	require.Equal(t, "meeting-notes", page.Slug)
+
+	for _, slug := range []string{"notes", "minutes"} {
+		_, err = store.UpdatePage(ctx, storage.UpdatePageInput{PageID: page.ID, Slug: &slug})
+		require.NoError(t, err)
+	}
+	for _, slug := range []string{"meeting-notes", "notes", "minutes"} {
+		got, err := store.GetPageBySlug(ctx, slug)
+		require.NoError(t, err, slug)
+		require.Equal(t, page.ID, got.ID)
+		require.Equal(t, "minutes", got.Slug)
+	}
+	_, err = store.GetPageBySlug(ctx, "missing")
+	require.ErrorIs(t, err, storage.ErrPageNotFound)
+
+	// Generated slugs skip former slugs so old links keep working, but an
+	// explicit slug takes a former one over.
+	generated, err := store.CreatePage(ctx, storage.CreatePageInput{Title: "Notes"})
+	require.NoError(t, err)
+	require.Equal(t, "notes-2", generated.Slug)
+	other, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "notes", Title: "Other notes"})
+	require.NoError(t, err)
+	got, err := store.GetPageBySlug(ctx, "notes")
+	require.NoError(t, err)
+	require.Equal(t, other.ID, got.ID)
+
+	// Moving back to a former slug drops it from the history.
+	_, err = store.UpdatePage(ctx, storage.UpdatePageInput{PageID: page.ID, Slug: strPtr("meeting-notes")})
+	require.NoError(t, err)
+	var history []string
+	rows, err := store.db.QueryContext(ctx, `SELECT slug FROM page_slug_history WHERE page_id = ? ORDER BY slug`, page.ID)
+	require.NoError(t, err)
+	for rows.Next() {
+		var slug string
+		require.NoError(t, rows.Scan(&slug))
+		history = append(history, slug)
+	}
+	require.NoError(t, rows.Err())
+	require.Equal(t, []string{"minutes"}, history)
+
+	db, err := store.CreateDatabase(ctx, storage.CreateDatabaseInput{Title: "Tasks"})
+	require.NoError(t, err)
+	item, err := store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{DatabaseID: db.ID, Page: storage.CreatePageInput{Title: "Task"}})
+	require.NoError(t, err)
+	_, err = store.UpdatePage(ctx, storage.UpdatePageInput{PageID: item.Page.ID, Slug: strPtr("chore")})
+	require.NoError(t, err)
+	require.NoError(t, store.DeleteDatabaseItem(ctx, db.ID, item.ID))
+	_, err = store.GetPageBySlug(ctx, "task")
+	require.ErrorIs(t, err, storage.ErrPageNotFound)
+}
+
+func TestDatabaseSlugHistory(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db, err := store.CreateDatabase(ctx, storage.CreateDatabaseInput{Title: "Reading List"})
+	require.NoError(t, err)
+	_, err = store.CreateDatabase(ctx, storage.CreateDatabaseInput{Slug: "books", Title: "Books"})
+	require.NoError(t, err)
+
+	_, err = store.UpdateDatabaseSlug(ctx, storage.UpdateDatabaseSlugInput{DatabaseID: db.ID, Slug: "books"})
+	require.ErrorIs(t, err, storage.ErrSlugConflict)
+	_, err = store.UpdateDatabaseSlug(ctx, storage.UpdateDatabaseSlugInput{DatabaseID: db.ID, Slug: "reading", ExpectedVersion: intPtr(2)})
+	require.ErrorIs(t, err, storage.ErrVersionMismatch)
+	updated, err := store.UpdateDatabaseSlug(ctx, storage.UpdateDatabaseSlugInput{DatabaseID: db.ID, Slug: "reading", ExpectedVersion: intPtr(1)})
+	require.NoError(t, err)
+	require.Equal(t, "reading", updated.Slug)
+	require.Equal(t, 2, updated.Version)
+
+	got, err := store.GetDatabaseBySlug(ctx, "reading-list")
+	require.NoError(t, err)
+	require.Equal(t, db.ID, got.ID)
+	require.Equal(t, "reading", got.Slug)
+	_, err = store.GetDatabaseBySlug(ctx, "missing")
+	require.ErrorIs(t, err, storage.ErrDatabaseNotFound)
+	_, err = store.UpdateDatabaseSlug(ctx, storage.UpdateDatabaseSlugInput{DatabaseID: "missing", Slug: "x"})
+	require.ErrorIs(t, err, storage.ErrDatabaseNotFound)
+
+	entries, _, err := store.ListAuditEntries(ctx, storage.AuditFilter{EntityType: storage.AuditEntityDatabase, EntityID: db.ID, Action: storage.AuditActionUpdate})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
@@ -10,61 +10,253 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
-	"sort"
+	"runtime"
 	"strings"
 	"time"
 
 	_ "modernc.org/sqlite"
 
 	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
 	"github.com/google/uuid"
 )
 
-// ErrViewNotFound is returned when a database view cannot be located for an item listing.
-var ErrViewNotFound = errors.New("view not found")
-
 //go:embed migrations/*.sql
 var migrationsFS embed.FS
 
-// Store wraps access to the SQLite database.
+// Store wraps access to the SQLite database. File databases run in WAL mode
+// with one writer connection, used for every transaction, and a pool of
+// read-only connections, so reads proceed while a write is in progress.
 type Store struct {
-	db *sql.DB
+	db        *sql.DB
+	reader    *sql.DB
+	revisions storage.RevisionPolicy
+	schemas   *schemaCache
+	// notifications pushes committed notifications to subscribers.
+	notifications *storage.NotificationBroker
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx so read helpers can run
+// inside or outside a transaction.
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// writeTx is a transaction on the writer connection. Schemas loaded and
+// notifications created inside it are held back until it commits, when they
+// describe committed state: the schemas are added to the schema cache and
+// the notifications pushed to subscribers. A rollback discards both with the
+// transaction.
+type writeTx struct {
+	*sql.Tx
+	store    *Store
+	loaded   []*databaseSchema
+	notified []domain.Notification
+}
+
+// begin starts a write transaction.
+func (s *Store) begin(ctx context.Context) (*writeTx, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &writeTx{Tx: tx, store: s}, nil
+}
+
+// schema returns the schema of a database loaded earlier in the transaction
+// at version, or nil. tx may be nil.
+func (tx *writeTx) schema(databaseID string, version int) *databaseSchema {
+	if tx == nil {
+		return nil
+	}
+	for _, schema := range tx.loaded {
+		if schema.databaseID == databaseID && schema.version == version {
+			return schema
+		}
+	}
+	return nil
+}
+
+// Commit commits the transaction, caches the schemas loaded inside it and
+// publishes its notifications.
+func (tx *writeTx) Commit() error {
+	if err := tx.Tx.Commit(); err != nil {
+		return err
+	}
+	for _, schema := range tx.loaded {
+		tx.store.schemas.put(schema)
+	}
+	tx.store.notifications.Publish(tx.notified)
+	return nil
+}
+
+// txSavepoint marks a point of a write transaction that later changes can
+// be rolled back to.
+type txSavepoint struct {
+	name     string
+	loaded   int
+	notified int
+}
+
+// savepoint starts a savepoint named name. name must be a trusted identifier.
+func (tx *writeTx) savepoint(ctx context.Context, name string) (txSavepoint, error) {
+	if _, err := tx.ExecContext(ctx, `SAVEPOINT `+name); err != nil {
+		return txSavepoint{}, fmt.Errorf("create savepoint: %w", err)
+	}
+	return txSavepoint{name: name, loaded: len(tx.loaded), notified: len(tx.notified)}, nil
+}
+
+// rollbackTo undoes the changes made since sp, including the schemas loaded
+// and the notifications queued since, and keeps the savepoint open.
+func (tx *writeTx) rollbackTo(ctx context.Context, sp txSavepoint) error {
+	if _, err := tx.ExecContext(ctx, `ROLLBACK TO `+sp.name); err != nil {
+		return fmt.Errorf("rollback savepoint: %w", err)
+	}
+	tx.loaded = tx.loaded[:sp.loaded]
+	tx.notified = tx.notified[:sp.notified]
+	return nil
+}
+
+// release ends sp, keeping its changes in the transaction.
+func (tx *writeTx) release(ctx context.Context, sp txSavepoint) error {
+	if _, err := tx.ExecContext(ctx, `RELEASE `+sp.name); err != nil {
+		return fmt.Errorf("release savepoint: %w", err)
+	}
+	return nil
+}
+
+// busyTimeoutMillis is how long a connection waits for a lock held by another
+// process before failing with SQLITE_BUSY.
+const busyTimeoutMillis = 5000
+
+// writerParams configure the writer connection. Synchronous NORMAL is durable
+// against application crashes in WAL mode and only risks the most recent
+// commits on power loss. Transactions start IMMEDIATE so they take the write
+// lock up front instead of failing to upgrade a read lock.
+var writerParams = []string{
+	fmt.Sprintf("_pragma=busy_timeout(%d)", busyTimeoutMillis),
+	"_pragma=journal_mode(WAL)",
+	"_pragma=synchronous(NORMAL)",
+	"_txlock=immediate",
 }
 
+// readerParams configure the pooled read-only connections.
+var readerParams = []string{
+	fmt.Sprintf("_pragma=busy_timeout(%d)", busyTimeoutMillis),
+	"_pragma=query_only(1)",
+}
+
+// readerPoolSize bounds the read-only connections of a file database.
+var readerPoolSize = max(4, runtime.NumCPU())
+
 // Open initializes a SQLite store at the provided DSN.
 func Open(dsn string) (*Store, error) {
+	return open(dsn, readerPoolSize)
+}
+
+// open initializes a store with up to readers read-only connections. With no
+// readers, or for in-memory databases, whose every connection would see a
+// separate empty database, reads share the writer connection.
+func open(dsn string, readers int) (*Store, error) {
 	if strings.TrimSpace(dsn) == "" {
 		dsn = ":memory:"
 	}
 	if err := ensureSQLiteDir(dsn); err != nil {
 		return nil, err
 	}
-	db, err := sql.Open("sqlite", dsn)
+	memory := isMemoryDSN(dsn)
+	params := writerParams
+	if memory {
+		params = nil
+	}
+	db, err := openDB(dsn, 1, params)
 	if err != nil {
-		return nil, fmt.Errorf("open sqlite: %w", err)
+		return nil, err
 	}
-	db.SetMaxOpenConns(1)
 	if err := applyMigrations(db); err != nil {
 		_ = db.Close()
 		return nil, err
 	}
-	return &Store{db: db}, nil
+	if err := backfillItemRanks(db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	store := &Store{db: db, reader: db, revisions: storage.DefaultRevisionPolicy, schemas: newSchemaCache(DefaultSchemaCacheSize), notifications: storage.NewNotificationBroker()}
+	if memory || readers <= 0 {
+		return store, nil
+	}
+	if store.reader, err = openDB(dsn, readers, readerParams); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return store, nil
 }
 
-// Close closes underlying db.
+// openDB opens a handle on dsn limited to conns connections, each configured
+// with the given DSN query parameters.
+func openDB(dsn string, conns int, params []string) (*sql.DB, error) {
+	if len(params) > 0 {
+		sep := "?"
+		if strings.Contains(dsn, "?") {
+			sep = "&"
+		}
+		dsn += sep + strings.Join(params, "&")
+	}
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	db.SetMaxOpenConns(conns)
+	db.SetMaxIdleConns(conns)
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("connect sqlite: %w", err)
+	}
+	return db, nil
+}
+
+func isMemoryDSN(dsn string) bool {
+	return dsn == ":memory:" || strings.HasPrefix(dsn, "file::memory:") || strings.Contains(dsn, "mode=memory")
+}
+
+// Close closes the reader pool and the writer connection.
 func (s *Store) Close() error {
 	if s.db == nil {
 		return nil
 	}
-	return s.db.Close()
+	var err error
+	if s.reader != s.db {
+		err = s.reader.Close()
+	}
+	return errors.Join(err, s.db.Close())
 }
 
 // Ping verifies database connectivity.
 func (s *Store) Ping(ctx context.Context) error {
-	return s.db.PingContext(ctx)
+	if err := s.db.PingContext(ctx); err != nil {
+		return err
+	}
+	return s.reader.PingContext(ctx)
 }
 
+// migrationHooks complete migrations whose data changes cannot be written in
+// SQL. A hook runs in the transaction of its migration, after the file.
+var migrationHooks = map[string]func(context.Context, *sql.Tx) error{
+	"011_typed_values.sql": backfillIndexedValues,
+}
+
+// applyMigrations runs every embedded migration that has not been recorded in
+// schema_migrations yet. Migrations that alter existing tables are not
+// idempotent, so each file must only ever run once per database.
 func applyMigrations(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+    version TEXT PRIMARY KEY,
+    applied_at DATETIME NOT NULL
+)`); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
 	entries, err := migrationsFS.ReadDir("migrations")
 	if err != nil {
 		return fmt.Errorf("read migrations: %w", err)
@@ -73,13 +265,39 @@ func applyMigrations(db *sql.DB) error {
 		if entry.IsDir() {
 			continue
 		}
+		var applied int
+		err := db.QueryRow(`SELECT COUNT(1) FROM schema_migrations WHERE version = ?`, entry.Name()).Scan(&applied)
+		if err != nil {
+			return fmt.Errorf("check migration %s: %w", entry.Name(), err)
+		}
+		if applied > 0 {
+			continue
+		}
 		sqlBytes, err := migrationsFS.ReadFile("migrations/" + entry.Name())
 		if err != nil {
 			return fmt.Errorf("read migration %s: %w", entry.Name(), err)
 		}
-		if _, err := db.Exec(string(sqlBytes)); err != nil {
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("begin migration %s: %w", entry.Name(), err)
+		}
+		if _, err := tx.Exec(string(sqlBytes)); err != nil {
+			_ = tx.Rollback()
 			return fmt.Errorf("exec migration %s: %w", entry.Name(), err)
 		}
+		if hook, ok := migrationHooks[entry.Name()]; ok {
+			if err := hook(context.Background(), tx); err != nil {
+				_ = tx.Rollback()
+				return fmt.Errorf("complete migration %s: %w", entry.Name(), err)
+			}
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations(version, applied_at) VALUES (?, ?)`, entry.Name(), time.Now().UTC()); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("record migration %s: %w", entry.Name(), err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("commit migration %s: %w", entry.Name(), err)
+		}
 	}
 	return nil
 }
@@ -122,21 +340,11 @@ func ensureSQLiteDir(dsn string) error {
 	return nil
 }
 
-// CreatePageInput holds fields for a new page.
-type CreatePageInput struct {
-	Slug          string
-	Title         string
-	Summary       string
-	Content       string
-	ParentPageID  *string
-	Tags          []string
-	LinkedPageIDs []string
-}
-
 // CreatePage persists a new page.
-func (s *Store) CreatePage(ctx context.Context, in CreatePageInput) (*domain.Page, error) {
-	if in.Slug == "" || in.Title == "" {
-		return nil, errors.New("slug and title are required")
+// A page without a slug gets a unique one generated from its title.
+func (s *Store) CreatePage(ctx context.Context, in storage.CreatePageInput) (*domain.Page, error) {
+	if err := storage.RequireFields("title", in.Title); err != nil {
+		return nil, err
 	}
 	now := time.Now().UTC()
 	id := uuid.NewString()
@@ -144,7 +352,7 @@ func (s *Store) CreatePage(ctx context.Context, in CreatePageInput) (*domain.Pag
 	if err != nil {
 		return nil, fmt.Errorf("marshal tags: %w", err)
 	}
-	tx, err := s.db.BeginTx(ctx, nil)
+	tx, err := s.begin(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("begin transaction: %w", err)
 	}
@@ -153,19 +361,29 @@ func (s *Store) CreatePage(ctx context.Context, in CreatePageInput) (*domain.Pag
 			_ = tx.Rollback()
 		}
 	}()
+	if strings.TrimSpace(in.Slug) == "" {
+		if in.Slug, err = uniqueSlug(ctx, tx, "pages", storage.SlugOrDefault(in.Title, "page")); err != nil {
+			return nil, err
+		}
+	}
+	if !in.IsTemplate {
+		if in.IsTemplate, err = inheritsTemplate(ctx, tx, "", in.ParentPageID); err != nil {
+			return nil, err
+		}
+	}
 
 	if _, err = tx.ExecContext(
 		ctx,
 		`INSERT INTO pages(
-    id, slug, title, summary, content, parent_page_id, tags, is_archived, created_at, updated_at
-) VALUES (?, ?, ?, ?, ?, ?, ?, 0, ?, ?)
+    id, slug, title, summary, content, parent_page_id, tags, is_archived, is_template, created_at, updated_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, 0, ?, ?, ?)
 `,
-		id, in.Slug, in.Title, in.Summary, in.Content, in.ParentPageID, string(tagJSON), now, now,
+		id, in.Slug, in.Title, in.Summary, in.Content, in.ParentPageID, string(tagJSON), boolToInt(in.IsTemplate), now, now,
 	); err != nil {
-		return nil, fmt.Errorf("insert page: %w", err)
+		return nil, insertError("page", in.Slug, err)
 	}
 
-	cleanedLinks := uniqueLinkedPageIDs(in.LinkedPageIDs, id)
+	cleanedLinks := storage.UniquePageLinks(in.LinkedPageIDs, id)
 	for _, targetID := range cleanedLinks {
 		if _, err = tx.ExecContext(
 			ctx,
@@ -175,6 +393,17 @@ func (s *Store) CreatePage(ctx context.Context, in CreatePageInput) (*domain.Pag
 			return nil, fmt.Errorf("insert page link: %w", err)
 		}
 	}
+	if _, err = s.recordPageRevision(ctx, tx, id, storage.RevisionActionCreate, in.Author, nil, now); err != nil {
+		return nil, err
+	}
+	if err = auditPageChange(ctx, tx, storage.AuditActionCreate, id, nil, now); err != nil {
+		return nil, err
+	}
+	if !in.IsTemplate {
+		if err = notifyContentMentions(ctx, tx, id, storage.ChangeActor(ctx, in.Author), "", in.Content, now); err != nil {
+			return nil, err
+		}
+	}
 
 	if err = tx.Commit(); err != nil {
 		return nil, fmt.Errorf("commit transaction: %w", err)
@@ -190,22 +419,25 @@ func (s *Store) CreatePage(ctx context.Context, in CreatePageInput) (*domain.Pag
 		Tags:          in.Tags,
 		LinkedPageIDs: cleanedLinks,
 		IsArchived:    false,
+		IsTemplate:    in.IsTemplate,
+		Version:       1,
 		CreatedAt:     now,
 		UpdatedAt:     now,
 	}, nil
 }
 
-// GetPage retrieves a page by id.
+// GetPage retrieves a page by id. It returns storage.ErrPageNotFound when the page does
+// not exist.
 func (s *Store) GetPage(ctx context.Context, id string) (*domain.Page, error) {
-	row := s.db.QueryRowContext(ctx, `SELECT id, slug, title, summary, content, parent_page_id, cover_image_id, icon, tags, is_archived, created_at, updated_at FROM pages WHERE id = ?`, id)
+	row := s.reader.QueryRowContext(ctx, `SELECT id, slug, title, summary, content, parent_page_id, cover_image_id, icon, tags, is_archived, is_template, version, created_at, updated_at FROM pages WHERE id = ?`, id)
 	var page domain.Page
 	var tags string
 	var parent sql.NullString
 	var cover sql.NullString
 	var icon sql.NullString
-	if err := row.Scan(&page.ID, &page.Slug, &page.Title, &page.Summary, &page.Content, &parent, &cover, &icon, &tags, &page.IsArchived, &page.CreatedAt, &page.UpdatedAt); err != nil {
+	if err := row.Scan(&page.ID, &page.Slug, &page.Title, &page.Summary, &page.Content, &parent, &cover, &icon, &tags, &page.IsArchived, &page.IsTemplate, &page.Version, &page.CreatedAt, &page.UpdatedAt); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil
+			return nil, storage.ErrPageNotFound
 		}
 		return nil, fmt.Errorf("scan page: %w", err)
 	}
@@ -227,13 +459,28 @@ func (s *Store) GetPage(ctx context.Context, id string) (*domain.Page, error) {
 	if err != nil {
 		return nil, err
 	}
-	page.LinkedPageIDs = links.outbound
-	page.BacklinkedPageIDs = links.inbound
+	page.LinkedPageIDs = links.Outbound
+	page.BacklinkedPageIDs = links.Inbound
+	if page.CommentCounts, err = loadCommentCounts(ctx, s.reader, page.ID); err != nil {
+		return nil, err
+	}
 	return &page, nil
 }
 
-func (s *Store) loadPageLinks(ctx context.Context, id string) (*pageLinks, error) {
-	rows, err := s.db.QueryContext(ctx, `SELECT target_page_id FROM page_links WHERE source_page_id = ? ORDER BY target_page_id`, id)
+// ListPageLinks returns the pages a page links to and the pages linking to it.
+func (s *Store) ListPageLinks(ctx context.Context, pageID string) (*domain.PageLinks, error) {
+	page, err := loadPageRow(ctx, s.reader, pageID)
+	if err != nil {
+		return nil, err
+	}
+	if page == nil {
+		return nil, storage.ErrPageNotFound
+	}
+	return s.loadPageLinks(ctx, pageID)
+}
+
+func (s *Store) loadPageLinks(ctx context.Context, id string) (*domain.PageLinks, error) {
+	rows, err := s.reader.QueryContext(ctx, `SELECT target_page_id FROM page_links WHERE source_page_id = ? ORDER BY target_page_id`, id)
 	if err != nil {
 		return nil, fmt.Errorf("select outbound links: %w", err)
 	}
@@ -250,7 +497,7 @@ func (s *Store) loadPageLinks(ctx context.Context, id string) (*pageLinks, error
 		return nil, fmt.Errorf("iterate outbound links: %w", err)
 	}
 
-	inboundRows, err := s.db.QueryContext(ctx, `SELECT source_page_id FROM page_links WHERE target_page_id = ? ORDER BY source_page_id`, id)
+	inboundRows, err := s.reader.QueryContext(ctx, `SELECT source_page_id FROM page_links WHERE target_page_id = ? ORDER BY source_page_id`, id)
 	if err != nil {
 		return nil, fmt.Errorf("select inbound links: %w", err)
 	}
@@ -267,37 +514,13 @@ func (s *Store) loadPageLinks(ctx context.Context, id string) (*pageLinks, error
 		return nil, fmt.Errorf("iterate inbound links: %w", err)
 	}
 
-	return &pageLinks{outbound: outbound, inbound: inbound}, nil
+	return &domain.PageLinks{PageID: id, Outbound: outbound, Inbound: inbound}, nil
 }
 
-type pageLinks struct {
-	outbound []string
-	inbound  []string
-}
-
-func uniqueLinkedPageIDs(ids []string, currentID string) []string {
-	dedup := make(map[string]struct{}, len(ids))
-	for _, id := range ids {
-		trimmed := strings.TrimSpace(id)
-		if trimmed == "" || trimmed == currentID {
-			continue
-		}
-		dedup[trimmed] = struct{}{}
-	}
-	if len(dedup) == 0 {
-		return nil
-	}
-	result := make([]string, 0, len(dedup))
-	for id := range dedup {
-		result = append(result, id)
-	}
-	sort.Strings(result)
-	return result
-}
-
-// ListPages returns a lightweight listing of stored pages.
+// ListPages returns a lightweight listing of stored pages. Template pages are
+// excluded; use ListPageTemplates to browse them.
 func (s *Store) ListPages(ctx context.Context) ([]domain.Page, error) {
-	rows, err := s.db.QueryContext(ctx, `SELECT id, slug, title, summary, parent_page_id FROM pages ORDER BY title`)
+	rows, err := s.reader.QueryContext(ctx, `SELECT id, slug, title, summary, parent_page_id FROM pages WHERE is_template = 0 ORDER BY title`)
 	if err != nil {
 		return nil, fmt.Errorf("select pages: %w", err)
 	}
@@ -320,62 +543,34 @@ func (s *Store) ListPages(ctx context.Context) ([]domain.Page, error) {
 	return pages, nil
 }
 
-// CreateDatabaseInput defines payload for new database.
-type CreateDatabaseInput struct {
-	Slug        string
-	Title       string
-	Description string
-	Icon        *string
-	CoverImage  *string
-	Properties  []DatabasePropertyInput
-	Views       []DatabaseViewInput
-}
-
-// DatabasePropertyInput describes a property definition.
-type DatabasePropertyInput struct {
-	Name       string
-	Slug       string
-	Type       domain.PropertyType
-	Config     map[string]any
-	IsRequired bool
-	Default    any
-	OrderIndex int
-}
-
-// DatabaseViewInput describes saved view configuration.
-type DatabaseViewInput struct {
-	Name          string
-	Type          domain.ViewType
-	Filters       map[string]any
-	Sorts         []domain.ViewSort
-	Grouping      map[string]any
-	Display       []string
-	LayoutOptions map[string]any
-}
-
-// CreateDatabase persists a database with properties and views.
-func (s *Store) CreateDatabase(ctx context.Context, in CreateDatabaseInput) (*domain.Database, error) {
-	if in.Slug == "" || in.Title == "" {
-		return nil, errors.New("slug and title are required")
+// CreateDatabase persists a database with properties and views. A database
+// without a slug gets a unique one generated from its title.
+func (s *Store) CreateDatabase(ctx context.Context, in storage.CreateDatabaseInput) (*domain.Database, error) {
+	if err := storage.RequireFields("title", in.Title); err != nil {
+		return nil, err
 	}
-	tx, err := s.db.BeginTx(ctx, nil)
+	tx, err := s.begin(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("begin tx: %w", err)
 	}
-	defer func() {
-		if err != nil {
-			_ = tx.Rollback()
+	defer func() { _ = tx.Rollback() }()
+	if strings.TrimSpace(in.Slug) == "" {
+		if in.Slug, err = uniqueSlug(ctx, tx, "databases", storage.SlugOrDefault(in.Title, "database")); err != nil {
+			return nil, err
 		}
-	}()
+	}
 	now := time.Now().UTC()
 	dbID := uuid.NewString()
-	_, err = tx.ExecContext(ctx, `INSERT INTO databases(id, slug, title, description, icon, cover_image_id, is_archived, created_at, updated_at) VALUES(?, ?, ?, ?, ?, ?, 0, ?, ?)`,
-		dbID, in.Slug, in.Title, in.Description, in.Icon, in.CoverImage, now, now)
+	_, err = tx.ExecContext(ctx, `INSERT INTO databases(id, slug, title, description, icon, cover_image_id, is_archived, is_template, created_at, updated_at) VALUES(?, ?, ?, ?, ?, ?, 0, ?, ?, ?)`,
+		dbID, in.Slug, in.Title, in.Description, in.Icon, in.CoverImage, boolToInt(in.IsTemplate), now, now)
 	if err != nil {
-		return nil, fmt.Errorf("insert database: %w", err)
+		return nil, insertError("database", in.Slug, err)
 	}
 	props := make([]domain.DatabaseProperty, 0, len(in.Properties))
-	for _, propInput := range in.Properties {
+	for i, propInput := range in.Properties {
+		if err := validatePropertyConfig(fmt.Sprintf("properties[%d].config", i), propInput); err != nil {
+			return nil, err
+		}
 		propID := uuid.NewString()
 		cfg, err := json.Marshal(propInput.Config)
 		if err != nil {
@@ -388,7 +583,7 @@ func (s *Store) CreateDatabase(ctx context.Context, in CreateDatabaseInput) (*do
 		_, err = tx.ExecContext(ctx, `INSERT INTO database_properties(id, database_id, name, slug, type, config, is_required, default_value, order_index, created_at, updated_at) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 			propID, dbID, propInput.Name, propInput.Slug, string(propInput.Type), string(cfg), boolToInt(propInput.IsRequired), string(defVal), propInput.OrderIndex, now, now)
 		if err != nil {
-			return nil, fmt.Errorf("insert property: %w", err)
+			return nil, insertError("property", propInput.Slug, err)
 		}
 		props = append(props, domain.DatabaseProperty{
 			ID:         propID,
@@ -442,14 +637,12 @@ func (s *Store) CreateDatabase(ctx context.Context, in CreateDatabaseInput) (*do
 			Grouping:      viewInput.Grouping,
 			Display:       viewInput.Display,
 			LayoutOptions: viewInput.LayoutOptions,
+			Version:       1,
 			CreatedAt:     now,
 			UpdatedAt:     now,
 		})
 	}
-	if err = tx.Commit(); err != nil {
-		return nil, fmt.Errorf("commit db: %w", err)
-	}
-	return &domain.Database{
+	database := &domain.Database{
 		ID:          dbID,
 		Slug:        in.Slug,
 		Title:       in.Title,
@@ -457,20 +650,41 @@ func (s *Store) CreateDatabase(ctx context.Context, in CreateDatabaseInput) (*do
 		Icon:        in.Icon,
 		CoverImage:  in.CoverImage,
 		IsArchived:  false,
+		IsTemplate:  in.IsTemplate,
+		Version:     1,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 		Properties:  props,
 		Views:       views,
-	}, nil
+	}
+	if err = auditDatabaseCreate(ctx, tx, database, now); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit db: %w", err)
+	}
+	return database, nil
 }
 
-// GetDatabase fetches a database and eager loads properties and views.
+// GetDatabase fetches a database and eager loads properties and views. It
+// returns storage.ErrDatabaseNotFound when the database does not exist.
 func (s *Store) GetDatabase(ctx context.Context, id string) (*domain.Database, error) {
-	row := s.db.QueryRowContext(ctx, `SELECT id, slug, title, description, icon, cover_image_id, is_archived, created_at, updated_at FROM databases WHERE id = ?`, id)
+	db, err := s.loadDatabase(ctx, s.reader, id)
+	if err != nil {
+		return nil, err
+	}
+	if db == nil {
+		return nil, storage.ErrDatabaseNotFound
+	}
+	return db, nil
+}
+
+func (s *Store) loadDatabase(ctx context.Context, q queryer, id string) (*domain.Database, error) {
+	row := q.QueryRowContext(ctx, `SELECT id, slug, title, description, icon, cover_image_id, is_archived, is_template, version, created_at, updated_at FROM databases WHERE id = ?`, id)
 	var dbModel domain.Database
 	var icon sql.NullString
 	var cover sql.NullString
-	if err := row.Scan(&dbModel.ID, &dbModel.Slug, &dbModel.Title, &dbModel.Description, &icon, &cover, &dbModel.IsArchived, &dbModel.CreatedAt, &dbModel.UpdatedAt); err != nil {
+	if err := row.Scan(&dbModel.ID, &dbModel.Slug, &dbModel.Title, &dbModel.Description, &icon, &cover, &dbModel.IsArchived, &dbModel.IsTemplate, &dbModel.Version, &dbModel.CreatedAt, &dbModel.UpdatedAt); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
@@ -482,7 +696,7 @@ func (s *Store) GetDatabase(ctx context.Context, id string) (*domain.Database, e
 	if cover.Valid {
 		dbModel.CoverImage = &cover.String
 	}
-	propsRows, err := s.db.QueryContext(ctx, `SELECT id, name, slug, type, config, is_required, default_value, order_index, created_at, updated_at FROM database_properties WHERE database_id = ? ORDER BY order_index ASC`, dbModel.ID)
+	propsRows, err := q.QueryContext(ctx, `SELECT id, name, slug, type, config, is_required, default_value, order_index, created_at, updated_at FROM database_properties WHERE database_id = ? ORDER BY order_index ASC`, dbModel.ID)
 	if err != nil {
 		return nil, fmt.Errorf("query properties: %w", err)
 	}
@@ -507,108 +721,107 @@ func (s *Store) GetDatabase(ctx context.Context, id string) (*domain.Database, e
 		prop.IsRequired = isReq == 1
 		dbModel.Properties = append(dbModel.Properties, prop)
 	}
-	viewRows, err := s.db.QueryContext(ctx, `SELECT id, name, type, filters, sorts, grouping, display_properties, layout_options, created_at, updated_at FROM database_views WHERE database_id = ? ORDER BY created_at ASC`, dbModel.ID)
+	viewRows, err := q.QueryContext(ctx, `SELECT `+viewColumns+` FROM database_views WHERE database_id = ? ORDER BY created_at ASC`, dbModel.ID)
 	if err != nil {
 		return nil, fmt.Errorf("query views: %w", err)
 	}
 	defer viewRows.Close()
 	for viewRows.Next() {
-		var view domain.DatabaseView
-		var filters, sorts, grouping, display, layout string
-		if err := viewRows.Scan(&view.ID, &view.Name, &view.Type, &filters, &sorts, &grouping, &display, &layout, &view.CreatedAt, &view.UpdatedAt); err != nil {
-			return nil, fmt.Errorf("scan view: %w", err)
-		}
-		view.DatabaseID = dbModel.ID
-		if filters != "" {
-			_ = json.Unmarshal([]byte(filters), &view.Filters)
-		}
-		if sorts != "" {
-			_ = json.Unmarshal([]byte(sorts), &view.Sorts)
-		}
-		if grouping != "" {
-			_ = json.Unmarshal([]byte(grouping), &view.Grouping)
-		}
-		if display != "" {
-			_ = json.Unmarshal([]byte(display), &view.Display)
-		}
-		if layout != "" {
-			_ = json.Unmarshal([]byte(layout), &view.LayoutOptions)
+		view, err := scanViewRow(viewRows)
+		if err != nil {
+			return nil, err
 		}
-		dbModel.Views = append(dbModel.Views, view)
+		dbModel.Views = append(dbModel.Views, *view)
+	}
+	itemTemplates, err := listItemTemplates(ctx, q, dbModel.ID)
+	if err != nil {
+		return nil, err
 	}
+	dbModel.ItemTemplates = itemTemplates
 	return &dbModel, nil
 }
 
-// CreateDatabaseItemInput describes payload for items.
-type CreateDatabaseItemInput struct {
-	DatabaseID string
-	Page       CreatePageInput
-	Position   int
-	Values     map[string]any // keyed by property slug
-}
-
 // CreateDatabaseItem persists a new item and associated page/values.
-func (s *Store) CreateDatabaseItem(ctx context.Context, in CreateDatabaseItemInput) (*domain.DatabaseItem, error) {
-	if in.DatabaseID == "" {
-		return nil, errors.New("database id required")
+func (s *Store) CreateDatabaseItem(ctx context.Context, in storage.CreateDatabaseItemInput) (*domain.DatabaseItem, error) {
+	if err := storage.RequireFields("database_id", in.DatabaseID); err != nil {
+		return nil, err
 	}
-	tx, err := s.db.BeginTx(ctx, nil)
+	tx, err := s.begin(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("begin tx: %w", err)
 	}
-	defer func() {
-		if err != nil {
-			_ = tx.Rollback()
+	defer func() { _ = tx.Rollback() }()
+	item, err := s.createDatabaseItem(ctx, tx, in, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit item: %w", err)
+	}
+	return item, nil
+}
+
+// createDatabaseItem inserts the item page, item row and values using q so it
+// can run as part of a larger transaction. It returns
+// storage.ErrDatabaseNotFound when the database does not exist.
+func (s *Store) createDatabaseItem(ctx context.Context, q queryer, in storage.CreateDatabaseItemInput, now time.Time) (*domain.DatabaseItem, error) {
+	schema, err := s.schema(ctx, q, in.DatabaseID)
+	if err != nil {
+		return nil, err
+	}
+	if in.TemplateID != "" {
+		if err := applyItemTemplate(ctx, q, &in, now); err != nil {
+			return nil, err
 		}
-	}()
-	now := time.Now().UTC()
+	}
+	if strings.TrimSpace(in.Page.Slug) == "" {
+		if in.Page.Slug, err = uniqueSlug(ctx, q, "pages", storage.SlugOrDefault(in.Page.Title, "item")); err != nil {
+			return nil, err
+		}
+	}
 	pageID := uuid.NewString()
 	tagJSON, err := json.Marshal(in.Page.Tags)
 	if err != nil {
 		return nil, fmt.Errorf("marshal page tags: %w", err)
 	}
-	_, err = tx.ExecContext(ctx, `INSERT INTO pages(id, slug, title, summary, content, parent_page_id, tags, is_archived, created_at, updated_at) VALUES(?, ?, ?, ?, ?, ?, ?, 0, ?, ?)`,
-		pageID, in.Page.Slug, in.Page.Title, in.Page.Summary, in.Page.Content, in.Page.ParentPageID, string(tagJSON), now, now)
+	isTemplate, err := inheritsTemplate(ctx, q, in.DatabaseID, in.Page.ParentPageID)
 	if err != nil {
-		return nil, fmt.Errorf("insert item page: %w", err)
+		return nil, err
 	}
-	itemID := uuid.NewString()
-	_, err = tx.ExecContext(ctx, `INSERT INTO database_items(id, database_id, page_id, position, is_archived, created_at, updated_at) VALUES(?, ?, ?, ?, 0, ?, ?)`,
-		itemID, in.DatabaseID, pageID, in.Position, now, now)
+	_, err = q.ExecContext(ctx, `INSERT INTO pages(id, slug, title, summary, content, parent_page_id, tags, is_archived, is_template, created_at, updated_at) VALUES(?, ?, ?, ?, ?, ?, ?, 0, ?, ?, ?)`,
+		pageID, in.Page.Slug, in.Page.Title, in.Page.Summary, in.Page.Content, in.Page.ParentPageID, string(tagJSON), boolToInt(isTemplate), now, now)
 	if err != nil {
-		return nil, fmt.Errorf("insert database item: %w", err)
+		return nil, insertError("item page", in.Page.Slug, err)
 	}
-	// load property map to map slug->id
-	propRows, err := tx.QueryContext(ctx, `SELECT id, slug FROM database_properties WHERE database_id = ?`, in.DatabaseID)
+	itemID := uuid.NewString()
+	rank, err := nextItemRank(ctx, q, in.DatabaseID)
 	if err != nil {
-		return nil, fmt.Errorf("query properties: %w", err)
+		return nil, err
 	}
-	propMap := make(map[string]string)
-	for propRows.Next() {
-		var id, slug string
-		if err := propRows.Scan(&id, &slug); err != nil {
-			propRows.Close()
-			return nil, fmt.Errorf("scan property: %w", err)
-		}
-		propMap[slug] = id
+	_, err = q.ExecContext(ctx, `INSERT INTO database_items(id, database_id, page_id, position, rank, is_archived, created_at, updated_at) VALUES(?, ?, ?, ?, ?, 0, ?, ?)`,
+		itemID, in.DatabaseID, pageID, in.Position, rank, now, now)
+	if err != nil {
+		return nil, fmt.Errorf("insert database item: %w", err)
 	}
-	propRows.Close()
 	storedValues := make(map[string]domain.DatabaseValue)
 	for slug, value := range in.Values {
-		propID, ok := propMap[slug]
+		propID, ok := schema.slugs[slug]
 		if !ok {
-			return nil, fmt.Errorf("unknown property slug %s", slug)
+			return nil, storage.InvalidField("values."+slug, "refers to an unknown property")
 		}
 		valueID := uuid.NewString()
 		raw, err := json.Marshal(value)
 		if err != nil {
 			return nil, fmt.Errorf("marshal value %s: %w", slug, err)
 		}
-		_, err = tx.ExecContext(ctx, `INSERT INTO database_values(id, database_item_id, property_id, value, is_computed, created_at, updated_at) VALUES(?, ?, ?, ?, 0, ?, ?)`,
+		_, err = q.ExecContext(ctx, `INSERT INTO database_values(id, database_item_id, property_id, value, is_computed, created_at, updated_at) VALUES(?, ?, ?, ?, 0, ?, ?)`,
 			valueID, itemID, propID, string(raw), now, now)
 		if err != nil {
 			return nil, fmt.Errorf("insert value %s: %w", slug, err)
 		}
+		if err := indexValue(ctx, q, itemID, propID, raw); err != nil {
+			return nil, err
+		}
 		storedValues[slug] = domain.DatabaseValue{
 			ID:         valueID,
 			ItemID:     itemID,
@@ -619,54 +832,90 @@ func (s *Store) CreateDatabaseItem(ctx context.Context, in CreateDatabaseItemInp
 			UpdatedAt:  now,
 		}
 	}
-	if err = tx.Commit(); err != nil {
-		return nil, fmt.Errorf("commit item: %w", err)
-	}
-	return &domain.DatabaseItem{
+	item := &domain.DatabaseItem{
 		ID:         itemID,
 		DatabaseID: in.DatabaseID,
 		Page: domain.Page{
-			ID:        pageID,
-			Slug:      in.Page.Slug,
-			Title:     in.Page.Title,
-			Summary:   in.Page.Summary,
-			Content:   in.Page.Content,
-			Tags:      in.Page.Tags,
-			CreatedAt: now,
-			UpdatedAt: now,
+			ID:           pageID,
+			Slug:         in.Page.Slug,
+			Title:        in.Page.Title,
+			Summary:      in.Page.Summary,
+			Content:      in.Page.Content,
+			ParentPageID: in.Page.ParentPageID,
+			Tags:         in.Page.Tags,
+			IsTemplate:   isTemplate,
+			Version:      1,
+			CreatedAt:    now,
+			UpdatedAt:    now,
 		},
 		Position:    in.Position,
+		Rank:        rank,
 		IsArchived:  false,
+		Version:     1,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 		PropertyMap: storedValues,
-	}, nil
+	}
+	if err := recordAudit(ctx, q, storage.AuditActionCreate, storage.AuditEntityItem, itemID, nil, item, now); err != nil {
+		return nil, err
+	}
+	if isTemplate {
+		return item, nil
+	}
+	actor := storage.ChangeActor(ctx, in.Page.Author)
+	if err := notifyContentMentions(ctx, q, pageID, actor, "", in.Page.Content, now); err != nil {
+		return nil, err
+	}
+	if err := s.notifyItemChange(ctx, q, actor, nil, item, now); err != nil {
+		return nil, err
+	}
+	if err := scheduleItemReminders(ctx, q, itemID, now); err != nil {
+		return nil, err
+	}
+	return item, nil
 }
 
-// ListViewItems fetches items rendered for a view (basic filters).
+// ListViewItems fetches the items of a view that match its filters.
 func (s *Store) ListViewItems(ctx context.Context, databaseID, viewID string) ([]domain.DatabaseItem, error) {
-	if databaseID == "" || viewID == "" {
-		return nil, errors.New("database id and view id required")
-	}
-	var ok int
-	err := s.db.QueryRowContext(ctx, `SELECT 1 FROM database_views WHERE id = ? AND database_id = ?`, viewID, databaseID).Scan(&ok)
-	if errors.Is(err, sql.ErrNoRows) {
-		return nil, ErrViewNotFound
-	}
+	result, err := s.QueryView(ctx, databaseID, viewID)
 	if err != nil {
-		return nil, fmt.Errorf("verify view: %w", err)
+		return nil, err
 	}
-	rows, err := s.db.QueryContext(ctx, `SELECT di.id, di.page_id, di.position, di.is_archived, di.created_at, di.updated_at, p.slug, p.title, p.summary, p.content, p.tags FROM database_items di JOIN pages p ON di.page_id = p.id WHERE di.database_id = ? ORDER BY di.position ASC, di.created_at ASC`, databaseID)
+	return result.Items, nil
+}
+
+// listDatabaseItems loads every non-archived item of a database in rank order
+// with its values keyed by property slug.
+func (s *Store) listDatabaseItems(ctx context.Context, databaseID string) ([]domain.DatabaseItem, error) {
+	return s.queryDatabaseItems(ctx, databaseID, itemQuery{})
+}
+
+// queryDatabaseItems loads the non-archived items of a database selected and
+// ordered by query, falling back to rank order, with their values keyed by
+// property slug.
+func (s *Store) queryDatabaseItems(ctx context.Context, databaseID string, query itemQuery) ([]domain.DatabaseItem, error) {
+	where := `di.database_id = ? AND di.is_archived = 0`
+	whereArgs := append([]any{databaseID}, query.args...)
+	if query.where != "" {
+		where += ` AND ` + query.where
+	}
+	order := `di.rank ASC, di.created_at ASC`
+	if query.order != "" {
+		order = query.order + `, ` + order
+	}
+	rows, err := s.reader.QueryContext(ctx, `SELECT di.id, di.page_id, di.position, di.rank, di.is_archived, di.version, di.created_at, di.updated_at, p.slug, p.title, p.summary, p.content, p.tags, p.version FROM database_items di JOIN pages p ON di.page_id = p.id `+query.joins+` WHERE `+where+` ORDER BY `+order,
+		append(append([]any{}, query.joinArgs...), whereArgs...)...)
 	if err != nil {
 		return nil, fmt.Errorf("query items: %w", err)
 	}
 	defer rows.Close()
 	var items []domain.DatabaseItem
+	byID := make(map[string]int)
 	for rows.Next() {
 		var item domain.DatabaseItem
 		var page domain.Page
 		var tags string
-		if err := rows.Scan(&item.ID, &page.ID, &item.Position, &item.IsArchived, &item.CreatedAt, &item.UpdatedAt, &page.Slug, &page.Title, &page.Summary, &page.Content, &tags); err != nil {
+		if err := rows.Scan(&item.ID, &page.ID, &item.Position, &item.Rank, &item.IsArchived, &item.Version, &item.CreatedAt, &item.UpdatedAt, &page.Slug, &page.Title, &page.Summary, &page.Content, &tags, &page.Version); err != nil {
 			return nil, fmt.Errorf("scan item: %w", err)
 		}
 		if tags != "" {
@@ -677,49 +926,41 @@ func (s *Store) ListViewItems(ctx context.Context, databaseID, viewID string) ([
 		item.DatabaseID = databaseID
 		item.Page = page
 		item.PropertyMap = make(map[string]domain.DatabaseValue)
+		byID[item.ID] = len(items)
 		items = append(items, item)
 	}
-	// load property slugs for view display
-	propRows, err := s.db.QueryContext(ctx, `SELECT dp.id, dp.slug FROM database_properties dp WHERE dp.database_id = ?`, databaseID)
-	if err != nil {
-		return nil, fmt.Errorf("query property map: %w", err)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate items: %w", err)
 	}
-	propIDs := make(map[string]string)
-	for propRows.Next() {
-		var id, slug string
-		if err := propRows.Scan(&id, &slug); err != nil {
-			propRows.Close()
-			return nil, fmt.Errorf("scan property map: %w", err)
-		}
-		propIDs[id] = slug
+	rows.Close()
+	if len(items) == 0 {
+		return items, nil
 	}
-	propRows.Close()
-	for idx := range items {
-		rows, err := s.db.QueryContext(ctx, `SELECT dv.id, dv.property_id, dv.value, dv.is_computed, dv.created_at, dv.updated_at FROM database_values dv WHERE dv.database_item_id = ?`, items[idx].ID)
-		if err != nil {
-			return nil, fmt.Errorf("query item values: %w", err)
-		}
-		for rows.Next() {
-			var value domain.DatabaseValue
-			var raw string
-			var isComputed int
-			if err := rows.Scan(&value.ID, &value.PropertyID, &raw, &isComputed, &value.CreatedAt, &value.UpdatedAt); err != nil {
-				rows.Close()
-				return nil, fmt.Errorf("scan value: %w", err)
-			}
-			if raw != "" {
-				var parsed any
-				if err := json.Unmarshal([]byte(raw), &parsed); err == nil {
-					value.RawValue = parsed
-				}
-			}
-			value.ItemID = items[idx].ID
-			value.IsComputed = isComputed == 1
-			if slug, ok := propIDs[value.PropertyID]; ok {
-				items[idx].PropertyMap[slug] = value
+	valueRows, err := s.reader.QueryContext(ctx, `SELECT dv.database_item_id, dv.id, dv.property_id, dp.slug, dv.value, dv.is_computed, dv.created_at, dv.updated_at FROM database_values dv JOIN database_properties dp ON dp.id = dv.property_id JOIN database_items di ON di.id = dv.database_item_id WHERE `+where, whereArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("query item values: %w", err)
+	}
+	defer valueRows.Close()
+	for valueRows.Next() {
+		var value domain.DatabaseValue
+		var slug, raw string
+		var isComputed int
+		if err := valueRows.Scan(&value.ItemID, &value.ID, &value.PropertyID, &slug, &raw, &isComputed, &value.CreatedAt, &value.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan value: %w", err)
+		}
+		if raw != "" {
+			var parsed any
+			if err := json.Unmarshal([]byte(raw), &parsed); err == nil {
+				value.RawValue = parsed
 			}
 		}
-		rows.Close()
+		value.IsComputed = isComputed == 1
+		if idx, ok := byID[value.ItemID]; ok {
+			items[idx].PropertyMap[slug] = value
+		}
+	}
+	if err := valueRows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate item values: %w", err)
 	}
 	return items, nil
 }
@@ -10,13 +10,14 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
 )
 
 func TestStoreCreateAndGetPage(t *testing.T) {
 	store := newTestStore(t)
 	ctx := context.Background()
 
-	page, err := store.CreatePage(ctx, CreatePageInput{Slug: "welcome", Title: "Welcome", Summary: "intro", Content: "hello"})
+	page, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "welcome", Title: "Welcome", Summary: "intro", Content: "hello"})
 	require.NoError(t, err)
 	require.NotEmpty(t, page.ID)
 
@@ -32,10 +33,10 @@ func TestStoreCreatePageWithLinks(t *testing.T) {
 	store := newTestStore(t)
 	ctx := context.Background()
 
-	anchor, err := store.CreatePage(ctx, CreatePageInput{Slug: "anchor", Title: "Anchor"})
+	anchor, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "anchor", Title: "Anchor"})
 	require.NoError(t, err)
 
-	linked, err := store.CreatePage(ctx, CreatePageInput{Slug: "linked", Title: "Linked", LinkedPageIDs: []string{anchor.ID, anchor.ID, ""}})
+	linked, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "linked", Title: "Linked", LinkedPageIDs: []string{anchor.ID, anchor.ID, ""}})
 	require.NoError(t, err)
 	require.Equal(t, []string{anchor.ID}, linked.LinkedPageIDs)
 
@@ -52,9 +53,9 @@ func TestStoreListPages(t *testing.T) {
 	store := newTestStore(t)
 	ctx := context.Background()
 
-	first, err := store.CreatePage(ctx, CreatePageInput{Slug: "first", Title: "First"})
+	first, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "first", Title: "First"})
 	require.NoError(t, err)
-	_, err = store.CreatePage(ctx, CreatePageInput{Slug: "second", Title: "Second", ParentPageID: &first.ID})
+	_, err = store.CreatePage(ctx, storage.CreatePageInput{Slug: "second", Title: "Second", ParentPageID: &first.ID})
 	require.NoError(t, err)
 
 	pages, err := store.ListPages(ctx)
@@ -71,13 +72,13 @@ func TestStoreCreateDatabaseWithPropertiesAndViews(t *testing.T) {
 
 	icon := "📚"
 	cover := "cover.png"
-	db, err := store.CreateDatabase(ctx, CreateDatabaseInput{
+	db, err := store.CreateDatabase(ctx, storage.CreateDatabaseInput{
 		Slug:        "recipes",
 		Title:       "Recipes",
 		Description: "Family cookbook",
 		Icon:        &icon,
 		CoverImage:  &cover,
-		Properties: []DatabasePropertyInput{
+		Properties: []storage.DatabasePropertyInput{
 			{
 				Name:       "Category",
 				Slug:       "category",
@@ -95,7 +96,7 @@ func TestStoreCreateDatabaseWithPropertiesAndViews(t *testing.T) {
 				OrderIndex: 1,
 			},
 		},
-		Views: []DatabaseViewInput{
+		Views: []storage.DatabaseViewInput{
 			{
 				Name:    "Table",
 				Type:    domain.ViewTypeTable,
@@ -125,20 +126,20 @@ func TestStoreCreateDatabaseItem(t *testing.T) {
 	store := newTestStore(t)
 	ctx := context.Background()
 
-	db, err := store.CreateDatabase(ctx, CreateDatabaseInput{
+	db, err := store.CreateDatabase(ctx, storage.CreateDatabaseInput{
 		Slug:  "inventory",
 		Title: "Inventory",
-		Properties: []DatabasePropertyInput{
+		Properties: []storage.DatabasePropertyInput{
 			{Name: "Name", Slug: "name", Type: domain.PropertyTypeText},
 			{Name: "Quantity", Slug: "qty", Type: domain.PropertyTypeNumber},
 		},
-		Views: []DatabaseViewInput{{Name: "Table", Type: domain.ViewTypeTable}},
+		Views: []storage.DatabaseViewInput{{Name: "Table", Type: domain.ViewTypeTable}},
 	})
 	require.NoError(t, err)
 
-	item, err := store.CreateDatabaseItem(ctx, CreateDatabaseItemInput{
+	item, err := store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
 		DatabaseID: db.ID,
-		Page: CreatePageInput{
+		Page: storage.CreatePageInput{
 			Slug:    "hammer",
 			Title:   "Hammer",
 			Content: "Sturdy hammer",
@@ -209,23 +210,46 @@ func TestStoreListViewItemsViewNotFound(t *testing.T) {
 	store := newTestStore(t)
 	ctx := context.Background()
 
-	db, err := store.CreateDatabase(ctx, CreateDatabaseInput{
+	db, err := store.CreateDatabase(ctx, storage.CreateDatabaseInput{
 		Slug:       "inventory",
 		Title:      "Inventory",
-		Properties: []DatabasePropertyInput{{Name: "Name", Slug: "name", Type: domain.PropertyTypeText}},
-		Views:      []DatabaseViewInput{{Name: "Table", Type: domain.ViewTypeTable}},
+		Properties: []storage.DatabasePropertyInput{{Name: "Name", Slug: "name", Type: domain.PropertyTypeText}},
+		Views:      []storage.DatabaseViewInput{{Name: "Table", Type: domain.ViewTypeTable}},
 	})
 	require.NoError(t, err)
 
 	_, err = store.ListViewItems(ctx, db.ID, "missing")
-	require.ErrorIs(t, err, ErrViewNotFound)
+	require.ErrorIs(t, err, storage.ErrViewNotFound)
 }
 
 func TestStoreCreatePageValidation(t *testing.T) {
 	store := newTestStore(t)
 	ctx := context.Background()
 
-	_, err := store.CreatePage(ctx, CreatePageInput{Title: "No slug"})
-	require.Error(t, err)
-	require.Contains(t, err.Error(), "slug and title")
+	_, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "untitled"})
+	require.ErrorIs(t, err, storage.ErrValidation)
+	require.Equal(t, []storage.FieldError{{Field: "title", Message: "is required"}}, storage.AsError(err).Fields)
+}
+
+func TestStoreSlugConflict(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	_, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "notes", Title: "Notes"})
+	require.NoError(t, err)
+	_, err = store.CreatePage(ctx, storage.CreatePageInput{Slug: "notes", Title: "Again"})
+	require.ErrorIs(t, err, storage.ErrSlugConflict)
+	require.ErrorIs(t, err, storage.ErrConflict)
+	require.Equal(t, `slug "notes" is already in use`, err.Error())
+
+	_, err = store.CreateDatabase(ctx, storage.CreateDatabaseInput{Slug: "tasks", Title: "Tasks", Properties: []storage.DatabasePropertyInput{
+		{Name: "Name", Slug: "name", Type: domain.PropertyTypeText},
+		{Name: "Other name", Slug: "name", Type: domain.PropertyTypeText},
+	}})
+	require.ErrorIs(t, err, storage.ErrSlugConflict)
+
+	_, err = store.GetDatabase(ctx, "missing")
+	require.ErrorIs(t, err, storage.ErrDatabaseNotFound)
+	require.ErrorIs(t, err, storage.ErrNotFound)
+	require.NotErrorIs(t, err, storage.ErrPageNotFound)
 }
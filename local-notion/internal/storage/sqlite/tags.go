@@ -0,0 +1,214 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// ErrTagNotFound is returned when no page carries a tag.
+var ErrTagNotFound = storage.NotFoundError("tag_not_found", "tag not found")
+
+// Page tags are stored on the page as a JSON array. The tags and page_tags
+// tables index them and are maintained by triggers on pages (see migration
+// 012_tags.sql); renaming or merging tags rewrites the pages themselves.
+
+// ListTags returns every tag in use with the number of pages carrying it,
+// most used first. Template pages are not counted.
+func (s *Store) ListTags(ctx context.Context) ([]domain.Tag, error) {
+	rows, err := s.reader.QueryContext(ctx, `SELECT t.name, COUNT(p.id) FROM tags t
+JOIN page_tags pt ON pt.tag_id = t.id
+JOIN pages p ON p.id = pt.page_id AND p.is_template = 0
+GROUP BY t.id ORDER BY COUNT(p.id) DESC, t.name ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("query tags: %w", err)
+	}
+	defer rows.Close()
+	tags := []domain.Tag{}
+	for rows.Next() {
+		var tag domain.Tag
+		if err := rows.Scan(&tag.Name, &tag.PageCount); err != nil {
+			return nil, fmt.Errorf("scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// ListPagesByTags returns the pages carrying all of the given tags, or any of
+// them when matchAll is false, in the lightweight form of ListPages.
+func (s *Store) ListPagesByTags(ctx context.Context, tags []string, matchAll bool) ([]domain.Page, error) {
+	tags = normalizeTags(tags)
+	if len(tags) == 0 {
+		return nil, storage.InvalidField("tag", "at least one tag is required")
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(tags)), ", ")
+	args := make([]any, 0, len(tags)+1)
+	for _, tag := range tags {
+		args = append(args, tag)
+	}
+	required := 1
+	if matchAll {
+		required = len(tags)
+	}
+	args = append(args, required)
+	rows, err := s.reader.QueryContext(ctx, `SELECT p.id, p.slug, p.title, p.summary, p.parent_page_id FROM pages p
+JOIN page_tags pt ON pt.page_id = p.id
+JOIN tags t ON t.id = pt.tag_id
+WHERE t.name IN (`+placeholders+`) AND p.is_template = 0
+GROUP BY p.id HAVING COUNT(*) >= ?
+ORDER BY p.title ASC, p.id ASC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query tagged pages: %w", err)
+	}
+	defer rows.Close()
+	pages := []domain.Page{}
+	for rows.Next() {
+		var page domain.Page
+		var parent sql.NullString
+		if err := rows.Scan(&page.ID, &page.Slug, &page.Title, &page.Summary, &parent); err != nil {
+			return nil, fmt.Errorf("scan tagged page: %w", err)
+		}
+		if parent.Valid {
+			page.ParentPageID = &parent.String
+		}
+		pages = append(pages, page)
+	}
+	return pages, rows.Err()
+}
+
+// RenameTag renames a tag everywhere. Renaming to a tag that already exists
+// merges the two.
+func (s *Store) RenameTag(ctx context.Context, in storage.RenameTagInput) (*domain.Tag, error) {
+	return s.MergeTags(ctx, storage.MergeTagsInput{Sources: []string{in.Name}, Target: in.NewName, Author: in.Author})
+}
+
+// MergeTags replaces every source tag with the target tag on every page
+// carrying one, recording a revision and an audit entry for each changed
+// page. It returns ErrTagNotFound when no page carries any source tag.
+func (s *Store) MergeTags(ctx context.Context, in storage.MergeTagsInput) (*domain.Tag, error) {
+	target := strings.TrimSpace(in.Target)
+	if target == "" {
+		return nil, storage.InvalidField("name", "cannot be empty")
+	}
+	sources := normalizeTags(in.Sources)
+	if len(sources) == 0 {
+		return nil, storage.InvalidField("sources", "at least one tag is required")
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(sources)), ", ")
+	args := make([]any, len(sources))
+	for idx, tag := range sources {
+		args[idx] = tag
+	}
+	rows, err := tx.QueryContext(ctx, `SELECT DISTINCT pt.page_id, p.tags FROM page_tags pt
+JOIN tags t ON t.id = pt.tag_id
+JOIN pages p ON p.id = pt.page_id
+WHERE t.name IN (`+placeholders+`) ORDER BY pt.page_id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query tagged pages: %w", err)
+	}
+	type taggedPage struct {
+		id   string
+		tags []string
+	}
+	var pages []taggedPage
+	for rows.Next() {
+		var page taggedPage
+		var raw string
+		if err := rows.Scan(&page.id, &raw); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan tagged page: %w", err)
+		}
+		if err := json.Unmarshal([]byte(raw), &page.tags); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("unmarshal tags of page %s: %w", page.id, err)
+		}
+		pages = append(pages, page)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate tagged pages: %w", err)
+	}
+	if len(pages) == 0 {
+		return nil, ErrTagNotFound
+	}
+	now := time.Now().UTC()
+	for _, page := range pages {
+		retagged := replaceTags(page.tags, sources, target)
+		if slices.Equal(retagged, page.tags) {
+			continue
+		}
+		if err := s.retagPage(ctx, tx, page.id, retagged, in.Author, now); err != nil {
+			return nil, err
+		}
+	}
+	var tag domain.Tag
+	err = tx.QueryRowContext(ctx, `SELECT t.name, COUNT(p.id) FROM tags t
+JOIN page_tags pt ON pt.tag_id = t.id
+LEFT JOIN pages p ON p.id = pt.page_id AND p.is_template = 0
+WHERE t.name = ? GROUP BY t.id`, target).Scan(&tag.Name, &tag.PageCount)
+	if err != nil {
+		return nil, fmt.Errorf("load merged tag: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit tag merge: %w", err)
+	}
+	return &tag, nil
+}
+
+// retagPage replaces the tags of a page the way UpdatePage would.
+func (s *Store) retagPage(ctx context.Context, q queryer, pageID string, tags []string, author string, now time.Time) error {
+	before, err := loadAuditPage(ctx, q, pageID)
+	if err != nil {
+		return err
+	}
+	if err := ensurePageBaseline(ctx, q, pageID, now); err != nil {
+		return err
+	}
+	if err := updatePageFields(ctx, q, storage.UpdatePageInput{PageID: pageID, Tags: tags}, now); err != nil {
+		return err
+	}
+	if _, err := s.recordPageRevision(ctx, q, pageID, storage.RevisionActionUpdate, author, nil, now); err != nil {
+		return err
+	}
+	return auditPageChange(ctx, q, storage.AuditActionUpdate, pageID, before, now)
+}
+
+// replaceTags swaps each source tag for target, keeping the first position
+// any of them had and dropping duplicates.
+func replaceTags(tags, sources []string, target string) []string {
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if slices.Contains(sources, tag) {
+			tag = target
+		}
+		if !slices.Contains(out, tag) {
+			out = append(out, tag)
+		}
+	}
+	return out
+}
+
+// normalizeTags trims tag names and drops blanks and duplicates.
+func normalizeTags(tags []string) []string {
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !slices.Contains(out, tag) {
+			out = append(out, tag)
+		}
+	}
+	return out
+}
@@ -0,0 +1,146 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+func pageSlugs(pages []domain.Page) []string {
+	var slugs []string
+	for _, page := range pages {
+		slugs = append(slugs, page.Slug)
+	}
+	return slugs
+}
+
+func TestTagIndexFollowsPageWrites(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	alpha, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "alpha", Title: "Alpha", Tags: []string{"go", "db"}})
+	require.NoError(t, err)
+	_, err = store.CreatePage(ctx, storage.CreatePageInput{Slug: "beta", Title: "Beta", Tags: []string{"go"}})
+	require.NoError(t, err)
+
+	tags, err := store.ListTags(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []domain.Tag{{Name: "go", PageCount: 2}, {Name: "db", PageCount: 1}}, tags)
+
+	_, err = store.UpdatePage(ctx, storage.UpdatePageInput{PageID: alpha.ID, Tags: []string{"go", "sql"}, ExpectedVersion: &alpha.Version})
+	require.NoError(t, err)
+	tags, err = store.ListTags(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []domain.Tag{{Name: "go", PageCount: 2}, {Name: "sql", PageCount: 1}}, tags)
+
+	db, err := store.CreateDatabase(ctx, storage.CreateDatabaseInput{Slug: "tasks", Title: "Tasks"})
+	require.NoError(t, err)
+	item, err := store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
+		DatabaseID: db.ID,
+		Page:       storage.CreatePageInput{Slug: "task", Title: "Task", Tags: []string{"sql"}},
+	})
+	require.NoError(t, err)
+	pages, err := store.ListPagesByTags(ctx, []string{"sql"}, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"alpha", "task"}, pageSlugs(pages))
+
+	require.NoError(t, store.DeleteDatabaseItem(ctx, db.ID, item.ID))
+	tags, err = store.ListTags(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []domain.Tag{{Name: "go", PageCount: 2}, {Name: "sql", PageCount: 1}}, tags)
+}
+
+func TestListPagesByTagsMatchesAnyOrAll(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	for slug, tags := range map[string][]string{
+		"both":  {"go", "db"},
+		"go":    {"go"},
+		"db":    {"db"},
+		"other": {"misc"},
+	} {
+		_, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: slug, Title: slug, Tags: tags})
+		require.NoError(t, err)
+	}
+
+	pages, err := store.ListPagesByTags(ctx, []string{"go", "db"}, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"both", "db", "go"}, pageSlugs(pages))
+	pages, err = store.ListPagesByTags(ctx, []string{"go", "db", "go"}, true)
+	require.NoError(t, err)
+	require.Equal(t, []string{"both"}, pageSlugs(pages))
+	pages, err = store.ListPagesByTags(ctx, []string{"missing"}, false)
+	require.NoError(t, err)
+	require.Empty(t, pages)
+	_, err = store.ListPagesByTags(ctx, []string{" "}, false)
+	require.ErrorIs(t, err, storage.ErrValidation)
+}
+
+func TestRenameAndMergeTags(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	first, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "first", Title: "First", Tags: []string{"golang", "db"}})
+	require.NoError(t, err)
+	second, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "second", Title: "Second", Tags: []string{"go", "golang"}})
+	require.NoError(t, err)
+
+	// Renaming onto an existing tag merges them without duplicating it.
+	tag, err := store.RenameTag(ctx, storage.RenameTagInput{Name: "golang", NewName: "go", Author: "ana"})
+	require.NoError(t, err)
+	require.Equal(t, &domain.Tag{Name: "go", PageCount: 2}, tag)
+	page, err := store.GetPage(ctx, second.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"go"}, page.Tags)
+	require.Equal(t, second.Version+1, page.Version)
+	revisions, err := store.ListPageRevisions(ctx, second.ID)
+	require.NoError(t, err)
+	require.Equal(t, "ana", revisions[0].Author)
+
+	tag, err = store.MergeTags(ctx, storage.MergeTagsInput{Sources: []string{"go", "db"}, Target: "backend"})
+	require.NoError(t, err)
+	require.Equal(t, &domain.Tag{Name: "backend", PageCount: 2}, tag)
+	page, err = store.GetPage(ctx, first.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"backend"}, page.Tags)
+	tags, err := store.ListTags(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []domain.Tag{{Name: "backend", PageCount: 2}}, tags)
+	entries, _, err := store.ListAuditEntries(ctx, storage.AuditFilter{EntityType: storage.AuditEntityPage, EntityID: first.ID, Action: storage.AuditActionUpdate})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	_, err = store.RenameTag(ctx, storage.RenameTagInput{Name: "golang", NewName: "go"})
+	require.ErrorIs(t, err, ErrTagNotFound)
+	_, err = store.RenameTag(ctx, storage.RenameTagInput{Name: "backend", NewName: " "})
+	require.ErrorIs(t, err, storage.ErrValidation)
+}
+
+func TestMigrationBackfillsTags(t *testing.T) {
+	store := newFileTestStore(t, 0)
+	ctx := context.Background()
+	_, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "alpha", Title: "Alpha", Tags: []string{"go", "db"}})
+	require.NoError(t, err)
+	_, err = store.CreatePage(ctx, storage.CreatePageInput{Slug: "beta", Title: "Beta", Tags: []string{"go"}})
+	require.NoError(t, err)
+
+	// Roll the schema back to before the tag index existed.
+	for _, stmt := range []string{
+		`DROP TRIGGER pages_tags_insert`,
+		`DROP TRIGGER pages_tags_update`,
+		`DROP TRIGGER pages_tags_delete`,
+		`DROP TABLE page_tags`,
+		`DROP TABLE tags`,
+		`DELETE FROM schema_migrations WHERE version = '012_tags.sql'`,
+	} {
+		_, err := store.db.ExecContext(ctx, stmt)
+		require.NoError(t, err, stmt)
+	}
+	require.NoError(t, applyMigrations(store.db))
+
+	tags, err := store.ListTags(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []domain.Tag{{Name: "go", PageCount: 2}, {Name: "db", PageCount: 1}}, tags)
+}
@@ -0,0 +1,472 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.]+)\s*\}\}`)
+
+// expandPlaceholders replaces {{name}} tokens with values from vars. Unknown
+// placeholders are left untouched so they remain visible to the user.
+func expandPlaceholders(text string, vars map[string]string) string {
+	if !strings.Contains(text, "{{") {
+		return text
+	}
+	return placeholderPattern.ReplaceAllStringFunc(text, func(match string) string {
+		name := placeholderPattern.FindStringSubmatch(match)[1]
+		if value, ok := vars[name]; ok {
+			return value
+		}
+		return match
+	})
+}
+
+// templateVars builds the placeholder set used during instantiation. Caller
+// supplied variables never override the built-in date, title and parent.title.
+func templateVars(now time.Time, title, parentTitle string, extra map[string]string) map[string]string {
+	vars := make(map[string]string, len(extra)+3)
+	for key, value := range extra {
+		vars[key] = value
+	}
+	vars["date"] = now.Format("2006-01-02")
+	vars["title"] = title
+	vars["parent.title"] = parentTitle
+	return vars
+}
+
+// expandValue expands placeholders in string values, including strings nested in lists.
+func expandValue(value any, vars map[string]string) any {
+	switch v := value.(type) {
+	case string:
+		return expandPlaceholders(v, vars)
+	case []any:
+		out := make([]any, len(v))
+		for i, elem := range v {
+			out[i] = expandValue(elem, vars)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// remapIDs returns a copy of value where every string equal to a key in ids is
+// replaced by the mapped identifier. Maps and slices are walked recursively.
+func remapIDs(value any, ids map[string]string) any {
+	switch v := value.(type) {
+	case string:
+		if mapped, ok := ids[v]; ok {
+			return mapped
+		}
+		return v
+	case []any:
+		out := make([]any, len(v))
+		for i, elem := range v {
+			out[i] = remapIDs(elem, ids)
+		}
+		return out
+	case []string:
+		out := make([]string, len(v))
+		for i, elem := range v {
+			out[i] = remapIDs(elem, ids).(string)
+		}
+		return out
+	case map[string]any:
+		if v == nil {
+			return v
+		}
+		out := make(map[string]any, len(v))
+		for key, elem := range v {
+			out[key] = remapIDs(elem, ids)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// inheritsTemplate reports whether a new page belongs to a template, either
+// as an item of a template database or as a child of a template page, so it
+// stays out of listings like the template itself.
+func inheritsTemplate(ctx context.Context, q queryer, databaseID string, parentPageID *string) (bool, error) {
+	var parent string
+	if parentPageID != nil {
+		parent = *parentPageID
+	}
+	var inherits bool
+	err := q.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM databases WHERE id = ? AND is_template = 1)
+    OR EXISTS(SELECT 1 FROM pages WHERE id = ? AND is_template = 1)`, databaseID, parent).Scan(&inherits)
+	if err != nil {
+		return false, fmt.Errorf("check template owner: %w", err)
+	}
+	return inherits, nil
+}
+
+// ListPageTemplates returns the root pages of every page template.
+func (s *Store) ListPageTemplates(ctx context.Context) ([]domain.Page, error) {
+	rows, err := s.reader.QueryContext(ctx, `SELECT p.id, p.slug, p.title, p.summary, p.parent_page_id FROM pages p
+LEFT JOIN pages parent ON parent.id = p.parent_page_id
+WHERE p.is_template = 1 AND (parent.id IS NULL OR parent.is_template = 0)
+  AND NOT EXISTS (SELECT 1 FROM database_items di WHERE di.page_id = p.id)
+ORDER BY p.title`)
+	if err != nil {
+		return nil, fmt.Errorf("select page templates: %w", err)
+	}
+	defer rows.Close()
+	var pages []domain.Page
+	for rows.Next() {
+		var page domain.Page
+		var parent sql.NullString
+		if err := rows.Scan(&page.ID, &page.Slug, &page.Title, &page.Summary, &parent); err != nil {
+			return nil, fmt.Errorf("scan page template: %w", err)
+		}
+		if parent.Valid {
+			page.ParentPageID = &parent.String
+		}
+		page.IsTemplate = true
+		pages = append(pages, page)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate page templates: %w", err)
+	}
+	return pages, nil
+}
+
+// InstantiatePageTemplate deep-copies a template page together with its child
+// pages, tags and links. Placeholders in titles, summaries and content are
+// expanded; {{title}} resolves to the new root title and {{parent.title}} to
+// the title of each copied page's new parent.
+func (s *Store) InstantiatePageTemplate(ctx context.Context, in storage.InstantiatePageTemplateInput) (*domain.Page, error) {
+	if err := storage.RequireFields("template_id", in.TemplateID, "slug", in.Slug); err != nil {
+		return nil, err
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	tree, err := loadPageSubtree(ctx, tx, in.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+	if len(tree) == 0 || !tree[0].IsTemplate {
+		return nil, storage.ErrTemplateNotFound
+	}
+	now := time.Now().UTC()
+	parentTitle := ""
+	if in.ParentPageID != nil {
+		if err := tx.QueryRowContext(ctx, `SELECT title FROM pages WHERE id = ?`, *in.ParentPageID).Scan(&parentTitle); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, storage.InvalidField("parent_page_id", "refers to a page that does not exist")
+			}
+			return nil, fmt.Errorf("load parent page: %w", err)
+		}
+	}
+	rootTitle := in.Title
+	if rootTitle == "" {
+		rootTitle = expandPlaceholders(tree[0].Title, templateVars(now, "", parentTitle, in.Variables))
+	}
+
+	newIDs := make(map[string]string, len(tree))
+	newTitles := make(map[string]string, len(tree))
+	for _, tpl := range tree {
+		newIDs[tpl.ID] = uuid.NewString()
+	}
+	for idx, tpl := range tree {
+		page := tpl
+		page.ID = newIDs[tpl.ID]
+		page.IsTemplate = false
+		page.IsArchived = false
+		page.CreatedAt = now
+		page.UpdatedAt = now
+		pageParentTitle := parentTitle
+		if idx == 0 {
+			page.Slug = in.Slug
+			page.Title = rootTitle
+			page.ParentPageID = in.ParentPageID
+		} else {
+			page.Slug = in.Slug + "-" + tpl.Slug
+			newParent := newIDs[*tpl.ParentPageID]
+			page.ParentPageID = &newParent
+			pageParentTitle = newTitles[newParent]
+		}
+		vars := templateVars(now, rootTitle, pageParentTitle, in.Variables)
+		if idx != 0 {
+			page.Title = expandPlaceholders(tpl.Title, vars)
+		}
+		page.Summary = expandPlaceholders(tpl.Summary, vars)
+		page.Content = expandPlaceholders(tpl.Content, vars)
+		newTitles[page.ID] = page.Title
+		if err := insertPageRow(ctx, tx, page); err != nil {
+			return nil, err
+		}
+	}
+	if err := copyPageLinks(ctx, tx, tree, newIDs, now); err != nil {
+		return nil, err
+	}
+	if err := auditPagesCreated(ctx, tx, tree, newIDs, now); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit template instantiation: %w", err)
+	}
+	return s.GetPage(ctx, newIDs[tree[0].ID])
+}
+
+// ListDatabaseTemplates returns lightweight metadata for every database template.
+func (s *Store) ListDatabaseTemplates(ctx context.Context) ([]domain.Database, error) {
+	rows, err := s.reader.QueryContext(ctx, `SELECT id, slug, title, description, created_at, updated_at FROM databases WHERE is_template = 1 ORDER BY title`)
+	if err != nil {
+		return nil, fmt.Errorf("select database templates: %w", err)
+	}
+	defer rows.Close()
+	var databases []domain.Database
+	for rows.Next() {
+		var dbModel domain.Database
+		var description sql.NullString
+		if err := rows.Scan(&dbModel.ID, &dbModel.Slug, &dbModel.Title, &description, &dbModel.CreatedAt, &dbModel.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan database template: %w", err)
+		}
+		dbModel.Description = description.String
+		dbModel.IsTemplate = true
+		databases = append(databases, dbModel)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate database templates: %w", err)
+	}
+	return databases, nil
+}
+
+// InstantiateDatabaseTemplate copies a template database with its properties,
+// views and item templates, optionally seeding the template's items. Property
+// identifiers referenced by views and relation values between seeded items are
+// remapped to the new identifiers.
+func (s *Store) InstantiateDatabaseTemplate(ctx context.Context, in storage.InstantiateDatabaseTemplateInput) (*domain.Database, error) {
+	if err := storage.RequireFields("template_id", in.TemplateID, "slug", in.Slug); err != nil {
+		return nil, err
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	tpl, err := s.loadDatabase(ctx, tx, in.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+	if tpl == nil || !tpl.IsTemplate {
+		return nil, storage.ErrTemplateNotFound
+	}
+	now := time.Now().UTC()
+	title := in.Title
+	if title == "" {
+		title = expandPlaceholders(tpl.Title, templateVars(now, "", "", in.Variables))
+	}
+	vars := templateVars(now, title, "", in.Variables)
+	dbID := uuid.NewString()
+	if _, err := tx.ExecContext(ctx, `INSERT INTO databases(id, slug, title, description, icon, cover_image_id, is_archived, is_template, created_at, updated_at) VALUES(?, ?, ?, ?, ?, ?, 0, 0, ?, ?)`,
+		dbID, in.Slug, title, expandPlaceholders(tpl.Description, vars), tpl.Icon, tpl.CoverImage, now, now); err != nil {
+		return nil, insertError("database", in.Slug, err)
+	}
+	ids := map[string]string{tpl.ID: dbID}
+	if err := copyDatabaseSchema(ctx, tx, tpl, dbID, ids, now); err != nil {
+		return nil, err
+	}
+	if err := s.auditDatabaseCopy(ctx, tx, dbID, now); err != nil {
+		return nil, err
+	}
+	if in.IncludeItems {
+		err := copyDatabaseItems(ctx, tx, tpl.ID, dbID, ids, now, func(page *domain.Page) (func(any) any, error) {
+			pageVars := templateVars(now, page.Title, title, in.Variables)
+			page.Slug = in.Slug + "-" + page.Slug
+			page.Title = expandPlaceholders(page.Title, pageVars)
+			page.Summary = expandPlaceholders(page.Summary, pageVars)
+			page.Content = expandPlaceholders(page.Content, pageVars)
+			page.IsTemplate = false
+			return func(value any) any { return expandValue(value, pageVars) }, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit template instantiation: %w", err)
+	}
+	return s.GetDatabase(ctx, dbID)
+}
+
+// CreateItemTemplate stores an item template used to prefill new database items.
+func (s *Store) CreateItemTemplate(ctx context.Context, in storage.CreateItemTemplateInput) (*domain.DatabaseItemTemplate, error) {
+	if err := storage.RequireFields("database_id", in.DatabaseID, "name", in.Name); err != nil {
+		return nil, err
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	slugs, err := propertySlugs(ctx, tx, in.DatabaseID)
+	if err != nil {
+		return nil, err
+	}
+	for slug := range in.Values {
+		if _, ok := slugs[slug]; !ok {
+			return nil, storage.InvalidField("values."+slug, "refers to an unknown property")
+		}
+	}
+	now := time.Now().UTC()
+	tpl := domain.DatabaseItemTemplate{
+		ID:         uuid.NewString(),
+		DatabaseID: in.DatabaseID,
+		Name:       in.Name,
+		Title:      in.Title,
+		Summary:    in.Summary,
+		Content:    in.Content,
+		Tags:       in.Tags,
+		Values:     in.Values,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := insertItemTemplateRow(ctx, tx, tpl, now); err != nil {
+		return nil, err
+	}
+	if err := recordAudit(ctx, tx, storage.AuditActionCreate, storage.AuditEntityItemTemplate, tpl.ID, nil, tpl, now); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit item template: %w", err)
+	}
+	return &tpl, nil
+}
+
+// applyItemTemplate merges an item template into the item input. Explicit page
+// fields and values win over the template; template strings are expanded with
+// the item title and the database title as {{parent.title}}.
+func applyItemTemplate(ctx context.Context, q queryer, in *storage.CreateDatabaseItemInput, now time.Time) error {
+	templates, err := listItemTemplates(ctx, q, in.DatabaseID)
+	if err != nil {
+		return err
+	}
+	var tpl *domain.DatabaseItemTemplate
+	for i := range templates {
+		if templates[i].ID == in.TemplateID {
+			tpl = &templates[i]
+			break
+		}
+	}
+	if tpl == nil {
+		return storage.ErrTemplateNotFound
+	}
+	var dbTitle string
+	if err := q.QueryRowContext(ctx, `SELECT title FROM databases WHERE id = ?`, in.DatabaseID).Scan(&dbTitle); err != nil {
+		return fmt.Errorf("load database title: %w", err)
+	}
+	if in.Page.Title == "" {
+		in.Page.Title = expandPlaceholders(tpl.Title, templateVars(now, "", dbTitle, nil))
+	}
+	vars := templateVars(now, in.Page.Title, dbTitle, nil)
+	if in.Page.Summary == "" {
+		in.Page.Summary = expandPlaceholders(tpl.Summary, vars)
+	}
+	if in.Page.Content == "" {
+		in.Page.Content = expandPlaceholders(tpl.Content, vars)
+	}
+	if len(in.Page.Tags) == 0 {
+		in.Page.Tags = tpl.Tags
+	}
+	merged := make(map[string]any, len(tpl.Values)+len(in.Values))
+	for slug, value := range tpl.Values {
+		merged[slug] = expandValue(value, vars)
+	}
+	for slug, value := range in.Values {
+		merged[slug] = value
+	}
+	in.Values = merged
+	return nil
+}
+
+func listItemTemplates(ctx context.Context, q queryer, databaseID string) ([]domain.DatabaseItemTemplate, error) {
+	rows, err := q.QueryContext(ctx, `SELECT id, name, title, summary, content, tags, item_values, created_at, updated_at FROM database_item_templates WHERE database_id = ? ORDER BY created_at ASC`, databaseID)
+	if err != nil {
+		return nil, fmt.Errorf("query item templates: %w", err)
+	}
+	defer rows.Close()
+	var templates []domain.DatabaseItemTemplate
+	for rows.Next() {
+		var tpl domain.DatabaseItemTemplate
+		var title, summary, content, tags, values sql.NullString
+		if err := rows.Scan(&tpl.ID, &tpl.Name, &title, &summary, &content, &tags, &values, &tpl.CreatedAt, &tpl.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan item template: %w", err)
+		}
+		tpl.DatabaseID = databaseID
+		tpl.Title = title.String
+		tpl.Summary = summary.String
+		tpl.Content = content.String
+		if tags.String != "" {
+			_ = json.Unmarshal([]byte(tags.String), &tpl.Tags)
+		}
+		if values.String != "" {
+			_ = json.Unmarshal([]byte(values.String), &tpl.Values)
+		}
+		templates = append(templates, tpl)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate item templates: %w", err)
+	}
+	return templates, nil
+}
+
+func insertItemTemplateRow(ctx context.Context, q queryer, tpl domain.DatabaseItemTemplate, now time.Time) error {
+	tags, err := json.Marshal(tpl.Tags)
+	if err != nil {
+		return fmt.Errorf("marshal item template tags: %w", err)
+	}
+	values, err := json.Marshal(tpl.Values)
+	if err != nil {
+		return fmt.Errorf("marshal item template values: %w", err)
+	}
+	if _, err := q.ExecContext(ctx, `INSERT INTO database_item_templates(id, database_id, name, title, summary, content, tags, item_values, created_at, updated_at) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		tpl.ID, tpl.DatabaseID, tpl.Name, tpl.Title, tpl.Summary, tpl.Content, string(tags), string(values), now, now); err != nil {
+		return fmt.Errorf("insert item template: %w", err)
+	}
+	return nil
+}
+
+// remapView rewrites property identifiers referenced by a view configuration.
+func remapView(view domain.DatabaseView, ids map[string]string) domain.DatabaseView {
+	out := view
+	if view.Filters != nil {
+		out.Filters = remapIDs(view.Filters, ids).(map[string]any)
+	}
+	if view.Grouping != nil {
+		out.Grouping = remapIDs(view.Grouping, ids).(map[string]any)
+	}
+	if view.LayoutOptions != nil {
+		out.LayoutOptions = remapIDs(view.LayoutOptions, ids).(map[string]any)
+	}
+	if view.Display != nil {
+		out.Display = remapIDs(view.Display, ids).([]string)
+	}
+	if view.Sorts != nil {
+		out.Sorts = make([]domain.ViewSort, len(view.Sorts))
+		for i, sort := range view.Sorts {
+			out.Sorts[i] = domain.ViewSort{PropertyID: remapIDs(sort.PropertyID, ids).(string), Direction: sort.Direction}
+		}
+	}
+	return out
+}
@@ -0,0 +1,246 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+func TestStoreInstantiatePageTemplate(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	project, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "project", Title: "Project X"})
+	require.NoError(t, err)
+	tpl, err := store.CreatePage(ctx, storage.CreatePageInput{
+		Slug:       "meeting-template",
+		Title:      "Meeting {{date}}",
+		Content:    "Notes for {{title}} under {{parent.title}} by {{author}}",
+		Tags:       []string{"meeting"},
+		IsTemplate: true,
+	})
+	require.NoError(t, err)
+	_, err = store.CreatePage(ctx, storage.CreatePageInput{
+		Slug:          "actions",
+		Title:         "Actions for {{parent.title}}",
+		ParentPageID:  &tpl.ID,
+		LinkedPageIDs: []string{tpl.ID, project.ID},
+		IsTemplate:    true,
+	})
+	require.NoError(t, err)
+
+	pages, err := store.ListPages(ctx)
+	require.NoError(t, err)
+	require.Len(t, pages, 1)
+	templates, err := store.ListPageTemplates(ctx)
+	require.NoError(t, err)
+	require.Len(t, templates, 1)
+	require.Equal(t, tpl.ID, templates[0].ID)
+
+	page, err := store.InstantiatePageTemplate(ctx, storage.InstantiatePageTemplateInput{
+		TemplateID:   tpl.ID,
+		Slug:         "weekly-sync",
+		ParentPageID: &project.ID,
+		Variables:    map[string]string{"author": "ana"},
+	})
+	require.NoError(t, err)
+	today := time.Now().UTC().Format("2006-01-02")
+	require.Equal(t, "Meeting "+today, page.Title)
+	require.Equal(t, "Notes for Meeting "+today+" under Project X by ana", page.Content)
+	require.Equal(t, []string{"meeting"}, page.Tags)
+	require.False(t, page.IsTemplate)
+	require.Equal(t, project.ID, *page.ParentPageID)
+
+	tree, err := loadPageSubtree(ctx, store.db, page.ID)
+	require.NoError(t, err)
+	require.Len(t, tree, 2)
+	child := tree[1]
+	require.Equal(t, "weekly-sync-actions", child.Slug)
+	require.Equal(t, "Actions for Meeting "+today, child.Title)
+
+	childPage, err := store.GetPage(ctx, child.ID)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{page.ID, project.ID}, childPage.LinkedPageIDs)
+}
+
+func TestStoreInstantiatePageTemplateRejectsRegularPage(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	page, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "plain", Title: "Plain"})
+	require.NoError(t, err)
+
+	_, err = store.InstantiatePageTemplate(ctx, storage.InstantiatePageTemplateInput{TemplateID: page.ID, Slug: "copy"})
+	require.ErrorIs(t, err, storage.ErrTemplateNotFound)
+}
+
+func TestStoreHidesTemplateItemsAndChildren(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	tpl, err := store.CreateDatabase(ctx, storage.CreateDatabaseInput{Slug: "sprint-template", Title: "Sprint", IsTemplate: true})
+	require.NoError(t, err)
+	item, err := store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
+		DatabaseID: tpl.ID,
+		Page:       storage.CreatePageInput{Slug: "retro", Title: "Retro", Content: "ping @ana"},
+	})
+	require.NoError(t, err)
+	require.True(t, item.Page.IsTemplate)
+	root, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "runbook", Title: "Runbook", IsTemplate: true})
+	require.NoError(t, err)
+	child, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "steps", Title: "Steps", ParentPageID: &root.ID})
+	require.NoError(t, err)
+	require.True(t, child.IsTemplate)
+	_, err = store.CreatePage(ctx, storage.CreatePageInput{Slug: "notes", Title: "Notes"})
+	require.NoError(t, err)
+
+	pages, err := store.ListPages(ctx)
+	require.NoError(t, err)
+	require.Len(t, pages, 1)
+	require.Equal(t, "notes", pages[0].Slug)
+	templates, err := store.ListPageTemplates(ctx)
+	require.NoError(t, err)
+	require.Len(t, templates, 1)
+	require.Equal(t, root.ID, templates[0].ID)
+
+	db, err := store.InstantiateDatabaseTemplate(ctx, storage.InstantiateDatabaseTemplateInput{TemplateID: tpl.ID, Slug: "sprint-1", Title: "Sprint 1", IncludeItems: true})
+	require.NoError(t, err)
+	pages, err = store.ListPages(ctx)
+	require.NoError(t, err)
+	require.Len(t, pages, 2)
+	require.Equal(t, "sprint-1-retro", pages[1].Slug)
+	require.NotEmpty(t, db.ID)
+}
+
+func TestStoreInstantiateDatabaseTemplate(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	tpl, err := store.CreateDatabase(ctx, storage.CreateDatabaseInput{
+		Slug:       "project-template",
+		Title:      "Project board",
+		IsTemplate: true,
+		Properties: []storage.DatabasePropertyInput{
+			{Name: "Status", Slug: "status", Type: domain.PropertyTypeSelect},
+			{Name: "Owner", Slug: "owner", Type: domain.PropertyTypeText},
+		},
+	})
+	require.NoError(t, err)
+	statusID := propertyIDBySlug(t, tpl, "status")
+	_, err = store.db.ExecContext(ctx, `INSERT INTO database_views(id, database_id, name, type, filters, sorts, grouping, display_properties, layout_options, created_at, updated_at) VALUES('v1', ?, 'Board', 'board', ?, ?, ?, ?, 'null', ?, ?)`,
+		tpl.ID, fmt.Sprintf(`{"property_id":%q,"equals":"Todo"}`, statusID), fmt.Sprintf(`[{"property_id":%q,"direction":"asc"}]`, statusID),
+		fmt.Sprintf(`{"property_id":%q}`, statusID), fmt.Sprintf(`[%q]`, statusID), time.Now().UTC(), time.Now().UTC())
+	require.NoError(t, err)
+	_, err = store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
+		DatabaseID: tpl.ID,
+		Page:       storage.CreatePageInput{Slug: "kickoff", Title: "Kickoff for {{parent.title}}"},
+		Values:     map[string]any{"status": "Todo"},
+	})
+	require.NoError(t, err)
+	_, err = store.CreateItemTemplate(ctx, storage.CreateItemTemplateInput{DatabaseID: tpl.ID, Name: "Bug", Values: map[string]any{"status": "Todo"}})
+	require.NoError(t, err)
+
+	templates, err := store.ListDatabaseTemplates(ctx)
+	require.NoError(t, err)
+	require.Len(t, templates, 1)
+
+	db, err := store.InstantiateDatabaseTemplate(ctx, storage.InstantiateDatabaseTemplateInput{TemplateID: tpl.ID, Slug: "apollo", Title: "Apollo", IncludeItems: true})
+	require.NoError(t, err)
+	require.False(t, db.IsTemplate)
+	require.Len(t, db.Properties, 2)
+	newStatusID := propertyIDBySlug(t, db, "status")
+	require.NotEqual(t, statusID, newStatusID)
+	require.Len(t, db.ItemTemplates, 1)
+	require.Len(t, db.Views, 1)
+	require.Equal(t, newStatusID, db.Views[0].Filters["property_id"])
+	require.Equal(t, newStatusID, db.Views[0].Grouping["property_id"])
+	require.Equal(t, newStatusID, db.Views[0].Sorts[0].PropertyID)
+	require.Equal(t, []string{newStatusID}, db.Views[0].Display)
+
+	items, err := store.ListViewItems(ctx, db.ID, db.Views[0].ID)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "Kickoff for Apollo", items[0].Page.Title)
+	require.Equal(t, "apollo-kickoff", items[0].Page.Slug)
+	require.Equal(t, "Todo", items[0].PropertyMap["status"].RawValue)
+}
+
+func TestStoreCreateDatabaseItemFromTemplate(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	db, err := store.CreateDatabase(ctx, storage.CreateDatabaseInput{
+		Slug:  "bugs",
+		Title: "Bugs",
+		Properties: []storage.DatabasePropertyInput{
+			{Name: "Status", Slug: "status", Type: domain.PropertyTypeSelect},
+			{Name: "Reported", Slug: "reported", Type: domain.PropertyTypeText},
+		},
+		Views: []storage.DatabaseViewInput{{Name: "Table", Type: domain.ViewTypeTable}},
+	})
+	require.NoError(t, err)
+	tpl, err := store.CreateItemTemplate(ctx, storage.CreateItemTemplateInput{
+		DatabaseID: db.ID,
+		Name:       "Bug report",
+		Title:      "Bug {{date}}",
+		Content:    "Filed in {{parent.title}}: {{title}}",
+		Tags:       []string{"bug"},
+		Values:     map[string]any{"status": "Triage", "reported": "{{date}}"},
+	})
+	require.NoError(t, err)
+
+	_, err = store.CreateItemTemplate(ctx, storage.CreateItemTemplateInput{DatabaseID: db.ID, Name: "Broken", Values: map[string]any{"missing": 1}})
+	require.Error(t, err)
+
+	item, err := store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
+		DatabaseID: db.ID,
+		TemplateID: tpl.ID,
+		Page:       storage.CreatePageInput{Slug: "crash", Title: "Crash on save"},
+		Values:     map[string]any{"status": "Open"},
+	})
+	require.NoError(t, err)
+	today := time.Now().UTC().Format("2006-01-02")
+	require.Equal(t, "Filed in Bugs: Crash on save", item.Page.Content)
+	require.Equal(t, []string{"bug"}, item.Page.Tags)
+	require.Equal(t, "Open", item.PropertyMap["status"].RawValue)
+	require.Equal(t, today, item.PropertyMap["reported"].RawValue)
+
+	_, err = store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{DatabaseID: db.ID, TemplateID: "missing", Page: storage.CreatePageInput{Slug: "x", Title: "X"}})
+	require.ErrorIs(t, err, storage.ErrTemplateNotFound)
+}
+
+func TestOpenAppliesMigrationsOnce(t *testing.T) {
+	dsn := fmt.Sprintf("file:%s?_fk=1", filepath.Join(t.TempDir(), "app.db"))
+
+	store, err := Open(dsn)
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+
+	store, err = Open(dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	var applied int
+	require.NoError(t, store.db.QueryRow(`SELECT COUNT(1) FROM schema_migrations`).Scan(&applied))
+	entries, err := migrationsFS.ReadDir("migrations")
+	require.NoError(t, err)
+	require.Equal(t, len(entries), applied)
+}
+
+func propertyIDBySlug(t *testing.T, db *domain.Database, slug string) string {
+	t.Helper()
+	for _, prop := range db.Properties {
+		if prop.Slug == slug {
+			return prop.ID
+		}
+	}
+	t.Fatalf("property %s not found", slug)
+	return ""
+}
@@ -0,0 +1,584 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// View version actions recorded in database_view_versions.
+const (
+	ViewActionCreate  = "create"
+	ViewActionUpdate  = "update"
+	ViewActionDelete  = "delete"
+	ViewActionRestore = "restore"
+)
+
+// ErrViewVersionNotFound is returned when a view has no version to restore.
+var ErrViewVersionNotFound = storage.NotFoundError("view_version_not_found", "view version not found")
+
+// QueryView returns the items of a view that match its filters, ordered by its
+// sorts and then by rank, together with the aggregates configured in its
+// layout options. Filters and sorts run against the indexed values; items
+// without a value for a sort property come last.
+func (s *Store) QueryView(ctx context.Context, databaseID, viewID string) (*domain.ViewResult, error) {
+	if err := storage.RequireFields("database_id", databaseID, "view_id", viewID); err != nil {
+		return nil, err
+	}
+	schema, err := s.schema(ctx, s.reader, databaseID)
+	if err != nil {
+		return nil, err
+	}
+	view, ok := schema.view(viewID)
+	if !ok {
+		return nil, storage.ErrViewNotFound
+	}
+	items, err := s.queryDatabaseItems(ctx, databaseID, viewItemQuery(*view, schema.types))
+	if err != nil {
+		return nil, err
+	}
+	return storage.BuildViewResult(*view, items, schema.options), nil
+}
+
+// GetDatabaseView loads a single view of a database.
+func (s *Store) GetDatabaseView(ctx context.Context, databaseID, viewID string) (*domain.DatabaseView, error) {
+	return loadViewRow(ctx, s.reader, databaseID, viewID)
+}
+
+// CreateDatabaseView adds a view to an existing database after validating the
+// properties it references.
+func (s *Store) CreateDatabaseView(ctx context.Context, databaseID string, in storage.DatabaseViewInput) (*domain.DatabaseView, error) {
+	view := domain.DatabaseView{
+		ID:            uuid.NewString(),
+		DatabaseID:    databaseID,
+		Name:          in.Name,
+		Type:          in.Type,
+		Filters:       in.Filters,
+		Sorts:         in.Sorts,
+		Grouping:      in.Grouping,
+		Display:       in.Display,
+		LayoutOptions: in.LayoutOptions,
+	}
+	return s.writeView(ctx, databaseID, storage.AuditActionCreate, "", func(ctx context.Context, q queryer, now time.Time) (*domain.DatabaseView, error) {
+		return createView(ctx, q, view, now)
+	})
+}
+
+// UpdateDatabaseView applies a partial update to a view and records the new
+// version so the change can be undone.
+func (s *Store) UpdateDatabaseView(ctx context.Context, in storage.UpdateDatabaseViewInput) (*domain.DatabaseView, error) {
+	return s.writeView(ctx, in.DatabaseID, storage.AuditActionUpdate, in.ViewID, func(ctx context.Context, q queryer, now time.Time) (*domain.DatabaseView, error) {
+		view, err := loadViewRow(ctx, q, in.DatabaseID, in.ViewID)
+		if err != nil {
+			return nil, err
+		}
+		if err := storage.CheckVersion(in.ExpectedVersion, view.Version); err != nil {
+			return nil, err
+		}
+		if in.Name != nil {
+			view.Name = *in.Name
+		}
+		if in.Type != nil {
+			view.Type = *in.Type
+		}
+		if in.Filters != nil {
+			view.Filters = in.Filters
+		}
+		if in.Sorts != nil {
+			view.Sorts = in.Sorts
+		}
+		if in.Grouping != nil {
+			view.Grouping = in.Grouping
+		}
+		if in.Display != nil {
+			view.Display = in.Display
+		}
+		if in.LayoutOptions != nil {
+			view.LayoutOptions = in.LayoutOptions
+		}
+		return saveView(ctx, q, *view, ViewActionUpdate, nil, now)
+	})
+}
+
+// DeleteDatabaseView removes a view. The deletion is versioned like any other
+// change, so the view can be brought back with UndoDatabaseView.
+func (s *Store) DeleteDatabaseView(ctx context.Context, databaseID, viewID string) error {
+	_, err := s.writeView(ctx, databaseID, storage.AuditActionDelete, viewID, func(ctx context.Context, q queryer, now time.Time) (*domain.DatabaseView, error) {
+		view, err := loadViewRow(ctx, q, databaseID, viewID)
+		if err != nil {
+			return nil, err
+		}
+		if err := ensureViewBaseline(ctx, q, view, now); err != nil {
+			return nil, err
+		}
+		if _, err := q.ExecContext(ctx, `DELETE FROM database_views WHERE id = ?`, viewID); err != nil {
+			return nil, fmt.Errorf("delete view: %w", err)
+		}
+		return nil, recordViewVersion(ctx, q, viewID, databaseID, view.Version+1, ViewActionDelete, nil, nil, now)
+	})
+	return err
+}
+
+// DuplicateDatabaseView copies a view under a new id.
+func (s *Store) DuplicateDatabaseView(ctx context.Context, in storage.DuplicateDatabaseViewInput) (*domain.DatabaseView, error) {
+	return s.writeView(ctx, in.DatabaseID, storage.AuditActionCreate, "", func(ctx context.Context, q queryer, now time.Time) (*domain.DatabaseView, error) {
+		source, err := loadViewRow(ctx, q, in.DatabaseID, in.ViewID)
+		if err != nil {
+			return nil, err
+		}
+		copied := *source
+		copied.ID = uuid.NewString()
+		copied.Name = in.Name
+		if strings.TrimSpace(copied.Name) == "" {
+			copied.Name = source.Name + " (copy)"
+		}
+		return createView(ctx, q, copied, now)
+	})
+}
+
+// ListDatabaseViewVersions returns the recorded versions of a view, newest first.
+func (s *Store) ListDatabaseViewVersions(ctx context.Context, databaseID, viewID string) ([]domain.DatabaseViewVersion, error) {
+	rows, err := s.reader.QueryContext(ctx, `SELECT view_id, database_id, version, action, snapshot, restored_from, created_at FROM database_view_versions WHERE database_id = ? AND view_id = ? ORDER BY version DESC`, databaseID, viewID)
+	if err != nil {
+		return nil, fmt.Errorf("query view versions: %w", err)
+	}
+	defer rows.Close()
+	var versions []domain.DatabaseViewVersion
+	for rows.Next() {
+		version, err := scanViewVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, *version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate view versions: %w", err)
+	}
+	if len(versions) == 0 {
+		view, err := loadViewRow(ctx, s.reader, databaseID, viewID)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, domain.DatabaseViewVersion{ViewID: view.ID, DatabaseID: databaseID, Version: view.Version, Action: ViewActionCreate, View: view, CreatedAt: view.UpdatedAt})
+	}
+	return versions, nil
+}
+
+// RestoreDatabaseView brings a view back to the state of an earlier version,
+// recreating it if it has been deleted since. The restore is recorded as a new
+// version.
+func (s *Store) RestoreDatabaseView(ctx context.Context, databaseID, viewID string, version int) (*domain.DatabaseView, error) {
+	return s.writeView(ctx, databaseID, storage.AuditActionRestore, viewID, func(ctx context.Context, q queryer, now time.Time) (*domain.DatabaseView, error) {
+		return restoreView(ctx, q, databaseID, viewID, version, now)
+	})
+}
+
+// UndoDatabaseView reverts the most recent change of a view. Undoing a restore
+// steps further back, so repeated undos walk through the history instead of
+// toggling between two versions.
+func (s *Store) UndoDatabaseView(ctx context.Context, databaseID, viewID string) (*domain.DatabaseView, error) {
+	return s.writeView(ctx, databaseID, storage.AuditActionRestore, viewID, func(ctx context.Context, q queryer, now time.Time) (*domain.DatabaseView, error) {
+		row := q.QueryRowContext(ctx, `SELECT view_id, database_id, version, action, snapshot, restored_from, created_at FROM database_view_versions WHERE database_id = ? AND view_id = ? ORDER BY version DESC LIMIT 1`, databaseID, viewID)
+		latest, err := scanViewVersion(row)
+		if errors.Is(err, sql.ErrNoRows) {
+			if _, err := loadViewRow(ctx, q, databaseID, viewID); err != nil {
+				return nil, err
+			}
+			return nil, ErrViewVersionNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		base := latest.Version
+		if latest.RestoredFrom != nil {
+			base = *latest.RestoredFrom
+		}
+		if base <= 1 {
+			return nil, ErrViewVersionNotFound
+		}
+		return restoreView(ctx, q, databaseID, viewID, base-1, now)
+	})
+}
+
+type viewWrite func(ctx context.Context, q queryer, now time.Time) (*domain.DatabaseView, error)
+
+// writeView runs a view change in a transaction after checking the database
+// exists, and audits it under action. viewID names the view changed in place
+// and is empty when fn creates a new view.
+func (s *Store) writeView(ctx context.Context, databaseID, action, viewID string, fn viewWrite) (*domain.DatabaseView, error) {
+	if err := storage.RequireFields("database_id", databaseID); err != nil {
+		return nil, err
+	}
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	var exists int
+	if err := tx.QueryRowContext(ctx, `SELECT 1 FROM databases WHERE id = ?`, databaseID).Scan(&exists); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrDatabaseNotFound
+		}
+		return nil, fmt.Errorf("verify database: %w", err)
+	}
+	now := time.Now().UTC()
+	var before *domain.DatabaseView
+	if viewID != "" {
+		// A restore may bring back a deleted view, which has no before state.
+		before, err = loadViewRow(ctx, tx, databaseID, viewID)
+		if err != nil && !errors.Is(err, storage.ErrViewNotFound) {
+			return nil, err
+		}
+	}
+	view, err := fn(ctx, tx, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := touchDatabase(ctx, tx, databaseID, now); err != nil {
+		return nil, err
+	}
+	entityID := viewID
+	if view != nil {
+		entityID = view.ID
+	}
+	if err := recordAudit(ctx, tx, action, storage.AuditEntityView, entityID, before, view, now); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit view: %w", err)
+	}
+	return view, nil
+}
+
+func createView(ctx context.Context, q queryer, view domain.DatabaseView, now time.Time) (*domain.DatabaseView, error) {
+	if err := validateView(ctx, q, view); err != nil {
+		return nil, err
+	}
+	if err := insertViewRow(ctx, q, view, now); err != nil {
+		return nil, err
+	}
+	view.Version = 1
+	view.CreatedAt = now
+	view.UpdatedAt = now
+	if err := recordViewVersion(ctx, q, view.ID, view.DatabaseID, 1, ViewActionCreate, &view, nil, now); err != nil {
+		return nil, err
+	}
+	return &view, nil
+}
+
+// saveView validates and stores the new state of an existing view under the
+// next version number.
+func saveView(ctx context.Context, q queryer, view domain.DatabaseView, action string, restoredFrom *int, now time.Time) (*domain.DatabaseView, error) {
+	if err := validateView(ctx, q, view); err != nil {
+		return nil, err
+	}
+	current, err := loadViewRow(ctx, q, view.DatabaseID, view.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureViewBaseline(ctx, q, current, now); err != nil {
+		return nil, err
+	}
+	view.Version = current.Version + 1
+	view.CreatedAt = current.CreatedAt
+	view.UpdatedAt = now
+	filters, sorts, grouping, display, layout, err := marshalViewSettings(view)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := q.ExecContext(ctx, `UPDATE database_views SET name = ?, type = ?, filters = ?, sorts = ?, grouping = ?, display_properties = ?, layout_options = ?, version = ?, updated_at = ? WHERE id = ?`,
+		view.Name, string(view.Type), filters, sorts, grouping, display, layout, view.Version, now, view.ID); err != nil {
+		return nil, fmt.Errorf("update view: %w", err)
+	}
+	if err := recordViewVersion(ctx, q, view.ID, view.DatabaseID, view.Version, action, &view, restoredFrom, now); err != nil {
+		return nil, err
+	}
+	return &view, nil
+}
+
+func restoreView(ctx context.Context, q queryer, databaseID, viewID string, version int, now time.Time) (*domain.DatabaseView, error) {
+	row := q.QueryRowContext(ctx, `SELECT view_id, database_id, version, action, snapshot, restored_from, created_at FROM database_view_versions WHERE database_id = ? AND view_id = ? AND version = ?`, databaseID, viewID, version)
+	target, err := scanViewVersion(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrViewVersionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var latest int
+	if err := q.QueryRowContext(ctx, `SELECT MAX(version) FROM database_view_versions WHERE view_id = ?`, viewID).Scan(&latest); err != nil {
+		return nil, fmt.Errorf("load latest view version: %w", err)
+	}
+	current, err := loadViewRow(ctx, q, databaseID, viewID)
+	if err != nil && !errors.Is(err, storage.ErrViewNotFound) {
+		return nil, err
+	}
+	if target.View == nil {
+		if current != nil {
+			if _, err := q.ExecContext(ctx, `DELETE FROM database_views WHERE id = ?`, viewID); err != nil {
+				return nil, fmt.Errorf("delete view: %w", err)
+			}
+		}
+		return nil, recordViewVersion(ctx, q, viewID, databaseID, latest+1, ViewActionRestore, nil, &version, now)
+	}
+	view := *target.View
+	if current == nil {
+		// The view was deleted; put the row back at the version it will be
+		// recorded under.
+		if err := validateView(ctx, q, view); err != nil {
+			return nil, err
+		}
+		if err := insertViewRow(ctx, q, view, now); err != nil {
+			return nil, err
+		}
+		view.Version = latest + 1
+		view.UpdatedAt = now
+		if _, err := q.ExecContext(ctx, `UPDATE database_views SET version = ?, created_at = ? WHERE id = ?`, view.Version, view.CreatedAt, viewID); err != nil {
+			return nil, fmt.Errorf("restore view version: %w", err)
+		}
+		if err := recordViewVersion(ctx, q, viewID, databaseID, view.Version, ViewActionRestore, &view, &version, now); err != nil {
+			return nil, err
+		}
+		return &view, nil
+	}
+	return saveView(ctx, q, view, ViewActionRestore, &version, now)
+}
+
+// ensureViewBaseline records the current state of a view that predates view
+// versioning, so its first change can still be undone.
+func ensureViewBaseline(ctx context.Context, q queryer, view *domain.DatabaseView, now time.Time) error {
+	var count int
+	if err := q.QueryRowContext(ctx, `SELECT COUNT(1) FROM database_view_versions WHERE view_id = ?`, view.ID).Scan(&count); err != nil {
+		return fmt.Errorf("count view versions: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+	return recordViewVersion(ctx, q, view.ID, view.DatabaseID, view.Version, ViewActionCreate, view, nil, now)
+}
+
+func recordViewVersion(ctx context.Context, q queryer, viewID, databaseID string, version int, action string, view *domain.DatabaseView, restoredFrom *int, now time.Time) error {
+	var snapshot sql.NullString
+	if view != nil {
+		raw, err := json.Marshal(view)
+		if err != nil {
+			return fmt.Errorf("marshal view snapshot: %w", err)
+		}
+		snapshot = sql.NullString{String: string(raw), Valid: true}
+	}
+	if _, err := q.ExecContext(ctx, `INSERT INTO database_view_versions(view_id, database_id, version, action, snapshot, restored_from, created_at) VALUES(?, ?, ?, ?, ?, ?, ?)`,
+		viewID, databaseID, version, action, snapshot, restoredFrom, now); err != nil {
+		return fmt.Errorf("record view version: %w", err)
+	}
+	return nil
+}
+
+func scanViewVersion(row interface{ Scan(...any) error }) (*domain.DatabaseViewVersion, error) {
+	var version domain.DatabaseViewVersion
+	var snapshot sql.NullString
+	var restoredFrom sql.NullInt64
+	if err := row.Scan(&version.ViewID, &version.DatabaseID, &version.Version, &version.Action, &snapshot, &restoredFrom, &version.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("scan view version: %w", err)
+	}
+	if snapshot.Valid {
+		var view domain.DatabaseView
+		if err := json.Unmarshal([]byte(snapshot.String), &view); err != nil {
+			return nil, fmt.Errorf("decode view snapshot: %w", err)
+		}
+		version.View = &view
+	}
+	if restoredFrom.Valid {
+		from := int(restoredFrom.Int64)
+		version.RestoredFrom = &from
+	}
+	return &version, nil
+}
+
+func loadViewRow(ctx context.Context, q queryer, databaseID, viewID string) (*domain.DatabaseView, error) {
+	row := q.QueryRowContext(ctx, `SELECT `+viewColumns+` FROM database_views WHERE id = ? AND database_id = ?`, viewID, databaseID)
+	view, err := scanViewRow(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrViewNotFound
+	}
+	return view, err
+}
+
+func marshalViewSettings(view domain.DatabaseView) (filters, sorts, grouping, display, layout string, err error) {
+	parts := []struct {
+		value any
+		out   *string
+		label string
+	}{
+		{view.Filters, &filters, "filters"},
+		{view.Sorts, &sorts, "sorts"},
+		{view.Grouping, &grouping, "grouping"},
+		{view.Display, &display, "display"},
+		{view.LayoutOptions, &layout, "layout"},
+	}
+	for _, part := range parts {
+		raw, err := json.Marshal(part.value)
+		if err != nil {
+			return "", "", "", "", "", fmt.Errorf("marshal view %s: %w", part.label, err)
+		}
+		*part.out = string(raw)
+	}
+	return filters, sorts, grouping, display, layout, nil
+}
+
+// groupableTypes lists the property types a board can use for its columns.
+var groupableTypes = map[domain.PropertyType]bool{
+	domain.PropertyTypeSelect:      true,
+	domain.PropertyTypeMultiSelect: true,
+	domain.PropertyTypeCheckbox:    true,
+	domain.PropertyTypeRelation:    true,
+}
+
+// validateView checks that every property a view references exists in its
+// database and suits the view type: boards group by a select-like property,
+// calendars need a date_property_id and timelines a start_property_id (and
+// optional end_property_id) of type date in layout_options. Filter operators
+// and aggregations must be known and fit the property they apply to.
+func validateView(ctx context.Context, q queryer, view domain.DatabaseView) error {
+	if strings.TrimSpace(view.Name) == "" {
+		return fmt.Errorf("%w: name is required", storage.ErrInvalidView)
+	}
+	switch view.Type {
+	case domain.ViewTypeTable, domain.ViewTypeList, domain.ViewTypeGallery, domain.ViewTypeBoard, domain.ViewTypeCalendar, domain.ViewTypeTimeline:
+	default:
+		return fmt.Errorf("%w: unknown view type %q", storage.ErrInvalidView, view.Type)
+	}
+	props, err := propertyTypes(ctx, q, view.DatabaseID)
+	if err != nil {
+		return err
+	}
+	property := func(field, id string) (domain.PropertyType, error) {
+		propType, ok := props[id]
+		if !ok {
+			return "", fmt.Errorf("%w: %s references unknown property %q", storage.ErrInvalidView, field, id)
+		}
+		return propType, nil
+	}
+	for _, sort := range view.Sorts {
+		if _, err := property("sorts", sort.PropertyID); err != nil {
+			return err
+		}
+		if sort.Direction != "" && sort.Direction != "asc" && sort.Direction != "desc" {
+			return fmt.Errorf("%w: sort direction must be asc or desc", storage.ErrInvalidView)
+		}
+	}
+	if len(view.Display) > 0 {
+		// Views created with their database list display columns by slug.
+		slugs, err := propertySlugs(ctx, q, view.DatabaseID)
+		if err != nil {
+			return err
+		}
+		for _, id := range view.Display {
+			if _, ok := slugs[id]; ok {
+				continue
+			}
+			if _, err := property("display_properties", id); err != nil {
+				return err
+			}
+		}
+	}
+	for _, id := range referencedPropertyIDs(view.Filters) {
+		if _, err := property("filters", id); err != nil {
+			return err
+		}
+	}
+	if err := storage.ValidateFilterOperators(map[string]any(view.Filters)); err != nil {
+		return err
+	}
+	if err := storage.ValidateAggregations(view, props); err != nil {
+		return err
+	}
+	groupBy, _ := view.Grouping["property_id"].(string)
+	if groupBy != "" {
+		propType, err := property("grouping", groupBy)
+		if err != nil {
+			return err
+		}
+		if !groupableTypes[propType] {
+			return fmt.Errorf("%w: cannot group by %s property", storage.ErrInvalidView, propType)
+		}
+	} else if view.Type == domain.ViewTypeBoard {
+		return fmt.Errorf("%w: board views require grouping.property_id", storage.ErrInvalidView)
+	}
+	dateField := func(key string, required bool) error {
+		id, _ := view.LayoutOptions[key].(string)
+		if id == "" {
+			if required {
+				return fmt.Errorf("%w: %s views require layout_options.%s", storage.ErrInvalidView, view.Type, key)
+			}
+			return nil
+		}
+		propType, err := property("layout_options."+key, id)
+		if err != nil {
+			return err
+		}
+		if propType != domain.PropertyTypeDate {
+			return fmt.Errorf("%w: layout_options.%s must be a date property", storage.ErrInvalidView, key)
+		}
+		return nil
+	}
+	switch view.Type {
+	case domain.ViewTypeCalendar:
+		return dateField("date_property_id", true)
+	case domain.ViewTypeTimeline:
+		if err := dateField("start_property_id", true); err != nil {
+			return err
+		}
+		return dateField("end_property_id", false)
+	}
+	return nil
+}
+
+// referencedPropertyIDs collects every "property_id" value in a filter tree.
+func referencedPropertyIDs(value any) []string {
+	var ids []string
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if id, ok := child.(string); ok && key == "property_id" {
+				ids = append(ids, id)
+				continue
+			}
+			ids = append(ids, referencedPropertyIDs(child)...)
+		}
+	case []any:
+		for _, child := range v {
+			ids = append(ids, referencedPropertyIDs(child)...)
+		}
+	}
+	return ids
+}
+
+func propertyTypes(ctx context.Context, q queryer, databaseID string) (map[string]domain.PropertyType, error) {
+	rows, err := q.QueryContext(ctx, `SELECT id, type FROM database_properties WHERE database_id = ?`, databaseID)
+	if err != nil {
+		return nil, fmt.Errorf("query property types: %w", err)
+	}
+	defer rows.Close()
+	types := make(map[string]domain.PropertyType)
+	for rows.Next() {
+		var id string
+		var propType domain.PropertyType
+		if err := rows.Scan(&id, &propType); err != nil {
+			return nil, fmt.Errorf("scan property type: %w", err)
+		}
+		types[id] = propType
+	}
+	return types, rows.Err()
+}
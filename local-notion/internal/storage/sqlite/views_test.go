@@ -0,0 +1,232 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+func newViewTestDatabase(t *testing.T, store *Store) *domain.Database {
+	t.Helper()
+	db, err := store.CreateDatabase(context.Background(), storage.CreateDatabaseInput{
+		Slug:  "tasks",
+		Title: "Tasks",
+		Properties: []storage.DatabasePropertyInput{
+			{Name: "Status", Slug: "status", Type: domain.PropertyTypeSelect},
+			{Name: "Due", Slug: "due", Type: domain.PropertyTypeDate},
+			{Name: "Notes", Slug: "notes", Type: domain.PropertyTypeText},
+		},
+		Views: []storage.DatabaseViewInput{{Name: "Table", Type: domain.ViewTypeTable}},
+	})
+	require.NoError(t, err)
+	return db
+}
+
+func TestStoreCreateDatabaseViewValidates(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db := newViewTestDatabase(t, store)
+	status := propertyIDBySlug(t, db, "status")
+	due := propertyIDBySlug(t, db, "due")
+	notes := propertyIDBySlug(t, db, "notes")
+
+	board, err := store.CreateDatabaseView(ctx, db.ID, storage.DatabaseViewInput{
+		Name:     "Board",
+		Type:     domain.ViewTypeBoard,
+		Grouping: map[string]any{"property_id": status},
+		Filters:  map[string]any{"and": []any{map[string]any{"property_id": notes, "operator": "contains", "value": "x"}}},
+		Sorts:    []domain.ViewSort{{PropertyID: due, Direction: "asc"}},
+		Display:  []string{notes, "due"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, board.Version)
+
+	loaded, err := store.GetDatabase(ctx, db.ID)
+	require.NoError(t, err)
+	require.Len(t, loaded.Views, 2)
+
+	invalid := []storage.DatabaseViewInput{
+		{Name: "", Type: domain.ViewTypeTable},
+		{Name: "Odd", Type: "spreadsheet"},
+		{Name: "Board", Type: domain.ViewTypeBoard},
+		{Name: "Board", Type: domain.ViewTypeBoard, Grouping: map[string]any{"property_id": notes}},
+		{Name: "Calendar", Type: domain.ViewTypeCalendar, LayoutOptions: map[string]any{"date_property_id": status}},
+		{Name: "Timeline", Type: domain.ViewTypeTimeline},
+		{Name: "Table", Type: domain.ViewTypeTable, Sorts: []domain.ViewSort{{PropertyID: "missing"}}},
+		{Name: "Table", Type: domain.ViewTypeTable, Sorts: []domain.ViewSort{{PropertyID: due, Direction: "sideways"}}},
+		{Name: "Table", Type: domain.ViewTypeTable, Filters: map[string]any{"property_id": "missing"}},
+		{Name: "Table", Type: domain.ViewTypeTable, Display: []string{"missing"}},
+	}
+	for _, in := range invalid {
+		_, err := store.CreateDatabaseView(ctx, db.ID, in)
+		require.ErrorIs(t, err, storage.ErrInvalidView, "view %+v", in)
+	}
+
+	_, err = store.CreateDatabaseView(ctx, "missing", storage.DatabaseViewInput{Name: "Table", Type: domain.ViewTypeTable})
+	require.ErrorIs(t, err, storage.ErrDatabaseNotFound)
+}
+
+func TestStoreUpdateAndUndoDatabaseView(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db := newViewTestDatabase(t, store)
+	viewID := db.Views[0].ID
+	due := propertyIDBySlug(t, db, "due")
+
+	// The view was created with the database, before it had any history.
+	renamed, err := store.UpdateDatabaseView(ctx, storage.UpdateDatabaseViewInput{DatabaseID: db.ID, ViewID: viewID, Name: strPtr("All tasks")})
+	require.NoError(t, err)
+	require.Equal(t, 2, renamed.Version)
+
+	calendar := domain.ViewTypeCalendar
+	_, err = store.UpdateDatabaseView(ctx, storage.UpdateDatabaseViewInput{DatabaseID: db.ID, ViewID: viewID, Type: &calendar})
+	require.ErrorIs(t, err, storage.ErrInvalidView)
+
+	moved, err := store.UpdateDatabaseView(ctx, storage.UpdateDatabaseViewInput{DatabaseID: db.ID, ViewID: viewID, Type: &calendar, LayoutOptions: map[string]any{"date_property_id": due}})
+	require.NoError(t, err)
+	require.Equal(t, 3, moved.Version)
+	require.Equal(t, "All tasks", moved.Name)
+
+	undone, err := store.UndoDatabaseView(ctx, db.ID, viewID)
+	require.NoError(t, err)
+	require.Equal(t, domain.ViewTypeTable, undone.Type)
+	require.Equal(t, "All tasks", undone.Name)
+
+	undone, err = store.UndoDatabaseView(ctx, db.ID, viewID)
+	require.NoError(t, err)
+	require.Equal(t, "Table", undone.Name)
+
+	_, err = store.UndoDatabaseView(ctx, db.ID, viewID)
+	require.ErrorIs(t, err, ErrViewVersionNotFound)
+
+	versions, err := store.ListDatabaseViewVersions(ctx, db.ID, viewID)
+	require.NoError(t, err)
+	require.Len(t, versions, 5)
+	require.Equal(t, ViewActionRestore, versions[0].Action)
+	require.Equal(t, 1, *versions[0].RestoredFrom)
+
+	restored, err := store.RestoreDatabaseView(ctx, db.ID, viewID, 3)
+	require.NoError(t, err)
+	require.Equal(t, domain.ViewTypeCalendar, restored.Type)
+	require.Equal(t, 6, restored.Version)
+}
+
+func TestStoreDeleteDuplicateAndUndoDatabaseView(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db := newViewTestDatabase(t, store)
+	source := db.Views[0]
+
+	copied, err := store.DuplicateDatabaseView(ctx, storage.DuplicateDatabaseViewInput{DatabaseID: db.ID, ViewID: source.ID})
+	require.NoError(t, err)
+	require.NotEqual(t, source.ID, copied.ID)
+	require.Equal(t, "Table (copy)", copied.Name)
+
+	require.NoError(t, store.DeleteDatabaseView(ctx, db.ID, source.ID))
+	_, err = store.GetDatabaseView(ctx, db.ID, source.ID)
+	require.ErrorIs(t, err, storage.ErrViewNotFound)
+	_, err = store.ListViewItems(ctx, db.ID, source.ID)
+	require.ErrorIs(t, err, storage.ErrViewNotFound)
+
+	back, err := store.UndoDatabaseView(ctx, db.ID, source.ID)
+	require.NoError(t, err)
+	require.Equal(t, source.ID, back.ID)
+	require.Equal(t, "Table", back.Name)
+
+	loaded, err := store.GetDatabaseView(ctx, db.ID, source.ID)
+	require.NoError(t, err)
+	require.Equal(t, back.Version, loaded.Version)
+	require.Equal(t, source.CreatedAt.Unix(), loaded.CreatedAt.Unix())
+
+	require.ErrorIs(t, store.DeleteDatabaseView(ctx, db.ID, "missing"), storage.ErrViewNotFound)
+}
+
+func TestStoreQueryViewAggregates(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	db, err := store.CreateDatabase(ctx, storage.CreateDatabaseInput{
+		Slug:  "tasks",
+		Title: "Tasks",
+		Properties: []storage.DatabasePropertyInput{
+			{Name: "Status", Slug: "status", Type: domain.PropertyTypeSelect, Config: map[string]any{"options": []string{"Todo", "Doing", "Done"}}},
+			{Name: "Points", Slug: "points", Type: domain.PropertyTypeNumber},
+			{Name: "Shipped", Slug: "shipped", Type: domain.PropertyTypeCheckbox},
+		},
+	})
+	require.NoError(t, err)
+	status := propertyIDBySlug(t, db, "status")
+	points := propertyIDBySlug(t, db, "points")
+	shipped := propertyIDBySlug(t, db, "shipped")
+
+	rows := []map[string]any{
+		{"status": "Todo", "points": 3},
+		{"status": "Done", "points": 5, "shipped": true},
+		{"status": "Done", "points": 8, "shipped": false},
+		{"points": 100},
+	}
+	for idx, values := range rows {
+		_, err := store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
+			DatabaseID: db.ID,
+			Page:       storage.CreatePageInput{Slug: string(rune('a' + idx)), Title: string(rune('A' + idx))},
+			Values:     values,
+		})
+		require.NoError(t, err)
+	}
+
+	view, err := store.CreateDatabaseView(ctx, db.ID, storage.DatabaseViewInput{
+		Name:     "Board",
+		Type:     domain.ViewTypeBoard,
+		Grouping: map[string]any{"property_id": status},
+		Filters:  map[string]any{"property_id": points, "operator": "less_than", "value": 50},
+		LayoutOptions: map[string]any{"aggregations": map[string]any{
+			points:  "sum",
+			shipped: "percent_checked",
+		}},
+	})
+	require.NoError(t, err)
+
+	result, err := store.QueryView(ctx, db.ID, view.ID)
+	require.NoError(t, err)
+	require.Len(t, result.Items, 3)
+	require.NotNil(t, result.Summary)
+	require.Equal(t, 3, result.Summary.Count)
+	footer := aggregatesByProperty(result.Summary.Aggregates)
+	require.Equal(t, 16.0, footer[points])
+	require.InDelta(t, 33.33, footer[shipped], 0.01)
+
+	require.Len(t, result.Summary.Groups, 3)
+	require.Equal(t, "Todo", result.Summary.Groups[0].Key)
+	require.Equal(t, "Doing", result.Summary.Groups[1].Key)
+	require.Zero(t, result.Summary.Groups[1].Count)
+	done := result.Summary.Groups[2]
+	require.Equal(t, "Done", done.Key)
+	require.Equal(t, 2, done.Count)
+	require.Equal(t, 13.0, aggregatesByProperty(done.Aggregates)[points])
+	require.Equal(t, 50.0, aggregatesByProperty(done.Aggregates)[shipped])
+
+	_, err = store.CreateDatabaseView(ctx, db.ID, storage.DatabaseViewInput{
+		Name:          "Bad",
+		Type:          domain.ViewTypeTable,
+		LayoutOptions: map[string]any{"aggregations": map[string]any{status: "sum"}},
+	})
+	require.ErrorIs(t, err, storage.ErrInvalidView)
+
+	table, err := store.CreateDatabaseView(ctx, db.ID, storage.DatabaseViewInput{Name: "Table", Type: domain.ViewTypeTable})
+	require.NoError(t, err)
+	plain, err := store.QueryView(ctx, db.ID, table.ID)
+	require.NoError(t, err)
+	require.Nil(t, plain.Summary)
+	require.Len(t, plain.Items, 4)
+}
+
+func aggregatesByProperty(aggregates []domain.ViewAggregate) map[string]any {
+	out := make(map[string]any, len(aggregates))
+	for _, agg := range aggregates {
+		out[agg.PropertyID] = agg.Value
+	}
+	return out
+}
@@ -0,0 +1,133 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+	"github.com/example/agents-playground/internal/storage/storagetest"
+)
+
+func TestStoreConformanceFile(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Store { return newFileTestStore(t, readerPoolSize) })
+}
+
+func TestOpenFileUsesWAL(t *testing.T) {
+	store := newFileTestStore(t, readerPoolSize)
+	var mode string
+	require.NoError(t, store.reader.QueryRow(`PRAGMA journal_mode`).Scan(&mode))
+	require.Equal(t, "wal", mode)
+	var synchronous int
+	require.NoError(t, store.db.QueryRow(`PRAGMA synchronous`).Scan(&synchronous))
+	require.Equal(t, 1, synchronous, "NORMAL")
+
+	_, err := store.reader.Exec(`DELETE FROM pages`)
+	require.Error(t, err, "reader connections are read-only")
+}
+
+func TestReadsDoNotWaitForWriter(t *testing.T) {
+	store := newFileTestStore(t, readerPoolSize)
+	ctx := context.Background()
+	page, err := store.CreatePage(ctx, storage.CreatePageInput{Slug: "notes", Title: "Notes"})
+	require.NoError(t, err)
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+	_, err = tx.ExecContext(ctx, `UPDATE pages SET title = 'Draft' WHERE id = ?`, page.ID)
+	require.NoError(t, err)
+
+	readCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	got, err := store.GetPage(readCtx, page.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Notes", got.Title, "uncommitted writes are not visible")
+	pages, err := store.ListPages(readCtx)
+	require.NoError(t, err)
+	require.Len(t, pages, 1)
+}
+
+// BenchmarkReadsDuringLongWrites measures view reads while a writer keeps
+// holding transactions open. With a shared connection every read waits for
+// the current write; with the reader pool reads proceed in parallel.
+func BenchmarkReadsDuringLongWrites(b *testing.B) {
+	for _, bc := range []struct {
+		name    string
+		readers int
+	}{
+		{"shared", 0},
+		{"pool", readerPoolSize},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			store := newFileTestStore(b, bc.readers)
+			ctx := context.Background()
+			db, view := newBenchmarkDatabase(b, store, 50)
+
+			var stop atomic.Bool
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for i := 0; !stop.Load(); i++ {
+					tx, err := store.db.BeginTx(ctx, nil)
+					if err != nil {
+						return
+					}
+					_, _ = tx.ExecContext(ctx, `UPDATE pages SET summary = ? WHERE slug = 'item-0'`, fmt.Sprint(i))
+					time.Sleep(2 * time.Millisecond)
+					_ = tx.Commit()
+				}
+			}()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := store.QueryView(ctx, db.ID, view.ID); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+			b.StopTimer()
+			stop.Store(true)
+			<-done
+		})
+	}
+}
+
+func newBenchmarkDatabase(tb testing.TB, store *Store, items int) (*domain.Database, *domain.DatabaseView) {
+	tb.Helper()
+	ctx := context.Background()
+	db, err := store.CreateDatabase(ctx, storage.CreateDatabaseInput{
+		Slug:       "bench",
+		Title:      "Bench",
+		Properties: []storage.DatabasePropertyInput{{Name: "Estimate", Slug: "estimate", Type: domain.PropertyTypeNumber}},
+		Views:      []storage.DatabaseViewInput{{Name: "All", Type: domain.ViewTypeTable}},
+	})
+	require.NoError(tb, err)
+	for i := 0; i < items; i++ {
+		_, err := store.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
+			DatabaseID: db.ID,
+			Page:       storage.CreatePageInput{Slug: fmt.Sprintf("item-%d", i), Title: fmt.Sprintf("Item %d", i)},
+			Values:     map[string]any{"estimate": i},
+		})
+		require.NoError(tb, err)
+	}
+	return db, &db.Views[0]
+}
+
+// newFileTestStore opens a store on a database file in a temporary directory
+// with up to readers pooled read connections.
+func newFileTestStore(tb testing.TB, readers int) *Store {
+	tb.Helper()
+	dsn := fmt.Sprintf("file:%s?_fk=1", filepath.Join(tb.TempDir(), "app.db"))
+	store, err := open(dsn, readers)
+	require.NoError(tb, err)
+	tb.Cleanup(func() { _ = store.Close() })
+	return store
+}
@@ -0,0 +1,204 @@
+// Package storage defines the repositories the HTTP layer reads and writes
+// pages, databases, items and links through, together with the inputs and
+// errors every backend shares. The sqlite package is the production backend;
+// the memory package keeps everything in process for tests.
+package storage
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/example/agents-playground/internal/domain"
+)
+
+// PageRepository stores free-form pages.
+type PageRepository interface {
+	// CreatePage persists a new page.
+	CreatePage(ctx context.Context, in CreatePageInput) (*domain.Page, error)
+	// GetPage retrieves a page with its links. It returns ErrPageNotFound
+	// when the page does not exist.
+	GetPage(ctx context.Context, id string) (*domain.Page, error)
+	// ListPages returns a lightweight listing of the non-template pages,
+	// ordered by title.
+	ListPages(ctx context.Context) ([]domain.Page, error)
+	// UpdatePage applies a partial update to a page.
+	UpdatePage(ctx context.Context, in UpdatePageInput) (*domain.Page, error)
+}
+
+// DatabaseRepository stores databases with their properties and views.
+type DatabaseRepository interface {
+	// CreateDatabase persists a database with properties and views.
+	CreateDatabase(ctx context.Context, in CreateDatabaseInput) (*domain.Database, error)
+	// GetDatabase fetches a database with its properties and views. It
+	// returns ErrDatabaseNotFound when the database does not exist.
+	GetDatabase(ctx context.Context, id string) (*domain.Database, error)
+}
+
+// ItemRepository stores the page-backed items of databases.
+type ItemRepository interface {
+	// CreateDatabaseItem persists a new item, its page and its values.
+	CreateDatabaseItem(ctx context.Context, in CreateDatabaseItemInput) (*domain.DatabaseItem, error)
+	// GetDatabaseItem loads a single item with its page and values.
+	GetDatabaseItem(ctx context.Context, databaseID, itemID string) (*domain.DatabaseItem, error)
+	// UpdateDatabaseItem applies a partial update to an item's page and values.
+	UpdateDatabaseItem(ctx context.Context, in UpdateDatabaseItemInput) (*domain.DatabaseItem, error)
+	// ArchiveDatabaseItem archives or restores an item and its page.
+	ArchiveDatabaseItem(ctx context.Context, databaseID, itemID string, archived bool) (*domain.DatabaseItem, error)
+	// DeleteDatabaseItem permanently removes an item, its values and its page.
+	DeleteDatabaseItem(ctx context.Context, databaseID, itemID string) error
+	// MoveDatabaseItem places an item directly before or after another item.
+	MoveDatabaseItem(ctx context.Context, in MoveDatabaseItemInput) (*domain.DatabaseItem, error)
+}
+
+// ViewRepository reads databases through their saved views.
+type ViewRepository interface {
+	// QueryView returns the non-archived items of a view that match its
+	// filters, ordered by its sorts and then by rank, together with the
+	// aggregates configured in its layout options. It returns ErrViewNotFound
+	// when the database has no such view.
+	QueryView(ctx context.Context, databaseID, viewID string) (*domain.ViewResult, error)
+}
+
+// LinkRepository reads the links between pages.
+type LinkRepository interface {
+	// ListPageLinks returns the pages a page links to and the pages linking
+	// to it. It returns ErrPageNotFound when the page does not exist.
+	ListPageLinks(ctx context.Context, pageID string) (*domain.PageLinks, error)
+}
+
+// Store is a complete storage backend.
+type Store interface {
+	PageRepository
+	DatabaseRepository
+	ItemRepository
+	ViewRepository
+	LinkRepository
+	// Ping verifies the backend is reachable.
+	Ping(ctx context.Context) error
+	// Close releases the backend's resources.
+	Close() error
+}
+
+// CreatePageInput holds fields for a new page. An empty Slug is generated
+// from the title.
+type CreatePageInput struct {
+	Slug          string
+	Title         string
+	Summary       string
+	Content       string
+	ParentPageID  *string
+	Tags          []string
+	LinkedPageIDs []string
+	IsTemplate    bool
+	Author        string
+}
+
+// UpdatePageInput describes a partial page update. Nil fields are left
+// untouched; a nil Tags or LinkedPageIDs slice keeps the current value.
+type UpdatePageInput struct {
+	PageID        string
+	Slug          *string
+	Title         *string
+	Summary       *string
+	Content       *string
+	Tags          []string
+	LinkedPageIDs []string
+	Author        string
+	// ExpectedVersion, when set, rejects the update with ErrVersionMismatch
+	// unless the page is still at that version.
+	ExpectedVersion *int
+}
+
+// CreateDatabaseInput defines payload for new database. An empty Slug is
+// generated from the title.
+type CreateDatabaseInput struct {
+	Slug        string
+	Title       string
+	Description string
+	Icon        *string
+	CoverImage  *string
+	IsTemplate  bool
+	Properties  []DatabasePropertyInput
+	Views       []DatabaseViewInput
+}
+
+// DatabasePropertyInput describes a property definition.
+type DatabasePropertyInput struct {
+	Name       string
+	Slug       string
+	Type       domain.PropertyType
+	Config     map[string]any
+	IsRequired bool
+	Default    any
+	OrderIndex int
+}
+
+// DatabaseViewInput describes saved view configuration.
+type DatabaseViewInput struct {
+	Name          string
+	Type          domain.ViewType
+	Filters       map[string]any
+	Sorts         []domain.ViewSort
+	Grouping      map[string]any
+	Display       []string
+	LayoutOptions map[string]any
+}
+
+// CreateDatabaseItemInput describes payload for items.
+type CreateDatabaseItemInput struct {
+	DatabaseID string
+	TemplateID string // optional item template used to prefill page and values
+	Page       CreatePageInput
+	Position   int            // legacy ordering hint; items are ordered by rank and appended at the end
+	Values     map[string]any // keyed by property slug
+}
+
+// UpdateDatabaseItemInput describes a partial item update. Nil page fields are
+// left untouched, a nil Tags slice keeps the current tags and a nil entry in
+// Values clears the stored value of that property.
+type UpdateDatabaseItemInput struct {
+	DatabaseID string
+	ItemID     string
+	Title      *string
+	Summary    *string
+	Content    *string
+	Tags       []string
+	Archived   *bool
+	Values     map[string]any // keyed by property slug
+	Author     string
+	// ExpectedVersion, when set, rejects the update with ErrVersionMismatch
+	// unless the item is still at that version.
+	ExpectedVersion *int
+}
+
+// MoveDatabaseItemInput places an item directly before or after another item
+// of the same database. Exactly one of BeforeID and AfterID must be set.
+type MoveDatabaseItemInput struct {
+	DatabaseID string
+	ItemID     string
+	BeforeID   string
+	AfterID    string
+}
+
+// UniquePageLinks trims and deduplicates link targets, drops links of a page
+// to itself and returns the rest sorted, or nil when none remain.
+func UniquePageLinks(ids []string, currentID string) []string {
+	dedup := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		trimmed := strings.TrimSpace(id)
+		if trimmed == "" || trimmed == currentID {
+			continue
+		}
+		dedup[trimmed] = struct{}{}
+	}
+	if len(dedup) == 0 {
+		return nil
+	}
+	result := make([]string, 0, len(dedup))
+	for id := range dedup {
+		result = append(result, id)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,443 @@
+// Package storagetest holds the conformance suite every storage backend must
+// pass. Backends call Run from their own tests with a constructor for an
+// empty store.
+package storagetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/domain"
+	"github.com/example/agents-playground/internal/storage"
+)
+
+// Run exercises the page, database, item and link repositories of the stores
+// returned by newStore. Every subtest gets a fresh store.
+func Run(t *testing.T, newStore func(t *testing.T) storage.Store) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s storage.Store)
+	}{
+		{"Pages", testPages},
+		{"PageValidation", testPageValidation},
+		{"PageVersions", testPageVersions},
+		{"Slugs", testSlugs},
+		{"Links", testLinks},
+		{"Databases", testDatabases},
+		{"Items", testItems},
+		{"ItemUpdates", testItemUpdates},
+		{"ItemDelete", testItemDelete},
+		{"ItemMove", testItemMove},
+		{"ViewQuery", testViewQuery},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.fn(t, newStore(t))
+		})
+	}
+}
+
+func testPages(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	parent, err := s.CreatePage(ctx, storage.CreatePageInput{Slug: "zeta", Title: "Zeta", Tags: []string{"a"}})
+	require.NoError(t, err)
+	require.Equal(t, 1, parent.Version)
+	child, err := s.CreatePage(ctx, storage.CreatePageInput{Slug: "alpha", Title: "Alpha", Summary: "first", Content: "body", ParentPageID: &parent.ID})
+	require.NoError(t, err)
+	_, err = s.CreatePage(ctx, storage.CreatePageInput{Slug: "tpl", Title: "Template", IsTemplate: true})
+	require.NoError(t, err)
+
+	got, err := s.GetPage(ctx, child.ID)
+	require.NoError(t, err)
+	require.Equal(t, "alpha", got.Slug)
+	require.Equal(t, "body", got.Content)
+	require.Equal(t, parent.ID, *got.ParentPageID)
+	require.Nil(t, got.Tags)
+	require.WithinDuration(t, child.CreatedAt, got.CreatedAt, 0)
+
+	got, err = s.GetPage(ctx, parent.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, got.Tags)
+
+	pages, err := s.ListPages(ctx)
+	require.NoError(t, err)
+	require.Len(t, pages, 2, "templates are not listed")
+	require.Equal(t, "Alpha", pages[0].Title)
+	require.Equal(t, "first", pages[0].Summary)
+	require.Equal(t, parent.ID, *pages[0].ParentPageID)
+	require.Equal(t, "Zeta", pages[1].Title)
+
+	_, err = s.GetPage(ctx, "missing")
+	require.ErrorIs(t, err, storage.ErrPageNotFound)
+}
+
+func testPageValidation(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	_, err := s.CreatePage(ctx, storage.CreatePageInput{})
+	require.ErrorIs(t, err, storage.ErrValidation)
+	require.Equal(t, []storage.FieldError{{Field: "title", Message: "is required"}}, storage.AsError(err).Fields)
+
+	_, err = s.CreatePage(ctx, storage.CreatePageInput{Slug: "notes", Title: "Notes"})
+	require.NoError(t, err)
+	_, err = s.CreatePage(ctx, storage.CreatePageInput{Slug: "notes", Title: "Again"})
+	require.ErrorIs(t, err, storage.ErrSlugConflict)
+
+	_, err = s.UpdatePage(ctx, storage.UpdatePageInput{PageID: "missing", Content: strPtr("x")})
+	require.ErrorIs(t, err, storage.ErrPageNotFound)
+	_, err = s.UpdatePage(ctx, storage.UpdatePageInput{PageID: "missing", Title: strPtr(" ")})
+	require.ErrorIs(t, err, storage.ErrValidation)
+}
+
+func testPageVersions(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	page, err := s.CreatePage(ctx, storage.CreatePageInput{Slug: "notes", Title: "Notes", Tags: []string{"a"}})
+	require.NoError(t, err)
+
+	updated, err := s.UpdatePage(ctx, storage.UpdatePageInput{PageID: page.ID, Content: strPtr("hello"), ExpectedVersion: intPtr(1)})
+	require.NoError(t, err)
+	require.Equal(t, 2, updated.Version)
+	require.Equal(t, "Notes", updated.Title, "nil fields are left untouched")
+	require.Equal(t, []string{"a"}, updated.Tags)
+	require.Equal(t, "hello", updated.Content)
+
+	_, err = s.UpdatePage(ctx, storage.UpdatePageInput{PageID: page.ID, Title: strPtr("Stale"), ExpectedVersion: intPtr(1)})
+	require.ErrorIs(t, err, storage.ErrVersionMismatch)
+	require.ErrorIs(t, err, storage.ErrPreconditionFailed)
+
+	updated, err = s.UpdatePage(ctx, storage.UpdatePageInput{PageID: page.ID, Tags: []string{}})
+	require.NoError(t, err)
+	require.Equal(t, 3, updated.Version)
+	require.Empty(t, updated.Tags)
+	require.Equal(t, "Notes", updated.Title)
+}
+
+func testSlugs(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	first, err := s.CreatePage(ctx, storage.CreatePageInput{Title: "Crème Brûlée & Straße"})
+	require.NoError(t, err)
+	require.Equal(t, "creme-brulee-strasse", first.Slug)
+	second, err := s.CreatePage(ctx, storage.CreatePageInput{Title: "Crème brûlée, Strasse!"})
+	require.NoError(t, err)
+	require.Equal(t, "creme-brulee-strasse-2", second.Slug)
+	untitled, err := s.CreatePage(ctx, storage.CreatePageInput{Title: "日本"})
+	require.NoError(t, err)
+	require.Equal(t, "page", untitled.Slug)
+	got, err := s.GetPage(ctx, second.ID)
+	require.NoError(t, err)
+	require.Equal(t, "creme-brulee-strasse-2", got.Slug)
+
+	db, err := s.CreateDatabase(ctx, storage.CreateDatabaseInput{Title: "Reading List"})
+	require.NoError(t, err)
+	require.Equal(t, "reading-list", db.Slug)
+	item, err := s.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{DatabaseID: db.ID, Page: storage.CreatePageInput{Title: "Page"}})
+	require.NoError(t, err)
+	require.Equal(t, "page-2", item.Page.Slug)
+
+	updated, err := s.UpdatePage(ctx, storage.UpdatePageInput{PageID: first.ID, Slug: strPtr("dessert")})
+	require.NoError(t, err)
+	require.Equal(t, "dessert", updated.Slug)
+	_, err = s.UpdatePage(ctx, storage.UpdatePageInput{PageID: second.ID, Slug: strPtr("dessert")})
+	require.ErrorIs(t, err, storage.ErrSlugConflict)
+	_, err = s.UpdatePage(ctx, storage.UpdatePageInput{PageID: second.ID, Slug: strPtr(" ")})
+	require.ErrorIs(t, err, storage.ErrValidation)
+}
+
+func testLinks(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	a, err := s.CreatePage(ctx, storage.CreatePageInput{Slug: "a", Title: "A"})
+	require.NoError(t, err)
+	b, err := s.CreatePage(ctx, storage.CreatePageInput{Slug: "b", Title: "B"})
+	require.NoError(t, err)
+	c, err := s.CreatePage(ctx, storage.CreatePageInput{Slug: "c", Title: "C", LinkedPageIDs: []string{b.ID, " " + a.ID + " ", b.ID, ""}})
+	require.NoError(t, err)
+	require.Len(t, c.LinkedPageIDs, 2, "links are trimmed and deduplicated")
+
+	_, err = s.UpdatePage(ctx, storage.UpdatePageInput{PageID: c.ID, LinkedPageIDs: []string{b.ID, c.ID}})
+	require.NoError(t, err)
+	_, err = s.UpdatePage(ctx, storage.UpdatePageInput{PageID: a.ID, LinkedPageIDs: []string{b.ID}})
+	require.NoError(t, err)
+
+	links, err := s.ListPageLinks(ctx, b.ID)
+	require.NoError(t, err)
+	require.Empty(t, links.Outbound)
+	require.ElementsMatch(t, []string{a.ID, c.ID}, links.Inbound)
+	require.IsIncreasing(t, links.Inbound)
+
+	page, err := s.GetPage(ctx, c.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{b.ID}, page.LinkedPageIDs, "self links are dropped")
+	require.Empty(t, page.BacklinkedPageIDs)
+
+	_, err = s.UpdatePage(ctx, storage.UpdatePageInput{PageID: c.ID, LinkedPageIDs: []string{}})
+	require.NoError(t, err)
+	links, err = s.ListPageLinks(ctx, b.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{a.ID}, links.Inbound)
+
+	_, err = s.ListPageLinks(ctx, "missing")
+	require.ErrorIs(t, err, storage.ErrPageNotFound)
+}
+
+func testDatabases(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	db, err := s.CreateDatabase(ctx, storage.CreateDatabaseInput{
+		Slug:  "tasks",
+		Title: "Tasks",
+		Properties: []storage.DatabasePropertyInput{
+			{Name: "Estimate", Slug: "estimate", Type: domain.PropertyTypeNumber, Default: 1, OrderIndex: 2},
+			{Name: "Status", Slug: "status", Type: domain.PropertyTypeSelect, Config: map[string]any{"options": []string{"todo", "done"}}, OrderIndex: 1},
+		},
+		Views: []storage.DatabaseViewInput{{Name: "Table", Type: domain.ViewTypeTable, Display: []string{"status"}}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, db.Version)
+	require.Len(t, db.Properties, 2)
+
+	got, err := s.GetDatabase(ctx, db.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Tasks", got.Title)
+	require.Equal(t, 1, got.Version)
+	require.Equal(t, "status", got.Properties[0].Slug, "properties are ordered by order index")
+	require.Equal(t, map[string]any{"options": []any{"todo", "done"}}, got.Properties[0].Config)
+	require.Equal(t, 1.0, got.Properties[1].Default)
+	require.Equal(t, db.ID, got.Properties[1].DatabaseID)
+	require.Len(t, got.Views, 1)
+	require.Equal(t, []string{"status"}, got.Views[0].Display)
+	require.Equal(t, 1, got.Views[0].Version)
+
+	_, err = s.CreateDatabase(ctx, storage.CreateDatabaseInput{Slug: "tasks", Title: "Again"})
+	require.ErrorIs(t, err, storage.ErrSlugConflict)
+	_, err = s.CreateDatabase(ctx, storage.CreateDatabaseInput{Slug: "other", Title: "Other", Properties: []storage.DatabasePropertyInput{
+		{Name: "A", Slug: "dup", Type: domain.PropertyTypeText},
+		{Name: "B", Slug: "dup", Type: domain.PropertyTypeText},
+	}})
+	require.ErrorIs(t, err, storage.ErrSlugConflict)
+	_, err = s.GetDatabase(ctx, "other")
+	require.ErrorIs(t, err, storage.ErrDatabaseNotFound)
+	_, err = s.CreateDatabase(ctx, storage.CreateDatabaseInput{Slug: "untitled"})
+	require.ErrorIs(t, err, storage.ErrValidation)
+}
+
+func testItems(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	db := newTasksDatabase(t, s)
+	item, err := s.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
+		DatabaseID: db.ID,
+		Page:       storage.CreatePageInput{Slug: "write-docs", Title: "Write docs", Tags: []string{"docs"}},
+		Values:     map[string]any{"status": "todo", "estimate": 3},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, item.Version)
+	require.Equal(t, 1, item.Page.Version)
+	require.NotEmpty(t, item.Rank)
+
+	got, err := s.GetDatabaseItem(ctx, db.ID, item.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Write docs", got.Page.Title)
+	require.Equal(t, []string{"docs"}, got.Page.Tags)
+	require.Equal(t, "todo", got.PropertyMap["status"].RawValue)
+	require.Equal(t, 3.0, got.PropertyMap["estimate"].RawValue)
+	require.Equal(t, db.Properties[0].ID, got.PropertyMap["status"].PropertyID)
+	require.Equal(t, item.ID, got.PropertyMap["status"].ItemID)
+
+	page, err := s.GetPage(ctx, item.Page.ID)
+	require.NoError(t, err)
+	require.Equal(t, "write-docs", page.Slug, "items are backed by pages")
+
+	_, err = s.GetDatabaseItem(ctx, "other", item.ID)
+	require.ErrorIs(t, err, storage.ErrItemNotFound)
+	_, err = s.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{DatabaseID: db.ID, Page: storage.CreatePageInput{Slug: "bad", Title: "Bad"}, Values: map[string]any{"nope": 1}})
+	require.ErrorIs(t, err, storage.ErrValidation)
+	require.Equal(t, "values.nope", storage.AsError(err).Fields[0].Field)
+	_, err = s.GetPage(ctx, "bad")
+	require.ErrorIs(t, err, storage.ErrPageNotFound)
+	_, err = s.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{DatabaseID: db.ID, Page: storage.CreatePageInput{Slug: "write-docs", Title: "Again"}})
+	require.ErrorIs(t, err, storage.ErrSlugConflict)
+	_, err = s.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{DatabaseID: "missing", Page: storage.CreatePageInput{Slug: "orphan", Title: "Orphan"}})
+	require.ErrorIs(t, err, storage.ErrDatabaseNotFound)
+	_, err = s.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{DatabaseID: db.ID, TemplateID: "missing", Page: storage.CreatePageInput{Slug: "tpl", Title: "Tpl"}})
+	require.ErrorIs(t, err, storage.ErrTemplateNotFound)
+}
+
+func testItemUpdates(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	db := newTasksDatabase(t, s)
+	item, err := s.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
+		DatabaseID: db.ID,
+		Page:       storage.CreatePageInput{Slug: "ship", Title: "Ship"},
+		Values:     map[string]any{"status": "todo", "estimate": 5},
+	})
+	require.NoError(t, err)
+	created := item.PropertyMap["status"]
+
+	updated, err := s.UpdateDatabaseItem(ctx, storage.UpdateDatabaseItemInput{
+		DatabaseID:      db.ID,
+		ItemID:          item.ID,
+		Title:           strPtr("Ship it"),
+		Values:          map[string]any{"status": "done", "estimate": nil},
+		ExpectedVersion: intPtr(1),
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, updated.Version)
+	require.Equal(t, 2, updated.Page.Version)
+	require.Equal(t, "Ship it", updated.Page.Title)
+	require.Equal(t, "done", updated.PropertyMap["status"].RawValue)
+	require.Equal(t, created.ID, updated.PropertyMap["status"].ID, "values are updated in place")
+	require.NotContains(t, updated.PropertyMap, "estimate", "nil values are cleared")
+
+	_, err = s.UpdateDatabaseItem(ctx, storage.UpdateDatabaseItemInput{DatabaseID: db.ID, ItemID: item.ID, Title: strPtr("Stale"), ExpectedVersion: intPtr(1)})
+	require.ErrorIs(t, err, storage.ErrVersionMismatch)
+	_, err = s.UpdateDatabaseItem(ctx, storage.UpdateDatabaseItemInput{DatabaseID: db.ID, ItemID: item.ID, Title: strPtr("Renamed"), Values: map[string]any{"nope": 1}})
+	require.ErrorIs(t, err, storage.ErrValidation)
+	_, err = s.UpdateDatabaseItem(ctx, storage.UpdateDatabaseItemInput{DatabaseID: db.ID, ItemID: item.ID, Title: strPtr("")})
+	require.ErrorIs(t, err, storage.ErrValidation)
+	_, err = s.UpdateDatabaseItem(ctx, storage.UpdateDatabaseItemInput{DatabaseID: db.ID, ItemID: "missing", Title: strPtr("x")})
+	require.ErrorIs(t, err, storage.ErrItemNotFound)
+
+	got, err := s.GetDatabaseItem(ctx, db.ID, item.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Ship it", got.Page.Title, "rejected updates change nothing")
+	require.Equal(t, 2, got.Version)
+
+	archived, err := s.ArchiveDatabaseItem(ctx, db.ID, item.ID, true)
+	require.NoError(t, err)
+	require.True(t, archived.IsArchived)
+	require.True(t, archived.Page.IsArchived)
+	require.Equal(t, 3, archived.Version)
+
+	// Editing the backing page changes the item as well.
+	_, err = s.UpdatePage(ctx, storage.UpdatePageInput{PageID: item.Page.ID, Content: strPtr("notes")})
+	require.NoError(t, err)
+	got, err = s.GetDatabaseItem(ctx, db.ID, item.ID)
+	require.NoError(t, err)
+	require.Equal(t, 4, got.Version)
+	require.Equal(t, "notes", got.Page.Content)
+}
+
+func testItemDelete(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	db := newTasksDatabase(t, s)
+	item, err := s.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{DatabaseID: db.ID, Page: storage.CreatePageInput{Slug: "gone", Title: "Gone"}})
+	require.NoError(t, err)
+	child, err := s.CreatePage(ctx, storage.CreatePageInput{Slug: "child", Title: "Child", ParentPageID: &item.Page.ID})
+	require.NoError(t, err)
+	linker, err := s.CreatePage(ctx, storage.CreatePageInput{Slug: "linker", Title: "Linker", LinkedPageIDs: []string{item.Page.ID, child.ID}})
+	require.NoError(t, err)
+
+	require.ErrorIs(t, s.DeleteDatabaseItem(ctx, "other", item.ID), storage.ErrItemNotFound)
+	require.NoError(t, s.DeleteDatabaseItem(ctx, db.ID, item.ID))
+	_, err = s.GetDatabaseItem(ctx, db.ID, item.ID)
+	require.ErrorIs(t, err, storage.ErrItemNotFound)
+	_, err = s.GetPage(ctx, item.Page.ID)
+	require.ErrorIs(t, err, storage.ErrPageNotFound)
+
+	got, err := s.GetPage(ctx, child.ID)
+	require.NoError(t, err)
+	require.Nil(t, got.ParentPageID, "child pages are detached")
+	require.Equal(t, 2, got.Version)
+	links, err := s.ListPageLinks(ctx, linker.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{child.ID}, links.Outbound)
+	require.ErrorIs(t, s.DeleteDatabaseItem(ctx, db.ID, item.ID), storage.ErrItemNotFound)
+}
+
+func testItemMove(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	db := newTasksDatabase(t, s)
+	var ids []string
+	for _, slug := range []string{"one", "two", "three"} {
+		item, err := s.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{DatabaseID: db.ID, Page: storage.CreatePageInput{Slug: slug, Title: slug}})
+		require.NoError(t, err)
+		ids = append(ids, item.ID)
+	}
+	rankOf := func(id string) string {
+		item, err := s.GetDatabaseItem(ctx, db.ID, id)
+		require.NoError(t, err)
+		return item.Rank
+	}
+	require.Less(t, rankOf(ids[0]), rankOf(ids[1]), "items are appended in order")
+	require.Less(t, rankOf(ids[1]), rankOf(ids[2]))
+
+	moved, err := s.MoveDatabaseItem(ctx, storage.MoveDatabaseItemInput{DatabaseID: db.ID, ItemID: ids[2], BeforeID: ids[0]})
+	require.NoError(t, err)
+	require.Equal(t, 2, moved.Version)
+	require.Less(t, moved.Rank, rankOf(ids[0]))
+
+	moved, err = s.MoveDatabaseItem(ctx, storage.MoveDatabaseItemInput{DatabaseID: db.ID, ItemID: ids[2], AfterID: ids[0]})
+	require.NoError(t, err)
+	require.Greater(t, moved.Rank, rankOf(ids[0]))
+	require.Less(t, moved.Rank, rankOf(ids[1]))
+
+	_, err = s.MoveDatabaseItem(ctx, storage.MoveDatabaseItemInput{DatabaseID: db.ID, ItemID: ids[0]})
+	require.ErrorIs(t, err, storage.ErrValidation)
+	_, err = s.MoveDatabaseItem(ctx, storage.MoveDatabaseItemInput{DatabaseID: db.ID, ItemID: ids[0], AfterID: ids[0]})
+	require.ErrorIs(t, err, storage.ErrValidation)
+	_, err = s.MoveDatabaseItem(ctx, storage.MoveDatabaseItemInput{DatabaseID: db.ID, ItemID: ids[0], AfterID: "missing"})
+	require.ErrorIs(t, err, storage.ErrItemNotFound)
+
+	// Repeated moves into the same gap eventually force a rebalance; the
+	// order must survive it.
+	for i := 0; i < 300; i++ {
+		_, err = s.MoveDatabaseItem(ctx, storage.MoveDatabaseItemInput{DatabaseID: db.ID, ItemID: ids[i%2+1], AfterID: ids[0]})
+		require.NoError(t, err)
+	}
+	require.Less(t, rankOf(ids[0]), rankOf(ids[2]))
+	require.Less(t, rankOf(ids[2]), rankOf(ids[1]))
+}
+
+func testViewQuery(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	db, err := s.CreateDatabase(ctx, storage.CreateDatabaseInput{
+		Slug:       "board",
+		Title:      "Board",
+		Properties: []storage.DatabasePropertyInput{{Name: "Status", Slug: "status", Type: domain.PropertyTypeSelect}},
+		Views:      []storage.DatabaseViewInput{{Name: "All", Type: domain.ViewTypeTable}},
+	})
+	require.NoError(t, err)
+	var ids []string
+	for _, title := range []string{"C", "A", "B"} {
+		item, err := s.CreateDatabaseItem(ctx, storage.CreateDatabaseItemInput{
+			DatabaseID: db.ID,
+			Page:       storage.CreatePageInput{Title: title},
+			Values:     map[string]any{"status": "todo"},
+		})
+		require.NoError(t, err)
+		ids = append(ids, item.ID)
+	}
+	_, err = s.ArchiveDatabaseItem(ctx, db.ID, ids[1], true)
+	require.NoError(t, err)
+
+	result, err := s.QueryView(ctx, db.ID, db.Views[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, db.Views[0].ID, result.View.ID)
+	require.Len(t, result.Items, 2, "archived items are left out")
+	require.Equal(t, "C", result.Items[0].Page.Title, "items without sorts come in rank order")
+	require.Equal(t, "B", result.Items[1].Page.Title)
+	require.Equal(t, "todo", result.Items[0].PropertyMap["status"].RawValue)
+	require.Nil(t, result.Summary)
+
+	_, err = s.QueryView(ctx, db.ID, "missing")
+	require.ErrorIs(t, err, storage.ErrViewNotFound)
+	_, err = s.QueryView(ctx, "missing", db.Views[0].ID)
+	require.ErrorIs(t, err, storage.ErrDatabaseNotFound)
+}
+
+func newTasksDatabase(t *testing.T, s storage.Store) *domain.Database {
+	t.Helper()
+	db, err := s.CreateDatabase(context.Background(), storage.CreateDatabaseInput{
+		Slug:  "tasks",
+		Title: "Tasks",
+		Properties: []storage.DatabasePropertyInput{
+			{Name: "Status", Slug: "status", Type: domain.PropertyTypeSelect},
+			{Name: "Estimate", Slug: "estimate", Type: domain.PropertyTypeNumber, OrderIndex: 1},
+		},
+	})
+	require.NoError(t, err)
+	return db
+}
+
+func strPtr(s string) *string { return &s }
+
+func intPtr(i int) *int { return &i }
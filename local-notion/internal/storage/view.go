@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/example/agents-playground/internal/domain"
+)
+
+// QueryViewItems answers QueryView from the items of db, for backends that
+// filter and sort in memory rather than in their queries. items are the
+// non-archived items of db in any order. It returns ErrViewNotFound when db has
+// no view viewID.
+func QueryViewItems(db *domain.Database, viewID string, items []domain.DatabaseItem) (*domain.ViewResult, error) {
+	i := slices.IndexFunc(db.Views, func(view domain.DatabaseView) bool { return view.ID == viewID })
+	if i < 0 {
+		return nil, ErrViewNotFound
+	}
+	view := db.Views[i]
+	types := make(map[string]domain.PropertyType, len(db.Properties))
+	options := make(map[string][]string)
+	for _, prop := range db.Properties {
+		types[prop.ID] = prop.Type
+		if names := PropertyOptions(prop.Config); len(names) > 0 {
+			options[prop.ID] = names
+		}
+	}
+	SortViewItems(items, view.Sorts, types)
+	return BuildViewResult(view, items, options), nil
+}
+
+// PropertyOptions returns the option names configured for a select or
+// multi-select property, given as plain names or {"name": ...} objects.
+func PropertyOptions(config map[string]any) []string {
+	raw, _ := config["options"].([]any)
+	out := make([]string, 0, len(raw))
+	for _, option := range raw {
+		switch v := option.(type) {
+		case string:
+			out = append(out, v)
+		case map[string]any:
+			if name, ok := v["name"].(string); ok {
+				out = append(out, name)
+			}
+		}
+	}
+	return out
+}
+
+// ItemValuesByID returns the raw values of an item keyed by property id, the
+// form filters and aggregates read.
+func ItemValuesByID(item domain.DatabaseItem) map[string]any {
+	values := make(map[string]any, len(item.PropertyMap))
+	for _, value := range item.PropertyMap {
+		values[value.PropertyID] = value.RawValue
+	}
+	return values
+}
+
+// BuildViewResult keeps the items that match the filters of view, in their
+// current order, and adds the aggregates configured in its layout options.
+// options holds the select options of each property by id and orders groups.
+func BuildViewResult(view domain.DatabaseView, items []domain.DatabaseItem, options map[string][]string) *domain.ViewResult {
+	filtered := make([]domain.DatabaseItem, 0, len(items))
+	var values []map[string]any
+	for _, item := range items {
+		byID := ItemValuesByID(item)
+		if !MatchesFilter(view.Filters, byID) {
+			continue
+		}
+		filtered = append(filtered, item)
+		values = append(values, byID)
+	}
+	return &domain.ViewResult{
+		View:    view,
+		Items:   filtered,
+		Summary: SummarizeView(view, filtered, values, options),
+	}
+}
+
+// SortViewItems orders items by the sorts of view and then by rank, the
+// order every backend returns view items in. Numbers sort numerically, dates
+// chronologically, checkboxes false first and anything else as text; items
+// without a scalar value for a sort property come last in either direction.
+// types maps property ids to their types.
+func SortViewItems(items []domain.DatabaseItem, sorts []domain.ViewSort, types map[string]domain.PropertyType) {
+	keys := make(map[string][]sortKey, len(items))
+	for _, item := range items {
+		values := ItemValuesByID(item)
+		itemKeys := make([]sortKey, len(sorts))
+		for i, sort := range sorts {
+			itemKeys[i] = sortKeyOf(values[sort.PropertyID], types[sort.PropertyID])
+		}
+		keys[item.ID] = itemKeys
+	}
+	slices.SortStableFunc(items, func(a, b domain.DatabaseItem) int {
+		for i, sort := range sorts {
+			ka, kb := keys[a.ID][i], keys[b.ID][i]
+			switch {
+			case !ka.ok && !kb.ok:
+				continue
+			case !ka.ok:
+				return 1
+			case !kb.ok:
+				return -1
+			}
+			c := ka.compare(kb)
+			if sort.Direction == "desc" {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return cmp.Or(cmp.Compare(a.Rank, b.Rank), a.CreatedAt.Compare(b.CreatedAt))
+	})
+}
+
+// sortKey is the typed form of a value a view sorts by.
+type sortKey struct {
+	ok     bool
+	number float64
+	date   time.Time
+	text   string
+}
+
+func sortKeyOf(v any, typ domain.PropertyType) sortKey {
+	if v == nil {
+		return sortKey{}
+	}
+	if _, ok := v.([]any); ok {
+		return sortKey{}
+	}
+	switch typ {
+	case domain.PropertyTypeNumber:
+		f, ok := NumericValue(v)
+		return sortKey{ok: ok, number: f}
+	case domain.PropertyTypeDate:
+		t, ok := DateValue(v)
+		return sortKey{ok: ok, date: t}
+	case domain.PropertyTypeCheckbox:
+		switch val := v.(type) {
+		case bool:
+			return sortKey{ok: true, number: boolNumber(val)}
+		case string:
+			if val == "true" || val == "false" {
+				return sortKey{ok: true, number: boolNumber(val == "true")}
+			}
+		}
+		return sortKey{}
+	}
+	return sortKey{ok: true, text: fmt.Sprint(v)}
+}
+
+func (k sortKey) compare(other sortKey) int {
+	return cmp.Or(cmp.Compare(k.number, other.number), k.date.Compare(other.date), cmp.Compare(k.text, other.text))
+}
+
+func boolNumber(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
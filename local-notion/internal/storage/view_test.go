@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/example/agents-playground/internal/domain"
+)
+
+func viewTestItem(id, rank string, values map[string]any) domain.DatabaseItem {
+	item := domain.DatabaseItem{ID: id, Rank: rank, Page: domain.Page{Title: id}, PropertyMap: map[string]domain.DatabaseValue{}}
+	for propertyID, value := range values {
+		item.PropertyMap[propertyID] = domain.DatabaseValue{PropertyID: propertyID, RawValue: value}
+	}
+	return item
+}
+
+func viewItemIDs(items []domain.DatabaseItem) []string {
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	return ids
+}
+
+func TestQueryViewItems(t *testing.T) {
+	db := &domain.Database{
+		Properties: []domain.DatabaseProperty{
+			{ID: "status", Type: domain.PropertyTypeSelect, Config: map[string]any{"options": []any{"Todo", map[string]any{"name": "Done"}}}},
+			{ID: "points", Type: domain.PropertyTypeNumber},
+			{ID: "due", Type: domain.PropertyTypeDate},
+		},
+		Views: []domain.DatabaseView{{
+			ID:       "board",
+			Filters:  map[string]any{"property_id": "status", "operator": "is_not_empty"},
+			Sorts:    []domain.ViewSort{{PropertyID: "points", Direction: "desc"}},
+			Grouping: map[string]any{"property_id": "status"},
+		}},
+	}
+	items := []domain.DatabaseItem{
+		viewTestItem("a", "1", map[string]any{"status": "Done", "points": 2.0}),
+		viewTestItem("b", "2", map[string]any{"status": "Todo", "points": 10.0}),
+		viewTestItem("c", "3", map[string]any{"status": "Todo"}),
+		viewTestItem("d", "4", map[string]any{"points": 50.0}),
+		viewTestItem("e", "0", map[string]any{"status": "Done", "points": 2.0}),
+	}
+
+	result, err := QueryViewItems(db, "board", items)
+	require.NoError(t, err)
+	// Numbers sort numerically, ties fall back to rank and items without a
+	// value come last even in descending order.
+	require.Equal(t, []string{"b", "e", "a", "c"}, viewItemIDs(result.Items))
+	require.Equal(t, 4, result.Summary.Count)
+	require.Len(t, result.Summary.Groups, 2)
+	require.Equal(t, "Todo", result.Summary.Groups[0].Key)
+	require.Equal(t, 2, result.Summary.Groups[0].Count)
+
+	_, err = QueryViewItems(db, "missing", items)
+	require.ErrorIs(t, err, ErrViewNotFound)
+}
+
+func TestSortViewItemsByDateAndText(t *testing.T) {
+	types := map[string]domain.PropertyType{"due": domain.PropertyTypeDate, "name": domain.PropertyTypeText}
+	items := []domain.DatabaseItem{
+		viewTestItem("late", "1", map[string]any{"due": "2026-03-01", "name": "b"}),
+		viewTestItem("early", "2", map[string]any{"due": "2026-01-15T09:00:00Z", "name": "b"}),
+		viewTestItem("none", "3", map[string]any{"name": "a"}),
+	}
+	SortViewItems(items, []domain.ViewSort{{PropertyID: "due", Direction: "asc"}}, types)
+	require.Equal(t, []string{"early", "late", "none"}, viewItemIDs(items))
+	SortViewItems(items, []domain.ViewSort{{PropertyID: "name", Direction: "asc"}}, types)
+	require.Equal(t, []string{"none", "late", "early"}, viewItemIDs(items))
+}